@@ -0,0 +1,187 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// autodiscoverSettle is how long a file dropped in an auto discover path must
+// be left unchanged before it is loaded, so that plugins are not loaded while
+// being copied.
+var autodiscoverSettle = time.Second
+
+// autodiscoverWatcher loads the plugins dropped in the auto discover paths
+// while snapteld runs, and optionally unloads those removed from them.
+type autodiscoverWatcher struct {
+	sync.Mutex
+	control *pluginControl
+	watcher *fsnotify.Watcher
+	unload  bool
+	// dirs maps the absolute path of the watched directories to the auto
+	// discover paths as configured
+	dirs map[string]string
+	// plugins maps the files of the plugins to the plugins they loaded
+	plugins map[string]core.Plugin
+	pending map[string]*time.Timer
+}
+
+func newAutodiscoverWatcher(p *pluginControl, unload bool) (*autodiscoverWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &autodiscoverWatcher{
+		control: p,
+		watcher: watcher,
+		unload:  unload,
+		dirs:    map[string]string{},
+		plugins: map[string]core.Plugin{},
+		pending: map[string]*time.Timer{},
+	}, nil
+}
+
+// watch adds an auto discover path to the watched directories
+func (w *autodiscoverWatcher) watch(pa, fullPath string) error {
+	w.Lock()
+	w.dirs[fullPath] = pa
+	w.Unlock()
+	return w.watcher.Add(fullPath)
+}
+
+// loaded records the plugin loaded from a file
+func (w *autodiscoverWatcher) loaded(file string, pl core.Plugin) {
+	w.Lock()
+	defer w.Unlock()
+	w.plugins[file] = pl
+}
+
+func (w *autodiscoverWatcher) start() {
+	go func() {
+		for {
+			select {
+			case e, ok := <-w.watcher.Events:
+				if !ok {
+					return
+				}
+				w.handle(e)
+			case err, ok := <-w.watcher.Errors:
+				if !ok {
+					return
+				}
+				controlLogger.WithFields(log.Fields{
+					"_block": "autodiscover-watch",
+					"error":  err,
+				}).Error("error watching auto discover paths")
+			}
+		}
+	}()
+}
+
+func (w *autodiscoverWatcher) stop() {
+	w.watcher.Close()
+	w.Lock()
+	defer w.Unlock()
+	for file, t := range w.pending {
+		t.Stop()
+		delete(w.pending, file)
+	}
+}
+
+func (w *autodiscoverWatcher) handle(e fsnotify.Event) {
+	// a signature or metadata file dropped after its plugin is read when
+	// the plugin is loaded, so it delays the loading of the plugin
+	file := strings.TrimSuffix(strings.TrimSuffix(e.Name, ".asc"), core.PluginMetadataExt)
+	switch {
+	case e.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		if file == e.Name {
+			w.removed(file)
+		}
+	case e.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Chmod) != 0:
+		w.Lock()
+		defer w.Unlock()
+		if t, ok := w.pending[file]; ok {
+			t.Reset(autodiscoverSettle)
+			return
+		}
+		w.pending[file] = time.AfterFunc(autodiscoverSettle, func() { w.load(file) })
+	}
+}
+
+func (w *autodiscoverWatcher) load(file string) {
+	w.Lock()
+	delete(w.pending, file)
+	_, ok := w.plugins[file]
+	dir := filepath.Dir(file)
+	pa := w.dirs[dir]
+	w.Unlock()
+	if ok {
+		controlLogger.WithFields(log.Fields{
+			"_block": "autodiscover-watch",
+			"plugin": file,
+		}).Debug("plugin changed but already loaded")
+		return
+	}
+	fi, err := os.Lstat(file)
+	if err != nil {
+		// removed before being loaded
+		return
+	}
+	if pl := w.control.autoloadPlugin(pa, dir, fi); pl != nil {
+		w.loaded(file, pl)
+	}
+}
+
+func (w *autodiscoverWatcher) removed(file string) {
+	if !w.unload {
+		return
+	}
+	w.Lock()
+	pl, ok := w.plugins[file]
+	delete(w.plugins, file)
+	w.Unlock()
+	if !ok {
+		return
+	}
+	if _, err := w.control.Unload(pl); err != nil {
+		controlLogger.WithFields(log.Fields{
+			"_block": "autodiscover-watch",
+			"plugin": file,
+			"error":  err,
+		}).Error("error unloading removed plugin")
+		return
+	}
+	controlLogger.WithFields(log.Fields{
+		"_block":         "autodiscover-watch",
+		"plugin":         file,
+		"plugin-name":    pl.Name(),
+		"plugin-version": pl.Version(),
+		"plugin-type":    pl.TypeName(),
+	}).Info("Unloaded removed plugin")
+}
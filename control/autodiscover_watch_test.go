@@ -0,0 +1,64 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+func TestAutodiscoverWatcher(t *testing.T) {
+	Convey("Auto discover watcher", t, func() {
+		settle := autodiscoverSettle
+		autodiscoverSettle = time.Hour
+		defer func() { autodiscoverSettle = settle }()
+
+		w, err := newAutodiscoverWatcher(nil, false)
+		So(err, ShouldBeNil)
+		defer w.stop()
+		file := filepath.Join("/opt/snap/plugins", "snap-plugin-collector-mock1")
+
+		Convey("delays the loading of a dropped plugin", func() {
+			w.handle(fsnotify.Event{Name: file, Op: fsnotify.Create})
+			w.handle(fsnotify.Event{Name: file, Op: fsnotify.Write})
+			So(w.pending, ShouldContainKey, file)
+			So(len(w.pending), ShouldEqual, 1)
+		})
+		Convey("loads a plugin again once its signature is dropped", func() {
+			w.handle(fsnotify.Event{Name: file + ".asc", Op: fsnotify.Create})
+			So(w.pending, ShouldContainKey, file)
+			w.handle(fsnotify.Event{Name: file + core.PluginMetadataExt, Op: fsnotify.Create})
+			So(len(w.pending), ShouldEqual, 1)
+		})
+		Convey("keeps removed plugins loaded unless told to unload them", func() {
+			w.loaded(file, subscribedPlugin{typeName: "collector", name: "mock1", version: 1})
+			w.handle(fsnotify.Event{Name: file, Op: fsnotify.Remove})
+			So(w.plugins, ShouldContainKey, file)
+		})
+	})
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/intelsdi-x/snap/core/control_event"
 	"github.com/intelsdi-x/snap/core/ctypes"
 	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/pkg/opmetrics"
 )
 
 const (
@@ -51,6 +52,12 @@ const (
 )
 
 var (
+	// HealthCheckTimeout is the timeout of the health checks of the plugins
+	HealthCheckTimeout = DefaultHealthCheckTimeout
+	// HealthCheckFailureLimit is the count of consecutive failed health checks
+	// after which a plugin is killed and replaced
+	HealthCheckFailureLimit = DefaultHealthCheckFailureLimit
+
 	ErrPoolNotFound = errors.New("plugin pool not found")
 	ErrBadKey       = errors.New("bad key")
 )
@@ -69,19 +76,28 @@ type availablePlugin struct {
 	lastHitTime        time.Time
 	emitter            gomit.Emitter
 	failedHealthChecks int
+	lastHealthCheck    time.Time
 	healthChan         chan error
 	ePlugin            executablePlugin
 	execPath           string
 	fromPackage        bool
 	pprofPort          string
+	cgroups            *pluginCgroups
+	usage              *pluginUsage
+	calls              *pluginCalls
+	// timeout is the default timeout of the calls made to the plugin
+	timeout time.Duration
 }
 
 // newAvailablePlugin returns an availablePlugin with information from a
 // plugin.Response
-func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executablePlugin) (*availablePlugin, error) {
+func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executablePlugin, security client.GRPCSecurity, timeout time.Duration) (*availablePlugin, error) {
 	if resp.Type != plugin.CollectorPluginType && resp.Type != plugin.ProcessorPluginType && resp.Type != plugin.PublisherPluginType {
 		return nil, strategy.ErrBadType
 	}
+	if err := checkPluginTLS(security, resp.Meta); err != nil {
+		return nil, err
+	}
 	ap := &availablePlugin{
 		meta:        resp.Meta,
 		name:        resp.Meta.Name,
@@ -92,6 +108,9 @@ func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executab
 		lastHitTime: time.Now(),
 		ePlugin:     ep,
 		pprofPort:   resp.PprofAddress,
+		usage:       &pluginUsage{},
+		calls:       &pluginCalls{},
+		timeout:     timeout,
 	}
 	ap.key = fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", ap.pluginType.String(), ap.name, ap.version)
 
@@ -105,13 +124,13 @@ func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executab
 				"_block":      "newAvailablePlugin",
 				"plugin_name": ap.name,
 			}).Warning("This plugin is using a deprecated RPC protocol. Find more information here: https://github.com/intelsdi-x/snap/issues/1289 ")
-			c, e := client.NewCollectorNativeClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewCollectorNativeClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
 			ap.client = c
 		case plugin.GRPC:
-			c, e := client.NewCollectorGrpcClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewCollectorGrpcClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure, security)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
@@ -119,9 +138,10 @@ func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executab
 		case plugin.STREAMGRPC:
 			c, e := client.NewStreamCollectorGrpcClient(
 				resp.ListenAddress,
-				DefaultClientTimeout,
+				timeout,
 				resp.PublicKey,
-				!resp.Meta.Unsecure)
+				!resp.Meta.Unsecure,
+				security)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
@@ -132,13 +152,13 @@ func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executab
 	case plugin.PublisherPluginType:
 		switch resp.Meta.RPCType {
 		case plugin.NativeRPC:
-			c, e := client.NewPublisherNativeClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewPublisherNativeClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
 			ap.client = c
 		case plugin.GRPC:
-			c, e := client.NewPublisherGrpcClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewPublisherGrpcClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure, security)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
@@ -149,13 +169,13 @@ func newAvailablePlugin(resp plugin.Response, emitter gomit.Emitter, ep executab
 	case plugin.ProcessorPluginType:
 		switch resp.Meta.RPCType {
 		case plugin.NativeRPC:
-			c, e := client.NewProcessorNativeClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewProcessorNativeClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
 			ap.client = c
 		case plugin.GRPC:
-			c, e := client.NewProcessorGrpcClient(resp.ListenAddress, DefaultClientTimeout, resp.PublicKey, !resp.Meta.Unsecure)
+			c, e := client.NewProcessorGrpcClient(resp.ListenAddress, timeout, resp.PublicKey, !resp.Meta.Unsecure, security)
 			if e != nil {
 				return nil, errors.New("error while creating client connection: " + e.Error())
 			}
@@ -226,6 +246,16 @@ func (a *availablePlugin) LastHit() time.Time {
 	return a.lastHitTime
 }
 
+// FailedHealthChecks returns the count of consecutive failed health checks
+func (a *availablePlugin) FailedHealthChecks() int {
+	return a.failedHealthChecks
+}
+
+// LastHealthCheck returns the time of the last health check
+func (a *availablePlugin) LastHealthCheck() time.Time {
+	return a.lastHealthCheck
+}
+
 // Stop halts a running availablePlugin
 func (a *availablePlugin) Stop(r string) error {
 	log.WithFields(log.Fields{
@@ -233,6 +263,9 @@ func (a *availablePlugin) Stop(r string) error {
 		"block":       "stop",
 		"plugin_name": a,
 	}).Info("stopping available plugin")
+	if isRemote(a.ePlugin) {
+		return nil
+	}
 	return a.client.Kill(r)
 }
 
@@ -258,12 +291,16 @@ func (a *availablePlugin) Kill(r string) error {
 		c.Killed()
 	}
 
-	return a.ePlugin.Kill()
+	err := a.ePlugin.Kill()
+	// the cgroups can only be removed once the process exited
+	a.cgroups.remove()
+	return err
 }
 
 // CheckHealth checks the health of a plugin and updates
 // a.failedHealthChecks
 func (a *availablePlugin) CheckHealth() {
+	a.lastHealthCheck = time.Now()
 	go func() {
 		a.healthChan <- a.client.Ping()
 	}()
@@ -282,7 +319,7 @@ func (a *availablePlugin) CheckHealth() {
 		} else {
 			a.healthCheckFailed()
 		}
-	case <-time.After(DefaultHealthCheckTimeout):
+	case <-time.After(HealthCheckTimeout):
 		a.healthCheckFailed()
 	}
 }
@@ -296,7 +333,7 @@ func (a *availablePlugin) healthCheckFailed() {
 		"plugin_name": a,
 	}).Warning("heartbeat missed")
 	a.failedHealthChecks++
-	if a.failedHealthChecks >= DefaultHealthCheckFailureLimit {
+	if a.failedHealthChecks >= HealthCheckFailureLimit {
 		log.WithFields(log.Fields{
 			"_module":     "control-aplugin",
 			"block":       "check-health",
@@ -309,13 +346,15 @@ func (a *availablePlugin) healthCheckFailed() {
 			Key:     a.key,
 			Id:      a.ID(),
 			String:  a.String(),
+			Output:  string(a.Output(deadPluginOutputSize)),
 		}
 		defer a.emitter.Emit(pde)
 	}
 	hcfe := &control_event.HealthCheckFailedEvent{
-		Name:    a.name,
-		Version: a.version,
-		Type:    int(a.pluginType),
+		Name:     a.name,
+		Version:  a.version,
+		Type:     int(a.pluginType),
+		Failures: a.failedHealthChecks,
 	}
 	defer a.emitter.Emit(hcfe)
 }
@@ -327,6 +366,8 @@ type availablePlugins struct {
 	// The Pools' primary keys are equal to
 	// {plugin_type}:{plugin_name}:{plugin_version}
 	table map[string]strategy.Pool
+	// sizes holds the pool sizes configured by plugin name
+	sizes map[string]*PluginPool
 }
 
 func newAvailablePlugins() *availablePlugins {
@@ -353,6 +394,9 @@ func (ap *availablePlugins) insert(pl *availablePlugin) error {
 				"key": key,
 			})
 		}
+		if size, ok := ap.sizes[pl.name]; ok {
+			applyPluginPool(p, size)
+		}
 		ap.table[key] = p
 		return nil
 	}
@@ -389,7 +433,7 @@ func (ap *availablePlugins) getPool(key string) (strategy.Pool, serror.SnapError
 	return pool, nil
 }
 
-func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.Metric, taskID string) ([]core.Metric, error) {
+func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.Metric, taskID string, timeout time.Duration) ([]core.Metric, error) {
 	var results []core.Metric
 	pool, serr := ap.getPool(pluginKey)
 	if serr != nil {
@@ -405,13 +449,15 @@ func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.
 		return nil, errors.New("Plugin strategy not set")
 	}
 
-	metricsToCollect, metricsFromCache := pool.CheckCache(metricTypes, taskID)
+	// the metrics collected for a task share the same config
+	config := metricTypes[0].Config()
+	digest := strategy.ConfigDigest(config)
+	metricsToCollect, metricsFromCache := pool.CheckCache(metricTypes, digest, taskID)
 
 	if len(metricsToCollect) == 0 {
 		return metricsFromCache, nil
 	}
 
-	config := metricTypes[0].Config()
 	cfg := map[string]ctypes.ConfigValue{}
 	if config != nil {
 		cfg = config.Table()
@@ -425,18 +471,20 @@ func (ap *availablePlugins) collectMetrics(pluginKey string, metricTypes []core.
 	}
 
 	// cast client to PluginCollectorClient
-	cli, ok := p.(*availablePlugin).client.(client.PluginCollectorClient)
+	cli, ok := client.WithTimeout(p.(*availablePlugin).client, timeout).(client.PluginCollectorClient)
 	if !ok {
 		return nil, serror.New(errors.New("unable to cast client to PluginCollectorClient"))
 	}
 
 	// collect metrics
+	start := time.Now()
 	metrics, err := cli.CollectMetrics(metricsToCollect)
+	observePluginRPC(p.(*availablePlugin), "collect", start, err)
 	if err != nil {
-		return nil, serror.New(err)
+		return nil, callError(p.(*availablePlugin), timeout, err)
 	}
 
-	pool.UpdateCache(metrics, taskID)
+	pool.UpdateCache(metrics, digest, taskID)
 
 	results = make([]core.Metric, len(metricsFromCache)+len(metrics))
 	idx := 0
@@ -509,7 +557,7 @@ func (ap *availablePlugins) streamMetrics(
 	return metricChan, errChan, nil
 }
 
-func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) []error {
+func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string, timeout time.Duration) []error {
 	key := strings.Join([]string{plugin.PublisherPluginType.String(), pluginName, strconv.Itoa(pluginVersion)}, core.Separator)
 	pool, serr := ap.getPool(key)
 	if serr != nil {
@@ -519,29 +567,49 @@ func (ap *availablePlugins) publishMetrics(metrics []core.Metric, pluginName str
 		return []error{serror.New(ErrPoolNotFound, map[string]interface{}{"pool-key": key})}
 	}
 
+	p, err := ap.publish(pool, metrics, config, taskID, timeout)
+	// an instance which cannot be reached is replaced at once by a standby
+	// one of its pool, which publishes the metrics again
+	if p != nil && failOver(pool, p, err) {
+		p, err = ap.publish(pool, metrics, config, taskID, timeout)
+	}
+	if err != nil {
+		if p != nil {
+			return []error{callError(p, timeout, err)}
+		}
+		return []error{err}
+	}
+	return nil
+}
+
+// publish publishes the metrics with a plugin of the pool, returned with the
+// error of the call once one is selected
+func (ap *availablePlugins) publish(pool strategy.Pool, metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID string, timeout time.Duration) (*availablePlugin, error) {
 	pool.RLock()
 	defer pool.RUnlock()
 
 	p, serr := pool.SelectAP(taskID, config)
 	if serr != nil {
-		return []error{serr}
+		return nil, serr
 	}
 
-	cli, ok := p.(*availablePlugin).client.(client.PluginPublisherClient)
+	cli, ok := client.WithTimeout(p.(*availablePlugin).client, timeout).(client.PluginPublisherClient)
 	if !ok {
-		return []error{errors.New("unable to cast client to PluginPublisherClient")}
+		return nil, errors.New("unable to cast client to PluginPublisherClient")
 	}
 
+	start := time.Now()
 	err := cli.Publish(metrics, config)
+	observePluginRPC(p.(*availablePlugin), "publish", start, err)
 	if err != nil {
-		return []error{err}
+		return p.(*availablePlugin), err
 	}
 	p.(*availablePlugin).hitCount++
 	p.(*availablePlugin).lastHitTime = time.Now()
-	return nil
+	return p.(*availablePlugin), nil
 }
 
-func (ap *availablePlugins) processMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string) ([]core.Metric, []error) {
+func (ap *availablePlugins) processMetrics(metrics []core.Metric, pluginName string, pluginVersion int, config map[string]ctypes.ConfigValue, taskID string, timeout time.Duration) ([]core.Metric, []error) {
 	var errs []error
 	key := strings.Join([]string{plugin.ProcessorPluginType.String(), pluginName, strconv.Itoa(pluginVersion)}, core.Separator)
 	pool, serr := ap.getPool(key)
@@ -561,14 +629,16 @@ func (ap *availablePlugins) processMetrics(metrics []core.Metric, pluginName str
 		return nil, errs
 	}
 
-	cli, ok := p.(*availablePlugin).client.(client.PluginProcessorClient)
+	cli, ok := client.WithTimeout(p.(*availablePlugin).client, timeout).(client.PluginProcessorClient)
 	if !ok {
 		return nil, []error{errors.New("unable to cast client to PluginProcessorClient")}
 	}
 
+	start := time.Now()
 	mts, errp := cli.Process(metrics, config)
+	observePluginRPC(p.(*availablePlugin), "process", start, errp)
 	if errp != nil {
-		return nil, []error{errp}
+		return nil, []error{callError(p.(*availablePlugin), timeout, errp)}
 	}
 	p.(*availablePlugin).hitCount++
 	p.(*availablePlugin).lastHitTime = time.Now()
@@ -610,6 +680,9 @@ func (ap *availablePlugins) getOrCreatePool(key string) (strategy.Pool, error) {
 	if err != nil {
 		return nil, err
 	}
+	if size, ok := ap.sizes[poolPluginName(key)]; ok {
+		applyPluginPool(pool, size)
+	}
 	ap.table[key] = pool
 	return pool, nil
 }
@@ -631,3 +704,17 @@ func (ap *availablePlugins) all() []strategy.AvailablePlugin {
 	}
 	return aps
 }
+
+// pluginRPCDuration is the duration of the calls made to plugins to collect,
+// process and publish metrics.
+var pluginRPCDuration = opmetrics.NewSummary(
+	"snap_plugin_rpc_duration_seconds",
+	"Duration of the calls made to plugins.",
+	"plugin_type", "plugin_name", "plugin_version", "method",
+)
+
+func observePluginRPC(a *availablePlugin, method string, start time.Time, err error) {
+	pluginRPCDuration.Since(start, a.TypeName(), a.Name(), strconv.Itoa(a.Version()), method)
+	a.usage.observeRPC(time.Since(start))
+	a.calls.add(method, start, err)
+}
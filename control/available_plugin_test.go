@@ -28,6 +28,7 @@ import (
 
 	"github.com/intelsdi-x/snap/control/fixtures"
 	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
 	"github.com/intelsdi-x/snap/core"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -45,10 +46,24 @@ func TestAvailablePlugin(t *testing.T) {
 				Type:          plugin.CollectorPluginType,
 				ListenAddress: "127.0.0.1:4000",
 			}
-			ap, err := newAvailablePlugin(resp, nil, nil)
+			ap, err := newAvailablePlugin(resp, nil, nil, client.GRPCSecurity{}, DefaultClientTimeout)
 			So(ap, ShouldHaveSameTypeAs, new(availablePlugin))
 			So(err, ShouldBeNil)
 		})
+		Convey("refuses a plugin without TLS when TLS is required", func() {
+			resp := plugin.Response{
+				Meta: plugin.PluginMeta{
+					Name:    "testPlugin",
+					Version: 1,
+					RPCType: plugin.GRPC,
+				},
+				Type:          plugin.CollectorPluginType,
+				ListenAddress: "127.0.0.1:4000",
+			}
+			ap, err := newAvailablePlugin(resp, nil, nil, client.GRPCSecurity{TLSEnabled: true}, DefaultClientTimeout)
+			So(ap, ShouldBeNil)
+			So(err, ShouldEqual, ErrPluginTLSRequired)
+		})
 	})
 
 	Convey("Stop()", t, func() {
@@ -112,7 +127,7 @@ func TestAvailablePlugins(t *testing.T) {
 			Type:          plugin.CollectorPluginType,
 			ListenAddress: "localhost:asdf",
 		}
-		ap, err := newAvailablePlugin(resp, nil, nil)
+		ap, err := newAvailablePlugin(resp, nil, nil, client.GRPCSecurity{}, DefaultClientTimeout)
 		So(ap, ShouldBeNil)
 		So(err, ShouldNotBeNil)
 	})
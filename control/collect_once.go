@@ -0,0 +1,53 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/pborman/uuid"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// CollectOnce collects the requested metrics a single time, outside of any
+// task, subscribing to their collectors for the time of the collection. The
+// errors subscribing to the metrics, i.e. unknown metrics or an invalid
+// config, are returned apart from the errors of the collection itself.
+func (p *pluginControl) CollectOnce(requested []core.RequestedMetric, configTree *cdata.ConfigDataTree) ([]core.Metric, []serror.SnapError, []error) {
+	if !p.Started {
+		return nil, nil, []error{ErrControllerNotStarted}
+	}
+	id := "collect-once-" + uuid.New()
+	if serrs := p.SubscribeDeps(id, requested, nil, configTree); len(serrs) > 0 {
+		return nil, serrs, nil
+	}
+	defer func() {
+		if serrs := p.UnsubscribeDeps(id); len(serrs) > 0 {
+			controlLogger.WithFields(log.Fields{
+				"_block":                "collect-once",
+				"subscription-group-id": id,
+			}).Warn(serrs[0])
+		}
+	}()
+	mts, errs := p.CollectMetrics(id, nil)
+	return mts, nil, errs
+}
@@ -48,6 +48,8 @@ var (
 	defaultCacheExpiration   = 500 * time.Millisecond
 	defaultPprof             = false
 	defaultTempDirPath       = os.TempDir()
+	defaultRestartBackoff    = time.Second
+	defaultContainerRuntime  = "docker"
 )
 
 type pluginConfig struct {
@@ -73,19 +75,56 @@ type pluginConfigItem struct {
 //         UnmarshalJSON method in this same file needs to be modified to
 //         match the field mapping that is defined here
 type Config struct {
-	MaxRunningPlugins int                          `json:"max_running_plugins"yaml:"max_running_plugins"`
-	PluginLoadTimeout int                          `json:"plugin_load_timeout"yaml:"plugin_load_timeout"`
-	PluginTrust       int                          `json:"plugin_trust_level"yaml:"plugin_trust_level"`
-	AutoDiscoverPath  string                       `json:"auto_discover_path"yaml:"auto_discover_path"`
-	KeyringPaths      string                       `json:"keyring_paths"yaml:"keyring_paths"`
-	CacheExpiration   jsonutil.Duration            `json:"cache_expiration"yaml:"cache_expiration"`
-	Plugins           *pluginConfig                `json:"plugins"yaml:"plugins"`
-	Tags              map[string]map[string]string `json:"tags,omitempty"yaml:"tags"`
-	ListenAddr        string                       `json:"listen_addr,omitempty"yaml:"listen_addr"`
-	ListenPort        int                          `json:"listen_port,omitempty"yaml:"listen_port"`
-	Pprof             bool                         `json:"pprof"yaml:"pprof"`
-	MaxPluginRestarts int                          `json:"max_plugin_restarts"yaml:"max_plugin_restarts"`
-	TempDirPath       string                       `json:"temp_dir_path"yaml:"temp_dir_path"`
+	MaxRunningPlugins int                           `json:"max_running_plugins"yaml:"max_running_plugins"`
+	PluginLoadTimeout int                           `json:"plugin_load_timeout"yaml:"plugin_load_timeout"`
+	PluginTrust       int                           `json:"plugin_trust_level"yaml:"plugin_trust_level"`
+	AutoDiscoverPath  string                        `json:"auto_discover_path"yaml:"auto_discover_path"`
+	AutoDiscoverWatch bool                          `json:"auto_discover_watch"yaml:"auto_discover_watch"`
+	AutoUnload        bool                          `json:"auto_discover_unload"yaml:"auto_discover_unload"`
+	KeyringPaths      string                        `json:"keyring_paths"yaml:"keyring_paths"`
+	CacheExpiration   jsonutil.Duration             `json:"cache_expiration"yaml:"cache_expiration"`
+	MetricCacheTTL    map[string]jsonutil.Duration  `json:"metric_cache_ttl,omitempty"yaml:"metric_cache_ttl"`
+	Plugins           *pluginConfig                 `json:"plugins"yaml:"plugins"`
+	Tags              map[string]map[string]string  `json:"tags,omitempty"yaml:"tags"`
+	ListenAddr        string                        `json:"listen_addr,omitempty"yaml:"listen_addr"`
+	ListenPort        int                           `json:"listen_port,omitempty"yaml:"listen_port"`
+	Pprof             bool                          `json:"pprof"yaml:"pprof"`
+	MaxPluginRestarts int                           `json:"max_plugin_restarts"yaml:"max_plugin_restarts"`
+	RestartBackoff    jsonutil.Duration             `json:"plugin_restart_backoff"yaml:"plugin_restart_backoff"`
+	HealthCheck       *HealthCheckConfig            `json:"health_check"yaml:"health_check"`
+	TempDirPath       string                        `json:"temp_dir_path"yaml:"temp_dir_path"`
+	PluginPlacement   map[string]*PluginPlacement   `json:"plugin_placement,omitempty"yaml:"plugin_placement"`
+	PluginLimits      map[string]*PluginLimits      `json:"plugin_limits,omitempty"yaml:"plugin_limits"`
+	PluginConfinement map[string]*PluginConfinement `json:"plugin_confinement,omitempty"yaml:"plugin_confinement"`
+	PluginTimeouts    map[string]jsonutil.Duration  `json:"plugin_timeouts,omitempty"yaml:"plugin_timeouts"`
+	PluginPools       map[string]*PluginPool        `json:"plugin_pools,omitempty"yaml:"plugin_pools"`
+	MetricProxies     []*MetricProxy                `json:"metric_proxies,omitempty"yaml:"metric_proxies"`
+	PluginTLS         *PluginTLSConfig              `json:"plugin_tls,omitempty"yaml:"plugin_tls"`
+	PluginOutput      *PluginOutputConfig           `json:"plugin_output"yaml:"plugin_output"`
+	ContainerRuntime  string                        `json:"container_runtime"yaml:"container_runtime"`
+	RemotePlugins     []*RemotePlugin               `json:"remote_plugins,omitempty"yaml:"remote_plugins"`
+	PluginAccess      *PluginAccess                 `json:"plugin_access,omitempty"yaml:"plugin_access"`
+	PluginIdleTimeout jsonutil.Duration             `json:"plugin_idle_timeout"yaml:"plugin_idle_timeout"`
+	NamespacePolicies map[string]*NamespacePolicy   `json:"namespace_policies,omitempty"yaml:"namespace_policies"`
+}
+
+// HealthCheckConfig sets how the running plugins are health checked
+type HealthCheckConfig struct {
+	// Interval is the interval between the health checks of a plugin
+	Interval jsonutil.Duration `json:"interval"yaml:"interval"`
+	// Timeout is the time a plugin has to answer a health check
+	Timeout jsonutil.Duration `json:"timeout"yaml:"timeout"`
+	// FailureLimit is the count of consecutive failed health checks after
+	// which a plugin is killed and replaced
+	FailureLimit int `json:"failure_limit"yaml:"failure_limit"`
+}
+
+func newHealthCheckConfig() *HealthCheckConfig {
+	return &HealthCheckConfig{
+		Interval:     jsonutil.Duration{DefaultMonitorDuration},
+		Timeout:      jsonutil.Duration{DefaultHealthCheckTimeout},
+		FailureLimit: DefaultHealthCheckFailureLimit,
+	}
 }
 
 const (
@@ -101,9 +140,21 @@ const (
 					"auto_discover_path": {
 						"type": "string"
 					},
+					"auto_discover_watch": {
+						"type": "boolean"
+					},
+					"auto_discover_unload": {
+						"type": "boolean"
+					},
 					"cache_expiration": {
 						"type": "string"
 					},
+					"metric_cache_ttl": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "string"
+						}
+					},
 					"max_running_plugins": {
 						"type": "integer",
 						"minimum": 1
@@ -140,6 +191,267 @@ const (
 					},
 					"max_plugin_restarts": {
 						"type": "integer"
+					},
+					"plugin_restart_backoff": {
+						"type": "string"
+					},
+					"plugin_idle_timeout": {
+						"type": "string"
+					},
+					"health_check": {
+						"type": ["object", "null"],
+						"properties": {
+							"interval": {
+								"type": "string"
+							},
+							"timeout": {
+								"type": "string"
+							},
+							"failure_limit": {
+								"type": "integer",
+								"minimum": 1
+							}
+						},
+						"additionalProperties": false
+					},
+					"plugin_placement": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "object",
+							"properties": {
+								"cpuset": {
+									"type": "string",
+									"pattern": "^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$"
+								},
+								"numa_node": {
+									"type": "integer",
+									"minimum": 0
+								}
+							},
+							"additionalProperties": false
+						}
+					},
+					"plugin_access": {
+						"type": ["object", "null"],
+						"properties": {
+							"allow": {
+								"type": ["array", "null"],
+								"items": {
+									"type": "object",
+									"properties": {
+										"name": {
+											"type": "string"
+										},
+										"version": {
+											"type": "string"
+										},
+										"checksum": {
+											"type": "string",
+											"pattern": "^[0-9a-fA-F]{64}$"
+										}
+									},
+									"additionalProperties": false
+								}
+							},
+							"deny": {
+								"type": ["array", "null"],
+								"items": {
+									"type": "object",
+									"properties": {
+										"name": {
+											"type": "string"
+										},
+										"version": {
+											"type": "string"
+										},
+										"checksum": {
+											"type": "string",
+											"pattern": "^[0-9a-fA-F]{64}$"
+										}
+									},
+									"additionalProperties": false
+								}
+							}
+						},
+						"additionalProperties": false
+					},
+					"plugin_timeouts": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "string"
+						}
+					},
+					"plugin_limits": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "object",
+							"properties": {
+								"cpus": {
+									"type": "number",
+									"exclusiveMinimum": true,
+									"minimum": 0
+								},
+								"memory_mb": {
+									"type": "integer",
+									"minimum": 1
+								}
+							},
+							"additionalProperties": false
+						}
+					},
+					"plugin_confinement": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "object",
+							"properties": {
+								"apparmor": {
+									"type": "string"
+								},
+								"seccomp": {
+									"type": "string"
+								}
+							},
+							"additionalProperties": false
+						}
+					},
+					"plugin_pools": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "object",
+							"properties": {
+								"min": {
+									"type": "integer",
+									"minimum": 0
+								},
+								"max": {
+									"type": "integer",
+									"minimum": 0
+								},
+								"standby": {
+									"type": "integer",
+									"minimum": 0
+								},
+								"routing": {
+									"type": "string",
+									"enum": ["least-recently-used", "sticky", "config", "task-hash"]
+								}
+							},
+							"additionalProperties": false
+						}
+					},
+					"namespace_policies": {
+						"type": ["object", "null"],
+						"additionalProperties": {
+							"type": "object",
+							"properties": {
+								"policy": {
+									"type": "string",
+									"enum": ["highest_version", "priority", "reject", "merge"]
+								},
+								"priority": {
+									"type": ["array", "null"],
+									"items": {
+										"type": "string"
+									}
+								}
+							},
+							"required": ["policy"],
+							"additionalProperties": false
+						}
+					},
+					"metric_proxies": {
+						"type": ["array", "null"],
+						"items": {
+							"type": "object",
+							"properties": {
+								"namespace": {
+									"type": "string",
+									"pattern": "^/[^/]+(/[^/]+)*$"
+								},
+								"address": {
+									"type": "string"
+								}
+							},
+							"required": ["namespace", "address"],
+							"additionalProperties": false
+						}
+					},
+					"plugin_tls": {
+						"type": ["object", "null"],
+						"properties": {
+							"cert_path": {
+								"type": "string",
+								"minLength": 1
+							},
+							"key_path": {
+								"type": "string",
+								"minLength": 1
+							},
+							"ca_cert_paths": {
+								"type": "string"
+							}
+						},
+						"required": ["cert_path", "key_path"],
+						"additionalProperties": false
+					},
+					"plugin_output": {
+						"type": ["object", "null"],
+						"properties": {
+							"buffer_kb": {
+								"type": "integer",
+								"minimum": 0
+							},
+							"dir": {
+								"type": "string"
+							}
+						},
+						"additionalProperties": false
+					},
+					"container_runtime": {
+						"type": "string"
+					},
+					"remote_plugins": {
+						"type": ["array", "null"],
+						"items": {
+							"type": "object",
+							"properties": {
+								"name": {
+									"type": "string"
+								},
+								"version": {
+									"type": "integer",
+									"minimum": 1
+								},
+								"type": {
+									"type": "string",
+									"enum": ["collector", "processor", "publisher"]
+								},
+								"address": {
+									"type": "string"
+								},
+								"streaming": {
+									"type": "boolean"
+								},
+								"tls": {
+									"type": "object",
+									"properties": {
+										"cert_path": {
+											"type": "string"
+										},
+										"key_path": {
+											"type": "string"
+										},
+										"ca_cert_paths": {
+											"type": "string"
+										}
+									},
+									"required": ["cert_path", "key_path"],
+									"additionalProperties": false
+								}
+							},
+							"required": ["name", "version", "type", "address"],
+							"additionalProperties": false
+						}
 					}
 				},
 				"additionalProperties": false
@@ -162,7 +474,11 @@ func GetDefaultConfig() *Config {
 		Tags:              newPluginTags(),
 		Pprof:             defaultPprof,
 		MaxPluginRestarts: MaxPluginRestartCount,
+		RestartBackoff:    jsonutil.Duration{defaultRestartBackoff},
+		HealthCheck:       newHealthCheckConfig(),
 		TempDirPath:       defaultTempDirPath,
+		PluginOutput:      newPluginOutputConfig(),
+		ContainerRuntime:  defaultContainerRuntime,
 	}
 }
 
@@ -0,0 +1,43 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// configPolicyErrors returns the errors of a config processed by a config
+// policy with the given fields, adding the key of the config item failing its
+// rule as the field "field" so that the items to fix are known.
+func configPolicyErrors(errs *cpolicy.ProcessingErrors, fields map[string]interface{}) []serror.SnapError {
+	var serrs []serror.SnapError
+	for _, e := range errs.Errors() {
+		f := make(map[string]interface{}, len(fields)+1)
+		for k, v := range fields {
+			f[k] = v
+		}
+		if fe, ok := e.(*cpolicy.FieldError); ok {
+			f["field"] = fe.Key
+		}
+		serrs = append(serrs, serror.New(e, f))
+	}
+	return serrs
+}
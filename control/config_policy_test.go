@@ -0,0 +1,54 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigPolicyErrors(t *testing.T) {
+	Convey("Given a config failing a config policy", t, func() {
+		n := cpolicy.NewPolicyNode()
+		r1, _ := cpolicy.NewStringRule("user", true)
+		r2, _ := cpolicy.NewIntegerRule("port", false, 8080)
+		n.Add(r1, r2)
+		_, pe := n.Process(map[string]ctypes.ConfigValue{
+			"port": ctypes.ConfigValueStr{Value: "http"},
+		})
+
+		Convey("an error is returned for each config item", func() {
+			serrs := configPolicyErrors(pe, map[string]interface{}{"name": "mock"})
+			So(serrs, ShouldHaveLength, 2)
+			fields := []interface{}{}
+			for _, se := range serrs {
+				So(se.Fields()["name"], ShouldEqual, "mock")
+				fields = append(fields, se.Fields()["field"])
+			}
+			So(fields, ShouldContain, "user")
+			So(fields, ShouldContain, "port")
+		})
+	})
+}
@@ -315,5 +315,10 @@ func TestControlDefaultConfig(t *testing.T) {
 		Convey("max_plugin_restarts should be set to 3", func() {
 			So(cfg.MaxPluginRestarts, ShouldEqual, 3)
 		})
+		Convey("health_check should ping plugins every 5s, replacing them after 3 failures", func() {
+			So(cfg.HealthCheck.Interval.Duration, ShouldEqual, 5*time.Second)
+			So(cfg.HealthCheck.Timeout.Duration, ShouldEqual, 10*time.Second)
+			So(cfg.HealthCheck.FailureLimit, ShouldEqual, 3)
+		})
 	})
 }
@@ -0,0 +1,75 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+var (
+	ErrSeccompRequiresContainer = errors.New("seccomp profiles can only be applied to plugins run in a container")
+)
+
+// PluginConfinement gives the AppArmor and seccomp profiles confining the
+// subprocesses of a plugin when they are launched, limiting the files and
+// the syscalls they have access to.
+type PluginConfinement struct {
+	// AppArmor is the name of an AppArmor profile loaded in the kernel
+	AppArmor string `json:"apparmor,omitempty"yaml:"apparmor"`
+	// Seccomp is the path of a seccomp profile, in the JSON format of the
+	// container runtime. It is only applied to plugins run in a container.
+	Seccomp string `json:"seccomp,omitempty"yaml:"seccomp"`
+}
+
+// command returns the command line which launches the given plugin command
+// confined by the AppArmor profile, wrapping the command with aa-exec. As a
+// seccomp profile can not be applied outside of a container, the plugin is
+// refused rather than run unconfined when one is given.
+func (c *PluginConfinement) command(commands []string) ([]string, error) {
+	if c == nil || (c.AppArmor == "" && c.Seccomp == "") {
+		return commands, nil
+	}
+	if c.Seccomp != "" {
+		return nil, ErrSeccompRequiresContainer
+	}
+	path, err := exec.LookPath("aa-exec")
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply plugin confinement: %v", err)
+	}
+	return append([]string{path, "--profile", c.AppArmor, "--"}, commands...), nil
+}
+
+// containerArgs returns the flags of the run command applying the profiles
+// to a plugin run in a container.
+func (c *PluginConfinement) containerArgs() []string {
+	if c == nil {
+		return nil
+	}
+	var args []string
+	if c.AppArmor != "" {
+		args = append(args, "--security-opt=apparmor="+c.AppArmor)
+	}
+	if c.Seccomp != "" {
+		args = append(args, "--security-opt=seccomp="+c.Seccomp)
+	}
+	return args
+}
@@ -0,0 +1,64 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginConfinement(t *testing.T) {
+	commands := []string{"/opt/snap/plugins/snap-plugin-collector-psutil"}
+	Convey("Plugin confinement", t, func() {
+		Convey("leaves the command untouched when not set", func() {
+			var c *PluginConfinement
+			cmd, err := c.command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, commands)
+			cmd, err = (&PluginConfinement{}).command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, commands)
+		})
+		Convey("wraps the command with aa-exec for an AppArmor profile", func() {
+			path, err := exec.LookPath("aa-exec")
+			if err != nil {
+				SkipSo(err, ShouldBeNil)
+				return
+			}
+			cmd, err := (&PluginConfinement{AppArmor: "snap-plugin"}).command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, []string{path, "--profile", "snap-plugin", "--", commands[0]})
+		})
+		Convey("refuses a seccomp profile outside of a container", func() {
+			_, err := (&PluginConfinement{Seccomp: "/etc/snap/seccomp.json"}).command(commands)
+			So(err, ShouldEqual, ErrSeccompRequiresContainer)
+		})
+		Convey("is given to the runtime of containerized plugins", func() {
+			var c *PluginConfinement
+			So(c.containerArgs(), ShouldBeEmpty)
+			c = &PluginConfinement{AppArmor: "snap-plugin", Seccomp: "/etc/snap/seccomp.json"}
+			So(c.containerArgs(), ShouldResemble, []string{"--security-opt=apparmor=snap-plugin", "--security-opt=seccomp=/etc/snap/seccomp.json"})
+		})
+	})
+}
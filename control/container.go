@@ -0,0 +1,68 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"path/filepath"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+)
+
+var (
+	// ContainerRuntime is the docker compatible CLI running the plugins
+	// whose metadata give a container image
+	ContainerRuntime = defaultContainerRuntime
+)
+
+// OptSetContainerRuntime sets the CLI running the plugins in containers.
+func OptSetContainerRuntime(runtime string) PluginControlOpt {
+	return func(c *pluginControl) {
+		if runtime != "" {
+			ContainerRuntime = runtime
+		}
+	}
+}
+
+// isContainerized returns whether the plugin is run in a container, its
+// metadata giving the image to run it in.
+func isContainerized(details *pluginDetails) bool {
+	return details.Metadata != nil && details.Metadata.Image != ""
+}
+
+// newContainerPlugin returns the executable plugin running the commands,
+// in a container given the extra flags containerArgs when the plugin is
+// containerized. The certificates given to a plugin using TLS are mounted
+// in its container.
+func newContainerPlugin(args plugin.Arg, details *pluginDetails, containerArgs []string, commands []string) (*plugin.ExecutablePlugin, error) {
+	if !isContainerized(details) {
+		return plugin.NewExecutablePlugin(args, commands...)
+	}
+	var mounts []string
+	if args.TLSEnabled {
+		mounts = append(mounts, args.CertPath, args.KeyPath)
+		mounts = append(mounts, filepath.SplitList(args.CACertPaths)...)
+	}
+	return plugin.NewContainerExecutablePlugin(args, plugin.Container{
+		Runtime: ContainerRuntime,
+		Image:   details.Metadata.Image,
+		Mounts:  mounts,
+		Args:    containerArgs,
+	}, commands...)
+}
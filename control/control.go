@@ -26,7 +26,6 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -37,8 +36,10 @@ import (
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/intelsdi-x/gomit"
+	"github.com/vrischmann/jsonutil"
 
 	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
 	"github.com/intelsdi-x/snap/control/strategy"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
@@ -47,6 +48,7 @@ import (
 	"github.com/intelsdi-x/snap/core/serror"
 	"github.com/intelsdi-x/snap/grpc/controlproxy/rpc"
 	"github.com/intelsdi-x/snap/pkg/aci"
+	"github.com/intelsdi-x/snap/pkg/fileutils"
 	"github.com/intelsdi-x/snap/pkg/psigning"
 )
 
@@ -69,6 +71,15 @@ var (
 
 	// ErrControllerNotStarted - error message when the Controller was not started
 	ErrControllerNotStarted = errors.New("Must start Controller before use")
+
+	// ErrAmbiguousMetricVersion - error message when a namespace matches metrics of several versions
+	ErrAmbiguousMetricVersion = errors.New("Namespace matches metrics of several versions")
+
+	// ErrControllerUnresponsive - error message when the Controller does not list its plugins in time
+	ErrControllerUnresponsive = errors.New("Controller did not respond in time")
+
+	// readyTimeout is how long Ready waits on the plugin catalog
+	readyTimeout = 5 * time.Second
 )
 
 type pluginControl struct {
@@ -76,8 +87,9 @@ type pluginControl struct {
 	Started bool
 	Config  *Config
 
-	autodiscoverPaths []string
-	eventManager      *gomit.EventController
+	autodiscoverPaths   []string
+	autodiscoverWatcher *autodiscoverWatcher
+	eventManager        *gomit.EventController
 
 	pluginManager  managesPlugins
 	metricCatalog  catalogsMetrics
@@ -92,6 +104,7 @@ type pluginControl struct {
 	wg          sync.WaitGroup
 
 	subscriptionGroups ManagesSubscriptionGroups
+	metricProxies      *metricProxies
 }
 
 type subscribedPlugin struct {
@@ -114,8 +127,14 @@ type runsPlugins interface {
 	SetEmitter(gomit.Emitter)
 	SetMetricCatalog(catalogsMetrics)
 	SetPluginManager(managesPlugins)
+	SetPluginPlacement(map[string]*PluginPlacement)
+	SetPluginLimits(map[string]*PluginLimits)
+	SetPluginConfinement(map[string]*PluginConfinement)
+	SetPluginTimeouts(pluginTimeouts)
+	SetPluginIdleTimeout(time.Duration)
 	Monitor() *monitor
 	runPlugin(string, *pluginDetails) error
+	wakePlugin(string) error
 }
 
 type managesPlugins interface {
@@ -126,9 +145,11 @@ type managesPlugins interface {
 	UnloadPlugin(core.Plugin) (*loadedPlugin, serror.SnapError)
 	SetMetricCatalog(catalogsMetrics)
 	GenerateArgs(logLevel int) plugin.Arg
+	PluginTLS() client.GRPCSecurity
 	SetPluginConfig(*pluginConfig)
 	GetPluginConfig() *pluginConfig
 	SetPluginTags(map[string]map[string]string)
+	SetPluginTimeouts(pluginTimeouts)
 	AddStandardAndWorkflowTags(core.Metric, map[string]map[string]string) core.Metric
 	SetPluginLoadTimeout(int)
 }
@@ -168,6 +189,20 @@ func CacheExpiration(t time.Duration) PluginControlOpt {
 	}
 }
 
+// MetricCacheTTL is the PluginControlOpt which overrides the metric cache TTL
+// for the metrics under the given namespaces
+func MetricCacheTTL(ttls map[string]jsonutil.Duration) PluginControlOpt {
+	return func(c *pluginControl) {
+		if len(ttls) == 0 {
+			return
+		}
+		strategy.MetricCacheTTL = make(map[string]time.Duration, len(ttls))
+		for ns, ttl := range ttls {
+			strategy.MetricCacheTTL[ns] = ttl.Duration
+		}
+	}
+}
+
 // OptSetConfig sets the plugin control configuration.
 func OptSetConfig(cfg *Config) PluginControlOpt {
 	return func(c *pluginControl) {
@@ -184,6 +219,34 @@ func OptSetTags(tags map[string]map[string]string) PluginControlOpt {
 	}
 }
 
+// OptSetPluginPlacement sets the CPU and NUMA placement of plugins by name.
+func OptSetPluginPlacement(placement map[string]*PluginPlacement) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.pluginRunner.SetPluginPlacement(placement)
+	}
+}
+
+// OptSetPluginLimits sets the CPU and memory limits of plugins by name.
+func OptSetPluginLimits(limits map[string]*PluginLimits) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.pluginRunner.SetPluginLimits(limits)
+	}
+}
+
+// OptSetPluginConfinement sets the AppArmor and seccomp profiles of plugins by name.
+func OptSetPluginConfinement(confinement map[string]*PluginConfinement) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.pluginRunner.SetPluginConfinement(confinement)
+	}
+}
+
+// OptSetMetricProxies sets the namespaces collected through remote snapteld instances.
+func OptSetMetricProxies(proxies []*MetricProxy) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.metricProxies = newMetricProxies(proxies)
+	}
+}
+
 // MaximumPluginRestarts
 func MaxPluginRestarts(cfg *Config) PluginControlOpt {
 	return func(*pluginControl) {
@@ -191,15 +254,61 @@ func MaxPluginRestarts(cfg *Config) PluginControlOpt {
 	}
 }
 
+// OptSetPluginPools sets the min and max running instances of plugins by name.
+func OptSetPluginPools(pools map[string]*PluginPool) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.pluginRunner.AvailablePlugins().SetPluginPools(pools)
+	}
+}
+
+// RestartBackoff sets the delay before the first restart of a dead plugin
+func RestartBackoff(cfg *Config) PluginControlOpt {
+	return func(*pluginControl) {
+		PluginRestartBackoff = cfg.RestartBackoff.Duration
+	}
+}
+
+// HealthCheck sets the interval, timeout and failure limit of the health
+// checks of the running plugins
+func HealthCheck(cfg *Config) PluginControlOpt {
+	return func(c *pluginControl) {
+		if cfg.HealthCheck == nil {
+			return
+		}
+		if cfg.HealthCheck.Interval.Duration > 0 {
+			c.pluginRunner.Monitor().Option(MonitorDurationOption(cfg.HealthCheck.Interval.Duration))
+		}
+		if cfg.HealthCheck.Timeout.Duration > 0 {
+			HealthCheckTimeout = cfg.HealthCheck.Timeout.Duration
+		}
+		if cfg.HealthCheck.FailureLimit > 0 {
+			HealthCheckFailureLimit = cfg.HealthCheck.FailureLimit
+		}
+	}
+}
+
 // New returns a new pluginControl instance
 func New(cfg *Config) *pluginControl {
 	// construct a slice of options from the input configuration
 	opts := []PluginControlOpt{
 		MaxRunningPlugins(cfg.MaxRunningPlugins),
 		CacheExpiration(cfg.CacheExpiration.Duration),
+		MetricCacheTTL(cfg.MetricCacheTTL),
+		OptSetPluginTimeouts(cfg.PluginTimeouts),
 		OptSetConfig(cfg),
 		OptSetTags(cfg.Tags),
 		MaxPluginRestarts(cfg),
+		RestartBackoff(cfg),
+		OptSetPluginIdleTimeout(cfg.PluginIdleTimeout.Duration),
+		HealthCheck(cfg),
+		OptSetPluginPlacement(cfg.PluginPlacement),
+		OptSetPluginLimits(cfg.PluginLimits),
+		OptSetPluginConfinement(cfg.PluginConfinement),
+		OptSetPluginPools(cfg.PluginPools),
+		OptSetMetricProxies(cfg.MetricProxies),
+		OptSetNamespacePolicies(cfg.NamespacePolicies),
+		PluginOutput(cfg),
+		OptSetContainerRuntime(cfg.ContainerRuntime),
 	}
 	c := &pluginControl{}
 	c.Config = cfg
@@ -218,7 +327,10 @@ func New(cfg *Config) *pluginControl {
 	}).Debug("metric catalog created")
 
 	// Plugin Manager
-	c.pluginManager = newPluginManager(OptSetPprof(cfg.Pprof))
+	c.pluginManager = newPluginManager(
+		OptSetPprof(cfg.Pprof),
+		OptSetPluginTLS(cfg.PluginTLS.security()),
+		OptSetPluginAccess(cfg.PluginAccess))
 	controlLogger.WithFields(log.Fields{
 		"_block": "new",
 	}).Debug("plugin manager created")
@@ -246,6 +358,7 @@ func New(cfg *Config) *pluginControl {
 
 	// Create subscription group - used for managing a group of subscriptions
 	c.subscriptionGroups = newSubscriptionGroups(c)
+	c.metricProxies = newMetricProxies(nil)
 
 	// Start stuff
 	err := c.pluginRunner.Start()
@@ -316,6 +429,15 @@ func (p *pluginControl) Start() error {
 
 		paths := filepath.SplitList(p.Config.AutoDiscoverPath)
 		p.SetAutodiscoverPaths(paths)
+		if p.Config.AutoDiscoverWatch {
+			w, err := newAutodiscoverWatcher(p, p.Config.AutoUnload)
+			if err != nil {
+				controlLogger.WithFields(log.Fields{
+					"_block": "start",
+				}).Fatal(err)
+			}
+			p.autodiscoverWatcher = w
+		}
 		for _, pa := range paths {
 			fullPath, err := filepath.Abs(pa)
 			if err != nil {
@@ -327,6 +449,16 @@ func (p *pluginControl) Start() error {
 			controlLogger.WithFields(log.Fields{
 				"_block": "start",
 			}).Info("autoloading plugins from: ", fullPath)
+			// the path is watched before being read so that no plugin
+			// dropped in between is missed
+			if p.autodiscoverWatcher != nil {
+				if err := p.autodiscoverWatcher.watch(pa, fullPath); err != nil {
+					controlLogger.WithFields(log.Fields{
+						"_block":           "start",
+						"autodiscoverpath": pa,
+					}).Fatal(err)
+				}
+			}
 			files, err := ioutil.ReadDir(fullPath)
 			if err != nil {
 				controlLogger.WithFields(log.Fields{
@@ -335,108 +467,34 @@ func (p *pluginControl) Start() error {
 				}).Fatal(err)
 			}
 			for _, file := range files {
-				fileName := file.Name()
-
-				statCheck := file
-				if file.Mode()&os.ModeSymlink != 0 {
-					realPath, err := filepath.EvalSymlinks(filepath.Join(fullPath, fileName))
-					if err != nil {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": pa,
-							"error":            err,
-							"plugin":           fileName,
-						}).Error("Cannot follow symlink")
-						continue
-					}
-					statCheck, err = os.Stat(realPath)
-					if err != nil {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": pa,
-							"error":            err,
-							"plugin":           fileName,
-							"target-path":      realPath,
-						}).Error("Target of symlink inacessible")
-						continue
-					}
-				}
-
-				if statCheck.IsDir() {
-					controlLogger.WithFields(log.Fields{
-						"_block":           "start",
-						"autodiscoverpath": pa,
-					}).Warning("Ignoring subdirectory: ", fileName)
-					continue
-				}
-				// Ignore tasks files (JSON and YAML)
-				fname := strings.ToLower(fileName)
-				if strings.HasSuffix(fname, ".json") || strings.HasSuffix(fname, ".yaml") || strings.HasSuffix(fname, ".yml") {
-					controlLogger.WithFields(log.Fields{
-						"_block":           "start",
-						"autodiscoverpath": pa,
-					}).Warning("Ignoring JSON/Yaml file: ", fileName)
-					continue
-				}
-				// if the file is a plugin package (which would have a suffix of '.aci') or if the file
-				// is not a plugin signing file (which would have a suffix of '.asc'), then attempt to
-				// automatically load the file as a plugin
-				if strings.HasSuffix(fileName, ".aci") || !(strings.HasSuffix(fileName, ".asc")) {
-					// check to makd sure the file is executable by someone (even if it isn't you); if no one
-					// can execute this file then skip it (and include a warning in the log output)
-					if (statCheck.Mode() & 0111) == 0 {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": pa,
-							"plugin":           fileName,
-						}).Warn("Auto-loading of plugin '", fileName, "' skipped (plugin not executable)")
-						continue
-					}
-					rp, err := core.NewRequestedPlugin(path.Join(fullPath, fileName), p.GetTempDir(), nil)
-					if err != nil {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": pa,
-							"plugin":           fileName,
-						}).Error(err)
-					}
-					signatureFile := fileName + ".asc"
-					if _, err := os.Stat(path.Join(fullPath, signatureFile)); err == nil {
-						err = rp.ReadSignatureFile(path.Join(fullPath, signatureFile))
-						if err != nil {
-							controlLogger.WithFields(log.Fields{
-								"_block":           "start",
-								"autodiscoverpath": pa,
-								"plugin":           fileName + ".asc",
-							}).Error(err)
-						}
-					}
-					pl, err := p.Load(rp)
-					if err != nil {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": fullPath,
-							"plugin":           fileName,
-						}).Error(err)
-					} else {
-						controlLogger.WithFields(log.Fields{
-							"_block":           "start",
-							"autodiscoverpath": fullPath,
-							"plugin-file-name": fileName,
-							"plugin-name":      pl.Name(),
-							"plugin-version":   pl.Version(),
-							"plugin-type":      pl.TypeName(),
-						}).Info("Loading plugin")
-					}
+				pl := p.autoloadPlugin(pa, fullPath, file)
+				if pl != nil && p.autodiscoverWatcher != nil {
+					p.autodiscoverWatcher.loaded(filepath.Join(fullPath, file.Name()), pl)
 				}
 			}
 		}
+		if p.autodiscoverWatcher != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block": "start",
+			}).Info("watching auto discover paths for plugins")
+			p.autodiscoverWatcher.start()
+		}
 	} else {
 		controlLogger.WithFields(log.Fields{
 			"_block": "start",
 		}).Info("auto discover path is disabled")
 	}
 
+	for _, r := range p.Config.RemotePlugins {
+		if _, err := p.loadRemotePlugin(r); err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":  "start",
+				"plugin":  r.Name,
+				"address": r.Address,
+			}).Error(err)
+		}
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf("%v:%v", p.Config.ListenAddr, p.Config.ListenPort))
 	if err != nil {
 		controlLogger.WithField("error", err.Error()).Error("Failed to start control grpc listener")
@@ -465,6 +523,140 @@ func (p *pluginControl) Start() error {
 	return nil
 }
 
+// autoloadPlugin loads the plugin file found in an auto discover path,
+// skipping the files which are not plugins. It returns the loaded plugin, nil
+// when the file was skipped or failed to load.
+func (p *pluginControl) autoloadPlugin(pa, fullPath string, file os.FileInfo) core.CatalogedPlugin {
+	fileName := file.Name()
+
+	statCheck := file
+	if file.Mode()&os.ModeSymlink != 0 {
+		realPath, err := filepath.EvalSymlinks(filepath.Join(fullPath, fileName))
+		if err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": pa,
+				"error":            err,
+				"plugin":           fileName,
+			}).Error("Cannot follow symlink")
+			return nil
+		}
+		statCheck, err = os.Stat(realPath)
+		if err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": pa,
+				"error":            err,
+				"plugin":           fileName,
+				"target-path":      realPath,
+			}).Error("Target of symlink inacessible")
+			return nil
+		}
+	}
+
+	if statCheck.IsDir() {
+		controlLogger.WithFields(log.Fields{
+			"_block":           "autoload",
+			"autodiscoverpath": pa,
+		}).Warning("Ignoring subdirectory: ", fileName)
+		return nil
+	}
+	// Ignore tasks files (JSON and YAML)
+	fname := strings.ToLower(fileName)
+	if strings.HasSuffix(fname, ".json") || strings.HasSuffix(fname, ".yaml") || strings.HasSuffix(fname, ".yml") {
+		controlLogger.WithFields(log.Fields{
+			"_block":           "autoload",
+			"autodiscoverpath": pa,
+		}).Warning("Ignoring JSON/Yaml file: ", fileName)
+		return nil
+	}
+	// if the file is a plugin package (which would have a suffix of '.aci') or if the file
+	// is not a plugin signing file (which would have a suffix of '.asc'), then attempt to
+	// automatically load the file as a plugin
+	if strings.HasSuffix(fileName, ".aci") || !(strings.HasSuffix(fileName, ".asc")) {
+		// check to makd sure the file is executable by someone (even if it isn't you); if no one
+		// can execute this file then skip it (and include a warning in the log output)
+		if !fileutils.IsExecutable(statCheck) {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": pa,
+				"plugin":           fileName,
+			}).Warn("Auto-loading of plugin '", fileName, "' skipped (plugin not executable)")
+			return nil
+		}
+		rp, err := core.NewRequestedPlugin(filepath.Join(fullPath, fileName), p.GetTempDir(), nil)
+		if err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": pa,
+				"plugin":           fileName,
+			}).Error(err)
+			return nil
+		}
+		metadataFile := fileName + core.PluginMetadataExt
+		if _, err := os.Stat(filepath.Join(fullPath, metadataFile)); err == nil {
+			err = rp.ReadMetadataFile(filepath.Join(fullPath, metadataFile))
+			if err != nil {
+				controlLogger.WithFields(log.Fields{
+					"_block":           "autoload",
+					"autodiscoverpath": pa,
+					"plugin":           metadataFile,
+				}).Error(err)
+				return nil
+			}
+		}
+		signatureFile := fileName + ".asc"
+		if _, err := os.Stat(filepath.Join(fullPath, signatureFile)); err == nil {
+			err = rp.ReadSignatureFile(filepath.Join(fullPath, signatureFile))
+			if err != nil {
+				controlLogger.WithFields(log.Fields{
+					"_block":           "autoload",
+					"autodiscoverpath": pa,
+					"plugin":           fileName + ".asc",
+				}).Error(err)
+			}
+		}
+		pl, err := p.Load(rp)
+		if err != nil {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": fullPath,
+				"plugin":           fileName,
+			}).Error(err)
+		} else {
+			controlLogger.WithFields(log.Fields{
+				"_block":           "autoload",
+				"autodiscoverpath": fullPath,
+				"plugin-file-name": fileName,
+				"plugin-name":      pl.Name(),
+				"plugin-version":   pl.Version(),
+				"plugin-type":      pl.TypeName(),
+			}).Info("Loading plugin")
+			return pl
+		}
+	}
+	return nil
+}
+
+// Ready returns an error when the Controller is not started or does not
+// list its plugins in time, i.e. because it is stuck on a plugin.
+func (p *pluginControl) Ready() error {
+	if !p.Started {
+		return ErrControllerNotStarted
+	}
+	done := make(chan struct{})
+	go func() {
+		p.PluginCatalog()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(readyTimeout):
+		return ErrControllerUnresponsive
+	}
+}
+
 func (p *pluginControl) Stop() {
 	// set the Started flag to false (since we're stopping the server)
 	p.Started = false
@@ -477,6 +669,11 @@ func (p *pluginControl) Stop() {
 	p.grpcServer.Stop()
 	p.wg.Wait()
 
+	// stop loading the plugins dropped in the auto discover paths
+	if p.autodiscoverWatcher != nil {
+		p.autodiscoverWatcher.stop()
+	}
+
 	// stop runner
 	err := p.pluginRunner.Stop()
 	if err != nil {
@@ -545,6 +742,14 @@ func (p *pluginControl) Load(rp *core.RequestedPlugin) (core.CatalogedPlugin, se
 	return pl, nil
 }
 
+// VerifySignature checks the signature of the requested plugin against the
+// keyrings, as Load does, so that the plugins of a request can all be checked
+// before any of them is loaded.
+func (p *pluginControl) VerifySignature(rp *core.RequestedPlugin) serror.SnapError {
+	_, se := p.verifySignature(rp)
+	return se
+}
+
 func (p *pluginControl) verifySignature(rp *core.RequestedPlugin) (bool, serror.SnapError) {
 	f := map[string]interface{}{
 		"_block": "verifySignature",
@@ -574,6 +779,20 @@ func (p *pluginControl) verifySignature(rp *core.RequestedPlugin) (bool, serror.
 func (p *pluginControl) returnPluginDetails(rp *core.RequestedPlugin) (*pluginDetails, serror.SnapError) {
 	details := &pluginDetails{}
 	var serr serror.SnapError
+	// Check the binary against its metadata before anything else runs it
+	if md := rp.Metadata(); md != nil {
+		if err := md.VerifyCheckSum(rp.CheckSum()); err != nil {
+			return nil, serror.New(err, map[string]interface{}{"plugin-name": md.Name})
+		}
+		// Refuse the plugins which cannot run here before running them
+		if err := md.CheckPlatform(); err != nil {
+			return nil, serror.New(err, map[string]interface{}{"plugin-name": md.Name})
+		}
+		if err := checkDependencies(p.pluginManager, md); err != nil {
+			return nil, serror.New(err, map[string]interface{}{"plugin-name": md.Name})
+		}
+		details.Metadata = md
+	}
 	//Check plugin signing
 	details.Signed, serr = p.verifySignature(rp)
 	if serr != nil {
@@ -597,7 +816,7 @@ func (p *pluginControl) returnPluginDetails(rp *core.RequestedPlugin) (*pluginDe
 		if err != nil {
 			return nil, serror.New(err)
 		}
-		details.ExecPath = path.Join(tempPath, "rootfs")
+		details.ExecPath = filepath.Join(tempPath, "rootfs")
 		if details.Manifest, err = aci.Manifest(f); err != nil {
 			return nil, serror.New(err)
 		}
@@ -689,23 +908,40 @@ func (p *pluginControl) SwapPlugins(in *core.RequestedPlugin, out core.Cataloged
 }
 
 func (p *pluginControl) ValidateDeps(requested []core.RequestedMetric, plugins []core.SubscribedPlugin, configTree *cdata.ConfigDataTree) []serror.SnapError {
-	return p.subscriptionGroups.ValidateDeps(requested, plugins, configTree)
+	local, remote := p.metricProxies.split(requested)
+	serrs := p.subscriptionGroups.ValidateDeps(local, plugins, configTree)
+	return append(serrs, p.metricProxies.validateDeps(remote)...)
 }
 
 // SubscribeDeps will subscribe to collectors, processors and publishers.  The collectors are subscribed by mapping the provided
 // array of core.RequestedMetrics to the corresponding plugins while processors and publishers provided in the array of core.Plugin
 // will be subscribed directly.  The ID provides a logical grouping of subscriptions.
+// Metrics under a namespace served by a metric proxy are subscribed to on the remote instance.
 func (p *pluginControl) SubscribeDeps(id string, requested []core.RequestedMetric, plugins []core.SubscribedPlugin, configTree *cdata.ConfigDataTree) (serrs []serror.SnapError) {
-	return p.subscriptionGroups.Add(id, requested, configTree, plugins)
+	local, remote := p.metricProxies.split(requested)
+	serrs = p.subscriptionGroups.Add(id, local, configTree, plugins)
+	if len(serrs) > 0 {
+		return serrs
+	}
+	serrs = p.metricProxies.subscribeDeps(id, remote)
+	if len(serrs) > 0 {
+		p.metricProxies.unsubscribeDeps(id)
+		p.subscriptionGroups.Remove(id)
+	}
+	return serrs
 }
 
 // UnsubscribeDeps unsubscribes a group of dependencies provided the subscription group ID
 func (p *pluginControl) UnsubscribeDeps(id string) []serror.SnapError {
 	// update view and unsubscribe to plugins
-	return p.subscriptionGroups.Remove(id)
+	serrs := p.subscriptionGroups.Remove(id)
+	return append(serrs, p.metricProxies.unsubscribeDeps(id)...)
 }
 
 func (p *pluginControl) verifyPlugin(lp *loadedPlugin) error {
+	if lp.Details.Remote != nil {
+		return nil
+	}
 	b, err := ioutil.ReadFile(lp.Details.Path)
 	if err != nil {
 		return err
@@ -720,6 +956,28 @@ func (p *pluginControl) verifyPlugin(lp *loadedPlugin) error {
 	return nil
 }
 
+// filterMetricsByTags returns the metric types carrying all of the given tags
+func filterMetricsByTags(mts []*metricType, tags map[string]string) []*metricType {
+	if len(tags) == 0 {
+		return mts
+	}
+	filtered := []*metricType{}
+	for _, mt := range mts {
+		mtTags := mt.Tags()
+		matches := true
+		for k, v := range tags {
+			if val, ok := mtTags[k]; !ok || val != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, mt)
+		}
+	}
+	return filtered
+}
+
 // getMetricsAndCollectors returns metrics to be collected grouped by plugin and collectors which are used to collect all of them
 func (p *pluginControl) getMetricsAndCollectors(requested []core.RequestedMetric, configTree *cdata.ConfigDataTree) (map[string]metricTypes, []core.SubscribedPlugin, []serror.SnapError) {
 	newMetricsGroupedByPlugin := make(map[string]metricTypes)
@@ -728,6 +986,20 @@ func (p *pluginControl) getMetricsAndCollectors(requested []core.RequestedMetric
 	for _, r := range requested {
 		// get all metric types available in metricCatalog which fulfill the requested namespace and version (if ver <=0 the latest version will be taken)
 		newMetrics, err := p.metricCatalog.GetMetrics(r.Namespace(), r.Version())
+		if q, ok := r.(core.CatalogQuery); ok {
+			// a catalog query matching nothing is not an error, matching
+			// metrics may be exposed by a collector plugin loaded later on
+			if err != nil {
+				controlLogger.WithFields(log.Fields{
+					"_block": "control",
+					"action": "expanding-catalog-query",
+					"query":  r.Namespace(),
+					"err":    err,
+				}).Debug("catalog query does not match any metric")
+				continue
+			}
+			newMetrics = filterMetricsByTags(newMetrics, q.QueryTags())
+		}
 		if err != nil {
 			log.WithFields(log.Fields{
 				"_block": "control",
@@ -834,6 +1106,56 @@ func (p *pluginControl) PluginCatalog() core.PluginCatalog {
 	return plugins
 }
 
+// GetPluginContentTypes returns the content types accepted and returned by a
+// loaded plugin in priority order. A version below 1 selects the latest
+// loaded version of the plugin.
+func (p *pluginControl) GetPluginContentTypes(name string, pluginType core.PluginType, version int) ([]string, []string, error) {
+	var lp *loadedPlugin
+	for _, l := range p.pluginManager.all() {
+		if l.Name() != name || l.TypeName() != pluginType.String() {
+			continue
+		}
+		if l.Version() == version || (version < 1 && (lp == nil || l.Version() > lp.Version())) {
+			lp = l
+		}
+	}
+	if lp == nil {
+		return nil, nil, ErrLoadedPluginNotFound
+	}
+	return lp.Meta.AcceptedContentTypes, lp.Meta.ReturnedContentTypes, nil
+}
+
+// LatestPluginVersion returns the version of the latest loaded plugin of the
+// given type and name
+func (p *pluginControl) LatestPluginVersion(typeName, name string) (int, error) {
+	lp, err := p.pluginManager.get(fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", typeName, name, -1))
+	if err != nil {
+		return 0, err
+	}
+	return lp.Version(), nil
+}
+
+// LatestMetricVersion returns the version of the latest metrics cataloged at
+// the given namespace. A namespace matching metrics of several versions, such
+// as one with a wildcard spanning plugins, has no single version.
+func (p *pluginControl) LatestMetricVersion(ns core.Namespace) (int, error) {
+	mts, err := p.metricCatalog.GetMetrics(ns, -1)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for _, mt := range mts {
+		if version != 0 && mt.Version() != version {
+			return 0, ErrAmbiguousMetricVersion
+		}
+		version = mt.Version()
+	}
+	if version == 0 {
+		return 0, errorMetricNotFound(ns.String())
+	}
+	return version, nil
+}
+
 // AvailablePlugins returns pointers to all the running plugins in the pools
 // NOTE: The returned data from this function should be considered constant and read only
 func (p *pluginControl) AvailablePlugins() []core.AvailablePlugin {
@@ -924,6 +1246,12 @@ func (p *pluginControl) MetricExists(mns core.Namespace, ver int) bool {
 // of metrics and errors.  If an error is encountered no metrics will be
 // returned.
 func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]string) (metrics []core.Metric, errs []error) {
+	return p.CollectMetricsTimeout(id, allTags, 0)
+}
+
+// CollectMetricsTimeout is CollectMetrics with the timeout of the calls to the
+// collector plugins overridden when greater than 0.
+func (p *pluginControl) CollectMetricsTimeout(id string, allTags map[string]map[string]string, timeout time.Duration) (metrics []core.Metric, errs []error) {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -977,7 +1305,11 @@ func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]
 		wg.Add(1)
 
 		go func(pluginKey string, mt []core.Metric) {
-			mts, err := p.pluginRunner.AvailablePlugins().collectMetrics(pluginKey, mt, id)
+			if err := p.pluginRunner.wakePlugin(pluginKey); err != nil {
+				cError <- err
+				return
+			}
+			mts, err := p.pluginRunner.AvailablePlugins().collectMetrics(pluginKey, mt, id, timeout)
 			if err != nil {
 				cError <- err
 			} else {
@@ -1010,6 +1342,11 @@ func (p *pluginControl) CollectMetrics(id string, allTags map[string]map[string]
 	close(cMetrics)
 	close(cError)
 
+	// Metrics served by remote instances are collected through their proxies
+	rmts, rerrs := p.metricProxies.collectMetrics(id, allTags)
+	metrics = append(metrics, rmts...)
+	errs = append(errs, rerrs...)
+
 	if len(errs) > 0 {
 		return nil, errs
 	}
@@ -1066,6 +1403,12 @@ func (p *pluginControl) StreamMetrics(
 
 // PublishMetrics
 func (p *pluginControl) PublishMetrics(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) []error {
+	return p.PublishMetricsTimeout(metrics, config, taskID, pluginName, pluginVersion, 0)
+}
+
+// PublishMetricsTimeout is PublishMetrics with the timeout of the call to the
+// publisher plugin overridden when greater than 0.
+func (p *pluginControl) PublishMetricsTimeout(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int, timeout time.Duration) []error {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -1082,11 +1425,21 @@ func (p *pluginControl) PublishMetrics(metrics []core.Metric, config map[string]
 		merged[k] = v
 	}
 
-	return p.pluginRunner.AvailablePlugins().publishMetrics(metrics, pluginName, pluginVersion, merged, taskID)
+	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", core.PublisherPluginType.String(), pluginName, pluginVersion)
+	if err := p.pluginRunner.wakePlugin(key); err != nil {
+		return []error{err}
+	}
+	return p.pluginRunner.AvailablePlugins().publishMetrics(metrics, pluginName, pluginVersion, merged, taskID, timeout)
 }
 
 // ProcessMetrics
 func (p *pluginControl) ProcessMetrics(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int) ([]core.Metric, []error) {
+	return p.ProcessMetricsTimeout(metrics, config, taskID, pluginName, pluginVersion, 0)
+}
+
+// ProcessMetricsTimeout is ProcessMetrics with the timeout of the call to the
+// processor plugin overridden when greater than 0.
+func (p *pluginControl) ProcessMetricsTimeout(metrics []core.Metric, config map[string]ctypes.ConfigValue, taskID, pluginName string, pluginVersion int, timeout time.Duration) ([]core.Metric, []error) {
 	// If control is not started we don't want tasks to be able to
 	// go through a workflow.
 	if !p.Started {
@@ -1103,7 +1456,11 @@ func (p *pluginControl) ProcessMetrics(metrics []core.Metric, config map[string]
 		merged[k] = v
 	}
 
-	return p.pluginRunner.AvailablePlugins().processMetrics(metrics, pluginName, pluginVersion, merged, taskID)
+	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", core.ProcessorPluginType.String(), pluginName, pluginVersion)
+	if err := p.pluginRunner.wakePlugin(key); err != nil {
+		return nil, []error{err}
+	}
+	return p.pluginRunner.AvailablePlugins().processMetrics(metrics, pluginName, pluginVersion, merged, taskID, timeout)
 }
 
 func (p *pluginControl) SetAutodiscoverPaths(paths []string) {
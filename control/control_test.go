@@ -38,6 +38,7 @@ import (
 	"github.com/intelsdi-x/gomit"
 	"github.com/intelsdi-x/snap/control/fixtures"
 	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
 	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
 	"github.com/intelsdi-x/snap/control/strategy"
 	"github.com/intelsdi-x/snap/core"
@@ -75,6 +76,7 @@ func (m *MockPluginManagerBadSwap) teardown()                                  {
 func (m *MockPluginManagerBadSwap) GetPluginConfig() *pluginConfig             { return nil }
 func (m *MockPluginManagerBadSwap) SetPluginConfig(*pluginConfig)              {}
 func (m *MockPluginManagerBadSwap) SetPluginTags(map[string]map[string]string) {}
+func (m *MockPluginManagerBadSwap) SetPluginTimeouts(pluginTimeouts)           {}
 func (m *MockPluginManagerBadSwap) AddStandardAndWorkflowTags(met core.Metric, allTags map[string]map[string]string) core.Metric {
 	return nil
 }
@@ -82,6 +84,7 @@ func (m *MockPluginManagerBadSwap) SetPluginLoadTimeout(int)         {}
 func (m *MockPluginManagerBadSwap) SetMetricCatalog(catalogsMetrics) {}
 func (m *MockPluginManagerBadSwap) SetEmitter(gomit.Emitter)         {}
 func (m *MockPluginManagerBadSwap) GenerateArgs(int) plugin.Arg      { return plugin.Arg{} }
+func (m *MockPluginManagerBadSwap) PluginTLS() client.GRPCSecurity   { return client.GRPCSecurity{} }
 
 func (m *MockPluginManagerBadSwap) all() map[string]*loadedPlugin {
 	return m.loadedPlugins.table
@@ -340,6 +343,47 @@ func (l *listenToPluginEvent) HandleGomitEvent(e gomit.Event) {
 	}
 }
 
+func TestHotSwap(t *testing.T) {
+	if fixtures.SnapPath == "" {
+		t.Fatal("SNAP_PATH not set. Cannot test swapping plugins.")
+	}
+	Convey("Given a loaded plugin", t, func() {
+		c := New(getTestConfig())
+		So(c.Start(), ShouldBeNil)
+		defer c.Stop()
+		_, err := load(c, fixtures.PluginPathMock1)
+		So(err, ShouldBeNil)
+		out := c.PluginCatalog()[0]
+
+		Convey("a newer version of the plugin is swapped in", func() {
+			rp, err := core.NewRequestedPlugin(fixtures.PluginPathMock2, GetDefaultConfig().TempDirPath, nil)
+			So(err, ShouldBeNil)
+			in, serr := c.HotSwap(rp, out)
+			So(serr, ShouldBeNil)
+			So(in.Version(), ShouldEqual, 2)
+			So(c.PluginCatalog(), ShouldHaveLength, 1)
+			So(c.PluginCatalog()[0].Version(), ShouldEqual, 2)
+
+			Convey("and an older version cannot be swapped back in", func() {
+				rp, err := core.NewRequestedPlugin(fixtures.PluginPathMock1, GetDefaultConfig().TempDirPath, nil)
+				So(err, ShouldBeNil)
+				_, serr := c.HotSwap(rp, c.PluginCatalog()[0])
+				So(serr, ShouldNotBeNil)
+				So(serr.Error(), ShouldEqual, ErrSwapNotLatest.Error())
+				So(c.PluginCatalog(), ShouldHaveLength, 1)
+				So(c.PluginCatalog()[0].Version(), ShouldEqual, 2)
+			})
+		})
+		Convey("a plugin not loaded cannot be swapped out", func() {
+			rp, err := core.NewRequestedPlugin(fixtures.PluginPathMock2, GetDefaultConfig().TempDirPath, nil)
+			So(err, ShouldBeNil)
+			_, serr := c.HotSwap(rp, &loadedPlugin{Type: plugin.CollectorPluginType, Meta: plugin.PluginMeta{Name: "mock", Version: 3}})
+			So(serr, ShouldNotBeNil)
+			So(serr.Error(), ShouldEqual, ErrPluginNotFound.Error())
+		})
+	})
+}
+
 var (
 	AciPath = path.Join(strings.TrimRight(fixtures.SnapPath, "build"), "pkg/unpackage/")
 	AciFile = "snap-collector-plugin-mock1.darwin-x86_64.aci"
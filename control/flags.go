@@ -47,6 +47,11 @@ var (
 		Usage:  "Auto discover paths separated by colons.",
 		EnvVar: "SNAP_AUTODISCOVER_PATH",
 	}
+	flAutoDiscoverWatch = cli.BoolFlag{
+		Name:   "auto-discover-watch",
+		Usage:  "Watch the auto discover paths, loading the plugins dropped in them while running",
+		EnvVar: "SNAP_AUTODISCOVER_WATCH",
+	}
 	flKeyringPaths = cli.StringFlag{
 		Name:   "keyring-paths, k",
 		Usage:  "Keyring paths for signing verification separated by colons",
@@ -76,5 +81,5 @@ var (
 		EnvVar: "SNAP_TEMP_DIR_PATH",
 	}
 
-	Flags = []cli.Flag{flNumberOfPLs, flPluginLoadTimeout, flAutoDiscover, flPluginTrust, flKeyringPaths, flCache, flControlRpcPort, flControlRpcAddr, flTempDirPath}
+	Flags = []cli.Flag{flNumberOfPLs, flPluginLoadTimeout, flAutoDiscover, flAutoDiscoverWatch, flPluginTrust, flKeyringPaths, flCache, flControlRpcPort, flControlRpcAddr, flTempDirPath}
 )
@@ -0,0 +1,93 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
+	"github.com/intelsdi-x/snap/core"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLatestVersions(t *testing.T) {
+	newPlugin := func(typ plugin.PluginType, name string, version int) *loadedPlugin {
+		lp := &loadedPlugin{Type: typ, ConfigPolicy: cpolicy.New()}
+		lp.Meta.Name = name
+		lp.Meta.Version = version
+		return lp
+	}
+	Convey("Latest versions", t, func() {
+		pm := newPluginManager()
+		c := &pluginControl{
+			pluginManager: pm,
+			metricCatalog: newMetricCatalog(),
+		}
+		mock2 := newPlugin(plugin.CollectorPluginType, "mock", 2)
+		mock5 := newPlugin(plugin.CollectorPluginType, "mock", 5)
+		other := newPlugin(plugin.CollectorPluginType, "other", 1)
+		for _, lp := range []*loadedPlugin{
+			mock2,
+			mock5,
+			other,
+			newPlugin(plugin.ProcessorPluginType, "passthru", 1),
+			newPlugin(plugin.ProcessorPluginType, "passthru", 3),
+		} {
+			So(pm.loadedPlugins.add(lp), ShouldBeNil)
+		}
+		c.metricCatalog.Add(newMetricType(core.NewNamespace("intel", "mock", "foo"), time.Now(), mock2))
+		c.metricCatalog.Add(newMetricType(core.NewNamespace("intel", "mock", "foo"), time.Now(), mock5))
+		c.metricCatalog.Add(newMetricType(core.NewNamespace("intel", "mock", "bar"), time.Now(), other))
+
+		Convey("of a plugin", func() {
+			v, err := c.LatestPluginVersion("processor", "passthru")
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 3)
+			_, err = c.LatestPluginVersion("publisher", "file")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("of a metric", func() {
+			v, err := c.LatestMetricVersion(core.NewNamespace("intel", "mock", "foo"))
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 5)
+		})
+		Convey("of a namespace matching metrics of several versions", func() {
+			_, err := c.LatestMetricVersion(core.NewNamespace("intel", "mock", "*"))
+			So(err, ShouldEqual, ErrAmbiguousMetricVersion)
+		})
+		Convey("satisfying the dependencies of a plugin", func() {
+			md := &core.PluginMetadata{Name: "filter", Requires: []core.PluginDependency{
+				{Type: "collector", Name: "mock", Version: ">=5"},
+				{Type: "processor", Name: "passthru"},
+			}}
+			So(checkDependencies(pm, md), ShouldBeNil)
+			md.Requires[0].Version = ">5"
+			So(checkDependencies(pm, md), ShouldNotBeNil)
+			md.Requires[0].Version = ""
+			md.Requires = append(md.Requires, core.PluginDependency{Type: "publisher", Name: "file"})
+			So(checkDependencies(pm, md), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,173 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// cfsPeriod is the CFS scheduler period, in microseconds, over which the
+	// CPU quota of a plugin is enforced
+	cfsPeriod = 100000
+)
+
+var (
+	// cgroupRoot is where the cgroup (v1) controllers are mounted
+	cgroupRoot = "/sys/fs/cgroup"
+
+	ErrPluginLimitsUnsupported = errors.New("plugin resource limits require the cpu and memory cgroup controllers")
+)
+
+// PluginLimits caps the CPU and memory used by each subprocess of a plugin.
+// The limits are enforced by placing the subprocess in its own cgroups when
+// it is launched.
+type PluginLimits struct {
+	// CPUs is the number of CPUs, possibly fractional, the plugin may use
+	CPUs float64 `json:"cpus,omitempty"yaml:"cpus"`
+	// MemoryMB is the memory, in megabytes, the plugin may use. A plugin
+	// exceeding it is killed by the kernel
+	MemoryMB int `json:"memory_mb,omitempty"yaml:"memory_mb"`
+}
+
+// pluginCgroups are the cgroups created for a plugin subprocess
+type pluginCgroups struct {
+	cpu    string
+	memory string
+}
+
+// containerArgs returns the flags of the run command enforcing the limits on
+// a plugin run in a container, whose runtime then manages the cgroups.
+func (l *PluginLimits) containerArgs() []string {
+	if l == nil {
+		return nil
+	}
+	var args []string
+	if l.CPUs > 0 {
+		args = append(args, "--cpus="+strconv.FormatFloat(l.CPUs, 'f', -1, 64))
+	}
+	if l.MemoryMB > 0 {
+		args = append(args, "--memory="+strconv.Itoa(l.MemoryMB)+"m")
+	}
+	return args
+}
+
+// apply places the process of the given plugin in new cgroups enforcing the
+// limits. It returns nil when no limit is set.
+func (l *PluginLimits) apply(name string, pid int) (*pluginCgroups, error) {
+	if l == nil || (l.CPUs <= 0 && l.MemoryMB <= 0) {
+		return nil, nil
+	}
+	if pid <= 0 {
+		return nil, fmt.Errorf("unable to limit plugin %s: process not started", name)
+	}
+	group := filepath.Join("snap", fmt.Sprintf("%s-%d", name, pid))
+	cg := &pluginCgroups{}
+	if l.CPUs > 0 {
+		dir, err := createCgroup("cpu", group)
+		if err != nil {
+			return nil, err
+		}
+		cg.cpu = dir
+		quota := strconv.Itoa(int(l.CPUs * cfsPeriod))
+		if err := writeCgroup(dir, "cpu.cfs_period_us", strconv.Itoa(cfsPeriod)); err != nil {
+			cg.remove()
+			return nil, err
+		}
+		if err := writeCgroup(dir, "cpu.cfs_quota_us", quota); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	if l.MemoryMB > 0 {
+		dir, err := createCgroup("memory", group)
+		if err != nil {
+			cg.remove()
+			return nil, err
+		}
+		cg.memory = dir
+		limit := strconv.FormatInt(int64(l.MemoryMB)<<20, 10)
+		if err := writeCgroup(dir, "memory.limit_in_bytes", limit); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	for _, dir := range []string{cg.cpu, cg.memory} {
+		if dir == "" {
+			continue
+		}
+		if err := writeCgroup(dir, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	return cg, nil
+}
+
+// memoryExceeded returns true when the plugin reached its memory limit, the
+// kernel failing its allocations or killing it.
+func (cg *pluginCgroups) memoryExceeded() bool {
+	if cg == nil || cg.memory == "" {
+		return false
+	}
+	b, err := ioutil.ReadFile(filepath.Join(cg.memory, "memory.failcnt"))
+	if err != nil {
+		return false
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	return err == nil && n > 0
+}
+
+// remove deletes the cgroups, once the plugin process exited
+func (cg *pluginCgroups) remove() {
+	if cg == nil {
+		return
+	}
+	for _, dir := range []string{cg.cpu, cg.memory} {
+		if dir != "" {
+			os.Remove(dir)
+		}
+	}
+}
+
+func createCgroup(controller, group string) (string, error) {
+	root := filepath.Join(cgroupRoot, controller)
+	if _, err := os.Stat(filepath.Join(root, "cgroup.procs")); err != nil {
+		return "", ErrPluginLimitsUnsupported
+	}
+	dir := filepath.Join(root, group)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("unable to create %s cgroup: %v", controller, err)
+	}
+	return dir, nil
+}
+
+func writeCgroup(dir, file, value string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("unable to set %s: %v", file, err)
+	}
+	return nil
+}
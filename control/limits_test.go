@@ -0,0 +1,86 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginLimits(t *testing.T) {
+	Convey("Plugin limits", t, func() {
+		root, err := ioutil.TempDir("", "snap-cgroup")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+		cgroupRoot = root
+		defer func() { cgroupRoot = "/sys/fs/cgroup" }()
+
+		Convey("are not applied when not set", func() {
+			var l *PluginLimits
+			cg, err := l.apply("psutil", 42)
+			So(err, ShouldBeNil)
+			So(cg, ShouldBeNil)
+			cg, err = (&PluginLimits{}).apply("psutil", 42)
+			So(err, ShouldBeNil)
+			So(cg, ShouldBeNil)
+		})
+		Convey("are given to the runtime of containerized plugins", func() {
+			var l *PluginLimits
+			So(l.containerArgs(), ShouldBeEmpty)
+			So((&PluginLimits{CPUs: 0.5, MemoryMB: 128}).containerArgs(), ShouldResemble, []string{"--cpus=0.5", "--memory=128m"})
+		})
+		Convey("fail without the cgroup controllers", func() {
+			_, err := (&PluginLimits{CPUs: 0.5}).apply("psutil", 42)
+			So(err, ShouldEqual, ErrPluginLimitsUnsupported)
+		})
+		Convey("place the plugin process in its cgroups", func() {
+			for _, c := range []string{"cpu", "memory"} {
+				So(os.MkdirAll(filepath.Join(root, c), 0755), ShouldBeNil)
+				So(ioutil.WriteFile(filepath.Join(root, c, "cgroup.procs"), nil, 0644), ShouldBeNil)
+			}
+			cg, err := (&PluginLimits{CPUs: 0.5, MemoryMB: 64}).apply("psutil", 42)
+			So(err, ShouldBeNil)
+			So(cg.cpu, ShouldEqual, filepath.Join(root, "cpu", "snap", "psutil-42"))
+			So(cg.memory, ShouldEqual, filepath.Join(root, "memory", "snap", "psutil-42"))
+
+			read := func(dir, file string) string {
+				b, err := ioutil.ReadFile(filepath.Join(dir, file))
+				So(err, ShouldBeNil)
+				return string(b)
+			}
+			So(read(cg.cpu, "cpu.cfs_quota_us"), ShouldEqual, "50000")
+			So(read(cg.cpu, "cgroup.procs"), ShouldEqual, "42")
+			So(read(cg.memory, "memory.limit_in_bytes"), ShouldEqual, "67108864")
+			So(read(cg.memory, "cgroup.procs"), ShouldEqual, "42")
+
+			Convey("and report the memory limit being reached", func() {
+				So(cg.memoryExceeded(), ShouldBeFalse)
+				So(ioutil.WriteFile(filepath.Join(cg.memory, "memory.failcnt"), []byte("3\n"), 0644), ShouldBeNil)
+				So(cg.memoryExceeded(), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,227 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/grpc/controlproxy"
+)
+
+// MetricProxy routes the metrics found under a namespace to the control
+// service of a remote snapteld, letting a hub schedule tasks whose metrics
+// are collected on edge agents.
+type MetricProxy struct {
+	// Namespace is the namespace subtree served by the remote instance (e.g. /intel/edge)
+	Namespace string `json:"namespace"yaml:"namespace"`
+	// Address is the host:port the remote control service listens on
+	Address string `json:"address"yaml:"address"`
+}
+
+// remoteMetricManager is the part of the control service used to collect
+// metrics through a remote instance
+type remoteMetricManager interface {
+	CollectMetrics(string, map[string]map[string]string) ([]core.Metric, []error)
+	ValidateDeps([]core.RequestedMetric, []core.SubscribedPlugin, *cdata.ConfigDataTree) []serror.SnapError
+	SubscribeDeps(string, []core.RequestedMetric, []core.SubscribedPlugin, *cdata.ConfigDataTree) []serror.SnapError
+	UnsubscribeDeps(string) []serror.SnapError
+}
+
+type metricProxy struct {
+	sync.Mutex
+	prefix  []string
+	address string
+	client  remoteMetricManager
+}
+
+// manager returns the client of the remote instance, connecting to it on
+// first use so a hub does not depend on its edge agents being up to start.
+func (m *metricProxy) manager() (remoteMetricManager, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.client != nil {
+		return m.client, nil
+	}
+	host, port, err := net.SplitHostPort(m.address)
+	if err != nil {
+		return nil, err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, err
+	}
+	c, err := controlproxy.New(host, p)
+	if err != nil {
+		return nil, err
+	}
+	m.client = c
+	return m.client, nil
+}
+
+// serves reports whether the requested namespace lies in the subtree of the proxy
+func (m *metricProxy) serves(ns core.Namespace) bool {
+	if len(ns) < len(m.prefix) {
+		return false
+	}
+	for i, p := range m.prefix {
+		if ns[i].Value != p {
+			return false
+		}
+	}
+	return true
+}
+
+type metricProxies struct {
+	sync.Mutex
+	proxies []*metricProxy
+	// proxies subscribed to by each task
+	subscribed map[string][]*metricProxy
+}
+
+func newMetricProxies(cfg []*MetricProxy) *metricProxies {
+	m := &metricProxies{subscribed: map[string][]*metricProxy{}}
+	for _, c := range cfg {
+		m.proxies = append(m.proxies, &metricProxy{
+			prefix:  strings.Split(strings.Trim(c.Namespace, "/"), "/"),
+			address: c.Address,
+		})
+	}
+	return m
+}
+
+// split separates the requested metrics collected locally from those
+// collected through a proxy.
+func (m *metricProxies) split(requested []core.RequestedMetric) ([]core.RequestedMetric, map[*metricProxy][]core.RequestedMetric) {
+	if len(m.proxies) == 0 {
+		return requested, nil
+	}
+	local := []core.RequestedMetric{}
+	remote := map[*metricProxy][]core.RequestedMetric{}
+	for _, r := range requested {
+		proxied := false
+		for _, p := range m.proxies {
+			if p.serves(r.Namespace()) {
+				remote[p] = append(remote[p], r)
+				proxied = true
+				break
+			}
+		}
+		if !proxied {
+			local = append(local, r)
+		}
+	}
+	return local, remote
+}
+
+func (m *metricProxies) validateDeps(remote map[*metricProxy][]core.RequestedMetric) []serror.SnapError {
+	var serrs []serror.SnapError
+	for p, requested := range remote {
+		mgr, err := p.manager()
+		if err != nil {
+			serrs = append(serrs, proxyError(p, err))
+			continue
+		}
+		serrs = append(serrs, mgr.ValidateDeps(requested, nil, nil)...)
+	}
+	return serrs
+}
+
+func (m *metricProxies) subscribeDeps(id string, remote map[*metricProxy][]core.RequestedMetric) []serror.SnapError {
+	var serrs []serror.SnapError
+	subscribed := []*metricProxy{}
+	for p, requested := range remote {
+		mgr, err := p.manager()
+		if err != nil {
+			serrs = append(serrs, proxyError(p, err))
+			continue
+		}
+		if errs := mgr.SubscribeDeps(id, requested, nil, nil); len(errs) > 0 {
+			serrs = append(serrs, errs...)
+			continue
+		}
+		subscribed = append(subscribed, p)
+	}
+	m.Lock()
+	m.subscribed[id] = subscribed
+	m.Unlock()
+	return serrs
+}
+
+func (m *metricProxies) unsubscribeDeps(id string) []serror.SnapError {
+	m.Lock()
+	subscribed := m.subscribed[id]
+	delete(m.subscribed, id)
+	m.Unlock()
+	var serrs []serror.SnapError
+	for _, p := range subscribed {
+		mgr, err := p.manager()
+		if err != nil {
+			serrs = append(serrs, proxyError(p, err))
+			continue
+		}
+		serrs = append(serrs, mgr.UnsubscribeDeps(id)...)
+	}
+	return serrs
+}
+
+func (m *metricProxies) collectMetrics(id string, allTags map[string]map[string]string) ([]core.Metric, []error) {
+	m.Lock()
+	subscribed := m.subscribed[id]
+	m.Unlock()
+	var metrics []core.Metric
+	var errs []error
+	for _, p := range subscribed {
+		mgr, err := p.manager()
+		if err != nil {
+			errs = append(errs, proxyError(p, err))
+			continue
+		}
+		mts, rerrs := mgr.CollectMetrics(id, allTags)
+		if len(rerrs) > 0 {
+			controlLogger.WithFields(log.Fields{
+				"_block":  "proxy-collect-metrics",
+				"task-id": id,
+				"address": p.address,
+			}).Warn("error collecting metrics through proxy")
+			errs = append(errs, rerrs...)
+			continue
+		}
+		metrics = append(metrics, mts...)
+	}
+	return metrics, errs
+}
+
+func proxyError(p *metricProxy, err error) serror.SnapError {
+	se := serror.New(fmt.Errorf("Unable to reach metric proxy %s: %v", p.address, err))
+	se.SetFields(map[string]interface{}{
+		"namespace": "/" + strings.Join(p.prefix, "/"),
+		"address":   p.address,
+	})
+	return se
+}
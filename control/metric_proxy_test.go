@@ -0,0 +1,103 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockRemoteMetricManager struct {
+	subscribed map[string][]core.RequestedMetric
+}
+
+func (m *mockRemoteMetricManager) CollectMetrics(id string, _ map[string]map[string]string) ([]core.Metric, []error) {
+	mts := []core.Metric{}
+	for _, r := range m.subscribed[id] {
+		mts = append(mts, plugin.MetricType{Namespace_: r.Namespace(), Data_: 1})
+	}
+	return mts, nil
+}
+
+func (m *mockRemoteMetricManager) ValidateDeps([]core.RequestedMetric, []core.SubscribedPlugin, *cdata.ConfigDataTree) []serror.SnapError {
+	return nil
+}
+
+func (m *mockRemoteMetricManager) SubscribeDeps(id string, requested []core.RequestedMetric, _ []core.SubscribedPlugin, _ *cdata.ConfigDataTree) []serror.SnapError {
+	m.subscribed[id] = requested
+	return nil
+}
+
+func (m *mockRemoteMetricManager) UnsubscribeDeps(id string) []serror.SnapError {
+	delete(m.subscribed, id)
+	return nil
+}
+
+func TestMetricProxies(t *testing.T) {
+	Convey("Metric proxies", t, func() {
+		proxies := newMetricProxies([]*MetricProxy{{Namespace: "/intel/edge", Address: "10.0.0.2:8082"}})
+		remote := &mockRemoteMetricManager{subscribed: map[string][]core.RequestedMetric{}}
+		proxies.proxies[0].client = remote
+
+		requested := []core.RequestedMetric{
+			plugin.MetricType{Namespace_: core.NewNamespace("intel", "edge", "cpu")},
+			plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", "foo")},
+			plugin.MetricType{Namespace_: core.NewNamespace("intel", "edgy")},
+		}
+
+		Convey("route the served subtree to the remote instance", func() {
+			local, rmt := proxies.split(requested)
+			So(local, ShouldHaveLength, 2)
+			So(rmt[proxies.proxies[0]], ShouldHaveLength, 1)
+			So(rmt[proxies.proxies[0]][0].Namespace().String(), ShouldEqual, "/intel/edge/cpu")
+		})
+		Convey("keep everything local without proxies", func() {
+			local, rmt := newMetricProxies(nil).split(requested)
+			So(local, ShouldHaveLength, 3)
+			So(rmt, ShouldBeEmpty)
+		})
+		Convey("collect through the remote subscription", func() {
+			_, rmt := proxies.split(requested)
+			So(proxies.subscribeDeps("task-1", rmt), ShouldBeEmpty)
+			mts, errs := proxies.collectMetrics("task-1", nil)
+			So(errs, ShouldBeEmpty)
+			So(mts, ShouldHaveLength, 1)
+			So(mts[0].Namespace().String(), ShouldEqual, "/intel/edge/cpu")
+
+			So(proxies.unsubscribeDeps("task-1"), ShouldBeEmpty)
+			So(remote.subscribed, ShouldBeEmpty)
+			mts, _ = proxies.collectMetrics("task-1", nil)
+			So(mts, ShouldBeEmpty)
+		})
+		Convey("report an invalid address", func() {
+			p := newMetricProxies([]*MetricProxy{{Namespace: "/intel/edge", Address: "nowhere"}})
+			_, rmt := p.split(requested)
+			So(p.validateDeps(rmt), ShouldHaveLength, 1)
+		})
+	})
+}
@@ -147,6 +147,10 @@ type metricType struct {
 	timestamp          time.Time
 	description        string
 	unit               string
+	conflicts          []core.NamespaceConflict
+	// merged are the metrics of the other plugins collected along with
+	// this one under the merge namespace policy
+	merged []*metricType
 }
 
 type metric struct {
@@ -275,6 +279,7 @@ type catalogedPlugin struct {
 	path         string
 	loadedTime   time.Time
 	configPolicy *cpolicy.ConfigPolicy
+	metadata     *core.PluginMetadata
 }
 
 func (cp *catalogedPlugin) TypeName() string {
@@ -309,6 +314,14 @@ func (cp *catalogedPlugin) Policy() *cpolicy.ConfigPolicy {
 	return cp.configPolicy
 }
 
+func (cp *catalogedPlugin) Metadata() *core.PluginMetadata {
+	return cp.metadata
+}
+
+func (cp *catalogedPlugin) Info() core.PluginInfo {
+	return cp.metadata.Info()
+}
+
 func newCatalogedPlugin(lp *loadedPlugin) core.CatalogedPlugin {
 	cp := cpolicy.New()
 	for _, keyNode := range lp.Policy().GetAll() {
@@ -335,6 +348,7 @@ func newCatalogedPlugin(lp *loadedPlugin) core.CatalogedPlugin {
 		path:         lp.PluginPath(),
 		loadedTime:   lp.LoadedTime,
 		configPolicy: cp,
+		metadata:     lp.Metadata(),
 	}
 }
 
@@ -342,13 +356,18 @@ type metricCatalog struct {
 	tree  *MTTrie
 	mutex *sync.Mutex
 	keys  []string
+	// advertised holds the metrics advertised by the loaded collectors by
+	// namespace, including the ones shadowed by the namespace policies
+	advertised map[string][]*metricType
+	policies   map[string]*NamespacePolicy
 }
 
 func newMetricCatalog() *metricCatalog {
 	return &metricCatalog{
-		tree:  NewMTTrie(),
-		mutex: &sync.Mutex{},
-		keys:  []string{},
+		tree:       NewMTTrie(),
+		mutex:      &sync.Mutex{},
+		keys:       []string{},
+		advertised: map[string][]*metricType{},
 	}
 }
 
@@ -387,8 +406,7 @@ func (mc *metricCatalog) AddLoadedMetricType(lp *loadedPlugin, mt core.Metric) e
 		description:        mt.Description(),
 		unit:               mt.Unit(),
 	}
-	mc.Add(&newMt)
-	return nil
+	return mc.addAdvertised(&newMt)
 }
 
 // RmUnloadedPluginMetrics removes plugin metrics which was unloaded,
@@ -397,6 +415,7 @@ func (mc *metricCatalog) RmUnloadedPluginMetrics(lp *loadedPlugin) {
 	mc.mutex.Lock()
 	defer mc.mutex.Unlock()
 	mc.tree.DeleteByPlugin(lp)
+	mc.removeAdvertised(lp)
 
 	// Update metric catalog keys
 	mc.keys = []string{}
@@ -459,6 +478,7 @@ func (mc *metricCatalog) GetMetric(requested core.Namespace, version int) (*metr
 		unit:               catalogedmt.Unit(),
 		description:        catalogedmt.Description(),
 		subscriptions:      catalogedmt.SubscriptionCount(),
+		conflicts:          catalogedmt.Conflicts(),
 	}
 	return returnedmt, nil
 }
@@ -484,7 +504,7 @@ func (mc *metricCatalog) GetMetrics(requested core.Namespace, version int) ([]*m
 			}).Error("error getting metric")
 			return nil, err
 		}
-		for _, catalogedmt := range catalogedmts {
+		for _, catalogedmt := range withMerged(catalogedmts) {
 			ns := catalogedmt.Namespace()
 
 			if isDynamic, _ := ns.IsDynamic(); isDynamic {
@@ -507,6 +527,7 @@ func (mc *metricCatalog) GetMetrics(requested core.Namespace, version int) ([]*m
 				unit:               catalogedmt.Unit(),
 				description:        catalogedmt.Description(),
 				subscriptions:      catalogedmt.SubscriptionCount(),
+				conflicts:          catalogedmt.Conflicts(),
 			}
 			returnedmts = append(returnedmts, returnedmt)
 		}
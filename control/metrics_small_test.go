@@ -49,6 +49,25 @@ func TestAddTagsFromWorkflow(t *testing.T) {
 	})
 }
 
+func TestAddTagsPrecedence(t *testing.T) {
+	hostnameReader = &mockHostnameReader{}
+	Convey("Tags defined for a more specific namespace take precedence", t, func() {
+		p := newPluginManager()
+		allTags := map[string]map[string]string{
+			"/":   {"dc": "default", "role": "agent"},
+			foo:   {"dc": "foo"},
+			bar:   {"dc": "foobar"},
+			"/tu": {"dc": "tu"},
+		}
+		m := plugin.MetricType{Namespace_: core.NewNamespace("intel", "foo", "bar")}
+		for i := 0; i < 10; i++ {
+			tags := p.AddStandardAndWorkflowTags(m, allTags).Tags()
+			So(tags["dc"], ShouldEqual, "foobar")
+			So(tags["role"], ShouldEqual, "agent")
+		}
+	})
+}
+
 func TestContainsTuplePositive(t *testing.T) {
 	Convey("when tuple contains two items", t, func() {
 		dut := "(host0;host1)"
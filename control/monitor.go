@@ -37,7 +37,10 @@ type monitor struct {
 	State monitorState
 
 	duration time.Duration
-	quit     chan struct{}
+	// idleTimeout is the period without work after which a running plugin
+	// instance is stopped, zero keeping idle instances running
+	idleTimeout time.Duration
+	quit        chan struct{}
 }
 
 type monitorOption func(m *monitor) monitorOption
@@ -87,9 +90,15 @@ func (m *monitor) Start(availablePlugins *availablePlugins) {
 					availablePlugins.RLock()
 					for _, ap := range availablePlugins.all() {
 						go ap.CheckHealth()
+						if a, ok := ap.(*availablePlugin); ok {
+							go a.sampleUsage()
+						}
 					}
 					availablePlugins.RUnlock()
 				}()
+				if m.idleTimeout > 0 {
+					go availablePlugins.reapIdle(m.idleTimeout)
+				}
 			case <-m.quit:
 				ticker.Stop()
 				m.State = MonitorStopped
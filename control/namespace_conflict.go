@@ -0,0 +1,293 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// The policies resolving the conflicts between the collectors advertising
+// the same metric namespace
+const (
+	// NamespaceHighestVersion catalogs the metrics of all the collectors,
+	// the one of the highest version being used when no version is
+	// requested. Of the metrics of the same version, the one of the
+	// collector loaded first is kept.
+	NamespaceHighestVersion = "highest_version"
+	// NamespacePriority catalogs the metrics of the collector coming first
+	// in the priority list of the policy, the others being shadowed.
+	NamespacePriority = "priority"
+	// NamespaceReject refuses to load a collector advertising a namespace
+	// already advertised by another collector.
+	NamespaceReject = "reject"
+	// NamespaceMerge collects the metrics from all the collectors advertising
+	// them in the same version, their results being merged, e.g. for
+	// collectors sharding a large target. Versions are resolved as by
+	// highest_version.
+	NamespaceMerge = "merge"
+)
+
+// ErrBadNamespacePolicy is returned for an unknown namespace conflict policy
+var ErrBadNamespacePolicy = errors.New("unknown namespace conflict policy")
+
+// defaultNamespacePolicy applies to the namespaces no policy is set for
+var defaultNamespacePolicy = &NamespacePolicy{Policy: NamespaceHighestVersion}
+
+// NamespacePolicy sets how the conflicts between the collectors advertising
+// the same metric namespaces under a namespace prefix are resolved.
+type NamespacePolicy struct {
+	// Policy is one of highest_version, priority, reject or merge
+	Policy string `json:"policy"yaml:"policy"`
+	// Priority lists the names of the collectors by decreasing priority for
+	// the priority policy, the collectors not listed coming last
+	Priority []string `json:"priority,omitempty"yaml:"priority"`
+}
+
+// OptSetNamespacePolicies sets the namespace conflict policies by namespace
+// prefix, e.g. /intel/procfs. The policy of the longest prefix of a
+// namespace applies to it.
+func OptSetNamespacePolicies(policies map[string]*NamespacePolicy) PluginControlOpt {
+	return func(c *pluginControl) {
+		mc, ok := c.metricCatalog.(*metricCatalog)
+		if !ok {
+			return
+		}
+		valid := map[string]*NamespacePolicy{}
+		for prefix, p := range policies {
+			if p == nil {
+				continue
+			}
+			switch p.Policy {
+			case NamespaceHighestVersion, NamespacePriority, NamespaceReject, NamespaceMerge:
+				valid[prefix] = p
+			default:
+				controlLogger.WithFields(log.Fields{
+					"_block": "namespace-policies",
+					"prefix": prefix,
+					"policy": p.Policy,
+				}).Error(ErrBadNamespacePolicy)
+			}
+		}
+		mc.mutex.Lock()
+		defer mc.mutex.Unlock()
+		mc.policies = valid
+	}
+}
+
+func errorNamespaceConflict(ns string, cp core.CatalogedPlugin) error {
+	return fmt.Errorf("Metric namespace %s is already advertised by plugin %s:%s:%d", ns, cp.TypeName(), cp.Name(), cp.Version())
+}
+
+// catalogedPluginKey returns the key of the plugin, type:name:version
+func catalogedPluginKey(cp core.CatalogedPlugin) string {
+	return fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", cp.TypeName(), cp.Name(), cp.Version())
+}
+
+// policyFor returns the policy of the longest prefix of ns
+func (mc *metricCatalog) policyFor(ns core.Namespace) *NamespacePolicy {
+	policy, length := defaultNamespacePolicy, -1
+	elements := ns.Strings()
+	for prefix, p := range mc.policies {
+		pe := strings.Split(strings.Trim(prefix, "/"), "/")
+		if len(pe) == 1 && pe[0] == "" {
+			pe = nil
+		}
+		if len(pe) <= length || len(pe) > len(elements) {
+			continue
+		}
+		matches := true
+		for i, e := range pe {
+			if elements[i] != e {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			policy, length = p, len(pe)
+		}
+	}
+	return policy
+}
+
+// addAdvertised catalogs the metric m advertised by a collector, resolving
+// its conflicts with the metrics of the other collectors advertising the
+// same namespace.
+func (mc *metricCatalog) addAdvertised(m *metricType) error {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	key := m.Namespace().String()
+	policy := mc.policyFor(m.Namespace())
+	pluginKey := catalogedPluginKey(m.Plugin)
+	advertised := []*metricType{}
+	for _, a := range mc.advertised[key] {
+		if catalogedPluginKey(a.Plugin) == pluginKey && a.Version() == m.Version() {
+			// advertised again by the same plugin
+			continue
+		}
+		if policy.Policy == NamespaceReject && a.Plugin.Name() != m.Plugin.Name() {
+			return errorNamespaceConflict(key, a.Plugin)
+		}
+		advertised = append(advertised, a)
+	}
+	mc.advertised[key] = append(advertised, m)
+	mc.resolve(key, policy)
+	mc.keys = appendIfMissing(mc.keys, key)
+	return nil
+}
+
+// removeAdvertised removes the metrics advertised by the plugin cp and
+// resolves again the conflicts of their namespaces, the metrics they
+// shadowed taking their place.
+func (mc *metricCatalog) removeAdvertised(cp core.CatalogedPlugin) {
+	pluginKey := catalogedPluginKey(cp)
+	for key, mts := range mc.advertised {
+		kept := []*metricType{}
+		for _, m := range mts {
+			if catalogedPluginKey(m.Plugin) != pluginKey {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == len(mts) {
+			continue
+		}
+		if len(kept) == 0 {
+			delete(mc.advertised, key)
+			continue
+		}
+		mc.advertised[key] = kept
+		mc.resolve(key, mc.policyFor(kept[0].Namespace()))
+	}
+}
+
+// resolve catalogs the metrics advertised for the namespace key which win
+// their conflicts under policy, and records the conflicts of each metric.
+// The metrics merged into a cataloged one are collected along with it.
+func (mc *metricCatalog) resolve(key string, policy *NamespacePolicy) {
+	mts := mc.advertised[key]
+	for _, m := range mts {
+		mc.tree.RemoveMetric(*m)
+		m.merged = nil
+	}
+	winners := resolveConflicts(mts, policy)
+	merged := map[*metricType]bool{}
+	if policy.Policy == NamespaceMerge {
+		for _, m := range mts {
+			if winners[m] {
+				continue
+			}
+			for _, w := range mts {
+				if winners[w] && w.Version() == m.Version() {
+					w.merged = append(w.merged, m)
+					merged[m] = true
+					break
+				}
+			}
+		}
+	}
+	for _, m := range mts {
+		var conflicts []core.NamespaceConflict
+		for _, o := range mts {
+			if o.Plugin.Name() == m.Plugin.Name() {
+				continue
+			}
+			conflicts = append(conflicts, core.NamespaceConflict{
+				PluginName:    o.Plugin.Name(),
+				PluginVersion: o.Plugin.Version(),
+				Policy:        policy.Policy,
+				Shadowed:      !winners[o] && !merged[o],
+				Merged:        merged[o],
+			})
+		}
+		m.conflicts = conflicts
+		if winners[m] {
+			mc.tree.Add(m)
+		} else if !merged[m] {
+			log.WithFields(log.Fields{
+				"_module":        "control",
+				"_block":         "resolve-namespace-conflict",
+				"namespace":      key,
+				"policy":         policy.Policy,
+				"plugin-name":    m.Plugin.Name(),
+				"plugin-version": m.Plugin.Version(),
+			}).Warn("metric shadowed by the one of another plugin")
+		}
+	}
+}
+
+// resolveConflicts returns the metrics of mts, advertised for the same
+// namespace in the order the plugins were loaded, to catalog under policy
+func resolveConflicts(mts []*metricType, policy *NamespacePolicy) map[*metricType]bool {
+	candidates := mts
+	if policy.Policy == NamespacePriority {
+		rank := func(name string) int {
+			for i, n := range policy.Priority {
+				if n == name {
+					return i
+				}
+			}
+			return len(policy.Priority)
+		}
+		best := -1
+		for _, m := range mts {
+			if r := rank(m.Plugin.Name()); best == -1 || r < best {
+				best = r
+			}
+		}
+		candidates = nil
+		for _, m := range mts {
+			if rank(m.Plugin.Name()) == best {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+	winners := map[*metricType]bool{}
+	versions := map[int]bool{}
+	for _, m := range candidates {
+		if !versions[m.Version()] {
+			versions[m.Version()] = true
+			winners[m] = true
+		}
+	}
+	return winners
+}
+
+// withMerged returns mts followed, for each of them, by the metrics merged
+// into it
+func withMerged(mts []*metricType) []*metricType {
+	out := make([]*metricType, 0, len(mts))
+	for _, m := range mts {
+		out = append(out, m)
+		out = append(out, m.merged...)
+	}
+	return out
+}
+
+// Conflicts returns the other plugins advertising the namespace of the
+// metric.
+func (m *metricType) Conflicts() []core.NamespaceConflict {
+	return m.conflicts
+}
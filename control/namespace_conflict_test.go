@@ -0,0 +1,131 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
+	"github.com/intelsdi-x/snap/core"
+)
+
+func conflictingCollector(name string, version int) *loadedPlugin {
+	return &loadedPlugin{
+		Meta:         plugin.PluginMeta{Name: name, Version: version},
+		Type:         plugin.CollectorPluginType,
+		Details:      &pluginDetails{},
+		ConfigPolicy: cpolicy.New(),
+	}
+}
+
+func TestNamespaceConflicts(t *testing.T) {
+	ns := core.NewNamespace("intel", "cpu", "load")
+	advertise := func(mc *metricCatalog, lp *loadedPlugin) error {
+		return mc.AddLoadedMetricType(lp, &metricType{namespace: ns, version: lp.Version()})
+	}
+	Convey("Given collectors advertising the same namespace", t, func() {
+		mc := newMetricCatalog()
+		foo, bar, baz := conflictingCollector("foo", 1), conflictingCollector("bar", 2), conflictingCollector("baz", 1)
+
+		Convey("by default the highest version is used", func() {
+			So(advertise(mc, foo), ShouldBeNil)
+			So(advertise(mc, bar), ShouldBeNil)
+			So(advertise(mc, baz), ShouldBeNil)
+			mt, err := mc.GetMetric(ns, -1)
+			So(err, ShouldBeNil)
+			So(mt.Plugin.Name(), ShouldEqual, "bar")
+			So(mt.Conflicts(), ShouldResemble, []core.NamespaceConflict{
+				{PluginName: "foo", PluginVersion: 1, Policy: NamespaceHighestVersion},
+				{PluginName: "baz", PluginVersion: 1, Policy: NamespaceHighestVersion, Shadowed: true},
+			})
+			Convey("the metric of the first plugin loaded is kept for the same version", func() {
+				mt, err := mc.GetMetric(ns, 1)
+				So(err, ShouldBeNil)
+				So(mt.Plugin.Name(), ShouldEqual, "foo")
+			})
+			Convey("a shadowed metric takes over when its plugin is unloaded", func() {
+				mc.RmUnloadedPluginMetrics(foo)
+				mt, err := mc.GetMetric(ns, 1)
+				So(err, ShouldBeNil)
+				So(mt.Plugin.Name(), ShouldEqual, "baz")
+				So(mt.Conflicts(), ShouldHaveLength, 1)
+			})
+		})
+		Convey("the collector with the highest priority is used", func() {
+			mc.policies = map[string]*NamespacePolicy{
+				"/intel":     {Policy: NamespaceHighestVersion},
+				"/intel/cpu": {Policy: NamespacePriority, Priority: []string{"baz", "foo"}},
+			}
+			So(advertise(mc, foo), ShouldBeNil)
+			So(advertise(mc, bar), ShouldBeNil)
+			So(advertise(mc, baz), ShouldBeNil)
+			mts, err := mc.GetVersions(ns)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 1)
+			So(mts[0].Plugin.Name(), ShouldEqual, "baz")
+
+			mc.RmUnloadedPluginMetrics(baz)
+			mt, err := mc.GetMetric(ns, -1)
+			So(err, ShouldBeNil)
+			So(mt.Plugin.Name(), ShouldEqual, "foo")
+		})
+		Convey("the metrics of the collectors merged are all collected", func() {
+			mc.policies = map[string]*NamespacePolicy{"/intel/cpu": {Policy: NamespaceMerge}}
+			So(advertise(mc, foo), ShouldBeNil)
+			So(advertise(mc, baz), ShouldBeNil)
+			So(advertise(mc, bar), ShouldBeNil)
+			mts, err := mc.GetMetrics(ns, 1)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 2)
+			So(mts[0].Plugin.Name(), ShouldEqual, "foo")
+			So(mts[1].Plugin.Name(), ShouldEqual, "baz")
+			So(mts[0].Conflicts(), ShouldResemble, []core.NamespaceConflict{
+				{PluginName: "baz", PluginVersion: 1, Policy: NamespaceMerge, Merged: true},
+				{PluginName: "bar", PluginVersion: 2, Policy: NamespaceMerge},
+			})
+
+			mts, err = mc.GetMetrics(ns, -1)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 1)
+			So(mts[0].Plugin.Name(), ShouldEqual, "bar")
+
+			mc.RmUnloadedPluginMetrics(foo)
+			mts, err = mc.GetMetrics(ns, 1)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 1)
+			So(mts[0].Plugin.Name(), ShouldEqual, "baz")
+		})
+		Convey("a collector advertising a namespace already advertised is rejected", func() {
+			mc.policies = map[string]*NamespacePolicy{"/": {Policy: NamespaceReject}}
+			So(advertise(mc, foo), ShouldBeNil)
+			So(advertise(mc, foo), ShouldBeNil)
+			So(advertise(mc, bar), ShouldNotBeNil)
+			mt, err := mc.GetMetric(ns, -1)
+			So(err, ShouldBeNil)
+			So(mt.Plugin.Name(), ShouldEqual, "foo")
+			So(mt.Conflicts(), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,77 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// PluginPlacement describes the CPUs and NUMA node the subprocesses of a
+// plugin are bound to when they are launched.
+type PluginPlacement struct {
+	// CPUSet is a list of CPUs in the format accepted by taskset, e.g. "0-3,8"
+	CPUSet string `json:"cpuset,omitempty"yaml:"cpuset"`
+	// NUMANode binds both the CPUs and the memory of the plugin to a NUMA node
+	NUMANode *int `json:"numa_node,omitempty"yaml:"numa_node"`
+}
+
+// command returns the command line which launches the given plugin command
+// with the placement applied. The placement is applied by wrapping the
+// command with numactl when a NUMA node is given or with taskset otherwise.
+func (p *PluginPlacement) command(commands []string) ([]string, error) {
+	if p == nil || (p.CPUSet == "" && p.NUMANode == nil) {
+		return commands, nil
+	}
+	var wrapper []string
+	if p.NUMANode != nil {
+		node := strconv.Itoa(*p.NUMANode)
+		wrapper = []string{"numactl", "--cpunodebind=" + node, "--membind=" + node}
+		if p.CPUSet != "" {
+			wrapper = append(wrapper, "--physcpubind="+p.CPUSet)
+		}
+		wrapper = append(wrapper, "--")
+	} else {
+		wrapper = []string{"taskset", "--cpu-list", p.CPUSet}
+	}
+	path, err := exec.LookPath(wrapper[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply plugin placement: %v", err)
+	}
+	wrapper[0] = path
+	return append(wrapper, commands...), nil
+}
+
+// containerArgs returns the flags of the run command applying the placement
+// to a plugin run in a container.
+func (p *PluginPlacement) containerArgs() []string {
+	if p == nil {
+		return nil
+	}
+	var args []string
+	if p.CPUSet != "" {
+		args = append(args, "--cpuset-cpus="+p.CPUSet)
+	}
+	if p.NUMANode != nil {
+		args = append(args, "--cpuset-mems="+strconv.Itoa(*p.NUMANode))
+	}
+	return args
+}
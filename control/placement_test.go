@@ -0,0 +1,71 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"os/exec"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginPlacement(t *testing.T) {
+	commands := []string{"/opt/snap/plugins/snap-plugin-collector-pcm"}
+	Convey("Plugin placement", t, func() {
+		Convey("leaves the command untouched when not set", func() {
+			var p *PluginPlacement
+			cmd, err := p.command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, commands)
+			cmd, err = (&PluginPlacement{}).command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, commands)
+		})
+		Convey("wraps the command with taskset for a cpuset", func() {
+			path, err := exec.LookPath("taskset")
+			if err != nil {
+				SkipSo(err, ShouldBeNil)
+				return
+			}
+			cmd, err := (&PluginPlacement{CPUSet: "0-3"}).command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, []string{path, "--cpu-list", "0-3", commands[0]})
+		})
+		Convey("wraps the command with numactl for a NUMA node", func() {
+			path, err := exec.LookPath("numactl")
+			if err != nil {
+				SkipSo(err, ShouldBeNil)
+				return
+			}
+			node := 1
+			cmd, err := (&PluginPlacement{CPUSet: "8-11", NUMANode: &node}).command(commands)
+			So(err, ShouldBeNil)
+			So(cmd, ShouldResemble, []string{path, "--cpunodebind=1", "--membind=1", "--physcpubind=8-11", "--", commands[0]})
+		})
+		Convey("is given to the runtime of containerized plugins", func() {
+			var p *PluginPlacement
+			So(p.containerArgs(), ShouldBeEmpty)
+			node := 1
+			So((&PluginPlacement{CPUSet: "8-11", NUMANode: &node}).containerArgs(), ShouldResemble, []string{"--cpuset-cpus=8-11", "--cpuset-mems=1"})
+		})
+	})
+}
@@ -0,0 +1,55 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import "errors"
+
+const (
+	// APIVersion is the latest version of the plugin API, spoken by snapteld
+	// and by the plugins built with this package
+	APIVersion = 2
+	// MinAPIVersion is the oldest version of the plugin API snapteld still
+	// runs plugins of
+	MinAPIVersion = 1
+)
+
+// ErrUnsupportedAPIVersion is returned when no version of the plugin API is
+// supported by both snapteld and a plugin
+var ErrUnsupportedAPIVersion = errors.New("plugin API version not supported")
+
+// NegotiateAPIVersion returns the highest version of the plugin API supported
+// both here and by a peer supporting the versions min to max. A peer
+// predating the negotiation, giving no version, supports version 1 only.
+func NegotiateAPIVersion(min, max int) (int, error) {
+	if max == 0 {
+		max = 1
+	}
+	if min == 0 {
+		min = 1
+	}
+	v := max
+	if v > APIVersion {
+		v = APIVersion
+	}
+	if v < min || v < MinAPIVersion {
+		return 0, ErrUnsupportedAPIVersion
+	}
+	return v, nil
+}
@@ -0,0 +1,50 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	Convey("Negotiating the plugin API version", t, func() {
+		Convey("picks the highest version supported by both sides", func() {
+			v, err := NegotiateAPIVersion(MinAPIVersion, APIVersion+3)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, APIVersion)
+			v, err = NegotiateAPIVersion(1, 1)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 1)
+		})
+		Convey("speaks version 1 with peers predating the negotiation", func() {
+			v, err := NegotiateAPIVersion(0, 0)
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, 1)
+		})
+		Convey("fails without a version supported by both sides", func() {
+			_, err := NegotiateAPIVersion(APIVersion+1, APIVersion+2)
+			So(err, ShouldEqual, ErrUnsupportedAPIVersion)
+		})
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"golang.org/x/net/context"
 
@@ -68,12 +69,12 @@ type grpcClient struct {
 }
 
 // NewCollectorGrpcClient returns a collector gRPC Client.
-func NewCollectorGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginCollectorClient, error) {
+func NewCollectorGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool, security GRPCSecurity) (PluginCollectorClient, error) {
 	address, port, err := parseAddress(address)
 	if err != nil {
 		return nil, err
 	}
-	p, err := newGrpcClient(address, int(port), timeout, plugin.CollectorPluginType)
+	p, err := newGrpcClient(address, int(port), timeout, plugin.CollectorPluginType, security)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +87,8 @@ func NewStreamCollectorGrpcClient(
 	address string,
 	timeout time.Duration,
 	_ *rsa.PublicKey,
-	secure bool) (PluginStreamCollectorClient, error) {
+	secure bool,
+	security GRPCSecurity) (PluginStreamCollectorClient, error) {
 	address, port, err := parseAddress(address)
 	if err != nil {
 		return nil, err
@@ -95,7 +97,8 @@ func NewStreamCollectorGrpcClient(
 		address,
 		int(port),
 		timeout,
-		plugin.StreamCollectorPluginType)
+		plugin.StreamCollectorPluginType,
+		security)
 	p.killChan = make(chan struct{})
 	if err != nil {
 		return nil, err
@@ -105,12 +108,12 @@ func NewStreamCollectorGrpcClient(
 }
 
 // NewProcessorGrpcClient returns a processor gRPC Client.
-func NewProcessorGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginProcessorClient, error) {
+func NewProcessorGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool, security GRPCSecurity) (PluginProcessorClient, error) {
 	address, port, err := parseAddress(address)
 	if err != nil {
 		return nil, err
 	}
-	p, err := newGrpcClient(address, int(port), timeout, plugin.ProcessorPluginType)
+	p, err := newGrpcClient(address, int(port), timeout, plugin.ProcessorPluginType, security)
 	if err != nil {
 		return nil, err
 	}
@@ -128,12 +131,12 @@ func NewProcessorGrpcClient(address string, timeout time.Duration, pub *rsa.Publ
 }
 
 // NewPublisherGrpcClient returns a publisher gRPC Client.
-func NewPublisherGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginPublisherClient, error) {
+func NewPublisherGrpcClient(address string, timeout time.Duration, pub *rsa.PublicKey, secure bool, security GRPCSecurity) (PluginPublisherClient, error) {
 	address, port, err := parseAddress(address)
 	if err != nil {
 		return nil, err
 	}
-	p, err := newGrpcClient(address, int(port), timeout, plugin.PublisherPluginType)
+	p, err := newGrpcClient(address, int(port), timeout, plugin.PublisherPluginType, security)
 	if err != nil {
 		return nil, err
 	}
@@ -163,8 +166,15 @@ func parseAddress(address string) (string, int64, error) {
 	return address, port, nil
 }
 
-func newGrpcClient(addr string, port int, timeout time.Duration, typ plugin.PluginType) (*grpcClient, error) {
-	conn, err := rpcutil.GetClientConnection(addr, port)
+func newGrpcClient(addr string, port int, timeout time.Duration, typ plugin.PluginType, security GRPCSecurity) (*grpcClient, error) {
+	var creds credentials.TransportCredentials
+	if security.TLSEnabled {
+		var err error
+		if creds, err = security.credentials(); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := rpcutil.GetClientConnectionWithCreds(addr, port, creds)
 	if err != nil {
 		return nil, err
 	}
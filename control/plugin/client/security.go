@@ -0,0 +1,103 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ErrInvalidCACert is returned when a CA certificate file holds no PEM
+// encoded certificate.
+var ErrInvalidCACert = errors.New("no PEM encoded certificate in CA certificate file")
+
+// GRPCSecurity is the TLS setup of the gRPC connections to plugins. When
+// TLSEnabled is set, snapteld authenticates itself to the plugins with the
+// certificate CertPath and its key KeyPath, and checks the certificates of
+// the plugins against the CA certificates found at CACertPaths, files or
+// directories of .crt and .pem files, or against the system ones when it is
+// empty.
+type GRPCSecurity struct {
+	TLSEnabled  bool
+	CertPath    string
+	KeyPath     string
+	CACertPaths []string
+}
+
+// credentials returns the credentials of a connection to a plugin.
+func (s GRPCSecurity) credentials() (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(s.CertPath, s.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := loadCACerts(s.CACertPaths)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// loadCACerts returns the pool of the CA certificates found at paths, the
+// system one when there is none.
+func loadCACerts(paths []string) (*x509.CertPool, error) {
+	if len(paths) == 0 {
+		return x509.SystemCertPool()
+	}
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if fi.IsDir() {
+			files = files[:0]
+			entries, err := ioutil.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".crt" || ext == ".pem") {
+					files = append(files, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, ErrInvalidCACert
+			}
+		}
+	}
+	return pool, nil
+}
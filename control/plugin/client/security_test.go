@@ -0,0 +1,106 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeCert writes a self-signed certificate valid for 127.0.0.1 and its
+// key to dir, returning their paths
+func writeCert(dir string) (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "snapteld"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	certPath := filepath.Join(dir, "snapteld.crt")
+	keyPath := filepath.Join(dir, "snapteld.key")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		return "", "", err
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
+func TestGRPCSecurity(t *testing.T) {
+	Convey("Given a certificate and its key", t, func() {
+		dir, err := ioutil.TempDir("", "snap-tls")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		certPath, keyPath, err := writeCert(dir)
+		So(err, ShouldBeNil)
+
+		Convey("credentials are created with the certificate as CA", func() {
+			creds, err := GRPCSecurity{TLSEnabled: true, CertPath: certPath, KeyPath: keyPath, CACertPaths: []string{certPath}}.credentials()
+			So(err, ShouldBeNil)
+			So(creds.Info().SecurityProtocol, ShouldEqual, "tls")
+		})
+		Convey("CA certificates are read from directories", func() {
+			pool, err := loadCACerts([]string{dir})
+			So(err, ShouldBeNil)
+			So(pool.Subjects(), ShouldHaveLength, 1)
+		})
+		Convey("a CA certificate file without certificate is refused", func() {
+			_, err := loadCACerts([]string{keyPath})
+			So(err, ShouldEqual, ErrInvalidCACert)
+		})
+		Convey("a missing key is refused", func() {
+			_, err := GRPCSecurity{TLSEnabled: true, CertPath: certPath, KeyPath: filepath.Join(dir, "missing.key")}.credentials()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// WithTimeout returns a client calling the same plugin as c but giving up on
+// its calls after the given timeout. Only the gRPC clients support it, the
+// other clients being returned unchanged.
+func WithTimeout(c PluginClient, timeout time.Duration) PluginClient {
+	g, ok := c.(*grpcClient)
+	if !ok || timeout <= 0 || timeout == g.timeout {
+		return c
+	}
+	cp := *g
+	cp.timeout = timeout
+	return &cp
+}
+
+// IsTimeout returns true when the error returned by a call to a plugin is
+// due to the plugin not answering within the timeout of the client.
+func IsTimeout(err error) bool {
+	return err != nil && grpc.Code(err) == codes.DeadlineExceeded
+}
+
+// IsUnavailable returns true when the error returned by a call to a plugin is
+// due to the plugin not being reachable anymore, e.g. after it died.
+func IsUnavailable(err error) bool {
+	return err != nil && grpc.Code(err) == codes.Unavailable
+}
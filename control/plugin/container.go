@@ -0,0 +1,96 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pborman/uuid"
+)
+
+// Container describes the container a plugin is run in, with a docker
+// compatible CLI.
+type Container struct {
+	// Runtime is the CLI running the container, e.g. docker
+	Runtime string
+	// Image is the reference of the image the plugin is run in
+	Image string
+	// Mounts are the host paths mounted read-only at the same place in the
+	// container, the directory of the plugin being always mounted
+	Mounts []string
+	// Args are the extra flags given to the run command, e.g. resource
+	// limits
+	Args []string
+}
+
+// containerCommand runs a plugin in a container. The container is removed
+// when the plugin is killed, as killing the CLI does not stop it.
+type containerCommand struct {
+	*commandWrapper
+	runtime string
+	name    string
+	path    string
+}
+
+func (cc *containerCommand) Path() string { return cc.path }
+func (cc *containerCommand) Kill() error {
+	err := cc.commandWrapper.Kill()
+	if out, rmErr := exec.Command(cc.runtime, "rm", "-f", cc.name).CombinedOutput(); rmErr != nil {
+		execLogger.WithFields(log.Fields{
+			"_block":    "Kill",
+			"container": cc.name,
+			"output":    string(out),
+		}).Warn(rmErr)
+	}
+	return err
+}
+
+// NewContainerExecutablePlugin returns a new ExecutablePlugin running the
+// plugin commands in a container of c.Image. The container shares the
+// network of the host, for snapteld to reach the plugin on its listen
+// address.
+func NewContainerExecutablePlugin(a Arg, c Container, commands ...string) (*ExecutablePlugin, error) {
+	runtime, err := exec.LookPath(c.Runtime)
+	if err != nil {
+		return nil, fmt.Errorf("unable to run plugin in a container: %v", err)
+	}
+	name := "snap-" + filepath.Base(commands[0]) + "-" + uuid.New()[:8]
+	run := []string{runtime, "run", "--rm", "--name", name, "--network", "host"}
+	mounts := append([]string{filepath.Dir(commands[0])}, c.Mounts...)
+	for _, m := range mounts {
+		run = append(run, "--volume", m+":"+m+":ro")
+	}
+	run = append(run, c.Args...)
+	run = append(run, c.Image)
+	e, err := NewExecutablePlugin(a, append(run, commands...)...)
+	if err != nil {
+		return nil, err
+	}
+	e.cmd = &containerCommand{
+		commandWrapper: e.cmd.(*commandWrapper),
+		runtime:        runtime,
+		name:           name,
+		path:           commands[0],
+	}
+	return e, nil
+}
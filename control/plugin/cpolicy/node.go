@@ -59,6 +59,16 @@ func (p *ProcessingErrors) AddError(e error) {
 	p.errors = append(p.errors, e)
 }
 
+// FieldError is the error of a config item failing the rule of its key
+type FieldError struct {
+	Key string
+	Err error
+}
+
+func (f *FieldError) Error() string {
+	return f.Err.Error()
+}
+
 type ConfigPolicyNode struct {
 	rules map[string]Rule
 	mutex *sync.Mutex
@@ -227,13 +237,13 @@ func (c *ConfigPolicyNode) Process(m map[string]ctypes.ConfigValue) (*map[string
 			// Validate versus matching data
 			e := rule.Validate(cv)
 			if e != nil {
-				pErrors.AddError(e)
+				pErrors.AddError(&FieldError{Key: key, Err: e})
 			}
 		} else {
 			// If it was required add error
 			if rule.Required() {
 				e := fmt.Errorf("required key missing (%s)", key)
-				pErrors.AddError(e)
+				pErrors.AddError(&FieldError{Key: key, Err: e})
 			} else {
 				// If default returns we should add it
 				cv := rule.Default()
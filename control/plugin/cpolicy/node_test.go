@@ -57,6 +57,7 @@ func TestConfigPolicyNode(t *testing.T) {
 		So(pe.HasErrors(), ShouldBeTrue)
 		So(len(pe.Errors()), ShouldEqual, 1)
 		So(errorsMsg(pe.Errors()), ShouldContain, "required key missing (password)")
+		So(pe.Errors()[0].(*FieldError).Key, ShouldEqual, "password")
 	})
 
 	Convey("returns errors for missing required data (multiple)", t, func() {
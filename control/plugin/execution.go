@@ -24,8 +24,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -35,10 +36,12 @@ import (
 var execLogger = log.WithField("_module", "plugin-exec")
 
 type ExecutablePlugin struct {
-	name   string
-	cmd    command
-	stdout io.Reader
-	stderr io.Reader
+	name       string
+	cmd        command
+	stdout     io.Reader
+	stderr     io.Reader
+	output     *OutputBuffer
+	outputFile io.WriteCloser
 }
 
 // An interface for the interactions ExecutablePlugin has with an exec.Cmd
@@ -51,14 +54,15 @@ type command interface {
 
 // The implementation of command used here.
 type commandWrapper struct {
-	cmd *exec.Cmd
+	cmd   *exec.Cmd
+	state *os.ProcessState
 }
 
 func (cw *commandWrapper) Path() string { return cw.cmd.Path }
 func (cw *commandWrapper) Kill() error {
 	// first, kill the process wrapped up in the commandWrapper
 	if cw.cmd.Process == nil {
-		err := fmt.Errorf("Process for plugin '%s' not started; cannot kill", path.Base(cw.Path()))
+		err := fmt.Errorf("Process for plugin '%s' not started; cannot kill", filepath.Base(cw.Path()))
 		log.WithFields(log.Fields{
 			"_block": "Kill",
 		}).Warn(err)
@@ -71,7 +75,8 @@ func (cw *commandWrapper) Kill() error {
 	}
 	// then wait for it to exit (so that we don't have any zombie processes kicking
 	// around the system)
-	_, err := cw.cmd.Process.Wait()
+	state, err := cw.cmd.Process.Wait()
+	cw.state = state
 	return err
 }
 func (cw *commandWrapper) Start() error { return cw.cmd.Start() }
@@ -95,9 +100,10 @@ func NewExecutablePlugin(a Arg, commands ...string) (*ExecutablePlugin, error) {
 		return nil, err
 	}
 	return &ExecutablePlugin{
-		cmd:    &commandWrapper{cmd},
+		cmd:    &commandWrapper{cmd: cmd},
 		stdout: stdout,
 		stderr: stderr,
+		output: NewOutputBuffer(OutputBufferSize),
 	}, nil
 }
 
@@ -117,6 +123,10 @@ func (e *ExecutablePlugin) Run(timeout time.Duration) (Response, error) {
 	if err = e.cmd.Start(); err != nil {
 		return resp, err
 	}
+	if e.outputFile, err = openOutputFile(e.cmd.Path(), e.Pid()); err != nil {
+		execLogger.WithField("plugin", filepath.Base(e.cmd.Path())).Warn("cannot write plugin output to file: ", err)
+		err = nil
+	}
 
 	e.captureStderr()
 	go func() {
@@ -131,6 +141,7 @@ func (e *ExecutablePlugin) Run(timeout time.Duration) (Response, error) {
 					respReceived = true
 					close(doneChan)
 				} else {
+					e.writeOutput(stdOutScanner.Bytes())
 					execLogger.WithFields(log.Fields{
 						"plugin": e.name,
 						"io":     "stdout",
@@ -146,7 +157,7 @@ func (e *ExecutablePlugin) Run(timeout time.Duration) (Response, error) {
 				}
 
 				execLogger.
-					WithField("plugin", path.Base(e.cmd.Path())).
+					WithField("plugin", filepath.Base(e.cmd.Path())).
 					WithField("io", "stdout").
 					WithField("scanner_err", errScanner).
 					WithField("read_string_err", errRead).
@@ -166,7 +177,7 @@ func (e *ExecutablePlugin) Run(timeout time.Duration) (Response, error) {
 	case <-doneChan:
 	case <-time.After(timeout):
 		// We timed out waiting for the plugin's response.  Set err.
-		err = fmt.Errorf("timed out waiting for plugin %s", path.Base(e.cmd.Path()))
+		err = fmt.Errorf("timed out waiting for plugin %s", filepath.Base(e.cmd.Path()))
 	}
 	if err != nil {
 		execLogger.WithFields(log.Fields{
@@ -194,7 +205,53 @@ func (e *ExecutablePlugin) SetName(name string) {
 }
 
 func (e *ExecutablePlugin) Kill() error {
-	return e.cmd.Kill()
+	err := e.cmd.Kill()
+	if e.outputFile != nil {
+		e.outputFile.Close()
+	}
+	return err
+}
+
+// Pid returns the process ID of the plugin, or 0 when it is not started.
+// The process of a plugin run in a container is the one of the CLI running
+// the container.
+func (e *ExecutablePlugin) Pid() int {
+	cw, ok := e.cmd.(*commandWrapper)
+	if cc, isContainer := e.cmd.(*containerCommand); isContainer {
+		cw, ok = cc.commandWrapper, true
+	}
+	if ok && cw.cmd.Process != nil {
+		return cw.cmd.Process.Pid
+	}
+	return 0
+}
+
+// ExitStatus returns the exit status of the plugin process once it was
+// killed, or an empty string while it runs.
+func (e *ExecutablePlugin) ExitStatus() string {
+	cw, ok := e.cmd.(*commandWrapper)
+	if cc, isContainer := e.cmd.(*containerCommand); isContainer {
+		cw, ok = cc.commandWrapper, true
+	}
+	if ok && cw.state != nil {
+		return cw.state.String()
+	}
+	return ""
+}
+
+// Env returns the environment the plugin process is run with.
+func (e *ExecutablePlugin) Env() []string {
+	cw, ok := e.cmd.(*commandWrapper)
+	if cc, isContainer := e.cmd.(*containerCommand); isContainer {
+		cw, ok = cc.commandWrapper, true
+	}
+	if !ok {
+		return nil
+	}
+	if cw.cmd.Env == nil {
+		return os.Environ()
+	}
+	return cw.cmd.Env
 }
 
 func (e *ExecutablePlugin) captureStderr() {
@@ -202,6 +259,7 @@ func (e *ExecutablePlugin) captureStderr() {
 	go func() {
 		for {
 			for stdErrScanner.Scan() {
+				e.writeOutput(stdErrScanner.Bytes())
 				execLogger.
 					WithField("plugin", e.name).
 					WithField("io", "stderr").
@@ -0,0 +1,129 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	// OutputBufferSize is the size, in bytes, of the buffer keeping the
+	// last output of each plugin process
+	OutputBufferSize = 64 << 10
+	// OutputDir is the directory the output of each plugin process is
+	// also written to, none when empty
+	OutputDir = ""
+)
+
+// OutputBuffer is a ring buffer keeping the last output of a plugin, its
+// stdout, once the plugin answered the handshake, and its stderr.
+type OutputBuffer struct {
+	sync.Mutex
+	buf  []byte
+	next int
+	full bool
+}
+
+// NewOutputBuffer returns a buffer keeping the last size bytes written to it.
+func NewOutputBuffer(size int) *OutputBuffer {
+	return &OutputBuffer{buf: make([]byte, size)}
+}
+
+// Write appends p to the buffer, overwriting its oldest bytes when it is full.
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	n := len(p)
+	if len(b.buf) == 0 {
+		return n, nil
+	}
+	if len(p) > len(b.buf) {
+		p = p[len(p)-len(b.buf):]
+	}
+	for len(p) > 0 {
+		c := copy(b.buf[b.next:], p)
+		p = p[c:]
+		b.next += c
+		if b.next == len(b.buf) {
+			b.next = 0
+			b.full = true
+		}
+	}
+	return n, nil
+}
+
+// Tail returns the last n bytes written to the buffer, all of the ones it
+// keeps when n is 0 or larger than the buffer.
+func (b *OutputBuffer) Tail(n int) []byte {
+	b.Lock()
+	defer b.Unlock()
+	var out []byte
+	if b.full {
+		out = append(append(out, b.buf[b.next:]...), b.buf[:b.next]...)
+	} else {
+		out = append(out, b.buf[:b.next]...)
+	}
+	if n > 0 && n < len(out) {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// openOutputFile opens the file the output of the plugin process is written
+// to in OutputDir, named after the plugin and its pid.
+func openOutputFile(cmdPath string, pid int) (io.WriteCloser, error) {
+	if OutputDir == "" {
+		return nil, nil
+	}
+	name := fmt.Sprintf("%s-%d.log", filepath.Base(cmdPath), pid)
+	f, err := os.OpenFile(filepath.Join(OutputDir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// writeOutput keeps a line of the output of the plugin process, and writes
+// it to its output file if any.
+func (e *ExecutablePlugin) writeOutput(line []byte) {
+	// the line belongs to the scanner it was read with
+	b := make([]byte, len(line)+1)
+	copy(b, line)
+	b[len(line)] = '\n'
+	if e.output != nil {
+		e.output.Write(b)
+	}
+	if e.outputFile != nil {
+		e.outputFile.Write(b)
+	}
+}
+
+// Output returns the last n bytes of the output of the plugin process, all
+// of the ones kept when n is 0.
+func (e *ExecutablePlugin) Output(n int) []byte {
+	if e.output == nil {
+		return nil
+	}
+	return e.output.Tail(n)
+}
@@ -0,0 +1,67 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	Convey("Given an output buffer of 8 bytes", t, func() {
+		b := NewOutputBuffer(8)
+		Convey("it returns what was written while it is not full", func() {
+			b.Write([]byte("abc"))
+			So(string(b.Tail(0)), ShouldEqual, "abc")
+			So(string(b.Tail(2)), ShouldEqual, "bc")
+		})
+		Convey("it keeps the last bytes written once full", func() {
+			b.Write([]byte("abcdef"))
+			b.Write([]byte("ghij"))
+			So(string(b.Tail(0)), ShouldEqual, "cdefghij")
+			So(string(b.Tail(3)), ShouldEqual, "hij")
+			So(string(b.Tail(20)), ShouldEqual, "cdefghij")
+		})
+		Convey("it keeps the end of a write larger than itself", func() {
+			n, err := b.Write([]byte("0123456789"))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 10)
+			So(string(b.Tail(0)), ShouldEqual, "23456789")
+		})
+	})
+	Convey("The output of a plugin is kept after its handshake", t, func() {
+		e := setupMockExec([]byte(`{"Token": "a token"}`), false)
+		e.output = NewOutputBuffer(1024)
+		_, err := e.Run(time.Millisecond * 100)
+		So(err, ShouldBeNil)
+		// the output is read in the background
+		want := "some log message on stdout\nsome log message on stderr\n"
+		for i := 0; i < 100 && len(e.Output(0)) < len(want); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		So(string(e.Output(0)), ShouldContainSubstring, "some log message on stdout\n")
+		So(string(e.Output(0)), ShouldContainSubstring, "some log message on stderr\n")
+		So(string(e.Output(0)), ShouldNotContainSubstring, "a token")
+	})
+}
@@ -60,6 +60,10 @@ const (
 	// Using this strategy enables a running database plugin that has the same connection info between
 	// two tasks to be shared.
 	ConfigRouting
+	// TaskHashRouting shares the running instances of a plugin between tasks, the requests
+	// of a task being always sent to the same instance, picked by hashing the task id.
+	// Collectors computing rates or deltas internally then see all the collections of a task.
+	TaskHashRouting
 )
 
 // Plugin response states
@@ -97,6 +101,7 @@ var (
 		"least-recently-used",
 		"sticky",
 		"config",
+		"task-hash",
 	}
 )
 
@@ -131,6 +136,13 @@ type PluginMeta struct {
 	// RoutingStrategy will override the routing strategy this plugin requires.
 	// The default routing strategy round-robin.
 	RoutingStrategy RoutingStrategyType
+	// TLSEnabled tells that the gRPC server of the plugin uses the TLS setup
+	// given in its Arg.
+	TLSEnabled bool
+	// APIVersion is the version of the plugin API the plugin speaks, the
+	// highest one of the versions given in its Arg it supports. Plugins
+	// predating the negotiation leave it to 0, speaking version 1.
+	APIVersion int
 }
 
 // Arguments passed to startup of Plugin
@@ -146,6 +158,20 @@ type Arg struct {
 
 	// enable pprof
 	Pprof bool
+
+	// TLSEnabled asks the plugin to serve gRPC over TLS, with the
+	// certificate CertPath and its key KeyPath, only accepting clients with
+	// a certificate signed by the CA certificates at CACertPaths, separated
+	// by the OS path list separator.
+	TLSEnabled  bool
+	CertPath    string
+	KeyPath     string
+	CACertPaths string
+
+	// MinAPIVersion and MaxAPIVersion are the versions of the plugin API
+	// supported by snapteld, out of which the plugin picks the one it speaks.
+	MinAPIVersion int
+	MaxAPIVersion int
 }
 
 func NewArg(logLevel int, pprof bool) Arg {
@@ -153,6 +179,8 @@ func NewArg(logLevel int, pprof bool) Arg {
 		LogLevel:            log.Level(logLevel),
 		PingTimeoutDuration: PingTimeoutDurationDefault,
 		Pprof:               pprof,
+		MinAPIVersion:       MinAPIVersion,
+		MaxAPIVersion:       APIVersion,
 	}
 }
 
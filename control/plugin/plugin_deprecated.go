@@ -131,6 +131,12 @@ func Start(m *PluginMeta, c Plugin, requestString string) (error, int) {
 	if sErr != nil {
 		return sErr, retCode
 	}
+	// speaks the highest version of the plugin API snapteld supports
+	v, err := NegotiateAPIVersion(s.MinAPIVersion, s.MaxAPIVersion)
+	if err != nil {
+		return err, 2
+	}
+	m.APIVersion = v
 
 	var (
 		r        *Response
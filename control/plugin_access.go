@@ -0,0 +1,117 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/intelsdi-x/snap/pkg/versions"
+)
+
+var (
+	// ErrPluginNotAllowed is returned when a plugin matching none of the
+	// rules of the allowlist is loaded
+	ErrPluginNotAllowed = errors.New("plugin is not allowed by the plugin allowlist")
+	// ErrPluginDenied is returned when a plugin matching a rule of the
+	// denylist is loaded
+	ErrPluginDenied = errors.New("plugin is denied by the plugin denylist")
+)
+
+// PluginAccess lists the plugins snapteld may load. When Allow is set only
+// the plugins matching one of its rules are loaded, and the plugins matching
+// a rule of Deny are never loaded.
+type PluginAccess struct {
+	Allow []*PluginRule `json:"allow,omitempty"yaml:"allow"`
+	Deny  []*PluginRule `json:"deny,omitempty"yaml:"deny"`
+}
+
+// PluginRule matches the plugins with the given name, version range (e.g.
+// ">=3, <5") and hex encoded SHA-256 checksum, the empty fields matching any
+// plugin.
+type PluginRule struct {
+	Name     string `json:"name,omitempty"yaml:"name"`
+	Version  string `json:"version,omitempty"yaml:"version"`
+	CheckSum string `json:"checksum,omitempty"yaml:"checksum"`
+}
+
+// OptSetPluginAccess sets the plugins the plugin manager may load
+func OptSetPluginAccess(access *PluginAccess) pluginManagerOpt {
+	return func(p *pluginManager) {
+		p.access = access
+	}
+}
+
+// matches reports whether the rule matches the plugin. A plugin which is not
+// known by name yet (name is empty) is only matched by the rules giving a
+// checksum alone.
+func (r *PluginRule) matches(name string, version int, sum [sha256.Size]byte) (bool, error) {
+	if r.CheckSum != "" && !strings.EqualFold(r.CheckSum, hex.EncodeToString(sum[:])) {
+		return false, nil
+	}
+	if name == "" {
+		return r.CheckSum != "" && r.Name == "" && r.Version == "", nil
+	}
+	if r.Name != "" && r.Name != name {
+		return false, nil
+	}
+	if r.Version != "" {
+		c, err := versions.ParseConstraint(r.Version)
+		if err != nil {
+			return false, fmt.Errorf("invalid version of the plugin rule for %s: %v", r.Name, err)
+		}
+		return c.Check(version), nil
+	}
+	return true, nil
+}
+
+// check returns an error when the plugin may not be loaded. Before the
+// plugin is run its name may not be known (name is empty), in which case
+// only the rules with a checksum deny it and the allowlist is not checked.
+func (a *PluginAccess) check(name string, version int, sum [sha256.Size]byte) error {
+	if a == nil {
+		return nil
+	}
+	for _, r := range a.Deny {
+		ok, err := r.matches(name, version, sum)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return ErrPluginDenied
+		}
+	}
+	if len(a.Allow) == 0 || name == "" {
+		return nil
+	}
+	for _, r := range a.Allow {
+		ok, err := r.matches(name, version, sum)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return ErrPluginNotAllowed
+}
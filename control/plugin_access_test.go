@@ -0,0 +1,70 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginAccess(t *testing.T) {
+	sum := sha256.Sum256([]byte("plugin"))
+	other := sha256.Sum256([]byte("other"))
+	Convey("Plugin access", t, func() {
+		Convey("allows any plugin when not set", func() {
+			var a *PluginAccess
+			So(a.check("psutil", 9, sum), ShouldBeNil)
+		})
+		Convey("denies the plugins matching a deny rule", func() {
+			a := &PluginAccess{Deny: []*PluginRule{{Name: "psutil", Version: "<10"}}}
+			So(a.check("psutil", 9, sum), ShouldEqual, ErrPluginDenied)
+			So(a.check("psutil", 10, sum), ShouldBeNil)
+			So(a.check("mock", 1, sum), ShouldBeNil)
+		})
+		Convey("allows only the plugins matching an allow rule", func() {
+			a := &PluginAccess{Allow: []*PluginRule{
+				{Name: "psutil", Version: ">=9"},
+				{Name: "influxdb", CheckSum: hex.EncodeToString(sum[:])},
+			}}
+			So(a.check("psutil", 9, other), ShouldBeNil)
+			So(a.check("psutil", 8, other), ShouldEqual, ErrPluginNotAllowed)
+			So(a.check("influxdb", 1, sum), ShouldBeNil)
+			So(a.check("influxdb", 1, other), ShouldEqual, ErrPluginNotAllowed)
+			So(a.check("mock", 1, sum), ShouldEqual, ErrPluginNotAllowed)
+		})
+		Convey("checks the plugins not known by name by checksum only", func() {
+			a := &PluginAccess{
+				Allow: []*PluginRule{{Name: "psutil"}},
+				Deny:  []*PluginRule{{CheckSum: hex.EncodeToString(sum[:])}, {Name: "mock"}},
+			}
+			So(a.check("", 0, sum), ShouldEqual, ErrPluginDenied)
+			So(a.check("", 0, other), ShouldBeNil)
+		})
+		Convey("refuses the plugins when a version range is invalid", func() {
+			a := &PluginAccess{Deny: []*PluginRule{{Name: "psutil", Version: "newest"}}}
+			So(a.check("psutil", 9, sum), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,175 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+const (
+	// pluginCallsKept is the count of the last calls made to a plugin kept
+	// for its crash diagnostics
+	pluginCallsKept = 10
+	// pluginCrashesKept is the count of the last crashes kept for each plugin
+	pluginCrashesKept = 20
+	// redactedValue replaces the values of the environment variables which
+	// may hold secrets
+	redactedValue = "<redacted>"
+)
+
+// secretEnvNames are the parts of the names of the environment variables
+// which values are redacted from crash diagnostics
+var secretEnvNames = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "PASSWD", "CREDENTIAL"}
+
+// pluginCrashes keeps the diagnostics of the last crashes of plugins, by
+// plugin key
+var pluginCrashes = &crashStore{crashes: map[string][]core.PluginCrash{}}
+
+// describesProcess is implemented by the executable plugins which process is
+// run by snapteld.
+type describesProcess interface {
+	ExitStatus() string
+	Env() []string
+}
+
+// pluginCalls keeps the last calls made to a running plugin
+type pluginCalls struct {
+	sync.Mutex
+	calls []core.PluginCall
+}
+
+// add records a call to method started at start which failed with err, err
+// being nil for a successful call
+func (c *pluginCalls) add(method string, start time.Time, err error) {
+	if c == nil {
+		return
+	}
+	call := core.PluginCall{
+		Method:   method,
+		Time:     start,
+		Duration: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.calls = append(c.calls, call)
+	if len(c.calls) > pluginCallsKept {
+		c.calls = c.calls[len(c.calls)-pluginCallsKept:]
+	}
+}
+
+// get returns the last calls made to the plugin, the latest last
+func (c *pluginCalls) get() []core.PluginCall {
+	if c == nil {
+		return nil
+	}
+	c.Lock()
+	defer c.Unlock()
+	return append([]core.PluginCall(nil), c.calls...)
+}
+
+// crashStore keeps the last crashes of each plugin
+type crashStore struct {
+	sync.RWMutex
+	crashes map[string][]core.PluginCrash
+}
+
+func (s *crashStore) add(key string, c core.PluginCrash) {
+	s.Lock()
+	defer s.Unlock()
+	crashes := append(s.crashes[key], c)
+	if len(crashes) > pluginCrashesKept {
+		crashes = crashes[len(crashes)-pluginCrashesKept:]
+	}
+	s.crashes[key] = crashes
+}
+
+func (s *crashStore) get(key string) []core.PluginCrash {
+	s.RLock()
+	defer s.RUnlock()
+	return append([]core.PluginCrash{}, s.crashes[key]...)
+}
+
+// redactEnv returns env with the values of the variables which names look
+// like the ones of secrets redacted
+func redactEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		name := strings.ToUpper(kv[0])
+		for _, s := range secretEnvNames {
+			if len(kv) == 2 && strings.Contains(name, s) {
+				e = kv[0] + "=" + redactedValue
+				break
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// recordCrash captures the diagnostics of the plugin a handled as crashed
+// for reason. The plugin is expected to be killed already so that the exit
+// status of its process is known.
+func recordCrash(a *availablePlugin, reason string) {
+	c := core.PluginCrash{
+		ID:     a.ID(),
+		Time:   time.Now(),
+		Reason: reason,
+		Output: string(a.Output(deadPluginOutputSize)),
+		Calls:  a.calls.get(),
+	}
+	if d, ok := a.ePlugin.(describesProcess); ok {
+		c.ExitStatus = d.ExitStatus()
+		c.Env = redactEnv(d.Env())
+	}
+	runnerLog.WithFields(log.Fields{
+		"_block":      "record-crash",
+		"aplugin":     a.String(),
+		"reason":      reason,
+		"exit-status": c.ExitStatus,
+	}).Info("recorded plugin crash diagnostics")
+	pluginCrashes.add(a.key, c)
+}
+
+// PluginCrashes returns the diagnostics of the last crashes of the running
+// instances of the plugin, the latest last.
+func (p *pluginControl) PluginCrashes(typeName, name string, version int) ([]core.PluginCrash, serror.SnapError) {
+	f := map[string]interface{}{
+		"plugin-type":    typeName,
+		"plugin-name":    name,
+		"plugin-version": version,
+	}
+	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", typeName, name, version)
+	if _, err := p.pluginManager.get(key); err != nil {
+		return nil, serror.New(ErrPluginNotFound, f)
+	}
+	return pluginCrashes.get(key), nil
+}
@@ -0,0 +1,77 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+func TestPluginCrash(t *testing.T) {
+	Convey("The calls made to a plugin", t, func() {
+		c := &pluginCalls{}
+		for i := 0; i < pluginCallsKept+2; i++ {
+			c.add("collect", time.Now(), nil)
+		}
+		c.add("publish", time.Now(), errors.New("unavailable"))
+
+		Convey("are kept up to the last ones", func() {
+			calls := c.get()
+			So(calls, ShouldHaveLength, pluginCallsKept)
+			So(calls[len(calls)-1].Method, ShouldEqual, "publish")
+			So(calls[len(calls)-1].Error, ShouldEqual, "unavailable")
+			So(calls[0].Error, ShouldBeEmpty)
+		})
+		Convey("are not kept for a plugin without calls", func() {
+			var none *pluginCalls
+			none.add("collect", time.Now(), nil)
+			So(none.get(), ShouldBeNil)
+		})
+	})
+	Convey("The environment of a crashed plugin", t, func() {
+		env := redactEnv([]string{"PATH=/bin", "AWS_SECRET_ACCESS_KEY=s3cr3t", "api_token=abc", "EMPTY"})
+
+		Convey("has the values of secrets redacted", func() {
+			So(env, ShouldResemble, []string{"PATH=/bin", "AWS_SECRET_ACCESS_KEY=" + redactedValue, "api_token=" + redactedValue, "EMPTY"})
+		})
+	})
+	Convey("The crashes of a plugin", t, func() {
+		s := &crashStore{crashes: map[string][]core.PluginCrash{}}
+		for i := 0; i < pluginCrashesKept+5; i++ {
+			s.add("collector:foo:1", core.PluginCrash{ID: uint32(i)})
+		}
+
+		Convey("are kept up to the last ones", func() {
+			crashes := s.get("collector:foo:1")
+			So(crashes, ShouldHaveLength, pluginCrashesKept)
+			So(crashes[0].ID, ShouldEqual, 5)
+		})
+		Convey("of a plugin which never crashed are empty", func() {
+			So(s.get("collector:bar:1"), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,41 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// checkDependencies returns an error naming the first plugin required by the
+// metadata which is not loaded in a version satisfying its constraint.
+func checkDependencies(pm managesPlugins, md *core.PluginMetadata) error {
+	for _, d := range md.Requires {
+		constraint := d.Version
+		if constraint == "" {
+			constraint = ">=1"
+		}
+		if _, err := latestMatchingVersion(pm, d.Type, d.Name, constraint); err != nil {
+			return fmt.Errorf("plugin %s requires the plugin %s, which is not loaded; load it first", md.Name, d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/strategy"
+)
+
+// OptSetPluginIdleTimeout sets the period without work after which a running
+// plugin instance is stopped. The instances stopped are started again on the
+// next call made to their pool. A zero value disables the reaping of idle
+// instances.
+func OptSetPluginIdleTimeout(d time.Duration) PluginControlOpt {
+	return func(c *pluginControl) {
+		c.pluginRunner.SetPluginIdleTimeout(d)
+	}
+}
+
+// monitorIdleTimeoutOption sets the period without work after which the
+// monitor stops a running plugin instance.
+func monitorIdleTimeoutOption(d time.Duration) monitorOption {
+	return func(m *monitor) monitorOption {
+		previous := m.idleTimeout
+		m.idleTimeout = d
+		return monitorIdleTimeoutOption(previous)
+	}
+}
+
+// idle returns whether the plugin has had no work for the duration d.
+// Streaming collectors, which are not hit per collection, and remote plugins,
+// which are not run by snapteld, are never idle.
+func (a *availablePlugin) idle(d time.Duration) bool {
+	if isRemote(a.ePlugin) || a.meta.RPCType == plugin.STREAMGRPC {
+		return false
+	}
+	return time.Since(a.lastHitTime) > d
+}
+
+// reapIdle stops the running plugins which have had no work for the
+// duration d. Their pools keep their subscriptions.
+func (ap *availablePlugins) reapIdle(d time.Duration) {
+	ap.RLock()
+	pools := make([]strategy.Pool, 0, len(ap.table))
+	for _, pool := range ap.table {
+		pools = append(pools, pool)
+	}
+	ap.RUnlock()
+
+	for _, pool := range pools {
+		var idle []*availablePlugin
+		pool.RLock()
+		for _, p := range pool.Plugins() {
+			if a, ok := p.(*availablePlugin); ok && a.idle(d) {
+				idle = append(idle, a)
+			}
+		}
+		pool.RUnlock()
+
+		for _, a := range idle {
+			runnerLog.WithFields(log.Fields{
+				"_block":   "reap-idle",
+				"aplugin":  a.String(),
+				"last-hit": a.lastHitTime,
+			}).Info("stopping idle plugin")
+			if err := a.Stop("idle"); err != nil {
+				runnerLog.WithFields(log.Fields{
+					"_block":  "reap-idle",
+					"aplugin": a.String(),
+				}).Error(err)
+			}
+			pool.Kill(a.ID(), "idle")
+		}
+	}
+}
+
+// wakePlugin starts the plugins of a pool which instances were all stopped
+// for being idle while tasks are still subscribed to it.
+func (r *runner) wakePlugin(key string) error {
+	if r.idleTimeout <= 0 {
+		return nil
+	}
+	pool, err := r.availablePlugins.getPool(key)
+	if err != nil || pool == nil {
+		// the call made to the pool reports the error
+		return nil
+	}
+
+	r.wake.Lock()
+	defer r.wake.Unlock()
+	if pool.Count() > 0 || pool.SubscriptionCount() == 0 {
+		return nil
+	}
+	lp, e := r.pluginManager.get(key)
+	if e != nil {
+		return e
+	}
+	runnerLog.WithFields(log.Fields{
+		"_block": "wake-plugin",
+		"pool":   key,
+	}).Info("starting plugin stopped while idle")
+	for pool.Eligible() {
+		if err := r.runPlugin(lp.Name(), lp.Details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
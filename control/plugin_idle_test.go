@@ -0,0 +1,67 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginIdle(t *testing.T) {
+	Convey("Given a running plugin", t, func() {
+		ap := &availablePlugin{lastHitTime: time.Now().Add(-time.Minute)}
+
+		Convey("it is idle when not hit for the idle period", func() {
+			So(ap.idle(30*time.Second), ShouldBeTrue)
+			So(ap.idle(2*time.Minute), ShouldBeFalse)
+		})
+		Convey("a streaming collector is never idle", func() {
+			ap.meta.RPCType = plugin.STREAMGRPC
+			So(ap.idle(time.Second), ShouldBeFalse)
+		})
+		Convey("a remote plugin is never idle", func() {
+			ap.ePlugin = &remotePlugin{}
+			So(ap.idle(time.Second), ShouldBeFalse)
+		})
+	})
+	Convey("Given a runner", t, func() {
+		r := newRunner()
+
+		Convey("no plugin is woken when idle plugins are not stopped", func() {
+			So(r.wakePlugin("collector"+core.Separator+"mock"+core.Separator+"1"), ShouldBeNil)
+		})
+		Convey("the idle period is kept by the runner and its monitor", func() {
+			r.SetPluginIdleTimeout(time.Minute)
+			So(r.idleTimeout, ShouldEqual, time.Minute)
+			So(r.monitor.idleTimeout, ShouldEqual, time.Minute)
+			So(newRunner().idleTimeout, ShouldEqual, 0)
+
+			Convey("calls to unknown pools are left to fail", func() {
+				So(r.wakePlugin("collector"+core.Separator+"mock"+core.Separator+"1"), ShouldBeNil)
+			})
+		})
+	})
+}
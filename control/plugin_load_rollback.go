@@ -0,0 +1,74 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// The stages of the load of a plugin, past its start, reported in the
+// errors of the loads rolled back
+const (
+	loadStageHandshake     = "handshake"
+	loadStageHealthCheck   = "health-check"
+	loadStageConfigPolicy  = "config-policy"
+	loadStageMetricCatalog = "metric-catalog"
+	loadStageRegistration  = "registration"
+)
+
+// rollbackLoad undoes the load of a plugin which failed at the given stage:
+// the plugin is stopped and the metrics it added to the metric catalog are
+// removed. The error of the load is completed with the plugin and the stage
+// it failed at.
+func (p *pluginManager) rollbackLoad(lp *loadedPlugin, ep executablePlugin, resp plugin.Response, stage string, serr serror.SnapError) {
+	if stage == loadStageMetricCatalog || stage == loadStageRegistration {
+		p.metricCatalog.RmUnloadedPluginMetrics(lp)
+	}
+	if err := ep.Kill(); err != nil {
+		pmLogger.WithFields(log.Fields{
+			"_block":      "rollback-load",
+			"plugin-name": resp.Meta.Name,
+			"error":       err.Error(),
+		}).Debug("plugin already stopped")
+	}
+
+	fields := map[string]interface{}{}
+	for k, v := range serr.Fields() {
+		fields[k] = v
+	}
+	fields["plugin-name"] = resp.Meta.Name
+	fields["plugin-version"] = resp.Meta.Version
+	fields["plugin-type"] = resp.Type.String()
+	fields["load-stage"] = stage
+	fields["rolled-back"] = true
+	serr.SetFields(fields)
+
+	pmLogger.WithFields(log.Fields{
+		"_block":         "rollback-load",
+		"plugin-name":    resp.Meta.Name,
+		"plugin-version": resp.Meta.Version,
+		"plugin-type":    resp.Type.String(),
+		"load-stage":     stage,
+		"error":          serr.Error(),
+	}).Warn("plugin load failed, rolled back")
+}
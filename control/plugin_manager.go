@@ -23,11 +23,13 @@ package control
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -164,6 +166,8 @@ type pluginDetails struct {
 	Path      string
 	Signed    bool
 	Signature []byte
+	Metadata  *core.PluginMetadata
+	Remote    *remotePlugin
 }
 
 type loadedPlugin struct {
@@ -216,6 +220,36 @@ func (lp *loadedPlugin) IsSigned() bool {
 	return lp.Details.Signed
 }
 
+// Metadata returns the metadata read from the plugin's sidecar file, if any
+// implements the CatalogedPlugin interface
+func (lp *loadedPlugin) Metadata() *core.PluginMetadata {
+	if lp.Details == nil {
+		return nil
+	}
+	return lp.Details.Metadata
+}
+
+// Info returns the description, license, author, URL and platforms of the
+// plugin given by its metadata
+// implements the CatalogedPlugin interface
+func (lp *loadedPlugin) Info() core.PluginInfo {
+	return lp.Metadata().Info()
+}
+
+// APIVersion returns the version of the plugin API the plugin speaks
+func (lp *loadedPlugin) APIVersion() int {
+	return lp.Meta.APIVersion
+}
+
+// CheckSum returns the hex encoded sha256 checksum of the plugin, empty for
+// remote plugins
+func (lp *loadedPlugin) CheckSum() string {
+	if lp.Details == nil || lp.Details.Remote != nil {
+		return ""
+	}
+	return hex.EncodeToString(lp.Details.CheckSum[:])
+}
+
 // LoadedTimestamp returns a unix timestamp of the LoadTime of a plugin
 // implements the CatalogedPlugin interface
 func (lp *loadedPlugin) LoadedTimestamp() *time.Time {
@@ -236,12 +270,15 @@ type pluginManager struct {
 	pluginConfig      *pluginConfig
 	pluginTags        map[string]map[string]string
 	pprof             bool
+	security          client.GRPCSecurity
+	access            *PluginAccess
+	timeouts          pluginTimeouts
 }
 
 func newPluginManager(opts ...pluginManagerOpt) *pluginManager {
 	logPath := "/tmp"
 	if runtime.GOOS == "windows" {
-		logPath = `c:\temp`
+		logPath = os.TempDir()
 	}
 	p := &pluginManager{
 		pluginLoadTimeout: defaultPluginLoadTimeout,
@@ -267,6 +304,13 @@ func OptSetPprof(pprof bool) pluginManagerOpt {
 	}
 }
 
+// OptSetPluginTLS sets the TLS setup of the plugins on the plugin manager
+func OptSetPluginTLS(security client.GRPCSecurity) pluginManagerOpt {
+	return func(p *pluginManager) {
+		p.security = security
+	}
+}
+
 // OptSetPluginConfig sets the config on the plugin manager
 func OptSetPluginConfig(cf *pluginConfig) pluginManagerOpt {
 	return func(p *pluginManager) {
@@ -301,6 +345,11 @@ func (p *pluginManager) SetPluginTags(tags map[string]map[string]string) {
 	p.pluginTags = tags
 }
 
+// SetPluginTimeouts sets the timeout of the calls made to the plugins by name
+func (p *pluginManager) SetPluginTimeouts(t pluginTimeouts) {
+	p.timeouts = t
+}
+
 // SetMetricCatalog sets metric catalog
 func (p *pluginManager) SetMetricCatalog(mc catalogsMetrics) {
 	p.metricCatalog = mc
@@ -308,7 +357,7 @@ func (p *pluginManager) SetMetricCatalog(mc catalogsMetrics) {
 
 // LoadPlugin is the method for loading a plugin and
 // saving plugin into the LoadedPlugins array
-func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter) (*loadedPlugin, serror.SnapError) {
+func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter) (_ *loadedPlugin, serr serror.SnapError) {
 	lPlugin := new(loadedPlugin)
 	lPlugin.Details = details
 	lPlugin.State = DetectedState
@@ -326,15 +375,38 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 		commands[i] = filepath.Join(lPlugin.Details.ExecPath, e)
 	}
 
-	ePlugin, err := plugin.NewExecutablePlugin(
-		p.GenerateArgs(int(log.GetLevel())),
-		commands...)
-	if err != nil {
+	// Refuse the plugins known to be denied before running them
+	name, version := "", 0
+	if md := lPlugin.Details.Metadata; md != nil {
+		name, version = md.Name, md.Version
+	}
+	if err := p.access.check(name, version, lPlugin.Details.CheckSum); err != nil {
 		pmLogger.WithFields(log.Fields{
-			"_block": "load-plugin",
-			"error":  err.Error(),
-		}).Error("load plugin error while creating executable plugin")
-		return nil, serror.New(err)
+			"_block":         "load-plugin",
+			"plugin-name":    name,
+			"plugin-version": version,
+			"error":          err.Error(),
+		}).Error("load plugin error while checking plugin access")
+		return nil, serror.New(err, map[string]interface{}{"plugin-name": name, "plugin-version": version})
+	}
+
+	var ePlugin executablePlugin
+	if lPlugin.Details.Remote != nil {
+		ePlugin = lPlugin.Details.Remote
+	} else {
+		ep, err := newContainerPlugin(
+			p.GenerateArgs(int(log.GetLevel())),
+			lPlugin.Details,
+			nil,
+			commands)
+		if err != nil {
+			pmLogger.WithFields(log.Fields{
+				"_block": "load-plugin",
+				"error":  err.Error(),
+			}).Error("load plugin error while creating executable plugin")
+			return nil, serror.New(err)
+		}
+		ePlugin = ep
 	}
 
 	pmLogger.WithFields(log.Fields{
@@ -350,7 +422,18 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 		return nil, serror.New(err)
 	}
 
-	ePlugin.SetName(resp.Meta.Name)
+	if ep, ok := ePlugin.(*plugin.ExecutablePlugin); ok {
+		ep.SetName(resp.Meta.Name)
+	}
+
+	// From here on a failed load is rolled back, so that the plugin is
+	// neither left running nor half registered in the metric catalog.
+	stage := loadStageHandshake
+	defer func() {
+		if serr != nil {
+			p.rollbackLoad(lPlugin, ePlugin, resp, stage, serr)
+		}
+	}()
 
 	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", resp.Meta.Type.String(), resp.Meta.Name, resp.Meta.Version)
 	if _, exists := p.loadedPlugins.table[key]; exists {
@@ -361,7 +444,46 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 		})
 	}
 
-	ap, err := newAvailablePlugin(resp, emitter, ePlugin)
+	// the plugin speaks the version of the plugin API it picked out of the
+	// ones given in its arguments, older plugins speaking version 1
+	apiVersion, err := plugin.NegotiateAPIVersion(resp.Meta.APIVersion, resp.Meta.APIVersion)
+	if err != nil {
+		pmLogger.WithFields(log.Fields{
+			"_block":             "load-plugin",
+			"plugin-name":        resp.Meta.Name,
+			"plugin-api-version": resp.Meta.APIVersion,
+		}).Error("load plugin error while negotiating the plugin API version")
+		return nil, serror.New(err, map[string]interface{}{
+			"plugin-name":        resp.Meta.Name,
+			"plugin-version":     resp.Meta.Version,
+			"plugin-api-version": resp.Meta.APIVersion,
+		})
+	}
+	resp.Meta.APIVersion = apiVersion
+
+	if md := lPlugin.Details.Metadata; md != nil {
+		lPlugin.Meta = resp.Meta
+		lPlugin.Type = resp.Type
+		if err := md.Matches(lPlugin); err != nil {
+			pmLogger.WithFields(log.Fields{
+				"_block": "load-plugin",
+				"error":  err.Error(),
+			}).Error("load plugin error while checking plugin metadata")
+			return nil, serror.New(err)
+		}
+	}
+
+	if err := p.access.check(resp.Meta.Name, resp.Meta.Version, lPlugin.Details.CheckSum); err != nil {
+		pmLogger.WithFields(log.Fields{
+			"_block":         "load-plugin",
+			"plugin-name":    resp.Meta.Name,
+			"plugin-version": resp.Meta.Version,
+			"error":          err.Error(),
+		}).Error("load plugin error while checking plugin access")
+		return nil, serror.New(err, map[string]interface{}{"plugin-name": resp.Meta.Name, "plugin-version": resp.Meta.Version})
+	}
+
+	ap, err := newAvailablePlugin(resp, emitter, ePlugin, pluginSecurity(ePlugin, p.security), p.timeouts.get(resp.Meta.Name))
 	if err != nil {
 		pmLogger.WithFields(log.Fields{
 			"_block": "load-plugin",
@@ -370,6 +492,7 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 		return nil, serror.New(err)
 	}
 
+	stage = loadStageHealthCheck
 	if resp.Meta.Unsecure {
 		err = ap.client.Ping()
 	} else {
@@ -385,6 +508,7 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 	}
 
 	// Get the ConfigPolicy and add it to the loaded plugin
+	stage = loadStageConfigPolicy
 	c, ok := ap.client.(plugin.Plugin)
 	if !ok {
 		return nil, serror.New(errors.New("missing GetConfigPolicy function"))
@@ -454,6 +578,7 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 			ConfigDataNode: cfgNode,
 		}
 
+		stage = loadStageMetricCatalog
 		metricTypes, err := colClient.GetMetricTypes(cfg)
 		if err != nil {
 			pmLogger.WithFields(log.Fields{
@@ -519,7 +644,10 @@ func (p *pluginManager) LoadPlugin(details *pluginDetails, emitter gomit.Emitter
 	}
 
 	// Added so clients can adequately clean up connections
-	ap.client.Kill("Retrieved necessary plugin info")
+	stage = loadStageRegistration
+	if !isRemote(ePlugin) {
+		ap.client.Kill("Retrieved necessary plugin info")
+	}
 	err = ePlugin.Kill()
 	if err != nil {
 		pmLogger.WithFields(log.Fields{
@@ -582,21 +710,24 @@ func (p *pluginManager) UnloadPlugin(pl core.Plugin) (*loadedPlugin, serror.Snap
 		"plugin-version": plugin.Version(),
 		"plugin-path":    plugin.Details.Path,
 	}).Debugf("Removing plugin")
-	if err := os.RemoveAll(filepath.Dir(plugin.Details.Path)); err != nil {
-		pmLogger.WithFields(log.Fields{
-			"plugin-type":    plugin.TypeName(),
-			"plugin-name":    plugin.Name(),
-			"plugin-version": plugin.Version(),
-			"plugin-path":    plugin.Details.Path,
-		}).Error(err)
-		se := serror.New(err)
-		se.SetFields(map[string]interface{}{
-			"plugin-type":    plugin.TypeName(),
-			"plugin-name":    plugin.Name(),
-			"plugin-version": plugin.Version(),
-			"plugin-path":    plugin.Details.Path,
-		})
-		return nil, se
+	// remote plugins have no file to remove
+	if plugin.Details.Remote == nil {
+		if err := os.RemoveAll(filepath.Dir(plugin.Details.Path)); err != nil {
+			pmLogger.WithFields(log.Fields{
+				"plugin-type":    plugin.TypeName(),
+				"plugin-name":    plugin.Name(),
+				"plugin-version": plugin.Version(),
+				"plugin-path":    plugin.Details.Path,
+			}).Error(err)
+			se := serror.New(err)
+			se.SetFields(map[string]interface{}{
+				"plugin-type":    plugin.TypeName(),
+				"plugin-name":    plugin.Name(),
+				"plugin-version": plugin.Version(),
+				"plugin-path":    plugin.Details.Path,
+			})
+			return nil, se
+		}
 	}
 	p.loadedPlugins.remove(plugin.Key())
 
@@ -610,7 +741,19 @@ func (p *pluginManager) UnloadPlugin(pl core.Plugin) (*loadedPlugin, serror.Snap
 
 // GenerateArgs generates the cli args to send when stating a plugin
 func (p *pluginManager) GenerateArgs(logLevel int) plugin.Arg {
-	return plugin.NewArg(logLevel, p.pprof)
+	arg := plugin.NewArg(logLevel, p.pprof)
+	if p.security.TLSEnabled {
+		arg.TLSEnabled = true
+		arg.CertPath = p.security.CertPath
+		arg.KeyPath = p.security.KeyPath
+		arg.CACertPaths = strings.Join(p.security.CACertPaths, string(filepath.ListSeparator))
+	}
+	return arg
+}
+
+// PluginTLS returns the TLS setup of the plugins
+func (p *pluginManager) PluginTLS() client.GRPCSecurity {
+	return p.security
 }
 
 func (p *pluginManager) teardown() {
@@ -658,6 +801,29 @@ func split(ns string) []string {
 	return strings.Split(ns, sep)
 }
 
+// sortedByNamespaceDepth returns the namespaces of the given tags ordered from
+// the least to the most specific.
+func sortedByNamespaceDepth(tagsByNs map[string]map[string]string) []string {
+	nss := make([]string, 0, len(tagsByNs))
+	for ns := range tagsByNs {
+		nss = append(nss, ns)
+	}
+	sort.Sort(byNamespaceDepth(nss))
+	return nss
+}
+
+type byNamespaceDepth []string
+
+func (b byNamespaceDepth) Len() int      { return len(b) }
+func (b byNamespaceDepth) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byNamespaceDepth) Less(i, j int) bool {
+	di, dj := len(split(b[i])), len(split(b[j]))
+	if di == dj {
+		return b[i] < b[j]
+	}
+	return di < dj
+}
+
 func (p *pluginManager) AddStandardAndWorkflowTags(m core.Metric, allTags map[string]map[string]string) core.Metric {
 	hostname := hostnameReader.Hostname()
 
@@ -668,19 +834,14 @@ func (p *pluginManager) AddStandardAndWorkflowTags(m core.Metric, allTags map[st
 	// apply standard tag
 	tags[core.STD_TAG_PLUGIN_RUNNING_ON] = hostname
 
-	// apply tags from global tags
-	for ns, nsTags := range p.pluginTags {
-		if hasPrefix(m.Namespace().Strings(), split(ns)) {
-			for k, v := range nsTags {
-				tags[k] = v
-			}
-		}
-	}
-	// apply tags from workflow
-	for ns, nsTags := range allTags {
-		if hasPrefix(m.Namespace().Strings(), split(ns)) {
-			for k, v := range nsTags {
-				tags[k] = v
+	// apply tags from global tags and then from the workflow, in both cases
+	// tags defined for a more specific namespace take precedence
+	for _, tagsByNs := range []map[string]map[string]string{p.pluginTags, allTags} {
+		for _, ns := range sortedByNamespaceDepth(tagsByNs) {
+			if hasPrefix(m.Namespace().Strings(), split(ns)) {
+				for k, v := range tagsByNs[ns] {
+					tags[k] = v
+				}
 			}
 		}
 	}
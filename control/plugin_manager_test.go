@@ -174,6 +174,21 @@ func TestLoadPlugin(t *testing.T) {
 				So(len(p.all()), ShouldEqual, 0)
 			})
 
+			Convey("a load failing at the metric catalog is rolled back", func() {
+				cfg := GetDefaultConfig()
+				cfg.Plugins.Collector.Plugins["mock"] = newPluginConfigItem(optAddPluginConfigItem("test-fail", ctypes.ConfigValueBool{Value: true}))
+				p := newPluginManager(OptSetPluginConfig(cfg.Plugins))
+				p.SetMetricCatalog(newMetricCatalog())
+				_, err := loadPlugin(p, fixtures.PluginPathMock2)
+
+				So(err, ShouldNotBeNil)
+				So(err.Fields()["load-stage"], ShouldEqual, loadStageMetricCatalog)
+				So(err.Fields()["rolled-back"], ShouldBeTrue)
+				So(err.Fields()["plugin-name"], ShouldEqual, "mock")
+				So(p.all(), ShouldBeEmpty)
+				So(p.metricCatalog.Keys(), ShouldBeEmpty)
+			})
+
 			Convey("loads native-rpc plugin successfully", func() {
 				p := newPluginManager()
 				p.SetMetricCatalog(newMetricCatalog())
@@ -0,0 +1,121 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+const (
+	// deadPluginOutputSize is the size, in bytes, of the last output of a
+	// dead plugin given in its Control.AvailablePluginDead event
+	deadPluginOutputSize = 4 << 10
+)
+
+// PluginOutputConfig sets how the output of the plugin processes, their
+// stdout and stderr, is kept.
+type PluginOutputConfig struct {
+	// BufferKB is the size, in kilobytes, of the last output kept in
+	// memory for each plugin process
+	BufferKB int `json:"buffer_kb"yaml:"buffer_kb"`
+	// Dir is the directory the output of each plugin process is also
+	// written to, in a file named after the plugin and its pid
+	Dir string `json:"dir,omitempty"yaml:"dir"`
+}
+
+func newPluginOutputConfig() *PluginOutputConfig {
+	return &PluginOutputConfig{
+		BufferKB: plugin.OutputBufferSize >> 10,
+	}
+}
+
+// PluginOutput sets the size of the output kept for each plugin process and
+// the directory it is written to.
+func PluginOutput(cfg *Config) PluginControlOpt {
+	return func(c *pluginControl) {
+		if cfg.PluginOutput == nil {
+			return
+		}
+		plugin.OutputBufferSize = cfg.PluginOutput.BufferKB << 10
+		if cfg.PluginOutput.Dir != "" {
+			if err := os.MkdirAll(cfg.PluginOutput.Dir, 0750); err != nil {
+				controlLogger.WithFields(log.Fields{
+					"_block": "plugin-output",
+					"dir":    cfg.PluginOutput.Dir,
+				}).Error(err)
+				return
+			}
+		}
+		plugin.OutputDir = cfg.PluginOutput.Dir
+	}
+}
+
+// outputsPlugin is implemented by the executable plugins keeping their last
+// output.
+type outputsPlugin interface {
+	Output(n int) []byte
+}
+
+// Output returns the last n bytes of the output of the plugin process, all
+// of the ones kept when n is 0.
+func (a *availablePlugin) Output(n int) []byte {
+	if o, ok := a.ePlugin.(outputsPlugin); ok {
+		return o.Output(n)
+	}
+	return nil
+}
+
+// PluginOutput returns the last n bytes of the output of each running
+// instance of the plugin, by instance id, all of the ones kept when n is 0.
+// The latest version of the plugin is used when version is less than 1.
+func (p *pluginControl) PluginOutput(typeName, name string, version int, n int) (map[uint32][]byte, serror.SnapError) {
+	f := map[string]interface{}{
+		"plugin-type":    typeName,
+		"plugin-name":    name,
+		"plugin-version": version,
+	}
+	key := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", typeName, name, version)
+	if _, err := p.pluginManager.get(key); err != nil {
+		return nil, serror.New(ErrPluginNotFound, f)
+	}
+	out := map[uint32][]byte{}
+	pool, serr := p.pluginRunner.AvailablePlugins().getPool(key)
+	if serr != nil {
+		return nil, serr
+	}
+	if pool == nil {
+		return out, nil
+	}
+	pool.RLock()
+	defer pool.RUnlock()
+	for id, a := range pool.Plugins() {
+		if ap, ok := a.(*availablePlugin); ok {
+			out[id] = ap.Output(n)
+		}
+	}
+	return out, nil
+}
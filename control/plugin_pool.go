@@ -0,0 +1,85 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/strategy"
+	"github.com/intelsdi-x/snap/core"
+)
+
+// PluginPool sizes the pool of the running instances of a plugin, across
+// which the work of the tasks subscribed to the plugin is balanced.
+type PluginPool struct {
+	// Min is the number of instances started as soon as a task subscribes
+	// to the plugin and kept running while tasks are subscribed to it
+	Min int `json:"min,omitempty"yaml:"min"`
+	// Max caps the number of instances of the plugin, in place of
+	// max_running_plugins
+	Max int `json:"max,omitempty"yaml:"max"`
+	// Standby is the number of instances kept running on top of the ones
+	// the tasks need, so that one takes over at once when an instance dies
+	Standby int `json:"standby,omitempty"yaml:"standby"`
+	// Routing overrides the routing strategy declared by the plugin, one of
+	// least-recently-used, sticky, config or task-hash
+	Routing string `json:"routing,omitempty"yaml:"routing"`
+}
+
+// applyPluginPool sizes the pool p and sets its routing strategy as set by
+// size.
+func applyPluginPool(p strategy.Pool, size *PluginPool) {
+	p.SetSize(size.Min, size.Max)
+	p.SetStandby(size.Standby)
+	if size.Routing == "" {
+		return
+	}
+	for r := plugin.DefaultRouting; r <= plugin.TaskHashRouting; r++ {
+		if r.String() == size.Routing {
+			p.SetRouting(r)
+			return
+		}
+	}
+	controlLogger.WithFields(log.Fields{
+		"_block":  "plugin-pool",
+		"routing": size.Routing,
+	}).Error(strategy.ErrBadStrategy)
+}
+
+// SetPluginPools sets the pool sizes of the plugins by name. The pools
+// already created keep their size.
+func (ap *availablePlugins) SetPluginPools(pools map[string]*PluginPool) {
+	ap.Lock()
+	defer ap.Unlock()
+	ap.sizes = pools
+}
+
+// poolPluginName returns the name of the plugin of a pool given its key,
+// {plugin_type}:{plugin_name}:{plugin_version}
+func poolPluginName(key string) string {
+	tnv := strings.Split(key, core.Separator)
+	if len(tnv) != 3 {
+		return ""
+	}
+	return tnv[1]
+}
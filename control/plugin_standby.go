@@ -0,0 +1,55 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin/client"
+	"github.com/intelsdi-x/snap/control/strategy"
+	"github.com/intelsdi-x/snap/core/control_event"
+)
+
+// failOver removes from its pool the plugin p which call failed with err
+// when the plugin cannot be reached anymore and another plugin of the pool,
+// such as a standby one, can take over at once. The plugin is then handled
+// as dead, a new one being started in its place.
+func failOver(pool strategy.Pool, p *availablePlugin, err error) bool {
+	if !client.IsUnavailable(err) || pool.Count() < 2 {
+		return false
+	}
+	runnerLog.WithFields(log.Fields{
+		"_block":  "fail-over",
+		"aplugin": p.String(),
+		"error":   err.Error(),
+	}).Warning("plugin unavailable, failing over to another instance")
+	pool.Kill(p.ID(), "plugin unavailable")
+	recordCrash(p, "plugin unavailable")
+	p.emitter.Emit(&control_event.DeadAvailablePluginEvent{
+		Name:    p.name,
+		Version: p.version,
+		Type:    int(p.pluginType),
+		Key:     p.key,
+		Id:      p.ID(),
+		String:  p.String(),
+		Output:  string(p.Output(deadPluginOutputSize)),
+	})
+	return true
+}
@@ -0,0 +1,71 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin/client"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/vrischmann/jsonutil"
+)
+
+// pluginTimeouts holds the timeout of the calls made to the plugins by name,
+// overriding DefaultClientTimeout. It is not modified once set.
+type pluginTimeouts map[string]time.Duration
+
+// OptSetPluginTimeouts is the PluginControlOpt which sets the timeout of the
+// calls made to the plugins by name
+func OptSetPluginTimeouts(timeouts map[string]jsonutil.Duration) PluginControlOpt {
+	return func(c *pluginControl) {
+		t := make(pluginTimeouts, len(timeouts))
+		for name, timeout := range timeouts {
+			t[name] = timeout.Duration
+		}
+		c.pluginManager.SetPluginTimeouts(t)
+		c.pluginRunner.SetPluginTimeouts(t)
+	}
+}
+
+// get returns the timeout of the calls made to the named plugin
+func (t pluginTimeouts) get(name string) time.Duration {
+	if d, ok := t[name]; ok && d > 0 {
+		return d
+	}
+	return DefaultClientTimeout
+}
+
+// callError wraps the error of a call to the available plugin, turning the
+// errors due to the plugin not answering in time into ErrPluginTimeout.
+func callError(ap *availablePlugin, timeout time.Duration, err error) serror.SnapError {
+	if !client.IsTimeout(err) {
+		return serror.New(err)
+	}
+	if timeout <= 0 {
+		timeout = ap.timeout
+	}
+	return serror.New(core.ErrPluginTimeout, map[string]interface{}{
+		"plugin-name":    ap.name,
+		"plugin-version": ap.version,
+		"plugin-type":    ap.pluginType.String(),
+		"timeout":        timeout.String(),
+	})
+}
@@ -0,0 +1,71 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
+)
+
+// ErrPluginTLSRequired is returned when a plugin does not serve over TLS
+// while snapteld requires it.
+var ErrPluginTLSRequired = errors.New("plugin does not support TLS, which is required by plugin_tls")
+
+// PluginTLSConfig sets up mutual TLS between snapteld and its gRPC plugins,
+// so that the metrics they exchange are encrypted and neither side can be
+// impersonated by another local process. The plugins are given the
+// certificate and key to serve with, which must be valid for their listen
+// address, 127.0.0.1.
+type PluginTLSConfig struct {
+	// CertPath is the certificate snapteld and its plugins authenticate with
+	CertPath string `json:"cert_path"yaml:"cert_path"`
+	// KeyPath is the private key of the certificate
+	KeyPath string `json:"key_path"yaml:"key_path"`
+	// CACertPaths are the CA certificate files, or directories of them,
+	// separated by colons, the certificates are checked against. The
+	// system CA certificates are used when it is empty.
+	CACertPaths string `json:"ca_cert_paths,omitempty"yaml:"ca_cert_paths"`
+}
+
+// security returns the TLS setup of the connections to plugins, TLS being
+// disabled when c is nil.
+func (c *PluginTLSConfig) security() client.GRPCSecurity {
+	if c == nil {
+		return client.GRPCSecurity{}
+	}
+	return client.GRPCSecurity{
+		TLSEnabled:  true,
+		CertPath:    c.CertPath,
+		KeyPath:     c.KeyPath,
+		CACertPaths: filepath.SplitList(c.CACertPaths),
+	}
+}
+
+// checkPluginTLS refuses the plugins which do not serve over TLS when
+// snapteld requires it.
+func checkPluginTLS(security client.GRPCSecurity, meta plugin.PluginMeta) error {
+	if security.TLSEnabled && !meta.TLSEnabled {
+		return ErrPluginTLSRequired
+	}
+	return nil
+}
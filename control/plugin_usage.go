@@ -0,0 +1,168 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+const (
+	// procRoot is where the proc filesystem the usage of plugins is read
+	// from is mounted
+	procRoot = "/proc"
+	// clockTicks is the unit of the CPU times of /proc/[pid]/stat, the
+	// USER_HZ of Linux
+	clockTicks = 100
+	// rpcLatencyWeight is the weight of the last call in the moving average
+	// of the duration of the calls made to a plugin
+	rpcLatencyWeight = 0.2
+)
+
+var errBadProcStat = errors.New("unexpected format of the proc stat file")
+
+// pluginUsage samples the resource usage of a running plugin
+type pluginUsage struct {
+	sync.Mutex
+	usage core.PluginResourceUsage
+	// cpuTime is the CPU time used by the plugin up to sampled
+	cpuTime time.Duration
+	sampled time.Time
+}
+
+// observeRPC adds the duration of a call made to the plugin to the moving
+// average of the durations of its calls
+func (u *pluginUsage) observeRPC(d time.Duration) {
+	if u == nil {
+		return
+	}
+	u.Lock()
+	defer u.Unlock()
+	ms := float64(d) / float64(time.Millisecond)
+	if u.usage.RPCLatency == 0 {
+		u.usage.RPCLatency = ms
+		return
+	}
+	u.usage.RPCLatency += rpcLatencyWeight * (ms - u.usage.RPCLatency)
+}
+
+// sample reads the CPU time, resident memory and open file descriptors of the
+// process pid. Hosts without a proc filesystem are not sampled.
+func (u *pluginUsage) sample(pid int) error {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	cpuTime, rss, err := parseProcStat(string(stat))
+	if err != nil {
+		return err
+	}
+	fds, err := ioutil.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	u.Lock()
+	defer u.Unlock()
+	if !u.sampled.IsZero() && now.After(u.sampled) {
+		u.usage.CPUPercent = 100 * float64(cpuTime-u.cpuTime) / float64(now.Sub(u.sampled))
+	}
+	u.cpuTime = cpuTime
+	u.sampled = now
+	u.usage.RSS = rss
+	u.usage.OpenFDs = len(fds)
+	return nil
+}
+
+// get returns the last sampled usage
+func (u *pluginUsage) get() core.PluginResourceUsage {
+	if u == nil {
+		return core.PluginResourceUsage{}
+	}
+	u.Lock()
+	defer u.Unlock()
+	return u.usage
+}
+
+// parseProcStat returns the CPU time, user and system, and the resident
+// memory of a process given its /proc/[pid]/stat file
+func parseProcStat(stat string) (time.Duration, uint64, error) {
+	// the command name, in parentheses, may hold spaces
+	i := strings.LastIndex(stat, ")")
+	if i < 0 {
+		return 0, 0, errBadProcStat
+	}
+	// the fields following the command name, starting with the state
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 22 {
+		return 0, 0, errBadProcStat
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, errBadProcStat
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, errBadProcStat
+	}
+	pages, err := strconv.ParseUint(fields[21], 10, 64)
+	if err != nil {
+		return 0, 0, errBadProcStat
+	}
+	cpuTime := time.Duration(utime+stime) * time.Second / clockTicks
+	return cpuTime, pages * uint64(os.Getpagesize()), nil
+}
+
+// ResourceUsage returns the resource usage of the plugin last sampled
+func (a *availablePlugin) ResourceUsage() core.PluginResourceUsage {
+	return a.usage.get()
+}
+
+// sampleUsage samples the resource usage of the plugin process, when the
+// process is run by snapteld
+func (a *availablePlugin) sampleUsage() {
+	ep, ok := a.ePlugin.(interface {
+		Pid() int
+	})
+	if !ok || ep.Pid() <= 0 || a.usage == nil {
+		return
+	}
+	if err := a.usage.sample(ep.Pid()); err != nil {
+		runnerLog.WithFields(log.Fields{
+			"_block":  "sample-usage",
+			"aplugin": a.String(),
+		}).Debug(err)
+	}
+}
@@ -0,0 +1,57 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginUsage(t *testing.T) {
+	Convey("Parsing the proc stat file of a plugin", t, func() {
+		stat := "1234 (snap-plugin-collector mock) S 1 1234 1234 0 -1 4194560 1000 0 0 0 250 150 0 0 20 0 8 0 100 500000000 2048 18446744073709551615"
+
+		Convey("gives its CPU time and resident memory", func() {
+			cpuTime, rss, err := parseProcStat(stat)
+			So(err, ShouldBeNil)
+			So(cpuTime, ShouldEqual, 4*time.Second)
+			So(rss, ShouldEqual, 2048*uint64(os.Getpagesize()))
+		})
+		Convey("fails on a truncated file", func() {
+			_, _, err := parseProcStat("1234 (mock) S 1")
+			So(err, ShouldEqual, errBadProcStat)
+		})
+	})
+	Convey("The RPC latency of a plugin", t, func() {
+		u := &pluginUsage{}
+
+		Convey("is a moving average of its calls", func() {
+			u.observeRPC(10 * time.Millisecond)
+			So(u.get().RPCLatency, ShouldEqual, 10)
+			u.observeRPC(20 * time.Millisecond)
+			So(u.get().RPCLatency, ShouldAlmostEqual, 12)
+		})
+	})
+}
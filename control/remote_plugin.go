@@ -0,0 +1,161 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/control_event"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+var (
+	// ErrRemotePluginName is returned for a remote plugin given without a
+	// name or version
+	ErrRemotePluginName = errors.New("remote plugin requires a name and a version")
+	// ErrRemotePluginAddress is returned for a remote plugin whose address
+	// is not given as host:port
+	ErrRemotePluginAddress = errors.New("remote plugin address must be given as host:port")
+)
+
+// RemotePlugin is a gRPC plugin served by another host, e.g. an appliance
+// snapteld cannot run on, which snapteld connects to instead of running it.
+// As the plugin does not announce itself, its name, version and type are
+// given here and its process is never started nor stopped by snapteld.
+type RemotePlugin struct {
+	// Name, Version and Type are the ones the plugin is cataloged with
+	Name    string `json:"name"yaml:"name"`
+	Version int    `json:"version"yaml:"version"`
+	Type    string `json:"type"yaml:"type"`
+	// Address is the host:port the plugin serves on
+	Address string `json:"address"yaml:"address"`
+	// Streaming tells the collector streams its metrics
+	Streaming bool `json:"streaming,omitempty"yaml:"streaming"`
+	// TLS sets the client certificate snapteld authenticates to the plugin
+	// with and the CA certificates the plugin is checked against. The
+	// connection is not encrypted when it is not set.
+	TLS *PluginTLSConfig `json:"tls,omitempty"yaml:"tls"`
+}
+
+// remotePlugin stands for the executable of a remote plugin, its start
+// giving the response the plugin would have announced.
+type remotePlugin struct {
+	cfg        *RemotePlugin
+	pluginType plugin.PluginType
+}
+
+func newRemotePlugin(cfg *RemotePlugin) (*remotePlugin, error) {
+	if cfg.Name == "" || cfg.Version < 1 {
+		return nil, ErrRemotePluginName
+	}
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, ErrRemotePluginAddress
+	}
+	t, err := core.ToPluginType(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &remotePlugin{cfg: cfg, pluginType: plugin.PluginType(t)}, nil
+}
+
+// Run returns the response of the remote plugin, reached by snapteld with a
+// ping once its client is created.
+func (r *remotePlugin) Run(time.Duration) (plugin.Response, error) {
+	rpcType := plugin.GRPC
+	if r.cfg.Streaming {
+		rpcType = plugin.STREAMGRPC
+	}
+	return plugin.Response{
+		Meta: plugin.PluginMeta{
+			Name:                 r.cfg.Name,
+			Version:              r.cfg.Version,
+			Type:                 r.pluginType,
+			RPCType:              rpcType,
+			AcceptedContentTypes: []string{plugin.SnapAllContentType},
+			ReturnedContentTypes: []string{plugin.SnapAllContentType},
+			Exclusive:            true,
+			Unsecure:             true,
+			TLSEnabled:           r.cfg.TLS != nil,
+		},
+		ListenAddress: r.cfg.Address,
+		Type:          r.pluginType,
+		State:         plugin.PluginSuccess,
+	}, nil
+}
+
+// Kill does nothing, the process of a remote plugin not being snapteld's.
+func (r *remotePlugin) Kill() error {
+	return nil
+}
+
+// isRemote tells whether the executable is the one of a remote plugin.
+func isRemote(ep executablePlugin) bool {
+	_, ok := ep.(*remotePlugin)
+	return ok
+}
+
+// pluginSecurity returns the TLS setup of the connections to the plugin,
+// remote plugins having their own.
+func pluginSecurity(ep executablePlugin, security client.GRPCSecurity) client.GRPCSecurity {
+	if r, ok := ep.(*remotePlugin); ok {
+		return r.cfg.TLS.security()
+	}
+	return security
+}
+
+// loadRemotePlugin loads the remote plugin, adding it and its metrics to
+// the catalog.
+func (p *pluginControl) loadRemotePlugin(cfg *RemotePlugin) (core.CatalogedPlugin, serror.SnapError) {
+	r, err := newRemotePlugin(cfg)
+	if err != nil {
+		return nil, serror.New(err, map[string]interface{}{
+			"plugin-name": cfg.Name,
+			"address":     cfg.Address,
+		})
+	}
+	details := &pluginDetails{
+		Exec:   []string{cfg.Name},
+		Remote: r,
+	}
+	pl, se := p.pluginManager.LoadPlugin(details, p.eventManager)
+	if se != nil {
+		return nil, se
+	}
+	controlLogger.WithFields(log.Fields{
+		"_block":         "load-remote-plugin",
+		"plugin-name":    pl.Name(),
+		"plugin-version": pl.Version(),
+		"plugin-type":    pl.TypeName(),
+		"address":        cfg.Address,
+	}).Info("remote plugin loaded")
+	defer p.eventManager.Emit(&control_event.LoadPluginEvent{
+		Name:    pl.Meta.Name,
+		Version: pl.Meta.Version,
+		Type:    int(pl.Meta.Type),
+	})
+	return pl, nil
+}
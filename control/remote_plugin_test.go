@@ -0,0 +1,70 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRemotePlugin(t *testing.T) {
+	Convey("Remote plugin", t, func() {
+		Convey("requires a name, a version, a type and an address", func() {
+			_, err := newRemotePlugin(&RemotePlugin{Version: 1, Type: "collector", Address: "10.0.0.7:8183"})
+			So(err, ShouldEqual, ErrRemotePluginName)
+			_, err = newRemotePlugin(&RemotePlugin{Name: "ipmi", Version: 1, Type: "collector", Address: "10.0.0.7"})
+			So(err, ShouldEqual, ErrRemotePluginAddress)
+			_, err = newRemotePlugin(&RemotePlugin{Name: "ipmi", Version: 1, Type: "exporter", Address: "10.0.0.7:8183"})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("answers with the response of the plugin at its address", func() {
+			r, err := newRemotePlugin(&RemotePlugin{Name: "ipmi", Version: 3, Type: "collector", Address: "10.0.0.7:8183", Streaming: true})
+			So(err, ShouldBeNil)
+			resp, err := r.Run(0)
+			So(err, ShouldBeNil)
+			So(resp.State, ShouldEqual, plugin.PluginSuccess)
+			So(resp.Type, ShouldEqual, plugin.CollectorPluginType)
+			So(resp.ListenAddress, ShouldEqual, "10.0.0.7:8183")
+			So(resp.Meta.Name, ShouldEqual, "ipmi")
+			So(resp.Meta.Version, ShouldEqual, 3)
+			So(resp.Meta.RPCType, ShouldEqual, plugin.STREAMGRPC)
+			So(resp.Meta.Exclusive, ShouldBeTrue)
+			So(resp.Meta.TLSEnabled, ShouldBeFalse)
+			So(r.Kill(), ShouldBeNil)
+		})
+		Convey("is connected to with its own TLS setup", func() {
+			tls := &PluginTLSConfig{CertPath: "snapteld.crt", KeyPath: "snapteld.key"}
+			r, err := newRemotePlugin(&RemotePlugin{Name: "ipmi", Version: 3, Type: "collector", Address: "10.0.0.7:8183", TLS: tls})
+			So(err, ShouldBeNil)
+			resp, _ := r.Run(0)
+			So(resp.Meta.TLSEnabled, ShouldBeTrue)
+			So(isRemote(r), ShouldBeTrue)
+			So(pluginSecurity(r, client.GRPCSecurity{}), ShouldResemble, tls.security())
+			local := client.GRPCSecurity{TLSEnabled: true, CertPath: "local.crt"}
+			So(pluginSecurity(&plugin.ExecutablePlugin{}, local), ShouldResemble, local)
+		})
+	})
+}
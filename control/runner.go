@@ -24,12 +24,15 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/gomit"
 
 	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/control/plugin/client"
+	"github.com/intelsdi-x/snap/control/strategy"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/control_event"
 	"github.com/intelsdi-x/snap/pkg/aci"
@@ -57,6 +60,14 @@ var (
 	// MaximumRestartOnDeadPluginEvent is the maximum count of restarting a plugin
 	// after the event of control_event.DeadAvailablePluginEvent
 	MaxPluginRestartCount = 3
+	// PluginRestartBackoff is the delay before the first restart of a dead
+	// plugin, doubled on each of the following restarts of its pool
+	PluginRestartBackoff = time.Duration(0)
+)
+
+const (
+	// maxPluginRestartBackoff caps the delay before the restart of a plugin
+	maxPluginRestartBackoff = time.Minute
 )
 
 type executablePlugin interface {
@@ -72,6 +83,12 @@ type runner struct {
 	availablePlugins *availablePlugins
 	metricCatalog    catalogsMetrics
 	pluginManager    managesPlugins
+	placement        map[string]*PluginPlacement
+	limits           map[string]*PluginLimits
+	confinement      map[string]*PluginConfinement
+	timeouts         pluginTimeouts
+	idleTimeout      time.Duration
+	wake             sync.Mutex
 }
 
 func newRunner() *runner {
@@ -94,6 +111,27 @@ func (r *runner) SetPluginManager(m managesPlugins) {
 	r.pluginManager = m
 }
 
+func (r *runner) SetPluginPlacement(p map[string]*PluginPlacement) {
+	r.placement = p
+}
+
+func (r *runner) SetPluginLimits(l map[string]*PluginLimits) {
+	r.limits = l
+}
+
+func (r *runner) SetPluginConfinement(c map[string]*PluginConfinement) {
+	r.confinement = c
+}
+
+func (r *runner) SetPluginTimeouts(t pluginTimeouts) {
+	r.timeouts = t
+}
+
+func (r *runner) SetPluginIdleTimeout(d time.Duration) {
+	r.idleTimeout = d
+	r.monitor.Option(monitorIdleTimeoutOption(d))
+}
+
 func (r *runner) AvailablePlugins() *availablePlugins {
 	return r.availablePlugins
 }
@@ -175,8 +213,13 @@ func (r *runner) startPlugin(p executablePlugin) (*availablePlugin, error) {
 		return nil, e
 	}
 
-	// build availablePlugin
-	ap, err := newAvailablePlugin(resp, r.emitter, p)
+	// build availablePlugin, with the TLS setup of the plugin manager when
+	// the runner has one
+	var security client.GRPCSecurity
+	if r.pluginManager != nil {
+		security = r.pluginManager.PluginTLS()
+	}
+	ap, err := newAvailablePlugin(resp, r.emitter, p, pluginSecurity(p, security), r.timeouts.get(resp.Meta.Name))
 	if err != nil {
 		return nil, err
 	}
@@ -240,34 +283,41 @@ func (r *runner) HandleGomitEvent(e gomit.Event) {
 		}
 
 		if pool != nil {
+			ap, ok := pool.Plugins()[v.Id].(*availablePlugin)
+			if ok && ap.cgroups.memoryExceeded() {
+				runnerLog.WithFields(log.Fields{
+					"_block":  "handle-events",
+					"aplugin": v.String,
+				}).Warning("plugin exceeded its memory limit")
+				r.emitter.Emit(&control_event.PluginResourceLimitExceededEvent{
+					Id:       v.Id,
+					Name:     v.Name,
+					Version:  v.Version,
+					Key:      v.Key,
+					Type:     v.Type,
+					Resource: "memory",
+				})
+			}
 			pool.Kill(v.Id, "plugin dead")
+			if ok {
+				recordCrash(ap, "plugin dead")
+			}
 		}
 
 		if pool.Eligible() {
 			if pool.RestartCount() < MaxPluginRestartCount || MaxPluginRestartCount == -1 {
-				e := r.restartPlugin(v.Key)
-				if e != nil {
+				// the restarts of a crashing plugin are spaced out so that
+				// it is not relaunched in a tight loop
+				if d := restartBackoff(pool.RestartCount()); d > 0 {
 					runnerLog.WithFields(log.Fields{
 						"_block":  "handle-events",
 						"aplugin": v.String,
-					}).Error(e.Error())
-					return
+						"backoff": d,
+					}).Info("restarting plugin after backoff")
+					time.AfterFunc(d, func() { r.restartDeadPlugin(v, pool) })
+				} else {
+					r.restartDeadPlugin(v, pool)
 				}
-				pool.IncRestartCount()
-
-				runnerLog.WithFields(log.Fields{
-					"_block":        "handle-events",
-					"aplugin":       v.String,
-					"restart-count": pool.RestartCount(),
-				}).Warning("plugin restarted")
-
-				r.emitter.Emit(&control_event.RestartedAvailablePluginEvent{
-					Id:      v.Id,
-					Name:    v.Name,
-					Version: v.Version,
-					Key:     v.Key,
-					Type:    v.Type,
-				})
 			} else {
 				runnerLog.WithFields(log.Fields{
 					"_block":  "handle-events",
@@ -305,6 +355,18 @@ func (r *runner) HandleGomitEvent(e gomit.Event) {
 }
 
 func (r *runner) runPlugin(name string, details *pluginDetails) error {
+	if details.Remote != nil {
+		if _, err := r.startPlugin(details.Remote); err != nil {
+			runnerLog.WithFields(log.Fields{
+				"_block":  "run-plugin",
+				"plugin":  name,
+				"address": details.Remote.cfg.Address,
+				"error":   err,
+			}).Error("error connecting to remote plugin")
+			return err
+		}
+		return nil
+	}
 	if details.IsPackage {
 		f, err := os.Open(details.Path)
 		if err != nil {
@@ -321,7 +383,35 @@ func (r *runner) runPlugin(name string, details *pluginDetails) error {
 	for i, e := range details.Exec {
 		commands[i] = path.Join(details.ExecPath, e)
 	}
-	ePlugin, err := plugin.NewExecutablePlugin(r.pluginManager.GenerateArgs(int(log.GetLevel())), commands...)
+	// plugins run in a container are placed, limited and confined by the
+	// container runtime rather than wrapped and put in cgroups here
+	inContainer := isContainerized(details)
+	var containerArgs []string
+	if inContainer {
+		containerArgs = append(r.placement[name].containerArgs(), r.limits[name].containerArgs()...)
+		containerArgs = append(containerArgs, r.confinement[name].containerArgs()...)
+	} else {
+		var err error
+		commands, err = r.confinement[name].command(commands)
+		if err != nil {
+			runnerLog.WithFields(log.Fields{
+				"_block": "run-plugin",
+				"plugin": name,
+				"error":  err,
+			}).Error("error applying plugin confinement")
+			return err
+		}
+		commands, err = r.placement[name].command(commands)
+		if err != nil {
+			runnerLog.WithFields(log.Fields{
+				"_block": "run-plugin",
+				"plugin": name,
+				"error":  err,
+			}).Error("error applying plugin placement")
+			return err
+		}
+	}
+	ePlugin, err := newContainerPlugin(r.pluginManager.GenerateArgs(int(log.GetLevel())), details, containerArgs, commands)
 	if err != nil {
 		runnerLog.WithFields(log.Fields{
 			"_block": "run-plugin",
@@ -344,6 +434,24 @@ func (r *runner) runPlugin(name string, details *pluginDetails) error {
 	if details.IsPackage {
 		ap.fromPackage = true
 	}
+	if l := r.limits[name]; l != nil && !inContainer {
+		pid := 0
+		if p, ok := ap.ePlugin.(interface {
+			Pid() int
+		}); ok {
+			pid = p.Pid()
+		}
+		ap.cgroups, err = l.apply(name, pid)
+		if err != nil {
+			runnerLog.WithFields(log.Fields{
+				"_block": "run-plugin",
+				"plugin": name,
+				"error":  err,
+			}).Error("error applying plugin resource limits")
+			r.stopPlugin("resource limits not applied", ap)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -367,7 +475,7 @@ func (r *runner) handleUnsubscription(pType, pName string, pVersion int, taskID
 		}).Error("pool not found")
 		return errors.New("pool not found")
 	}
-	if pool.SubscriptionCount() < pool.Count() {
+	if pool.Shrinkable() {
 		runnerLog.WithFields(log.Fields{
 			"_block":                  "handle-unsubscription",
 			"pool-count":              pool.Count(),
@@ -378,6 +486,51 @@ func (r *runner) handleUnsubscription(pType, pName string, pVersion int, taskID
 	return nil
 }
 
+// restartBackoff returns the delay before the restart of a dead plugin which
+// pool was restarted n times already
+func restartBackoff(n int) time.Duration {
+	if PluginRestartBackoff <= 0 {
+		return 0
+	}
+	d := PluginRestartBackoff
+	for i := 0; i < n && d < maxPluginRestartBackoff; i++ {
+		d *= 2
+	}
+	if d > maxPluginRestartBackoff {
+		d = maxPluginRestartBackoff
+	}
+	return d
+}
+
+// restartDeadPlugin launches a new instance of a dead plugin in its pool. The
+// subscriptions of the pool are kept, so that the tasks collecting from the
+// dead instance are served by the new one.
+func (r *runner) restartDeadPlugin(v *control_event.DeadAvailablePluginEvent, pool strategy.Pool) {
+	e := r.restartPlugin(v.Key)
+	if e != nil {
+		runnerLog.WithFields(log.Fields{
+			"_block":  "handle-events",
+			"aplugin": v.String,
+		}).Error(e.Error())
+		return
+	}
+	pool.IncRestartCount()
+
+	runnerLog.WithFields(log.Fields{
+		"_block":        "handle-events",
+		"aplugin":       v.String,
+		"restart-count": pool.RestartCount(),
+	}).Warning("plugin restarted")
+
+	r.emitter.Emit(&control_event.RestartedAvailablePluginEvent{
+		Id:      v.Id,
+		Name:    v.Name,
+		Version: v.Version,
+		Key:     v.Key,
+		Type:    v.Type,
+	})
+}
+
 func (r *runner) restartPlugin(key string) error {
 	lp, err := r.pluginManager.get(key)
 	if err != nil {
@@ -0,0 +1,50 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	Convey("Given a plugin restart backoff", t, func() {
+		defer func(d time.Duration) { PluginRestartBackoff = d }(PluginRestartBackoff)
+		PluginRestartBackoff = time.Second
+
+		Convey("the delay doubles on each restart", func() {
+			So(restartBackoff(0), ShouldEqual, time.Second)
+			So(restartBackoff(1), ShouldEqual, 2*time.Second)
+			So(restartBackoff(3), ShouldEqual, 8*time.Second)
+		})
+		Convey("the delay is capped", func() {
+			So(restartBackoff(6), ShouldEqual, maxPluginRestartBackoff)
+			So(restartBackoff(1000), ShouldEqual, maxPluginRestartBackoff)
+		})
+		Convey("plugins are restarted at once without backoff", func() {
+			PluginRestartBackoff = 0
+			So(restartBackoff(2), ShouldEqual, 0)
+		})
+	})
+}
@@ -463,6 +463,21 @@ func TestRunnerPluginRunning(t *testing.T) {
 						So(ap.failedHealthChecks, ShouldEqual, 3)
 					})
 
+					Convey("failed health checks are reported by the plugin", func() {
+						r := newRunner()
+						r.SetEmitter(new(MockEmitter))
+						a := plugin.Arg{}
+						exPlugin, err := newExecutablePlugin(a, fixtures.PluginPathMock2)
+						So(err, ShouldBeNil)
+						ap, e := r.startPlugin(exPlugin)
+						So(e, ShouldBeNil)
+						So(ap.LastHealthCheck().IsZero(), ShouldBeTrue)
+						ap.client = new(MockUnhealthyPluginCollectorClient)
+						ap.CheckHealth()
+						So(ap.FailedHealthChecks(), ShouldEqual, 1)
+						So(ap.LastHealthCheck().IsZero(), ShouldBeFalse)
+					})
+
 					Convey("should return error for Run error", func() {
 						r := newRunner()
 						r.SetEmitter(new(MockEmitter))
@@ -20,12 +20,17 @@ limitations under the License.
 package strategy
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/pkg/chrono"
 )
 
@@ -33,6 +38,11 @@ import (
 // A plugin can override the GlobalCacheExpiration (default).
 var GlobalCacheExpiration time.Duration
 
+// MetricCacheTTL overrides the cache TTL of the metrics under the namespaces
+// it maps, the longest namespace matching a metric applying. A TTL of 0
+// disables the caching of the metrics.
+var MetricCacheTTL map[string]time.Duration
+
 var (
 	cacheLog = log.WithField("_module", "routing-cache")
 
@@ -59,14 +69,56 @@ func NewCache(expiration time.Duration) *cache {
 	}
 }
 
-func (c *cache) get(ns string, version int) interface{} {
+// ConfigDigest returns the digest of a metric config, which the collected
+// metrics are cached with so that the values collected with a config are
+// not returned for another one. It is empty for an empty config.
+func ConfigDigest(cfg *cdata.ConfigDataNode) string {
+	if cfg == nil {
+		return ""
+	}
+	table := cfg.Table()
+	if len(table) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%v\n", k, table[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func cacheKey(ns string, version int, digest string) string {
+	if digest == "" {
+		return fmt.Sprintf("%v:%v", ns, version)
+	}
+	return fmt.Sprintf("%v:%v:%v", ns, version, digest)
+}
+
+// ttlOf returns the TTL of the metrics of the namespace
+func (c *cache) ttlOf(ns string) time.Duration {
+	ttl, longest := c.ttl, -1
+	for prefix, t := range MetricCacheTTL {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if len(prefix) > longest && (ns == prefix || strings.HasPrefix(ns, prefix+"/")) {
+			ttl, longest = t, len(prefix)
+		}
+	}
+	return ttl
+}
+
+func (c *cache) get(ns string, version int, digest string) interface{} {
 	var (
 		cell *cachecell
 		ok   bool
 	)
 
-	key := fmt.Sprintf("%v:%v", ns, version)
-	if cell, ok = c.table[key]; ok && chrono.Chrono.Now().Sub(cell.time) < c.ttl {
+	key := cacheKey(ns, version, digest)
+	if cell, ok = c.table[key]; ok && chrono.Chrono.Now().Sub(cell.time) < c.ttlOf(ns) {
 		cell.hits++
 		cacheLog.WithFields(log.Fields{
 			"namespace": key,
@@ -93,8 +145,8 @@ func (c *cache) get(ns string, version int) interface{} {
 	return nil
 }
 
-func (c *cache) put(ns string, version int, m interface{}) {
-	key := fmt.Sprintf("%v:%v", ns, version)
+func (c *cache) put(ns string, version int, digest string, m interface{}) {
+	key := cacheKey(ns, version, digest)
 	switch metric := m.(type) {
 	case core.Metric:
 		if _, ok := c.table[key]; ok {
@@ -124,9 +176,9 @@ func (c *cache) put(ns string, version int, m interface{}) {
 	}
 }
 
-func (c *cache) checkCache(mts []core.Metric) (metricsToCollect []core.Metric, fromCache []core.Metric) {
+func (c *cache) checkCache(mts []core.Metric, digest string) (metricsToCollect []core.Metric, fromCache []core.Metric) {
 	for _, mt := range mts {
-		if m := c.get(mt.Namespace().String(), mt.Version()); m != nil {
+		if m := c.get(mt.Namespace().String(), mt.Version(), digest); m != nil {
 			switch metric := m.(type) {
 			case core.Metric:
 				fromCache = append(fromCache, metric)
@@ -152,7 +204,7 @@ type listMetricInfo struct {
 	version   int
 }
 
-func (c *cache) updateCache(mts []core.Metric) {
+func (c *cache) updateCache(mts []core.Metric, digest string) {
 	dc := map[string]*listMetricInfo{}
 	for _, mt := range mts {
 		isDynamic, idx := mt.Namespace().IsDynamic()
@@ -175,11 +227,11 @@ func (c *cache) updateCache(mts []core.Metric) {
 			continue
 		}
 		// cache the individual metric
-		c.put(mt.Namespace().String(), mt.Version(), mt)
+		c.put(mt.Namespace().String(), mt.Version(), digest, mt)
 	}
 	// write our dynamic metrics to the cache.
 	for _, v := range dc {
-		c.put(v.namespace, v.version, v.metrics)
+		c.put(v.namespace, v.version, digest, v.metrics)
 	}
 }
 
@@ -199,18 +251,39 @@ func (c *cache) allCacheMisses() uint64 {
 	return misses
 }
 
+// cells returns the cache cells of the metric, one for each config it was
+// collected with
+func (c *cache) cells(ns string, version int) []*cachecell {
+	key := cacheKey(ns, version, "")
+	var cells []*cachecell
+	for k, v := range c.table {
+		if k == key || strings.HasPrefix(k, key+":") {
+			cells = append(cells, v)
+		}
+	}
+	return cells
+}
+
 func (c *cache) cacheHits(ns string, version int) (uint64, error) {
-	key := fmt.Sprintf("%v:%v", ns, version)
-	if v, ok := c.table[key]; ok {
-		return v.hits, nil
+	cells := c.cells(ns, version)
+	if len(cells) == 0 {
+		return 0, ErrCacheEntryDoesNotExist
 	}
-	return 0, ErrCacheEntryDoesNotExist
+	var hits uint64
+	for _, v := range cells {
+		hits += v.hits
+	}
+	return hits, nil
 }
 
 func (c *cache) cacheMisses(ns string, version int) (uint64, error) {
-	key := fmt.Sprintf("%v:%v", ns, version)
-	if v, ok := c.table[key]; ok {
-		return v.misses, nil
+	cells := c.cells(ns, version)
+	if len(cells) == 0 {
+		return 0, ErrCacheEntryDoesNotExist
+	}
+	var misses uint64
+	for _, v := range cells {
+		misses += v.misses
 	}
-	return 0, ErrCacheEntryDoesNotExist
+	return misses, nil
 }
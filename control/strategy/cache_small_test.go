@@ -27,6 +27,9 @@ import (
 
 	"github.com/intelsdi-x/snap/control/fixtures"
 	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/pkg/chrono"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -57,7 +60,7 @@ func TestUpdateCache(t *testing.T) {
 			Ver:        0,
 		},
 	}
-	scache.updateCache(staticMetrics)
+	scache.updateCache(staticMetrics, "")
 	Convey("Updating cache with two static metrics", t, func() {
 		Convey("Should result in a cache with two entries", func() {
 			So(len(scache.table), ShouldEqual, 2)
@@ -108,7 +111,7 @@ func TestUpdateCache(t *testing.T) {
 			Ver:        0,
 		},
 	}
-	dcache.updateCache(dynamicMetrics)
+	dcache.updateCache(dynamicMetrics, "")
 	Convey("Updating cache with four metrics on three dynamic namespaces", t, func() {
 		Convey("Should result in a cache with two entries", func() {
 			So(len(dcache.table), ShouldEqual, 3)
@@ -127,3 +130,54 @@ func TestUpdateCache(t *testing.T) {
 		})
 	})
 }
+
+func TestCacheConfigDigestAndTTL(t *testing.T) {
+	newConfig := func(user string) *cdata.ConfigDataNode {
+		cfg := cdata.NewNode()
+		cfg.AddItem("user", ctypes.ConfigValueStr{Value: user})
+		return cfg
+	}
+	Convey("Caching metrics", t, func() {
+		defer chrono.Chrono.Reset()
+		defer chrono.Chrono.Continue()
+		chrono.Chrono.Pause()
+		foo := fixtures.MockMetricType{Namespace_: core.NewNamespace("foo", "bar"), Ver: 1}
+
+		Convey("keeps the metrics collected with different configs apart", func() {
+			So(ConfigDigest(nil), ShouldBeEmpty)
+			So(ConfigDigest(cdata.NewNode()), ShouldBeEmpty)
+			jane, joe := ConfigDigest(newConfig("jane")), ConfigDigest(newConfig("joe"))
+			So(jane, ShouldEqual, ConfigDigest(newConfig("jane")))
+			So(jane, ShouldNotEqual, joe)
+
+			c := NewCache(time.Second)
+			c.updateCache([]core.Metric{foo}, jane)
+			toCollect, fromCache := c.checkCache([]core.Metric{foo}, jane)
+			So(toCollect, ShouldBeEmpty)
+			So(fromCache, ShouldHaveLength, 1)
+			toCollect, fromCache = c.checkCache([]core.Metric{foo}, joe)
+			So(toCollect, ShouldHaveLength, 1)
+			So(fromCache, ShouldBeEmpty)
+			hits, err := c.cacheHits("/foo/bar", 1)
+			So(err, ShouldBeNil)
+			So(hits, ShouldEqual, 1)
+			misses, err := c.cacheMisses("/foo/bar", 1)
+			So(err, ShouldBeNil)
+			So(misses, ShouldEqual, 1)
+		})
+		Convey("expires them after the TTL of their namespace", func() {
+			MetricCacheTTL = map[string]time.Duration{"/foo": 5 * time.Second, "/foo/bar/": 0}
+			defer func() { MetricCacheTTL = nil }()
+			c := NewCache(time.Second)
+			So(c.ttlOf("/foo/baz"), ShouldEqual, 5*time.Second)
+			So(c.ttlOf("/foo/bar"), ShouldEqual, 0)
+			So(c.ttlOf("/fooo"), ShouldEqual, time.Second)
+
+			c.put("/foo/baz", 1, "", foo)
+			chrono.Chrono.Forward(2 * time.Second)
+			So(c.get("/foo/baz", 1, ""), ShouldNotBeNil)
+			c.put("/foo/bar", 1, "", foo)
+			So(c.get("/foo/bar", 1, ""), ShouldBeNil)
+		})
+	})
+}
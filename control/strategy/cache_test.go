@@ -40,15 +40,15 @@ func TestCache(t *testing.T) {
 			Namespace_: core.NewNamespace("foo", "bar"),
 		}
 
-		mc.put("/foo/bar", 1, foo)
-		ret := mc.get("/foo/bar", 1)
+		mc.put("/foo/bar", 1, "", foo)
+		ret := mc.get("/foo/bar", 1, "")
 
 		So(ret, ShouldNotBeNil)
 		So(ret, ShouldEqual, foo)
 	})
 	Convey("returns nil if the cache cell does not exist", t, func() {
 		mc := NewCache(GlobalCacheExpiration)
-		ret := mc.get("/foo/bar", 1)
+		ret := mc.get("/foo/bar", 1, "")
 		So(ret, ShouldBeNil)
 	})
 	Convey("returns nil if the cache cell has expired", t, func() {
@@ -63,10 +63,10 @@ func TestCache(t *testing.T) {
 		foo := &plugin.MetricType{
 			Namespace_: core.NewNamespace("foo", "bar"),
 		}
-		mc.put("/foo/bar", 1, foo)
+		mc.put("/foo/bar", 1, "", foo)
 		chrono.Chrono.Forward(401 * time.Millisecond)
 
-		ret := mc.get("/foo/bar", 1)
+		ret := mc.get("/foo/bar", 1, "")
 		So(ret, ShouldBeNil)
 	})
 	Convey("hit and miss counts", t, func() {
@@ -75,8 +75,8 @@ func TestCache(t *testing.T) {
 			foo := &plugin.MetricType{
 				Namespace_: core.NewNamespace("foo", "bar"),
 			}
-			mc.put("/foo/bar", 1, foo)
-			mc.get("/foo/bar", 1)
+			mc.put("/foo/bar", 1, "", foo)
+			mc.get("/foo/bar", 1, "")
 			So(mc.table["/foo/bar:1"].hits, ShouldEqual, 1)
 		})
 		Convey("ticks miss count when a cache entry is still a hit", func() {
@@ -90,9 +90,9 @@ func TestCache(t *testing.T) {
 				Namespace_: core.NewNamespace("foo", "bar"),
 			}
 
-			mc.put("/foo/bar", 1, foo)
+			mc.put("/foo/bar", 1, "", foo)
 			chrono.Chrono.Forward(250 * time.Millisecond)
-			mc.get("/foo/bar", 1)
+			mc.get("/foo/bar", 1, "")
 			So(mc.table["/foo/bar:1"].hits, ShouldEqual, 1)
 		})
 		Convey("ticks miss count when a cache entry is missed", func() {
@@ -105,9 +105,9 @@ func TestCache(t *testing.T) {
 			foo := &plugin.MetricType{
 				Namespace_: core.NewNamespace("foo", "bar"),
 			}
-			mc.put("/foo/bar", 1, foo)
+			mc.put("/foo/bar", 1, "", foo)
 			chrono.Chrono.Forward(301 * time.Millisecond)
-			mc.get("/foo/bar", 1)
+			mc.get("/foo/bar", 1, "")
 			So(mc.table["/foo/bar:1"].misses, ShouldEqual, 1)
 		})
 	})
@@ -126,11 +126,11 @@ func TestCache(t *testing.T) {
 			Namespace_: core.NewNamespace("foo", "baz"),
 		}
 		metricList := []core.Metric{foo, baz}
-		mc.updateCache(metricList)
+		mc.updateCache(metricList, "")
 		Convey("they should be retrievable via get", func() {
-			ret := mc.get(foo.Namespace().String(), foo.Version())
+			ret := mc.get(foo.Namespace().String(), foo.Version(), "")
 			So(ret, ShouldEqual, foo)
-			ret = mc.get(baz.Namespace().String(), baz.Version())
+			ret = mc.get(baz.Namespace().String(), baz.Version(), "")
 			So(ret, ShouldEqual, baz)
 		})
 		Convey("they should be retrievable via checkCache", func() {
@@ -138,7 +138,7 @@ func TestCache(t *testing.T) {
 				Namespace_: core.NewNamespace("foo", "fooer"),
 			}
 			metricList = append(metricList, nonCached)
-			toCollect, fromCache := mc.checkCache(metricList)
+			toCollect, fromCache := mc.checkCache(metricList, "")
 			Convey("Should return cached metrics", func() {
 				So(len(fromCache), ShouldEqual, 2)
 				So(fromCache[0], ShouldEqual, foo)
@@ -165,7 +165,7 @@ func TestCache(t *testing.T) {
 			Version_:   2,
 		}
 		metricList := []core.Metric{v1, v2}
-		mc.updateCache(metricList)
+		mc.updateCache(metricList, "")
 		Convey("Should be cached separately", func() {
 			Convey("so only 1 should be returned from the cache", func() {
 				starMetric := &plugin.MetricType{
@@ -173,11 +173,11 @@ func TestCache(t *testing.T) {
 					Version_:   2,
 				}
 				// Check /foo/* with both versions
-				toCollect, fromCache := mc.checkCache([]core.Metric{starMetric})
+				toCollect, fromCache := mc.checkCache([]core.Metric{starMetric}, "")
 				So(len(toCollect), ShouldEqual, 0)
 				So(len(fromCache), ShouldEqual, 1)
 				starMetric.Version_ = 1
-				toCollect, fromCache = mc.checkCache([]core.Metric{starMetric})
+				toCollect, fromCache = mc.checkCache([]core.Metric{starMetric}, "")
 				So(len(toCollect), ShouldEqual, 0)
 				So(len(fromCache), ShouldEqual, 1)
 			})
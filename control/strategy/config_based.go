@@ -51,7 +51,11 @@ func NewConfigBased(cacheTTL time.Duration) *configBased {
 // Select selects an available plugin using the config based plugin strategy.
 func (cb *configBased) Select(aps []AvailablePlugin, id string) (AvailablePlugin, error) {
 	if ap, ok := cb.plugins[id]; ok && ap != nil {
-		return ap, nil
+		if hasPlugin(aps, ap) {
+			return ap, nil
+		}
+		// the plugin of the config is gone, another one takes over
+		delete(cb.plugins, id)
 	}
 
 	// add first one in case it's new id
@@ -100,19 +104,19 @@ func (cb *configBased) CacheTTL(id string) (time.Duration, error) {
 // returns:
 //  - array of metrics that need to be collected
 //  - array of metrics that were returned from the cache
-func (cb *configBased) CheckCache(mts []core.Metric, id string) ([]core.Metric, []core.Metric) {
+func (cb *configBased) CheckCache(mts []core.Metric, digest string, id string) ([]core.Metric, []core.Metric) {
 	if _, ok := cb.metricCache[id]; !ok {
 		cb.metricCache[id] = NewCache(cb.cacheTTL)
 	}
-	return cb.metricCache[id].checkCache(mts)
+	return cb.metricCache[id].checkCache(mts, digest)
 }
 
 // updateCache updates the cache with the given array of metrics.
-func (cb *configBased) UpdateCache(mts []core.Metric, id string) {
+func (cb *configBased) UpdateCache(mts []core.Metric, digest string, id string) {
 	if _, ok := cb.metricCache[id]; !ok {
 		cb.metricCache[id] = NewCache(cb.cacheTTL)
 	}
-	cb.metricCache[id].updateCache(mts)
+	cb.metricCache[id].updateCache(mts, digest)
 }
 
 // AllCacheHits returns cache hits across all metrics.
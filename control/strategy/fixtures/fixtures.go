@@ -136,6 +136,14 @@ func (m MockAvailablePlugin) LastHit() time.Time {
 	return m.lastHit
 }
 
+func (m MockAvailablePlugin) FailedHealthChecks() int {
+	return 0
+}
+
+func (m MockAvailablePlugin) LastHealthCheck() time.Time {
+	return time.Time{}
+}
+
 func (m MockAvailablePlugin) String() string {
 	return strings.Join([]string{m.pluginType.String(), m.pluginName, strconv.Itoa(m.Version())}, core.Separator)
 }
@@ -94,13 +94,13 @@ func (l *lru) Remove(aps []AvailablePlugin, taskID string) (AvailablePlugin, err
 // returns:
 //  - array of metrics that need to be collected
 //  - array of metrics that were returned from the cache
-func (l *lru) CheckCache(mts []core.Metric, _ string) ([]core.Metric, []core.Metric) {
-	return l.checkCache(mts)
+func (l *lru) CheckCache(mts []core.Metric, digest string, _ string) ([]core.Metric, []core.Metric) {
+	return l.checkCache(mts, digest)
 }
 
 // updateCache updates the cache with the given array of metrics.
-func (l *lru) UpdateCache(mts []core.Metric, _ string) {
-	l.updateCache(mts)
+func (l *lru) UpdateCache(mts []core.Metric, digest string, _ string) {
+	l.updateCache(mts, digest)
 }
 
 // AllCacheHits returns cache hits across all metrics.
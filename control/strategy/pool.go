@@ -69,6 +69,10 @@ type Pool interface {
 	RestartCount() int
 	IncRestartCount()
 	KillAll(string)
+	SetSize(min, max int)
+	SetStandby(n int)
+	SetRouting(r plugin.RoutingStrategyType)
+	Shrinkable() bool
 }
 
 type AvailablePlugin interface {
@@ -112,6 +116,11 @@ type pool struct {
 
 	// The max size which this pool may grow.
 	max int
+	// The number of plugins kept running while the pool has subscriptions.
+	min int
+	// The number of plugins kept running on top of the ones the
+	// subscriptions need, taking over at once when one of them dies.
+	standby int
 
 	// The number of subscriptions per running instance
 	concurrencyCount int
@@ -119,6 +128,8 @@ type pool struct {
 	// The routing and caching strategy declared by the plugin.
 	// strategy RoutingAndCaching
 	RoutingAndCaching
+	// routing overrides the routing strategy declared by the plugin when set
+	routing *plugin.RoutingStrategyType
 
 	// restartCount the restart count of available plugins
 	// when the DeadAvailablePluginEvent occurs
@@ -202,6 +213,9 @@ func (p *pool) applyPluginMeta(a AvailablePlugin) error {
 	// (only one instance should be running).
 	if a.Exclusive() {
 		p.max = 1
+		if p.min > 1 {
+			p.min = 1
+		}
 	}
 
 	// Set the cache TTL
@@ -215,7 +229,11 @@ func (p *pool) applyPluginMeta(a AvailablePlugin) error {
 	p.concurrencyCount = a.ConcurrencyCount()
 
 	// Set the routing and caching strategy
-	switch a.RoutingStrategy() {
+	routing := a.RoutingStrategy()
+	if p.routing != nil {
+		routing = *p.routing
+	}
+	switch routing {
 	case plugin.DefaultRouting:
 		p.RoutingAndCaching = NewLRU(cacheTTL)
 	case plugin.StickyRouting:
@@ -223,6 +241,8 @@ func (p *pool) applyPluginMeta(a AvailablePlugin) error {
 		p.concurrencyCount = 1
 	case plugin.ConfigRouting:
 		p.RoutingAndCaching = NewConfigBased(cacheTTL)
+	case plugin.TaskHashRouting:
+		p.RoutingAndCaching = NewTaskHash(cacheTTL)
 	default:
 		return ErrBadStrategy
 	}
@@ -254,6 +274,61 @@ func (p *pool) Unsubscribe(taskID string) {
 	delete(p.subs, taskID)
 }
 
+// SetSize sets the number of plugins the pool keeps running while it has
+// subscriptions and the number it may grow to. A max of 0 keeps the
+// MaximumRunningPlugins default. An exclusive plugin is never run more than
+// once.
+func (p *pool) SetSize(min, max int) {
+	p.Lock()
+	defer p.Unlock()
+	if max > 0 && (len(p.plugins) == 0 || p.max > 1) {
+		p.max = max
+	}
+	if min > p.max {
+		min = p.max
+	}
+	p.min = min
+}
+
+// SetStandby sets the number of plugins the pool keeps running on top of the
+// ones its subscriptions need, without growing over its max size.
+func (p *pool) SetStandby(n int) {
+	p.Lock()
+	defer p.Unlock()
+	p.standby = n
+}
+
+// SetRouting overrides the routing strategy declared by the plugins of the
+// pool.
+func (p *pool) SetRouting(r plugin.RoutingStrategyType) {
+	p.Lock()
+	defer p.Unlock()
+	p.routing = &r
+	for _, a := range p.plugins {
+		if err := p.applyPluginMeta(a); err != nil {
+			log.WithFields(log.Fields{
+				"_block":  "SetRouting",
+				"pool":    p.key,
+				"routing": r.String(),
+			}).Error(err)
+		}
+		break
+	}
+}
+
+// standbySize returns the number of plugins the subscriptions of the pool
+// need, standby plugins included. It must be called with the pool locked.
+func (p *pool) standbySize() int {
+	if len(p.subs) == 0 {
+		return 0
+	}
+	n := (len(p.subs) + p.concurrencyCount - 1) / p.concurrencyCount
+	if n < p.min {
+		n = p.min
+	}
+	return n + p.standby
+}
+
 // Eligible returns a bool indicating whether the pool is eligible to grow
 func (p *pool) Eligible() bool {
 	p.RLock()
@@ -265,6 +340,16 @@ func (p *pool) Eligible() bool {
 		return false
 	}
 
+	// a subscribed pool grows to its min size whatever its subscriptions
+	if len(p.subs) > 0 && len(p.plugins) < p.min {
+		return true
+	}
+
+	// and runs its standby plugins on top of the ones it needs
+	if p.standby > 0 && len(p.plugins) < p.standbySize() {
+		return true
+	}
+
 	// Check if pool is eligible and number of plugins is less than maximum allowed
 	if len(p.subs) > p.concurrencyCount*len(p.plugins) {
 		return true
@@ -273,6 +358,21 @@ func (p *pool) Eligible() bool {
 	return false
 }
 
+// Shrinkable returns a bool indicating whether the pool runs more plugins
+// than its subscriptions need, without going under its min size while it
+// has subscriptions
+func (p *pool) Shrinkable() bool {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.subs) >= len(p.plugins) {
+		return false
+	}
+	if p.standby > 0 && len(p.plugins) <= p.standbySize() {
+		return false
+	}
+	return len(p.subs) == 0 || len(p.plugins) > p.min
+}
+
 // kill kills and removes the available plugin from its pool.
 // Using kill is idempotent.
 func (p *pool) Kill(id uint32, reason string) {
@@ -369,7 +469,7 @@ func (p *pool) SelectAP(taskID string, config map[string]ctypes.ConfigValue) (Av
 	switch p.Strategy().String() {
 	case "least-recently-used":
 		id = ""
-	case "sticky":
+	case "sticky", "task-hash":
 		id = taskID
 	case "config-based":
 		id = idFromCfg(config)
@@ -289,3 +289,80 @@ func TestPoolSelectAPStickyRouter(t *testing.T) {
 		})
 	})
 }
+
+func TestPoolSize(t *testing.T) {
+	Convey("Given a pool sized to run 2 to 3 plugins", t, func() {
+		plg := func(id uint32) *MockAvailablePlugin {
+			return NewMockAvailablePlugin().WithConCount(1).WithID(id)
+		}
+		pool, err := NewPool(plg(1).String())
+		So(err, ShouldBeNil)
+		pool.SetSize(2, 3)
+
+		Convey("an unsubscribed pool does not grow", func() {
+			So(pool.Eligible(), ShouldBeFalse)
+		})
+		Convey("a subscribed pool grows to its min size", func() {
+			pool.Subscribe("task-1")
+			So(pool.Eligible(), ShouldBeTrue)
+			So(pool.Insert(plg(1)), ShouldBeNil)
+			So(pool.Eligible(), ShouldBeTrue)
+			So(pool.Insert(plg(2)), ShouldBeNil)
+			So(pool.Eligible(), ShouldBeFalse)
+
+			Convey("then with its subscriptions, up to its max size", func() {
+				pool.Subscribe("task-2")
+				pool.Subscribe("task-3")
+				pool.Subscribe("task-4")
+				So(pool.Eligible(), ShouldBeTrue)
+				So(pool.Insert(plg(3)), ShouldBeNil)
+				So(pool.Eligible(), ShouldBeFalse)
+			})
+			Convey("it does not shrink under its min size while subscribed", func() {
+				So(pool.Shrinkable(), ShouldBeFalse)
+				pool.Unsubscribe("task-1")
+				So(pool.Shrinkable(), ShouldBeTrue)
+			})
+		})
+	})
+	Convey("Given a pool of an exclusive plugin", t, func() {
+		plg := NewMockAvailablePlugin().WithExclusive(true)
+		pool, err := NewPool(plg.String())
+		So(err, ShouldBeNil)
+		pool.SetSize(2, 3)
+		pool.Subscribe("task-1")
+		So(pool.Insert(plg), ShouldBeNil)
+
+		Convey("a single plugin is run", func() {
+			So(pool.Eligible(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestPoolStandby(t *testing.T) {
+	Convey("Given a pool keeping a standby plugin", t, func() {
+		plg := func(id uint32) *MockAvailablePlugin {
+			return NewMockAvailablePlugin().WithConCount(1).WithID(id)
+		}
+		pool, err := NewPool(plg(1).String())
+		So(err, ShouldBeNil)
+		pool.SetStandby(1)
+
+		Convey("an unsubscribed pool does not grow", func() {
+			So(pool.Eligible(), ShouldBeFalse)
+		})
+		Convey("a subscribed pool runs a plugin more than it needs", func() {
+			pool.Subscribe("task-1")
+			So(pool.Insert(plg(1)), ShouldBeNil)
+			So(pool.Eligible(), ShouldBeTrue)
+			So(pool.Insert(plg(2)), ShouldBeNil)
+			So(pool.Eligible(), ShouldBeFalse)
+
+			Convey("and does not shrink to the plugins it needs", func() {
+				So(pool.Shrinkable(), ShouldBeFalse)
+				pool.Unsubscribe("task-1")
+				So(pool.Shrinkable(), ShouldBeTrue)
+			})
+		})
+	})
+}
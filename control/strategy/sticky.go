@@ -55,7 +55,11 @@ func NewSticky(cacheTTL time.Duration) *sticky {
 // Select selects an available plugin using the sticky plugin strategy.
 func (s *sticky) Select(aps []AvailablePlugin, taskID string) (AvailablePlugin, error) {
 	if ap, ok := s.plugins[taskID]; ok && ap != nil {
-		return ap, nil
+		if hasPlugin(aps, ap) {
+			return ap, nil
+		}
+		// the plugin of the task is gone, another one takes over
+		delete(s.plugins, taskID)
 	}
 	return s.selectPlugin(aps, taskID)
 }
@@ -85,19 +89,19 @@ func (s *sticky) CacheTTL(taskID string) (time.Duration, error) {
 // returns:
 //  - array of metrics that need to be collected
 //  - array of metrics that were returned from the cache
-func (s *sticky) CheckCache(mts []core.Metric, taskID string) ([]core.Metric, []core.Metric) {
+func (s *sticky) CheckCache(mts []core.Metric, digest string, taskID string) ([]core.Metric, []core.Metric) {
 	if _, ok := s.metricCache[taskID]; !ok {
 		s.metricCache[taskID] = NewCache(s.cacheTTL)
 	}
-	return s.metricCache[taskID].checkCache(mts)
+	return s.metricCache[taskID].checkCache(mts, digest)
 }
 
 // updateCache updates the cache with the given array of metrics.
-func (s *sticky) UpdateCache(mts []core.Metric, taskID string) {
+func (s *sticky) UpdateCache(mts []core.Metric, digest string, taskID string) {
 	if _, ok := s.metricCache[taskID]; !ok {
 		s.metricCache[taskID] = NewCache(s.cacheTTL)
 	}
-	s.metricCache[taskID].updateCache(mts)
+	s.metricCache[taskID].updateCache(mts, digest)
 }
 
 // AllCacheHits returns cache hits across all metrics.
@@ -154,3 +158,13 @@ func (s *sticky) selectPlugin(aps []AvailablePlugin, taskID string) (AvailablePl
 	}).Error(ErrCouldNotSelect)
 	return nil, ErrCouldNotSelect
 }
+
+// hasPlugin returns whether ap is one of the plugins aps
+func hasPlugin(aps []AvailablePlugin, ap AvailablePlugin) bool {
+	for _, a := range aps {
+		if a == ap {
+			return true
+		}
+	}
+	return false
+}
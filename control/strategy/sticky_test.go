@@ -58,6 +58,12 @@ func TestStickyRouter(t *testing.T) {
 				So(sp, ShouldBeNil)
 				So(err, ShouldEqual, ErrCouldNotSelect)
 			})
+			Convey("Select another plugin when the one of the task is gone", func() {
+				p3 := NewMockAvailablePlugin().WithName("p3")
+				sp, err := router.Select([]AvailablePlugin{p2, p3}, "task1")
+				So(err, ShouldBeNil)
+				So(sp, ShouldEqual, p3)
+			})
 		})
 
 	})
@@ -37,8 +37,10 @@ var (
 type RoutingAndCaching interface {
 	Select(availablePlugins []AvailablePlugin, id string) (AvailablePlugin, error)
 	Remove(availablePlugins []AvailablePlugin, id string) (AvailablePlugin, error)
-	CheckCache(metrics []core.Metric, id string) ([]core.Metric, []core.Metric)
-	UpdateCache(metrics []core.Metric, id string)
+	// CheckCache and UpdateCache cache the metrics by the digest of the
+	// config they are collected with, given by ConfigDigest
+	CheckCache(metrics []core.Metric, digest string, id string) ([]core.Metric, []core.Metric)
+	UpdateCache(metrics []core.Metric, digest string, id string)
 	CacheHits(ns string, ver int, id string) (uint64, error)
 	CacheMisses(ns string, ver int, id string) (uint64, error)
 	AllCacheHits() uint64
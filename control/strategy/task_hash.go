@@ -0,0 +1,132 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategy
+
+import (
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/intelsdi-x/snap/core"
+)
+
+// taskHash provides a strategy that always selects the same available plugin
+// for a task, by rendezvous hashing of the task id over the ids of the
+// plugins. Only the tasks of a plugin removed from the pool, or the ones
+// picking a plugin added to it, move to another plugin.
+type taskHash struct {
+	*cache
+	logger *log.Entry
+}
+
+func NewTaskHash(cacheTTL time.Duration) *taskHash {
+	return &taskHash{
+		NewCache(cacheTTL),
+		log.WithFields(log.Fields{
+			"_module": "control-routing",
+		}),
+	}
+}
+
+// String returns the strategy name.
+func (t *taskHash) String() string {
+	return "task-hash"
+}
+
+// CacheTTL returns the TTL for the cache.
+func (t *taskHash) CacheTTL(taskID string) (time.Duration, error) {
+	return t.ttl, nil
+}
+
+// Select selects the available plugin of the task, the one which id hashed
+// along with the task id weighs the most.
+func (t *taskHash) Select(aps []AvailablePlugin, taskID string) (AvailablePlugin, error) {
+	var (
+		selected AvailablePlugin
+		weight   uint64
+	)
+	for _, ap := range aps {
+		if w := taskWeight(taskID, ap.ID()); selected == nil || w > weight {
+			selected, weight = ap, w
+		}
+	}
+	if selected == nil {
+		t.logger.WithFields(log.Fields{
+			"block":    "select",
+			"strategy": t.String(),
+			"error":    ErrCouldNotSelect,
+		}).Error("error selecting")
+		return nil, ErrCouldNotSelect
+	}
+	t.logger.WithFields(log.Fields{
+		"block":     "select",
+		"strategy":  t.String(),
+		"pool size": len(aps),
+		"task-id":   taskID,
+		"index":     selected.String(),
+	}).Debug("plugin selected")
+	return selected, nil
+}
+
+// taskWeight returns the weight of the plugin id for the task
+func taskWeight(taskID string, id uint32) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(taskID))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatUint(uint64(id), 10)))
+	return h.Sum64()
+}
+
+// Remove selects a plugin
+// Since there is no state to cleanup we only need to return the selected plugin
+func (t *taskHash) Remove(aps []AvailablePlugin, taskID string) (AvailablePlugin, error) {
+	return t.Select(aps, taskID)
+}
+
+// CheckCache checks the cache for metric types.
+func (t *taskHash) CheckCache(mts []core.Metric, digest string, _ string) ([]core.Metric, []core.Metric) {
+	return t.checkCache(mts, digest)
+}
+
+// UpdateCache updates the cache with the given array of metrics.
+func (t *taskHash) UpdateCache(mts []core.Metric, digest string, _ string) {
+	t.updateCache(mts, digest)
+}
+
+// AllCacheHits returns cache hits across all metrics.
+func (t *taskHash) AllCacheHits() uint64 {
+	return t.allCacheHits()
+}
+
+// AllCacheMisses returns cache misses across all metrics.
+func (t *taskHash) AllCacheMisses() uint64 {
+	return t.allCacheMisses()
+}
+
+// CacheHits returns the cache hits for a given metric namespace and version.
+func (t *taskHash) CacheHits(ns string, version int, _ string) (uint64, error) {
+	return t.cacheHits(ns, version)
+}
+
+// CacheMisses returns the cache misses for a given metric namespace and version.
+func (t *taskHash) CacheMisses(ns string, version int, _ string) (uint64, error) {
+	return t.cacheMisses(ns, version)
+}
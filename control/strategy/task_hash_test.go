@@ -0,0 +1,85 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	. "github.com/intelsdi-x/snap/control/strategy/fixtures"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskHashRouter(t *testing.T) {
+	Convey("Given a task hash router", t, func() {
+		router := NewTaskHash(100 * time.Millisecond)
+		So(router.String(), ShouldEqual, "task-hash")
+		p1 := NewMockAvailablePlugin().WithName("p1").WithID(1)
+		p2 := NewMockAvailablePlugin().WithName("p2").WithID(2)
+		p3 := NewMockAvailablePlugin().WithName("p3").WithID(3)
+
+		Convey("a task is always routed to the same plugin", func() {
+			sp, err := router.Select([]AvailablePlugin{p1, p2, p3}, "task1")
+			So(err, ShouldBeNil)
+			for i := 0; i < 5; i++ {
+				again, err := router.Select([]AvailablePlugin{p3, p1, p2}, "task1")
+				So(err, ShouldBeNil)
+				So(again, ShouldEqual, sp)
+			}
+		})
+		Convey("the tasks are spread over the plugins", func() {
+			selected := map[AvailablePlugin]bool{}
+			for i := 0; i < 30; i++ {
+				sp, err := router.Select([]AvailablePlugin{p1, p2, p3}, fmt.Sprintf("task%d", i))
+				So(err, ShouldBeNil)
+				selected[sp] = true
+			}
+			So(len(selected), ShouldBeGreaterThan, 1)
+		})
+		Convey("only the tasks of a plugin removed move to another plugin", func() {
+			for i := 0; i < 30; i++ {
+				task := fmt.Sprintf("task%d", i)
+				before, _ := router.Select([]AvailablePlugin{p1, p2, p3}, task)
+				after, err := router.Select([]AvailablePlugin{p1, p2}, task)
+				So(err, ShouldBeNil)
+				if before != p3 {
+					So(after, ShouldEqual, before)
+				}
+			}
+		})
+		Convey("no plugin can be selected from an empty pool", func() {
+			sp, err := router.Select([]AvailablePlugin{}, "task1")
+			So(sp, ShouldBeNil)
+			So(err, ShouldEqual, ErrCouldNotSelect)
+		})
+	})
+	Convey("Given a pool which routing is overridden", t, func() {
+		plg := NewMockAvailablePlugin().WithStrategy(plugin.DefaultRouting)
+		pool, err := NewPool(plg.String(), plg)
+		So(err, ShouldBeNil)
+		So(pool.Strategy().String(), ShouldEqual, "least-recently-used")
+		pool.SetRouting(plugin.TaskHashRouting)
+		So(pool.Strategy().String(), ShouldEqual, "task-hash")
+	})
+}
@@ -221,8 +221,13 @@ func (s *subscriptionGroups) ValidateDeps(requested []core.RequestedMetric,
 		plugins = append(plugins, collector)
 	}
 
-	// validate plugins
+	// validate plugins, reporting the config errors of all of them
 	for _, plg := range plugins {
+		plg, serr := resolveVersion(s.pluginManager, plg)
+		if serr != nil {
+			serrs = append(serrs, serr)
+			return serrs
+		}
 		typ, err := core.ToPluginType(plg.TypeName())
 		if err != nil {
 			return []serror.SnapError{serror.New(err)}
@@ -233,7 +238,6 @@ func (s *subscriptionGroups) ValidateDeps(requested []core.RequestedMetric,
 		errs := s.validatePluginSubscription(plg, mergedConfig)
 		if len(errs) > 0 {
 			serrs = append(serrs, errs...)
-			return serrs
 		}
 	}
 	return
@@ -253,13 +257,24 @@ func (p *subscriptionGroups) validatePluginSubscription(pl core.SubscribedPlugin
 
 	if lp.ConfigPolicy != nil {
 		ncd := lp.ConfigPolicy.Get([]string{""})
+		// the defaults of the items set neither by the workflow nor by
+		// the global config of the plugin
+		defaults := ncd.Defaults()
+		for k := range mergedConfig.Table() {
+			delete(defaults, k)
+		}
 		_, errs := ncd.Process(mergedConfig.Table())
 		if errs != nil && errs.HasErrors() {
-			for _, e := range errs.Errors() {
-				se := serror.New(e)
-				se.SetFields(map[string]interface{}{"name": pl.Name(), "version": pl.Version()})
-				serrs = append(serrs, se)
-			}
+			return configPolicyErrors(errs, map[string]interface{}{
+				"name":    pl.Name(),
+				"version": pl.Version(),
+				"type":    pl.TypeName(),
+			})
+		}
+		// are merged into the config of the workflow, so that the plugin
+		// is called with them
+		if pl.Config() != nil {
+			pl.Config().ApplyDefaults(defaults)
 		}
 	}
 	return serrs
@@ -319,9 +334,11 @@ func (s *subscriptionGroups) validateMetric(
 			}
 			ncdTable, errs := m.policy.Process(m.Config().Table())
 			if errs != nil && errs.HasErrors() {
-				for _, e := range errs.Errors() {
-					serrs = append(serrs, serror.New(e))
-				}
+				serrs = append(serrs, configPolicyErrors(errs, map[string]interface{}{
+					"metric":  m.Namespace().String(),
+					"version": m.Version(),
+					"plugin":  m.Plugin.Name(),
+				})...)
 				continue
 			}
 			m.config = cdata.FromTable(*ncdTable)
@@ -342,13 +359,29 @@ func (s *subscriptionGroup) process(id string) (serrs []serror.SnapError) {
 	for _, plugin := range s.requestedPlugins {
 		//add processors and publishers to collectors just gathered
 		if plugin.TypeName() != core.CollectorPluginType.String() {
-			plugins = append(plugins, plugin)
-			// add defaults to plugins (exposed in a plugins ConfigPolicy)
-			if lp, err := s.pluginManager.get(
+			plugin, serr := resolveVersion(s.pluginManager, plugin)
+			if serr != nil {
+				serrs = append(serrs, serr)
+				continue
+			}
+			lp, err := s.pluginManager.get(
 				fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d",
 					plugin.TypeName(),
 					plugin.Name(),
-					plugin.Version())); err == nil && lp.ConfigPolicy != nil {
+					plugin.Version()))
+			// subscribe to the latest version loaded so the subscription
+			// moves to newer versions as they are loaded
+			if err == nil && plugin.Version() < 1 {
+				plugin = subscribedPlugin{
+					typeName: plugin.TypeName(),
+					name:     plugin.Name(),
+					version:  lp.Version(),
+					config:   plugin.Config(),
+				}
+			}
+			plugins = append(plugins, plugin)
+			// add defaults to plugins (exposed in a plugins ConfigPolicy)
+			if err == nil && lp.ConfigPolicy != nil {
 				if policy := lp.ConfigPolicy.Get([]string{""}); policy != nil && len(policy.Defaults()) > 0 {
 					plugin.Config().ApplyDefaults(policy.Defaults())
 				}
@@ -411,7 +444,9 @@ func (s *subscriptionGroup) subscribePlugins(id string,
 			return serrs
 		}
 		pool.Subscribe(id)
-		if pool.Eligible() {
+		// grows the pool by one plugin for the subscription, or up to its
+		// min size for its first one
+		for pool.Eligible() {
 			err = s.verifyPlugin(plg)
 			if err != nil {
 				serrs = append(serrs, serror.New(err))
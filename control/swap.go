@@ -0,0 +1,144 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/control_event"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+var (
+	// ErrSwapPluginMismatch is returned when the plugin swapped in is not a
+	// version of the plugin swapped out
+	ErrSwapPluginMismatch = errors.New("Plugin types and names must match.")
+	// ErrSwapNotLatest is returned when the plugin swapped in is not the
+	// latest version of the plugin, which tasks would not move to
+	ErrSwapNotLatest = errors.New("plugin swapped in must be the latest version of the plugin")
+	// ErrSwapPinnedVersion is returned when tasks subscribe to the version
+	// swapped out explicitly, and so cannot move to the new version
+	ErrSwapPinnedVersion = errors.New("tasks subscribe to the version of the plugin swapped out")
+	// ErrSwapVerificationFailed is returned when the instances of the plugin
+	// swapped in fail to answer once tasks moved to it
+	ErrSwapVerificationFailed = errors.New("plugin swapped in failed to answer")
+)
+
+// HotSwap loads the plugin in, moves the subscriptions of the tasks to it,
+// checks that its running instances answer, then unloads the plugin out.
+// The tasks subscribing to the latest version of the plugin move to the new
+// one as it is loaded; the swap is rolled back, unloading the new version,
+// when tasks subscribe to the version swapped out explicitly or when the new
+// version fails to answer, so that the tasks keep running throughout.
+func (p *pluginControl) HotSwap(in *core.RequestedPlugin, out core.Plugin) (core.CatalogedPlugin, serror.SnapError) {
+	f := map[string]interface{}{
+		"_block":         "hot-swap",
+		"plugin-type":    out.TypeName(),
+		"plugin-name":    out.Name(),
+		"plugin-version": out.Version(),
+	}
+	if !p.Started {
+		return nil, serror.New(ErrControllerNotStarted, f)
+	}
+	outKey := fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", out.TypeName(), out.Name(), out.Version())
+	if _, err := p.pluginManager.get(outKey); err != nil {
+		return nil, serror.New(ErrPluginNotFound, f)
+	}
+
+	pl, se := p.Load(in)
+	if se != nil {
+		return nil, se
+	}
+	lp := pl.(*loadedPlugin)
+	f["new-plugin-version"] = lp.Version()
+	if lp.TypeName() != out.TypeName() || lp.Name() != out.Name() {
+		f["new-plugin-type"] = lp.TypeName()
+		f["new-plugin-name"] = lp.Name()
+		return nil, p.rollbackSwap(lp, serror.New(ErrSwapPluginMismatch, f))
+	}
+	latest, err := p.pluginManager.get(fmt.Sprintf("%s"+core.Separator+"%s"+core.Separator+"%d", lp.TypeName(), lp.Name(), -1))
+	if err != nil || latest.Version() != lp.Version() {
+		return nil, p.rollbackSwap(lp, serror.New(ErrSwapNotLatest, f))
+	}
+
+	// move the subscriptions now rather than on the load event, so that
+	// the instances of the new version can be checked
+	p.processSubscriptions("hot-swap")
+	aps := p.pluginRunner.AvailablePlugins()
+	if pool, _ := aps.getPool(outKey); pool != nil && pool.SubscriptionCount() > 0 {
+		return nil, p.rollbackSwap(lp, serror.New(ErrSwapPinnedVersion, f))
+	}
+	if pool, _ := aps.getPool(lp.Key()); pool != nil {
+		for id, a := range pool.Plugins() {
+			ap, ok := a.(*availablePlugin)
+			if !ok {
+				continue
+			}
+			if err := ap.client.Ping(); err != nil {
+				f["plugin-id"] = id
+				f["error"] = err.Error()
+				return nil, p.rollbackSwap(lp, serror.New(ErrSwapVerificationFailed, f))
+			}
+		}
+	}
+
+	up, se := p.Unload(out)
+	if se != nil {
+		return nil, p.rollbackSwap(lp, se)
+	}
+	controlLogger.WithFields(f).Info("plugin swapped")
+	event := &control_event.SwapPluginsEvent{
+		LoadedPluginName:      lp.Name(),
+		LoadedPluginVersion:   lp.Version(),
+		UnloadedPluginName:    up.Name(),
+		UnloadedPluginVersion: up.Version(),
+		PluginType:            int(lp.Meta.Type),
+	}
+	defer p.eventManager.Emit(event)
+	return lp, nil
+}
+
+// rollbackSwap unloads the plugin swapped in, moving the tasks back to the
+// plugin swapped out, and returns the error which failed the swap.
+func (p *pluginControl) rollbackSwap(lp core.CatalogedPlugin, serr serror.SnapError) serror.SnapError {
+	controlLogger.WithFields(serr.Fields()).Warn(serr)
+	if _, se := p.Unload(lp); se != nil {
+		return serror.New(errors.New("Failed to rollback after error"), map[string]interface{}{
+			"original-error":        serr.Error(),
+			"rollback-unload-error": se.Error(),
+		})
+	}
+	p.processSubscriptions("hot-swap")
+	return serr
+}
+
+// processSubscriptions moves the subscriptions of the tasks to the plugins
+// loaded, logging the errors.
+func (p *pluginControl) processSubscriptions(block string) {
+	for _, err := range p.subscriptionGroups.Process() {
+		controlLogger.WithFields(log.Fields{
+			"_block": block,
+		}).Error(err)
+	}
+}
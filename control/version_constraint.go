@@ -0,0 +1,86 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/pkg/versions"
+)
+
+// constrainedPlugin is implemented by the subscribed plugins which select
+// their version with a constraint (e.g. ">=3, <5") rather than a version
+type constrainedPlugin interface {
+	VersionConstraint() string
+}
+
+// latestMatchingVersion returns the latest version of the loaded plugin of
+// the given type and name matching the version constraint
+func latestMatchingVersion(pm managesPlugins, typeName, name, constraint string) (int, error) {
+	c, err := versions.ParseConstraint(constraint)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for _, lp := range pm.all() {
+		if lp.TypeName() == typeName && lp.Name() == name && lp.Version() > version && c.Check(lp.Version()) {
+			version = lp.Version()
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("Plugin not found: type(%s) name(%s) version(%s)", typeName, name, c)
+	}
+	return version, nil
+}
+
+// resolveVersion returns the subscribed plugin with the latest loaded version
+// matching its version constraint, if it has one. The constraint is resolved
+// again each time the subscription group is processed, so that subscriptions
+// move to newer matching versions as they are loaded.
+func resolveVersion(pm managesPlugins, pl core.SubscribedPlugin) (core.SubscribedPlugin, serror.SnapError) {
+	cp, ok := pl.(constrainedPlugin)
+	if !ok || cp.VersionConstraint() == "" {
+		return pl, nil
+	}
+	version, err := latestMatchingVersion(pm, pl.TypeName(), pl.Name(), cp.VersionConstraint())
+	if err != nil {
+		se := serror.New(err)
+		se.SetFields(map[string]interface{}{
+			"name":               pl.Name(),
+			"version-constraint": cp.VersionConstraint(),
+			"type":               pl.TypeName(),
+		})
+		return nil, se
+	}
+	return subscribedPlugin{
+		typeName: pl.TypeName(),
+		name:     pl.Name(),
+		version:  version,
+		config:   pl.Config(),
+	}, nil
+}
+
+// ResolvePluginVersion returns the latest version of the loaded plugin of the
+// given type and name matching the version constraint
+func (p *pluginControl) ResolvePluginVersion(typeName, name, constraint string) (int, error) {
+	return latestMatchingVersion(p.pluginManager, typeName, name, constraint)
+}
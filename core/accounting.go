@@ -0,0 +1,31 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import "time"
+
+// AccountingRecord holds the number of metrics collected and published
+// within an accounting window for a group of tasks.
+type AccountingRecord struct {
+	Group     string    `json:"group"`
+	Window    time.Time `json:"window"`
+	Collected uint64    `json:"collected"`
+	Published uint64    `json:"published"`
+}
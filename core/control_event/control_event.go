@@ -23,6 +23,7 @@ const (
 	AvailablePluginDead      = "Control.AvailablePluginDead"
 	AvailablePluginRestarted = "Control.RestartedAvailablePlugin"
 	PluginRestartsExceeded   = "Control.PluginRestartsExceeded"
+	PluginLimitExceeded      = "Control.PluginResourceLimitExceeded"
 	PluginStarted            = "Control.PluginStarted"
 	PluginLoaded             = "Control.PluginLoaded"
 	PluginUnloaded           = "Control.PluginUnloaded"
@@ -77,6 +78,8 @@ type DeadAvailablePluginEvent struct {
 	Key     string
 	Id      uint32
 	String  string
+	// Output is the last output of the plugin process
+	Output string
 }
 
 func (e *DeadAvailablePluginEvent) Namespace() string {
@@ -103,6 +106,21 @@ type MaxPluginRestartsExceededEvent struct {
 	Id      uint32
 }
 
+// PluginResourceLimitExceededEvent is emitted when a dead plugin had reached
+// one of its resource limits
+type PluginResourceLimitExceededEvent struct {
+	Name     string
+	Version  int
+	Type     int
+	Key      string
+	Id       uint32
+	Resource string
+}
+
+func (e *PluginResourceLimitExceededEvent) Namespace() string {
+	return PluginLimitExceeded
+}
+
 func (e *RestartedAvailablePluginEvent) Namespace() string {
 	return AvailablePluginRestarted
 }
@@ -142,9 +160,10 @@ func (pu PluginUnsubscriptionEvent) Namespace() string {
 }
 
 type HealthCheckFailedEvent struct {
-	Name    string
-	Version int
-	Type    int
+	Name     string
+	Version  int
+	Type     int
+	Failures int
 }
 
 func (hfe HealthCheckFailedEvent) Namespace() string {
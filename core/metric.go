@@ -32,6 +32,12 @@ var (
 	// Standard Tags are in added to the metric by the framework on plugin load.
 	// STD_TAG_PLUGIN_RUNNING_ON describes where the plugin is running (hostname).
 	STD_TAG_PLUGIN_RUNNING_ON = "plugin_running_on"
+	// STD_TAG_DEAD_LETTER_PUBLISHER names the publisher which failed to publish a metric
+	// routed to a dead-letter publish node.
+	STD_TAG_DEAD_LETTER_PUBLISHER = "dead_letter_publisher"
+	// STD_TAG_DEAD_LETTER_ERROR holds the error of the publisher which failed to publish a
+	// metric routed to a dead-letter publish node.
+	STD_TAG_DEAD_LETTER_ERROR = "dead_letter_error"
 	nsPriorityList            = []string{"/", "|", "%", ":", "-", ";", "_", "^", ">", "<", "+", "=", "&", "㊽", "Ä", "大", "小", "ᵹ", "☍", "ヒ"}
 )
 
@@ -191,6 +197,14 @@ type RequestedMetric interface {
 	Version() int
 }
 
+// CatalogQuery is a requested metric selecting every cataloged metric which
+// matches its namespace and carries all of its tags. Unlike a plain
+// RequestedMetric, a query matching nothing is not an error.
+type CatalogQuery interface {
+	RequestedMetric
+	QueryTags() map[string]string
+}
+
 type CatalogedMetric interface {
 	RequestedMetric
 	LastAdvertisedTime() time.Time
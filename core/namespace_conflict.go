@@ -0,0 +1,35 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+// NamespaceConflict is another collector plugin advertising the namespace of
+// a cataloged metric, and how the conflict between them was resolved.
+type NamespaceConflict struct {
+	PluginName    string `json:"plugin_name"`
+	PluginVersion int    `json:"plugin_version"`
+	// Policy is the policy which resolved the conflict
+	Policy string `json:"policy"`
+	// Shadowed is true when the metric of the other plugin was left out of
+	// the catalog, false when both metrics are cataloged
+	Shadowed bool `json:"shadowed"`
+	// Merged is true when the metric of the other plugin is collected along
+	// with this one, their results being merged
+	Merged bool `json:"merged,omitempty"`
+}
@@ -22,10 +22,14 @@ package core
 import (
 	"bufio"
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/intelsdi-x/snap/control/plugin/cpolicy"
@@ -33,6 +37,37 @@ import (
 	"github.com/intelsdi-x/snap/pkg/fileutils"
 )
 
+var (
+	// ErrCheckSumMismatch is returned when a plugin does not match the SHA-256
+	// checksum expected.
+	ErrCheckSumMismatch = errors.New("plugin does not match the expected sha256 checksum")
+	// ErrPluginTooLarge is returned when a plugin is larger than the maximum
+	// size allowed.
+	ErrPluginTooLarge = errors.New("plugin is larger than the maximum size allowed")
+	// ErrPluginTimeout is returned when a plugin does not answer a call
+	// within its timeout.
+	ErrPluginTimeout = errors.New("plugin did not answer within its timeout")
+)
+
+// IsPluginTimeout returns true when err is ErrPluginTimeout. Errors are
+// compared by message as they may have crossed the control proxy.
+func IsPluginTimeout(err error) bool {
+	return err != nil && err.Error() == ErrPluginTimeout.Error()
+}
+
+// VerifyCheckSum returns ErrCheckSumMismatch when the SHA-256 checksum of the
+// plugin content (b) is not the hex encoded one expected.
+func VerifyCheckSum(b []byte, expected string) error {
+	return verifyCheckSum(sha256.Sum256(b), expected)
+}
+
+func verifyCheckSum(sum [sha256.Size]byte, expected string) error {
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(expected)) {
+		return ErrCheckSumMismatch
+	}
+	return nil
+}
+
 type Plugin interface {
 	TypeName() string
 	Name() string
@@ -75,6 +110,8 @@ type AvailablePlugin interface {
 	LastHit() time.Time
 	ID() uint32
 	Port() string
+	FailedHealthChecks() int
+	LastHealthCheck() time.Time
 }
 
 // the public interface for a plugin
@@ -87,6 +124,9 @@ type CatalogedPlugin interface {
 	PluginPath() string
 	LoadedTimestamp() *time.Time
 	Policy() *cpolicy.ConfigPolicy
+	Metadata() *PluginMetadata
+	// Info describes the plugin with its metadata
+	Info() PluginInfo
 }
 
 // the collection of cataloged plugins used
@@ -102,6 +142,7 @@ type RequestedPlugin struct {
 	path      string
 	checkSum  [sha256.Size]byte
 	signature []byte
+	metadata  *PluginMetadata
 }
 
 // NewRequestedPlugin returns a Requested Plugin which represents the plugin path and signature
@@ -158,6 +199,32 @@ func NewRequestedPlugin(path, fileName string, b []byte) (*RequestedPlugin, erro
 	return rp, nil
 }
 
+// NewRequestedPluginFromReader returns a requested plugin read from r, as it
+// is received by the REST API, and written to a temporary directory in dir as
+// it is read, so that the plugin is never held in memory. ErrPluginTooLarge is
+// returned when the plugin is larger than maxSize bytes, unless maxSize is 0.
+func NewRequestedPluginFromReader(fileName, dir string, r io.Reader, maxSize int64) (*RequestedPlugin, error) {
+	h := sha256.New()
+	r = io.TeeReader(r, h)
+	var lr *io.LimitedReader
+	if maxSize > 0 {
+		// read one byte past the maximum size to tell a plugin too large
+		lr = &io.LimitedReader{R: r, N: maxSize + 1}
+		r = lr
+	}
+	path, err := fileutils.WriteFileFrom(filepath.Base(fileName), dir, r)
+	if err != nil {
+		return nil, err
+	}
+	if lr != nil && lr.N == 0 {
+		os.RemoveAll(filepath.Dir(path))
+		return nil, ErrPluginTooLarge
+	}
+	rp := &RequestedPlugin{path: path}
+	copy(rp.checkSum[:], h.Sum(nil))
+	return rp, nil
+}
+
 func (p *RequestedPlugin) Path() string {
 	return p.path
 }
@@ -166,6 +233,12 @@ func (p *RequestedPlugin) CheckSum() [sha256.Size]byte {
 	return p.checkSum
 }
 
+// VerifyCheckSum returns ErrCheckSumMismatch when the plugin does not match
+// the hex encoded SHA-256 checksum expected.
+func (p *RequestedPlugin) VerifyCheckSum(expected string) error {
+	return verifyCheckSum(p.checkSum, expected)
+}
+
 func (p *RequestedPlugin) Signature() []byte {
 	return p.signature
 }
@@ -197,3 +270,25 @@ func (p *RequestedPlugin) ReadSignatureFile(file string) error {
 	p.SetSignature(b)
 	return nil
 }
+
+func (p *RequestedPlugin) Metadata() *PluginMetadata {
+	return p.metadata
+}
+
+func (p *RequestedPlugin) SetMetadata(m *PluginMetadata) {
+	p.metadata = m
+}
+
+// ReadMetadataFile reads the plugin metadata file. A signature embedded in the
+// metadata is used when the plugin has no signature yet.
+func (p *RequestedPlugin) ReadMetadataFile(file string) error {
+	m, err := ReadPluginMetadataFile(file)
+	if err != nil {
+		return err
+	}
+	p.SetMetadata(m)
+	if p.signature == nil && m.Signature != "" {
+		p.SetSignature([]byte(m.Signature))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/intelsdi-x/snap/pkg/versions"
+)
+
+// PluginAPIVersion is the version of the plugin API implemented by
+// snapteld, which plugins may require with the api_version of their
+// metadata.
+const PluginAPIVersion = 1
+
+// PluginDependency is a plugin which must be loaded before the plugin
+// requiring it.
+type PluginDependency struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Version is the constraint the version of the required plugin must
+	// satisfy (e.g. ">=3, <5"), any version being accepted when it is empty
+	Version string `json:"version,omitempty"`
+}
+
+func (d PluginDependency) String() string {
+	if d.Version == "" {
+		return fmt.Sprintf("%s:%s", d.Type, d.Name)
+	}
+	return fmt.Sprintf("%s:%s (%s)", d.Type, d.Name, d.Version)
+}
+
+// validateCompatibility checks the compatibility declarations of the
+// metadata can be parsed.
+func (m *PluginMetadata) validateCompatibility() error {
+	if m.APIVersion != "" {
+		if _, err := versions.ParseConstraint(m.APIVersion); err != nil {
+			return fmt.Errorf("plugin metadata api_version: %v", err)
+		}
+	}
+	for _, d := range m.Requires {
+		if d.Name == "" {
+			return fmt.Errorf("plugin metadata requires a plugin without name")
+		}
+		if _, err := ToPluginType(d.Type); err != nil {
+			return fmt.Errorf("plugin metadata requires %s: %v", d, err)
+		}
+		if d.Version != "" {
+			if _, err := versions.ParseConstraint(d.Version); err != nil {
+				return fmt.Errorf("plugin metadata requires %s: %v", d, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckPlatform returns an error if the plugin does not support the plugin
+// API version, the operating system or the architecture of snapteld, so
+// that it is refused before it is run.
+func (m *PluginMetadata) CheckPlatform() error {
+	if m.APIVersion != "" {
+		c, err := versions.ParseConstraint(m.APIVersion)
+		if err != nil {
+			return err
+		}
+		if !c.Check(PluginAPIVersion) {
+			return fmt.Errorf("plugin %s requires the plugin API version %s, snapteld implements version %d; upgrade snapteld or load a plugin release supporting it",
+				m.Name, c, PluginAPIVersion)
+		}
+	}
+	if !supports(m.OS, runtime.GOOS) {
+		return fmt.Errorf("plugin %s is built for the operating systems %s, not %s; load the %s build of the plugin",
+			m.Name, strings.Join(m.OS, ", "), runtime.GOOS, runtime.GOOS)
+	}
+	if !supports(m.Arch, runtime.GOARCH) {
+		return fmt.Errorf("plugin %s is built for the architectures %s, not %s; load the %s build of the plugin",
+			m.Name, strings.Join(m.Arch, ", "), runtime.GOARCH, runtime.GOARCH)
+	}
+	return nil
+}
+
+// supports returns true when the list is empty or contains the value
+func supports(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"runtime"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginCompatibility(t *testing.T) {
+	Convey("Plugin compatibility declarations", t, func() {
+		Convey("are validated", func() {
+			So((&PluginMetadata{Name: "mock", APIVersion: ">=1"}).Validate(), ShouldBeNil)
+			So((&PluginMetadata{Name: "mock", APIVersion: "latest"}).Validate(), ShouldNotBeNil)
+			So((&PluginMetadata{Name: "mock", Requires: []PluginDependency{{Type: "collector", Name: "cpu", Version: ">=2"}}}).Validate(), ShouldBeNil)
+			So((&PluginMetadata{Name: "mock", Requires: []PluginDependency{{Type: "exporter", Name: "cpu"}}}).Validate(), ShouldNotBeNil)
+			So((&PluginMetadata{Name: "mock", Requires: []PluginDependency{{Type: "collector"}}}).Validate(), ShouldNotBeNil)
+		})
+		Convey("accept the platform of snapteld", func() {
+			md := &PluginMetadata{
+				Name:       "mock",
+				APIVersion: ">=1",
+				OS:         []string{"plan9", runtime.GOOS},
+				Arch:       []string{runtime.GOARCH},
+			}
+			So(md.CheckPlatform(), ShouldBeNil)
+			So((&PluginMetadata{Name: "mock"}).CheckPlatform(), ShouldBeNil)
+		})
+		Convey("refuse another platform", func() {
+			So((&PluginMetadata{Name: "mock", APIVersion: ">1"}).CheckPlatform(), ShouldNotBeNil)
+			So((&PluginMetadata{Name: "mock", OS: []string{"plan9"}}).CheckPlatform(), ShouldNotBeNil)
+			So((&PluginMetadata{Name: "mock", Arch: []string{"mips"}}).CheckPlatform(), ShouldNotBeNil)
+		})
+	})
+}
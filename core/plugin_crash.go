@@ -0,0 +1,52 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import "time"
+
+// PluginCrash is the diagnostics record captured when a running instance of
+// a plugin crashed or stopped answering.
+type PluginCrash struct {
+	// ID is the id of the instance of the plugin which crashed
+	ID uint32 `json:"id"`
+	// Time is when the crash was detected
+	Time time.Time `json:"time"`
+	// Reason is why the instance was handled as crashed
+	Reason string `json:"reason"`
+	// ExitStatus is the exit status of the plugin process, empty when the
+	// process is not run by snapteld
+	ExitStatus string `json:"exit_status,omitempty"`
+	// Output is the last output, stdout and stderr, of the plugin process
+	Output string `json:"output,omitempty"`
+	// Calls are the last calls made to the instance, the latest last
+	Calls []PluginCall `json:"calls,omitempty"`
+	// Env is the environment of the plugin process, the values of the
+	// variables which may hold secrets being redacted
+	Env []string `json:"env,omitempty"`
+}
+
+// PluginCall is a call made to a running instance of a plugin.
+type PluginCall struct {
+	Method string    `json:"method"`
+	Time   time.Time `json:"time"`
+	// Duration is the duration of the call in milliseconds
+	Duration float64 `json:"duration_ms"`
+	Error    string  `json:"error,omitempty"`
+}
@@ -0,0 +1,198 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// PluginMetadataExt is the extension of the optional metadata file placed
+// next to a plugin binary (e.g. snap-plugin-collector-cpu.meta.json).
+const PluginMetadataExt = ".meta.json"
+
+var (
+	// ErrPluginMetadataNameMissing - error message when the metadata file does not name the plugin
+	ErrPluginMetadataNameMissing = errors.New("plugin metadata must contain the plugin name")
+	// ErrPluginMetadataChecksumMismatch - error message when the plugin binary does not match the metadata checksum
+	ErrPluginMetadataChecksumMismatch = errors.New("plugin checksum does not match the checksum in plugin metadata")
+)
+
+// PluginMetadata describes a plugin binary without having to run it. It is
+// read from a sidecar file and checked against the binary before it is
+// executed and against the plugin's handshake once it is.
+type PluginMetadata struct {
+	Name           string             `json:"name"`
+	Version        int                `json:"version,omitempty"`
+	Type           string             `json:"type,omitempty"`
+	CheckSum       string             `json:"checksum,omitempty"`
+	Signature      string             `json:"signature,omitempty"`
+	Description    string             `json:"description,omitempty"`
+	License        string             `json:"license,omitempty"`
+	Author         string             `json:"author,omitempty"`
+	URL            string             `json:"url,omitempty"`
+	RequiredConfig []string           `json:"required_config,omitempty"`
+	Image          string             `json:"image,omitempty"`
+	APIVersion     string             `json:"api_version,omitempty"`
+	OS             []string           `json:"os,omitempty"`
+	Arch           []string           `json:"arch,omitempty"`
+	Requires       []PluginDependency `json:"requires,omitempty"`
+}
+
+func (m *PluginMetadata) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		var dest interface{}
+		switch k {
+		case "name":
+			dest = &m.Name
+		case "version":
+			dest = &m.Version
+		case "type":
+			dest = &m.Type
+		case "checksum":
+			dest = &m.CheckSum
+		case "signature":
+			dest = &m.Signature
+		case "description":
+			dest = &m.Description
+		case "license":
+			dest = &m.License
+		case "author":
+			dest = &m.Author
+		case "url":
+			dest = &m.URL
+		case "required_config":
+			dest = &m.RequiredConfig
+		case "image":
+			dest = &m.Image
+		case "api_version":
+			dest = &m.APIVersion
+		case "os":
+			dest = &m.OS
+		case "arch":
+			dest = &m.Arch
+		case "requires":
+			dest = &m.Requires
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in plugin metadata.", k)
+		}
+		if err := json.Unmarshal(v, dest); err != nil {
+			return fmt.Errorf("%v (while parsing '%v')", err, k)
+		}
+	}
+	return nil
+}
+
+// Validate checks the metadata is complete and consistent
+func (m *PluginMetadata) Validate() error {
+	if m.Name == "" {
+		return ErrPluginMetadataNameMissing
+	}
+	if m.Version < 0 {
+		return fmt.Errorf("plugin metadata version must not be negative, got %d", m.Version)
+	}
+	if m.Type != "" {
+		if _, err := ToPluginType(m.Type); err != nil {
+			return err
+		}
+	}
+	if m.CheckSum != "" {
+		if b, err := hex.DecodeString(m.CheckSum); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("plugin metadata checksum must be a hex encoded SHA-256 sum")
+		}
+	}
+	return m.validateCompatibility()
+}
+
+// VerifyCheckSum returns an error if the metadata contains a checksum which
+// is different from the given one.
+func (m *PluginMetadata) VerifyCheckSum(cs [sha256.Size]byte) error {
+	if m.CheckSum == "" {
+		return nil
+	}
+	if !strings.EqualFold(m.CheckSum, hex.EncodeToString(cs[:])) {
+		return ErrPluginMetadataChecksumMismatch
+	}
+	return nil
+}
+
+// Matches returns an error if the name, type or version the plugin announced
+// are different from the ones found in the metadata. Type and version are
+// only compared when the metadata sets them.
+func (m *PluginMetadata) Matches(p Plugin) error {
+	if m.Name != p.Name() ||
+		(m.Type != "" && m.Type != p.TypeName()) ||
+		(m.Version > 0 && m.Version != p.Version()) {
+		return fmt.Errorf("plugin metadata (%s:%s:%d) does not match the loaded plugin (%s:%s:%d)",
+			m.Type, m.Name, m.Version, p.TypeName(), p.Name(), p.Version())
+	}
+	return nil
+}
+
+// PluginInfo describes a plugin in the plugin catalog
+type PluginInfo struct {
+	Description string   `json:"description,omitempty"`
+	License     string   `json:"license,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	OS          []string `json:"os,omitempty"`
+	Arch        []string `json:"arch,omitempty"`
+}
+
+// Info returns the description of the plugin given by the metadata, which
+// is empty when there is no metadata.
+func (m *PluginMetadata) Info() PluginInfo {
+	if m == nil {
+		return PluginInfo{}
+	}
+	return PluginInfo{
+		Description: m.Description,
+		License:     m.License,
+		Author:      m.Author,
+		URL:         m.URL,
+		OS:          m.OS,
+		Arch:        m.Arch,
+	}
+}
+
+// ReadPluginMetadataFile reads and validates the plugin metadata file
+func ReadPluginMetadataFile(file string) (*PluginMetadata, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := &PluginMetadata{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%v (while parsing '%v')", err, file)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,97 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type metadataTestPlugin struct {
+	name, typeName string
+	version        int
+}
+
+func (p metadataTestPlugin) Name() string     { return p.name }
+func (p metadataTestPlugin) TypeName() string { return p.typeName }
+func (p metadataTestPlugin) Version() int     { return p.version }
+
+func TestPluginMetadata(t *testing.T) {
+	Convey("Plugin metadata file", t, func() {
+		dir, err := ioutil.TempDir("", "plugin-metadata")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "snap-plugin-collector-mock"+PluginMetadataExt)
+		cs := sha256.Sum256([]byte("binary"))
+
+		Convey("is read and validated", func() {
+			content := `{"name": "mock", "version": 2, "type": "collector", "checksum": "` + hex.EncodeToString(cs[:]) + `",
+				"description": "mock collector", "required_config": ["password"], "signature": "sig",
+				"image": "alpine:3.5", "license": "Apache-2.0", "author": "Intel", "url": "https://github.com/intelsdi-x/snap",
+				"os": ["linux"], "arch": ["amd64"]}`
+			So(ioutil.WriteFile(file, []byte(content), 0644), ShouldBeNil)
+			rp := &RequestedPlugin{}
+			So(rp.ReadMetadataFile(file), ShouldBeNil)
+			md := rp.Metadata()
+			So(md.Name, ShouldEqual, "mock")
+			So(md.RequiredConfig, ShouldResemble, []string{"password"})
+			So(md.Image, ShouldEqual, "alpine:3.5")
+			So(md.Info(), ShouldResemble, PluginInfo{
+				Description: "mock collector",
+				License:     "Apache-2.0",
+				Author:      "Intel",
+				URL:         "https://github.com/intelsdi-x/snap",
+				OS:          []string{"linux"},
+				Arch:        []string{"amd64"},
+			})
+			So((*PluginMetadata)(nil).Info(), ShouldResemble, PluginInfo{})
+			So(string(rp.Signature()), ShouldEqual, "sig")
+			So(md.VerifyCheckSum(cs), ShouldBeNil)
+			So(md.VerifyCheckSum(sha256.Sum256([]byte("other"))), ShouldEqual, ErrPluginMetadataChecksumMismatch)
+			So(md.Matches(metadataTestPlugin{"mock", "collector", 2}), ShouldBeNil)
+			So(md.Matches(metadataTestPlugin{"mock", "collector", 3}), ShouldNotBeNil)
+			So(md.Matches(metadataTestPlugin{"mock", "publisher", 2}), ShouldNotBeNil)
+		})
+		Convey("requires a name", func() {
+			So(ioutil.WriteFile(file, []byte(`{"version": 1}`), 0644), ShouldBeNil)
+			_, err := ReadPluginMetadataFile(file)
+			So(err, ShouldEqual, ErrPluginMetadataNameMissing)
+		})
+		Convey("rejects unknown keys and invalid values", func() {
+			So(ioutil.WriteFile(file, []byte(`{"name": "mock", "maintainer": "me"}`), 0644), ShouldBeNil)
+			_, err := ReadPluginMetadataFile(file)
+			So(err, ShouldNotBeNil)
+			So(ioutil.WriteFile(file, []byte(`{"name": "mock", "type": "exporter"}`), 0644), ShouldBeNil)
+			_, err = ReadPluginMetadataFile(file)
+			So(err, ShouldNotBeNil)
+			So(ioutil.WriteFile(file, []byte(`{"name": "mock", "checksum": "abc"}`), 0644), ShouldBeNil)
+			_, err = ReadPluginMetadataFile(file)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -23,9 +23,11 @@ package core
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/intelsdi-x/snap/plugin/helper"
@@ -117,3 +119,18 @@ func TestRequestedPlugin(t *testing.T) {
 		})
 	})
 }
+
+func TestVerifyCheckSum(t *testing.T) {
+	Convey("VerifyCheckSum", t, func() {
+		b := []byte("plugin")
+		sum := sha256.Sum256(b)
+		Convey("accepts the checksum of the content in any case", func() {
+			So(VerifyCheckSum(b, hex.EncodeToString(sum[:])), ShouldBeNil)
+			So(VerifyCheckSum(b, strings.ToUpper(hex.EncodeToString(sum[:]))), ShouldBeNil)
+		})
+		Convey("rejects other checksums", func() {
+			So(VerifyCheckSum(append(b, 0), hex.EncodeToString(sum[:])), ShouldEqual, ErrCheckSumMismatch)
+			So(VerifyCheckSum(b, "not a checksum"), ShouldEqual, ErrCheckSumMismatch)
+		})
+	})
+}
@@ -0,0 +1,36 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+// PluginResourceUsage is the usage of the resources of the host by a running
+// plugin, as last sampled by snapteld. The usage of plugins which process is
+// not run by snapteld, or on hosts snapteld cannot sample, is left empty.
+type PluginResourceUsage struct {
+	// CPUPercent is the share of a CPU used by the plugin since the previous
+	// sample
+	CPUPercent float64 `json:"cpu_percent,omitempty"`
+	// RSS is the resident memory of the plugin in bytes
+	RSS uint64 `json:"rss_bytes,omitempty"`
+	// OpenFDs is the count of the file descriptors opened by the plugin
+	OpenFDs int `json:"open_fds,omitempty"`
+	// RPCLatency is the moving average of the duration of the calls made to
+	// the plugin, in milliseconds
+	RPCLatency float64 `json:"rpc_latency_ms,omitempty"`
+}
@@ -32,6 +32,9 @@ const (
 	TaskDisabled           = "Scheduler.TaskDisabled"
 	MetricCollected        = "Scheduler.MetricsCollected"
 	MetricCollectionFailed = "Scheduler.MetricCollectionFailed"
+	MetricPublished        = "Scheduler.MetricsPublished"
+
+	TaskMetricsThresholdExceeded = "Scheduler.TaskMetricsThresholdExceeded"
 )
 
 type TaskStartedEvent struct {
@@ -106,3 +109,24 @@ type MetricCollectionFailedEvent struct {
 func (e MetricCollectionFailedEvent) Namespace() string {
 	return MetricCollectionFailed
 }
+
+type MetricPublishedEvent struct {
+	TaskID string
+	Count  int
+}
+
+func (e MetricPublishedEvent) Namespace() string {
+	return MetricPublished
+}
+
+type TaskMetricsThresholdExceededEvent struct {
+	TaskID     string
+	Count      int
+	Average    float64
+	Threshold  float64
+	Namespaces []string
+}
+
+func (e TaskMetricsThresholdExceededEvent) Namespace() string {
+	return TaskMetricsThresholdExceeded
+}
@@ -0,0 +1,145 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+var (
+	// ErrInvalidSecretRef is returned for secret references not of the form <scheme>:<path>
+	ErrInvalidSecretRef = errors.New("Secret reference must be of the form <scheme>:<path>")
+	// ErrSecretOutsideDir is returned for file secret references to files
+	// outside of the secrets directory
+	ErrSecretOutsideDir = errors.New("Secret file is outside of the secrets directory")
+
+	secretResolversMutex sync.RWMutex
+	secretResolvers      = map[string]SecretResolver{}
+)
+
+func init() {
+	wmap.ResolveSecret = ResolveSecret
+}
+
+// SecretResolver resolves references to secrets kept in an external secret
+// store. The path is the part of the reference after its scheme, e.g.
+// "kv/db#password" for "vault:kv/db#password".
+type SecretResolver interface {
+	ResolveSecret(path string) (string, error)
+}
+
+// RegisterSecretResolver registers the resolver of the secret references with
+// the given scheme, replacing the one registered before if any. No scheme is
+// registered by default.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretResolversMutex.Lock()
+	defer secretResolversMutex.Unlock()
+	secretResolvers[scheme] = r
+}
+
+// ResolveSecret resolves a secret reference with the resolver registered for
+// its scheme.
+func ResolveSecret(ref string) (string, error) {
+	i := strings.Index(ref, ":")
+	if i < 1 || i == len(ref)-1 {
+		return "", ErrInvalidSecretRef
+	}
+	scheme, path := ref[:i], ref[i+1:]
+	secretResolversMutex.RLock()
+	r, ok := secretResolvers[scheme]
+	secretResolversMutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("No secret resolver registered for scheme '%s'", scheme)
+	}
+	return r.ResolveSecret(path)
+}
+
+// NewEnvSecretResolver returns a SecretResolver for references to the
+// environment variables of snapteld, e.g. "env:DB_PASSWORD".
+func NewEnvSecretResolver() SecretResolver {
+	return envSecretResolver{}
+}
+
+// NewFileSecretResolver returns a SecretResolver for references to the files
+// of the given directory holding a secret, e.g. "file:db_password" or
+// "file:/run/secrets/db_password" for the directory /run/secrets.
+func NewFileSecretResolver(dir string) SecretResolver {
+	return fileSecretResolver{dir: dir}
+}
+
+// envSecretResolver resolves references to environment variables
+type envSecretResolver struct{}
+
+func (envSecretResolver) ResolveSecret(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable '%s' is not set", name)
+	}
+	return v, nil
+}
+
+// fileSecretResolver resolves references to files holding a secret, which
+// must be in its directory once their symbolic links are followed. A trailing
+// newline is not part of the secret.
+type fileSecretResolver struct {
+	dir string
+}
+
+func (f fileSecretResolver) ResolveSecret(path string) (string, error) {
+	dir, err := filepath.Abs(f.dir)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	// files outside of the directory are refused before being looked up
+	if !inDir(dir, path) {
+		return "", ErrSecretOutsideDir
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", err
+	}
+	if !inDir(dir, path) {
+		return "", ErrSecretOutsideDir
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// inDir returns whether the path is in the directory
+func inDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
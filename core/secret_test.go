@@ -0,0 +1,109 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+type mockSecretResolver map[string]string
+
+func (m mockSecretResolver) ResolveSecret(path string) (string, error) {
+	if s, ok := m[path]; ok {
+		return s, nil
+	}
+	return "", errors.New("secret not found")
+}
+
+func TestResolveSecret(t *testing.T) {
+	Convey("Resolve secret references", t, func() {
+		Convey("fails for the env and file schemes until they are registered", func() {
+			_, err := ResolveSecret("env:HOME")
+			So(err, ShouldNotBeNil)
+			_, err = ResolveSecret("file:/etc/hostname")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("with a registered resolver", func() {
+			RegisterSecretResolver("mock", mockSecretResolver{"kv/db#password": "hunter2"})
+			s, err := ResolveSecret("mock:kv/db#password")
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "hunter2")
+			_, err = ResolveSecret("mock:kv/db#user")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("from the environment", func() {
+			os.Setenv("SNAP_TEST_SECRET", "s3cret")
+			defer os.Unsetenv("SNAP_TEST_SECRET")
+			RegisterSecretResolver("env", NewEnvSecretResolver())
+			s, err := ResolveSecret("env:SNAP_TEST_SECRET")
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "s3cret")
+		})
+		Convey("from a file of the secrets directory", func() {
+			dir, err := ioutil.TempDir("", "secrets")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			So(ioutil.WriteFile(filepath.Join(dir, "db_password"), []byte("s3cret\n"), 0600), ShouldBeNil)
+			RegisterSecretResolver("file", NewFileSecretResolver(dir))
+			s, err := ResolveSecret("file:db_password")
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "s3cret")
+			s, err = ResolveSecret("file:" + filepath.Join(dir, "db_password"))
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "s3cret")
+
+			Convey("but not from other files", func() {
+				_, err := ResolveSecret("file:../db_password")
+				So(err, ShouldEqual, ErrSecretOutsideDir)
+				_, err = ResolveSecret("file:/etc/hostname")
+				So(err, ShouldEqual, ErrSecretOutsideDir)
+				So(os.Symlink(filepath.Dir(dir), filepath.Join(dir, "parent")), ShouldBeNil)
+				_, err = ResolveSecret("file:parent")
+				So(err, ShouldEqual, ErrSecretOutsideDir)
+			})
+		})
+		Convey("fails for invalid references", func() {
+			_, err := ResolveSecret("kv/db")
+			So(err, ShouldEqual, ErrInvalidSecretRef)
+			_, err = ResolveSecret("unknown:kv/db")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("when converting workflow config", func() {
+			RegisterSecretResolver("mock", mockSecretResolver{"kv/db#password": "hunter2"})
+			w, err := wmap.FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "config": {"password": {"secretRef": "mock:kv/db#password"}}}]}}`)
+			So(err, ShouldBeNil)
+			cdn, err := w.CollectNode.PublishNodes[0].GetConfigNode()
+			So(err, ShouldBeNil)
+			So(cdn.Table()["password"], ShouldResemble, ctypes.ConfigValueStr{Value: "hunter2"})
+			So(w.CollectNode.PublishNodes[0].Config["password"], ShouldResemble, map[string]interface{}{"secretRef": "mock:kv/db#password"})
+		})
+	})
+}
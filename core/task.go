@@ -26,6 +26,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -94,10 +95,70 @@ type Task interface {
 	SetMaxCollectDuration(time.Duration)
 	MaxMetricsBuffer() int64
 	SetMaxMetricsBuffer(int64)
+	MetricsThreshold() float64
+	SetMetricsThreshold(float64)
 	GetStopOnFailure() int
 	Option(...TaskOption) TaskOption
 	WMap() *wmap.WorkflowMap
 	Schedule() schedule.Schedule
+	// Revision changes every time the task is modified
+	Revision() uint64
+	ConfigHistory() []TaskConfigChange
+	// RunErrors returns the last errors of the runs of the task, oldest first
+	RunErrors() []TaskRunError
+	// WorkflowGraph describes the nodes of the workflow of the task
+	WorkflowGraph() *WorkflowGraph
+	// Stats returns the run counters and workflow latencies of the task
+	Stats() TaskStats
+}
+
+// TaskConfigChange records a config patch applied to a task and the
+// revision of the task it resulted in
+type TaskConfigChange struct {
+	Time     time.Time         `json:"time"`
+	Revision uint64            `json:"revision"`
+	Patch    *wmap.ConfigPatch `json:"patch"`
+}
+
+// TaskRunError records an error of a run of a task and the workflow node it
+// comes from
+type TaskRunError struct {
+	Time time.Time `json:"time"`
+	// Node is the type of the workflow node: collector, processor or publisher
+	Node          string `json:"node"`
+	PluginName    string `json:"plugin_name,omitempty"`
+	PluginVersion int    `json:"plugin_version,omitempty"`
+	Message       string `json:"message"`
+	// Class is set for the errors of a known kind, e.g. TaskRunErrorTimeout
+	Class string `json:"class,omitempty"`
+}
+
+// TaskRunErrorTimeout is the class of the errors of the plugins which did
+// not answer within their timeout
+const TaskRunErrorTimeout = "timeout"
+
+// TaskStats holds the counters of the firings of a task and the latencies
+// of the collections and publishes of its workflow
+type TaskStats struct {
+	HitCount    uint `json:"hit_count"`
+	MissCount   uint `json:"miss_count"`
+	FailedCount uint `json:"failed_count"`
+	// SkippedCount is the number of firings which did not run the workflow:
+	// the scheduler was drained, no metric was due or the run was held back
+	// by the metrics threshold of the task
+	SkippedCount   uint         `json:"skipped_count"`
+	CollectLatency LatencyStats `json:"collect_latency"`
+	PublishLatency LatencyStats `json:"publish_latency"`
+}
+
+// LatencyStats summarizes the durations, in milliseconds, of the last runs
+// of a workflow step
+type LatencyStats struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
 }
 
 type TaskOption func(Task) TaskOption
@@ -174,10 +235,41 @@ func SetMaxCollectDuration(d time.Duration) TaskOption {
 	}
 }
 
+// SetMetricsThreshold sets the multiple of a task's historical metrics volume
+// which, when exceeded by a single run, disables the task before the
+// collected metrics are processed or published. A value of 0 turns the
+// check off.
+func SetMetricsThreshold(n float64) TaskOption {
+	return func(t Task) TaskOption {
+		previous := t.MetricsThreshold()
+		t.SetMetricsThreshold(n)
+		return SetMetricsThreshold(previous)
+	}
+}
+
 type TaskErrors interface {
 	Errors() []serror.SnapError
 }
 
+// TaskCreationError is returned by CreateTaskFromContent when the task is
+// rejected, keeping the errors of the validation of the task with their fields.
+type TaskCreationError struct {
+	errs []serror.SnapError
+}
+
+func (t *TaskCreationError) Error() string {
+	msgs := make([]string, len(t.errs))
+	for i, e := range t.errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, " -- ")
+}
+
+// Errors returns the errors of the validation of the task
+func (t *TaskCreationError) Errors() []serror.SnapError {
+	return t.errs
+}
+
 type TaskCreationRequest struct {
 	Name               string            `json:"name"`
 	Version            int               `json:"version"`
@@ -188,6 +280,7 @@ type TaskCreationRequest struct {
 	MaxFailures        int               `json:"max-failures"`
 	MaxCollectDuration string            `json:"max-collect-duration"`
 	MaxMetricsBuffer   int64             `json:"max-metrics-buffer"`
+	MetricsThreshold   float64           `json:"metrics-threshold"`
 }
 
 func (tr *TaskCreationRequest) UnmarshalJSON(data []byte) error {
@@ -233,6 +326,13 @@ func (tr *TaskCreationRequest) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &(tr.MaxMetricsBuffer)); err != nil {
 				return fmt.Errorf("%v (while parsing 'max-metrics-buffer')", err)
 			}
+		case "metrics-threshold":
+			if err := json.Unmarshal(v, &(tr.MetricsThreshold)); err != nil {
+				return fmt.Errorf("%v (while parsing 'metrics-threshold')", err)
+			}
+			if tr.MetricsThreshold < 0 {
+				return fmt.Errorf("metrics-threshold must not be negative")
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in task creation request", k)
 		}
@@ -300,16 +400,16 @@ func CreateTaskFromContent(body io.ReadCloser,
 		opts = append(opts, SetMaxCollectDuration(dl))
 	}
 
+	if tr.MetricsThreshold != 0 {
+		opts = append(opts, SetMetricsThreshold(tr.MetricsThreshold))
+	}
+
 	if fp == nil {
 		return nil, errors.New("Missing workflow creation routine")
 	}
 	task, errs := fp(sch, tr.Workflow, *mode, opts...)
 	if errs != nil && len(errs.Errors()) != 0 {
-		var errMsg string
-		for _, e := range errs.Errors() {
-			errMsg = errMsg + e.Error() + " -- "
-		}
-		return nil, errors.New(errMsg[:len(errMsg)-4])
+		return nil, &TaskCreationError{errs: errs.Errors()}
 	}
 	return task, nil
 }
@@ -340,7 +440,7 @@ func validateTaskRequest(tr *TaskCreationRequest) error {
 		return fmt.Errorf("Task must include a schedule, and the schedule must not be empty")
 	}
 
-	if tr.Workflow == nil || *tr.Workflow == (wmap.WorkflowMap{}) {
+	if tr.Workflow == nil || (tr.Workflow.CollectNode == nil && len(tr.Workflow.Tags) == 0 && tr.Workflow.Ref == "" && tr.Workflow.URI == "") {
 		return fmt.Errorf("Task must include a workflow, and the workflow must not be empty")
 	}
 	return nil
@@ -0,0 +1,103 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/intelsdi-x/snap/pkg/schedule"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// ErrEmptyTaskUpdate is returned for a task update request replacing nothing
+var ErrEmptyTaskUpdate = errors.New("Task update must include a schedule, a workflow or a deadline")
+
+// TaskUpdate holds the parts of a task replaced by an update, the parts left
+// nil or zero are kept.
+type TaskUpdate struct {
+	Schedule schedule.Schedule
+	Workflow *wmap.WorkflowMap
+	Deadline time.Duration
+}
+
+// TaskUpdateRequest is the body of a request updating a task
+type TaskUpdateRequest struct {
+	Deadline string            `json:"deadline,omitempty"`
+	Workflow *wmap.WorkflowMap `json:"workflow,omitempty"`
+	Schedule *Schedule         `json:"schedule,omitempty"`
+}
+
+func (tr *TaskUpdateRequest) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "deadline":
+			if err := json.Unmarshal(v, &(tr.Deadline)); err != nil {
+				return fmt.Errorf("%v (while parsing 'deadline')", err)
+			}
+		case "workflow":
+			if err := json.Unmarshal(v, &(tr.Workflow)); err != nil {
+				return err
+			}
+		case "schedule":
+			if err := json.Unmarshal(v, &(tr.Schedule)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in task update request", k)
+		}
+	}
+	return nil
+}
+
+// TaskUpdateFromContent reads a task update request from the content, which
+// can be a HTTP REST request body.
+func TaskUpdateFromContent(body io.ReadCloser) (*TaskUpdate, error) {
+	var tr TaskUpdateRequest
+	if errCode, err := UnmarshalBody(&tr, body); errCode != 0 && err != nil {
+		return nil, err
+	}
+	update := &TaskUpdate{Workflow: tr.Workflow}
+	if tr.Schedule != nil && *tr.Schedule != (Schedule{}) {
+		sch, err := makeSchedule(*tr.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		update.Schedule = sch
+	}
+	if tr.Deadline != "" {
+		dl, err := time.ParseDuration(tr.Deadline)
+		if err != nil {
+			return nil, err
+		}
+		update.Deadline = dl
+	}
+	if update.Schedule == nil && update.Workflow == nil && update.Deadline == 0 {
+		return nil, ErrEmptyTaskUpdate
+	}
+	return update, nil
+}
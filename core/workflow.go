@@ -19,6 +19,12 @@ limitations under the License.
 
 package core
 
+import (
+	"time"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
 type WorkflowState int
 
 const (
@@ -31,3 +37,15 @@ type Workflow interface {
 	Unmarshal([]byte) error
 	State() WorkflowState
 }
+
+// StoredWorkflow is a workflow map stored in the scheduler under a name so
+// that tasks can reference it instead of embedding it. The version is
+// incremented every time the workflow is updated.
+type StoredWorkflow struct {
+	Name     string
+	Version  int
+	Updated  time.Time
+	Workflow *wmap.WorkflowMap
+	// TaskIDs are the tasks referencing the workflow
+	TaskIDs []string
+}
@@ -0,0 +1,133 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of the edges of a workflow graph
+const (
+	// WorkflowEdgeMetrics links a node to a child it sends metrics to
+	WorkflowEdgeMetrics = "metrics"
+	// WorkflowEdgeDeadLetter links a publish node to the dead-letter node
+	// receiving the metrics it failed to publish
+	WorkflowEdgeDeadLetter = "dead_letter"
+)
+
+// WorkflowGraph describes the collect, process and publish nodes of the
+// workflow of a task and how metrics flow between them, for visualization.
+type WorkflowGraph struct {
+	Nodes []WorkflowGraphNode `json:"nodes"`
+	Edges []WorkflowGraphEdge `json:"edges"`
+}
+
+// WorkflowGraphNode is a node of a workflow graph. Its ID is the path to the
+// node in the workflow map, e.g. collect.process[0].publish[1].
+type WorkflowGraphNode struct {
+	ID string `json:"id"`
+	// Type is collector, processor, publisher, filter or sample
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Target  string `json:"target,omitempty"`
+	// ContentType is the content type of the metrics the node receives,
+	// empty when it is negotiated by the plugins
+	ContentType string `json:"content_type,omitempty"`
+	// Metrics lists the namespaces requested by the collect node
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+// WorkflowGraphEdge links two nodes of a workflow graph by their IDs
+type WorkflowGraphEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Kind        string `json:"kind"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// AddNode adds a node to the graph, linking it to its parent unless parent is empty
+func (g *WorkflowGraph) AddNode(parent, kind string, n WorkflowGraphNode) {
+	g.Nodes = append(g.Nodes, n)
+	if parent != "" {
+		g.Edges = append(g.Edges, WorkflowGraphEdge{
+			From:        parent,
+			To:          n.ID,
+			Kind:        kind,
+			ContentType: n.ContentType,
+		})
+	}
+}
+
+// DOT returns the graph in the Graphviz DOT language
+func (g *WorkflowGraph) DOT() string {
+	var b bytes.Buffer
+	b.WriteString("digraph workflow {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		lines := []string{n.Type}
+		if n.Name != "" {
+			name := n.Name
+			if n.Version > 0 {
+				name += fmt.Sprintf(":%d", n.Version)
+			}
+			lines = append(lines, name)
+		}
+		if n.Target != "" {
+			lines = append(lines, "@"+n.Target)
+		}
+		lines = append(lines, n.Metrics...)
+		fmt.Fprintf(&b, "  %s [label=%s];\n", strconv.Quote(n.ID), dotLabel(lines))
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		if e.ContentType != "" {
+			attrs = " [label=" + strconv.Quote(e.ContentType)
+		}
+		if e.Kind == WorkflowEdgeDeadLetter {
+			if attrs == "" {
+				attrs = " [style=dashed"
+			} else {
+				attrs += ", style=dashed"
+			}
+		}
+		if attrs != "" {
+			attrs += "]"
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotLabel quotes the lines of a label, separated by the line breaks
+// understood by Graphviz
+func dotLabel(lines []string) string {
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		l = strings.Replace(l, `\`, `\\`, -1)
+		quoted[i] = strings.Replace(l, `"`, `\"`, -1)
+	}
+	return `"` + strings.Join(quoted, `\n`) + `"`
+}
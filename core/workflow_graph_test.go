@@ -0,0 +1,48 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorkflowGraphDOT(t *testing.T) {
+	Convey("Workflow graph in DOT", t, func() {
+		g := &WorkflowGraph{}
+		g.AddNode("", "", WorkflowGraphNode{ID: "collect", Type: "collector", Metrics: []string{"/intel/mock/foo"}})
+		g.AddNode("collect", WorkflowEdgeMetrics, WorkflowGraphNode{ID: "collect.publish[0]", Type: "publisher", Name: "influxdb", Version: 2, ContentType: "snap.json"})
+		g.AddNode("collect.publish[0]", WorkflowEdgeDeadLetter, WorkflowGraphNode{ID: "collect.publish[0].dead_letter", Type: "publisher", Name: `fi"le`, Version: -1, Target: "10.0.0.1:8082"})
+
+		So(g.DOT(), ShouldEqual, `digraph workflow {
+  rankdir=LR;
+  node [shape=box];
+  "collect" [label="collector\n/intel/mock/foo"];
+  "collect.publish[0]" [label="publisher\ninfluxdb:2"];
+  "collect.publish[0].dead_letter" [label="publisher\nfi\"le\n@10.0.0.1:8082"];
+  "collect" -> "collect.publish[0]" [label="snap.json"];
+  "collect.publish[0]" -> "collect.publish[0].dead_letter" [style=dashed];
+}
+`)
+	})
+}
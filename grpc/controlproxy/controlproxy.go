@@ -105,7 +105,7 @@ func (c ControlProxy) ProcessMetrics(metrics []core.Metric,
 }
 
 func (c ControlProxy) CollectMetrics(taskID string, AllTags map[string]map[string]string) ([]core.Metric, []error) {
-	var allTags map[string]*rpc.Map
+	allTags := map[string]*rpc.Map{}
 	for k, v := range AllTags {
 		tags := &rpc.Map{}
 		for kn, vn := range v {
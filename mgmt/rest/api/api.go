@@ -4,6 +4,15 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
+// RequestIDHeader is the header carrying the ID assigned to each request made
+// to the REST API, set on both the request and its response.
+const RequestIDHeader = "X-Request-Id"
+
+// PluginChecksumHeader is the header carrying the hex encoded SHA-256
+// checksum a plugin uploaded has to match to be loaded, and the one of a
+// plugin downloaded.
+const PluginChecksumHeader = "Plugin-Sha256"
+
 type API interface {
 	GetRoutes() []Route
 	BindMetricManager(Metrics)
@@ -15,4 +24,11 @@ type API interface {
 type Route struct {
 	Method, Path string
 	Handle       httprouter.Handle
+	// Role is the role required to call the route when API authentication
+	// is enabled; left unset it is derived from the method.
+	Role Role
+	// Deprecated marks a route superseded by the same method and path in a
+	// more recent API version. Its responses carry a 'Deprecation' header and
+	// a 'Link' to the successor.
+	Deprecated bool
 }
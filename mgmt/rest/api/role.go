@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Role is the level of access to the REST API granted to an authenticated
+// identity. Each role is allowed everything the roles below it are.
+type Role int
+
+const (
+	// RoleUnset leaves the role required by a route to be derived from its
+	// method: viewer for GET and HEAD, operator otherwise.
+	RoleUnset Role = iota
+	// RoleViewer can read the state of snapteld.
+	RoleViewer
+	// RoleOperator can create, change and delete tasks and workflows.
+	RoleOperator
+	// RoleAdmin can load and unload plugins and change their config.
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleViewer:   "viewer",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	if n, ok := roleNames[r]; ok {
+		return n
+	}
+	return "unset"
+}
+
+// Allows reports whether the role grants the access required by another.
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+// ParseRole returns the role with the given name.
+func ParseRole(name string) (Role, error) {
+	for r, n := range roleNames {
+		if strings.EqualFold(name, n) {
+			return r, nil
+		}
+	}
+	return RoleUnset, fmt.Errorf("unknown role '%s'", name)
+}
@@ -14,6 +14,17 @@ type Tasks interface {
 	StartTask(string) []serror.SnapError
 	StopTask(string) []serror.SnapError
 	RemoveTask(string) error
+	StartTaskIfRevision(string, uint64) []serror.SnapError
+	StopTaskIfRevision(string, uint64) []serror.SnapError
+	RemoveTaskIfRevision(string, uint64) error
+	EnableTaskIfRevision(string, uint64) (core.Task, error)
+	PatchTaskConfig(string, uint64, *wmap.ConfigPatch) (core.Task, []serror.SnapError)
+	UpdateTask(string, uint64, *core.TaskUpdate) (core.Task, []serror.SnapError)
 	WatchTask(string, core.TaskWatcherHandler) (core.TaskWatcherCloser, error)
 	EnableTask(string) (core.Task, error)
+	Accounting(string) ([]core.AccountingRecord, error)
+	AddWorkflow(string, *wmap.WorkflowMap, bool) (core.StoredWorkflow, []serror.SnapError)
+	GetWorkflows() []core.StoredWorkflow
+	GetWorkflow(string) (core.StoredWorkflow, error)
+	RemoveWorkflow(string) error
 }
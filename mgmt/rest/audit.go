@@ -0,0 +1,316 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const (
+	// auditLogSize is the number of the most recent audit records kept in
+	// memory and served by GET /audit.
+	auditLogSize = 1000
+	// defaultAuditLogMaxBackups is the number of rotated audit files kept
+	// next to the current one.
+	defaultAuditLogMaxBackups = 3
+)
+
+// AuditRecord is the record of a request changing snapteld, such as a plugin
+// load or a task creation: who made it, when, and the digest of its payload.
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	RequestID     string    `json:"request_id"`
+	User          string    `json:"user,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	Client        string    `json:"client"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Status        int       `json:"status"`
+	PayloadSize   int64     `json:"payload_size"`
+	PayloadSHA256 string    `json:"payload_sha256,omitempty"`
+}
+
+// AuditSink persists the audit records of the REST API, e.g. to a file or a
+// remote log collector.
+type AuditSink interface {
+	Write(rec *AuditRecord) error
+}
+
+// AuditRecordsResponse is the body of GET /audit.
+type AuditRecordsResponse struct {
+	Records []AuditRecord `json:"records"`
+}
+
+// auditLog is a middleware recording the mutating requests made to the REST
+// API, once authenticated and served, in memory and to its sinks. Requests
+// refused for lack of credentials never reach it.
+type auditLog struct {
+	sync.Mutex
+	records []AuditRecord
+	next    int
+	full    bool
+	sinks   []AuditSink
+	now     func() time.Time
+}
+
+func newAuditLog(size int) *auditLog {
+	return &auditLog{
+		records: make([]AuditRecord, size),
+		now:     time.Now,
+	}
+}
+
+// payloadDigest hashes the request body as it is read.
+type payloadDigest struct {
+	io.ReadCloser
+	h    hash.Hash
+	size int64
+}
+
+func (d *payloadDigest) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	d.h.Write(p[:n])
+	d.size += int64(n)
+	return n, err
+}
+
+func (a *auditLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if isReadOnly(r) || r.Method == "OPTIONS" {
+		next(rw, r)
+		return
+	}
+	start := a.now()
+	body := &payloadDigest{ReadCloser: r.Body, h: sha256.New()}
+	r.Body = body
+	next(rw, r)
+	// the digest covers the payload read by the handler: the rest of it, such
+	// as the remainder of a plugin refused for its size, is not read
+	body.Close()
+
+	rec := AuditRecord{
+		Time:        start.UTC(),
+		RequestID:   r.Header.Get(api.RequestIDHeader),
+		Client:      clientAddr(r),
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Status:      rw.(negroni.ResponseWriter).Status(),
+		PayloadSize: body.size,
+	}
+	if body.size > 0 {
+		rec.PayloadSHA256 = hex.EncodeToString(body.h.Sum(nil))
+	}
+	if id := RequestIdentity(r); id != nil {
+		rec.User = id.Name
+		rec.Role = id.Role.String()
+	}
+	a.add(rec)
+}
+
+func (a *auditLog) add(rec AuditRecord) {
+	a.Lock()
+	a.records[a.next] = rec
+	a.next = (a.next + 1) % len(a.records)
+	if a.next == 0 {
+		a.full = true
+	}
+	sinks := a.sinks
+	a.Unlock()
+
+	for _, sink := range sinks {
+		if err := sink.Write(&rec); err != nil {
+			restLogger.WithFields(log.Fields{
+				"_block":     "audit",
+				"request-id": rec.RequestID,
+			}).WithError(err).Error("Failed to write audit record")
+		}
+	}
+}
+
+// addSink adds a sink to which the records are written from now on.
+func (a *auditLog) addSink(sink AuditSink) {
+	a.Lock()
+	defer a.Unlock()
+	sinks := make([]AuditSink, len(a.sinks), len(a.sinks)+1)
+	copy(sinks, a.sinks)
+	a.sinks = append(sinks, sink)
+}
+
+// recent returns the records matching the filter, most recent first, up to
+// limit of them when limit is positive.
+func (a *auditLog) recent(limit int, match func(*AuditRecord) bool) []AuditRecord {
+	a.Lock()
+	defer a.Unlock()
+	n := a.next
+	if a.full {
+		n = len(a.records)
+	}
+	recs := []AuditRecord{}
+	for i := 1; i <= n; i++ {
+		rec := &a.records[(a.next-i+len(a.records))%len(a.records)]
+		if !match(rec) {
+			continue
+		}
+		recs = append(recs, *rec)
+		if limit > 0 && len(recs) == limit {
+			break
+		}
+	}
+	return recs
+}
+
+// auditFile is an AuditSink writing the records as JSON lines to a file. Once
+// the file grows over maxSize bytes it is rotated: renamed with a .1 suffix,
+// the previous ones shifting to .2 and so on, keeping up to maxBackups of them.
+type auditFile struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func newAuditFile(path string, maxSize int64, maxBackups int) (*auditFile, error) {
+	a := &auditFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *auditFile) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.f, a.size = f, fi.Size()
+	return nil
+}
+
+func (a *auditFile) Write(rec *AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	a.Lock()
+	defer a.Unlock()
+	if a.f == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(b)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.f.Write(b)
+	a.size += int64(n)
+	return err
+}
+
+func (a *auditFile) rotate() error {
+	err := a.f.Close()
+	a.f = nil
+	if err != nil {
+		return err
+	}
+	if a.maxBackups < 1 {
+		if err := os.Remove(a.path); err != nil {
+			return err
+		}
+		return a.open()
+	}
+	for i := a.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", a.path, i), fmt.Sprintf("%s.%d", a.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		return err
+	}
+	return a.open()
+}
+
+// Close closes the audit file.
+func (a *auditFile) Close() error {
+	a.Lock()
+	defer a.Unlock()
+	if a.f == nil {
+		return nil
+	}
+	err := a.f.Close()
+	a.f = nil
+	return err
+}
+
+// AddAuditSink adds a sink to which the audit records of the mutating
+// requests are written, along with the audit file when one is configured.
+func (s *Server) AddAuditSink(sink AuditSink) {
+	s.audit.addSink(sink)
+}
+
+func (s *Server) addAuditRoutes() {
+	s.r.GET("/audit", s.authorize(api.RoleAdmin, s.getAudit))
+}
+
+// getAudit returns the most recent audit records, optionally only the ones of
+// a user or of a method, up to the limit given.
+func (s *Server) getAudit(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	limit := 0
+	if l := q.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit: "+l, 400)
+			return
+		}
+	}
+	user, method := q.Get("user"), q.Get("method")
+	recs := s.audit.recent(limit, func(rec *AuditRecord) bool {
+		return (user == "" || rec.User == user) && (method == "" || rec.Method == method)
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&AuditRecordsResponse{Records: recs})
+}
@@ -0,0 +1,213 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+type memoryAuditSink []AuditRecord
+
+func (m *memoryAuditSink) Write(rec *AuditRecord) error {
+	*m = append(*m, *rec)
+	return nil
+}
+
+func TestAuditLog(t *testing.T) {
+	Convey("Given an audit log", t, func() {
+		a := newAuditLog(3)
+		a.now = func() time.Time { return time.Unix(1490000000, 0) }
+		sink := &memoryAuditSink{}
+		a.addSink(sink)
+		serve := func(method, path, body string, id *Identity, next http.HandlerFunc) {
+			r := httptest.NewRequest(method, path, strings.NewReader(body))
+			r.RemoteAddr = "10.0.0.1:5000"
+			r.Header.Set(api.RequestIDHeader, "req-"+path)
+			if id != nil {
+				r = withIdentity(r, id)
+			}
+			a.ServeHTTP(negroni.NewResponseWriter(httptest.NewRecorder()), r, next)
+		}
+		created := func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(201)
+		}
+
+		Convey("mutating requests are recorded", func() {
+			serve("POST", "/v2/tasks", "foo", &Identity{Name: "snap", Role: api.RoleOperator}, created)
+			So(*sink, ShouldHaveLength, 1)
+			rec := (*sink)[0]
+			So(rec.Time, ShouldResemble, time.Unix(1490000000, 0).UTC())
+			So(rec.RequestID, ShouldEqual, "req-/v2/tasks")
+			So(rec.User, ShouldEqual, "snap")
+			So(rec.Role, ShouldEqual, "operator")
+			So(rec.Client, ShouldEqual, "10.0.0.1")
+			So(rec.Method, ShouldEqual, "POST")
+			So(rec.Path, ShouldEqual, "/v2/tasks")
+			So(rec.Status, ShouldEqual, 201)
+			So(rec.PayloadSize, ShouldEqual, 3)
+			So(rec.PayloadSHA256, ShouldEqual, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+			So(a.recent(0, func(*AuditRecord) bool { return true }), ShouldResemble, []AuditRecord(*sink))
+		})
+		Convey("the digest only covers the payload read by the handler", func() {
+			serve("PUT", "/v2/tasks/1", "foo", nil, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(413)
+			})
+			So((*sink)[0].User, ShouldEqual, "")
+			So((*sink)[0].Status, ShouldEqual, 413)
+			So((*sink)[0].PayloadSize, ShouldEqual, 0)
+			So((*sink)[0].PayloadSHA256, ShouldEqual, "")
+		})
+		Convey("read-only requests are not recorded", func() {
+			serve("GET", "/v2/tasks", "", nil, created)
+			serve("OPTIONS", "/v2/tasks", "", nil, created)
+			So(*sink, ShouldBeEmpty)
+			So(a.recent(0, func(*AuditRecord) bool { return true }), ShouldBeEmpty)
+		})
+		Convey("the most recent records are kept, most recent first", func() {
+			for _, p := range []string{"/1", "/2", "/3", "/4"} {
+				serve("DELETE", p, "", nil, created)
+			}
+			recs := a.recent(0, func(*AuditRecord) bool { return true })
+			So(recs, ShouldHaveLength, 3)
+			So(recs[0].Path, ShouldEqual, "/4")
+			So(recs[2].Path, ShouldEqual, "/2")
+			So(recs[0].PayloadSHA256, ShouldEqual, "")
+			Convey("up to the limit given", func() {
+				recs := a.recent(1, func(*AuditRecord) bool { return true })
+				So(recs, ShouldHaveLength, 1)
+				So(recs[0].Path, ShouldEqual, "/4")
+			})
+		})
+	})
+}
+
+func TestGetAudit(t *testing.T) {
+	Convey("Given a server with audit records", t, func() {
+		s := &Server{audit: newAuditLog(10)}
+		s.audit.add(AuditRecord{User: "alice", Method: "POST", Path: "/v2/plugins"})
+		s.audit.add(AuditRecord{User: "bob", Method: "DELETE", Path: "/v2/tasks/1"})
+		s.audit.add(AuditRecord{User: "alice", Method: "DELETE", Path: "/v2/plugins/collector/mock/1"})
+		get := func(query string) (*httptest.ResponseRecorder, *AuditRecordsResponse) {
+			rw := httptest.NewRecorder()
+			s.getAudit(rw, httptest.NewRequest("GET", "/audit"+query, nil), nil)
+			resp := &AuditRecordsResponse{}
+			json.Unmarshal(rw.Body.Bytes(), resp)
+			return rw, resp
+		}
+
+		Convey("all the records are returned, most recent first", func() {
+			rw, resp := get("")
+			So(rw.Code, ShouldEqual, 200)
+			So(resp.Records, ShouldHaveLength, 3)
+			So(resp.Records[0].Path, ShouldEqual, "/v2/plugins/collector/mock/1")
+		})
+		Convey("the records can be filtered", func() {
+			_, resp := get("?user=alice&method=DELETE")
+			So(resp.Records, ShouldHaveLength, 1)
+			So(resp.Records[0].Path, ShouldEqual, "/v2/plugins/collector/mock/1")
+			_, resp = get("?user=alice&limit=1")
+			So(resp.Records, ShouldHaveLength, 1)
+		})
+		Convey("an invalid limit is rejected", func() {
+			rw, _ := get("?limit=-1")
+			So(rw.Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestAuditFile(t *testing.T) {
+	Convey("Given an audit file", t, func() {
+		dir, err := ioutil.TempDir("", "snap-audit")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "audit.log")
+		rec := &AuditRecord{User: "snap", Method: "POST", Path: "/v2/tasks", Status: 201}
+		b, _ := json.Marshal(rec)
+		lineSize := int64(len(b) + 1)
+
+		Convey("records are written as JSON lines", func() {
+			f, err := newAuditFile(path, 0, 1)
+			So(err, ShouldBeNil)
+			So(f.Write(rec), ShouldBeNil)
+			So(f.Write(rec), ShouldBeNil)
+			So(f.Close(), ShouldBeNil)
+			lines := readAuditLines(path)
+			So(lines, ShouldHaveLength, 2)
+			got := &AuditRecord{}
+			So(json.Unmarshal([]byte(lines[0]), got), ShouldBeNil)
+			So(got, ShouldResemble, rec)
+		})
+		Convey("the file is rotated once over its maximum size", func() {
+			f, err := newAuditFile(path, 2*lineSize, 2)
+			So(err, ShouldBeNil)
+			for i := 0; i < 7; i++ {
+				So(f.Write(rec), ShouldBeNil)
+			}
+			So(f.Close(), ShouldBeNil)
+			So(readAuditLines(path), ShouldHaveLength, 1)
+			So(readAuditLines(path+".1"), ShouldHaveLength, 2)
+			So(readAuditLines(path+".2"), ShouldHaveLength, 2)
+			_, err = os.Stat(path + ".3")
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("the records are appended to an existing file", func() {
+			f, err := newAuditFile(path, 0, 1)
+			So(err, ShouldBeNil)
+			So(f.Write(rec), ShouldBeNil)
+			f.Close()
+			f, err = newAuditFile(path, 0, 1)
+			So(err, ShouldBeNil)
+			So(f.Write(rec), ShouldBeNil)
+			f.Close()
+			So(readAuditLines(path), ShouldHaveLength, 2)
+		})
+	})
+}
+
+func readAuditLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	lines := []string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
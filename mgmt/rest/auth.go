@@ -0,0 +1,86 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// Authenticator decides whether a request made to the REST API carries valid
+// credentials. Authenticators are consulted in turn by the auth middleware and
+// the request is let through as soon as one of them returns an identity.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, bool)
+}
+
+// basicAuthenticator accepts requests using HTTP basic authentication with the
+// given password. The user name is not checked; the identity is an admin.
+type basicAuthenticator string
+
+func (p basicAuthenticator) Authenticate(r *http.Request) (*Identity, bool) {
+	user, password, ok := r.BasicAuth()
+	if !ok || p == "" || subtle.ConstantTimeCompare([]byte(password), []byte(p)) != 1 {
+		return nil, false
+	}
+	return &Identity{Name: user, Role: api.RoleAdmin}, true
+}
+
+// tokenAuthenticator accepts requests sending one of the static API tokens as
+// a bearer token, i.e. with the header 'Authorization: Bearer <token>'. Each
+// token is bound to the role of its identity.
+type tokenAuthenticator map[string]api.Role
+
+func (t tokenAuthenticator) Authenticate(r *http.Request) (*Identity, bool) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return nil, false
+	}
+	token := []byte(strings.TrimSpace(strings.TrimPrefix(h, "Bearer ")))
+	for tk, role := range t {
+		if tk != "" && subtle.ConstantTimeCompare(token, []byte(tk)) == 1 {
+			return &Identity{Name: "token", Role: role}, true
+		}
+	}
+	return nil, false
+}
+
+// isReadOnly reports whether the request can not change the state of snapteld.
+func isReadOnly(r *http.Request) bool {
+	return r.Method == "GET" || r.Method == "HEAD"
+}
+
+// authenticate returns the identity given by the first of the server's
+// authenticators accepting the request.
+func (s *Server) authenticate(r *http.Request) (*Identity, bool) {
+	authenticators := append([]Authenticator{
+		basicAuthenticator(s.authpwd),
+		tokenAuthenticator(s.authTokens),
+	}, s.authenticators...)
+	for _, a := range authenticators {
+		if id, ok := a.Authenticate(r); ok {
+			return id, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type headerAuthenticator string
+
+func (h headerAuthenticator) Authenticate(r *http.Request) (*Identity, bool) {
+	if r.Header.Get("X-Test-Auth") != string(h) {
+		return nil, false
+	}
+	return &Identity{Name: "header", Role: api.RoleOperator}, true
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	Convey("Given a server with API authentication enabled", t, func() {
+		s := &Server{}
+		s.SetAPIAuth(true)
+		s.SetAPIAuthPwd("changeme")
+		s.SetAPIAuthTokens(map[string]api.Role{"t0k3n": api.RoleAdmin, "v13w": api.RoleViewer})
+		var id *Identity
+		serve := func(r *http.Request) int {
+			id = nil
+			rw := httptest.NewRecorder()
+			s.authMiddleware(rw, r, func(w http.ResponseWriter, r *http.Request) {
+				id = RequestIdentity(r)
+				w.WriteHeader(200)
+			})
+			return rw.Code
+		}
+		Convey("requests without credentials are rejected", func() {
+			So(serve(httptest.NewRequest("GET", "/v1/plugins", nil)), ShouldEqual, 401)
+			So(serve(httptest.NewRequest("POST", "/v1/plugins", nil)), ShouldEqual, 401)
+		})
+		Convey("the password is accepted with basic auth", func() {
+			r := httptest.NewRequest("POST", "/v1/tasks", nil)
+			r.SetBasicAuth("snap", "changeme")
+			So(serve(r), ShouldEqual, 200)
+			So(id.Name, ShouldEqual, "snap")
+			So(id.Role, ShouldEqual, api.RoleAdmin)
+			r = httptest.NewRequest("POST", "/v1/tasks", nil)
+			r.SetBasicAuth("snap", "wrong")
+			So(serve(r), ShouldEqual, 401)
+		})
+		Convey("static tokens are accepted as bearer tokens", func() {
+			r := httptest.NewRequest("DELETE", "/v1/tasks/1234", nil)
+			r.Header.Set("Authorization", "Bearer t0k3n")
+			So(serve(r), ShouldEqual, 200)
+			So(id.Role, ShouldEqual, api.RoleAdmin)
+			r = httptest.NewRequest("GET", "/v1/tasks", nil)
+			r.Header.Set("Authorization", "Bearer v13w")
+			So(serve(r), ShouldEqual, 200)
+			So(id.Role, ShouldEqual, api.RoleViewer)
+			r = httptest.NewRequest("DELETE", "/v1/tasks/1234", nil)
+			r.Header.Set("Authorization", "Bearer wrong")
+			So(serve(r), ShouldEqual, 401)
+		})
+		Convey("an empty password does not authenticate", func() {
+			s.SetAPIAuthPwd("")
+			r := httptest.NewRequest("POST", "/v1/tasks", nil)
+			r.SetBasicAuth("snap", "")
+			So(serve(r), ShouldEqual, 401)
+		})
+		Convey("added authenticators are consulted", func() {
+			s.AddAuthenticator(headerAuthenticator("ok"))
+			r := httptest.NewRequest("PUT", "/v1/tasks/1234/start", nil)
+			r.Header.Set("X-Test-Auth", "ok")
+			So(serve(r), ShouldEqual, 200)
+			So(id.Role, ShouldEqual, api.RoleOperator)
+		})
+		Convey("read-only requests can be allowed without credentials", func() {
+			s.SetAPIAuthAllowReads(true)
+			So(serve(httptest.NewRequest("GET", "/v1/plugins", nil)), ShouldEqual, 200)
+			So(id, ShouldEqual, anonymousViewer)
+			So(serve(httptest.NewRequest("HEAD", "/v1/plugins", nil)), ShouldEqual, 200)
+			So(serve(httptest.NewRequest("POST", "/v1/plugins", nil)), ShouldEqual, 401)
+			So(serve(httptest.NewRequest("DELETE", "/v1/plugins/collector/mock/1", nil)), ShouldEqual, 401)
+		})
+	})
+}
@@ -23,7 +23,9 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -38,6 +40,7 @@ import (
 
 	"github.com/asaskevich/govalidator"
 
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
 	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
 )
 
@@ -194,7 +197,7 @@ func (c *Client) do(method, path string, ct contentType, body ...[]byte) (*rbody
 			return nil, fmt.Errorf("URL target is not available. %v", err)
 		}
 		defer rsp.Body.Close()
-	case "PUT":
+	case "PUT", "PATCH":
 		var b *bytes.Reader
 		if len(body) == 0 {
 			b = bytes.NewReader([]byte{})
@@ -334,6 +337,15 @@ func (c *Client) pluginUploadRequest(pluginPaths []string) (*rbody.APIResponse,
 	if CompressUpload {
 		req.Header.Add("Plugin-Compression", "gzip")
 	}
+	// the plugin is the first file uploaded, snapteld refuses it when it does
+	// not match its checksum
+	if len(pluginPaths) > 0 {
+		sum, err := fileCheckSum(pluginPaths[0])
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add(api.PluginChecksumHeader, sum)
+	}
 	rsp, err := c.http.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "tls: oversized record") || strings.Contains(err.Error(), "malformed HTTP response") {
@@ -348,6 +360,20 @@ func (c *Client) pluginUploadRequest(pluginPaths []string) (*rbody.APIResponse,
 	return httpRespToAPIResp(rsp)
 }
 
+// fileCheckSum returns the hex encoded SHA-256 checksum of the file.
+func fileCheckSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func writePluginToWriter(pw io.WriteCloser, bufin []*bufio.Reader, writer *multipart.Writer, pluginPaths []string, errChan chan error) {
 	for i, pluginPath := range pluginPaths {
 		part, err := writer.CreateFormFile("snap-plugins", pluginPath)
@@ -292,6 +292,62 @@ func (c *Client) EnableTask(id string) *EnableTaskResult {
 	}
 }
 
+// PatchTaskConfig overrides config items of the workflow of a task given its id.
+func (c *Client) PatchTaskConfig(id string, patch *wmap.ConfigPatch) *PatchTaskConfigResult {
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return &PatchTaskConfigResult{Err: err}
+	}
+	resp, err := c.do("PATCH", fmt.Sprintf("/tasks/%v/config", id), ContentTypeJSON, b)
+	if err != nil {
+		return &PatchTaskConfigResult{Err: err}
+	}
+
+	switch resp.Meta.Type {
+	case rbody.ScheduledTaskConfigPatchedType:
+		return &PatchTaskConfigResult{resp.Body.(*rbody.ScheduledTaskConfigPatched), nil}
+	case rbody.ErrorType:
+		return &PatchTaskConfigResult{Err: resp.Body.(*rbody.Error)}
+	default:
+		return &PatchTaskConfigResult{Err: ErrAPIResponseMetaType}
+	}
+}
+
+// UpdateTask replaces the schedule, workflow or deadline of a task given its
+// id, the ones left nil or empty are kept.
+func (c *Client) UpdateTask(id string, s *Schedule, wf *wmap.WorkflowMap, deadline string) *UpdateTaskResult {
+	t := core.TaskUpdateRequest{
+		Workflow: wf,
+		Deadline: deadline,
+	}
+	if s != nil {
+		t.Schedule = &core.Schedule{
+			Type:           s.Type,
+			Interval:       s.Interval,
+			StartTimestamp: s.StartTimestamp,
+			StopTimestamp:  s.StopTimestamp,
+			Count:          s.Count,
+		}
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return &UpdateTaskResult{Err: err}
+	}
+	resp, err := c.do("PUT", fmt.Sprintf("/tasks/%v", id), ContentTypeJSON, b)
+	if err != nil {
+		return &UpdateTaskResult{Err: err}
+	}
+
+	switch resp.Meta.Type {
+	case rbody.ScheduledTaskUpdatedType:
+		return &UpdateTaskResult{resp.Body.(*rbody.ScheduledTaskUpdated), nil}
+	case rbody.ErrorType:
+		return &UpdateTaskResult{Err: resp.Body.(*rbody.Error)}
+	default:
+		return &UpdateTaskResult{Err: ErrAPIResponseMetaType}
+	}
+}
+
 // CreateTaskResult is the response from snap/client on a CreateTask call.
 type CreateTaskResult struct {
 	*rbody.AddScheduledTask
@@ -345,3 +401,15 @@ type EnableTaskResult struct {
 	*rbody.ScheduledTaskEnabled
 	Err error
 }
+
+// PatchTaskConfigResult is the response from snap/client on a PatchTaskConfig call.
+type PatchTaskConfigResult struct {
+	*rbody.ScheduledTaskConfigPatched
+	Err error
+}
+
+// UpdateTaskResult is the response from snap/client on an UpdateTask call.
+type UpdateTaskResult struct {
+	*rbody.ScheduledTaskUpdated
+	Err error
+}
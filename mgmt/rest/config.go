@@ -1,18 +1,32 @@
 package rest
 
+import (
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
 // default configuration values
 const (
-	defaultEnable          bool   = true
-	defaultPort            int    = 8181
-	defaultAddress         string = ""
-	defaultHTTPS           bool   = false
-	defaultRestCertificate string = ""
-	defaultRestKey         string = ""
-	defaultAuth            bool   = false
-	defaultAuthPassword    string = ""
-	defaultPortSetByConfig bool   = false
-	defaultPprof           bool   = false
-	defaultCorsd           string = ""
+	defaultEnable          bool    = true
+	defaultPort            int     = 8181
+	defaultAddress         string  = ""
+	defaultHTTPS           bool    = false
+	defaultRestCertificate string  = ""
+	defaultRestKey         string  = ""
+	defaultAuth            bool    = false
+	defaultAuthPassword    string  = ""
+	defaultAuthAllowReads  bool    = false
+	defaultPortSetByConfig bool    = false
+	defaultPprof           bool    = false
+	defaultCorsd           string  = ""
+	defaultCorsMethods     string  = "GET, POST, DELETE, PUT, PATCH, OPTIONS"
+	defaultCorsHeaders     string  = "Origin, X-Requested-With, Content-Type, Accept, Authorization, If-Match"
+	defaultRateLimit       float64 = 0
+	defaultRateBurst       int     = 0
+	defaultMaxInflight     int     = 0
+	defaultAuditLog        string  = ""
+	defaultAuditLogMaxSize int     = 100
+	defaultMaxPluginUpload int     = 512
+	defaultPprofAddr       string  = ""
 )
 
 // holds the configuration passed in through the SNAP config file
@@ -20,17 +34,31 @@ const (
 //         UnmarshalJSON method in this same file needs to be modified to
 //         match the field mapping that is defined here
 type Config struct {
-	Enable           bool   `json:"enable"yaml:"enable"`
-	Port             int    `json:"port"yaml:"port"`
-	Address          string `json:"addr"yaml:"addr"`
-	HTTPS            bool   `json:"https"yaml:"https"`
-	RestCertificate  string `json:"rest_certificate"yaml:"rest_certificate"`
-	RestKey          string `json:"rest_key"yaml:"rest_key"`
-	RestAuth         bool   `json:"rest_auth"yaml:"rest_auth"`
-	RestAuthPassword string `json:"rest_auth_password"yaml:"rest_auth_password"`
-	portSetByConfig  bool   ``
-	Pprof            bool   `json:"pprof"yaml:"pprof"`
-	Corsd            string `json:"allowed_origins"yaml:"allowed_origins"`
+	Enable              bool              `json:"enable"yaml:"enable"`
+	Port                int               `json:"port"yaml:"port"`
+	Address             string            `json:"addr"yaml:"addr"`
+	HTTPS               bool              `json:"https"yaml:"https"`
+	RestCertificate     string            `json:"rest_certificate"yaml:"rest_certificate"`
+	RestKey             string            `json:"rest_key"yaml:"rest_key"`
+	RestAuth            bool              `json:"rest_auth"yaml:"rest_auth"`
+	RestAuthPassword    string            `json:"rest_auth_password"yaml:"rest_auth_password"`
+	RestAuthTokens      []string          `json:"rest_auth_tokens"yaml:"rest_auth_tokens"`
+	RestAuthTokenRoles  map[string]string `json:"rest_auth_token_roles"yaml:"rest_auth_token_roles"`
+	RestAuthAllowReads  bool              `json:"rest_auth_allow_reads"yaml:"rest_auth_allow_reads"`
+	portSetByConfig     bool              ``
+	Pprof               bool              `json:"pprof"yaml:"pprof"`
+	Corsd               string            `json:"allowed_origins"yaml:"allowed_origins"`
+	CorsMethods         string            `json:"allowed_methods"yaml:"allowed_methods"`
+	CorsHeaders         string            `json:"allowed_headers"yaml:"allowed_headers"`
+	RateLimit           float64           `json:"rate_limit"yaml:"rate_limit"`
+	RateBurst           int               `json:"rate_burst"yaml:"rate_burst"`
+	MaxInflightRequests int               `json:"max_inflight_requests"yaml:"max_inflight_requests"`
+	AuditLog            string            `json:"audit_log"yaml:"audit_log"`
+	AuditLogMaxSize     int               `json:"audit_log_max_size"yaml:"audit_log_max_size"`
+	AuditLogMaxBackups  int               `json:"audit_log_max_backups"yaml:"audit_log_max_backups"`
+	MaxPluginUploadSize int               `json:"max_plugin_upload_size"yaml:"max_plugin_upload_size"`
+	PprofAddr           string            `json:"pprof_addr"yaml:"pprof_addr"`
+	PluginRepository    string            `json:"plugin_repository"yaml:"plugin_repository"`
 }
 
 const (
@@ -50,6 +78,23 @@ const (
 					"rest_auth_password": {
 						"type": "string"
 					},
+					"rest_auth_tokens": {
+						"type": "array",
+						"items": {
+							"type": "string",
+							"minLength": 1
+						}
+					},
+					"rest_auth_token_roles": {
+						"type": "object",
+						"additionalProperties": {
+							"type": "string",
+							"enum": ["admin", "operator", "viewer"]
+						}
+					},
+					"rest_auth_allow_reads": {
+						"type": "boolean"
+					},
 					"rest_certificate": {
 						"type": "string"
 					},
@@ -69,6 +114,45 @@ const (
 					},
 					"allowed_origins" : {
 						"type": "string"
+					},
+					"allowed_methods" : {
+						"type": "string"
+					},
+					"allowed_headers" : {
+						"type": "string"
+					},
+					"rate_limit" : {
+						"type": "number",
+						"minimum": 0
+					},
+					"rate_burst" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"max_inflight_requests" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"audit_log" : {
+						"type": "string"
+					},
+					"audit_log_max_size" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"audit_log_max_backups" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"max_plugin_upload_size" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"pprof_addr" : {
+						"type": "string"
+					},
+					"plugin_repository" : {
+						"type": "string"
 					}
 				},
 				"additionalProperties": false
@@ -79,17 +163,30 @@ const (
 // GetDefaultConfig gets the default snapteld configuration
 func GetDefaultConfig() *Config {
 	return &Config{
-		Enable:           defaultEnable,
-		Port:             defaultPort,
-		Address:          defaultAddress,
-		HTTPS:            defaultHTTPS,
-		RestCertificate:  defaultRestCertificate,
-		RestKey:          defaultRestKey,
-		RestAuth:         defaultAuth,
-		RestAuthPassword: defaultAuthPassword,
-		portSetByConfig:  defaultPortSetByConfig,
-		Pprof:            defaultPprof,
-		Corsd:            defaultCorsd,
+		Enable:              defaultEnable,
+		Port:                defaultPort,
+		Address:             defaultAddress,
+		HTTPS:               defaultHTTPS,
+		RestCertificate:     defaultRestCertificate,
+		RestKey:             defaultRestKey,
+		RestAuth:            defaultAuth,
+		RestAuthPassword:    defaultAuthPassword,
+		RestAuthTokens:      []string{},
+		RestAuthTokenRoles:  map[string]string{},
+		RestAuthAllowReads:  defaultAuthAllowReads,
+		portSetByConfig:     defaultPortSetByConfig,
+		Pprof:               defaultPprof,
+		Corsd:               defaultCorsd,
+		CorsMethods:         defaultCorsMethods,
+		CorsHeaders:         defaultCorsHeaders,
+		RateLimit:           defaultRateLimit,
+		RateBurst:           defaultRateBurst,
+		MaxInflightRequests: defaultMaxInflight,
+		AuditLog:            defaultAuditLog,
+		AuditLogMaxSize:     defaultAuditLogMaxSize,
+		AuditLogMaxBackups:  defaultAuditLogMaxBackups,
+		MaxPluginUploadSize: defaultMaxPluginUpload,
+		PprofAddr:           defaultPprofAddr,
 	}
 }
 
@@ -98,3 +195,21 @@ func GetDefaultConfig() *Config {
 func (c *Config) PortSetByConfigFile() bool {
 	return c.portSetByConfig
 }
+
+// AuthTokens returns the static API tokens with the role bound to each of
+// them. Tokens listed in rest_auth_tokens are admins unless given another
+// role in rest_auth_token_roles.
+func (c *Config) AuthTokens() (map[string]api.Role, error) {
+	tokens := map[string]api.Role{}
+	for _, t := range c.RestAuthTokens {
+		tokens[t] = api.RoleAdmin
+	}
+	for t, name := range c.RestAuthTokenRoles {
+		role, err := api.ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		tokens[t] = role
+	}
+	return tokens, nil
+}
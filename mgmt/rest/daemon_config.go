@@ -0,0 +1,177 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+)
+
+const (
+	daemonConfigPath = "/config"
+	redacted         = "********"
+)
+
+// ErrDaemonConfigUnavailable is returned when the configuration of snapteld
+// was not given to the server.
+var ErrDaemonConfigUnavailable = errors.New("daemon configuration is not available")
+
+// DaemonSetting applies a new value of a setting of snapteld while it runs.
+type DaemonSetting func(value json.RawMessage) error
+
+// daemonConfig is the configuration of the running snapteld served at
+// /config, with the settings that can be changed without restarting it.
+type daemonConfig struct {
+	sync.Mutex
+	config   interface{}
+	settings map[string]DaemonSetting
+}
+
+// SetDaemonConfig sets the configuration of snapteld served at /config. It is
+// marshalled on each request, so changes made to it by the settings show.
+func (s *Server) SetDaemonConfig(cfg interface{}) {
+	s.daemonConfig.Lock()
+	defer s.daemonConfig.Unlock()
+	s.daemonConfig.config = cfg
+}
+
+// AddDaemonSetting makes a setting, named by its path in the configuration
+// (e.g. 'log_level' or 'control.plugin_trust'), changeable through
+// PATCH /config.
+func (s *Server) AddDaemonSetting(name string, apply DaemonSetting) {
+	s.daemonConfig.Lock()
+	defer s.daemonConfig.Unlock()
+	if s.daemonConfig.settings == nil {
+		s.daemonConfig.settings = map[string]DaemonSetting{}
+	}
+	s.daemonConfig.settings[name] = apply
+}
+
+func (s *Server) addDaemonConfigRoutes() {
+	s.r.GET(daemonConfigPath, s.authorize(api.RoleViewer, s.getDaemonConfig))
+	s.r.PATCH(daemonConfigPath, s.authorize(api.RoleAdmin, s.patchDaemonConfig))
+}
+
+// getDaemonConfig writes the configuration of snapteld, with its secrets
+// redacted, and the settings that can be changed.
+func (s *Server) getDaemonConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.daemonConfig.Lock()
+	defer s.daemonConfig.Unlock()
+	s.writeDaemonConfig(200, w)
+}
+
+// patchDaemonConfig applies the new values given for the settings that can
+// be changed while snapteld runs, e.g. {"log_level": 1}. The request is
+// refused without applying anything when it names another setting.
+func (s *Server) patchDaemonConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	changes := map[string]json.RawMessage{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+
+	s.daemonConfig.Lock()
+	defer s.daemonConfig.Unlock()
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		if _, ok := s.daemonConfig.settings[name]; !ok {
+			rbody.Write(400, rbody.FromError(fmt.Errorf("setting %q can not be changed while snapteld runs", name)), w)
+			return
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.daemonConfig.settings[name](changes[name]); err != nil {
+			rbody.Write(400, rbody.FromError(fmt.Errorf("setting %q: %v", name, err)), w)
+			return
+		}
+		requestLogger(r).WithFields(log.Fields{
+			"_block":  "patch-config",
+			"setting": name,
+			"value":   string(changes[name]),
+		}).Info("Daemon setting changed")
+	}
+	s.writeDaemonConfig(200, w)
+}
+
+// writeDaemonConfig writes the configuration, the caller holding the lock.
+func (s *Server) writeDaemonConfig(code int, w http.ResponseWriter) {
+	if s.daemonConfig.config == nil {
+		rbody.Write(404, rbody.FromError(ErrDaemonConfigUnavailable), w)
+		return
+	}
+	b, err := json.Marshal(s.daemonConfig.config)
+	if err != nil {
+		rbody.Write(500, rbody.FromError(err), w)
+		return
+	}
+	var cfg interface{}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		rbody.Write(500, rbody.FromError(err), w)
+		return
+	}
+	settings := make([]string, 0, len(s.daemonConfig.settings))
+	for name := range s.daemonConfig.settings {
+		settings = append(settings, name)
+	}
+	sort.Strings(settings)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"config":           redactSecrets(cfg),
+		"runtime_settings": settings,
+	})
+}
+
+// redactSecrets hides the values of the passwords and tokens found in the
+// configuration.
+func redactSecrets(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			key := strings.ToLower(k)
+			if strings.Contains(key, "password") || strings.Contains(key, "token") {
+				if e != nil && e != "" {
+					t[k] = redacted
+				}
+				continue
+			}
+			t[k] = redactSecrets(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redactSecrets(e)
+		}
+	}
+	return v
+}
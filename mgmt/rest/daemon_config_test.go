@@ -0,0 +1,113 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+)
+
+type testDaemonConfig struct {
+	LogLevel int               `json:"log_level"`
+	RestAPI  map[string]string `json:"restapi"`
+}
+
+func TestDaemonConfig(t *testing.T) {
+	Convey("Given a server with the daemon configuration", t, func() {
+		s := &Server{}
+		cfg := &testDaemonConfig{
+			LogLevel: 3,
+			RestAPI:  map[string]string{"rest_auth_password": "changeme", "rest_auth_tokens": "secret", "addr": ":8181"},
+		}
+		s.SetDaemonConfig(cfg)
+		s.AddDaemonSetting("log_level", func(v json.RawMessage) error {
+			var level int
+			if err := json.Unmarshal(v, &level); err != nil {
+				return err
+			}
+			if level < 1 || level > 5 {
+				return errors.New("log level was invalid (needs: 1-5)")
+			}
+			cfg.LogLevel = level
+			return nil
+		})
+		type response struct {
+			Config struct {
+				LogLevel int               `json:"log_level"`
+				RestAPI  map[string]string `json:"restapi"`
+			} `json:"config"`
+			Settings []string `json:"runtime_settings"`
+		}
+		get := func() (int, *response) {
+			rw := httptest.NewRecorder()
+			s.getDaemonConfig(negroni.NewResponseWriter(rw), httptest.NewRequest("GET", daemonConfigPath, nil), nil)
+			res := &response{}
+			json.Unmarshal(rw.Body.Bytes(), res)
+			return rw.Code, res
+		}
+		patch := func(body string) (int, *rbody.APIResponse) {
+			rw := httptest.NewRecorder()
+			s.patchDaemonConfig(negroni.NewResponseWriter(rw), httptest.NewRequest("PATCH", daemonConfigPath, strings.NewReader(body)), nil)
+			res := &rbody.APIResponse{}
+			json.Unmarshal(rw.Body.Bytes(), res)
+			return rw.Code, res
+		}
+
+		Convey("it is served with its secrets redacted", func() {
+			code, res := get()
+			So(code, ShouldEqual, 200)
+			So(res.Config.LogLevel, ShouldEqual, 3)
+			So(res.Config.RestAPI["addr"], ShouldEqual, ":8181")
+			So(res.Config.RestAPI["rest_auth_password"], ShouldEqual, redacted)
+			So(res.Config.RestAPI["rest_auth_tokens"], ShouldEqual, redacted)
+			So(res.Settings, ShouldResemble, []string{"log_level"})
+		})
+		Convey("the settings changeable at runtime are applied", func() {
+			code, _ := patch(`{"log_level": 1}`)
+			So(code, ShouldEqual, 200)
+			So(cfg.LogLevel, ShouldEqual, 1)
+			_, res := get()
+			So(res.Config.LogLevel, ShouldEqual, 1)
+		})
+		Convey("invalid values are refused", func() {
+			code, res := patch(`{"log_level": 9}`)
+			So(code, ShouldEqual, 400)
+			So(res.Meta.Type, ShouldEqual, rbody.ErrorType)
+			So(res.Body.(*rbody.Error).Code, ShouldEqual, rbody.ErrCodeInvalidRequest)
+			So(cfg.LogLevel, ShouldEqual, 3)
+		})
+		Convey("other settings are refused without applying anything", func() {
+			code, res := patch(`{"log_level": 1, "restapi": {"addr": ":80"}}`)
+			So(code, ShouldEqual, 400)
+			So(res.Meta.Message, ShouldContainSubstring, "restapi")
+			So(cfg.LogLevel, ShouldEqual, 3)
+		})
+	})
+}
@@ -0,0 +1,127 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+// drainer is implemented by task managers which can stop starting workflow
+// runs and wait for the ones in flight.
+type drainer interface {
+	Drain(timeout time.Duration) error
+	Resume()
+}
+
+type drainStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Shutdown bool   `json:"shutdown,omitempty"`
+}
+
+// SetShutdown sets the function shutting snapteld down once drained, when
+// requested by POST /admin/drain?shutdown=true.
+func (s *Server) SetShutdown(f func()) {
+	s.shutdown = f
+}
+
+func (s *Server) addAdminRoutes() {
+	s.r.POST("/admin/drain", s.authorize(api.RoleAdmin, s.drain))
+	s.r.POST("/admin/resume", s.authorize(api.RoleAdmin, s.resume))
+}
+
+// drain stops the scheduling of new workflow runs and waits, until the
+// timeout given (30s by default), for the runs in flight and their publishes
+// to finish. When asked to, snapteld is then shut down.
+func (s *Server) drain(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	d, ok := s.tasks.(drainer)
+	if !ok {
+		http.Error(w, "Draining unsupported", 501)
+		return
+	}
+	q := r.URL.Query()
+	timeout := defaultDrainTimeout
+	if t := q.Get("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil || timeout < 0 {
+			http.Error(w, "Invalid timeout: "+t, 400)
+			return
+		}
+	}
+	shutdown := false
+	if sd := q.Get("shutdown"); sd != "" {
+		var err error
+		if shutdown, err = strconv.ParseBool(sd); err != nil {
+			http.Error(w, "Invalid shutdown: "+sd, 400)
+			return
+		}
+	}
+	if shutdown && s.shutdown == nil {
+		http.Error(w, "Shutdown unsupported", 501)
+		return
+	}
+
+	logger := requestLogger(r).WithFields(log.Fields{
+		"_block":   "drain",
+		"timeout":  timeout.String(),
+		"shutdown": shutdown,
+	})
+	logger.Info("Draining snapteld")
+	if err := d.Drain(timeout); err != nil {
+		writeDrainStatus(503, &drainStatus{Status: "timeout", Error: err.Error()}, w)
+		return
+	}
+	writeDrainStatus(200, &drainStatus{Status: "drained", Shutdown: shutdown}, w)
+	if shutdown {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		logger.Info("Shutting down snapteld after drain")
+		go s.shutdown()
+	}
+}
+
+// resume starts the workflow runs again after a drain.
+func (s *Server) resume(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	d, ok := s.tasks.(drainer)
+	if !ok {
+		http.Error(w, "Draining unsupported", 501)
+		return
+	}
+	d.Resume()
+	requestLogger(r).WithField("_block", "resume").Info("Resumed snapteld")
+	writeDrainStatus(200, &drainStatus{Status: "running"}, w)
+}
+
+func writeDrainStatus(code int, ds *drainStatus, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ds)
+}
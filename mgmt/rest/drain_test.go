@@ -0,0 +1,103 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+	"github.com/intelsdi-x/snap/scheduler"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type drainTaskManager struct {
+	mock.MockTaskManager
+	drainErr error
+	timeout  time.Duration
+	resumed  bool
+}
+
+func (d *drainTaskManager) Drain(timeout time.Duration) error {
+	d.timeout = timeout
+	return d.drainErr
+}
+
+func (d *drainTaskManager) Resume() {
+	d.resumed = true
+}
+
+func TestDrainEndpoint(t *testing.T) {
+	Convey("Given a server with a task manager that can be drained", t, func() {
+		tm := &drainTaskManager{}
+		s := &Server{}
+		s.BindTaskManager(tm)
+		shutdown := make(chan struct{}, 1)
+		s.SetShutdown(func() { shutdown <- struct{}{} })
+		post := func(path string) (int, *drainStatus) {
+			rw := httptest.NewRecorder()
+			s.drain(rw, httptest.NewRequest("POST", path, nil), nil)
+			ds := &drainStatus{}
+			json.Unmarshal(rw.Body.Bytes(), ds)
+			return rw.Code, ds
+		}
+
+		Convey("it drains the scheduler with the default timeout", func() {
+			code, ds := post("/admin/drain")
+			So(code, ShouldEqual, 200)
+			So(ds.Status, ShouldEqual, "drained")
+			So(tm.timeout, ShouldEqual, defaultDrainTimeout)
+			So(len(shutdown), ShouldEqual, 0)
+		})
+		Convey("it shuts snapteld down once drained when asked to", func() {
+			code, ds := post("/admin/drain?timeout=5s&shutdown=true")
+			So(code, ShouldEqual, 200)
+			So(ds.Shutdown, ShouldBeTrue)
+			So(tm.timeout, ShouldEqual, 5*time.Second)
+			select {
+			case <-shutdown:
+			case <-time.After(time.Second):
+				t.Fatal("snapteld was not shut down")
+			}
+		})
+		Convey("it does not shut snapteld down when the drain times out", func() {
+			tm.drainErr = scheduler.ErrDrainTimeout
+			code, ds := post("/admin/drain?shutdown=true")
+			So(code, ShouldEqual, 503)
+			So(ds.Status, ShouldEqual, "timeout")
+			So(len(shutdown), ShouldEqual, 0)
+		})
+		Convey("it refuses an invalid timeout", func() {
+			code, _ := post("/admin/drain?timeout=soon")
+			So(code, ShouldEqual, 400)
+		})
+		Convey("it resumes the scheduler", func() {
+			rw := httptest.NewRecorder()
+			s.resume(rw, httptest.NewRequest("POST", "/admin/resume", nil), nil)
+			So(rw.Code, ShouldEqual, 200)
+			So(tm.resumed, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,253 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/intelsdi-x/gomit"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/control_event"
+	"github.com/intelsdi-x/snap/core/scheduler_event"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// eventBufferSize is the number of daemon events queued for a single
+// subscriber before further events are dropped for it.
+const eventBufferSize = 64
+
+// daemonEvent is a control or scheduler event as written to the /events stream.
+type daemonEvent struct {
+	Namespace string                 `json:"namespace"`
+	Timestamp int64                  `json:"timestamp"`
+	Body      map[string]interface{} `json:"body"`
+}
+
+// eventBroker fans daemon events out to the clients connected to /events.
+type eventBroker struct {
+	sync.Mutex
+	subscribers map[chan *daemonEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: map[chan *daemonEvent]struct{}{},
+	}
+}
+
+func (b *eventBroker) subscribe() chan *daemonEvent {
+	ch := make(chan *daemonEvent, eventBufferSize)
+	b.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.Unlock()
+	return ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan *daemonEvent) {
+	b.Lock()
+	delete(b.subscribers, ch)
+	b.Unlock()
+}
+
+// publish hands the event to every subscriber without blocking; a subscriber
+// that is not keeping up misses the event rather than stalling the daemon.
+func (b *eventBroker) publish(e *daemonEvent) {
+	b.Lock()
+	defer b.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			restLogger.WithFields(log.Fields{
+				"_block": "publish-event",
+				"event":  e.Namespace,
+			}).Warn("events subscriber is not keeping up; dropping event")
+		}
+	}
+}
+
+// HandleGomitEvent handles events emitted from control and the scheduler,
+// streaming them to the clients of the /events endpoint.
+func (s *Server) HandleGomitEvent(e gomit.Event) {
+	body := eventBody(e.Body)
+	if body == nil {
+		return
+	}
+	s.events.publish(&daemonEvent{
+		Namespace: e.Namespace(),
+		Timestamp: e.Header.Time.Unix(),
+		Body:      body,
+	})
+}
+
+// eventBody returns the fields of the events exposed on the /events stream,
+// or nil for events that are not streamed (i.e. per-run metric events).
+func eventBody(b gomit.EventBody) map[string]interface{} {
+	switch v := b.(type) {
+	case *control_event.LoadPluginEvent:
+		return pluginEventBody(v.Name, v.Version, v.Type)
+	case *control_event.UnloadPluginEvent:
+		return pluginEventBody(v.Name, v.Version, v.Type)
+	case *control_event.SwapPluginsEvent:
+		return map[string]interface{}{
+			"loaded_plugin_name":      v.LoadedPluginName,
+			"loaded_plugin_version":   v.LoadedPluginVersion,
+			"unloaded_plugin_name":    v.UnloadedPluginName,
+			"unloaded_plugin_version": v.UnloadedPluginVersion,
+			"plugin_type":             core.PluginType(v.PluginType).String(),
+		}
+	case *control_event.DeadAvailablePluginEvent:
+		body := pluginEventBody(v.Name, v.Version, v.Type)
+		body["output"] = v.Output
+		return body
+	case *control_event.RestartedAvailablePluginEvent:
+		return pluginEventBody(v.Name, v.Version, v.Type)
+	case *control_event.MaxPluginRestartsExceededEvent:
+		return pluginEventBody(v.Name, v.Version, v.Type)
+	case *control_event.PluginResourceLimitExceededEvent:
+		body := pluginEventBody(v.Name, v.Version, v.Type)
+		body["resource"] = v.Resource
+		return body
+	case *control_event.HealthCheckFailedEvent:
+		body := pluginEventBody(v.Name, v.Version, v.Type)
+		body["failures"] = v.Failures
+		return body
+	case *scheduler_event.TaskCreatedEvent:
+		return map[string]interface{}{"task_id": v.TaskID}
+	case *scheduler_event.TaskDeletedEvent:
+		return map[string]interface{}{"task_id": v.TaskID}
+	case *scheduler_event.TaskStartedEvent:
+		return map[string]interface{}{"task_id": v.TaskID}
+	case *scheduler_event.TaskStoppedEvent:
+		return map[string]interface{}{"task_id": v.TaskID}
+	case *scheduler_event.TaskEndedEvent:
+		return map[string]interface{}{"task_id": v.TaskID}
+	case *scheduler_event.TaskDisabledEvent:
+		return map[string]interface{}{"task_id": v.TaskID, "why": v.Why}
+	case *scheduler_event.MetricCollectionFailedEvent:
+		errs := make([]string, len(v.Errors))
+		for i, err := range v.Errors {
+			errs[i] = err.Error()
+		}
+		return map[string]interface{}{"task_id": v.TaskID, "errors": errs}
+	}
+	return nil
+}
+
+func pluginEventBody(name string, version, typ int) map[string]interface{} {
+	return map[string]interface{}{
+		"plugin_name":    name,
+		"plugin_version": version,
+		"plugin_type":    core.PluginType(typ).String(),
+	}
+}
+
+func (s *Server) addEventRoutes() {
+	s.r.GET("/events", s.authorize(api.RoleViewer, s.streamEvents))
+	s.r.GET("/plugins/watch", s.authorize(api.RoleViewer, s.watchPlugins))
+}
+
+// streamEvents writes daemon events to the client as Server-Sent Events. The
+// optional 'namespace' query parameter is a comma separated list of namespace
+// prefixes (i.e. 'Control.,Scheduler.TaskDisabled') limiting the events sent.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var prefixes []string
+	if ns := r.URL.Query().Get("namespace"); ns != "" {
+		prefixes = strings.Split(ns, ",")
+	}
+	s.serveEventStream(w, r, "stream-events", func(e *daemonEvent) string {
+		if !matchesNamespace(e.Namespace, prefixes) {
+			return ""
+		}
+		return e.Namespace
+	})
+}
+
+// serveEventStream writes the daemon events given a name by eventName to the
+// client as Server-Sent Events of that name, skipping the events it gives an
+// empty name.
+func (s *Server) serveEventStream(w http.ResponseWriter, r *http.Request, block string, eventName func(*daemonEvent) string) {
+	s.wg.Add(1)
+	defer s.wg.Done()
+	logger := requestLogger(r).WithFields(log.Fields{
+		"_block": block,
+		"client": r.RemoteAddr,
+	})
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	// Make this Server Sent Events compatible
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	logger.Debug("client subscribed to events")
+	n := w.(http.CloseNotifier).CloseNotify()
+	for {
+		select {
+		case e := <-ch:
+			name := eventName(e)
+			if name == "" {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				logger.WithField("event", e.Namespace).Error(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+			flusher.Flush()
+		case <-n:
+			logger.Debug("client disconnecting")
+			return
+		case <-s.killChan:
+			logger.Debug("snapteld exiting; disconnecting client")
+			return
+		}
+	}
+}
+
+func matchesNamespace(ns string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(ns, strings.TrimSpace(p)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// +build medium
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/gomit"
+	"github.com/intelsdi-x/snap/core/control_event"
+	"github.com/intelsdi-x/snap/core/scheduler_event"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventStream(t *testing.T) {
+	r := startV2API(getDefaultMockConfig(), "task")
+	Convey("Test the /events stream", t, func() {
+		readEvent := func(rd *bufio.Reader) (string, *daemonEvent) {
+			var name string
+			for {
+				line, err := rd.ReadString('\n')
+				So(err, ShouldBeNil)
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "event: ") {
+					name = strings.TrimPrefix(line, "event: ")
+				}
+				if strings.HasPrefix(line, "data: ") {
+					e := &daemonEvent{}
+					So(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), e), ShouldBeNil)
+					return name, e
+				}
+			}
+		}
+		subscribers := func() int {
+			r.server.events.Lock()
+			defer r.server.events.Unlock()
+			return len(r.server.events.subscribers)
+		}
+		// subscribe opens the stream and waits for the server to register it
+		subscribe := func(path string) *http.Response {
+			n := subscribers()
+			resp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", r.port, path))
+			So(err, ShouldBeNil)
+			for i := 0; i < 100 && subscribers() <= n; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return resp
+		}
+		Convey("streams plugin and task events", func() {
+			resp := subscribe("/events")
+			defer resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, 200)
+			So(resp.Header.Get("Content-Type"), ShouldEqual, "text/event-stream")
+
+			now := time.Now()
+			r.server.HandleGomitEvent(gomit.Event{
+				Header: gomit.EventHeader{Time: now},
+				Body:   &control_event.LoadPluginEvent{Name: "mock", Version: 1, Type: 0},
+			})
+			r.server.HandleGomitEvent(gomit.Event{
+				Header: gomit.EventHeader{Time: now},
+				Body:   &scheduler_event.MetricCollectedEvent{TaskID: "1234"},
+			})
+			r.server.HandleGomitEvent(gomit.Event{
+				Header: gomit.EventHeader{Time: now},
+				Body:   &scheduler_event.TaskDisabledEvent{TaskID: "1234", Why: "too many failures"},
+			})
+
+			rd := bufio.NewReader(resp.Body)
+			name, e := readEvent(rd)
+			So(name, ShouldEqual, control_event.PluginLoaded)
+			So(e.Namespace, ShouldEqual, control_event.PluginLoaded)
+			So(e.Timestamp, ShouldEqual, now.Unix())
+			So(e.Body["plugin_name"], ShouldEqual, "mock")
+			So(e.Body["plugin_type"], ShouldEqual, "collector")
+
+			// metric collected events are not streamed
+			name, e = readEvent(rd)
+			So(name, ShouldEqual, scheduler_event.TaskDisabled)
+			So(e.Body["task_id"], ShouldEqual, "1234")
+			So(e.Body["why"], ShouldEqual, "too many failures")
+		})
+		Convey("filters events by namespace", func() {
+			resp := subscribe("/events?namespace=Scheduler.")
+			defer resp.Body.Close()
+
+			r.server.HandleGomitEvent(gomit.Event{
+				Body: &control_event.UnloadPluginEvent{Name: "mock", Version: 1, Type: 0},
+			})
+			r.server.HandleGomitEvent(gomit.Event{
+				Body: &scheduler_event.TaskCreatedEvent{TaskID: "5678"},
+			})
+
+			name, e := readEvent(bufio.NewReader(resp.Body))
+			So(name, ShouldEqual, scheduler_event.TaskCreated)
+			So(e.Body["task_id"], ShouldEqual, "5678")
+		})
+		Convey("streams plugin lifecycle events on /plugins/watch", func() {
+			resp := subscribe("/plugins/watch")
+			defer resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, 200)
+
+			r.server.HandleGomitEvent(gomit.Event{
+				Body: &scheduler_event.TaskCreatedEvent{TaskID: "5678"},
+			})
+			r.server.HandleGomitEvent(gomit.Event{
+				Body: &control_event.DeadAvailablePluginEvent{Name: "mock", Version: 2, Type: 0, Output: "panic: oops\n"},
+			})
+			r.server.HandleGomitEvent(gomit.Event{
+				Body: &control_event.RestartedAvailablePluginEvent{Name: "mock", Version: 2, Type: 0},
+			})
+
+			rd := bufio.NewReader(resp.Body)
+			// task events are not plugin lifecycle events
+			name, e := readEvent(rd)
+			So(name, ShouldEqual, "crashed")
+			So(e.Namespace, ShouldEqual, control_event.AvailablePluginDead)
+			So(e.Body["plugin_name"], ShouldEqual, "mock")
+			So(e.Body["output"], ShouldEqual, "panic: oops\n")
+			name, e = readEvent(rd)
+			So(name, ShouldEqual, "restarted")
+			So(e.Body["plugin_version"], ShouldEqual, float64(2))
+		})
+	})
+}
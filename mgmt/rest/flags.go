@@ -60,11 +60,23 @@ var (
 		Name:  "pprof",
 		Usage: "Enables profiling tools",
 	}
+	flPProfAddr = cli.StringFlag{
+		Name:  "pprof-addr",
+		Usage: "Serves the profiling tools on their own address (e.g. 127.0.0.1:6060), without authentication, rather than on the REST API",
+	}
 	flCorsd = cli.StringFlag{
 		Name:  "allowed_origins",
 		Usage: "Define Cors allowed origins",
 	}
+	flCorsMethods = cli.StringFlag{
+		Name:  "allowed_methods",
+		Usage: fmt.Sprintf("Define Cors allowed methods (default: %v)", defaultCorsMethods),
+	}
+	flCorsHeaders = cli.StringFlag{
+		Name:  "allowed_headers",
+		Usage: fmt.Sprintf("Define Cors allowed headers (default: %v)", defaultCorsHeaders),
+	}
 
 	// Flags consumed by snapteld
-	Flags = []cli.Flag{flAPIDisabled, flAPIAddr, flAPIPort, flRestHTTPS, flRestCert, flRestKey, flRestAuth, flPProf, flCorsd}
+	Flags = []cli.Flag{flAPIDisabled, flAPIAddr, flAPIPort, flRestHTTPS, flRestCert, flRestKey, flRestAuth, flPProf, flPProfAddr, flCorsd, flCorsMethods, flCorsHeaders}
 )
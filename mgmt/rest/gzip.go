@@ -0,0 +1,123 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/urfave/negroni"
+)
+
+// gzipMiddleware compresses the responses sent to clients accepting gzip.
+type gzipMiddleware struct{}
+
+func (g gzipMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.Method == "HEAD" || !acceptsGzip(r) {
+		next(rw, r)
+		return
+	}
+	nrw, ok := rw.(negroni.ResponseWriter)
+	if !ok {
+		nrw = negroni.NewResponseWriter(rw)
+	}
+	grw := &gzipResponseWriter{ResponseWriter: nrw}
+	defer grw.close()
+	next(grw, r)
+}
+
+// acceptsGzip reports whether gzip is one of the encodings listed in the
+// Accept-Encoding header of the request and not refused with q=0.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.Replace(p, " ", "", -1); q == "q=0" || q == "q=0.0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter compresses what is written to the response unless the
+// handler already set its encoding or answers with a byte range, whose
+// offsets are those of the uncompressed content. It remains a negroni.ResponseWriter, and
+// flushing it sends what has been compressed so far so that event streams
+// keep working.
+type gzipResponseWriter struct {
+	negroni.ResponseWriter
+	gz          *gzip.Writer
+	passThrough bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.gz == nil && !g.passThrough {
+		h := g.Header()
+		if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" ||
+			code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusPartialContent {
+			g.passThrough = true
+		} else {
+			h.Set("Content-Encoding", "gzip")
+			h.Add("Vary", "Accept-Encoding")
+			h.Del("Content-Length")
+			g.gz = gzip.NewWriter(g.ResponseWriter)
+		}
+	}
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.Written() {
+		if g.Header().Get("Content-Type") == "" {
+			// sniff the content type of the uncompressed content
+			g.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.gz == nil {
+		return g.ResponseWriter.Write(b)
+	}
+	return g.gz.Write(b)
+}
+
+func (g *gzipResponseWriter) Flush() {
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	g.ResponseWriter.Flush()
+}
+
+// CloseNotify is not part of negroni.ResponseWriter but is used by the
+// streaming handlers.
+func (g *gzipResponseWriter) CloseNotify() <-chan bool {
+	return g.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+func (g *gzipResponseWriter) close() {
+	if g.gz != nil {
+		g.gz.Close()
+	}
+}
@@ -0,0 +1,105 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	Convey("Given a handler behind the gzip middleware", t, func() {
+		body := bytes.Repeat([]byte(`{"name":"mock"}`), 100)
+		n := negroni.New(gzipMiddleware{})
+		n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// handlers assert the writer they are given
+			_ = w.(negroni.ResponseWriter)
+			if r.URL.Path == "/encoded" {
+				w.Header().Set("Content-Encoding", "identity")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/range" {
+				w.Header().Set("Content-Range", "bytes 0-9/1500")
+				w.WriteHeader(206)
+				w.Write(body[:10])
+				return
+			}
+			w.WriteHeader(200)
+			w.Write(body)
+		}))
+		serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("GET", path, nil)
+			if acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+			rw := httptest.NewRecorder()
+			n.ServeHTTP(rw, r)
+			return rw
+		}
+		Convey("responses are compressed for clients accepting gzip", func() {
+			rw := serve("/plugins", "deflate, gzip")
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Header().Get("Content-Encoding"), ShouldEqual, "gzip")
+			So(rw.Header().Get("Vary"), ShouldEqual, "Accept-Encoding")
+			So(rw.Body.Len(), ShouldBeLessThan, len(body))
+			gz, err := gzip.NewReader(rw.Body)
+			So(err, ShouldBeNil)
+			b, err := ioutil.ReadAll(gz)
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, body)
+		})
+		Convey("responses are not compressed otherwise", func() {
+			for _, ae := range []string{"", "deflate", "gzip;q=0"} {
+				rw := serve("/plugins", ae)
+				So(rw.Header().Get("Content-Encoding"), ShouldEqual, "")
+				So(rw.Body.Bytes(), ShouldResemble, body)
+			}
+		})
+		Convey("responses with an encoding set are left alone", func() {
+			rw := serve("/encoded", "gzip")
+			So(rw.Header().Get("Content-Encoding"), ShouldEqual, "identity")
+			So(rw.Body.Bytes(), ShouldResemble, body)
+		})
+		Convey("partial content is not compressed", func() {
+			rw := serve("/range", "gzip")
+			So(rw.Code, ShouldEqual, 206)
+			So(rw.Header().Get("Content-Encoding"), ShouldEqual, "")
+			So(rw.Body.Bytes(), ShouldResemble, body[:10])
+		})
+		Convey("plain response writers are accepted", func() {
+			r := httptest.NewRequest("GET", "/plugins", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			rw := httptest.NewRecorder()
+			gzipMiddleware{}.ServeHTTP(rw, r, func(w http.ResponseWriter, r *http.Request) {
+				w.Write(body)
+			})
+			So(rw.Header().Get("Content-Encoding"), ShouldEqual, "gzip")
+		})
+	})
+}
@@ -0,0 +1,101 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// readinessCheck is a component of snapteld checked by /readyz.
+type readinessCheck struct {
+	name  string
+	check func() error
+}
+
+// componentStatus is the status of a component reported by /readyz.
+type componentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthStatus struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components,omitempty"`
+}
+
+// AddReadinessCheck adds a component to the ones that have to be ready, i.e.
+// check returns no error, for /readyz to report snapteld ready.
+func (s *Server) AddReadinessCheck(name string, check func() error) {
+	s.readyChecks = append(s.readyChecks, readinessCheck{name: name, check: check})
+}
+
+func (s *Server) addHealthRoutes() {
+	s.r.GET(healthzPath, s.healthz)
+	s.r.GET(readyzPath, s.readyz)
+}
+
+// isHealthCheck reports whether the request is made to the health endpoints,
+// which are served without authentication for load balancers and
+// orchestrators.
+func isHealthCheck(r *http.Request) bool {
+	return r.Method == "GET" && (r.URL.Path == healthzPath || r.URL.Path == readyzPath)
+}
+
+// healthz reports that the process is alive and serving requests.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writeHealth(200, &healthStatus{Status: "ok"}, w)
+}
+
+// readyz reports whether all the components of snapteld are ready, with the
+// status of each of them.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	code := 200
+	hs := &healthStatus{Status: "ready", Components: map[string]componentStatus{}}
+	for _, rc := range s.readyChecks {
+		if err := rc.check(); err != nil {
+			code = 503
+			hs.Status = "not ready"
+			hs.Components[rc.name] = componentStatus{Status: "not ready", Error: err.Error()}
+			requestLogger(r).WithFields(log.Fields{
+				"_block":    "readyz",
+				"component": rc.name,
+			}).Warn(err)
+			continue
+		}
+		hs.Components[rc.name] = componentStatus{Status: "ready"}
+	}
+	writeHealth(code, hs, w)
+}
+
+func writeHealth(code int, hs *healthStatus, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(hs)
+}
@@ -0,0 +1,85 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	Convey("Given a server with readiness checks", t, func() {
+		s := &Server{}
+		var schedulerErr error
+		s.AddReadinessCheck("control", func() error { return nil })
+		s.AddReadinessCheck("scheduler", func() error { return schedulerErr })
+		get := func(h func(http.ResponseWriter, *http.Request), path string) (int, *healthStatus) {
+			rw := httptest.NewRecorder()
+			h(rw, httptest.NewRequest("GET", path, nil))
+			hs := &healthStatus{}
+			So(json.Unmarshal(rw.Body.Bytes(), hs), ShouldBeNil)
+			return rw.Code, hs
+		}
+		healthz := func(w http.ResponseWriter, r *http.Request) { s.healthz(w, r, nil) }
+		readyz := func(w http.ResponseWriter, r *http.Request) { s.readyz(w, r, nil) }
+
+		Convey("/healthz reports the process alive", func() {
+			code, hs := get(healthz, "/healthz")
+			So(code, ShouldEqual, 200)
+			So(hs.Status, ShouldEqual, "ok")
+		})
+		Convey("/readyz reports ready when all components are", func() {
+			code, hs := get(readyz, "/readyz")
+			So(code, ShouldEqual, 200)
+			So(hs.Status, ShouldEqual, "ready")
+			So(hs.Components["control"].Status, ShouldEqual, "ready")
+			So(hs.Components["scheduler"].Status, ShouldEqual, "ready")
+		})
+		Convey("/readyz reports the components not ready", func() {
+			schedulerErr = errors.New("Scheduler is not started.")
+			code, hs := get(readyz, "/readyz")
+			So(code, ShouldEqual, 503)
+			So(hs.Status, ShouldEqual, "not ready")
+			So(hs.Components["control"].Status, ShouldEqual, "ready")
+			So(hs.Components["scheduler"], ShouldResemble, componentStatus{Status: "not ready", Error: "Scheduler is not started."})
+		})
+		Convey("health checks do not need credentials", func() {
+			s.SetAPIAuth(true)
+			s.SetAPIAuthPwd("changeme")
+			serve := func(path string) int {
+				rw := httptest.NewRecorder()
+				s.authMiddleware(rw, httptest.NewRequest("GET", path, nil), func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(200)
+				})
+				return rw.Code
+			}
+			So(serve("/healthz"), ShouldEqual, 200)
+			So(serve("/readyz"), ShouldEqual, 200)
+			So(serve("/v1/plugins"), ShouldEqual, 401)
+		})
+	})
+}
@@ -21,15 +21,32 @@ package rest
 
 import (
 	"net/http"
+	"regexp"
+	"strconv"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/pborman/uuid"
 	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/pkg/opmetrics"
 )
 
-// Logger is a snap middleware that logs to a logrus facility
-type Logger struct {
-	counter uint
-}
+// requestIDRegex matches the request IDs accepted from clients, or proxies in
+// front of snapteld, in place of generating one.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// restRequestDuration is the time taken to serve REST requests.
+var restRequestDuration = opmetrics.NewSummary(
+	"snap_rest_request_duration_seconds",
+	"Duration of the REST API requests.",
+	"method", "code",
+)
+
+// Logger is a snap middleware that logs to a logrus facility. It assigns
+// an ID to every request and writes an access log entry once it is served.
+type Logger struct{}
 
 // NewLogger returns a new Logger instance
 func NewLogger() *Logger {
@@ -37,19 +54,36 @@ func NewLogger() *Logger {
 }
 
 func (l *Logger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	l.counter++
+	start := time.Now()
+	id := r.Header.Get(api.RequestIDHeader)
+	if !requestIDRegex.MatchString(id) {
+		id = uuid.New()
+	}
+	// handlers find the ID on the request, error bodies on the response
+	r.Header.Set(api.RequestIDHeader, id)
+	rw.Header().Set(api.RequestIDHeader, id)
 	restLogger.WithFields(log.Fields{
-		"index":  l.counter,
-		"method": r.Method,
-		"url":    r.URL.Path,
+		"request-id": id,
+		"method":     r.Method,
+		"url":        r.URL.Path,
 	}).Debug("API request")
 	next(rw, r)
 	res := rw.(negroni.ResponseWriter)
+	restRequestDuration.Since(start, r.Method, strconv.Itoa(res.Status()))
 	restLogger.WithFields(log.Fields{
-		"index":       l.counter,
+		"_block":      "access",
+		"request-id":  id,
 		"method":      r.Method,
 		"url":         r.URL.Path,
 		"status-code": res.Status(),
 		"status":      http.StatusText(res.Status()),
-	}).Debug("API response")
+		"size":        res.Size(),
+		"latency":     time.Since(start).String(),
+		"client":      r.RemoteAddr,
+	}).Info("API access")
+}
+
+// requestLogger returns the REST logger with the ID of the request.
+func requestLogger(r *http.Request) *log.Entry {
+	return restLogger.WithField("request-id", r.Header.Get(api.RequestIDHeader))
 }
@@ -0,0 +1,79 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestRequestID(t *testing.T) {
+	Convey("Given a handler failing behind the logger", t, func() {
+		var seen string
+		n := negroni.New(NewLogger())
+		n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = r.Header.Get(api.RequestIDHeader)
+			rbody.Write(500, rbody.FromError(errors.New("plugin not found")), w)
+		}))
+		serve := func(id string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest("GET", "/v1/plugins", nil)
+			if id != "" {
+				r.Header.Set(api.RequestIDHeader, id)
+			}
+			rw := httptest.NewRecorder()
+			n.ServeHTTP(rw, r)
+			return rw
+		}
+		errorBody := func(rw *httptest.ResponseRecorder) map[string]interface{} {
+			resp := map[string]map[string]interface{}{}
+			So(json.Unmarshal(rw.Body.Bytes(), &resp), ShouldBeNil)
+			return resp["body"]
+		}
+		Convey("an ID is assigned to requests and sent in error bodies", func() {
+			rw := serve("")
+			id := rw.Header().Get(api.RequestIDHeader)
+			So(id, ShouldNotBeEmpty)
+			So(seen, ShouldEqual, id)
+			So(errorBody(rw)["request_id"], ShouldEqual, id)
+			So(serve("").Header().Get(api.RequestIDHeader), ShouldNotEqual, id)
+		})
+		Convey("the ID given by the client is kept", func() {
+			rw := serve("proxy-1234.5")
+			So(rw.Header().Get(api.RequestIDHeader), ShouldEqual, "proxy-1234.5")
+			So(seen, ShouldEqual, "proxy-1234.5")
+			So(errorBody(rw)["request_id"], ShouldEqual, "proxy-1234.5")
+		})
+		Convey("invalid IDs given by the client are replaced", func() {
+			rw := serve("bad id\n")
+			So(rw.Header().Get(api.RequestIDHeader), ShouldNotEqual, "bad id\n")
+			So(seen, ShouldEqual, rw.Header().Get(api.RequestIDHeader))
+		})
+	})
+}
@@ -0,0 +1,81 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/pkg/opmetrics"
+)
+
+const metricsPath = "/metrics"
+
+// workQueueLengther is implemented by task managers that report the number
+// of jobs waiting in their work queues.
+type workQueueLengther interface {
+	WorkQueueLengths() map[string]int
+}
+
+func (s *Server) addMetricsRoutes() {
+	s.r.GET(metricsPath, s.authorize(api.RoleViewer, s.getMetrics))
+}
+
+// getMetrics writes the operational metrics of snapteld in the Prometheus
+// text exposition format.
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(200)
+	opmetrics.Default.Collect(w)
+	if s.tasks == nil {
+		return
+	}
+	s.writeTaskMetrics(w)
+	if wq, ok := s.tasks.(workQueueLengther); ok {
+		var samples []opmetrics.Sample
+		for q, n := range wq.WorkQueueLengths() {
+			samples = append(samples, opmetrics.Sample{
+				Labels: []opmetrics.Label{{Name: "queue", Value: q}},
+				Value:  float64(n),
+			})
+		}
+		opmetrics.Write(w, "snap_work_queue_length", "Number of jobs waiting in the scheduler work queues.", "gauge", samples)
+	}
+}
+
+// writeTaskMetrics writes the firing counters of the tasks.
+func (s *Server) writeTaskMetrics(w http.ResponseWriter) {
+	var hits, misses, failures []opmetrics.Sample
+	for _, t := range s.tasks.GetTasks() {
+		labels := []opmetrics.Label{
+			{Name: "task_id", Value: t.ID()},
+			{Name: "task_name", Value: t.GetName()},
+		}
+		hits = append(hits, opmetrics.Sample{Labels: labels, Value: float64(t.HitCount())})
+		misses = append(misses, opmetrics.Sample{Labels: labels, Value: float64(t.MissedCount())})
+		failures = append(failures, opmetrics.Sample{Labels: labels, Value: float64(t.FailedCount())})
+	}
+	opmetrics.Write(w, "snap_task_hits_total", "Number of times the tasks fired.", "counter", hits)
+	opmetrics.Write(w, "snap_task_misses_total", "Number of times the tasks missed firing.", "counter", misses)
+	opmetrics.Write(w, "snap_task_failures_total", "Number of times the tasks failed.", "counter", failures)
+}
@@ -0,0 +1,66 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type queueTaskManager struct {
+	mock.MockTaskManager
+}
+
+func (q *queueTaskManager) WorkQueueLengths() map[string]int {
+	return map[string]int{"collect": 3, "process": 0, "publish": 1}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	Convey("Given a server with a task manager", t, func() {
+		s := &Server{}
+		s.BindTaskManager(&queueTaskManager{})
+		rw := httptest.NewRecorder()
+		s.getMetrics(rw, httptest.NewRequest("GET", metricsPath, nil), nil)
+		body := rw.Body.String()
+		Convey("it writes the metrics in the text exposition format", func() {
+			So(rw.Code, ShouldEqual, 200)
+			So(rw.Header().Get("Content-Type"), ShouldStartWith, "text/plain; version=0.0.4")
+		})
+		Convey("it writes the task counters", func() {
+			So(body, ShouldContainSubstring, "# TYPE snap_task_hits_total counter")
+			So(body, ShouldContainSubstring, `snap_task_hits_total{task_id="qwertyuiop",task_name="TASK1.0"} 0`)
+			So(body, ShouldContainSubstring, "# TYPE snap_task_misses_total counter")
+			So(body, ShouldContainSubstring, "# TYPE snap_task_failures_total counter")
+		})
+		Convey("it writes the work queue lengths", func() {
+			So(body, ShouldContainSubstring, `snap_work_queue_length{queue="collect"} 3`)
+			So(body, ShouldContainSubstring, `snap_work_queue_length{queue="publish"} 1`)
+		})
+		Convey("it writes the REST request durations", func() {
+			So(body, ShouldContainSubstring, "# TYPE snap_rest_request_duration_seconds summary")
+		})
+	})
+}
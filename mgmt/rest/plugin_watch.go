@@ -0,0 +1,46 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core/control_event"
+)
+
+// pluginLifecycleEvents names the plugin lifecycle events streamed by
+// /plugins/watch after the control events they come from.
+var pluginLifecycleEvents = map[string]string{
+	control_event.PluginLoaded:             "loaded",
+	control_event.PluginUnloaded:           "unloaded",
+	control_event.AvailablePluginDead:      "crashed",
+	control_event.AvailablePluginRestarted: "restarted",
+	control_event.PluginsSwapped:           "swapped",
+}
+
+// watchPlugins streams the plugin lifecycle events as Server-Sent Events so
+// clients keep their view of the plugin catalog in sync without polling it.
+func (s *Server) watchPlugins(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.serveEventStream(w, r, "watch-plugins", func(e *daemonEvent) string {
+		return pluginLifecycleEvents[e.Namespace]
+	})
+}
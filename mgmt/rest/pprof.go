@@ -1,24 +1,77 @@
 package rest
 
 import (
+	"expvar"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"runtime"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
 )
 
+func init() {
+	// memstats and cmdline are published by expvar itself
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+}
+
 func (s *Server) addPprofRoutes() {
-	if s.pprof {
-		s.r.GET("/debug/pprof/", s.index)
-		s.r.GET("/debug/pprof/block", s.index)
-		s.r.GET("/debug/pprof/goroutine", s.index)
-		s.r.GET("/debug/pprof/heap", s.index)
-		s.r.GET("/debug/pprof/threadcreate", s.index)
-		s.r.GET("/debug/pprof/cmdline", s.cmdline)
-		s.r.GET("/debug/pprof/profile", s.profile)
-		s.r.GET("/debug/pprof/symbol", s.symbol)
-		s.r.GET("/debug/pprof/trace", s.trace)
+	// the profiling tools are served on their own listener when given an
+	// address, see runPprof
+	if s.pprof && s.pprofAddr == "" {
+		s.addDebugRoutes(s.r, func(h httprouter.Handle) httprouter.Handle {
+			return s.authorize(api.RoleAdmin, h)
+		})
+	}
+}
+
+// addDebugRoutes adds the profiling tools and runtime diagnostics routes to
+// a router, their handlers wrapped by wrap
+func (s *Server) addDebugRoutes(r *httprouter.Router, wrap func(httprouter.Handle) httprouter.Handle) {
+	r.GET("/debug/pprof/", wrap(s.index))
+	r.GET("/debug/pprof/block", wrap(s.index))
+	r.GET("/debug/pprof/goroutine", wrap(s.index))
+	r.GET("/debug/pprof/heap", wrap(s.index))
+	r.GET("/debug/pprof/threadcreate", wrap(s.index))
+	r.GET("/debug/pprof/cmdline", wrap(s.cmdline))
+	r.GET("/debug/pprof/profile", wrap(s.profile))
+	r.GET("/debug/pprof/symbol", wrap(s.symbol))
+	r.GET("/debug/pprof/trace", wrap(s.trace))
+	r.GET("/debug/vars", wrap(s.vars))
+}
+
+// runPprof serves the profiling tools, without authentication, on the
+// listener of the pprof address
+func (s *Server) runPprof() error {
+	ln, err := net.Listen("tcp", s.pprofAddr)
+	if err != nil {
+		return err
 	}
+	s.pprofListener = ln
+	r := httprouter.New()
+	s.addDebugRoutes(r, func(h httprouter.Handle) httprouter.Handle { return h })
+	restLogger.Info("Starting profiling tools on ", ln.Addr())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := http.Serve(ln, r); err != nil {
+			select {
+			case <-s.killChan:
+			// the listener was closed by Stop()
+			default:
+				restLogger.WithFields(log.Fields{
+					"_block": "run-pprof",
+				}).Error(err)
+			}
+		}
+	}()
+	return nil
 }
 
 // profiling tools handlers
@@ -42,3 +95,18 @@ func (s *Server) symbol(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 func (s *Server) trace(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	pprof.Trace(w, r)
 }
+
+// vars writes the variables published with expvar as a JSON object
+func (s *Server) vars(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !first {
+			fmt.Fprint(w, ",\n")
+		}
+		first = false
+		fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprint(w, "\n}\n")
+}
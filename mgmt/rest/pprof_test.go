@@ -0,0 +1,65 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPprofRoutes(t *testing.T) {
+	Convey("Given the runtime diagnostics route", t, func() {
+		s := &Server{}
+		rec := httptest.NewRecorder()
+		s.vars(rec, httptest.NewRequest("GET", "/debug/vars", nil), nil)
+
+		Convey("the published variables are returned as JSON", func() {
+			So(rec.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+			vars := map[string]interface{}{}
+			So(json.Unmarshal(rec.Body.Bytes(), &vars), ShouldBeNil)
+			So(vars, ShouldContainKey, "memstats")
+			So(vars, ShouldContainKey, "cmdline")
+			So(vars["goroutines"], ShouldBeGreaterThan, 0)
+		})
+	})
+	Convey("Given a pprof address", t, func() {
+		s := &Server{pprof: true, pprofAddr: "127.0.0.1:0", killChan: make(chan struct{})}
+		So(s.runPprof(), ShouldBeNil)
+		defer func() {
+			close(s.killChan)
+			s.pprofListener.Close()
+			s.wg.Wait()
+		}()
+
+		Convey("the profiling tools are served on their own listener", func() {
+			resp, err := http.Get(fmt.Sprintf("http://%s/debug/vars", s.pprofListener.Addr()))
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, 200)
+		})
+	})
+}
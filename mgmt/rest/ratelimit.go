@@ -0,0 +1,155 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// idleClientSweep is how often the state of clients that are done with
+// their requests and have a full bucket again is discarded.
+const idleClientSweep = time.Minute
+
+// clientLimit is the token bucket and the number of mutating requests in
+// flight of a client.
+type clientLimit struct {
+	tokens   float64
+	last     time.Time
+	inflight int
+}
+
+// rateLimiter is a middleware limiting the rate of requests of each client,
+// identified by its address, with a token bucket refilled at rate tokens per
+// second holding up to burst tokens, and capping the mutating requests (i.e.
+// plugin loads and task creations) each client has in flight. Requests over
+// the limits are answered with 429 and a Retry-After header.
+type rateLimiter struct {
+	sync.Mutex
+	rate        float64
+	burst       float64
+	maxInflight int
+	clients     map[string]*clientLimit
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+func newRateLimiter(rate float64, burst, maxInflight int) *rateLimiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &rateLimiter{
+		rate:        rate,
+		burst:       float64(burst),
+		maxInflight: maxInflight,
+		clients:     map[string]*clientLimit{},
+		now:         time.Now,
+	}
+}
+
+func (l *rateLimiter) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	client := clientAddr(r)
+	mutating := !isReadOnly(r)
+	if wait, ok := l.acquire(client, mutating); !ok {
+		requestLogger(r).WithFields(log.Fields{
+			"_block": "rate-limit",
+			"client": client,
+			"method": r.Method,
+			"url":    r.URL.Path,
+		}).Warn("request rejected by rate limits")
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(rw, "Too Many Requests", 429)
+		return
+	}
+	if mutating {
+		defer l.release(client)
+	}
+	next(rw, r)
+}
+
+// acquire takes a token from the client's bucket and, for mutating requests,
+// a slot among the ones it can have in flight. When the request can not be
+// served it returns how long the client should wait before retrying.
+func (l *rateLimiter) acquire(client string, mutating bool) (time.Duration, bool) {
+	l.Lock()
+	defer l.Unlock()
+	now := l.now()
+	l.sweep(now)
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimit{tokens: l.burst, last: now}
+		l.clients[client] = c
+	}
+	if l.rate > 0 {
+		c.tokens = math.Min(l.burst, c.tokens+now.Sub(c.last).Seconds()*l.rate)
+		c.last = now
+		if c.tokens < 1 {
+			return time.Duration((1 - c.tokens) / l.rate * float64(time.Second)), false
+		}
+	}
+	if mutating && l.maxInflight > 0 && c.inflight >= l.maxInflight {
+		return time.Second, false
+	}
+	if l.rate > 0 {
+		c.tokens--
+	}
+	if mutating {
+		c.inflight++
+	}
+	return 0, true
+}
+
+func (l *rateLimiter) release(client string) {
+	l.Lock()
+	defer l.Unlock()
+	if c, ok := l.clients[client]; ok && c.inflight > 0 {
+		c.inflight--
+	}
+}
+
+// sweep discards the clients that would be back to their initial state.
+// The caller holds the lock.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleClientSweep {
+		return
+	}
+	l.lastSweep = now
+	for client, c := range l.clients {
+		refilled := l.rate <= 0 || c.tokens+now.Sub(c.last).Seconds()*l.rate >= l.burst
+		if c.inflight == 0 && refilled {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// clientAddr returns the address of the host a request comes from.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
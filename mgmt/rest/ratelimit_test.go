@@ -0,0 +1,89 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	Convey("Given a rate limiter", t, func() {
+		now := time.Unix(1490000000, 0)
+		serve := func(l *rateLimiter, method, addr string, next http.HandlerFunc) *httptest.ResponseRecorder {
+			l.now = func() time.Time { return now }
+			r := httptest.NewRequest(method, "/v1/tasks", nil)
+			r.RemoteAddr = addr
+			rw := httptest.NewRecorder()
+			l.ServeHTTP(rw, r, next)
+			return rw
+		}
+		ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(200) }
+
+		Convey("requests over the rate of a client are rejected", func() {
+			l := newRateLimiter(1, 2, 0)
+			So(serve(l, "GET", "10.0.0.1:5000", ok).Code, ShouldEqual, 200)
+			So(serve(l, "GET", "10.0.0.1:5001", ok).Code, ShouldEqual, 200)
+			rw := serve(l, "GET", "10.0.0.1:5002", ok)
+			So(rw.Code, ShouldEqual, 429)
+			So(rw.Header().Get("Retry-After"), ShouldEqual, "1")
+			Convey("other clients have their own bucket", func() {
+				So(serve(l, "GET", "10.0.0.2:5000", ok).Code, ShouldEqual, 200)
+			})
+			Convey("the bucket is refilled over time", func() {
+				now = now.Add(time.Second)
+				So(serve(l, "GET", "10.0.0.1:5003", ok).Code, ShouldEqual, 200)
+				So(serve(l, "GET", "10.0.0.1:5004", ok).Code, ShouldEqual, 429)
+			})
+		})
+
+		Convey("mutating requests in flight are capped per client", func() {
+			l := newRateLimiter(0, 0, 1)
+			var inner *httptest.ResponseRecorder
+			rw := serve(l, "POST", "10.0.0.1:5000", func(w http.ResponseWriter, r *http.Request) {
+				// a second upload while the first one is being served
+				inner = serve(l, "POST", "10.0.0.1:5001", ok)
+				So(serve(l, "GET", "10.0.0.1:5002", ok).Code, ShouldEqual, 200)
+				So(serve(l, "POST", "10.0.0.2:5000", ok).Code, ShouldEqual, 200)
+				w.WriteHeader(201)
+			})
+			So(rw.Code, ShouldEqual, 201)
+			So(inner.Code, ShouldEqual, 429)
+			So(inner.Header().Get("Retry-After"), ShouldEqual, "1")
+			So(serve(l, "POST", "10.0.0.1:5003", ok).Code, ShouldEqual, 200)
+		})
+
+		Convey("idle clients are discarded", func() {
+			l := newRateLimiter(10, 0, 0)
+			serve(l, "GET", "10.0.0.1:5000", ok)
+			So(len(l.clients), ShouldEqual, 1)
+			now = now.Add(2 * idleClientSweep)
+			serve(l, "GET", "10.0.0.2:5000", ok)
+			So(l.clients, ShouldNotContainKey, "10.0.0.1")
+			So(l.clients, ShouldContainKey, "10.0.0.2")
+		})
+	})
+}
@@ -0,0 +1,89 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// Identity is who a request to the REST API was authenticated as and the role
+// bound to them.
+type Identity struct {
+	Name string
+	Role api.Role
+}
+
+// anonymousViewer is the identity of the unauthenticated read-only requests
+// let through when reads are allowed without credentials.
+var anonymousViewer = &Identity{Name: "anonymous", Role: api.RoleViewer}
+
+type identityKey struct{}
+
+func withIdentity(r *http.Request, id *Identity) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), identityKey{}, id))
+}
+
+// RequestIdentity returns the identity the request was authenticated as, or
+// nil when API authentication is disabled.
+func RequestIdentity(r *http.Request) *Identity {
+	id, _ := r.Context().Value(identityKey{}).(*Identity)
+	return id
+}
+
+// requiredRole returns the role needed to call the route: the role set on it,
+// viewer for read-only methods and operator for everything else.
+func requiredRole(route api.Route) api.Role {
+	if route.Role != api.RoleUnset {
+		return route.Role
+	}
+	switch route.Method {
+	case "GET", "HEAD":
+		return api.RoleViewer
+	}
+	return api.RoleOperator
+}
+
+// authorize wraps the handler of a route so that it is only called for
+// identities holding the role required. Nothing is enforced when API
+// authentication is disabled.
+func (s *Server) authorize(role api.Role, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if s.auth {
+			id := RequestIdentity(r)
+			if id == nil || !id.Role.Allows(role) {
+				requestLogger(r).WithFields(log.Fields{
+					"_block":        "authorize",
+					"path":          r.URL.Path,
+					"method":        r.Method,
+					"required-role": role.String(),
+				}).Warn("request denied")
+				http.Error(w, "Forbidden", 403)
+				return
+			}
+		}
+		h(w, r, p)
+	}
+}
@@ -0,0 +1,110 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequiredRole(t *testing.T) {
+	Convey("The role required by a route", t, func() {
+		Convey("is the role set on it", func() {
+			So(requiredRole(api.Route{Method: "POST", Role: api.RoleAdmin}), ShouldEqual, api.RoleAdmin)
+			So(requiredRole(api.Route{Method: "POST", Role: api.RoleViewer}), ShouldEqual, api.RoleViewer)
+		})
+		Convey("is viewer for read-only methods", func() {
+			So(requiredRole(api.Route{Method: "GET"}), ShouldEqual, api.RoleViewer)
+			So(requiredRole(api.Route{Method: "HEAD"}), ShouldEqual, api.RoleViewer)
+		})
+		Convey("is operator for mutating methods", func() {
+			for _, m := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+				So(requiredRole(api.Route{Method: m}), ShouldEqual, api.RoleOperator)
+			}
+		})
+	})
+}
+
+func TestParseRole(t *testing.T) {
+	Convey("Roles are parsed from their names", t, func() {
+		r, err := api.ParseRole("Operator")
+		So(err, ShouldBeNil)
+		So(r, ShouldEqual, api.RoleOperator)
+		_, err = api.ParseRole("root")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	Convey("Given a handler requiring the operator role", t, func() {
+		s := &Server{}
+		h := s.authorize(api.RoleOperator, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			w.WriteHeader(200)
+		})
+		serve := func(id *Identity) int {
+			r := httptest.NewRequest("POST", "/v1/tasks", nil)
+			if id != nil {
+				r = withIdentity(r, id)
+			}
+			rw := httptest.NewRecorder()
+			h(rw, r, nil)
+			return rw.Code
+		}
+		Convey("everything is allowed when API authentication is disabled", func() {
+			So(serve(nil), ShouldEqual, 200)
+		})
+		Convey("when API authentication is enabled", func() {
+			s.SetAPIAuth(true)
+			Convey("operators and admins are allowed", func() {
+				So(serve(&Identity{Role: api.RoleOperator}), ShouldEqual, 200)
+				So(serve(&Identity{Role: api.RoleAdmin}), ShouldEqual, 200)
+			})
+			Convey("viewers are forbidden", func() {
+				So(serve(&Identity{Role: api.RoleViewer}), ShouldEqual, 403)
+				So(serve(anonymousViewer), ShouldEqual, 403)
+			})
+			Convey("requests without an identity are forbidden", func() {
+				So(serve(nil), ShouldEqual, 403)
+			})
+		})
+	})
+}
+
+func TestConfigAuthTokens(t *testing.T) {
+	Convey("Tokens are admins unless given another role", t, func() {
+		c := GetDefaultConfig()
+		c.RestAuthTokens = []string{"a", "b"}
+		c.RestAuthTokenRoles = map[string]string{"b": "viewer", "c": "operator"}
+		tokens, err := c.AuthTokens()
+		So(err, ShouldBeNil)
+		So(tokens, ShouldResemble, map[string]api.Role{"a": api.RoleAdmin, "b": api.RoleViewer, "c": api.RoleOperator})
+		c.RestAuthTokenRoles["d"] = "root"
+		_, err = c.AuthTokens()
+		So(err, ShouldNotBeNil)
+	})
+}
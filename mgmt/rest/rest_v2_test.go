@@ -38,6 +38,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/core/cdata"
 	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2"
 	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -169,7 +170,41 @@ func TestV2Plugin(t *testing.T) {
 			So(
 				string(body),
 				ShouldResemble,
-				fmt.Sprintf(mock.GET_PLUGINS_RESPONSE_TYPE_NAME_VERSION, r.port))
+				fmt.Sprintf(mock.GET_PLUGINS_RESPONSE_TYPE_NAME_VERSION, r.port, r.port))
+		})
+		Convey("Get plugins by type and name - v2/plugins/:type/:name", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/plugins/publisher/bar", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.GET_PLUGINS_RESPONSE_TYPE_NAME, r.port))
+
+			resp, err = http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/plugins/publisher/qux", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 404)
+		})
+		Convey("Get plugins by type - v2/plugins/:type", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/plugins/collector", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(
+				string(body),
+				ShouldResemble,
+				fmt.Sprintf(mock.GET_PLUGINS_RESPONSE_TYPE, r.port, r.port))
+
+			resp, err = http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/plugins/streamer", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 404)
 		})
 
 		Convey("Delete plugins - v2/plugins/:type:name:version", func() {
@@ -462,5 +497,30 @@ func TestV2Metric(t *testing.T) {
 				ShouldResemble,
 				fmt.Sprintf(mock.GET_METRICS_RESPONSE, r.port))
 		})
+		Convey("Get metrics from tree - v2/metrics/*namespace", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/metrics/one/*", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			resp1, err := url.QueryUnescape(string(body))
+			So(err, ShouldBeNil)
+			So(
+				resp1,
+				ShouldResemble,
+				fmt.Sprintf(mock.GET_METRICS_RESPONSE, r.port))
+		})
+		Convey("Get a metric version from tree - v2/metrics/*namespace?ver=", func() {
+			resp, err := http.Get(
+				fmt.Sprintf("http://localhost:%d/v2/metrics/one/two/three?ver=5", r.port))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, 200)
+			mr := v2.MetricsResonse{}
+			So(json.NewDecoder(resp.Body).Decode(&mr), ShouldBeNil)
+			So(len(mr.Metrics), ShouldEqual, 1)
+			So(mr.Metrics[0].Namespace, ShouldEqual, "/one/two/three")
+			So(mr.Metrics[0].Version, ShouldEqual, 5)
+		})
 	})
 }
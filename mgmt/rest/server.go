@@ -43,18 +43,39 @@ import (
 )
 
 const (
-	allowedMethods = "GET, POST, DELETE, PUT, OPTIONS"
-	allowedHeaders = "Origin, X-Requested-With, Content-Type, Accept"
-	maxAge         = 3600
+	maxAge = 3600
 )
 
 var (
 	ErrBadCert = errors.New("Invalid certificate given")
 
+	// corsMethods are the methods that can be allowed in cross-origin requests
+	corsMethods = map[string]bool{
+		"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+	}
+
 	restLogger     = log.WithField("_module", "_mgmt-rest")
 	protocolPrefix = "http"
 )
 
+// uploadLimiter is implemented by the API versions which can limit the size
+// of the plugins uploaded.
+type uploadLimiter interface {
+	SetMaxPluginUploadSize(size int64)
+}
+
+// pluginInstaller is implemented by the API versions which can install the
+// plugins of a plugin repository.
+type pluginInstaller interface {
+	SetPluginRepository(uri string)
+}
+
+// inventorySigner is implemented by the API versions which can sign the
+// inventory of the loaded plugins with the key of the REST API.
+type inventorySigner interface {
+	SetInventoryKey(cer tls.Certificate)
+}
+
 type Server struct {
 	apis           []api.API
 	n              *negroni.Negroni
@@ -62,16 +83,31 @@ type Server struct {
 	snapTLS        *snapTLS
 	auth           bool
 	pprof          bool
+	pprofAddr      string
+	pprofListener  net.Listener
 	authpwd        string
+	authTokens     map[string]api.Role
+	authAllowReads bool
+	authenticators []Authenticator
+	readyChecks    []readinessCheck
+	tasks          api.Tasks
 	addrString     string
 	addr           net.Addr
 	wg             sync.WaitGroup
 	killChan       chan struct{}
 	err            chan error
 	allowedOrigins map[string]bool
+	allowedMethods []string
+	allowedHeaders []string
 	// the following instance variables are used to cleanly shutdown the server
 	serverListener net.Listener
 	closingChan    chan bool
+	events         *eventBroker
+	daemonConfig   daemonConfig
+	shutdown       func()
+	audit          *auditLog
+	auditFile      *auditFile
+	webhooks       *webhooks
 }
 
 // New creates a REST API server with a given config
@@ -82,7 +118,11 @@ func New(cfg *Config) (*Server, error) {
 		killChan:   make(chan struct{}),
 		addrString: cfg.Address,
 		pprof:      cfg.Pprof,
+		pprofAddr:  cfg.PprofAddr,
+		events:     newEventBroker(),
+		audit:      newAuditLog(auditLogSize),
 	}
+	s.webhooks = newWebhooks(&s.wg, s.killChan)
 	if cfg.HTTPS {
 		var err error
 		s.snapTLS, err = newtls(cfg.RestCertificate, cfg.RestKey)
@@ -97,12 +137,49 @@ func New(cfg *Config) (*Server, error) {
 		v1.New(&s.wg, s.killChan, protocolPrefix),
 		v2.New(&s.wg, s.killChan, protocolPrefix),
 	}
+	for _, a := range s.apis {
+		if l, ok := a.(uploadLimiter); ok {
+			l.SetMaxPluginUploadSize(int64(cfg.MaxPluginUploadSize) << 20)
+		}
+		if i, ok := a.(pluginInstaller); ok {
+			i.SetPluginRepository(cfg.PluginRepository)
+		}
+	}
+	if s.snapTLS != nil {
+		cer, err := tls.LoadX509KeyPair(s.snapTLS.cert, s.snapTLS.key)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range s.apis {
+			if i, ok := a.(inventorySigner); ok {
+				i.SetInventoryKey(cer)
+			}
+		}
+	}
 
 	s.n = negroni.New(
 		NewLogger(),
 		negroni.NewRecovery(),
-		negroni.HandlerFunc(s.authMiddleware),
 	)
+	s.n.Use(tracingMiddleware{})
+	// Rate limits are applied ahead of authentication so that clients
+	// guessing credentials are limited as well.
+	if cfg.RateLimit > 0 || cfg.MaxInflightRequests > 0 {
+		s.n.Use(newRateLimiter(cfg.RateLimit, cfg.RateBurst, cfg.MaxInflightRequests))
+	}
+	s.n.Use(gzipMiddleware{})
+	s.n.Use(negroni.HandlerFunc(s.authMiddleware))
+	// Audited after authentication to know who made the requests.
+	s.n.Use(s.audit)
+	s.n.Use(yamlMiddleware{})
+	if cfg.AuditLog != "" {
+		f, err := newAuditFile(cfg.AuditLog, int64(cfg.AuditLogMaxSize)*1024*1024, cfg.AuditLogMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		s.auditFile = f
+		s.audit.addSink(f)
+	}
 	s.r = httprouter.New()
 
 	// CORS has to be turned on explictly in the global config.
@@ -112,10 +189,14 @@ func New(cfg *Config) (*Server, error) {
 		return nil, err
 	}
 	if len(origins) > 0 {
+		if s.allowedMethods, err = getAllowedMethods(cfg.CorsMethods); err != nil {
+			return nil, err
+		}
+		s.allowedHeaders = getAllowedHeaders(cfg.CorsHeaders)
 		c := cors.New(cors.Options{
 			AllowedOrigins: origins,
-			AllowedMethods: []string{allowedMethods},
-			AllowedHeaders: []string{allowedHeaders},
+			AllowedMethods: s.allowedMethods,
+			AllowedHeaders: s.allowedHeaders,
 			MaxAge:         maxAge,
 		})
 		s.n.Use(c)
@@ -133,6 +214,7 @@ func (s *Server) BindMetricManager(m api.Metrics) {
 }
 
 func (s *Server) BindTaskManager(t api.Tasks) {
+	s.tasks = t
 	for _, apiInstance := range s.apis {
 		apiInstance.BindTaskManager(t)
 	}
@@ -160,19 +242,37 @@ func (s *Server) SetAPIAuthPwd(pwd string) {
 	s.authpwd = pwd
 }
 
+// SetAPIAuthTokens sets the static tokens accepted as bearer tokens by the API
+// along with the role bound to each of them
+func (s *Server) SetAPIAuthTokens(tokens map[string]api.Role) {
+	s.authTokens = tokens
+}
+
+// SetAPIAuthAllowReads allows unauthenticated GET requests when API
+// authentication is enabled; mutating requests are still authenticated
+func (s *Server) SetAPIAuthAllowReads(allow bool) {
+	s.authAllowReads = allow
+}
+
+// AddAuthenticator adds an authenticator consulted, after the password and
+// the static tokens, for requests made when API authentication is enabled
+func (s *Server) AddAuthenticator(a Authenticator) {
+	s.authenticators = append(s.authenticators, a)
+}
+
 // Auth Middleware for REST API
 func (s *Server) authMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	reqOrigin := r.Header.Get("Origin")
 	s.setAllowedOrigins(rw, reqOrigin)
 
 	defer r.Body.Close()
-	if s.auth {
-		_, password, ok := r.BasicAuth()
-		// If we have valid password or going to tribe/agreements endpoint
-		// go to next. tribe/agreements endpoint used for populating
-		// snaptel help page when tribe mode is turned on.
-		if ok && password == s.authpwd {
-			next(rw, r)
+	if s.auth && !isHealthCheck(r) {
+		// Read-only requests may be let through without credentials as a
+		// viewer, mutating ones always need a valid password or token.
+		if id, ok := s.authenticate(r); ok {
+			next(rw, withIdentity(r, id))
+		} else if s.authAllowReads && isReadOnly(r) {
+			next(rw, withIdentity(r, anonymousViewer))
 		} else {
 			http.Error(rw, "Not Authorized", 401)
 		}
@@ -191,8 +291,8 @@ func (s *Server) setAllowedOrigins(rw http.ResponseWriter, ro string) {
 				ro = "*"
 			}
 			rw.Header().Set("Access-Control-Allow-Origin", ro)
-			rw.Header().Set("Access-Control-Allow-Methods", allowedMethods)
-			rw.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			rw.Header().Set("Access-Control-Allow-Methods", strings.Join(s.allowedMethods, ", "))
+			rw.Header().Set("Access-Control-Allow-Headers", strings.Join(s.allowedHeaders, ", "))
 			rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
 		}
 	}
@@ -210,6 +310,12 @@ func (s *Server) Name() string {
 func (s *Server) Start() error {
 	s.closingChan = make(chan bool, 1)
 	s.addRoutes()
+	s.webhooks.run(s.events)
+	if s.pprof && s.pprofAddr != "" {
+		if err := s.runPprof(); err != nil {
+			return err
+		}
+	}
 	s.run(s.addrString)
 	restLogger.WithFields(log.Fields{
 		"_block": "start",
@@ -225,8 +331,14 @@ func (s *Server) Stop() {
 	close(s.killChan)
 	// close the server listener
 	s.serverListener.Close()
+	if s.pprofListener != nil {
+		s.pprofListener.Close()
+	}
 	// wait for the server goroutines to complete (serve and watch)
 	s.wg.Wait()
+	if s.auditFile != nil {
+		s.auditFile.Close()
+	}
 	// finally log the result
 	restLogger.WithFields(log.Fields{
 		"_block": "stop",
@@ -300,12 +412,25 @@ func (s *Server) serve(ln net.Listener) {
 }
 
 func (s *Server) addRoutes() {
+	successors := successorVersions(s.apis)
 	for _, apiInstance := range s.apis {
 		for _, route := range apiInstance.GetRoutes() {
-			s.r.Handle(route.Method, route.Path, route.Handle)
+			h := s.authorize(requiredRole(route), route.Handle)
+			if v, ok := successors[route.Method+" "+route.Path]; ok {
+				h = deprecated(v, h)
+			}
+			s.r.Handle(route.Method, route.Path, h)
 		}
 	}
 	s.addPprofRoutes()
+	s.addEventRoutes()
+	s.addSwaggerRoutes()
+	s.addHealthRoutes()
+	s.addMetricsRoutes()
+	s.addDaemonConfigRoutes()
+	s.addAdminRoutes()
+	s.addAuditRoutes()
+	s.addWebhookRoutes()
 }
 
 func (s *Server) getAllowedOrigins(corsd string) ([]string, error) {
@@ -348,6 +473,39 @@ func (s *Server) getAllowedOrigins(corsd string) ([]string, error) {
 	return vo, nil
 }
 
+// getAllowedMethods returns the methods of the comma separated list allowed
+// in cross-origin requests, the default ones when the list is empty.
+func getAllowedMethods(methods string) ([]string, error) {
+	if strings.TrimSpace(methods) == "" {
+		methods = defaultCorsMethods
+	}
+	vm := []string{}
+	for _, m := range strings.Split(methods, ",") {
+		tm := strings.ToUpper(strings.TrimSpace(m))
+		if !corsMethods[tm] {
+			restLogger.Errorf("Invalid method found %s", m)
+			return nil, fmt.Errorf("Invalid method found: %s.", strings.TrimSpace(m))
+		}
+		vm = append(vm, tm)
+	}
+	return vm, nil
+}
+
+// getAllowedHeaders returns the headers of the comma separated list allowed
+// in cross-origin requests, the default ones when the list is empty.
+func getAllowedHeaders(headers string) []string {
+	if strings.TrimSpace(headers) == "" {
+		headers = defaultCorsHeaders
+	}
+	vh := []string{}
+	for _, h := range strings.Split(headers, ",") {
+		if th := strings.TrimSpace(h); th != "" {
+			vh = append(vh, http.CanonicalHeaderKey(th))
+		}
+	}
+	return vh
+}
+
 // Monkey patch ListenAndServe and TCP alive code from https://golang.org/src/net/http/server.go
 // The built in ListenAndServe and ListenAndServeTLS include TCP keepalive
 // At this point the Go team is not wanting to provide separate listen and serve methods
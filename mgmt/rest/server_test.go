@@ -264,3 +264,40 @@ func TestRestAPICorsd(t *testing.T) {
 		})
 	})
 }
+
+func TestRestAPICorsMethodsAndHeaders(t *testing.T) {
+	Convey("Test cors allowed methods and headers", t, func() {
+		Convey("Methods are valid", func() {
+			m, err := getAllowedMethods("get, POST ,DELETE")
+			So(err, ShouldBeNil)
+			So(m, ShouldResemble, []string{"GET", "POST", "DELETE"})
+		})
+
+		Convey("Methods default when empty", func() {
+			m, err := getAllowedMethods("")
+			So(err, ShouldBeNil)
+			So(m, ShouldResemble, []string{"GET", "POST", "DELETE", "PUT", "PATCH", "OPTIONS"})
+		})
+
+		Convey("Method is unknown", func() {
+			_, err := getAllowedMethods("GET, FETCH")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Headers are canonicalized", func() {
+			So(getAllowedHeaders("content-type, authorization,"), ShouldResemble, []string{"Content-Type", "Authorization"})
+		})
+
+		Convey("Headers default when empty", func() {
+			So(getAllowedHeaders(""), ShouldContain, "Authorization")
+		})
+
+		Convey("Server is not created with an invalid method", func() {
+			cfg := GetDefaultConfig()
+			cfg.Corsd = "http://example.com"
+			cfg.CorsMethods = "GET, FETCH"
+			_, err := New(cfg)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
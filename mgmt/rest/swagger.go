@@ -0,0 +1,242 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+)
+
+var (
+	routeParamRegex  = regexp.MustCompile(`[:*]([^/]+)`)
+	handlerNameRegex = regexp.MustCompile(`\.([A-Za-z0-9_]+)(-fm)?$`)
+
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// swaggerSchemas builds the Swagger schemas of Go types from their JSON
+// encoding, collecting the named struct types as definitions.
+type swaggerSchemas struct {
+	definitions map[string]interface{}
+}
+
+func (s *swaggerSchemas) schema(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	// types encoding themselves can be anything
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := s.definitions[name]; !ok {
+			// reserve the name first so that recursive types terminate
+			s.definitions[name] = nil
+			s.definitions[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (s *swaggerSchemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	s.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *swaggerSchemas) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !ft.Implements(marshalerType) && !reflect.PtrTo(ft).Implements(marshalerType) {
+				s.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = s.schema(ft)
+		omitempty := false
+		for _, o := range parts[1:] {
+			if o == "omitempty" {
+				omitempty = true
+			}
+		}
+		if !omitempty && ft.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// handlerName returns the name of the method handling a route.
+func handlerName(h httprouter.Handle) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	if m := handlerNameRegex.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return name
+}
+
+// swaggerSpec generates the Swagger 2.0 specification of the routes of the
+// APIs given, with the definitions of all the rbody response bodies.
+func swaggerSpec(apis []api.API) map[string]interface{} {
+	schemas := &swaggerSchemas{definitions: map[string]interface{}{}}
+	// response bodies are defined under their rbody type
+	for t, b := range rbody.BodyTypes() {
+		schemas.definitions[t] = schemas.schema(reflect.TypeOf(b))
+	}
+	schemas.definitions["APIResponse"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"meta": schemas.schema(reflect.TypeOf(rbody.APIResponseMeta{})),
+			"body": map[string]interface{}{
+				"description": "a body of the type given in meta, see the definition named after it",
+			},
+		},
+	}
+
+	latest := ""
+	paths := map[string]interface{}{}
+	for _, a := range apis {
+		for _, route := range a.GetRoutes() {
+			v, p := splitVersion(route.Path)
+			if versionNumber(v) > versionNumber(latest) {
+				latest = v
+			}
+			path := routeParamRegex.ReplaceAllString(route.Path, "{$1}")
+			ops, ok := paths[path].(map[string]interface{})
+			if !ok {
+				ops = map[string]interface{}{}
+				paths[path] = ops
+			}
+			params := []interface{}{}
+			for _, m := range routeParamRegex.FindAllStringSubmatch(route.Path, -1) {
+				params = append(params, map[string]interface{}{
+					"name":     m[1],
+					"in":       "path",
+					"required": true,
+					"type":     "string",
+				})
+			}
+			tag := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 2)[0]
+			op := map[string]interface{}{
+				"operationId": v + strings.Title(handlerName(route.Handle)),
+				"tags":        []string{tag},
+				"parameters":  params,
+				"responses": map[string]interface{}{
+					"default": map[string]interface{}{
+						"description": "response of the " + v + " API",
+						"schema":      map[string]interface{}{"$ref": "#/definitions/APIResponse"},
+					},
+				},
+				"x-snap-role": requiredRole(route).String(),
+			}
+			if route.Deprecated {
+				op["deprecated"] = true
+			}
+			ops[strings.ToLower(route.Method)] = op
+		}
+	}
+
+	return map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":   "Snap REST API",
+			"version": latest,
+		},
+		"consumes": []string{"application/json"},
+		"produces": []string{"application/json"},
+		"securityDefinitions": map[string]interface{}{
+			"basic": map[string]interface{}{"type": "basic"},
+			"token": map[string]interface{}{"type": "apiKey", "in": "header", "name": "Authorization"},
+		},
+		"paths":       paths,
+		"definitions": schemas.definitions,
+	}
+}
+
+func (s *Server) addSwaggerRoutes() {
+	spec, err := json.MarshalIndent(swaggerSpec(s.apis), "", "  ")
+	if err != nil {
+		restLogger.WithField("_block", "add-swagger-routes").Error(err)
+		return
+	}
+	s.r.GET("/v1/swagger.json", s.authorize(api.RoleViewer, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}))
+}
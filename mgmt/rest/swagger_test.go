@@ -0,0 +1,86 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type swaggerTestAPI struct{}
+
+func (swaggerTestAPI) getPlugin(w http.ResponseWriter, r *http.Request, _ httprouter.Params)  {}
+func (swaggerTestAPI) loadPlugin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {}
+
+func TestSwaggerSpec(t *testing.T) {
+	Convey("Given the routes of two API versions", t, func() {
+		a := swaggerTestAPI{}
+		apis := []api.API{
+			routesAPI{
+				api.Route{Method: "GET", Path: "/v1/plugins/:type/:name/:version", Handle: a.getPlugin, Deprecated: true},
+				api.Route{Method: "POST", Path: "/v1/plugins", Handle: a.loadPlugin, Role: api.RoleAdmin},
+			},
+			routesAPI{
+				api.Route{Method: "GET", Path: "/v2/metrics/*namespace", Handle: a.getPlugin},
+			},
+		}
+		spec := swaggerSpec(apis)
+		// round trip through JSON as served
+		b, err := json.Marshal(spec)
+		So(err, ShouldBeNil)
+		doc := map[string]interface{}{}
+		So(json.Unmarshal(b, &doc), ShouldBeNil)
+
+		So(doc["swagger"], ShouldEqual, "2.0")
+		So(doc["info"].(map[string]interface{})["version"], ShouldEqual, "v2")
+		paths := doc["paths"].(map[string]interface{})
+		Convey("routes are described with their path parameters", func() {
+			So(paths, ShouldContainKey, "/v1/plugins/{type}/{name}/{version}")
+			So(paths, ShouldContainKey, "/v2/metrics/{namespace}")
+			get := paths["/v1/plugins/{type}/{name}/{version}"].(map[string]interface{})["get"].(map[string]interface{})
+			So(get["operationId"], ShouldEqual, "v1GetPlugin")
+			So(get["deprecated"], ShouldEqual, true)
+			So(get["tags"], ShouldResemble, []interface{}{"plugins"})
+			So(len(get["parameters"].([]interface{})), ShouldEqual, 3)
+			post := paths["/v1/plugins"].(map[string]interface{})["post"].(map[string]interface{})
+			So(post["x-snap-role"], ShouldEqual, "admin")
+			So(post, ShouldNotContainKey, "deprecated")
+		})
+		Convey("every rbody type is defined", func() {
+			defs := doc["definitions"].(map[string]interface{})
+			for typ := range rbody.BodyTypes() {
+				So(defs, ShouldContainKey, typ)
+			}
+			So(defs, ShouldContainKey, "APIResponse")
+			plugin := defs["LoadedPlugin"].(map[string]interface{})
+			So(plugin["type"], ShouldEqual, "object")
+			So(plugin["properties"], ShouldContainKey, "name")
+		})
+	})
+}
@@ -0,0 +1,59 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// tracingMiddleware traces the requests with the global OpenTracing tracer.
+// The span of a request continues the trace propagated by the client in the
+// request headers, if any, and is handed to the handlers in the request
+// context.
+type tracingMiddleware struct{}
+
+func (tracingMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	tracer := opentracing.GlobalTracer()
+	// a request without a propagated trace starts a new one
+	parent, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+	span := tracer.StartSpan("HTTP "+r.Method, ext.RPCServerOption(parent))
+	defer span.Finish()
+	ext.Component.Set(span, "snap-rest")
+	ext.HTTPMethod.Set(span, r.Method)
+	ext.HTTPUrl.Set(span, r.URL.String())
+	if id := r.Header.Get(api.RequestIDHeader); id != "" {
+		span.SetTag("request-id", id)
+	}
+
+	next(rw, r.WithContext(opentracing.ContextWithSpan(r.Context(), span)))
+
+	if res, ok := rw.(negroni.ResponseWriter); ok {
+		ext.HTTPStatusCode.Set(span, uint16(res.Status()))
+		if res.Status() >= 500 {
+			ext.Error.Set(span, true)
+		}
+	}
+}
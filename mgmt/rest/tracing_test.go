@@ -0,0 +1,71 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	Convey("Given a tracer and the tracing middleware", t, func() {
+		tracer := mocktracer.New()
+		opentracing.SetGlobalTracer(tracer)
+		defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+		var handlerSpan opentracing.Span
+		n := negroni.New(tracingMiddleware{})
+		n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerSpan = opentracing.SpanFromContext(r.Context())
+			w.WriteHeader(503)
+		})
+
+		Convey("a request continues the trace propagated by its client", func() {
+			client := tracer.StartSpan("client")
+			r := httptest.NewRequest("POST", "/v2/tasks", nil)
+			So(tracer.Inject(client.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)), ShouldBeNil)
+			n.ServeHTTP(httptest.NewRecorder(), r)
+
+			spans := tracer.FinishedSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(handlerSpan, ShouldEqual, spans[0])
+			So(spans[0].OperationName, ShouldEqual, "HTTP POST")
+			So(spans[0].ParentID, ShouldEqual, client.Context().(mocktracer.MockSpanContext).SpanID)
+			So(spans[0].Tag("http.url"), ShouldEqual, "/v2/tasks")
+			So(spans[0].Tag("http.status_code"), ShouldEqual, uint16(503))
+			So(spans[0].Tag("error"), ShouldEqual, true)
+		})
+		Convey("a request without a propagated trace starts a new one", func() {
+			n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v2/tasks", nil))
+
+			spans := tracer.FinishedSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].ParentID, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,52 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"net/http"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+	"github.com/julienschmidt/httprouter"
+)
+
+func (s *apiV1) getAccounting(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "task"
+	}
+	records, err := s.taskManager.Accounting(groupBy)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	report := &rbody.AccountingReport{
+		GroupBy: groupBy,
+		Records: make([]rbody.AccountingRecord, len(records)),
+	}
+	for i, rec := range records {
+		report.Records[i] = rbody.AccountingRecord{
+			Group:           rec.Group,
+			WindowTimestamp: rec.Window.Unix(),
+			Collected:       rec.Collected,
+			Published:       rec.Published,
+		}
+	}
+	rbody.Write(200, report, w)
+}
@@ -22,6 +22,7 @@ type apiV1 struct {
 	taskManager   api.Tasks
 	tribeManager  api.Tribe
 	configManager api.Config
+	maxUploadSize int64
 
 	wg       *sync.WaitGroup
 	killChan chan struct{}
@@ -35,39 +36,53 @@ func New(wg *sync.WaitGroup, killChan chan struct{}, protocol string) *apiV1 {
 func (s *apiV1) GetRoutes() []api.Route {
 	routes := []api.Route{
 		// plugin routes
-		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name", Handle: s.getPlugins},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin},
-		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin},
-		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem},
-		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem},
+		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type", Handle: s.getPlugins, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name", Handle: s.getPlugins, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin, Deprecated: true},
+		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin, Role: api.RoleAdmin, Deprecated: true},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin, Role: api.RoleAdmin, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem, Deprecated: true},
+		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem, Role: api.RoleAdmin, Deprecated: true},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem, Role: api.RoleAdmin, Deprecated: true},
 
 		// metric routes
-		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics},
-		api.Route{Method: "GET", Path: prefix + "/metrics/*namespace", Handle: s.getMetricsFromTree},
+		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/metrics/*namespace", Handle: s.getMetricsFromTree, Deprecated: true},
 
 		// task routes
-		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks},
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask},
-		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask},
-		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask},
+		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask, Deprecated: true},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/workflow/graph", Handle: s.getTaskWorkflowGraph},
+		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask, Deprecated: true},
+		api.Route{Method: "PUT", Path: prefix + "/tasks/:id", Handle: s.updateTask},
 		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/start", Handle: s.startTask},
 		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/stop", Handle: s.stopTask},
-		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask},
+		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask, Deprecated: true},
 		api.Route{Method: "PUT", Path: prefix + "/tasks/:id/enable", Handle: s.enableTask},
+		api.Route{Method: "PATCH", Path: prefix + "/tasks/:id/config", Handle: s.patchTaskConfig},
+
+		// accounting routes
+		api.Route{Method: "GET", Path: prefix + "/accounting", Handle: s.getAccounting},
+
+		// workflow routes
+		api.Route{Method: "GET", Path: prefix + "/workflows", Handle: s.getWorkflows},
+		// also serves the workflow map JSON Schema at /workflows/schema
+		api.Route{Method: "GET", Path: prefix + "/workflows/:name", Handle: s.getWorkflow},
+		api.Route{Method: "POST", Path: prefix + "/workflows", Handle: s.addWorkflow},
+		api.Route{Method: "POST", Path: prefix + "/workflows/validate", Handle: s.validateWorkflow, Role: api.RoleViewer},
+		api.Route{Method: "DELETE", Path: prefix + "/workflows/:name", Handle: s.removeWorkflow},
 	}
 	// tribe routes
 	if s.tribeManager != nil {
 		routes = append(routes, []api.Route{
 			api.Route{Method: "GET", Path: prefix + "/tribe/agreements", Handle: s.getAgreements},
-			api.Route{Method: "POST", Path: prefix + "/tribe/agreements", Handle: s.addAgreement},
+			api.Route{Method: "POST", Path: prefix + "/tribe/agreements", Handle: s.addAgreement, Role: api.RoleAdmin},
 			api.Route{Method: "GET", Path: prefix + "/tribe/agreements/:name", Handle: s.getAgreement},
-			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name", Handle: s.deleteAgreement},
-			api.Route{Method: "PUT", Path: prefix + "/tribe/agreements/:name/join", Handle: s.joinAgreement},
-			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name/leave", Handle: s.leaveAgreement},
+			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name", Handle: s.deleteAgreement, Role: api.RoleAdmin},
+			api.Route{Method: "PUT", Path: prefix + "/tribe/agreements/:name/join", Handle: s.joinAgreement, Role: api.RoleAdmin},
+			api.Route{Method: "DELETE", Path: prefix + "/tribe/agreements/:name/leave", Handle: s.leaveAgreement, Role: api.RoleAdmin},
 			api.Route{Method: "GET", Path: prefix + "/tribe/members", Handle: s.getMembers},
 			api.Route{Method: "GET", Path: prefix + "/tribe/member/:name", Handle: s.getMember},
 		}...)
@@ -62,10 +62,14 @@ func (m MockLoadedPlugin) LoadedTimestamp() *time.Time {
 	t := time.Date(2016, time.September, 6, 0, 0, 0, 0, time.UTC)
 	return &t
 }
-func (m MockLoadedPlugin) Policy() *cpolicy.ConfigPolicy { return cpolicy.New() }
-func (m MockLoadedPlugin) HitCount() int                 { return 0 }
-func (m MockLoadedPlugin) LastHit() time.Time            { return time.Now() }
-func (m MockLoadedPlugin) ID() uint32                    { return 0 }
+func (m MockLoadedPlugin) Policy() *cpolicy.ConfigPolicy  { return cpolicy.New() }
+func (m MockLoadedPlugin) Metadata() *core.PluginMetadata { return nil }
+func (m MockLoadedPlugin) Info() core.PluginInfo          { return core.PluginInfo{} }
+func (m MockLoadedPlugin) HitCount() int                  { return 0 }
+func (m MockLoadedPlugin) LastHit() time.Time             { return time.Now() }
+func (m MockLoadedPlugin) ID() uint32                     { return 0 }
+func (m MockLoadedPlugin) FailedHealthChecks() int        { return 0 }
+func (m MockLoadedPlugin) LastHealthCheck() time.Time     { return time.Now() }
 
 //////MockCatalogedMetric/////
 
@@ -21,7 +21,6 @@ package v1
 
 import (
 	"compress/gzip"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -29,11 +28,11 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/serror"
 	"github.com/intelsdi-x/snap/mgmt/rest/api"
@@ -44,8 +43,12 @@ import (
 const PluginAlreadyLoaded = "plugin is already loaded"
 
 var (
-	ErrMissingPluginName = errors.New("missing plugin name")
-	ErrPluginNotFound    = errors.New("plugin not found")
+	ErrMissingPluginName      = errors.New("missing plugin name")
+	ErrPluginNotFound         = errors.New("plugin not found")
+	ErrPluginNotInLoadedState = errors.New("Plugin must be in a LoadedState")
+	ErrControllerNotStarted   = errors.New("Must start Controller before use")
+	ErrNotMultipart           = errors.New("Plugins must be loaded with a multipart request")
+	ErrNoPluginFile           = errors.New("No plugin file was passed to the load plugin api")
 )
 
 type plugin struct {
@@ -67,115 +70,136 @@ func (p *plugin) TypeName() string {
 }
 
 func (s *apiV1) loadPlugin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var rp *core.RequestedPlugin
 	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
-		rbody.Write(500, rbody.FromError(err), w)
+		rbody.Write(415, rbody.FromError(err), w)
 		return
 	}
 	if strings.HasPrefix(mediaType, "multipart/") {
-		var signature []byte
-		var checkSum [sha256.Size]byte
-		lp := &rbody.PluginsLoaded{}
-		lp.LoadedPlugins = make([]rbody.LoadedPlugin, 0)
+		// Each plugin file may be followed by its signature file (.asc).
+		var rps []*core.RequestedPlugin
+		signed := false
 		mr := multipart.NewReader(r.Body, params["boundary"])
-		var i int
 		for {
-			var b []byte
 			p, err := mr.NextPart()
 			if err == io.EOF {
 				break
 			}
 			if err != nil {
-				rbody.Write(500, rbody.FromError(err), w)
+				removeRequestedPlugins(rps)
+				rbody.Write(400, rbody.FromError(err), w)
 				return
 			}
+			var content io.Reader = p
 			if r.Header.Get("Plugin-Compression") == "gzip" {
 				g, err := gzip.NewReader(p)
-				defer g.Close()
-				if err != nil {
-					rbody.Write(500, rbody.FromError(err), w)
-					return
-				}
-				b, err = ioutil.ReadAll(g)
 				if err != nil {
-					rbody.Write(500, rbody.FromError(err), w)
-					return
-				}
-			} else {
-				b, err = ioutil.ReadAll(p)
-				if err != nil {
-					rbody.Write(500, rbody.FromError(err), w)
+					removeRequestedPlugins(rps)
+					rbody.Write(400, rbody.FromError(err), w)
 					return
 				}
+				defer g.Close()
+				content = g
 			}
 
-			// A little sanity checking for files being passed into the API server.
-			// First file passed in should be the plugin. If the first file is a signature
-			// file, an error is returned. The signature file should be the second
-			// file passed to the API server. If the second file does not have the ".asc"
-			// extension, an error is returned.
-			// If we loop around more than twice before receiving io.EOF, then
-			// an error is returned.
-
-			switch {
-			case i == 0:
-				if filepath.Ext(p.FileName()) == ".asc" {
-					e := errors.New("Error: first file passed to load plugin api can not be signature file")
-					rbody.Write(500, rbody.FromError(e), w)
+			if filepath.Ext(p.FileName()) == ".asc" {
+				if len(rps) == 0 || signed {
+					removeRequestedPlugins(rps)
+					rbody.Write(400, rbody.FromError(ErrSignatureWithoutPlugin), w)
 					return
 				}
-				if rp, err = core.NewRequestedPlugin(p.FileName(), s.metricManager.GetTempDir(), b); err != nil {
-					rbody.Write(500, rbody.FromError(err), w)
-					return
-				}
-				checkSum = sha256.Sum256(b)
-			case i == 1:
-				if filepath.Ext(p.FileName()) == ".asc" {
-					signature = b
-				} else {
-					e := errors.New("Error: second file passed was not a signature file")
-					rbody.Write(500, rbody.FromError(e), w)
+				signature, err := readUploadPart(content, s.maxUploadSize)
+				if err != nil {
+					removeRequestedPlugins(rps)
+					rbody.Write(uploadErrorStatus(err), rbody.FromError(err), w)
 					return
 				}
-			case i == 2:
-				e := errors.New("Error: More than two files passed to the load plugin api")
-				rbody.Write(500, rbody.FromError(e), w)
+				rps[len(rps)-1].SetSignature(signature)
+				signed = true
+				continue
+			}
+			// the plugin is written to disk as it is received
+			rp, err := core.NewRequestedPluginFromReader(p.FileName(), s.metricManager.GetTempDir(), content, s.maxUploadSize)
+			if err != nil {
+				removeRequestedPlugins(rps)
+				rbody.Write(uploadErrorStatus(err), rbody.FromError(err), w)
 				return
 			}
-			i++
+			rps = append(rps, rp)
+			signed = false
 		}
 
-		// Sanity check, verify the checkSum on the file sent is the same
-		// as after it is written to disk.
-		if rp.CheckSum() != checkSum {
-			e := errors.New("Error: CheckSum mismatch on requested plugin to load")
-			rbody.Write(500, rbody.FromError(e), w)
+		if len(rps) == 0 {
+			rbody.Write(400, rbody.FromError(ErrNoPluginFile), w)
 			return
 		}
-		rp.SetSignature(signature)
-		restLogger.Info("Loading plugin: ", rp.Path())
-		pl, err := s.metricManager.Load(rp)
-		if err != nil {
-			var ec int
-			restLogger.Error(err)
-			restLogger.Debugf("Removing file (%s)", rp.Path())
-			err2 := os.RemoveAll(filepath.Dir(rp.Path()))
-			if err2 != nil {
-				restLogger.Error(err2)
+		if err := verifyCheckSums(rps, r.Header.Get(api.PluginChecksumHeader)); err != nil {
+			removeRequestedPlugins(rps)
+			rbody.Write(400, rbody.FromError(err), w)
+			return
+		}
+
+		logger := restLogger.WithField("request-id", r.Header.Get(api.RequestIDHeader))
+		// The signatures of all the plugins are checked before any of them
+		// is loaded, when the metric manager can do so.
+		if v, ok := s.metricManager.(signatureVerifier); ok {
+			for _, rp := range rps {
+				if se := v.VerifySignature(rp); se != nil {
+					logger.WithField("plugin-path", rp.Path()).Error(se)
+					removeRequestedPlugins(rps)
+					se.SetFields(map[string]interface{}{"plugin-name": filepath.Base(rp.Path())})
+					rbody.Write(400, rbody.FromSnapError(se), w)
+					return
+				}
 			}
-			rb := rbody.FromError(err)
-			switch rb.ResponseBodyMessage() {
-			case PluginAlreadyLoaded:
-				ec = 409
-			default:
-				ec = 500
+		}
+		lp := &rbody.PluginsLoaded{}
+		lp.LoadedPlugins = make([]rbody.LoadedPlugin, 0, len(rps))
+		loaded := make([]core.CatalogedPlugin, 0, len(rps))
+		for i, rp := range rps {
+			logger.Info("Loading plugin: ", rp.Path())
+			pl, err := s.metricManager.Load(rp)
+			if err != nil {
+				var ec int
+				logger.Error(err)
+				logger.Debugf("Removing file (%s)", rp.Path())
+				removeRequestedPlugins(rps[i:])
+				s.unloadPlugins(r.Header.Get(api.RequestIDHeader), loaded)
+				rb := rbody.FromError(err)
+				switch rb.ResponseBodyMessage() {
+				case PluginAlreadyLoaded:
+					ec = 409
+					rb.WithCode(rbody.ErrCodeAlreadyLoaded)
+				case ErrControllerNotStarted.Error():
+					ec = 503
+				default:
+					ec = 500
+				}
+				rbody.Write(ec, rb, w)
+				return
 			}
-			rbody.Write(ec, rb, w)
-			return
+			loaded = append(loaded, pl)
+			lp.LoadedPlugins = append(lp.LoadedPlugins, catalogedPluginToLoaded(r.Host, pl))
 		}
-		lp.LoadedPlugins = append(lp.LoadedPlugins, catalogedPluginToLoaded(r.Host, pl))
 		rbody.Write(201, lp, w)
+	} else {
+		rbody.Write(415, rbody.FromError(ErrNotMultipart), w)
+	}
+}
+
+// unloadPlugins unloads the plugins loaded by a request loading several
+// plugins which failed, so that such a request loads all of them or none.
+func (s *apiV1) unloadPlugins(requestID string, plugins []core.CatalogedPlugin) {
+	for _, pl := range plugins {
+		if _, se := s.metricManager.Unload(pl); se != nil {
+			restLogger.WithFields(log.Fields{
+				"_block":         "unload-plugins",
+				"request-id":     requestID,
+				"plugin-name":    pl.Name(),
+				"plugin-version": pl.Version(),
+				"plugin-type":    pl.TypeName(),
+			}).Error(se)
+		}
 	}
 }
 
@@ -215,7 +239,14 @@ func (s *apiV1) unloadPlugin(w http.ResponseWriter, r *http.Request, p httproute
 	})
 	if se != nil {
 		se.SetFields(f)
-		rbody.Write(500, rbody.FromSnapError(se), w)
+		ec := 500
+		switch se.Error() {
+		case ErrPluginNotFound.Error():
+			ec = 404
+		case ErrPluginNotInLoadedState.Error():
+			ec = 409
+		}
+		rbody.Write(ec, rbody.FromSnapError(se), w)
 		return
 	}
 	pr := &rbody.PluginUnloaded{
@@ -262,6 +293,10 @@ func getPlugins(mm api.Metrics, detail bool, h string, plName string, plType str
 				ID:               p.ID(),
 				Href:             pluginURI(h, version, p),
 				PprofPort:        p.Port(),
+
+				FailedHealthChecks:       p.FailedHealthChecks(),
+				LastHealthCheckTimestamp: p.LastHealthCheck().Unix(),
+				PluginResourceUsage:      resourceUsage(p),
 			}
 		}
 	}
@@ -303,6 +338,19 @@ func getPlugins(mm api.Metrics, detail bool, h string, plName string, plType str
 	return &plugins
 }
 
+// reportsResourceUsage is implemented by the running plugins which usage of
+// the host resources is sampled
+type reportsResourceUsage interface {
+	ResourceUsage() core.PluginResourceUsage
+}
+
+func resourceUsage(p core.AvailablePlugin) core.PluginResourceUsage {
+	if r, ok := p.(reportsResourceUsage); ok {
+		return r.ResourceUsage()
+	}
+	return core.PluginResourceUsage{}
+}
+
 func catalogedPluginToLoaded(host string, c core.CatalogedPlugin) rbody.LoadedPlugin {
 	return rbody.LoadedPlugin{
 		Name:            c.Name(),
@@ -312,6 +360,8 @@ func catalogedPluginToLoaded(host string, c core.CatalogedPlugin) rbody.LoadedPl
 		Status:          c.Status(),
 		LoadedTimestamp: c.LoadedTimestamp().Unix(),
 		Href:            pluginURI(host, version, c),
+		Metadata:        c.Metadata(),
+		PluginInfo:      c.Info(),
 	}
 }
 
@@ -412,6 +462,8 @@ func (s *apiV1) getPlugin(w http.ResponseWriter, r *http.Request, p httprouter.P
 			LoadedTimestamp: plugin.LoadedTimestamp().Unix(),
 			Href:            pluginURI(r.Host, version, plugin),
 			ConfigPolicy:    configPolicy,
+			Metadata:        plugin.Metadata(),
+			PluginInfo:      plugin.Info(),
 		}
 		rbody.Write(200, pluginRet, w)
 	}
@@ -22,10 +22,13 @@ limitations under the License.
 package v1
 
 import (
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/intelsdi-x/snap/mgmt/rest/v1/fixtures"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
 )
 
 func TestGetPlugins(t *testing.T) {
@@ -97,3 +100,22 @@ func TestGetPlugins(t *testing.T) {
 	})
 
 }
+
+func TestLoadPluginContentType(t *testing.T) {
+	s := &apiV1{}
+	Convey("Loading a plugin without a multipart body", t, func() {
+		Convey("is refused as an unsupported media type", func() {
+			req := httptest.NewRequest("POST", "/v1/plugins", strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			s.loadPlugin(rw, req, nil)
+			So(rw.Status(), ShouldEqual, 415)
+		})
+		Convey("is refused when the content type is missing", func() {
+			req := httptest.NewRequest("POST", "/v1/plugins", strings.NewReader("{}"))
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			s.loadPlugin(rw, req, nil)
+			So(rw.Status(), ShouldEqual, 415)
+		})
+	})
+}
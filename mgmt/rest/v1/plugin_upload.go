@@ -0,0 +1,108 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+var (
+	ErrSignatureWithoutPlugin = errors.New("a signature file (.asc) has to follow the plugin file it signs")
+	ErrCheckSumCount          = errors.New("Plugin-Sha256 has to list one checksum for each plugin file")
+)
+
+// signatureVerifier is implemented by metric managers which can check the
+// signature of a plugin before it is loaded.
+type signatureVerifier interface {
+	VerifySignature(rp *core.RequestedPlugin) serror.SnapError
+}
+
+// SetMaxPluginUploadSize sets the size, in bytes, of the largest plugin, or
+// signature, which can be uploaded, 0 meaning any size.
+func (s *apiV1) SetMaxPluginUploadSize(size int64) {
+	s.maxUploadSize = size
+}
+
+// readUploadPart reads a part of a plugin upload, returning
+// core.ErrPluginTooLarge when it is larger than maxSize, unless it is 0.
+func readUploadPart(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	// read one byte past the maximum size to tell a part too large
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err == nil && int64(len(b)) > maxSize {
+		err = core.ErrPluginTooLarge
+	}
+	return b, err
+}
+
+// uploadErrorStatus is the status of the response to a plugin upload which
+// failed with err.
+func uploadErrorStatus(err error) int {
+	if err == core.ErrPluginTooLarge {
+		return 413
+	}
+	return 500
+}
+
+// removeRequestedPlugin removes the plugin written to disk, if any, when its
+// upload or load fails.
+func removeRequestedPlugin(rp *core.RequestedPlugin) {
+	if rp == nil {
+		return
+	}
+	if err := os.RemoveAll(filepath.Dir(rp.Path())); err != nil {
+		restLogger.WithField("plugin-path", rp.Path()).Error(err)
+	}
+}
+
+func removeRequestedPlugins(rps []*core.RequestedPlugin) {
+	for _, rp := range rps {
+		removeRequestedPlugin(rp)
+	}
+}
+
+// verifyCheckSums checks the plugins uploaded against the comma separated
+// list of their checksums, given in the Plugin-Sha256 header in the order
+// of the plugins, when the header is set.
+func verifyCheckSums(rps []*core.RequestedPlugin, header string) error {
+	if header == "" {
+		return nil
+	}
+	sums := strings.Split(header, ",")
+	if len(sums) != len(rps) {
+		return ErrCheckSumCount
+	}
+	for i, rp := range rps {
+		if err := rp.VerifyCheckSum(sums[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbody
+
+const (
+	AccountingReportType = "accounting_report"
+)
+
+type AccountingRecord struct {
+	Group           string `json:"group"`
+	WindowTimestamp int64  `json:"window_timestamp"`
+	Collected       uint64 `json:"collected"`
+	Published       uint64 `json:"published"`
+}
+
+type AccountingReport struct {
+	GroupBy string             `json:"group_by"`
+	Records []AccountingRecord `json:"records"`
+}
+
+func (a *AccountingReport) ResponseBodyMessage() string {
+	return "Accounting report returned"
+}
+
+func (a *AccountingReport) ResponseBodyType() string {
+	return AccountingReportType
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
 	"github.com/urfave/negroni"
 )
 
@@ -41,6 +42,14 @@ type Body interface {
 
 func Write(code int, b Body, w http.ResponseWriter) {
 	w.Header().Set("Deprecated", "true")
+	if e, ok := b.(*Error); ok {
+		if e.RequestID == "" {
+			e.RequestID = w.Header().Get(api.RequestIDHeader)
+		}
+		if e.Code == "" {
+			e.Code = ErrorCode(code)
+		}
+	}
 	resp := &APIResponse{
 		Meta: &APIResponseMeta{
 			Code:    code,
@@ -103,65 +112,63 @@ func (a *APIResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// bodyTypes holds, for each body type UnmarshalBody knows about, a function
+// returning an empty body of that type.
+var bodyTypes = map[string]func() Body{
+	PluginListType:                 func() Body { return &PluginList{} },
+	PluginsLoadedType:              func() Body { return &PluginsLoaded{} },
+	PluginUnloadedType:             func() Body { return &PluginUnloaded{} },
+	PluginReturnedType:             func() Body { return &PluginReturned{} },
+	ScheduledTaskListReturnedType:  func() Body { return &ScheduledTaskListReturned{} },
+	ScheduledTaskReturnedType:      func() Body { return &ScheduledTaskReturned{} },
+	ScheduledTaskType:              func() Body { return &ScheduledTask{} },
+	AddScheduledTaskType:           func() Body { return &AddScheduledTask{} },
+	ScheduledTaskStartedType:       func() Body { return &ScheduledTaskStarted{} },
+	ScheduledTaskStoppedType:       func() Body { return &ScheduledTaskStopped{} },
+	ScheduledTaskRemovedType:       func() Body { return &ScheduledTaskRemoved{} },
+	ScheduledTaskEnabledType:       func() Body { return &ScheduledTaskEnabled{} },
+	ScheduledTaskConfigPatchedType: func() Body { return &ScheduledTaskConfigPatched{} },
+	ScheduledTaskUpdatedType:       func() Body { return &ScheduledTaskUpdated{} },
+	ScheduledTaskWorkflowGraphType: func() Body { return &ScheduledTaskWorkflowGraph{} },
+	MetricReturnedType:             func() Body { return &MetricReturned{} },
+	MetricsReturnedType:            func() Body { return &MetricsReturned{} },
+	ScheduledTaskWatchingEndedType: func() Body { return &ScheduledTaskWatchingEnded{} },
+	TribeMemberListType:            func() Body { return &TribeMemberList{} },
+	TribeListAgreementType:         func() Body { return &TribeListAgreement{} },
+	TribeAddAgreementType:          func() Body { return &TribeAddAgreement{} },
+	TribeDeleteAgreementType:       func() Body { return &TribeDeleteAgreement{} },
+	TribeMemberShowType:            func() Body { return &TribeMemberShow{} },
+	TribeJoinAgreementType:         func() Body { return &TribeJoinAgreement{} },
+	TribeLeaveAgreementType:        func() Body { return &TribeLeaveAgreement{} },
+	TribeGetAgreementType:          func() Body { return &TribeGetAgreement{} },
+	PluginConfigItemType:           func() Body { return &PluginConfigItem{*cdata.NewNode()} },
+	SetPluginConfigItemType:        func() Body { return &SetPluginConfigItem{*cdata.NewNode()} },
+	DeletePluginConfigItemType:     func() Body { return &DeletePluginConfigItem{*cdata.NewNode()} },
+	AccountingReportType:           func() Body { return &AccountingReport{} },
+	WorkflowValidationType:         func() Body { return &WorkflowValidation{} },
+	StoredWorkflowListType:         func() Body { return &StoredWorkflowList{} },
+	StoredWorkflowType:             func() Body { return &StoredWorkflow{} },
+	AddStoredWorkflowType:          func() Body { return &AddStoredWorkflow{} },
+	StoredWorkflowRemovedType:      func() Body { return &StoredWorkflowRemoved{} },
+	ErrorType:                      func() Body { return &Error{} },
+}
+
 func UnmarshalBody(t string, b []byte) (Body, error) {
-	switch t {
-	case PluginListType:
-		return unmarshalAndHandleError(b, &PluginList{})
-	case PluginsLoadedType:
-		return unmarshalAndHandleError(b, &PluginsLoaded{})
-	case PluginUnloadedType:
-		return unmarshalAndHandleError(b, &PluginUnloaded{})
-	case PluginReturnedType:
-		return unmarshalAndHandleError(b, &PluginReturned{})
-	case ScheduledTaskListReturnedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskListReturned{})
-	case ScheduledTaskReturnedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskReturned{})
-	case ScheduledTaskType:
-		return unmarshalAndHandleError(b, &ScheduledTask{})
-	case AddScheduledTaskType:
-		return unmarshalAndHandleError(b, &AddScheduledTask{})
-	case ScheduledTaskStartedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskStarted{})
-	case ScheduledTaskStoppedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskStopped{})
-	case ScheduledTaskRemovedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskRemoved{})
-	case ScheduledTaskEnabledType:
-		return unmarshalAndHandleError(b, &ScheduledTaskEnabled{})
-	case MetricReturnedType:
-		return unmarshalAndHandleError(b, &MetricReturned{})
-	case MetricsReturnedType:
-		return unmarshalAndHandleError(b, &MetricsReturned{})
-	case ScheduledTaskWatchingEndedType:
-		return unmarshalAndHandleError(b, &ScheduledTaskWatchingEnded{})
-	case TribeMemberListType:
-		return unmarshalAndHandleError(b, &TribeMemberList{})
-	case TribeListAgreementType:
-		return unmarshalAndHandleError(b, &TribeListAgreement{})
-	case TribeAddAgreementType:
-		return unmarshalAndHandleError(b, &TribeAddAgreement{})
-	case TribeDeleteAgreementType:
-		return unmarshalAndHandleError(b, &TribeDeleteAgreement{})
-	case TribeMemberShowType:
-		return unmarshalAndHandleError(b, &TribeMemberShow{})
-	case TribeJoinAgreementType:
-		return unmarshalAndHandleError(b, &TribeJoinAgreement{})
-	case TribeLeaveAgreementType:
-		return unmarshalAndHandleError(b, &TribeLeaveAgreement{})
-	case TribeGetAgreementType:
-		return unmarshalAndHandleError(b, &TribeGetAgreement{})
-	case PluginConfigItemType:
-		return unmarshalAndHandleError(b, &PluginConfigItem{*cdata.NewNode()})
-	case SetPluginConfigItemType:
-		return unmarshalAndHandleError(b, &SetPluginConfigItem{*cdata.NewNode()})
-	case DeletePluginConfigItemType:
-		return unmarshalAndHandleError(b, &DeletePluginConfigItem{*cdata.NewNode()})
-	case ErrorType:
-		return unmarshalAndHandleError(b, &Error{})
-	default:
+	newBody, ok := bodyTypes[t]
+	if !ok {
 		return nil, ErrCannotUnmarshalBody
 	}
+	return unmarshalAndHandleError(b, newBody())
+}
+
+// BodyTypes returns an empty body of each type UnmarshalBody knows about,
+// keyed by the type.
+func BodyTypes() map[string]Body {
+	bodies := make(map[string]Body, len(bodyTypes))
+	for t, newBody := range bodyTypes {
+		bodies[t] = newBody()
+	}
+	return bodies
 }
 
 func unmarshalAndHandleError(b []byte, body Body) (Body, error) {
@@ -29,10 +29,45 @@ const (
 	ErrorType = "error"
 )
 
+// Machine-readable codes of API errors. Write sets the code matching the
+// status of the response unless the handler gave a more precise one.
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeConflict             = "conflict"
+	ErrCodeAlreadyLoaded        = "already_loaded"
+	ErrCodePayloadTooLarge      = "payload_too_large"
+	ErrCodeUnsupportedMediaType = "unsupported_media_type"
+	ErrCodeValidationFailed     = "validation_failed"
+	ErrCodeInternal             = "internal_error"
+	ErrCodeUnavailable          = "unavailable"
+)
+
+var statusErrorCodes = map[int]string{
+	400: ErrCodeInvalidRequest,
+	404: ErrCodeNotFound,
+	409: ErrCodeConflict,
+	413: ErrCodePayloadTooLarge,
+	415: ErrCodeUnsupportedMediaType,
+	422: ErrCodeValidationFailed,
+	500: ErrCodeInternal,
+	503: ErrCodeUnavailable,
+}
+
+// ErrorCode returns the error code of a response with the given status,
+// an empty string if the status has none.
+func ErrorCode(status int) string {
+	return statusErrorCodes[status]
+}
+
 // Unsuccessful generic response to a failed API call
 type Error struct {
+	// Code classifies the error, see the ErrCode constants
+	Code         string            `json:"code,omitempty"`
 	ErrorMessage string            `json:"message"`
 	Fields       map[string]string `json:"fields"`
+	// RequestID is the ID of the failed request, set when written
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func FromSnapError(pe serror.SnapError) *Error {
@@ -64,6 +99,12 @@ func FromError(err error) *Error {
 	return e
 }
 
+// WithCode sets a more precise code than the one of the response status.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
 func (e *Error) Error() string {
 	return e.ErrorMessage
 }
@@ -0,0 +1,66 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbody
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestErrorCode(t *testing.T) {
+	Convey("Error codes follow the status of the response", t, func() {
+		So(ErrorCode(400), ShouldEqual, ErrCodeInvalidRequest)
+		So(ErrorCode(404), ShouldEqual, ErrCodeNotFound)
+		So(ErrorCode(409), ShouldEqual, ErrCodeConflict)
+		So(ErrorCode(415), ShouldEqual, ErrCodeUnsupportedMediaType)
+		So(ErrorCode(422), ShouldEqual, ErrCodeValidationFailed)
+		So(ErrorCode(503), ShouldEqual, ErrCodeUnavailable)
+		So(ErrorCode(200), ShouldBeEmpty)
+	})
+	Convey("Write sets the code of an error", t, func() {
+		write := func(code int, e *Error) map[string]interface{} {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			Write(code, e, rw)
+			So(rw.Status(), ShouldEqual, code)
+			var resp struct {
+				Body map[string]interface{} `json:"body"`
+			}
+			So(json.Unmarshal(rec.Body.Bytes(), &resp), ShouldBeNil)
+			return resp.Body
+		}
+		Convey("from the status of the response", func() {
+			body := write(422, FromError(errors.New("invalid schedule")))
+			So(body["code"], ShouldEqual, ErrCodeValidationFailed)
+			So(body["message"], ShouldEqual, "invalid schedule")
+		})
+		Convey("unless the handler gave one", func() {
+			body := write(409, FromError(errors.New("plugin is already loaded")).WithCode(ErrCodeAlreadyLoaded))
+			So(body["code"], ShouldEqual, ErrCodeAlreadyLoaded)
+		})
+	})
+}
@@ -22,6 +22,8 @@ package rbody
 import (
 	"fmt"
 	"strings"
+
+	"github.com/intelsdi-x/snap/core"
 )
 
 const (
@@ -89,14 +91,16 @@ func (p *PluginReturned) ResponseBodyType() string {
 }
 
 type LoadedPlugin struct {
-	Name            string        `json:"name"`
-	Version         int           `json:"version"`
-	Type            string        `json:"type"`
-	Signed          bool          `json:"signed"`
-	Status          string        `json:"status"`
-	LoadedTimestamp int64         `json:"loaded_timestamp"`
-	Href            string        `json:"href"`
-	ConfigPolicy    []PolicyTable `json:"policy,omitempty"`
+	Name            string               `json:"name"`
+	Version         int                  `json:"version"`
+	Type            string               `json:"type"`
+	Signed          bool                 `json:"signed"`
+	Status          string               `json:"status"`
+	LoadedTimestamp int64                `json:"loaded_timestamp"`
+	Href            string               `json:"href"`
+	ConfigPolicy    []PolicyTable        `json:"policy,omitempty"`
+	Metadata        *core.PluginMetadata `json:"metadata,omitempty"`
+	core.PluginInfo
 }
 
 type AvailablePlugin struct {
@@ -108,4 +112,9 @@ type AvailablePlugin struct {
 	ID               uint32 `json:"id"`
 	Href             string `json:"href"`
 	PprofPort        string `json:"pprof_port"`
+
+	FailedHealthChecks       int   `json:"failed_health_checks"`
+	LastHealthCheckTimestamp int64 `json:"last_health_check_timestamp"`
+
+	core.PluginResourceUsage
 }
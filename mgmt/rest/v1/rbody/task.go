@@ -40,6 +40,9 @@ const (
 	ScheduledTaskRemovedType       = "scheduled_task_removed"
 	ScheduledTaskWatchingEndedType = "schedule_task_watch_ended"
 	ScheduledTaskEnabledType       = "scheduled_task_enabled"
+	ScheduledTaskConfigPatchedType = "scheduled_task_config_patched"
+	ScheduledTaskUpdatedType       = "scheduled_task_updated"
+	ScheduledTaskWorkflowGraphType = "scheduled_task_workflow_graph"
 
 	// Event types for task watcher streaming
 	TaskWatchStreamOpen   = "stream-open"
@@ -109,6 +112,7 @@ func AddSchedulerTaskFromTask(t core.Task) *AddScheduledTask {
 		LastFailureMessage: t.LastFailureMessage(),
 		State:              t.State().String(),
 		Workflow:           t.WMap(),
+		ConfigHistory:      t.ConfigHistory(),
 	}
 	assertSchedule(t.Schedule(), st)
 	if st.LastRunTimestamp < 0 {
@@ -118,19 +122,20 @@ func AddSchedulerTaskFromTask(t core.Task) *AddScheduledTask {
 }
 
 type ScheduledTask struct {
-	ID                 string            `json:"id"`
-	Name               string            `json:"name"`
-	Deadline           string            `json:"deadline"`
-	Workflow           *wmap.WorkflowMap `json:"workflow,omitempty"`
-	Schedule           *core.Schedule    `json:"schedule,omitempty"`
-	CreationTimestamp  int64             `json:"creation_timestamp,omitempty"`
-	LastRunTimestamp   int64             `json:"last_run_timestamp,omitempty"`
-	HitCount           int               `json:"hit_count,omitempty"`
-	MissCount          int               `json:"miss_count,omitempty"`
-	FailedCount        int               `json:"failed_count,omitempty"`
-	LastFailureMessage string            `json:"last_failure_message,omitempty"`
-	State              string            `json:"task_state"`
-	Href               string            `json:"href"`
+	ID                 string                  `json:"id"`
+	Name               string                  `json:"name"`
+	Deadline           string                  `json:"deadline"`
+	Workflow           *wmap.WorkflowMap       `json:"workflow,omitempty"`
+	Schedule           *core.Schedule          `json:"schedule,omitempty"`
+	CreationTimestamp  int64                   `json:"creation_timestamp,omitempty"`
+	LastRunTimestamp   int64                   `json:"last_run_timestamp,omitempty"`
+	HitCount           int                     `json:"hit_count,omitempty"`
+	MissCount          int                     `json:"miss_count,omitempty"`
+	FailedCount        int                     `json:"failed_count,omitempty"`
+	LastFailureMessage string                  `json:"last_failure_message,omitempty"`
+	State              string                  `json:"task_state"`
+	Href               string                  `json:"href"`
+	ConfigHistory      []core.TaskConfigChange `json:"config_history,omitempty"`
 }
 
 func (s *ScheduledTask) CreationTime() time.Time {
@@ -215,6 +220,44 @@ func (s *ScheduledTaskEnabled) ResponseBodyType() string {
 	return ScheduledTaskEnabledType
 }
 
+type ScheduledTaskConfigPatched struct {
+	AddScheduledTask
+}
+
+func (s *ScheduledTaskConfigPatched) ResponseBodyMessage() string {
+	return fmt.Sprintf("Scheduled task (%s) config patched", s.AddScheduledTask.ID)
+}
+
+func (s *ScheduledTaskConfigPatched) ResponseBodyType() string {
+	return ScheduledTaskConfigPatchedType
+}
+
+type ScheduledTaskUpdated struct {
+	AddScheduledTask
+}
+
+func (s *ScheduledTaskUpdated) ResponseBodyMessage() string {
+	return fmt.Sprintf("Scheduled task (%s) updated", s.AddScheduledTask.ID)
+}
+
+func (s *ScheduledTaskUpdated) ResponseBodyType() string {
+	return ScheduledTaskUpdatedType
+}
+
+// ScheduledTaskWorkflowGraph describes the nodes of the workflow of a task
+type ScheduledTaskWorkflowGraph struct {
+	ID string `json:"id"`
+	core.WorkflowGraph
+}
+
+func (s *ScheduledTaskWorkflowGraph) ResponseBodyMessage() string {
+	return fmt.Sprintf("Scheduled task (%s) workflow graph returned", s.ID)
+}
+
+func (s *ScheduledTaskWorkflowGraph) ResponseBodyType() string {
+	return ScheduledTaskWorkflowGraphType
+}
+
 func assertSchedule(s schedule.Schedule, t *AddScheduledTask) {
 	switch v := s.(type) {
 	case *schedule.WindowedSchedule:
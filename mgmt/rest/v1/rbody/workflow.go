@@ -0,0 +1,128 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbody
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+const (
+	WorkflowValidationType    = "workflow_validation"
+	StoredWorkflowListType    = "stored_workflow_list_returned"
+	StoredWorkflowType        = "stored_workflow_returned"
+	AddStoredWorkflowType     = "stored_workflow_added"
+	StoredWorkflowRemovedType = "stored_workflow_removed"
+)
+
+type WorkflowValidationError struct {
+	Path   string `json:"path"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type WorkflowValidation struct {
+	Valid         bool                      `json:"valid"`
+	SchemaVersion int                       `json:"schema_version"`
+	Errors        []WorkflowValidationError `json:"errors,omitempty"`
+}
+
+func (v *WorkflowValidation) ResponseBodyMessage() string {
+	if v.Valid {
+		return "Workflow is valid"
+	}
+	return "Workflow is not valid"
+}
+
+func (v *WorkflowValidation) ResponseBodyType() string {
+	return WorkflowValidationType
+}
+
+type StoredWorkflow struct {
+	Name             string            `json:"name"`
+	Version          int               `json:"version"`
+	UpdatedTimestamp int64             `json:"updated_timestamp"`
+	Workflow         *wmap.WorkflowMap `json:"workflow,omitempty"`
+	TaskIDs          []string          `json:"task_ids"`
+	Href             string            `json:"href"`
+}
+
+func StoredWorkflowFromCore(w core.StoredWorkflow) StoredWorkflow {
+	sw := StoredWorkflow{
+		Name:             w.Name,
+		Version:          w.Version,
+		UpdatedTimestamp: w.Updated.Unix(),
+		Workflow:         w.Workflow,
+		TaskIDs:          w.TaskIDs,
+	}
+	if sw.TaskIDs == nil {
+		sw.TaskIDs = []string{}
+	}
+	return sw
+}
+
+func (s *StoredWorkflow) ResponseBodyMessage() string {
+	return fmt.Sprintf("Stored workflow (%s) returned", s.Name)
+}
+
+func (s *StoredWorkflow) ResponseBodyType() string {
+	return StoredWorkflowType
+}
+
+type StoredWorkflowList struct {
+	Workflows []StoredWorkflow `json:"workflows"`
+}
+
+func (s *StoredWorkflowList) ResponseBodyMessage() string {
+	return "Stored workflows retrieved"
+}
+
+func (s *StoredWorkflowList) ResponseBodyType() string {
+	return StoredWorkflowListType
+}
+
+type AddStoredWorkflow struct {
+	StoredWorkflow
+	// RollForwardErrors are the errors of tasks which could not be moved
+	// to the new version of the workflow
+	RollForwardErrors []string `json:"roll_forward_errors,omitempty"`
+}
+
+func (s *AddStoredWorkflow) ResponseBodyMessage() string {
+	return fmt.Sprintf("Workflow stored (%s version %d)", s.Name, s.Version)
+}
+
+func (s *AddStoredWorkflow) ResponseBodyType() string {
+	return AddStoredWorkflowType
+}
+
+type StoredWorkflowRemoved struct {
+	Name string `json:"name"`
+}
+
+func (s *StoredWorkflowRemoved) ResponseBodyMessage() string {
+	return fmt.Sprintf("Stored workflow (%s) removed", s.Name)
+}
+
+func (s *StoredWorkflowRemoved) ResponseBodyType() string {
+	return StoredWorkflowRemovedType
+}
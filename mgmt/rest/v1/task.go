@@ -20,16 +20,20 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
 	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -42,16 +46,72 @@ var (
 	ErrTaskDisabledNotRunnable = errors.New("Task is disabled. Cannot be started")
 	ErrNoActionSpecified       = errors.New("No action was specified in the request")
 	ErrWrongAction             = errors.New("Wrong action requested")
+	ErrTaskRevisionMismatch    = errors.New("Task was modified, its revision does not match the expected one")
+	ErrInvalidIfMatch          = errors.New("If-Match must be a task revision")
+	ErrInvalidGraphFormat      = errors.New("Workflow graph format must be json or dot")
+	ErrTaskAlreadyRunning      = errors.New("Task is already running")
+	ErrTaskAlreadyStopped      = errors.New("Task is already stopped")
+	ErrTaskNotStoppable        = errors.New("Only running tasks can be stopped")
+	ErrTaskNotStopped          = errors.New("Task must be stopped")
+	ErrTaskNotDisabled         = errors.New("Task must be disabled")
+	ErrSchedulerNotStarted     = errors.New("Scheduler is not started")
 )
 
+// taskStateErrors are returned for operations the task does not allow in
+// its current state.
+var taskStateErrors = []error{
+	ErrTaskDisabledNotRunnable,
+	ErrTaskAlreadyRunning,
+	ErrTaskAlreadyStopped,
+	ErrTaskNotStoppable,
+	ErrTaskNotStopped,
+	ErrTaskNotDisabled,
+}
+
+// taskErrorStatus returns the status of the response to a task operation
+// which failed with err, fallback when the error is not a known one.
+func taskErrorStatus(err error, fallback int) int {
+	msg := err.Error()
+	if strings.Contains(msg, ErrTaskNotFound.Error()) {
+		return 404
+	}
+	if strings.Contains(msg, ErrSchedulerNotStarted.Error()) {
+		return 503
+	}
+	if strings.Contains(msg, ErrTaskRevisionMismatch.Error()) {
+		return 409
+	}
+	for _, e := range taskStateErrors {
+		if strings.Contains(msg, e.Error()) {
+			return 409
+		}
+	}
+	return fallback
+}
+
+// taskCreationStatus returns the status of the response to a task creation
+// which failed with err. A malformed body is a bad request, otherwise the
+// task itself was rejected.
+func taskCreationStatus(err error) int {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return 400
+	}
+	if strings.Contains(err.Error(), ErrSchedulerNotStarted.Error()) {
+		return 503
+	}
+	return 422
+}
+
 func (s *apiV1) addTask(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	task, err := core.CreateTaskFromContent(r.Body, nil, s.taskManager.CreateTask)
 	if err != nil {
-		rbody.Write(500, rbody.FromError(err), w)
+		rbody.Write(taskCreationStatus(err), rbody.FromError(err), w)
 		return
 	}
 	taskB := rbody.AddSchedulerTaskFromTask(task)
 	taskB.Href = taskURI(r.Host, version, task)
+	setTaskETag(w, task)
 	rbody.Write(201, taskB, w)
 }
 
@@ -81,6 +141,7 @@ func (s *apiV1) getTask(w http.ResponseWriter, r *http.Request, p httprouter.Par
 	task := &rbody.ScheduledTaskReturned{}
 	task.AddScheduledTask = *rbody.AddSchedulerTaskFromTask(t)
 	task.Href = taskURI(r.Host, version, t)
+	setTaskETag(w, t)
 	rbody.Write(200, task, w)
 }
 
@@ -88,9 +149,10 @@ func (s *apiV1) watchTask(w http.ResponseWriter, r *http.Request, p httprouter.P
 	s.wg.Add(1)
 	defer s.wg.Done()
 	logger := log.WithFields(log.Fields{
-		"_module": "api",
-		"_block":  "watch-task",
-		"client":  r.RemoteAddr,
+		"_module":    "api",
+		"_block":     "watch-task",
+		"client":     r.RemoteAddr,
+		"request-id": r.Header.Get(api.RequestIDHeader),
 	})
 
 	id := p.ByName("id")
@@ -192,46 +254,47 @@ func (s *apiV1) watchTask(w http.ResponseWriter, r *http.Request, p httprouter.P
 
 func (s *apiV1) startTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
-	errs := s.taskManager.StartTask(id)
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	errs := s.taskManager.StartTaskIfRevision(id, rev)
 	if errs != nil {
-		if strings.Contains(errs[0].Error(), ErrTaskNotFound.Error()) {
-			rbody.Write(404, rbody.FromSnapErrors(errs), w)
-			return
-		}
-		if strings.Contains(errs[0].Error(), ErrTaskDisabledNotRunnable.Error()) {
-			rbody.Write(409, rbody.FromSnapErrors(errs), w)
-			return
-		}
-		rbody.Write(500, rbody.FromSnapErrors(errs), w)
+		rbody.Write(taskErrorStatus(errs[0], 500), rbody.FromSnapErrors(errs), w)
 		return
 	}
+	s.setTaskETagByID(w, id)
 	// TODO should return resource
 	rbody.Write(200, &rbody.ScheduledTaskStarted{ID: id}, w)
 }
 
 func (s *apiV1) stopTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
-	errs := s.taskManager.StopTask(id)
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	errs := s.taskManager.StopTaskIfRevision(id, rev)
 	if errs != nil {
-		if strings.Contains(errs[0].Error(), ErrTaskNotFound.Error()) {
-			rbody.Write(404, rbody.FromSnapErrors(errs), w)
-			return
-		}
-		rbody.Write(500, rbody.FromSnapErrors(errs), w)
+		rbody.Write(taskErrorStatus(errs[0], 500), rbody.FromSnapErrors(errs), w)
 		return
 	}
+	s.setTaskETagByID(w, id)
 	rbody.Write(200, &rbody.ScheduledTaskStopped{ID: id}, w)
 }
 
 func (s *apiV1) removeTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
-	err := s.taskManager.RemoveTask(id)
+	rev, err := ifMatchRevision(r)
 	if err != nil {
-		if strings.Contains(err.Error(), ErrTaskNotFound.Error()) {
-			rbody.Write(404, rbody.FromError(err), w)
-			return
-		}
-		rbody.Write(500, rbody.FromError(err), w)
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	err = s.taskManager.RemoveTaskIfRevision(id, rev)
+	if err != nil {
+		rbody.Write(taskErrorStatus(err, 500), rbody.FromError(err), w)
 		return
 	}
 	rbody.Write(200, &rbody.ScheduledTaskRemoved{ID: id}, w)
@@ -240,20 +303,116 @@ func (s *apiV1) removeTask(w http.ResponseWriter, r *http.Request, p httprouter.
 //enableTask changes the task state from Disabled to Stopped
 func (s *apiV1) enableTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
-	tsk, err := s.taskManager.EnableTask(id)
+	rev, err := ifMatchRevision(r)
 	if err != nil {
-		if strings.Contains(err.Error(), ErrTaskNotFound.Error()) {
-			rbody.Write(404, rbody.FromError(err), w)
-			return
-		}
-		rbody.Write(500, rbody.FromError(err), w)
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	tsk, err := s.taskManager.EnableTaskIfRevision(id, rev)
+	if err != nil {
+		rbody.Write(taskErrorStatus(err, 500), rbody.FromError(err), w)
 		return
 	}
 	task := &rbody.ScheduledTaskEnabled{}
 	task.AddScheduledTask = *rbody.AddSchedulerTaskFromTask(tsk)
+	setTaskETag(w, tsk)
 	rbody.Write(200, task, w)
 }
 
+// patchTaskConfig overrides config items of the workflow of a task
+func (s *apiV1) patchTaskConfig(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	patch := &wmap.ConfigPatch{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	tsk, errs := s.taskManager.PatchTaskConfig(id, rev, patch)
+	if errs != nil {
+		rbody.Write(taskErrorStatus(errs[0], 422), rbody.FromSnapErrors(errs), w)
+		return
+	}
+	task := &rbody.ScheduledTaskConfigPatched{}
+	task.AddScheduledTask = *rbody.AddSchedulerTaskFromTask(tsk)
+	task.Href = taskURI(r.Host, version, tsk)
+	setTaskETag(w, tsk)
+	rbody.Write(200, task, w)
+}
+
+// updateTask replaces the schedule, workflow or deadline of a task
+func (s *apiV1) updateTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	update, err := core.TaskUpdateFromContent(r.Body)
+	if err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	tsk, errs := s.taskManager.UpdateTask(id, rev, update)
+	if errs != nil {
+		rbody.Write(taskErrorStatus(errs[0], 422), rbody.FromSnapErrors(errs), w)
+		return
+	}
+	task := &rbody.ScheduledTaskUpdated{}
+	task.AddScheduledTask = *rbody.AddSchedulerTaskFromTask(tsk)
+	task.Href = taskURI(r.Host, version, tsk)
+	setTaskETag(w, tsk)
+	rbody.Write(200, task, w)
+}
+
+func (s *apiV1) getTaskWorkflowGraph(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	t, err := s.taskManager.GetTask(id)
+	if err != nil {
+		rbody.Write(404, rbody.FromError(err), w)
+		return
+	}
+	graph := t.WorkflowGraph()
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		rbody.Write(200, &rbody.ScheduledTaskWorkflowGraph{ID: t.ID(), WorkflowGraph: *graph}, w)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(200)
+		fmt.Fprint(w, graph.DOT())
+	default:
+		rbody.Write(400, rbody.FromError(ErrInvalidGraphFormat), w)
+	}
+}
+
+// ifMatchRevision returns the task revision expected by the If-Match header
+// of the request, 0 matches any revision.
+func ifMatchRevision(r *http.Request) (uint64, error) {
+	v := strings.Trim(strings.TrimPrefix(r.Header.Get("If-Match"), "W/"), `"`)
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	rev, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	return rev, nil
+}
+
+func setTaskETag(w http.ResponseWriter, t core.Task) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, t.Revision()))
+}
+
+func (s *apiV1) setTaskETagByID(w http.ResponseWriter, id string) {
+	if t, err := s.taskManager.GetTask(id); err == nil {
+		setTaskETag(w, t)
+	}
+}
+
 type TaskWatchHandler struct {
 	streamCount int
 	alive       bool
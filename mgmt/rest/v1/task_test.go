@@ -0,0 +1,52 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskErrorStatus(t *testing.T) {
+	Convey("Task operation errors map to their status", t, func() {
+		So(taskErrorStatus(errors.New("Task not found: ID(1234)"), 500), ShouldEqual, 404)
+		So(taskErrorStatus(errors.New("Task was modified, its revision does not match the expected one."), 500), ShouldEqual, 409)
+		So(taskErrorStatus(errors.New("Task is already running."), 500), ShouldEqual, 409)
+		So(taskErrorStatus(errors.New("Task is ended. Only running tasks can be stopped."), 500), ShouldEqual, 409)
+		So(taskErrorStatus(errors.New("Task must be stopped"), 500), ShouldEqual, 409)
+		So(taskErrorStatus(errors.New("Scheduler is not started."), 500), ShouldEqual, 503)
+		Convey("falling back for unknown errors", func() {
+			So(taskErrorStatus(errors.New("boom"), 500), ShouldEqual, 500)
+			So(taskErrorStatus(errors.New("Metric not found: /intel/mock/foo"), 422), ShouldEqual, 422)
+		})
+	})
+	Convey("Task creation errors map to their status", t, func() {
+		var v map[string]interface{}
+		err := json.Unmarshal([]byte("{"), &v)
+		So(taskCreationStatus(err), ShouldEqual, 400)
+		So(taskCreationStatus(errors.New("Task must include a schedule, and the schedule must not be empty")), ShouldEqual, 422)
+		So(taskCreationStatus(errors.New("Scheduler is not started.")), ShouldEqual, 503)
+	})
+}
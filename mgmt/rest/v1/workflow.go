@@ -0,0 +1,168 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ErrWorkflowNotFound   = errors.New("Workflow not found")
+	ErrWorkflowInUse      = errors.New("Workflow is referenced by tasks")
+	ErrWorkflowMissing    = errors.New("Workflow request must include a workflow")
+	ErrRollForwardInvalid = errors.New("roll_forward must be a boolean")
+	// ErrWorkflowNameReserved is returned when storing a workflow under the
+	// name the workflow map JSON Schema is served at
+	ErrWorkflowNameReserved = fmt.Errorf("Workflow name '%s' is reserved", workflowSchemaName)
+)
+
+// workflowSchemaName is the path segment the workflow map JSON Schema is
+// served at, under /workflows
+const workflowSchemaName = "schema"
+
+func (s *apiV1) validateWorkflow(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		rbody.Write(500, rbody.FromError(err), w)
+		return
+	}
+	errs := wmap.Validate(b)
+	v := &rbody.WorkflowValidation{
+		Valid:         len(errs) == 0,
+		SchemaVersion: wmap.SchemaVersion,
+	}
+	for _, e := range errs {
+		v.Errors = append(v.Errors, rbody.WorkflowValidationError{
+			Path:   e.Path,
+			Field:  e.Field,
+			Reason: e.Reason,
+		})
+	}
+	rbody.Write(200, v, w)
+}
+
+type storeWorkflowRequest struct {
+	Name     string            `json:"name"`
+	Workflow *wmap.WorkflowMap `json:"workflow"`
+}
+
+func (s *apiV1) addWorkflow(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var rollForward bool
+	if v := r.URL.Query().Get("roll_forward"); v != "" {
+		var err error
+		if rollForward, err = strconv.ParseBool(v); err != nil {
+			rbody.Write(400, rbody.FromError(ErrRollForwardInvalid), w)
+			return
+		}
+	}
+	req := &storeWorkflowRequest{}
+	if code, err := core.UnmarshalBody(req, r.Body); err != nil {
+		rbody.Write(code, rbody.FromError(err), w)
+		return
+	}
+	if req.Workflow == nil {
+		rbody.Write(400, rbody.FromError(ErrWorkflowMissing), w)
+		return
+	}
+	if req.Name == workflowSchemaName {
+		rbody.Write(400, rbody.FromError(ErrWorkflowNameReserved), w)
+		return
+	}
+	stored, errs := s.taskManager.AddWorkflow(req.Name, req.Workflow, rollForward)
+	if stored.Name == "" {
+		rbody.Write(400, rbody.FromSnapErrors(errs), w)
+		return
+	}
+	body := &rbody.AddStoredWorkflow{StoredWorkflow: rbody.StoredWorkflowFromCore(stored)}
+	body.Href = workflowURI(r.Host, version, stored.Name)
+	for _, e := range errs {
+		body.RollForwardErrors = append(body.RollForwardErrors, fmt.Sprintf("%v (task %v)", e.Error(), e.Fields()["task-id"]))
+	}
+	rbody.Write(201, body, w)
+}
+
+func (s *apiV1) getWorkflows(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	body := &rbody.StoredWorkflowList{Workflows: []rbody.StoredWorkflow{}}
+	for _, stored := range s.taskManager.GetWorkflows() {
+		sw := rbody.StoredWorkflowFromCore(stored)
+		sw.Workflow = nil
+		sw.Href = workflowURI(r.Host, version, stored.Name)
+		body.Workflows = append(body.Workflows, sw)
+	}
+	rbody.Write(200, body, w)
+}
+
+func (s *apiV1) getWorkflow(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// the router cannot tell /workflows/schema from a workflow name
+	if p.ByName("name") == workflowSchemaName {
+		s.getWorkflowSchema(w, r)
+		return
+	}
+	stored, err := s.taskManager.GetWorkflow(p.ByName("name"))
+	if err != nil {
+		if strings.Contains(err.Error(), ErrWorkflowNotFound.Error()) {
+			rbody.Write(404, rbody.FromError(err), w)
+			return
+		}
+		rbody.Write(500, rbody.FromError(err), w)
+		return
+	}
+	body := rbody.StoredWorkflowFromCore(stored)
+	body.Href = workflowURI(r.Host, version, stored.Name)
+	rbody.Write(200, &body, w)
+}
+
+// getWorkflowSchema writes the JSON Schema of workflow maps as is, rather than
+// wrapped in a response body, so it can be given to JSON Schema validators.
+func (s *apiV1) getWorkflowSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(200)
+	w.Write(wmap.SchemaJSON())
+}
+
+func (s *apiV1) removeWorkflow(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	name := p.ByName("name")
+	if err := s.taskManager.RemoveWorkflow(name); err != nil {
+		switch {
+		case strings.Contains(err.Error(), ErrWorkflowNotFound.Error()):
+			rbody.Write(404, rbody.FromError(err), w)
+		case strings.Contains(err.Error(), ErrWorkflowInUse.Error()):
+			rbody.Write(409, rbody.FromError(err), w)
+		default:
+			rbody.Write(500, rbody.FromError(err), w)
+		}
+		return
+	}
+	rbody.Write(200, &rbody.StoredWorkflowRemoved{Name: name}, w)
+}
+
+func workflowURI(host, version, name string) string {
+	return fmt.Sprintf("%s://%s/%s/workflows/%s", protocolPrefix, host, version, name)
+}
@@ -20,6 +20,7 @@ limitations under the License.
 package v2
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"sync"
 
@@ -44,6 +45,13 @@ type apiV2 struct {
 	metricManager api.Metrics
 	taskManager   api.Tasks
 	configManager api.Config
+	uploads       *pluginUploads
+	maxUploadSize int64
+	// pluginRepository is the URL of the index of the plugin repository
+	pluginRepository string
+	// inventoryKey signs the inventory of the loaded plugins, none being
+	// set when the REST API does not serve HTTPS
+	inventoryKey *tls.Certificate
 
 	wg       *sync.WaitGroup
 	killChan chan struct{}
@@ -51,28 +59,47 @@ type apiV2 struct {
 
 func New(wg *sync.WaitGroup, killChan chan struct{}, protocol string) *apiV2 {
 	protocolPrefix = protocol
-	return &apiV2{wg: wg, killChan: killChan}
+	return &apiV2{wg: wg, killChan: killChan, uploads: newPluginUploads()}
 }
 
 func (s *apiV2) GetRoutes() []api.Route {
 	routes := []api.Route{
 		// plugin routes
 		api.Route{Method: "GET", Path: prefix + "/plugins", Handle: s.getPlugins},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type", Handle: s.getPluginsByType},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name", Handle: s.getPluginsByName},
 		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version", Handle: s.getPlugin},
-		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/binary", Handle: s.getPluginBinary, Role: api.RoleOperator},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/logs", Handle: s.getPluginLogs},
+		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/crashes", Handle: s.getPluginCrashes},
+		api.Route{Method: "POST", Path: prefix + "/plugins", Handle: s.loadPlugin, Role: api.RoleAdmin},
+		api.Route{Method: "POST", Path: prefix + "/plugins/:type", Handle: s.postPluginAction, Role: api.RoleAdmin},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name", Handle: s.unloadPluginVersions, Role: api.RoleAdmin},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version", Handle: s.unloadPlugin, Role: api.RoleAdmin},
+		api.Route{Method: "POST", Path: prefix + "/plugins/:type/:name/swap", Handle: s.swapPlugin, Role: api.RoleAdmin},
 
 		api.Route{Method: "GET", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.getPluginConfigItem},
-		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem},
-		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem},
+		api.Route{Method: "PUT", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.setPluginConfigItem, Role: api.RoleAdmin},
+		api.Route{Method: "DELETE", Path: prefix + "/plugins/:type/:name/:version/config", Handle: s.deletePluginConfigItem, Role: api.RoleAdmin},
+
+		// resumable plugin upload routes
+		api.Route{Method: "POST", Path: prefix + "/uploads", Handle: s.createUpload, Role: api.RoleAdmin},
+		api.Route{Method: "GET", Path: prefix + "/uploads/:id", Handle: s.getUpload, Role: api.RoleAdmin},
+		api.Route{Method: "PATCH", Path: prefix + "/uploads/:id", Handle: s.appendUpload, Role: api.RoleAdmin},
+		api.Route{Method: "POST", Path: prefix + "/uploads/:id/commit", Handle: s.commitUpload, Role: api.RoleAdmin},
+		api.Route{Method: "DELETE", Path: prefix + "/uploads/:id", Handle: s.deleteUpload, Role: api.RoleAdmin},
 
 		// metric routes
 		api.Route{Method: "GET", Path: prefix + "/metrics", Handle: s.getMetrics},
+		api.Route{Method: "GET", Path: prefix + "/metrics/*namespace", Handle: s.getMetricsFromTree},
+		api.Route{Method: "POST", Path: prefix + "/metrics/collect", Handle: s.collectMetrics},
 
 		// task routes
 		api.Route{Method: "GET", Path: prefix + "/tasks", Handle: s.getTasks},
 		api.Route{Method: "GET", Path: prefix + "/tasks/:id", Handle: s.getTask},
 		api.Route{Method: "GET", Path: prefix + "/tasks/:id/watch", Handle: s.watchTask},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/errors", Handle: s.getTaskErrors},
+		api.Route{Method: "GET", Path: prefix + "/tasks/:id/stats", Handle: s.getTaskStats},
 		api.Route{Method: "POST", Path: prefix + "/tasks", Handle: s.addTask},
 		api.Route{Method: "PUT", Path: prefix + "/tasks/:id", Handle: s.updateTaskState},
 		api.Route{Method: "DELETE", Path: prefix + "/tasks/:id", Handle: s.removeTask},
@@ -97,6 +124,9 @@ func (s *apiV2) BindConfigManager(configManager api.Config) {
 func Write(code int, body interface{}, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; version=2; charset=utf-8")
 	w.Header().Set("Version", "beta")
+	if e, ok := body.(*Error); ok && e.RequestID == "" {
+		e.RequestID = w.Header().Get(api.RequestIDHeader)
+	}
 
 	if !w.(negroni.ResponseWriter).Written() {
 		w.WriteHeader(code)
@@ -31,6 +31,7 @@ const (
 	ErrPluginAlreadyLoaded     = "plugin is already loaded"
 	ErrTaskNotFound            = "task not found"
 	ErrTaskDisabledNotRunnable = "task is disabled"
+	ErrTaskRevisionMismatch    = "revision does not match"
 )
 
 var (
@@ -38,12 +39,17 @@ var (
 	ErrStreamingUnsupported = errors.New("streaming unsupported")
 	ErrNoActionSpecified    = errors.New("no action was specified in the request")
 	ErrWrongAction          = errors.New("wrong action requested")
+	ErrInvalidIfMatch       = errors.New("If-Match must be a task revision")
+	ErrInvalidLimit         = errors.New("limit must be a non-negative integer")
+	ErrNoPluginFile         = errors.New("no plugin file was sent")
 )
 
 // Unsuccessful generic response to a failed API call
 type Error struct {
 	ErrorMessage string            `json:"message"`
 	Fields       map[string]string `json:"fields"`
+	// RequestID is the ID of the failed request, set when written
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func FromSnapError(pe serror.SnapError) *Error {
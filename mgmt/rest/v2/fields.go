@@ -0,0 +1,108 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// ErrUnknownField is returned when the fields query parameter names a field
+// the listed items do not have.
+var ErrUnknownField = errors.New("unknown field")
+
+// requestedFields returns the fields of the listed items requested with the
+// fields query parameter, e.g. ?fields=name,version,status, nil when every
+// field is wanted.
+func requestedFields(r *http.Request) []string {
+	q := r.URL.Query().Get("fields")
+	if q == "" {
+		return nil
+	}
+	var fields []string
+	for _, f := range strings.Split(q, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// writeSparseList writes the items, a slice of structs, under key keeping
+// only the given JSON fields of each item.
+func writeSparseList(w http.ResponseWriter, key string, items interface{}, fields []string) {
+	known := jsonFieldNames(reflect.TypeOf(items).Elem())
+	for _, f := range fields {
+		if !known[f] {
+			Write(400, FromSnapError(serror.New(ErrUnknownField, map[string]interface{}{"field": f})), w)
+			return
+		}
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	// numbers are kept as json.Number to be written back unchanged
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var full []map[string]interface{}
+	if err := d.Decode(&full); err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	list := make([]map[string]interface{}, len(full))
+	for i, item := range full {
+		list[i] = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if v, ok := item[f]; ok {
+				list[i][f] = v
+			}
+		}
+	}
+	Write(200, map[string]interface{}{key: list}, w)
+}
+
+// jsonFieldNames returns the names the exported fields of the struct type t
+// are marshalled to.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestRequestedFields(t *testing.T) {
+	Convey("Fields are read from the fields query parameter", t, func() {
+		So(requestedFields(httptest.NewRequest("GET", "/v2/plugins", nil)), ShouldBeNil)
+		So(requestedFields(httptest.NewRequest("GET", "/v2/plugins?fields=name,+version,,status", nil)),
+			ShouldResemble, []string{"name", "version", "status"})
+	})
+}
+
+func TestSparsePluginList(t *testing.T) {
+	Convey("Given plugins listed with some of their fields", t, func() {
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		list := func(query string) (int, map[string][]map[string]interface{}) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			s.getPlugins(rw, httptest.NewRequest("GET", "/v2/plugins"+query, nil), nil)
+			var body map[string][]map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			return rw.Status(), body
+		}
+
+		Convey("only the fields requested are returned", func() {
+			code, body := list("?fields=name,version")
+			So(code, ShouldEqual, 200)
+			So(body["plugins"], ShouldNotBeEmpty)
+			for _, p := range body["plugins"] {
+				So(p, ShouldContainKey, "name")
+				So(p, ShouldContainKey, "version")
+				So(len(p), ShouldEqual, 2)
+			}
+		})
+		Convey("every field is returned without the parameter", func() {
+			code, body := list("")
+			So(code, ShouldEqual, 200)
+			So(body["plugins"][0], ShouldContainKey, "href")
+		})
+		Convey("an unknown field is refused", func() {
+			code, _ := list("?fields=name,color")
+			So(code, ShouldEqual, 400)
+		})
+	})
+}
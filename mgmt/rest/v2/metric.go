@@ -49,6 +49,15 @@ type Metric struct {
 	Unit                    string           `json:"unit,omitempty"`
 	Policy                  PolicyTableSlice `json:"policy,omitempty"`
 	Href                    string           `json:"href"`
+	// Conflicts are the other plugins advertising the namespace of the
+	// metric
+	Conflicts []core.NamespaceConflict `json:"conflicts,omitempty"`
+}
+
+// conflictingMetric is implemented by the cataloged metrics which record
+// the other plugins advertising their namespace.
+type conflictingMetric interface {
+	Conflicts() []core.NamespaceConflict
 }
 
 type DynamicElement struct {
@@ -99,7 +108,7 @@ func (s *apiV2) getMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.
 			Write(404, FromError(err), w)
 			return
 		}
-		respondWithMetrics(r.Host, mts, w)
+		respondWithMetrics(r, mts, w)
 		return
 	}
 
@@ -108,10 +117,53 @@ func (s *apiV2) getMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.
 		Write(500, FromError(err), w)
 		return
 	}
-	respondWithMetrics(r.Host, mts, w)
+	respondWithMetrics(r, mts, w)
 }
 
-func respondWithMetrics(host string, mts []core.CatalogedMetric, w http.ResponseWriter) {
+// getMetricsFromTree returns the metrics of the catalog under the namespace
+// given in the path, e.g. /v2/metrics/intel/mock/*, in all their versions or
+// in the one given by ?ver=.
+func (s *apiV2) getMetricsFromTree(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	namespace := params.ByName("namespace")
+	// GET /v2/metrics/ behaves as GET /v2/metrics
+	if len(namespace) <= 1 {
+		s.getMetrics(w, r, params)
+		return
+	}
+	ns := parseNamespace(namespace)
+
+	ver := 0 // 0: get all versions
+	if v := r.URL.Query().Get("ver"); v != "" {
+		var err error
+		ver, err = strconv.Atoi(v)
+		if err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+
+	if ns[len(ns)-1] == "*" {
+		ns = ns[:len(ns)-1]
+	} else if ver > 0 {
+		// an explicit version of a single metric
+		mt, err := s.metricManager.GetMetric(core.NewNamespace(ns...), ver)
+		if err != nil {
+			Write(404, FromError(err), w)
+			return
+		}
+		respondWithMetrics(r, []core.CatalogedMetric{mt}, w)
+		return
+	}
+
+	mts, err := s.metricManager.FetchMetrics(core.NewNamespace(ns...), ver)
+	if err != nil {
+		Write(404, FromError(err), w)
+		return
+	}
+	respondWithMetrics(r, mts, w)
+}
+
+func respondWithMetrics(r *http.Request, mts []core.CatalogedMetric, w http.ResponseWriter) {
 	b := MetricsResonse{Metrics: make(Metrics, 0)}
 	for _, m := range mts {
 		policies := PolicyTableSlice(m.Policy().RulesAsTable())
@@ -125,10 +177,17 @@ func respondWithMetrics(host string, mts []core.CatalogedMetric, w http.Response
 			DynamicElements:         getDynamicElements(m.Namespace(), indexes),
 			Unit:                    m.Unit(),
 			Policy:                  policies,
-			Href:                    catalogedMetricURI(host, m),
+			Href:                    catalogedMetricURI(r.Host, m),
 		})
+		if c, ok := m.(conflictingMetric); ok {
+			b.Metrics[len(b.Metrics)-1].Conflicts = c.Conflicts()
+		}
 	}
 	sort.Sort(b.Metrics)
+	if fields := requestedFields(r); fields != nil {
+		writeSparseList(w, "metrics", b.Metrics, fields)
+		return
+	}
 	Write(200, b, w)
 }
 
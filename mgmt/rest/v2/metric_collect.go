@@ -0,0 +1,141 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+var (
+	// ErrNoMetricsToCollect is returned when a collection requests no metrics
+	ErrNoMetricsToCollect = errors.New("at least one metric must be requested")
+	// ErrEmptyNamespace is returned when a requested metric has no namespace
+	ErrEmptyNamespace = errors.New("metric namespace must not be empty")
+	// ErrCollectUnsupported is returned when the metric manager cannot
+	// collect metrics outside of a task
+	ErrCollectUnsupported = errors.New("collecting metrics on demand is not supported")
+)
+
+// collectsOnce is implemented by metric managers able to collect metrics
+// outside of a task.
+type collectsOnce interface {
+	CollectOnce([]core.RequestedMetric, *cdata.ConfigDataTree) ([]core.Metric, []serror.SnapError, []error)
+}
+
+// CollectRequest lists the metrics to collect on demand and the config of
+// their collectors, keyed by namespace prefix as in the collect node of a
+// task workflow.
+type CollectRequest struct {
+	Metrics []RequestedMetric                 `json:"metrics"`
+	Config  map[string]map[string]interface{} `json:"config,omitempty"`
+}
+
+// RequestedMetric is a metric to collect, its latest version when Version
+// is 0
+type RequestedMetric struct {
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version,omitempty"`
+}
+
+type requestedMetric struct {
+	namespace core.Namespace
+	version   int
+}
+
+func (m *requestedMetric) Namespace() core.Namespace {
+	return m.namespace
+}
+
+func (m *requestedMetric) Version() int {
+	return m.version
+}
+
+// MetricsCollectedResponse holds the metrics collected on demand
+type MetricsCollectedResponse struct {
+	Metrics StreamedMetrics `json:"metrics"`
+}
+
+// collectMetrics collects the requested metrics once, returning their values
+// so that the config of collectors can be checked before building a task.
+func (s *apiV2) collectMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	c, ok := s.metricManager.(collectsOnce)
+	if !ok {
+		Write(501, FromError(ErrCollectUnsupported), w)
+		return
+	}
+	req := &CollectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	if len(req.Metrics) == 0 {
+		Write(400, FromError(ErrNoMetricsToCollect), w)
+		return
+	}
+	requested := make([]core.RequestedMetric, len(req.Metrics))
+	for i, m := range req.Metrics {
+		if m.Namespace == "" {
+			Write(400, FromError(ErrEmptyNamespace), w)
+			return
+		}
+		requested[i] = &requestedMetric{namespace: core.NewNamespace(parseNamespace(m.Namespace)...), version: m.Version}
+	}
+	cnode := &wmap.CollectWorkflowMapNode{Config: req.Config}
+	configTree, err := cnode.GetConfigTree()
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+
+	mts, serrs, errs := c.CollectOnce(requested, configTree)
+	if len(serrs) > 0 {
+		Write(422, FromSnapErrors(serrs), w)
+		return
+	}
+	if len(errs) > 0 {
+		serrs = make([]serror.SnapError, len(errs))
+		for i, err := range errs {
+			serrs[i] = serror.New(err)
+		}
+		Write(502, FromSnapErrors(serrs), w)
+		return
+	}
+	b := MetricsCollectedResponse{Metrics: make(StreamedMetrics, len(mts))}
+	for i, m := range mts {
+		b.Metrics[i] = StreamedMetric{
+			Namespace: m.Namespace().String(),
+			Data:      m.Data(),
+			Timestamp: m.Timestamp(),
+			Tags:      m.Tags(),
+		}
+	}
+	sort.Sort(b.Metrics)
+	Write(200, b, w)
+}
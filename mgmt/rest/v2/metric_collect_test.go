@@ -0,0 +1,100 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cplugin "github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+// collectingMetricManager collects the metric /intel/mock/foo once its
+// password is configured
+type collectingMetricManager struct {
+	mock.MockManagesMetrics
+}
+
+func (m collectingMetricManager) CollectOnce(requested []core.RequestedMetric, cdt *cdata.ConfigDataTree) ([]core.Metric, []serror.SnapError, []error) {
+	var mts []core.Metric
+	for _, r := range requested {
+		if r.Namespace().String() != "/intel/mock/foo" {
+			return nil, []serror.SnapError{serror.New(errors.New("Metric not found: " + r.Namespace().String()))}, nil
+		}
+		cfg := cdt.Get([]string{"intel", "mock", "foo"})
+		if cfg == nil || cfg.Table()["password"] == nil {
+			return nil, nil, []error{errors.New("password is not set")}
+		}
+		mts = append(mts, cplugin.MetricType{Namespace_: r.Namespace(), Data_: 42})
+	}
+	return mts, nil, nil
+}
+
+func TestCollectMetrics(t *testing.T) {
+	Convey("Given a metric manager collecting metrics on demand", t, func() {
+		s := &apiV2{metricManager: collectingMetricManager{}}
+		collect := func(body string) (int, *MetricsCollectedResponse) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			s.collectMetrics(rw, httptest.NewRequest("POST", "/v2/metrics/collect", strings.NewReader(body)), nil)
+			resp := &MetricsCollectedResponse{}
+			json.Unmarshal(rec.Body.Bytes(), resp)
+			return rw.Status(), resp
+		}
+
+		Convey("the values of the metrics are returned", func() {
+			code, resp := collect(`{"metrics": [{"namespace": "/intel/mock/foo"}], "config": {"/intel/mock": {"password": "secret"}}}`)
+			So(code, ShouldEqual, 200)
+			So(resp.Metrics, ShouldHaveLength, 1)
+			So(resp.Metrics[0].Namespace, ShouldEqual, "/intel/mock/foo")
+			So(resp.Metrics[0].Data, ShouldEqual, float64(42))
+		})
+		Convey("a failed collection is a bad gateway", func() {
+			code, _ := collect(`{"metrics": [{"namespace": "/intel/mock/foo"}]}`)
+			So(code, ShouldEqual, 502)
+		})
+		Convey("unknown metrics are refused", func() {
+			code, _ := collect(`{"metrics": [{"namespace": "/intel/mock/bar"}]}`)
+			So(code, ShouldEqual, 422)
+		})
+		Convey("a collection without metrics is refused", func() {
+			code, _ := collect(`{"metrics": []}`)
+			So(code, ShouldEqual, 400)
+		})
+	})
+	Convey("Given a metric manager without on demand collection", t, func() {
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		rw := negroni.NewResponseWriter(httptest.NewRecorder())
+		s.collectMetrics(rw, httptest.NewRequest("POST", "/v2/metrics/collect", strings.NewReader(`{}`)), nil)
+		So(rw.Status(), ShouldEqual, 501)
+	})
+}
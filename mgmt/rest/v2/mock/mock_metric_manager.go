@@ -62,10 +62,18 @@ func (m MockLoadedPlugin) LoadedTimestamp() *time.Time {
 	t := time.Date(2016, time.September, 6, 0, 0, 0, 0, time.UTC)
 	return &t
 }
-func (m MockLoadedPlugin) Policy() *cpolicy.ConfigPolicy { return cpolicy.New() }
-func (m MockLoadedPlugin) HitCount() int                 { return 0 }
-func (m MockLoadedPlugin) LastHit() time.Time            { return time.Now() }
-func (m MockLoadedPlugin) ID() uint32                    { return 0 }
+func (m MockLoadedPlugin) Policy() *cpolicy.ConfigPolicy  { return cpolicy.New() }
+func (m MockLoadedPlugin) Metadata() *core.PluginMetadata { return nil }
+func (m MockLoadedPlugin) Info() core.PluginInfo          { return core.PluginInfo{} }
+func (m MockLoadedPlugin) HitCount() int                  { return 0 }
+func (m MockLoadedPlugin) LastHit() time.Time {
+	return time.Date(2016, time.September, 6, 1, 0, 0, 0, time.UTC)
+}
+func (m MockLoadedPlugin) ID() uint32              { return 0 }
+func (m MockLoadedPlugin) FailedHealthChecks() int { return 0 }
+func (m MockLoadedPlugin) LastHealthCheck() time.Time {
+	return time.Date(2016, time.September, 6, 1, 0, 5, 0, time.UTC)
+}
 
 //////MockCatalogedMetric/////
 
@@ -240,7 +248,21 @@ const (
   "signed": false,
   "status": "",
   "loaded_timestamp": 1473120000,
-  "href": "http://localhost:%d/v2/plugins/publisher/bar/3"
+  "href": "http://localhost:%d/v2/plugins/publisher/bar/3",
+  "running_instances": [
+    {
+      "name": "bar",
+      "version": 3,
+      "type": "publisher",
+      "hitcount": 0,
+      "last_hit_timestamp": 1473123600,
+      "id": 0,
+      "href": "http://localhost:%d/v2/plugins/publisher/bar/3",
+      "pprof_port": "",
+      "failed_health_checks": 0,
+      "last_health_check_timestamp": 1473123605
+    }
+  ]
 }
 `
 
@@ -1,3 +1,4 @@
+//go:build legacy || small || medium || large
 // +build legacy small medium large
 
 /*
@@ -67,26 +68,46 @@ type mockTask struct {
 	MyHref               string            `json:"href"`
 }
 
-func (t *mockTask) ID() string                          { return t.MyID }
-func (t *mockTask) State() core.TaskState               { return core.TaskSpinning }
-func (t *mockTask) HitCount() uint                      { return 0 }
-func (t *mockTask) GetName() string                     { return t.MyName }
-func (t *mockTask) SetName(string)                      { return }
-func (t *mockTask) SetID(string)                        { return }
-func (t *mockTask) MissedCount() uint                   { return 0 }
-func (t *mockTask) FailedCount() uint                   { return 0 }
-func (t *mockTask) LastFailureMessage() string          { return "" }
-func (t *mockTask) LastRunTime() *time.Time             { return &time.Time{} }
-func (t *mockTask) CreationTime() *time.Time            { return &time.Time{} }
-func (t *mockTask) DeadlineDuration() time.Duration     { return 4 }
-func (t *mockTask) SetDeadlineDuration(time.Duration)   { return }
-func (t *mockTask) SetTaskID(id string)                 { return }
-func (t *mockTask) SetStopOnFailure(int)                { return }
-func (t *mockTask) GetStopOnFailure() int               { return 0 }
-func (t *mockTask) MaxCollectDuration() time.Duration   { return time.Second }
-func (t *mockTask) SetMaxCollectDuration(time.Duration) {}
-func (t *mockTask) MaxMetricsBuffer() int64             { return 0 }
-func (t *mockTask) SetMaxMetricsBuffer(int64)           {}
+func (t *mockTask) ID() string                             { return t.MyID }
+func (t *mockTask) State() core.TaskState                  { return core.TaskSpinning }
+func (t *mockTask) HitCount() uint                         { return 0 }
+func (t *mockTask) GetName() string                        { return t.MyName }
+func (t *mockTask) SetName(string)                         { return }
+func (t *mockTask) SetID(string)                           { return }
+func (t *mockTask) MissedCount() uint                      { return 0 }
+func (t *mockTask) FailedCount() uint                      { return 0 }
+func (t *mockTask) LastFailureMessage() string             { return "" }
+func (t *mockTask) LastRunTime() *time.Time                { return &time.Time{} }
+func (t *mockTask) CreationTime() *time.Time               { return &time.Time{} }
+func (t *mockTask) DeadlineDuration() time.Duration        { return 4 }
+func (t *mockTask) SetDeadlineDuration(time.Duration)      { return }
+func (t *mockTask) SetTaskID(id string)                    { return }
+func (t *mockTask) SetStopOnFailure(int)                   { return }
+func (t *mockTask) GetStopOnFailure() int                  { return 0 }
+func (t *mockTask) MaxCollectDuration() time.Duration      { return time.Second }
+func (t *mockTask) SetMaxCollectDuration(time.Duration)    {}
+func (t *mockTask) MaxMetricsBuffer() int64                { return 0 }
+func (t *mockTask) SetMaxMetricsBuffer(int64)              {}
+func (t *mockTask) MetricsThreshold() float64              { return 0 }
+func (t *mockTask) SetMetricsThreshold(float64)            {}
+func (t *mockTask) Revision() uint64                       { return 1 }
+func (t *mockTask) ConfigHistory() []core.TaskConfigChange { return nil }
+func (t *mockTask) WorkflowGraph() *core.WorkflowGraph     { return &core.WorkflowGraph{} }
+func (t *mockTask) Stats() core.TaskStats {
+	return core.TaskStats{
+		HitCount:       uint(t.MyHitCount),
+		MissCount:      uint(t.MyMissCount),
+		SkippedCount:   1,
+		CollectLatency: core.LatencyStats{Count: 20, P50: 12, P90: 30, P99: 45, Max: 51},
+		PublishLatency: core.LatencyStats{Count: 20, P50: 3, P90: 4, P99: 8, Max: 9},
+	}
+}
+func (t *mockTask) RunErrors() []core.TaskRunError {
+	return []core.TaskRunError{
+		{Time: time.Unix(1490000400, 0), Node: "collector", Message: "collection failed"},
+		{Time: time.Unix(1490000460, 0), Node: "publisher", PluginName: "file", PluginVersion: 3, Message: "disk full"},
+	}
+}
 func (t *mockTask) Option(...core.TaskOption) core.TaskOption {
 	return core.TaskDeadlineDuration(0)
 }
@@ -150,6 +171,38 @@ func (m *MockTaskManager) EnableTask(id string) (core.Task, error) {
 		MyHref:              "http://localhost:8181/v2/tasks/alskdjf"}, nil
 }
 
+func (m *MockTaskManager) StartTaskIfRevision(id string, rev uint64) []serror.SnapError {
+	return m.StartTask(id)
+}
+func (m *MockTaskManager) StopTaskIfRevision(id string, rev uint64) []serror.SnapError {
+	return m.StopTask(id)
+}
+func (m *MockTaskManager) RemoveTaskIfRevision(id string, rev uint64) error {
+	return m.RemoveTask(id)
+}
+func (m *MockTaskManager) PatchTaskConfig(id string, rev uint64, patch *wmap.ConfigPatch) (core.Task, []serror.SnapError) {
+	return &mockTask{MyID: id}, nil
+}
+func (m *MockTaskManager) UpdateTask(id string, rev uint64, update *core.TaskUpdate) (core.Task, []serror.SnapError) {
+	return &mockTask{MyID: id}, nil
+}
+func (m *MockTaskManager) EnableTaskIfRevision(id string, rev uint64) (core.Task, error) {
+	return m.EnableTask(id)
+}
+
+func (m *MockTaskManager) Accounting(groupBy string) ([]core.AccountingRecord, error) {
+	return nil, nil
+}
+
+func (m *MockTaskManager) AddWorkflow(name string, wf *wmap.WorkflowMap, rollForward bool) (core.StoredWorkflow, []serror.SnapError) {
+	return core.StoredWorkflow{Name: name, Version: 1, Workflow: wf}, nil
+}
+func (m *MockTaskManager) GetWorkflows() []core.StoredWorkflow { return nil }
+func (m *MockTaskManager) GetWorkflow(name string) (core.StoredWorkflow, error) {
+	return core.StoredWorkflow{}, nil
+}
+func (m *MockTaskManager) RemoveWorkflow(name string) error { return nil }
+
 // Mock task used in the 'Add tasks' test in rest_v2_test.go
 const TASK = `{
     "version": 1,
@@ -21,7 +21,6 @@ package v2
 
 import (
 	"compress/gzip"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -29,14 +28,15 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/snap/control"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -46,14 +46,20 @@ type PluginsResponse struct {
 }
 
 type Plugin struct {
-	Name            string        `json:"name"`
-	Version         int           `json:"version"`
-	Type            string        `json:"type"`
-	Signed          bool          `json:"signed"`
-	Status          string        `json:"status"`
-	LoadedTimestamp int64         `json:"loaded_timestamp"`
-	Href            string        `json:"href"`
-	ConfigPolicy    []PolicyTable `json:"policy,omitempty"`
+	Name            string               `json:"name"`
+	Version         int                  `json:"version"`
+	Type            string               `json:"type"`
+	Signed          bool                 `json:"signed"`
+	Status          string               `json:"status"`
+	LoadedTimestamp int64                `json:"loaded_timestamp"`
+	Href            string               `json:"href"`
+	ConfigPolicy    []PolicyTable        `json:"policy,omitempty"`
+	Metadata        *core.PluginMetadata `json:"metadata,omitempty"`
+	core.PluginInfo
+	// APIVersion is the version of the plugin API the plugin speaks
+	APIVersion int `json:"api_version,omitempty"`
+	// RunningInstances is only given when a single plugin is requested
+	RunningInstances []RunningPlugin `json:"running_instances,omitempty"`
 }
 
 type RunningPlugin struct {
@@ -65,6 +71,12 @@ type RunningPlugin struct {
 	ID               uint32 `json:"id"`
 	Href             string `json:"href"`
 	PprofPort        string `json:"pprof_port"`
+	// FailedHealthChecks is the count of consecutive failed health checks,
+	// the plugin being replaced once it reaches the failure limit
+	FailedHealthChecks       int   `json:"failed_health_checks"`
+	LastHealthCheckTimestamp int64 `json:"last_health_check_timestamp"`
+	// PluginResourceUsage is the usage of the host resources by the plugin
+	core.PluginResourceUsage
 }
 
 type plugin struct {
@@ -86,7 +98,6 @@ func (p *plugin) TypeName() string {
 }
 
 func (s *apiV2) loadPlugin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var rp *core.RequestedPlugin
 	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
 		Write(415, FromError(err), w)
@@ -94,105 +105,152 @@ func (s *apiV2) loadPlugin(w http.ResponseWriter, r *http.Request, _ httprouter.
 	}
 
 	if strings.HasPrefix(mediaType, "multipart/") {
-		var signature []byte
-		var checkSum [sha256.Size]byte
-		mr := multipart.NewReader(r.Body, params["boundary"])
-		var i int
-		for {
-			var b []byte
-			p, err := mr.NextPart()
-			if err == io.EOF {
-				break
-			}
+		rps, code, err := s.readPluginParts(r, params["boundary"])
+		if err != nil {
+			Write(code, FromError(err), w)
+			return
+		}
+		s.loadRequestedPlugins(w, r, rps)
+		return
+	}
+	if mediaType == "application/json" {
+		s.loadRemotePlugin(w, r)
+		return
+	}
+	Write(415, FromError(fmt.Errorf("unsupported media type: %s", mediaType)), w)
+}
+
+// readPluginParts writes the plugins of a multipart upload to disk, along
+// with their signatures, and verifies their checksums. The status of the
+// response is returned with the error when the upload fails.
+func (s *apiV2) readPluginParts(r *http.Request, boundary string) ([]*core.RequestedPlugin, int, error) {
+	// Each plugin file may be followed by its signature file (.asc).
+	var rps []*core.RequestedPlugin
+	signed := false
+	mr := multipart.NewReader(r.Body, boundary)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			removeRequestedPlugins(rps)
+			return nil, 500, err
+		}
+		var content io.Reader = p
+		if r.Header.Get("Plugin-Compression") == "gzip" {
+			g, err := gzip.NewReader(p)
 			if err != nil {
-				Write(500, FromError(err), w)
-				return
-			}
-			if r.Header.Get("Plugin-Compression") == "gzip" {
-				g, err := gzip.NewReader(p)
-				defer g.Close()
-				if err != nil {
-					Write(500, FromError(err), w)
-					return
-				}
-				b, err = ioutil.ReadAll(g)
-				if err != nil {
-					Write(500, FromError(err), w)
-					return
-				}
-			} else {
-				b, err = ioutil.ReadAll(p)
-				if err != nil {
-					Write(500, FromError(err), w)
-					return
-				}
+				removeRequestedPlugins(rps)
+				return nil, 500, err
 			}
+			defer g.Close()
+			content = g
+		}
 
-			// A little sanity checking for files being passed into the API server.
-			// First file passed in should be the plugin. If the first file is a signature
-			// file, an error is returned. The signature file should be the second
-			// file passed to the API server. If the second file does not have the ".asc"
-			// extension, an error is returned.
-			// If we loop around more than twice before receiving io.EOF, then
-			// an error is returned.
-
-			switch {
-			case i == 0:
-				if filepath.Ext(p.FileName()) == ".asc" {
-					e := errors.New("Error: first file passed to load plugin api can not be signature file")
-					Write(400, FromError(e), w)
-					return
-				}
-				if rp, err = core.NewRequestedPlugin(p.FileName(), s.metricManager.GetTempDir(), b); err != nil {
-					Write(500, FromError(err), w)
-					return
-				}
-				checkSum = sha256.Sum256(b)
-			case i == 1:
-				if filepath.Ext(p.FileName()) == ".asc" {
-					signature = b
-				} else {
-					e := errors.New("Error: second file passed was not a signature file")
-					Write(400, FromError(e), w)
-					return
-				}
-			case i == 2:
-				e := errors.New("Error: More than two files passed to the load plugin api")
-				Write(400, FromError(e), w)
-				return
+		if filepath.Ext(p.FileName()) == ".asc" {
+			if len(rps) == 0 || signed {
+				removeRequestedPlugins(rps)
+				return nil, 400, ErrSignatureWithoutPlugin
 			}
-			i++
+			signature, err := readUploadPart(content, s.maxUploadSize)
+			if err != nil {
+				removeRequestedPlugins(rps)
+				return nil, uploadErrorStatus(err), err
+			}
+			rps[len(rps)-1].SetSignature(signature)
+			signed = true
+			continue
+		}
+		// the plugin is written to disk as it is received
+		rp, err := core.NewRequestedPluginFromReader(p.FileName(), s.metricManager.GetTempDir(), content, s.maxUploadSize)
+		if err != nil {
+			removeRequestedPlugins(rps)
+			return nil, uploadErrorStatus(err), err
 		}
+		rps = append(rps, rp)
+		signed = false
+	}
 
-		// Sanity check, verify the checkSum on the file sent is the same
-		// as after it is written to disk.
-		if rp.CheckSum() != checkSum {
-			e := errors.New("Error: CheckSum mismatch on requested plugin to load")
-			Write(400, FromError(e), w)
-			return
+	if len(rps) == 0 {
+		return nil, 400, ErrNoPluginFile
+	}
+	if err := verifyCheckSums(rps, r.Header.Get(api.PluginChecksumHeader)); err != nil {
+		removeRequestedPlugins(rps)
+		return nil, 400, err
+	}
+	return rps, 0, nil
+}
+
+// loadRequestedPlugin loads the plugin written to disk, removing it when the
+// load fails.
+func (s *apiV2) loadRequestedPlugin(w http.ResponseWriter, r *http.Request, rp *core.RequestedPlugin) {
+	s.loadRequestedPlugins(w, r, []*core.RequestedPlugin{rp})
+}
+
+// loadRequestedPlugins loads the plugins written to disk in turn, once all
+// their signatures are verified when the metric manager can verify them
+// ahead of the load, removing the ones not loaded when a load fails. The
+// plugin loaded is returned, or the list of them when there are several.
+func (s *apiV2) loadRequestedPlugins(w http.ResponseWriter, r *http.Request, rps []*core.RequestedPlugin) {
+	logger := restLogger.WithField("request-id", r.Header.Get(api.RequestIDHeader))
+	if v, ok := s.metricManager.(signatureVerifier); ok {
+		for _, rp := range rps {
+			if se := v.VerifySignature(rp); se != nil {
+				logger.WithField("plugin-path", rp.Path()).Error(se)
+				removeRequestedPlugins(rps)
+				se.SetFields(map[string]interface{}{"plugin-name": filepath.Base(rp.Path())})
+				Write(400, FromSnapError(se), w)
+				return
+			}
 		}
-		rp.SetSignature(signature)
-		restLogger.Info("Loading plugin: ", rp.Path())
+	}
+	plugins := make([]Plugin, 0, len(rps))
+	loaded := make([]core.CatalogedPlugin, 0, len(rps))
+	for i, rp := range rps {
+		logger.Info("Loading plugin: ", rp.Path())
 		pl, err := s.metricManager.Load(rp)
 		if err != nil {
 			var ec int
-			restLogger.Error(err)
-			restLogger.Debugf("Removing file (%s)", rp.Path())
-			err2 := os.RemoveAll(filepath.Dir(rp.Path()))
-			if err2 != nil {
-				restLogger.Error(err2)
-			}
+			logger.Error(err)
+			logger.Debugf("Removing file (%s)", rp.Path())
+			removeRequestedPlugins(rps[i:])
+			s.unloadPlugins(r.Header.Get(api.RequestIDHeader), loaded)
 			rb := FromError(err)
 			switch rb.ErrorMessage {
 			case ErrPluginAlreadyLoaded:
 				ec = 409
+			case control.ErrPluginDenied.Error(), control.ErrPluginNotAllowed.Error():
+				ec = 403
 			default:
 				ec = 500
 			}
 			Write(ec, rb, w)
 			return
 		}
-		Write(201, catalogedPluginBody(r.Host, pl), w)
+		loaded = append(loaded, pl)
+		plugins = append(plugins, catalogedPluginBody(r.Host, pl))
+	}
+	if len(plugins) == 1 {
+		Write(201, plugins[0], w)
+		return
+	}
+	Write(201, PluginsResponse{Plugins: plugins}, w)
+}
+
+// unloadPlugins unloads the plugins loaded by a request loading several
+// plugins which failed, so that such a request loads all of them or none.
+func (s *apiV2) unloadPlugins(requestID string, plugins []core.CatalogedPlugin) {
+	for _, pl := range plugins {
+		if _, se := s.metricManager.Unload(pl); se != nil {
+			restLogger.WithFields(log.Fields{
+				"_block":         "unload-plugins",
+				"request-id":     requestID,
+				"plugin-name":    pl.Name(),
+				"plugin-version": pl.Version(),
+				"plugin-type":    pl.TypeName(),
+			}).Error(se)
+		}
 	}
 }
 
@@ -245,6 +303,59 @@ func (s *apiV2) unloadPlugin(w http.ResponseWriter, r *http.Request, p httproute
 	Write(204, nil, w)
 }
 
+// unloadPluginVersions unloads every loaded version of the plugin and returns
+// the versions unloaded.
+func (s *apiV2) unloadPluginVersions(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	plType := p.ByName("type")
+	plName := p.ByName("name")
+	f := map[string]interface{}{
+		"plugin-name": plName,
+		"plugin-type": plType,
+	}
+
+	var versions []core.Plugin
+	for _, pl := range s.metricManager.PluginCatalog() {
+		if pl.TypeName() == plType && pl.Name() == plName {
+			versions = append(versions, pl)
+		}
+	}
+	if len(versions) == 0 {
+		Write(404, FromSnapError(serror.New(ErrPluginNotFound, f)), w)
+		return
+	}
+
+	unloaded := []Plugin{}
+	var errs []serror.SnapError
+	for _, pl := range versions {
+		up, se := s.metricManager.Unload(&plugin{
+			name:       plName,
+			version:    pl.Version(),
+			pluginType: plType,
+		})
+		if se != nil {
+			se.SetFields(map[string]interface{}{
+				"plugin-name":    plName,
+				"plugin-version": pl.Version(),
+				"plugin-type":    plType,
+			})
+			errs = append(errs, se)
+			continue
+		}
+		unloaded = append(unloaded, catalogedPluginBody(r.Host, up))
+	}
+	// the versions which could be unloaded stay unloaded, the error lists
+	// the others
+	if len(errs) > 0 {
+		statusCode := 500
+		if len(errs) == len(versions) && errs[0].Error() == control.ErrPluginNotInLoadedState.Error() {
+			statusCode = 409
+		}
+		Write(statusCode, FromSnapErrors(errs), w)
+		return
+	}
+	Write(200, PluginsResponse{Plugins: unloaded}, w)
+}
+
 func (s *apiV2) getPlugins(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 
 	// filter by plugin name or plugin type
@@ -266,6 +377,10 @@ func (s *apiV2) getPlugins(w http.ResponseWriter, r *http.Request, params httpro
 		} else {
 			filteredPlugins = plugins
 		}
+		if fields := requestedFields(r); fields != nil {
+			writeSparseList(w, "running_plugins", filteredPlugins, fields)
+			return
+		}
 		Write(200, PluginsResponse{RunningPlugins: filteredPlugins}, w)
 	} else {
 		// get plugins from the plugin catalog
@@ -281,10 +396,64 @@ func (s *apiV2) getPlugins(w http.ResponseWriter, r *http.Request, params httpro
 		} else {
 			filteredPlugins = plugins
 		}
+		if fields := requestedFields(r); fields != nil {
+			writeSparseList(w, "plugins", filteredPlugins, fields)
+			return
+		}
 		Write(200, PluginsResponse{Plugins: filteredPlugins}, w)
 	}
 }
 
+// getPluginsByType returns the cataloged plugins of the type
+func (s *apiV2) getPluginsByType(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if p.ByName("type") == availablePluginsType {
+		s.getAvailablePlugins(w, r)
+		return
+	}
+	if p.ByName("type") == inventoryPluginsType {
+		s.getPluginInventory(w, r)
+		return
+	}
+	s.getCatalogedPlugins(w, r, p.ByName("type"), "")
+}
+
+// postPluginAction runs the action on the plugins given in place of a plugin
+// type in the path, installing a plugin of the repository being the only one
+func (s *apiV2) postPluginAction(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if p.ByName("type") != installPluginType {
+		Write(404, FromError(ErrWrongAction), w)
+		return
+	}
+	s.installPlugin(w, r)
+}
+
+// getPluginsByName returns the cataloged versions of the plugin
+func (s *apiV2) getPluginsByName(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	s.getCatalogedPlugins(w, r, p.ByName("type"), p.ByName("name"))
+}
+
+func (s *apiV2) getCatalogedPlugins(w http.ResponseWriter, r *http.Request, plType, plName string) {
+	plugins := []Plugin{}
+	for _, p := range s.metricManager.PluginCatalog() {
+		if p.TypeName() == plType && (plName == "" || p.Name() == plName) {
+			plugins = append(plugins, catalogedPluginBody(r.Host, p))
+		}
+	}
+	if len(plugins) == 0 {
+		f := map[string]interface{}{"plugin-type": plType}
+		if plName != "" {
+			f["plugin-name"] = plName
+		}
+		Write(404, FromSnapError(serror.New(ErrPluginNotFound, f)), w)
+		return
+	}
+	if fields := requestedFields(r); fields != nil {
+		writeSparseList(w, "plugins", plugins, fields)
+		return
+	}
+	Write(200, PluginsResponse{Plugins: plugins}, w)
+}
+
 func Btoi(b bool) int {
 	if b {
 		return 1
@@ -309,9 +478,25 @@ func catalogedPluginBody(host string, c core.CatalogedPlugin) Plugin {
 		Status:          c.Status(),
 		LoadedTimestamp: c.LoadedTimestamp().Unix(),
 		Href:            pluginURI(host, c),
+		Metadata:        c.Metadata(),
+		PluginInfo:      c.Info(),
+		APIVersion:      pluginAPIVersion(c),
 	}
 }
 
+// speaksAPIVersion is implemented by the plugins giving the version of the
+// plugin API they speak
+type speaksAPIVersion interface {
+	APIVersion() int
+}
+
+func pluginAPIVersion(c core.CatalogedPlugin) int {
+	if v, ok := c.(speaksAPIVersion); ok {
+		return v.APIVersion()
+	}
+	return 0
+}
+
 func runningPluginsBody(host string, c []core.AvailablePlugin) []RunningPlugin {
 	plugins := make([]RunningPlugin, len(c))
 	for i, p := range c {
@@ -324,11 +509,28 @@ func runningPluginsBody(host string, c []core.AvailablePlugin) []RunningPlugin {
 			ID:               p.ID(),
 			Href:             pluginURI(host, p),
 			PprofPort:        p.Port(),
+
+			FailedHealthChecks:       p.FailedHealthChecks(),
+			LastHealthCheckTimestamp: p.LastHealthCheck().Unix(),
+			PluginResourceUsage:      resourceUsage(p),
 		}
 	}
 	return plugins
 }
 
+// reportsResourceUsage is implemented by the running plugins which usage of
+// the host resources is sampled
+type reportsResourceUsage interface {
+	ResourceUsage() core.PluginResourceUsage
+}
+
+func resourceUsage(p core.AvailablePlugin) core.PluginResourceUsage {
+	if r, ok := p.(reportsResourceUsage); ok {
+		return r.ResourceUsage()
+	}
+	return core.PluginResourceUsage{}
+}
+
 func pluginURI(host string, c core.Plugin) string {
 	return fmt.Sprintf("%s://%s/%s/plugins/%s/%s/%d", protocolPrefix, host, version, c.TypeName(), c.Name(), c.Version())
 }
@@ -340,16 +542,7 @@ func (s *apiV2) getPlugin(w http.ResponseWriter, r *http.Request, p httprouter.P
 		return
 	}
 
-	pluginCatalog := s.metricManager.PluginCatalog()
-	var plugin core.CatalogedPlugin
-	for _, item := range pluginCatalog {
-		if item.Name() == plName &&
-			item.Version() == int(plVersion) &&
-			item.TypeName() == plType {
-			plugin = item
-			break
-		}
-	}
+	plugin := s.catalogedPlugin(plType, plName, plVersion)
 	if plugin == nil {
 		se := serror.New(ErrPluginNotFound, f)
 		Write(404, FromSnapError(se), w)
@@ -398,15 +591,25 @@ func (s *apiV2) getPlugin(w http.ResponseWriter, r *http.Request, p httprouter.P
 		w.WriteHeader(200)
 		return
 	} else {
+		var running []core.AvailablePlugin
+		for _, ap := range s.metricManager.AvailablePlugins() {
+			if ap.Name() == plugin.Name() && ap.Version() == plugin.Version() && ap.TypeName() == plugin.TypeName() {
+				running = append(running, ap)
+			}
+		}
 		pluginRet := Plugin{
-			Name:            plugin.Name(),
-			Version:         plugin.Version(),
-			Type:            plugin.TypeName(),
-			Signed:          plugin.IsSigned(),
-			Status:          plugin.Status(),
-			LoadedTimestamp: plugin.LoadedTimestamp().Unix(),
-			Href:            pluginURI(r.Host, plugin),
-			ConfigPolicy:    configPolicy,
+			Name:             plugin.Name(),
+			Version:          plugin.Version(),
+			Type:             plugin.TypeName(),
+			Signed:           plugin.IsSigned(),
+			Status:           plugin.Status(),
+			LoadedTimestamp:  plugin.LoadedTimestamp().Unix(),
+			Href:             pluginURI(r.Host, plugin),
+			ConfigPolicy:     configPolicy,
+			Metadata:         plugin.Metadata(),
+			PluginInfo:       plugin.Info(),
+			APIVersion:       pluginAPIVersion(plugin),
+			RunningInstances: runningPluginsBody(r.Host, running),
 		}
 		Write(200, pluginRet, w)
 	}
@@ -0,0 +1,103 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// ErrPluginBinaryUnavailable is returned for the plugins, such as remote ones,
+// which have no executable snapteld can serve.
+var ErrPluginBinaryUnavailable = errors.New("plugin has no local binary")
+
+// catalogedPlugin returns the plugin of the catalog with the type, name and
+// version given, or nil when it is not loaded.
+func (s *apiV2) catalogedPlugin(plType, plName string, plVersion int) core.CatalogedPlugin {
+	for _, item := range s.metricManager.PluginCatalog() {
+		if item.Name() == plName &&
+			item.Version() == plVersion &&
+			item.TypeName() == plType {
+			return item
+		}
+	}
+	return nil
+}
+
+// getPluginBinary streams the executable of a loaded plugin as it is, with
+// its hex encoded SHA-256 checksum in the Plugin-Sha256 header, so that other
+// snapteld instances can load the same plugin from this one. Range and
+// conditional requests are supported, the checksum being the ETag.
+func (s *apiV2) getPluginBinary(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	plType, plName, plVersion, f, se := pluginParameters(p)
+	if se != nil {
+		Write(400, FromSnapError(se), w)
+		return
+	}
+	plugin := s.catalogedPlugin(plType, plName, plVersion)
+	if plugin == nil {
+		Write(404, FromSnapError(serror.New(ErrPluginNotFound, f)), w)
+		return
+	}
+
+	if plugin.PluginPath() == "" {
+		Write(409, FromSnapError(serror.New(ErrPluginBinaryUnavailable, f)), w)
+		return
+	}
+	f["plugin-path"] = plugin.PluginPath()
+	file, err := os.Open(plugin.PluginPath())
+	if err != nil {
+		Write(500, FromSnapError(serror.New(err, f)), w)
+		return
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		Write(500, FromSnapError(serror.New(err, f)), w)
+		return
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		Write(500, FromSnapError(serror.New(err, f)), w)
+		return
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(plugin.PluginPath())))
+	w.Header().Set("ETag", `"`+sum+`"`)
+	w.Header().Set(api.PluginChecksumHeader, sum)
+	http.ServeContent(w, r, "", fi.ModTime(), file)
+}
@@ -0,0 +1,121 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+// binaryPlugin is a loaded plugin whose executable is at path
+type binaryPlugin struct {
+	mock.MockLoadedPlugin
+	path string
+}
+
+func (p binaryPlugin) PluginPath() string { return p.path }
+
+// binaryMetricManager has the plugins of catalog loaded
+type binaryMetricManager struct {
+	mock.MockManagesMetrics
+	catalog core.PluginCatalog
+}
+
+func (m binaryMetricManager) PluginCatalog() core.PluginCatalog { return m.catalog }
+
+func TestGetPluginBinary(t *testing.T) {
+	Convey("Given a loaded plugin", t, func() {
+		dir, err := ioutil.TempDir("", "snap-plugin-binary")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "snap-plugin-collector-mock1")
+		So(ioutil.WriteFile(path, []byte("foo"), 0755), ShouldBeNil)
+
+		s := &apiV2{metricManager: binaryMetricManager{catalog: core.PluginCatalog{
+			binaryPlugin{MockLoadedPlugin: mock.MockLoadedPlugin{MyName: "mock", MyType: "collector", MyVersion: 1}, path: path},
+			binaryPlugin{MockLoadedPlugin: mock.MockLoadedPlugin{MyName: "gone", MyType: "collector", MyVersion: 1}, path: filepath.Join(dir, "gone")},
+			binaryPlugin{MockLoadedPlugin: mock.MockLoadedPlugin{MyName: "remote", MyType: "collector", MyVersion: 1}},
+		}}}
+		get := func(typ, name, version string, header map[string]string) (*httptest.ResponseRecorder, negroni.ResponseWriter) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			r := httptest.NewRequest("GET", "/v2/plugins/"+typ+"/"+name+"/"+version+"/binary", nil)
+			for k, v := range header {
+				r.Header.Set(k, v)
+			}
+			s.getPluginBinary(rw, r, httprouter.Params{
+				{Key: "type", Value: typ},
+				{Key: "name", Value: name},
+				{Key: "version", Value: version},
+			})
+			return rec, rw
+		}
+
+		Convey("its executable is returned with its checksum", func() {
+			rec, rw := get("collector", "mock", "1", nil)
+			So(rw.Status(), ShouldEqual, 200)
+			So(rec.Body.String(), ShouldEqual, "foo")
+			So(rec.Header().Get("Content-Type"), ShouldEqual, "application/octet-stream")
+			So(rec.Header().Get(api.PluginChecksumHeader), ShouldEqual, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+			So(rec.Header().Get("Content-Disposition"), ShouldEqual, `attachment; filename="snap-plugin-collector-mock1"`)
+		})
+		Convey("part of its executable can be requested", func() {
+			rec, rw := get("collector", "mock", "1", map[string]string{"Range": "bytes=1-"})
+			So(rw.Status(), ShouldEqual, 206)
+			So(rec.Body.String(), ShouldEqual, "oo")
+		})
+		Convey("its executable is not sent again when unchanged", func() {
+			_, rw := get("collector", "mock", "1", map[string]string{
+				"If-None-Match": `"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"`,
+			})
+			So(rw.Status(), ShouldEqual, 304)
+		})
+		Convey("an unknown plugin is not found", func() {
+			_, rw := get("collector", "mock", "2", nil)
+			So(rw.Status(), ShouldEqual, 404)
+		})
+		Convey("an invalid version is refused", func() {
+			_, rw := get("collector", "mock", "latest", nil)
+			So(rw.Status(), ShouldEqual, 400)
+		})
+		Convey("a plugin without a local executable is a conflict", func() {
+			_, rw := get("collector", "remote", "1", nil)
+			So(rw.Status(), ShouldEqual, 409)
+		})
+		Convey("a missing executable is an error", func() {
+			_, rw := get("collector", "gone", "1", nil)
+			So(rw.Status(), ShouldEqual, 500)
+		})
+	})
+}
@@ -0,0 +1,74 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// ErrPluginCrashesUnsupported is returned when the metric manager does not
+// keep the diagnostics of the crashes of plugins
+var ErrPluginCrashesUnsupported = errors.New("plugin crash diagnostics are not supported")
+
+// reportsPluginCrashes is implemented by metric managers keeping the
+// diagnostics of the crashes of the running instances of plugins.
+type reportsPluginCrashes interface {
+	PluginCrashes(typeName, name string, version int) ([]core.PluginCrash, serror.SnapError)
+}
+
+// PluginCrashesResponse holds the diagnostics of the last crashes of the
+// instances of a plugin, the latest last.
+type PluginCrashesResponse struct {
+	Crashes []core.PluginCrash `json:"crashes"`
+}
+
+// getPluginCrashes returns the diagnostics of the last crashes of the
+// instances of a plugin.
+func (s *apiV2) getPluginCrashes(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	c, ok := s.metricManager.(reportsPluginCrashes)
+	if !ok {
+		Write(501, FromError(ErrPluginCrashesUnsupported), w)
+		return
+	}
+	plType, plName, plVersion, f, se := pluginParameters(p)
+	if se != nil {
+		Write(400, FromSnapError(se), w)
+		return
+	}
+
+	crashes, se := c.PluginCrashes(plType, plName, plVersion)
+	if se != nil {
+		se.SetFields(f)
+		statusCode := 500
+		if se.Error() == control.ErrPluginNotFound.Error() {
+			statusCode = 404
+		}
+		Write(statusCode, FromSnapError(se), w)
+		return
+	}
+	Write(200, PluginCrashesResponse{Crashes: crashes}, w)
+}
@@ -0,0 +1,175 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// inventoryPluginsType is the plugin type of the path giving the signed
+// inventory of the loaded plugins: /v2/plugins/inventory
+const inventoryPluginsType = "inventory"
+
+var (
+	ErrInventoryUnsigned = errors.New("inventory can only be signed when the REST API serves HTTPS")
+	ErrInventoryKeyUnfit = errors.New("key of the REST API cannot sign the inventory")
+)
+
+// InventoryManifest lists the plugins loaded at the time it was generated.
+type InventoryManifest struct {
+	Hostname    string            `json:"hostname"`
+	GeneratedAt int64             `json:"generated_at"`
+	Plugins     []InventoryPlugin `json:"plugins"`
+}
+
+// InventoryPlugin is a loaded plugin of the inventory, the checksum being
+// empty for the remote plugins.
+type InventoryPlugin struct {
+	Type            string `json:"type"`
+	Name            string `json:"name"`
+	Version         int    `json:"version"`
+	SHA256          string `json:"sha256,omitempty"`
+	Signed          bool   `json:"signed"`
+	LoadedTimestamp int64  `json:"loaded_timestamp"`
+}
+
+// SignedInventory is the inventory with its signature. The signature is of
+// the sha256 digest of the payload, which holds the exact bytes of the
+// manifest signed.
+type SignedInventory struct {
+	Manifest    InventoryManifest `json:"manifest"`
+	Payload     string            `json:"payload"`
+	Algorithm   string            `json:"algorithm"`
+	Signature   string            `json:"signature"`
+	Certificate string            `json:"certificate"`
+}
+
+// hasCheckSum is implemented by the plugins giving the hex encoded sha256
+// checksum of their binary
+type hasCheckSum interface {
+	CheckSum() string
+}
+
+type inventoryPlugins []InventoryPlugin
+
+func (p inventoryPlugins) Len() int      { return len(p) }
+func (p inventoryPlugins) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p inventoryPlugins) Less(i, j int) bool {
+	if p[i].Type != p[j].Type {
+		return p[i].Type < p[j].Type
+	}
+	if p[i].Name != p[j].Name {
+		return p[i].Name < p[j].Name
+	}
+	return p[i].Version < p[j].Version
+}
+
+// SetInventoryKey sets the certificate and key of the REST API signing the
+// inventory of the loaded plugins.
+func (s *apiV2) SetInventoryKey(cer tls.Certificate) {
+	s.inventoryKey = &cer
+}
+
+// getPluginInventory returns the manifest of the loaded plugins signed with
+// the key of the REST API.
+func (s *apiV2) getPluginInventory(w http.ResponseWriter, r *http.Request) {
+	if s.inventoryKey == nil {
+		Write(501, FromError(ErrInventoryUnsigned), w)
+		return
+	}
+	m := inventoryManifest(s.metricManager.PluginCatalog())
+	inv, err := signInventory(m, *s.inventoryKey)
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	Write(200, inv, w)
+}
+
+func inventoryManifest(catalog core.PluginCatalog) InventoryManifest {
+	hostname, _ := os.Hostname()
+	plugins := inventoryPlugins{}
+	for _, p := range catalog {
+		ip := InventoryPlugin{
+			Type:            p.TypeName(),
+			Name:            p.Name(),
+			Version:         p.Version(),
+			Signed:          p.IsSigned(),
+			LoadedTimestamp: p.LoadedTimestamp().Unix(),
+		}
+		if c, ok := p.(hasCheckSum); ok {
+			ip.SHA256 = c.CheckSum()
+		}
+		plugins = append(plugins, ip)
+	}
+	sort.Sort(plugins)
+	return InventoryManifest{
+		Hostname:    hostname,
+		GeneratedAt: time.Now().Unix(),
+		Plugins:     plugins,
+	}
+}
+
+func signInventory(m InventoryManifest, cer tls.Certificate) (*SignedInventory, error) {
+	signer, ok := cer.PrivateKey.(crypto.Signer)
+	if !ok || len(cer.Certificate) == 0 {
+		return nil, ErrInventoryKeyUnfit
+	}
+	var alg string
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		alg = "RSA-SHA256"
+	case *ecdsa.PublicKey:
+		alg = "ECDSA-SHA256"
+	default:
+		return nil, ErrInventoryKeyUnfit
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(payload)
+	sig, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedInventory{
+		Manifest:    m,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Algorithm:   alg,
+		Signature:   base64.StdEncoding.EncodeToString(sig),
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cer.Certificate[0]})),
+	}, nil
+}
@@ -0,0 +1,118 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+	"github.com/julienschmidt/httprouter"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func inventoryCertificate(key crypto.Signer) tls.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "snapteld"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	So(err, ShouldBeNil)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestGetPluginInventory(t *testing.T) {
+	Convey("Given the plugin catalog", t, func() {
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		get := func() (int, *SignedInventory) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/v2/plugins/inventory", nil)
+			s.getPluginsByType(negroni.NewResponseWriter(rec), r, httprouter.Params{{Key: "type", Value: inventoryPluginsType}})
+			inv := &SignedInventory{}
+			json.Unmarshal(rec.Body.Bytes(), inv)
+			return rec.Code, inv
+		}
+		verify := func(inv *SignedInventory) {
+			block, _ := pem.Decode([]byte(inv.Certificate))
+			So(block, ShouldNotBeNil)
+			cert, err := x509.ParseCertificate(block.Bytes)
+			So(err, ShouldBeNil)
+			payload, err := base64.StdEncoding.DecodeString(inv.Payload)
+			So(err, ShouldBeNil)
+			sig, err := base64.StdEncoding.DecodeString(inv.Signature)
+			So(err, ShouldBeNil)
+			m := InventoryManifest{}
+			So(json.Unmarshal(payload, &m), ShouldBeNil)
+			So(m, ShouldResemble, inv.Manifest)
+			digest := sha256.Sum256(payload)
+			switch k := cert.PublicKey.(type) {
+			case *rsa.PublicKey:
+				So(rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig), ShouldBeNil)
+			case *ecdsa.PublicKey:
+				So(cert.CheckSignature(x509.ECDSAWithSHA256, payload, sig), ShouldBeNil)
+			}
+		}
+		Convey("It is not signed without the key of the REST API", func() {
+			code, _ := get()
+			So(code, ShouldEqual, 501)
+		})
+		Convey("It is signed with an RSA key", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 1024)
+			So(err, ShouldBeNil)
+			s.SetInventoryKey(inventoryCertificate(key))
+			code, inv := get()
+			So(code, ShouldEqual, 200)
+			So(inv.Algorithm, ShouldEqual, "RSA-SHA256")
+			verify(inv)
+			So(inv.Manifest.Plugins, ShouldHaveLength, 6)
+			So(inv.Manifest.Plugins[0].Name, ShouldEqual, "foo")
+			So(inv.Manifest.Plugins[0].Version, ShouldEqual, 2)
+			So(inv.Manifest.Plugins[0].LoadedTimestamp, ShouldEqual, 1473120000)
+		})
+		Convey("It is signed with an ECDSA key", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			So(err, ShouldBeNil)
+			s.SetInventoryKey(inventoryCertificate(key))
+			code, inv := get()
+			So(code, ShouldEqual, 200)
+			So(inv.Algorithm, ShouldEqual, "ECDSA-SHA256")
+			verify(inv)
+		})
+	})
+}
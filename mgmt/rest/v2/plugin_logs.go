@@ -0,0 +1,115 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+var (
+	// ErrPluginLogsUnsupported is returned when the metric manager does not
+	// keep the output of plugins
+	ErrPluginLogsUnsupported = errors.New("plugin logs are not supported")
+	// ErrInvalidKB is returned when the size of the logs requested is not a
+	// number
+	ErrInvalidKB = errors.New("kb must be a non-negative integer")
+)
+
+// outputsPlugins is implemented by metric managers keeping the output of
+// the running instances of plugins.
+type outputsPlugins interface {
+	PluginOutput(typeName, name string, version int, n int) (map[uint32][]byte, serror.SnapError)
+}
+
+// PluginLogsResponse holds the last output of the running instances of a
+// plugin.
+type PluginLogsResponse struct {
+	Instances []PluginInstanceLogs `json:"instances"`
+}
+
+// PluginInstanceLogs is the last output, stdout and stderr, of a running
+// instance of a plugin.
+type PluginInstanceLogs struct {
+	ID     uint32 `json:"id"`
+	Output string `json:"output"`
+}
+
+type pluginInstanceLogs []PluginInstanceLogs
+
+func (p pluginInstanceLogs) Len() int {
+	return len(p)
+}
+
+func (p pluginInstanceLogs) Less(i, j int) bool {
+	return p[i].ID < p[j].ID
+}
+
+func (p pluginInstanceLogs) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// getPluginLogs returns the last output of the running instances of a
+// plugin, the last kb kilobytes of it when the kb query parameter is set.
+func (s *apiV2) getPluginLogs(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	o, ok := s.metricManager.(outputsPlugins)
+	if !ok {
+		Write(501, FromError(ErrPluginLogsUnsupported), w)
+		return
+	}
+	plType, plName, plVersion, f, se := pluginParameters(p)
+	if se != nil {
+		Write(400, FromSnapError(se), w)
+		return
+	}
+	n := 0
+	if kb := r.URL.Query().Get("kb"); kb != "" {
+		v, err := strconv.Atoi(kb)
+		if err != nil || v < 0 {
+			Write(400, FromError(ErrInvalidKB), w)
+			return
+		}
+		n = v << 10
+	}
+
+	outputs, se := o.PluginOutput(plType, plName, plVersion, n)
+	if se != nil {
+		se.SetFields(f)
+		statusCode := 500
+		if se.Error() == control.ErrPluginNotFound.Error() {
+			statusCode = 404
+		}
+		Write(statusCode, FromSnapError(se), w)
+		return
+	}
+	logs := make(pluginInstanceLogs, 0, len(outputs))
+	for id, out := range outputs {
+		logs = append(logs, PluginInstanceLogs{ID: id, Output: string(out)})
+	}
+	sort.Sort(logs)
+	Write(200, PluginLogsResponse{Instances: logs}, w)
+}
@@ -0,0 +1,99 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+// outputMetricManager keeps the output of two instances of collector foo
+type outputMetricManager struct {
+	mock.MockManagesMetrics
+}
+
+func (m outputMetricManager) PluginOutput(typeName, name string, version int, n int) (map[uint32][]byte, serror.SnapError) {
+	if typeName != "collector" || name != "foo" {
+		return nil, serror.New(control.ErrPluginNotFound)
+	}
+	out := map[uint32][]byte{2: []byte("second\n"), 1: []byte("first\n")}
+	if n > 0 {
+		out[1] = out[1][len(out[1])-1:]
+	}
+	return out, nil
+}
+
+func TestGetPluginLogs(t *testing.T) {
+	Convey("Given a metric manager keeping the output of plugins", t, func() {
+		logs := func(m api.Metrics, name, version, query string) (int, *PluginLogsResponse) {
+			s := &apiV2{metricManager: m}
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			r := httptest.NewRequest("GET", "/v2/plugins/collector/"+name+"/"+version+"/logs"+query, nil)
+			s.getPluginLogs(rw, r, httprouter.Params{
+				httprouter.Param{Key: "type", Value: "collector"},
+				httprouter.Param{Key: "name", Value: name},
+				httprouter.Param{Key: "version", Value: version},
+			})
+			resp := &PluginLogsResponse{}
+			json.Unmarshal(rec.Body.Bytes(), resp)
+			return rw.Status(), resp
+		}
+
+		Convey("the output of each instance is returned by id", func() {
+			code, resp := logs(outputMetricManager{}, "foo", "2", "")
+			So(code, ShouldEqual, 200)
+			So(resp.Instances, ShouldResemble, []PluginInstanceLogs{
+				{ID: 1, Output: "first\n"},
+				{ID: 2, Output: "second\n"},
+			})
+		})
+		Convey("the size of the output is limited by kb", func() {
+			code, resp := logs(outputMetricManager{}, "foo", "2", "?kb=1")
+			So(code, ShouldEqual, 200)
+			So(resp.Instances[0].Output, ShouldEqual, "\n")
+		})
+		Convey("an invalid size is refused", func() {
+			code, _ := logs(outputMetricManager{}, "foo", "2", "?kb=-1")
+			So(code, ShouldEqual, 400)
+		})
+		Convey("the logs of a plugin not loaded are not found", func() {
+			code, _ := logs(outputMetricManager{}, "bar", "2", "")
+			So(code, ShouldEqual, 404)
+		})
+		Convey("a metric manager not keeping the output cannot return logs", func() {
+			code, _ := logs(mock.MockManagesMetrics{}, "foo", "2", "")
+			So(code, ShouldEqual, 501)
+		})
+	})
+}
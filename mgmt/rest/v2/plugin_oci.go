@@ -0,0 +1,364 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const (
+	// ociScheme is the scheme of the references of plugins stored in an OCI
+	// registry: oci://registry/repository[:tag|@digest]
+	ociScheme = "oci://"
+	// ociTitleAnnotation names the file of a layer of an OCI artifact
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// ociSignatureMediaType is the media type of the layer holding the
+	// signature of a plugin
+	ociSignatureMediaType = "application/pgp-signature"
+)
+
+var (
+	ErrInvalidOCIReference = errors.New("oci reference must be oci://registry/repository[:tag|@digest]")
+	ErrOCINoPluginLayer    = errors.New("oci artifact has no plugin layer")
+	ErrOCIDigestMismatch   = errors.New("oci blob does not match its digest")
+)
+
+// ociManifestMediaTypes are the manifests accepted from registries
+var ociManifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ociChallengeParam matches the parameters of a Bearer challenge
+var ociChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociReference is a reference to an artifact of an OCI registry.
+type ociReference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or the digest of the artifact
+	Reference string
+}
+
+// ociDescriptor describes a blob of an OCI artifact.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is the manifest of an OCI artifact.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// parseOCIReference parses oci://registry/repository[:tag|@digest], the tag
+// being latest when neither a tag nor a digest is given.
+func parseOCIReference(uri string) (*ociReference, error) {
+	if !strings.HasPrefix(uri, ociScheme) {
+		return nil, ErrInvalidOCIReference
+	}
+	parts := strings.SplitN(strings.TrimPrefix(uri, ociScheme), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrInvalidOCIReference
+	}
+	ref := &ociReference{Registry: parts[0], Repository: parts[1], Reference: "latest"}
+	if i := strings.Index(ref.Repository, "@"); i >= 0 {
+		ref.Repository, ref.Reference = ref.Repository[:i], ref.Repository[i+1:]
+	} else if i := strings.LastIndex(ref.Repository, ":"); i > strings.LastIndex(ref.Repository, "/") {
+		ref.Repository, ref.Reference = ref.Repository[:i], ref.Repository[i+1:]
+	}
+	if ref.Repository == "" || ref.Reference == "" || strings.HasSuffix(ref.Repository, "/") {
+		return nil, ErrInvalidOCIReference
+	}
+	return ref, nil
+}
+
+// ociRegistry pulls the artifacts of a repository of an OCI registry,
+// authenticating with the anonymous tokens of the registry when asked to.
+type ociRegistry struct {
+	ref   *ociReference
+	token string
+}
+
+// get fetches the path of the repository, e.g. manifests/latest.
+func (o *ociRegistry) get(p string, accept ...string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", o.ref.Registry, o.ref.Repository, p)
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if o.token != "" {
+			req.Header.Set("Authorization", "Bearer "+o.token)
+		}
+		return pluginClient.Do(req)
+	}
+	resp, err := do()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 401 && o.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if o.token, err = ociToken(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = do(); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// ociToken gets an anonymous token from the realm of a Bearer challenge.
+func ociToken(challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry authentication: %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range ociChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme != "https" {
+		return "", fmt.Errorf("invalid registry authentication realm: %q", params["realm"])
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	realm.RawQuery = q.Encode()
+	b, err := download(realm, maxPluginDownloadSize)
+	if err != nil {
+		return "", err
+	}
+	t := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return "", err
+	}
+	if t.Token == "" {
+		return t.AccessToken, nil
+	}
+	return t.Token, nil
+}
+
+// manifest fetches the manifest of the artifact.
+func (o *ociRegistry) manifest() (*ociManifest, error) {
+	resp, err := o.get("manifests/"+o.ref.Reference, ociManifestMediaTypes...)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	m := &ociManifest{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// blob returns the content of the blob d, from the cache in dir when it was
+// already pulled. The blobs pulled are verified against their digest and
+// added to the cache.
+func (o *ociRegistry) blob(d ociDescriptor, dir string) ([]byte, error) {
+	if !strings.HasPrefix(d.Digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported oci digest: %q", d.Digest)
+	}
+	sum := strings.TrimPrefix(d.Digest, "sha256:")
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid oci digest: %q", d.Digest)
+	}
+	cached := filepath.Join(dir, sum)
+	if b, err := ioutil.ReadFile(cached); err == nil && core.VerifyCheckSum(b, sum) == nil {
+		return b, nil
+	}
+
+	resp, err := o.get("blobs/" + d.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPluginDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxPluginDownloadSize {
+		return nil, core.ErrPluginTooLarge
+	}
+	if core.VerifyCheckSum(b, sum) != nil {
+		return nil, ErrOCIDigestMismatch
+	}
+	if err := writeOCICache(dir, sum, b); err != nil {
+		restLogger.WithFields(log.Fields{
+			"_block": "oci-blob",
+			"digest": d.Digest,
+			"error":  err.Error(),
+		}).Warn("cannot cache oci blob")
+	}
+	return b, nil
+}
+
+// writeOCICache adds the blob b of digest sum to the cache in dir.
+func writeOCICache(dir, sum string, b []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, sum+".part")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, sum))
+}
+
+// ociLayers returns the layer of the plugin and the one of its signature, if
+// any, of an artifact.
+func ociLayers(m *ociManifest) (*ociDescriptor, *ociDescriptor, error) {
+	var plugin, signature *ociDescriptor
+	for i, l := range m.Layers {
+		if l.MediaType == ociSignatureMediaType || strings.HasSuffix(l.Annotations[ociTitleAnnotation], ".asc") {
+			if signature == nil {
+				signature = &m.Layers[i]
+			}
+		} else if plugin == nil {
+			plugin = &m.Layers[i]
+		}
+	}
+	if plugin == nil {
+		return nil, nil, ErrOCINoPluginLayer
+	}
+	return plugin, signature, nil
+}
+
+// ociCacheDir returns where the blobs pulled from OCI registries are cached.
+func (s *apiV2) ociCacheDir() string {
+	dir := s.metricManager.GetTempDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "oci", "blobs", "sha256")
+}
+
+// loadOCIPlugin pulls the plugin of the OCI artifact given in the request,
+// along with its signature when the artifact or the request has one,
+// verifies it and loads it.
+func (s *apiV2) loadOCIPlugin(w http.ResponseWriter, r *http.Request, rem *RemotePlugin) {
+	ref, err := parseOCIReference(rem.URI)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	var su *url.URL
+	if rem.SignatureURI != "" {
+		if su, err = pluginURL(rem.SignatureURI); err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+	restLogger.WithFields(log.Fields{
+		"_block":     "load-oci-plugin",
+		"request-id": r.Header.Get(api.RequestIDHeader),
+		"uri":        rem.URI,
+	}).Info("Pulling plugin")
+
+	reg := &ociRegistry{ref: ref}
+	m, err := reg.manifest()
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+	pl, sl, err := ociLayers(m)
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+	dir := s.ociCacheDir()
+	b, err := reg.blob(*pl, dir)
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+	if rem.SHA256 != "" {
+		if err := core.VerifyCheckSum(b, rem.SHA256); err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+	var signature []byte
+	if su != nil {
+		signature, err = download(su, s.maxUploadSize)
+	} else if sl != nil {
+		signature, err = reg.blob(*sl, dir)
+	}
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+
+	name := pl.Annotations[ociTitleAnnotation]
+	if name == "" {
+		name = ref.Repository
+	}
+	rp, err := core.NewRequestedPlugin(path.Base(name), s.metricManager.GetTempDir(), b)
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	rp.SetSignature(signature)
+	s.loadRequestedPlugin(w, r, rp)
+}
@@ -0,0 +1,134 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	Convey("parseOCIReference", t, func() {
+		Convey("parses tags and digests", func() {
+			ref, err := parseOCIReference("oci://registry.example.com:5000/snap/psutil:9")
+			So(err, ShouldBeNil)
+			So(*ref, ShouldResemble, ociReference{Registry: "registry.example.com:5000", Repository: "snap/psutil", Reference: "9"})
+			ref, err = parseOCIReference("oci://registry.example.com/snap/psutil@sha256:abc")
+			So(err, ShouldBeNil)
+			So(ref.Reference, ShouldEqual, "sha256:abc")
+		})
+		Convey("defaults to the latest tag", func() {
+			ref, err := parseOCIReference("oci://registry.example.com/snap/psutil")
+			So(err, ShouldBeNil)
+			So(ref.Reference, ShouldEqual, "latest")
+		})
+		Convey("rejects references without a repository", func() {
+			for _, uri := range []string{"oci://registry.example.com", "oci://registry.example.com/", "oci:///snap/psutil", "https://registry.example.com/snap/psutil"} {
+				_, err := parseOCIReference(uri)
+				So(err, ShouldEqual, ErrInvalidOCIReference)
+			}
+		})
+	})
+}
+
+func TestLoadOCIPlugin(t *testing.T) {
+	Convey("Given a registry serving a plugin artifact", t, func() {
+		content := []byte("#!/bin/sh\n")
+		sum := sha256.Sum256(content)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		manifest, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{{
+			MediaType:   "application/octet-stream",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: "snap-plugin-collector-mock1"},
+		}}})
+		blobPulls := 0
+		var ts *httptest.Server
+		ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/token" {
+				w.Write([]byte(`{"token": "anonymous"}`))
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+ts.URL+`/token",service="registry",scope="repository:snap/mock:pull"`)
+				w.WriteHeader(401)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/snap/mock/manifests/1":
+				w.Write(manifest)
+			case "/v2/snap/mock/blobs/" + digest:
+				blobPulls++
+				w.Write(content)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer ts.Close()
+		client := pluginClient
+		pluginClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		defer func() { pluginClient = client }()
+
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		os.RemoveAll(filepath.Join(s.ociCacheDir(), hex.EncodeToString(sum[:])))
+		load := func(body string) int {
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest("POST", "/v2/plugins", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			s.loadPlugin(rw, r, nil)
+			return rw.Status()
+		}
+		registry := strings.TrimPrefix(ts.URL, "https://")
+
+		Convey("it pulls and loads the plugin, caching its layer", func() {
+			So(load(`{"uri": "oci://`+registry+`/snap/mock:1"}`), ShouldEqual, 201)
+			So(load(`{"uri": "oci://`+registry+`/snap/mock:1"}`), ShouldEqual, 201)
+			So(blobPulls, ShouldEqual, 1)
+			b, err := ioutil.ReadFile(filepath.Join(s.ociCacheDir(), hex.EncodeToString(sum[:])))
+			So(err, ShouldBeNil)
+			So(b, ShouldResemble, content)
+		})
+		Convey("it rejects a plugin not matching the checksum", func() {
+			So(load(`{"uri": "oci://`+registry+`/snap/mock:1", "sha256": "00"}`), ShouldEqual, 400)
+		})
+		Convey("it answers 502 when the artifact can not be pulled", func() {
+			So(load(`{"uri": "oci://`+registry+`/snap/mock:2"}`), ShouldEqual, 502)
+		})
+		Convey("it rejects an invalid reference", func() {
+			So(load(`{"uri": "oci://`+registry+`"}`), ShouldEqual, 400)
+		})
+	})
+}
@@ -0,0 +1,191 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const (
+	// maxPluginDownloadSize is the size of the largest repository index,
+	// registry token or oci blob downloaded by snapteld.
+	maxPluginDownloadSize = 512 << 20
+	// pluginDownloadTimeout bounds the download of a plugin, or signature.
+	pluginDownloadTimeout = 5 * time.Minute
+)
+
+var (
+	ErrPluginURIRequired = errors.New("plugin uri is required")
+	ErrInvalidPluginURI  = errors.New("plugin uri must be an https or s3 url of a file")
+)
+
+// pluginClient is the HTTP client downloading plugins.
+var pluginClient = &http.Client{Timeout: pluginDownloadTimeout}
+
+// RemotePlugin is the body of a request to load a plugin that snapteld
+// downloads itself, instead of receiving it from the client.
+type RemotePlugin struct {
+	// URI is the https or s3 URL of the plugin, or the oci:// reference of
+	// the artifact holding it.
+	URI string `json:"uri"`
+	// SignatureURI is the URL of the signature (.asc) of the plugin.
+	SignatureURI string `json:"signature_uri,omitempty"`
+	// SHA256 is the hex encoded checksum the plugin has to match.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// loadRemotePlugin downloads the plugin given in the request body, verifies
+// it and loads it.
+func (s *apiV2) loadRemotePlugin(w http.ResponseWriter, r *http.Request) {
+	rem := &RemotePlugin{}
+	errCode, err := core.UnmarshalBody(rem, r.Body)
+	if errCode != 0 && err != nil {
+		Write(errCode, FromError(err), w)
+		return
+	}
+	if rem.URI == "" {
+		Write(400, FromError(ErrPluginURIRequired), w)
+		return
+	}
+	if strings.HasPrefix(rem.URI, ociScheme) {
+		s.loadOCIPlugin(w, r, rem)
+		return
+	}
+	u, err := pluginURL(rem.URI)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	var su *url.URL
+	if rem.SignatureURI != "" {
+		if su, err = pluginURL(rem.SignatureURI); err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+	s.downloadPlugin(w, r, u, su, rem.SHA256)
+}
+
+// downloadPlugin downloads the plugin at u, along with its signature at su
+// when given, checks it against the hex encoded sha256 checksum when given
+// and loads it.
+func (s *apiV2) downloadPlugin(w http.ResponseWriter, r *http.Request, u, su *url.URL, sha256 string) {
+	restLogger.WithFields(log.Fields{
+		"_block":     "load-remote-plugin",
+		"request-id": r.Header.Get(api.RequestIDHeader),
+		"uri":        u.String(),
+	}).Info("Downloading plugin")
+	body, err := openDownload(u)
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+	// the plugin is written to disk as it is received, so that it is never
+	// held in memory
+	rp, err := core.NewRequestedPluginFromReader(path.Base(u.Path), s.metricManager.GetTempDir(), body, s.maxUploadSize)
+	body.Close()
+	if err != nil {
+		Write(downloadErrorStatus(err), FromError(err), w)
+		return
+	}
+	if sha256 != "" {
+		if err := rp.VerifyCheckSum(sha256); err != nil {
+			removeRequestedPlugin(rp)
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+	if su != nil {
+		signature, err := download(su, s.maxUploadSize)
+		if err != nil {
+			removeRequestedPlugin(rp)
+			Write(downloadErrorStatus(err), FromError(err), w)
+			return
+		}
+		rp.SetSignature(signature)
+	}
+	s.loadRequestedPlugin(w, r, rp)
+}
+
+// pluginURL returns the URL a plugin is downloaded from. s3://bucket/key URIs
+// are fetched from the HTTPS endpoint of the bucket, so private objects have
+// to be given as presigned https URLs instead.
+func pluginURL(uri string) (*url.URL, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return nil, ErrInvalidPluginURI
+	}
+	switch u.Scheme {
+	case "https":
+		return u, nil
+	case "s3":
+		return &url.URL{
+			Scheme: "https",
+			Host:   u.Host + ".s3.amazonaws.com",
+			Path:   u.Path,
+		}, nil
+	}
+	return nil, ErrInvalidPluginURI
+}
+
+// download returns the content at the URL, core.ErrPluginTooLarge when it is
+// larger than maxSize, unless it is 0.
+func download(u *url.URL, maxSize int64) ([]byte, error) {
+	body, err := openDownload(u)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return readUploadPart(body, maxSize)
+}
+
+// openDownload returns the body of the file at u, which the caller closes.
+func openDownload(u *url.URL) (io.ReadCloser, error) {
+	resp, err := pluginClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading %s: %s", u, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// downloadErrorStatus is the status of the response to a request loading a
+// plugin which download failed with err.
+func downloadErrorStatus(err error) int {
+	if err == core.ErrPluginTooLarge {
+		return 413
+	}
+	return 502
+}
@@ -0,0 +1,107 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestPluginURL(t *testing.T) {
+	Convey("pluginURL", t, func() {
+		Convey("accepts https URLs", func() {
+			u, err := pluginURL("https://example.com/plugins/snap-plugin-collector-mock1")
+			So(err, ShouldBeNil)
+			So(u.String(), ShouldEqual, "https://example.com/plugins/snap-plugin-collector-mock1")
+		})
+		Convey("fetches s3 URIs from the bucket endpoint", func() {
+			u, err := pluginURL("s3://plugins/linux/snap-plugin-collector-mock1")
+			So(err, ShouldBeNil)
+			So(u.String(), ShouldEqual, "https://plugins.s3.amazonaws.com/linux/snap-plugin-collector-mock1")
+		})
+		Convey("rejects other schemes and URLs without a file", func() {
+			for _, uri := range []string{
+				"http://example.com/snap-plugin-collector-mock1",
+				"file:///tmp/snap-plugin-collector-mock1",
+				"https://example.com/",
+				"snap-plugin-collector-mock1",
+			} {
+				_, err := pluginURL(uri)
+				So(err, ShouldEqual, ErrInvalidPluginURI)
+			}
+		})
+	})
+}
+
+func TestLoadRemotePlugin(t *testing.T) {
+	Convey("Given a server serving a plugin", t, func() {
+		content := []byte("#!/bin/sh\n")
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/snap-plugin-collector-mock1" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(content)
+		}))
+		defer ts.Close()
+		client := pluginClient
+		pluginClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		defer func() { pluginClient = client }()
+
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		load := func(body string) int {
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest("POST", "/v2/plugins", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			s.loadPlugin(rw, r, nil)
+			return rw.Status()
+		}
+		sum := sha256.Sum256(content)
+
+		Convey("it downloads and loads the plugin", func() {
+			So(load(`{"uri": "`+ts.URL+`/snap-plugin-collector-mock1", "sha256": "`+hex.EncodeToString(sum[:])+`"}`), ShouldEqual, 201)
+		})
+		Convey("it rejects a plugin not matching the checksum", func() {
+			So(load(`{"uri": "`+ts.URL+`/snap-plugin-collector-mock1", "sha256": "00"}`), ShouldEqual, 400)
+		})
+		Convey("it answers 413 when the plugin is larger than the maximum upload size", func() {
+			s.SetMaxPluginUploadSize(int64(len(content) - 1))
+			So(load(`{"uri": "`+ts.URL+`/snap-plugin-collector-mock1"}`), ShouldEqual, 413)
+		})
+		Convey("it answers 502 when the plugin can not be downloaded", func() {
+			So(load(`{"uri": "`+ts.URL+`/missing"}`), ShouldEqual, 502)
+		})
+		Convey("it requires a uri", func() {
+			So(load(`{}`), ShouldEqual, 400)
+		})
+	})
+}
@@ -0,0 +1,210 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const (
+	// availablePluginsType is the plugin type of the path listing the
+	// plugins of the repository: /v2/plugins/available
+	availablePluginsType = "available"
+	// installPluginType is the plugin type of the path installing a plugin
+	// of the repository: /v2/plugins/install
+	installPluginType = "install"
+)
+
+var (
+	ErrNoPluginRepository      = errors.New("no plugin repository is configured")
+	ErrPluginNameRequired      = errors.New("plugin name is required")
+	ErrRepositoryPluginInvalid = errors.New("plugin of the repository must have a url and a sha256 checksum")
+)
+
+// RepositoryPlugin is a plugin listed in the index of the plugin repository.
+// The URLs may be relative to the URL of the index.
+type RepositoryPlugin struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Version      int    `json:"version"`
+	Description  string `json:"description,omitempty"`
+	SHA256       string `json:"sha256"`
+	URL          string `json:"url"`
+	SignatureURL string `json:"signature_url,omitempty"`
+	// Loaded is set when the plugin is in the plugin catalog
+	Loaded bool `json:"loaded"`
+}
+
+// RepositoryIndex is the index of the plugin repository.
+type RepositoryIndex struct {
+	Plugins []RepositoryPlugin `json:"plugins"`
+}
+
+// InstallPlugin is the body of a request to install a plugin of the
+// repository, its latest version being installed when Version is 0.
+type InstallPlugin struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Version int    `json:"version,omitempty"`
+}
+
+// SetPluginRepository sets the https or s3 URL of the index of the plugin
+// repository, none being configured when it is empty.
+func (s *apiV2) SetPluginRepository(uri string) {
+	s.pluginRepository = uri
+}
+
+// getAvailablePlugins lists the plugins of the repository, filtered by the
+// type and name query parameters when given.
+func (s *apiV2) getAvailablePlugins(w http.ResponseWriter, r *http.Request) {
+	idx, code, err := s.repositoryIndex()
+	if err != nil {
+		Write(code, FromError(err), w)
+		return
+	}
+	q := r.URL.Query()
+	catalog := s.metricManager.PluginCatalog()
+	plugins := []RepositoryPlugin{}
+	for _, p := range idx.Plugins {
+		if (q.Get("type") != "" && p.Type != q.Get("type")) || (q.Get("name") != "" && p.Name != q.Get("name")) {
+			continue
+		}
+		for _, lp := range catalog {
+			if lp.TypeName() == p.Type && lp.Name() == p.Name && lp.Version() == p.Version {
+				p.Loaded = true
+				break
+			}
+		}
+		plugins = append(plugins, p)
+	}
+	Write(200, RepositoryIndex{Plugins: plugins}, w)
+}
+
+// installPlugin downloads the plugin of the repository given in the request
+// body, verifies it and loads it.
+func (s *apiV2) installPlugin(w http.ResponseWriter, r *http.Request) {
+	in := &InstallPlugin{}
+	errCode, err := core.UnmarshalBody(in, r.Body)
+	if errCode != 0 && err != nil {
+		Write(errCode, FromError(err), w)
+		return
+	}
+	if in.Name == "" {
+		Write(400, FromError(ErrPluginNameRequired), w)
+		return
+	}
+	idx, code, err := s.repositoryIndex()
+	if err != nil {
+		Write(code, FromError(err), w)
+		return
+	}
+	var found *RepositoryPlugin
+	for i, p := range idx.Plugins {
+		if p.Name != in.Name || (in.Type != "" && p.Type != in.Type) || (in.Version > 0 && p.Version != in.Version) {
+			continue
+		}
+		if found == nil || p.Version > found.Version {
+			found = &idx.Plugins[i]
+		}
+	}
+	if found == nil {
+		Write(404, FromSnapError(serror.New(ErrPluginNotFound, map[string]interface{}{
+			"plugin-name":    in.Name,
+			"plugin-type":    in.Type,
+			"plugin-version": in.Version,
+		})), w)
+		return
+	}
+
+	u, su, err := s.repositoryURLs(found)
+	if err != nil {
+		Write(502, FromError(err), w)
+		return
+	}
+	restLogger.WithFields(log.Fields{
+		"_block":         "install-plugin",
+		"request-id":     r.Header.Get(api.RequestIDHeader),
+		"plugin-name":    found.Name,
+		"plugin-type":    found.Type,
+		"plugin-version": found.Version,
+	}).Info("Installing plugin from the plugin repository")
+	s.downloadPlugin(w, r, u, su, found.SHA256)
+}
+
+// repositoryIndex downloads the index of the plugin repository. The status
+// of the response is returned with the error when it cannot be downloaded.
+func (s *apiV2) repositoryIndex() (*RepositoryIndex, int, error) {
+	if s.pluginRepository == "" {
+		return nil, 501, ErrNoPluginRepository
+	}
+	u, err := pluginURL(s.pluginRepository)
+	if err != nil {
+		return nil, 500, err
+	}
+	b, err := download(u, maxPluginDownloadSize)
+	if err != nil {
+		return nil, 502, err
+	}
+	idx := &RepositoryIndex{}
+	if err := json.Unmarshal(b, idx); err != nil {
+		return nil, 502, err
+	}
+	return idx, 0, nil
+}
+
+// repositoryURLs returns the URLs of a plugin of the repository and of its
+// signature, if any, resolved against the URL of the index.
+func (s *apiV2) repositoryURLs(p *RepositoryPlugin) (*url.URL, *url.URL, error) {
+	if p.URL == "" || p.SHA256 == "" {
+		return nil, nil, ErrRepositoryPluginInvalid
+	}
+	base, err := pluginURL(s.pluginRepository)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolve := func(ref string) (*url.URL, error) {
+		r, err := url.Parse(ref)
+		if err != nil {
+			return nil, ErrInvalidPluginURI
+		}
+		return pluginURL(base.ResolveReference(r).String())
+	}
+	u, err := resolve(p.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.SignatureURL == "" {
+		return u, nil, nil
+	}
+	su, err := resolve(p.SignatureURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, su, nil
+}
@@ -0,0 +1,112 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+	"github.com/julienschmidt/httprouter"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestPluginRepository(t *testing.T) {
+	Convey("Given a plugin repository", t, func() {
+		content := []byte("#!/bin/sh\n")
+		sum := sha256.Sum256(content)
+		index := fmt.Sprintf(`{"plugins": [
+			{"name": "foo", "type": "collector", "version": 2, "sha256": "%[1]s", "url": "foo/2/snap-plugin-collector-foo"},
+			{"name": "foo", "type": "collector", "version": 7, "sha256": "%[1]s", "url": "foo/7/snap-plugin-collector-foo"},
+			{"name": "qux", "type": "publisher", "version": 1, "sha256": "00", "url": "qux/1/snap-plugin-publisher-qux"}
+		]}`, hex.EncodeToString(sum[:]))
+		var downloaded []string
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/repo/index.json":
+				w.Write([]byte(index))
+			case "/repo/foo/2/snap-plugin-collector-foo", "/repo/foo/7/snap-plugin-collector-foo", "/repo/qux/1/snap-plugin-publisher-qux":
+				downloaded = append(downloaded, r.URL.Path)
+				w.Write(content)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer ts.Close()
+		client := pluginClient
+		pluginClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		defer func() { pluginClient = client }()
+
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		s.SetPluginRepository(ts.URL + "/repo/index.json")
+		get := func(query string) (int, *RepositoryIndex) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/v2/plugins/available"+query, nil)
+			s.getPluginsByType(negroni.NewResponseWriter(rec), r, httprouter.Params{{Key: "type", Value: availablePluginsType}})
+			idx := &RepositoryIndex{}
+			json.Unmarshal(rec.Body.Bytes(), idx)
+			return rec.Code, idx
+		}
+		install := func(body string) int {
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest("POST", "/v2/plugins/install", strings.NewReader(body))
+			s.postPluginAction(rw, r, httprouter.Params{{Key: "type", Value: installPluginType}})
+			return rw.Status()
+		}
+
+		Convey("it lists the available plugins, telling the loaded ones", func() {
+			code, idx := get("?type=collector")
+			So(code, ShouldEqual, 200)
+			So(idx.Plugins, ShouldHaveLength, 2)
+			So(idx.Plugins[0].Loaded, ShouldBeTrue)
+			So(idx.Plugins[1].Loaded, ShouldBeFalse)
+		})
+		Convey("it installs the latest version of a plugin", func() {
+			So(install(`{"name": "foo"}`), ShouldEqual, 201)
+			So(downloaded, ShouldResemble, []string{"/repo/foo/7/snap-plugin-collector-foo"})
+		})
+		Convey("it installs the version asked for", func() {
+			So(install(`{"name": "foo", "version": 2}`), ShouldEqual, 201)
+			So(downloaded, ShouldResemble, []string{"/repo/foo/2/snap-plugin-collector-foo"})
+		})
+		Convey("it rejects a plugin not matching its checksum", func() {
+			So(install(`{"name": "qux"}`), ShouldEqual, 400)
+		})
+		Convey("it answers 404 for a plugin not in the repository", func() {
+			So(install(`{"name": "bar"}`), ShouldEqual, 404)
+		})
+		Convey("it answers 501 without a repository", func() {
+			s.SetPluginRepository("")
+			code, _ := get("")
+			So(code, ShouldEqual, 501)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+var (
+	// ErrSwapUnsupported is returned when the metric manager cannot swap
+	// plugins while tasks run
+	ErrSwapUnsupported = errors.New("swapping plugins is not supported")
+	// ErrSwapOnePlugin is returned when a swap does not upload exactly one
+	// plugin
+	ErrSwapOnePlugin = errors.New("a swap has to upload exactly one plugin")
+	// ErrInvalidVersion is returned when the version to swap out is not a
+	// number
+	ErrInvalidVersion = errors.New("version must be a positive integer")
+)
+
+// hotSwapper is implemented by metric managers able to replace a loaded
+// plugin with a new version without stopping the tasks using it.
+type hotSwapper interface {
+	HotSwap(in *core.RequestedPlugin, out core.Plugin) (core.CatalogedPlugin, serror.SnapError)
+}
+
+// PluginSwapResponse holds the plugin loaded and the plugin unloaded by a
+// swap.
+type PluginSwapResponse struct {
+	Loaded   Plugin `json:"loaded"`
+	Unloaded Plugin `json:"unloaded"`
+}
+
+// swapPlugin loads the plugin uploaded, moves the tasks to it and unloads
+// the version of the plugin given by the version query parameter, the
+// latest loaded one by default.
+func (s *apiV2) swapPlugin(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	hs, ok := s.metricManager.(hotSwapper)
+	if !ok {
+		Write(501, FromError(ErrSwapUnsupported), w)
+		return
+	}
+	plType := p.ByName("type")
+	plName := p.ByName("name")
+	f := map[string]interface{}{
+		"plugin-name": plName,
+		"plugin-type": plType,
+	}
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		var err error
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			Write(400, FromError(ErrInvalidVersion), w)
+			return
+		}
+	}
+	var out core.CatalogedPlugin
+	for _, pl := range s.metricManager.PluginCatalog() {
+		if pl.TypeName() != plType || pl.Name() != plName {
+			continue
+		}
+		if pl.Version() == version || (version == 0 && (out == nil || pl.Version() > out.Version())) {
+			out = pl
+		}
+	}
+	if out == nil {
+		Write(404, FromSnapError(serror.New(ErrPluginNotFound, f)), w)
+		return
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		Write(415, FromError(err), w)
+		return
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		Write(415, FromError(fmt.Errorf("unsupported media type: %s", mediaType)), w)
+		return
+	}
+	rps, code, err := s.readPluginParts(r, params["boundary"])
+	if err != nil {
+		Write(code, FromError(err), w)
+		return
+	}
+	if len(rps) != 1 {
+		removeRequestedPlugins(rps)
+		Write(400, FromError(ErrSwapOnePlugin), w)
+		return
+	}
+
+	restLogger.WithField("request-id", r.Header.Get(api.RequestIDHeader)).Info("Swapping plugin: ", rps[0].Path())
+	in, se := hs.HotSwap(rps[0], out)
+	if se != nil {
+		removeRequestedPlugins(rps)
+		statusCode := 500
+		switch se.Error() {
+		case control.ErrPluginNotFound.Error():
+			statusCode = 404
+		case control.ErrSwapPluginMismatch.Error():
+			statusCode = 400
+		case control.ErrSwapNotLatest.Error(), control.ErrSwapPinnedVersion.Error(), ErrPluginAlreadyLoaded:
+			statusCode = 409
+		case control.ErrSwapVerificationFailed.Error():
+			statusCode = 502
+		}
+		Write(statusCode, FromSnapError(se), w)
+		return
+	}
+	Write(200, PluginSwapResponse{
+		Loaded:   catalogedPluginBody(r.Host, in),
+		Unloaded: catalogedPluginBody(r.Host, out),
+	}, w)
+}
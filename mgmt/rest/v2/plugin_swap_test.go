@@ -0,0 +1,122 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/control"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+// swappingMetricManager swaps in version 5 of a plugin, records the plugin
+// swapped out, and fails with err when it is set
+type swappingMetricManager struct {
+	mock.MockManagesMetrics
+	out *core.Plugin
+	err error
+}
+
+func (m swappingMetricManager) HotSwap(in *core.RequestedPlugin, out core.Plugin) (core.CatalogedPlugin, serror.SnapError) {
+	if m.err != nil {
+		return nil, serror.New(m.err)
+	}
+	*m.out = out
+	return mock.MockLoadedPlugin{MyName: out.Name(), MyType: out.TypeName(), MyVersion: 5}, nil
+}
+
+func TestSwapPlugin(t *testing.T) {
+	Convey("Given a plugin uploaded to swap a loaded one", t, func() {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		part, err := mw.CreateFormFile("snap-plugins", "snap-plugin-collector-mock1")
+		So(err, ShouldBeNil)
+		part.Write([]byte("#!/bin/sh\n"))
+		So(mw.Close(), ShouldBeNil)
+
+		var out core.Plugin
+		m := swappingMetricManager{out: &out}
+		swap := func(m api.Metrics, name, query string) (int, *PluginSwapResponse) {
+			s := &apiV2{metricManager: m}
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			r := httptest.NewRequest("POST", "/v2/plugins/collector/"+name+"/swap"+query, bytes.NewReader(body.Bytes()))
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			s.swapPlugin(rw, r, httprouter.Params{
+				httprouter.Param{Key: "type", Value: "collector"},
+				httprouter.Param{Key: "name", Value: name},
+			})
+			resp := &PluginSwapResponse{}
+			json.Unmarshal(rec.Body.Bytes(), resp)
+			return rw.Status(), resp
+		}
+
+		Convey("the latest version of the plugin is swapped out by default", func() {
+			code, resp := swap(m, "foo", "")
+			So(code, ShouldEqual, 200)
+			So(out.Version(), ShouldEqual, 4)
+			So(resp.Loaded.Version, ShouldEqual, 5)
+			So(resp.Unloaded.Version, ShouldEqual, 4)
+		})
+		Convey("the version given is swapped out", func() {
+			code, _ := swap(m, "foo", "?version=2")
+			So(code, ShouldEqual, 200)
+			So(out.Version(), ShouldEqual, 2)
+		})
+		Convey("an invalid version is refused", func() {
+			code, _ := swap(m, "foo", "?version=latest")
+			So(code, ShouldEqual, 400)
+		})
+		Convey("a plugin not loaded cannot be swapped", func() {
+			code, _ := swap(m, "qux", "")
+			So(code, ShouldEqual, 404)
+			code, _ = swap(m, "foo", "?version=3")
+			So(code, ShouldEqual, 404)
+		})
+		Convey("tasks pinning the version swapped out are a conflict", func() {
+			m.err = control.ErrSwapPinnedVersion
+			code, _ := swap(m, "foo", "")
+			So(code, ShouldEqual, 409)
+		})
+		Convey("a new version failing to answer is a bad gateway", func() {
+			m.err = control.ErrSwapVerificationFailed
+			code, _ := swap(m, "foo", "")
+			So(code, ShouldEqual, 502)
+		})
+		Convey("a metric manager without hot swap cannot swap plugins", func() {
+			code, _ := swap(mock.MockManagesMetrics{}, "foo", "")
+			So(code, ShouldEqual, 501)
+		})
+	})
+}
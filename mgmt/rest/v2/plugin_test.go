@@ -0,0 +1,221 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestLoadPluginCheckSum(t *testing.T) {
+	Convey("Given a plugin uploaded with its checksum", t, func() {
+		content := []byte("#!/bin/sh\n")
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		part, err := mw.CreateFormFile("snap-plugins", "snap-plugin-collector-mock1")
+		So(err, ShouldBeNil)
+		part.Write(content)
+		So(mw.Close(), ShouldBeNil)
+
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		load := func(sum string) int {
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest("POST", "/v2/plugins", bytes.NewReader(body.Bytes()))
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			r.Header.Set(api.PluginChecksumHeader, sum)
+			s.loadPlugin(rw, r, nil)
+			return rw.Status()
+		}
+
+		Convey("it loads the plugin matching the checksum", func() {
+			sum := sha256.Sum256(content)
+			So(load(hex.EncodeToString(sum[:])), ShouldEqual, 201)
+		})
+		Convey("it rejects the plugin not matching the checksum", func() {
+			sum := sha256.Sum256([]byte("tampered"))
+			So(load(hex.EncodeToString(sum[:])), ShouldEqual, 400)
+		})
+	})
+}
+
+func TestLoadPluginMaxSize(t *testing.T) {
+	Convey("Given a maximum plugin upload size", t, func() {
+		content := []byte("#!/bin/sh\n")
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		part, err := mw.CreateFormFile("snap-plugins", "snap-plugin-collector-mock1")
+		So(err, ShouldBeNil)
+		part.Write(content)
+		So(mw.Close(), ShouldBeNil)
+
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		load := func(maxSize int64, body []byte, contentType string) int {
+			s.SetMaxPluginUploadSize(maxSize)
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest("POST", "/v2/plugins", bytes.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+			s.loadPlugin(rw, r, nil)
+			return rw.Status()
+		}
+
+		Convey("a plugin of the maximum size is loaded", func() {
+			So(load(int64(len(content)), body.Bytes(), mw.FormDataContentType()), ShouldEqual, 201)
+		})
+		Convey("a larger plugin is refused", func() {
+			So(load(int64(len(content)-1), body.Bytes(), mw.FormDataContentType()), ShouldEqual, 413)
+		})
+		Convey("a request without a plugin is refused", func() {
+			empty := &bytes.Buffer{}
+			mw := multipart.NewWriter(empty)
+			So(mw.Close(), ShouldBeNil)
+			So(load(0, empty.Bytes(), mw.FormDataContentType()), ShouldEqual, 400)
+		})
+	})
+}
+
+// signingMetricManager accepts unsigned plugins and the signature "good", and
+// records the signatures of the plugins loaded and the plugins unloaded. The
+// plugins named after "fail" fail to load.
+type signingMetricManager struct {
+	mock.MockManagesMetrics
+	loaded   *[]string
+	unloaded *[]string
+}
+
+func (m signingMetricManager) VerifySignature(rp *core.RequestedPlugin) serror.SnapError {
+	if rp.Signature() != nil && string(rp.Signature()) != "good" {
+		return serror.New(errors.New("invalid signature"))
+	}
+	return nil
+}
+
+func (m signingMetricManager) Load(rp *core.RequestedPlugin) (core.CatalogedPlugin, serror.SnapError) {
+	if strings.Contains(filepath.Base(rp.Path()), "fail") {
+		return nil, serror.New(errors.New("plugin failed to load"))
+	}
+	*m.loaded = append(*m.loaded, filepath.Base(rp.Path())+":"+string(rp.Signature()))
+	return m.MockManagesMetrics.Load(rp)
+}
+
+func (m signingMetricManager) Unload(pl core.Plugin) (core.CatalogedPlugin, serror.SnapError) {
+	*m.unloaded = append(*m.unloaded, pl.Name())
+	return m.MockManagesMetrics.Unload(pl)
+}
+
+func TestLoadPluginSignatures(t *testing.T) {
+	Convey("Given plugins uploaded with their signatures", t, func() {
+		loaded, unloaded := []string{}, []string{}
+		s := &apiV2{metricManager: signingMetricManager{loaded: &loaded, unloaded: &unloaded}}
+		load := func(files ...string) (int, *PluginsResponse) {
+			body := &bytes.Buffer{}
+			mw := multipart.NewWriter(body)
+			for _, f := range files {
+				// the content of a file is the middle of its name, e.g. the
+				// signature "good" for mock1.good.asc
+				part, err := mw.CreateFormFile("snap-plugins", f)
+				So(err, ShouldBeNil)
+				part.Write([]byte(strings.Split(f, ".")[1]))
+			}
+			So(mw.Close(), ShouldBeNil)
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			r := httptest.NewRequest("POST", "/v2/plugins", body)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			s.loadPlugin(rw, r, nil)
+			pr := &PluginsResponse{}
+			json.Unmarshal(rec.Body.Bytes(), pr)
+			return rw.Status(), pr
+		}
+
+		Convey("each signature goes with the plugin before it", func() {
+			code, pr := load("mock1.plugin", "mock1.good.asc", "mock2.plugin", "mock3.plugin", "mock3.good.asc")
+			So(code, ShouldEqual, 201)
+			So(pr.Plugins, ShouldHaveLength, 3)
+			So(loaded, ShouldResemble, []string{"mock1.plugin:good", "mock2.plugin:", "mock3.plugin:good"})
+		})
+		Convey("no plugin is loaded when a signature is invalid", func() {
+			code, _ := load("mock1.plugin", "mock1.good.asc", "mock2.plugin", "mock2.bad.asc")
+			So(code, ShouldEqual, 400)
+			So(loaded, ShouldBeEmpty)
+		})
+		Convey("a signature has to follow a plugin", func() {
+			code, _ := load("mock1.good.asc", "mock1.plugin")
+			So(code, ShouldEqual, 400)
+			code, _ = load("mock1.plugin", "mock1.good.asc", "mock1.good.asc")
+			So(code, ShouldEqual, 400)
+			So(loaded, ShouldBeEmpty)
+		})
+		Convey("the plugins loaded are unloaded when a load fails", func() {
+			code, _ := load("mock1.plugin", "mock2.fail", "mock3.plugin")
+			So(code, ShouldEqual, 500)
+			So(loaded, ShouldResemble, []string{"mock1.plugin:"})
+			So(unloaded, ShouldResemble, []string{"foo"})
+		})
+	})
+}
+
+func TestUnloadPluginVersions(t *testing.T) {
+	Convey("Given the versions of a plugin loaded", t, func() {
+		s := &apiV2{metricManager: mock.MockManagesMetrics{}}
+		unload := func(plType, plName string) (int, *PluginsResponse) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			s.unloadPluginVersions(rw, httptest.NewRequest("DELETE", "/v2/plugins/"+plType+"/"+plName, nil),
+				httprouter.Params{{Key: "type", Value: plType}, {Key: "name", Value: plName}})
+			pr := &PluginsResponse{}
+			json.Unmarshal(rec.Body.Bytes(), pr)
+			return rw.Status(), pr
+		}
+
+		Convey("all of them are unloaded at once", func() {
+			code, pr := unload("collector", "foo")
+			So(code, ShouldEqual, 200)
+			So(len(pr.Plugins), ShouldEqual, 2)
+			for _, p := range pr.Plugins {
+				So(p.Name, ShouldEqual, "foo")
+				So(p.Type, ShouldEqual, "collector")
+			}
+		})
+		Convey("a plugin not loaded is not found", func() {
+			code, _ := unload("collector", "bar")
+			So(code, ShouldEqual, 404)
+		})
+	})
+}
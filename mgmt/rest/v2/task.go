@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/intelsdi-x/snap/pkg/schedule"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
 	"github.com/julienschmidt/httprouter"
+	opentracing "github.com/opentracing/opentracing-go"
 )
 
 type TasksResponse struct {
@@ -55,6 +57,17 @@ type Task struct {
 
 type Tasks []Task
 
+// TaskErrorsResponse lists the last errors of the runs of a task
+type TaskErrorsResponse struct {
+	Errors []core.TaskRunError `json:"errors"`
+}
+
+// TaskStatsResponse holds the run counters and workflow latencies of a task
+type TaskStatsResponse struct {
+	ID string `json:"id"`
+	core.TaskStats
+}
+
 func (s Tasks) Len() int {
 	return len(s)
 }
@@ -74,11 +87,22 @@ func (s *Task) CreationTime() time.Time {
 func (s *apiV2) addTask(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	task, err := core.CreateTaskFromContent(r.Body, nil, s.taskManager.CreateTask)
 	if err != nil {
+		// the errors of the validation of the task are listed with their
+		// fields, such as the config items failing the policy of a plugin
+		if terr, ok := err.(*core.TaskCreationError); ok {
+			Write(500, FromSnapErrors(terr.Errors()), w)
+			return
+		}
 		Write(500, FromError(err), w)
 		return
 	}
+	// lets the trace of the request be found from the task
+	if span := opentracing.SpanFromContext(r.Context()); span != nil {
+		span.SetTag("task-id", task.ID())
+	}
 	taskB := AddSchedulerTaskFromTask(task)
 	taskB.Href = taskURI(r.Host, task)
+	setTaskETag(w, task)
 	Write(201, taskB, w)
 }
 
@@ -96,6 +120,10 @@ func (s *apiV2) getTasks(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 	}
 	sort.Sort(tasks)
 
+	if fields := requestedFields(r); fields != nil {
+		writeSparseList(w, "tasks", tasks, fields)
+		return
+	}
 	Write(200, TasksResponse{Tasks: tasks}, w)
 }
 
@@ -106,34 +134,90 @@ func (s *apiV2) getTask(w http.ResponseWriter, r *http.Request, p httprouter.Par
 		Write(404, FromError(err), w)
 		return
 	}
+	if r.URL.Query().Get("wait") != "" {
+		wait, state, err := parseTaskWait(r)
+		if err != nil {
+			Write(400, FromError(err), w)
+			return
+		}
+		if t, err = s.waitTaskState(r, t, wait, state); err != nil {
+			Write(404, FromError(err), w)
+			return
+		}
+	}
 	task := AddSchedulerTaskFromTask(t)
 	task.Href = taskURI(r.Host, t)
+	setTaskETag(w, t)
 	Write(200, task, w)
 }
 
+// getTaskErrors returns the last errors of the runs of a task, most recent
+// first. The optional 'limit' query parameter caps the number returned.
+func (s *apiV2) getTaskErrors(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	limit := -1
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			Write(400, FromError(ErrInvalidLimit), w)
+			return
+		}
+	}
+	t, err := s.taskManager.GetTask(p.ByName("id"))
+	if err != nil {
+		Write(404, FromError(err), w)
+		return
+	}
+	runErrors := t.RunErrors()
+	errs := make([]core.TaskRunError, 0, len(runErrors))
+	for i := len(runErrors) - 1; i >= 0 && len(errs) != limit; i-- {
+		errs = append(errs, runErrors[i])
+	}
+	Write(200, TaskErrorsResponse{Errors: errs}, w)
+}
+
+// getTaskStats returns the counters of the firings of a task along with the
+// latency percentiles of the last collections and publishes of its workflow.
+func (s *apiV2) getTaskStats(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	t, err := s.taskManager.GetTask(p.ByName("id"))
+	if err != nil {
+		Write(404, FromError(err), w)
+		return
+	}
+	Write(200, TaskStatsResponse{ID: t.ID(), TaskStats: t.Stats()}, w)
+}
+
 func (s *apiV2) updateTaskState(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	errs := make([]serror.SnapError, 0, 1)
 	id := p.ByName("id")
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
 	action, exist := r.URL.Query()["action"]
 	if !exist && len(action) > 0 {
 		errs = append(errs, serror.New(ErrNoActionSpecified))
 	} else {
 		switch action[0] {
 		case "enable":
-			_, err := s.taskManager.EnableTask(id)
+			_, err := s.taskManager.EnableTaskIfRevision(id, rev)
 			if err != nil {
 				errs = append(errs, serror.New(err))
 			}
 		case "start":
-			errs = s.taskManager.StartTask(id)
+			errs = s.taskManager.StartTaskIfRevision(id, rev)
 		case "stop":
-			errs = s.taskManager.StopTask(id)
+			errs = s.taskManager.StopTaskIfRevision(id, rev)
 		default:
 			errs = append(errs, serror.New(ErrWrongAction))
 		}
 	}
 
 	if len(errs) > 0 {
+		if strings.Contains(errs[0].Error(), ErrTaskRevisionMismatch) {
+			Write(409, FromSnapErrors(errs), w)
+			return
+		}
 		statusCode := 500
 		switch errs[0].Error() {
 		case ErrNoActionSpecified.Error():
@@ -153,12 +237,21 @@ func (s *apiV2) updateTaskState(w http.ResponseWriter, r *http.Request, p httpro
 
 func (s *apiV2) removeTask(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	id := p.ByName("id")
-	err := s.taskManager.RemoveTask(id)
+	rev, err := ifMatchRevision(r)
+	if err != nil {
+		Write(400, FromError(err), w)
+		return
+	}
+	err = s.taskManager.RemoveTaskIfRevision(id, rev)
 	if err != nil {
 		if strings.Contains(err.Error(), ErrTaskNotFound) {
 			Write(404, FromError(err), w)
 			return
 		}
+		if strings.Contains(err.Error(), ErrTaskRevisionMismatch) {
+			Write(409, FromError(err), w)
+			return
+		}
 		Write(500, FromError(err), w)
 		return
 	}
@@ -214,3 +307,21 @@ func (t *Task) assertSchedule(s schedule.Schedule) {
 		return
 	}
 }
+
+// ifMatchRevision returns the task revision expected by the If-Match header
+// of the request, 0 matches any revision.
+func ifMatchRevision(r *http.Request) (uint64, error) {
+	v := strings.Trim(strings.TrimPrefix(r.Header.Get("If-Match"), "W/"), `"`)
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	rev, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidIfMatch
+	}
+	return rev, nil
+}
+
+func setTaskETag(w http.ResponseWriter, t core.Task) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, t.Revision()))
+}
@@ -0,0 +1,139 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestGetTaskErrors(t *testing.T) {
+	Convey("Given a task which runs failed", t, func() {
+		s := &apiV2{taskManager: &mock.MockTaskManager{}}
+		get := func(query string) (int, *TaskErrorsResponse) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			s.getTaskErrors(rw, httptest.NewRequest("GET", "/v2/tasks/1234/errors"+query, nil),
+				httprouter.Params{{Key: "id", Value: "1234"}})
+			resp := &TaskErrorsResponse{}
+			json.Unmarshal(rec.Body.Bytes(), resp)
+			return rw.Status(), resp
+		}
+
+		Convey("its errors are returned most recent first", func() {
+			code, resp := get("")
+			So(code, ShouldEqual, 200)
+			So(resp.Errors, ShouldHaveLength, 2)
+			So(resp.Errors[0].Node, ShouldEqual, "publisher")
+			So(resp.Errors[0].PluginName, ShouldEqual, "file")
+			So(resp.Errors[0].Message, ShouldEqual, "disk full")
+			So(resp.Errors[1].Node, ShouldEqual, "collector")
+		})
+		Convey("the number of errors returned can be limited", func() {
+			code, resp := get("?limit=1")
+			So(code, ShouldEqual, 200)
+			So(resp.Errors, ShouldHaveLength, 1)
+			So(resp.Errors[0].Message, ShouldEqual, "disk full")
+		})
+		Convey("an invalid limit is refused", func() {
+			code, _ := get("?limit=-1")
+			So(code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestGetTaskStats(t *testing.T) {
+	Convey("Given a task which ran", t, func() {
+		s := &apiV2{taskManager: &mock.MockTaskManager{}}
+		rec := httptest.NewRecorder()
+		rw := negroni.NewResponseWriter(rec)
+		s.getTaskStats(rw, httptest.NewRequest("GET", "/v2/tasks/1234/stats", nil),
+			httprouter.Params{{Key: "id", Value: "1234"}})
+
+		Convey("its counters and latencies are returned", func() {
+			So(rw.Status(), ShouldEqual, 200)
+			resp := &TaskStatsResponse{}
+			So(json.Unmarshal(rec.Body.Bytes(), resp), ShouldBeNil)
+			So(resp.ID, ShouldEqual, "1234")
+			So(resp.HitCount, ShouldEqual, 22)
+			So(resp.MissCount, ShouldEqual, 4)
+			So(resp.SkippedCount, ShouldEqual, 1)
+			So(resp.CollectLatency.P99, ShouldEqual, 45)
+			So(resp.PublishLatency.Max, ShouldEqual, 9)
+		})
+	})
+}
+
+func TestGetTaskWait(t *testing.T) {
+	Convey("Given a running task", t, func() {
+		s := &apiV2{wg: &sync.WaitGroup{}, killChan: make(chan struct{}), taskManager: &mock.MockTaskManager{}}
+		get := func(query string) (int, *Task) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			s.getTask(rw, httptest.NewRequest("GET", "/v2/tasks/1234"+query, nil),
+				httprouter.Params{{Key: "id", Value: "1234"}})
+			resp := &Task{}
+			json.Unmarshal(rec.Body.Bytes(), resp)
+			return rw.Status(), resp
+		}
+
+		Convey("a wait for its current state returns at once", func() {
+			start := time.Now()
+			code, resp := get("?wait=30s&state=running")
+			So(code, ShouldEqual, 200)
+			So(resp.State, ShouldEqual, "Running")
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+		Convey("a wait for another state returns the task when it expires", func() {
+			start := time.Now()
+			code, resp := get("?wait=300ms&state=Ended")
+			So(code, ShouldEqual, 200)
+			So(resp.State, ShouldEqual, "Running")
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 300*time.Millisecond)
+		})
+		Convey("a wait for any transition returns the task when it expires", func() {
+			code, resp := get("?wait=300ms")
+			So(code, ShouldEqual, 200)
+			So(resp.State, ShouldEqual, "Running")
+		})
+		Convey("an invalid wait is refused", func() {
+			code, _ := get("?wait=soon&state=Ended")
+			So(code, ShouldEqual, 400)
+			code, _ = get("?wait=-1s")
+			So(code, ShouldEqual, 400)
+		})
+		Convey("an unknown state is refused", func() {
+			code, _ := get("?wait=30s&state=Finished")
+			So(code, ShouldEqual, 400)
+		})
+	})
+}
@@ -0,0 +1,131 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+const (
+	// maxTaskWait caps the time a request waits for a task state
+	maxTaskWait = 5 * time.Minute
+	// taskWaitPollInterval is how often the state of a waited task is checked
+	// besides the notifications of its watcher
+	taskWaitPollInterval = 250 * time.Millisecond
+)
+
+var (
+	ErrInvalidWait      = errors.New("wait must be a positive duration, e.g. 30s")
+	ErrInvalidTaskState = errors.New("state must be one of Running, Stopped, Stopping, Ended or Disabled")
+)
+
+// parseTaskWait reads the 'wait' and 'state' query parameters of a request
+// for a task. The state is returned in its canonical form, empty when any
+// transition from the current state is waited for.
+func parseTaskWait(r *http.Request) (time.Duration, string, error) {
+	wait, err := time.ParseDuration(r.URL.Query().Get("wait"))
+	if err != nil || wait <= 0 {
+		return 0, "", ErrInvalidWait
+	}
+	if wait > maxTaskWait {
+		wait = maxTaskWait
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		return wait, "", nil
+	}
+	for _, s := range core.TaskStateLookup {
+		if strings.EqualFold(s, state) {
+			return wait, s, nil
+		}
+	}
+	return 0, "", ErrInvalidTaskState
+}
+
+// waitTaskState blocks until the task reaches the given state, or leaves its
+// current state when none is given, and returns the task as it then is. The
+// task is returned as is once the wait expires.
+func (s *apiV2) waitTaskState(r *http.Request, t core.Task, wait time.Duration, state string) (core.Task, error) {
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	from := t.State().String()
+	reached := func(t core.Task) bool {
+		if state == "" {
+			return t.State().String() != from
+		}
+		return t.State().String() == state
+	}
+
+	// the watcher only wakes the wait up, the state is read from the task
+	tw := &taskStateWatcher{changed: make(chan struct{}, 1)}
+	tc, err := s.taskManager.WatchTask(t.ID(), tw)
+	if err != nil {
+		return nil, err
+	}
+	if tc != nil {
+		defer tc.Close()
+	}
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	poll := time.NewTicker(taskWaitPollInterval)
+	defer poll.Stop()
+	for !reached(t) {
+		select {
+		case <-tw.changed:
+		case <-poll.C:
+		case <-timeout.C:
+			return t, nil
+		case <-r.Context().Done():
+			return t, nil
+		case <-s.killChan:
+			return t, nil
+		}
+		if t, err = s.taskManager.GetTask(t.ID()); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// taskStateWatcher signals the state changes of a task without blocking the
+// scheduler which notifies it
+type taskStateWatcher struct {
+	changed chan struct{}
+}
+
+func (t *taskStateWatcher) notify() {
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (t *taskStateWatcher) CatchCollection([]core.Metric) {}
+func (t *taskStateWatcher) CatchTaskStarted()             { t.notify() }
+func (t *taskStateWatcher) CatchTaskStopped()             { t.notify() }
+func (t *taskStateWatcher) CatchTaskEnded()               { t.notify() }
+func (t *taskStateWatcher) CatchTaskDisabled(string)      { t.notify() }
@@ -0,0 +1,337 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pborman/uuid"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+const (
+	// UploadOffsetHeader is the header carrying the offset, in bytes, at which
+	// a chunk is appended to a plugin upload and the offset reached after it.
+	UploadOffsetHeader = "Upload-Offset"
+
+	// uploadExpiry is how long an upload is kept without receiving a chunk.
+	uploadExpiry = 24 * time.Hour
+)
+
+var (
+	ErrUploadNotFound       = errors.New("upload not found")
+	ErrUploadNameInvalid    = errors.New("upload name must be the file name of the plugin")
+	ErrUploadOffsetRequired = errors.New("Upload-Offset header is required")
+	ErrUploadOffsetMismatch = errors.New("Upload-Offset does not match the offset of the upload")
+	ErrUploadTooLarge       = errors.New("chunk exceeds the size of the upload")
+	ErrUploadIncomplete     = errors.New("upload is not complete")
+	ErrUploadSizeInvalid    = errors.New("upload size must not be negative")
+)
+
+// PluginUpload is a plugin uploaded in chunks, resuming from its offset after
+// an interruption, and loaded once committed.
+type PluginUpload struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Href   string `json:"href"`
+}
+
+// uploadCommit is the optional body of the request committing an upload.
+type uploadCommit struct {
+	// Signature is the base64 encoded signature (.asc) of the plugin.
+	Signature []byte `json:"signature,omitempty"`
+}
+
+type pluginUpload struct {
+	sync.Mutex
+	PluginUpload
+	path    string
+	updated time.Time
+}
+
+// pluginUploads are the plugin uploads in progress.
+type pluginUploads struct {
+	sync.Mutex
+	uploads map[string]*pluginUpload
+}
+
+func newPluginUploads() *pluginUploads {
+	return &pluginUploads{uploads: map[string]*pluginUpload{}}
+}
+
+func (u *pluginUploads) get(id string) *pluginUpload {
+	u.Lock()
+	defer u.Unlock()
+	return u.uploads[id]
+}
+
+func (u *pluginUploads) add(up *pluginUpload) {
+	u.Lock()
+	defer u.Unlock()
+	u.uploads[up.ID] = up
+}
+
+func (u *pluginUploads) remove(id string) {
+	u.Lock()
+	up, ok := u.uploads[id]
+	delete(u.uploads, id)
+	u.Unlock()
+	if ok {
+		os.RemoveAll(filepath.Dir(up.path))
+	}
+}
+
+// expire removes the uploads which have not received a chunk in uploadExpiry.
+func (u *pluginUploads) expire() {
+	u.Lock()
+	var expired []string
+	for id, up := range u.uploads {
+		up.Lock()
+		if time.Since(up.updated) > uploadExpiry {
+			expired = append(expired, id)
+		}
+		up.Unlock()
+	}
+	u.Unlock()
+	for _, id := range expired {
+		restLogger.WithFields(log.Fields{
+			"_block":    "expire-uploads",
+			"upload-id": id,
+		}).Info("Removing expired plugin upload")
+		u.remove(id)
+	}
+}
+
+func uploadURI(host, id string) string {
+	return fmt.Sprintf("%s://%s/%s/uploads/%s", protocolPrefix, host, version, id)
+}
+
+func (up *pluginUpload) body(host string) *PluginUpload {
+	b := up.PluginUpload
+	b.Href = uploadURI(host, up.ID)
+	return &b
+}
+
+// createUpload starts a plugin upload, writing its chunks to an empty file.
+func (s *apiV2) createUpload(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.uploads.expire()
+	req := &PluginUpload{}
+	errCode, err := core.UnmarshalBody(req, r.Body)
+	if errCode != 0 && err != nil {
+		Write(errCode, FromError(err), w)
+		return
+	}
+	if req.Name == "" || req.Name != filepath.Base(req.Name) || req.Name == "." || req.Name == ".." {
+		Write(400, FromError(ErrUploadNameInvalid), w)
+		return
+	}
+	if req.Size < 0 {
+		Write(400, FromError(ErrUploadSizeInvalid), w)
+		return
+	}
+	if s.maxUploadSize > 0 && req.Size > s.maxUploadSize {
+		Write(413, FromError(core.ErrPluginTooLarge), w)
+		return
+	}
+	dir, err := ioutil.TempDir(s.metricManager.GetTempDir(), "snap-upload-")
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	path := filepath.Join(dir, req.Name)
+	f, err := os.Create(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		Write(500, FromError(err), w)
+		return
+	}
+	f.Close()
+	up := &pluginUpload{
+		PluginUpload: PluginUpload{
+			ID:     uuid.New(),
+			Name:   req.Name,
+			Size:   req.Size,
+			SHA256: req.SHA256,
+		},
+		path:    path,
+		updated: time.Now(),
+	}
+	s.uploads.add(up)
+	w.Header().Set(UploadOffsetHeader, "0")
+	Write(201, up.body(r.Host), w)
+}
+
+// getUpload returns the upload with the offset to resume it from.
+func (s *apiV2) getUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	up := s.uploads.get(p.ByName("id"))
+	if up == nil {
+		Write(404, FromError(ErrUploadNotFound), w)
+		return
+	}
+	up.Lock()
+	defer up.Unlock()
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+	Write(200, up.body(r.Host), w)
+}
+
+// appendUpload appends the request body to the upload at the offset given in
+// the Upload-Offset header, which has to be the offset of the upload. The
+// bytes received are kept when the request is interrupted, so the upload
+// resumes from them.
+func (s *apiV2) appendUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	up := s.uploads.get(p.ByName("id"))
+	if up == nil {
+		Write(404, FromError(ErrUploadNotFound), w)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get(UploadOffsetHeader), 10, 64)
+	if err != nil {
+		Write(400, FromError(ErrUploadOffsetRequired), w)
+		return
+	}
+
+	up.Lock()
+	defer up.Unlock()
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+	if offset != up.Offset {
+		Write(409, FromError(ErrUploadOffsetMismatch), w)
+		return
+	}
+	f, err := os.OpenFile(up.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	defer f.Close()
+	var body io.Reader = r.Body
+	// uploads of unknown size are bounded by the maximum upload size
+	size, tooLarge := up.Size, ErrUploadTooLarge
+	if size == 0 {
+		size, tooLarge = s.maxUploadSize, core.ErrPluginTooLarge
+	}
+	if size > 0 {
+		// read one byte past the size to tell a chunk too large
+		body = io.LimitReader(r.Body, size-up.Offset+1)
+	}
+	n, err := io.Copy(f, body)
+	up.Offset += n
+	up.updated = time.Now()
+	if size > 0 && up.Offset > size {
+		up.Offset = size
+		err = f.Truncate(size)
+		if err == nil {
+			err = tooLarge
+		}
+	}
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(up.Offset, 10))
+	if err != nil {
+		restLogger.WithFields(log.Fields{
+			"_block":     "append-upload",
+			"request-id": r.Header.Get(api.RequestIDHeader),
+			"upload-id":  up.ID,
+			"offset":     up.Offset,
+		}).Warn(err)
+		code := 500
+		switch err {
+		case ErrUploadTooLarge:
+			code = 400
+		case core.ErrPluginTooLarge:
+			code = 413
+		}
+		Write(code, FromError(err), w)
+		return
+	}
+	Write(200, up.body(r.Host), w)
+}
+
+// commitUpload loads the plugin uploaded once all its chunks are received
+// and it matches its checksum.
+func (s *apiV2) commitUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	up := s.uploads.get(p.ByName("id"))
+	if up == nil {
+		Write(404, FromError(ErrUploadNotFound), w)
+		return
+	}
+	commit := &uploadCommit{}
+	if r.ContentLength != 0 {
+		errCode, err := core.UnmarshalBody(commit, r.Body)
+		if errCode != 0 && err != nil {
+			Write(errCode, FromError(err), w)
+			return
+		}
+	}
+
+	up.Lock()
+	if up.Size > 0 && up.Offset != up.Size {
+		up.Unlock()
+		Write(409, FromError(ErrUploadIncomplete), w)
+		return
+	}
+	f, err := os.Open(up.path)
+	if err != nil {
+		up.Unlock()
+		Write(500, FromError(err), w)
+		return
+	}
+	rp, err := core.NewRequestedPluginFromReader(up.Name, s.metricManager.GetTempDir(), f, 0)
+	f.Close()
+	up.Unlock()
+	if err != nil {
+		Write(500, FromError(err), w)
+		return
+	}
+	if up.SHA256 != "" {
+		if err := rp.VerifyCheckSum(up.SHA256); err != nil {
+			removeRequestedPlugin(rp)
+			Write(400, FromError(err), w)
+			return
+		}
+	}
+	s.uploads.remove(up.ID)
+	rp.SetSignature(commit.Signature)
+	s.loadRequestedPlugin(w, r, rp)
+}
+
+// deleteUpload abandons an upload.
+func (s *apiV2) deleteUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id := p.ByName("id")
+	if s.uploads.get(id) == nil {
+		Write(404, FromError(ErrUploadNotFound), w)
+		return
+	}
+	s.uploads.remove(id)
+	Write(204, nil, w)
+}
@@ -0,0 +1,117 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v2/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestPluginUploads(t *testing.T) {
+	Convey("Given a plugin uploaded in chunks", t, func() {
+		s := New(&sync.WaitGroup{}, make(chan struct{}), "http")
+		s.BindMetricManager(mock.MockManagesMetrics{})
+		content := "#!/bin/sh\necho mock\n"
+		sum := sha256.Sum256([]byte(content))
+
+		serve := func(h httprouter.Handle, method, id string, body io.Reader, offset int64) negroni.ResponseWriter {
+			rw := negroni.NewResponseWriter(httptest.NewRecorder())
+			r := httptest.NewRequest(method, "/v2/uploads/"+id, body)
+			if offset >= 0 {
+				r.Header.Set(UploadOffsetHeader, strconv.FormatInt(offset, 10))
+			}
+			h(rw, r, httprouter.Params{{Key: "id", Value: id}})
+			return rw
+		}
+		rec := httptest.NewRecorder()
+		rw := negroni.NewResponseWriter(rec)
+		s.createUpload(rw, httptest.NewRequest("POST", "/v2/uploads", strings.NewReader(
+			`{"name": "snap-plugin-collector-mock1", "size": `+strconv.Itoa(len(content))+`, "sha256": "`+hex.EncodeToString(sum[:])+`"}`)), nil)
+		So(rw.Status(), ShouldEqual, 201)
+		up := &PluginUpload{}
+		So(json.Unmarshal(rec.Body.Bytes(), up), ShouldBeNil)
+		So(up.ID, ShouldNotBeEmpty)
+		So(up.Offset, ShouldEqual, 0)
+		defer s.uploads.remove(up.ID)
+
+		Convey("chunks are appended at the offset of the upload", func() {
+			rw := serve(s.appendUpload, "PATCH", up.ID, strings.NewReader(content[:10]), 0)
+			So(rw.Status(), ShouldEqual, 200)
+			So(rw.Header().Get(UploadOffsetHeader), ShouldEqual, "10")
+
+			Convey("a chunk at another offset is refused with the offset to resume from", func() {
+				rw := serve(s.appendUpload, "PATCH", up.ID, strings.NewReader(content), 0)
+				So(rw.Status(), ShouldEqual, 409)
+				So(rw.Header().Get(UploadOffsetHeader), ShouldEqual, "10")
+			})
+			Convey("the upload reports the offset to resume from", func() {
+				rw := serve(s.getUpload, "GET", up.ID, nil, -1)
+				So(rw.Status(), ShouldEqual, 200)
+				So(rw.Header().Get(UploadOffsetHeader), ShouldEqual, "10")
+			})
+			Convey("the upload can not be committed before it is complete", func() {
+				rw := serve(s.commitUpload, "POST", up.ID, nil, -1)
+				So(rw.Status(), ShouldEqual, 409)
+			})
+			Convey("the upload is loaded once complete and committed", func() {
+				rw := serve(s.appendUpload, "PATCH", up.ID, strings.NewReader(content[10:]), 10)
+				So(rw.Status(), ShouldEqual, 200)
+				rw = serve(s.commitUpload, "POST", up.ID, nil, -1)
+				So(rw.Status(), ShouldEqual, 201)
+				So(s.uploads.get(up.ID), ShouldBeNil)
+			})
+			Convey("a chunk past the size of the upload is refused", func() {
+				rw := serve(s.appendUpload, "PATCH", up.ID, strings.NewReader(content), 10)
+				So(rw.Status(), ShouldEqual, 400)
+				So(rw.Header().Get(UploadOffsetHeader), ShouldEqual, strconv.Itoa(len(content)))
+			})
+		})
+		Convey("an upload can be abandoned", func() {
+			rw := serve(s.deleteUpload, "DELETE", up.ID, nil, -1)
+			So(rw.Status(), ShouldEqual, 204)
+			rw = serve(s.getUpload, "GET", up.ID, nil, -1)
+			So(rw.Status(), ShouldEqual, 404)
+		})
+		Convey("an upload needs the file name of the plugin", func() {
+			rw := serve(s.createUpload, "POST", "", strings.NewReader(`{"name": "../snap-plugin-collector-mock1"}`), -1)
+			So(rw.Status(), ShouldEqual, 400)
+		})
+		Convey("an upload needs a size that is not negative", func() {
+			rw := serve(s.createUpload, "POST", "", strings.NewReader(`{"name": "snap-plugin-collector-mock1", "size": -1}`), -1)
+			So(rw.Status(), ShouldEqual, 400)
+		})
+	})
+}
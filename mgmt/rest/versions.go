@@ -0,0 +1,93 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+)
+
+// splitVersion splits a route path into its API version (i.e. 'v1') and the
+// rest of the path.
+func splitVersion(path string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "v") {
+		return "", path
+	}
+	if _, err := strconv.Atoi(parts[0][1:]); err != nil {
+		return "", path
+	}
+	return parts[0], "/" + parts[1]
+}
+
+func versionNumber(v string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(v, "v"))
+	return n
+}
+
+// successorVersions returns, for each deprecated route ("METHOD /vN/path"),
+// the most recent API version serving the same method and path.
+func successorVersions(apis []api.API) map[string]string {
+	latest := map[string]string{}
+	for _, a := range apis {
+		for _, route := range a.GetRoutes() {
+			v, p := splitVersion(route.Path)
+			if v == "" {
+				continue
+			}
+			key := route.Method + " " + p
+			if l, ok := latest[key]; !ok || versionNumber(v) > versionNumber(l) {
+				latest[key] = v
+			}
+		}
+	}
+	successors := map[string]string{}
+	for _, a := range apis {
+		for _, route := range a.GetRoutes() {
+			if !route.Deprecated {
+				continue
+			}
+			v, p := splitVersion(route.Path)
+			if l := latest[route.Method+" "+p]; v != "" && l != v {
+				successors[route.Method+" "+route.Path] = l
+			}
+		}
+	}
+	return successors
+}
+
+// deprecated wraps the handler of a route superseded in a more recent API
+// version so that its responses tell clients about the deprecation and where
+// the route has moved to.
+func deprecated(successor string, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if _, path := splitVersion(r.URL.Path); path != r.URL.Path {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", fmt.Sprintf("</%s%s>; rel=\"successor-version\"", successor, path))
+		}
+		h(w, r, p)
+	}
+}
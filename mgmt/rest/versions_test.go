@@ -0,0 +1,85 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type routesAPI []api.Route
+
+func (a routesAPI) GetRoutes() []api.Route        { return a }
+func (a routesAPI) BindMetricManager(api.Metrics) {}
+func (a routesAPI) BindTaskManager(api.Tasks)     {}
+func (a routesAPI) BindTribeManager(api.Tribe)    {}
+func (a routesAPI) BindConfigManager(api.Config)  {}
+
+func TestSuccessorVersions(t *testing.T) {
+	Convey("Given routes served by several API versions", t, func() {
+		apis := []api.API{
+			routesAPI{
+				api.Route{Method: "GET", Path: "/v1/plugins", Deprecated: true},
+				api.Route{Method: "PUT", Path: "/v1/tasks/:id", Deprecated: true},
+				api.Route{Method: "GET", Path: "/v1/tasks", Deprecated: true},
+				api.Route{Method: "GET", Path: "/v1/workflows"},
+			},
+			routesAPI{
+				api.Route{Method: "GET", Path: "/v2/plugins"},
+				api.Route{Method: "GET", Path: "/v2/tasks"},
+				api.Route{Method: "GET", Path: "/v2/workflows"},
+			},
+			routesAPI{
+				api.Route{Method: "GET", Path: "/v3/tasks"},
+			},
+		}
+		successors := successorVersions(apis)
+		Convey("deprecated routes point to the most recent version serving them", func() {
+			So(successors["GET /v1/plugins"], ShouldEqual, "v2")
+			So(successors["GET /v1/tasks"], ShouldEqual, "v3")
+		})
+		Convey("routes without a successor or not deprecated are left alone", func() {
+			So(successors, ShouldNotContainKey, "PUT /v1/tasks/:id")
+			So(successors, ShouldNotContainKey, "GET /v1/workflows")
+			So(len(successors), ShouldEqual, 2)
+		})
+	})
+}
+
+func TestDeprecated(t *testing.T) {
+	Convey("Responses of deprecated routes link to their successor", t, func() {
+		h := deprecated("v2", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			w.WriteHeader(200)
+		})
+		rw := httptest.NewRecorder()
+		h(rw, httptest.NewRequest("GET", "/v1/plugins/collector/mock/1", nil), nil)
+		So(rw.Code, ShouldEqual, 200)
+		So(rw.Header().Get("Deprecation"), ShouldEqual, "true")
+		So(rw.Header().Get("Link"), ShouldEqual, `</v2/plugins/collector/mock/1>; rel="successor-version"`)
+	})
+}
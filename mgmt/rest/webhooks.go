@@ -0,0 +1,364 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pborman/uuid"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/api"
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+)
+
+const (
+	// WebhookEventHeader, WebhookDeliveryHeader and WebhookSignatureHeader
+	// are the headers of the deliveries of a webhook, carrying the namespace
+	// of the event, the ID of the delivery, the same for all its attempts,
+	// and the hex encoded HMAC-SHA256 of the payload keyed with the secret of
+	// the webhook, prefixed with "sha256=".
+	WebhookEventHeader     = "X-Snap-Event"
+	WebhookDeliveryHeader  = "X-Snap-Delivery"
+	WebhookSignatureHeader = "X-Snap-Signature"
+
+	webhooksPath = "/notifications/webhooks"
+	// webhookQueueSize is the number of events queued for a webhook before
+	// further events are dropped for it.
+	webhookQueueSize = 256
+	// webhookAttempts is the number of times a delivery is attempted, waiting
+	// webhookBackoff after the first failure, and twice longer after each of
+	// the next ones.
+	webhookAttempts = 5
+	webhookBackoff  = time.Second
+	webhookTimeout  = 10 * time.Second
+)
+
+var (
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+)
+
+// Webhook is a callback URL to which the daemon events, of the namespaces
+// given when any, are delivered.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Events are the namespace prefixes of the events delivered, e.g.
+	// "Scheduler.TaskDisabled" or "Control.AvailablePluginDead", all the
+	// events being delivered when empty.
+	Events []string `json:"events,omitempty"`
+	// Secret is the key signing the payloads, generated when not given. It
+	// is only returned when the webhook is registered.
+	Secret  string    `json:"secret,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// WebhooksResponse is the body of GET /notifications/webhooks.
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// webhook is a registered webhook with the queue of the events to deliver.
+type webhook struct {
+	Webhook
+	queue chan *daemonEvent
+	done  chan struct{}
+}
+
+// webhooks delivers the daemon events to the webhooks registered, each of
+// them having its own queue so that a slow or failing callback does not
+// delay the others.
+type webhooks struct {
+	sync.Mutex
+	hooks    map[string]*webhook
+	client   *http.Client
+	backoff  time.Duration
+	killChan chan struct{}
+	wg       *sync.WaitGroup
+}
+
+func newWebhooks(wg *sync.WaitGroup, killChan chan struct{}) *webhooks {
+	return &webhooks{
+		hooks:    map[string]*webhook{},
+		client:   &http.Client{Timeout: webhookTimeout},
+		backoff:  webhookBackoff,
+		killChan: killChan,
+		wg:       wg,
+	}
+}
+
+func (wh *webhooks) add(hook Webhook) {
+	h := &webhook{
+		Webhook: hook,
+		queue:   make(chan *daemonEvent, webhookQueueSize),
+		done:    make(chan struct{}),
+	}
+	wh.Lock()
+	wh.hooks[h.ID] = h
+	wh.Unlock()
+	wh.wg.Add(1)
+	go wh.deliverAll(h)
+}
+
+func (wh *webhooks) remove(id string) bool {
+	wh.Lock()
+	defer wh.Unlock()
+	h, ok := wh.hooks[id]
+	if ok {
+		delete(wh.hooks, id)
+		close(h.done)
+	}
+	return ok
+}
+
+func (wh *webhooks) get(id string) (Webhook, bool) {
+	wh.Lock()
+	defer wh.Unlock()
+	h, ok := wh.hooks[id]
+	if !ok {
+		return Webhook{}, false
+	}
+	return h.Webhook, true
+}
+
+// list returns the webhooks registered, without their secrets, by date of
+// registration.
+func (wh *webhooks) list() []Webhook {
+	wh.Lock()
+	hooks := make([]Webhook, 0, len(wh.hooks))
+	for _, h := range wh.hooks {
+		hook := h.Webhook
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+	wh.Unlock()
+	sort.Sort(webhooksByCreation(hooks))
+	return hooks
+}
+
+type webhooksByCreation []Webhook
+
+func (w webhooksByCreation) Len() int           { return len(w) }
+func (w webhooksByCreation) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
+func (w webhooksByCreation) Less(i, j int) bool { return w[i].Created.Before(w[j].Created) }
+
+// dispatch queues the event for the webhooks it matches, without blocking.
+func (wh *webhooks) dispatch(e *daemonEvent) {
+	wh.Lock()
+	defer wh.Unlock()
+	for _, h := range wh.hooks {
+		if !matchesNamespace(e.Namespace, h.Events) {
+			continue
+		}
+		select {
+		case h.queue <- e:
+		default:
+			restLogger.WithFields(log.Fields{
+				"_block":  "dispatch-webhook",
+				"webhook": h.ID,
+				"event":   e.Namespace,
+			}).Warn("webhook is not keeping up; dropping event")
+		}
+	}
+}
+
+// run dispatches the daemon events to the webhooks until snapteld stops.
+func (wh *webhooks) run(events *eventBroker) {
+	ch := events.subscribe()
+	wh.wg.Add(1)
+	go func() {
+		defer wh.wg.Done()
+		defer events.unsubscribe(ch)
+		for {
+			select {
+			case e := <-ch:
+				wh.dispatch(e)
+			case <-wh.killChan:
+				return
+			}
+		}
+	}()
+}
+
+// deliverAll delivers the events queued for the webhook, in order, until it
+// is removed or snapteld stops.
+func (wh *webhooks) deliverAll(h *webhook) {
+	defer wh.wg.Done()
+	for {
+		select {
+		case e := <-h.queue:
+			wh.deliver(h, e)
+		case <-h.done:
+			return
+		case <-wh.killChan:
+			return
+		}
+	}
+}
+
+// deliver posts the event to the webhook, attempting it again after a
+// backoff while the callback fails or does not answer with a 2xx status.
+func (wh *webhooks) deliver(h *webhook, e *daemonEvent) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write(payload)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	delivery := uuid.New()
+	logger := restLogger.WithFields(log.Fields{
+		"_block":   "deliver-webhook",
+		"webhook":  h.ID,
+		"delivery": delivery,
+		"event":    e.Namespace,
+	})
+
+	backoff := wh.backoff
+	for attempt := 1; ; attempt++ {
+		err := wh.post(h.URL, payload, map[string]string{
+			WebhookEventHeader:     e.Namespace,
+			WebhookDeliveryHeader:  delivery,
+			WebhookSignatureHeader: signature,
+		})
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			logger.WithError(err).Error("Failed to deliver event to webhook")
+			return
+		}
+		logger.WithError(err).WithField("attempt", attempt).Warn("Failed to deliver event to webhook, retrying")
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-h.done:
+			return
+		case <-wh.killChan:
+			return
+		}
+	}
+}
+
+func (wh *webhooks) post(url string, payload []byte, header map[string]string) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Server) addWebhookRoutes() {
+	s.r.GET(webhooksPath, s.authorize(api.RoleAdmin, s.getWebhooks))
+	s.r.POST(webhooksPath, s.authorize(api.RoleAdmin, s.addWebhook))
+	s.r.GET(webhooksPath+"/:id", s.authorize(api.RoleAdmin, s.getWebhook))
+	s.r.DELETE(webhooksPath+"/:id", s.authorize(api.RoleAdmin, s.removeWebhook))
+}
+
+// addWebhook registers a webhook, e.g. {"url": "https://alerts.example.com",
+// "events": ["Scheduler.TaskDisabled", "Control.AvailablePluginDead"]}.
+func (s *Server) addWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	hook := Webhook{}
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		rbody.Write(400, rbody.FromError(err), w)
+		return
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		rbody.Write(400, rbody.FromError(ErrInvalidWebhookURL), w)
+		return
+	}
+	if hook.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			rbody.Write(500, rbody.FromError(err), w)
+			return
+		}
+		hook.Secret = hex.EncodeToString(b)
+	}
+	hook.ID = uuid.New()
+	hook.Created = time.Now().UTC()
+	s.webhooks.add(hook)
+	requestLogger(r).WithFields(log.Fields{
+		"_block":  "add-webhook",
+		"webhook": hook.ID,
+		"url":     hook.URL,
+		"events":  hook.Events,
+	}).Info("Webhook registered")
+	writeWebhookBody(201, hook, w)
+}
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writeWebhookBody(200, &WebhooksResponse{Webhooks: s.webhooks.list()}, w)
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	hook, ok := s.webhooks.get(p.ByName("id"))
+	if !ok {
+		rbody.Write(404, rbody.FromError(ErrWebhookNotFound), w)
+		return
+	}
+	hook.Secret = ""
+	writeWebhookBody(200, hook, w)
+}
+
+func (s *Server) removeWebhook(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if !s.webhooks.remove(p.ByName("id")) {
+		rbody.Write(404, rbody.FromError(ErrWebhookNotFound), w)
+		return
+	}
+	requestLogger(r).WithFields(log.Fields{
+		"_block":  "remove-webhook",
+		"webhook": p.ByName("id"),
+	}).Info("Webhook removed")
+	w.WriteHeader(204)
+}
+
+func writeWebhookBody(code int, body interface{}, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
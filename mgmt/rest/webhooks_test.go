@@ -0,0 +1,153 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/v1/rbody"
+)
+
+type webhookDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+func TestWebhooks(t *testing.T) {
+	Convey("Given a server with a webhook callback", t, func() {
+		deliveries := make(chan webhookDelivery, 10)
+		failures := 0
+		var mu sync.Mutex
+		callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if failures > 0 {
+				failures--
+				w.WriteHeader(503)
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			deliveries <- webhookDelivery{header: r.Header, body: b}
+		}))
+		defer callback.Close()
+
+		s := &Server{killChan: make(chan struct{}), events: newEventBroker()}
+		s.webhooks = newWebhooks(&s.wg, s.killChan)
+		s.webhooks.backoff = time.Millisecond
+		s.webhooks.run(s.events)
+		defer func() {
+			close(s.killChan)
+			s.wg.Wait()
+		}()
+
+		add := func(body string) (int, *Webhook) {
+			rw := httptest.NewRecorder()
+			s.addWebhook(negroni.NewResponseWriter(rw), httptest.NewRequest("POST", webhooksPath, strings.NewReader(body)), nil)
+			hook := &Webhook{}
+			json.Unmarshal(rw.Body.Bytes(), hook)
+			return rw.Code, hook
+		}
+		receive := func() *webhookDelivery {
+			select {
+			case d := <-deliveries:
+				return &d
+			case <-time.After(2 * time.Second):
+				return nil
+			}
+		}
+		disabled := &daemonEvent{Namespace: "Scheduler.TaskDisabled", Timestamp: 1490000460, Body: map[string]interface{}{"task_id": "1234"}}
+
+		Convey("the events of a webhook are delivered signed", func() {
+			code, hook := add(`{"url": "` + callback.URL + `", "events": ["Scheduler.TaskDisabled"], "secret": "foo"}`)
+			So(code, ShouldEqual, 201)
+			So(hook.ID, ShouldNotBeEmpty)
+			So(hook.Secret, ShouldEqual, "foo")
+
+			s.events.publish(&daemonEvent{Namespace: "Scheduler.TaskStarted", Body: map[string]interface{}{"task_id": "1234"}})
+			s.events.publish(disabled)
+			d := receive()
+			So(d, ShouldNotBeNil)
+			So(d.header.Get(WebhookEventHeader), ShouldEqual, "Scheduler.TaskDisabled")
+			So(d.header.Get(WebhookDeliveryHeader), ShouldNotBeEmpty)
+			mac := hmac.New(sha256.New, []byte("foo"))
+			mac.Write(d.body)
+			So(d.header.Get(WebhookSignatureHeader), ShouldEqual, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			e := map[string]interface{}{}
+			So(json.Unmarshal(d.body, &e), ShouldBeNil)
+			So(e["namespace"], ShouldEqual, "Scheduler.TaskDisabled")
+			So(e["body"], ShouldResemble, map[string]interface{}{"task_id": "1234"})
+			So(receive(), ShouldBeNil)
+
+			Convey("and listed without their secret", func() {
+				rw := httptest.NewRecorder()
+				s.getWebhooks(rw, httptest.NewRequest("GET", webhooksPath, nil), nil)
+				resp := &WebhooksResponse{}
+				So(json.Unmarshal(rw.Body.Bytes(), resp), ShouldBeNil)
+				So(resp.Webhooks, ShouldHaveLength, 1)
+				So(resp.Webhooks[0].ID, ShouldEqual, hook.ID)
+				So(resp.Webhooks[0].Secret, ShouldBeEmpty)
+			})
+			Convey("until it is removed", func() {
+				rw := httptest.NewRecorder()
+				s.removeWebhook(negroni.NewResponseWriter(rw), httptest.NewRequest("DELETE", webhooksPath+"/"+hook.ID, nil), httprouter.Params{{Key: "id", Value: hook.ID}})
+				So(rw.Code, ShouldEqual, 204)
+				s.events.publish(disabled)
+				So(receive(), ShouldBeNil)
+				rw = httptest.NewRecorder()
+				s.getWebhook(negroni.NewResponseWriter(rw), httptest.NewRequest("GET", webhooksPath+"/"+hook.ID, nil), httprouter.Params{{Key: "id", Value: hook.ID}})
+				So(rw.Code, ShouldEqual, 404)
+				resp := &rbody.APIResponse{}
+				So(json.Unmarshal(rw.Body.Bytes(), resp), ShouldBeNil)
+				So(resp.Body.(*rbody.Error).Code, ShouldEqual, rbody.ErrCodeNotFound)
+			})
+		})
+		Convey("failed deliveries are attempted again", func() {
+			mu.Lock()
+			failures = 2
+			mu.Unlock()
+			_, hook := add(`{"url": "` + callback.URL + `"}`)
+			So(hook.Secret, ShouldHaveLength, 64)
+			s.events.publish(disabled)
+			So(receive(), ShouldNotBeNil)
+		})
+		Convey("webhooks without a valid url are refused", func() {
+			code, _ := add(`{"url": "ftp://example.com"}`)
+			So(code, ShouldEqual, 400)
+			code, _ = add(`{"url": "/relative"}`)
+			So(code, ShouldEqual, 400)
+		})
+	})
+}
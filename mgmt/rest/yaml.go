@@ -0,0 +1,190 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/urfave/negroni"
+)
+
+// yamlMediaTypes are the media types accepted for YAML documents, the first
+// one being the one responses are sent with.
+var yamlMediaTypes = []string{"application/x-yaml", "application/yaml", "text/yaml", "text/x-yaml"}
+
+// yamlMiddleware lets the task and workflow endpoints be used with YAML
+// documents, which are easier to edit by hand than JSON: request bodies sent
+// with a YAML Content-Type are converted to JSON before reaching the
+// handlers, and the JSON responses are converted to YAML for clients
+// accepting it.
+type yamlMiddleware struct{}
+
+func (y yamlMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !isYAMLPath(r.URL.Path) {
+		next(rw, r)
+		return
+	}
+	if isYAMLMediaType(r.Header.Get("Content-Type")) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			b, err = yaml.YAMLToJSON(b)
+		}
+		if err != nil {
+			http.Error(rw, "Invalid YAML: "+err.Error(), 400)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+		r.ContentLength = int64(len(b))
+		r.Header.Set("Content-Length", strconv.Itoa(len(b)))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if r.Method == "HEAD" || !acceptsYAML(r) {
+		next(rw, r)
+		return
+	}
+	yrw := &yamlResponseWriter{ResponseWriter: rw.(negroni.ResponseWriter)}
+	next(yrw, r)
+	yrw.close()
+}
+
+// isYAMLPath reports whether the path is one of a task or workflow endpoint.
+func isYAMLPath(path string) bool {
+	for _, prefix := range []string{"/v1/", "/v2/"} {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		p := strings.TrimPrefix(path, prefix[:3])
+		return p == "/tasks" || strings.HasPrefix(p, "/tasks/") ||
+			p == "/workflows" || strings.HasPrefix(p, "/workflows/")
+	}
+	return false
+}
+
+func isYAMLMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range yamlMediaTypes {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsYAML reports whether a YAML media type is listed in the Accept
+// header of the request and not refused with q=0.
+func acceptsYAML(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		parts := strings.Split(accept, ";")
+		if !isYAMLMediaType(parts[0]) {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.Replace(p, " ", "", -1); q == "q=0" || q == "q=0.0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// yamlResponseWriter holds back the JSON written by the handler to convert it
+// to YAML once the handler is done. Other content, such as event streams, is
+// passed through as is.
+type yamlResponseWriter struct {
+	negroni.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	passThrough bool
+}
+
+func (y *yamlResponseWriter) WriteHeader(code int) {
+	if y.status != 0 {
+		return
+	}
+	y.status = code
+	mediaType, _, _ := mime.ParseMediaType(y.Header().Get("Content-Type"))
+	if mediaType != "" && !strings.HasSuffix(mediaType, "json") {
+		y.passThrough = true
+		y.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (y *yamlResponseWriter) Write(b []byte) (int, error) {
+	if y.status == 0 {
+		y.WriteHeader(http.StatusOK)
+	}
+	if y.passThrough {
+		return y.ResponseWriter.Write(b)
+	}
+	return y.buf.Write(b)
+}
+
+func (y *yamlResponseWriter) Status() int {
+	if y.status != 0 {
+		return y.status
+	}
+	return y.ResponseWriter.Status()
+}
+
+func (y *yamlResponseWriter) Written() bool {
+	return y.status != 0
+}
+
+func (y *yamlResponseWriter) Flush() {
+	if y.passThrough {
+		y.ResponseWriter.Flush()
+	}
+}
+
+// CloseNotify is not part of negroni.ResponseWriter but is used by the
+// streaming handlers.
+func (y *yamlResponseWriter) CloseNotify() <-chan bool {
+	return y.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// close converts the JSON written by the handler to YAML and sends it. What
+// cannot be converted is sent as it was written.
+func (y *yamlResponseWriter) close() {
+	if y.status == 0 || y.passThrough {
+		return
+	}
+	h := y.Header()
+	h.Add("Vary", "Accept")
+	b := y.buf.Bytes()
+	if len(bytes.TrimSpace(b)) > 0 {
+		if yb, err := yaml.JSONToYAML(b); err == nil {
+			b = yb
+			h.Set("Content-Type", yamlMediaTypes[0])
+			h.Del("Content-Length")
+		}
+	}
+	y.ResponseWriter.WriteHeader(y.status)
+	y.ResponseWriter.Write(b)
+}
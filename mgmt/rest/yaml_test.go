@@ -0,0 +1,105 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/negroni"
+)
+
+func TestYAMLMiddleware(t *testing.T) {
+	Convey("Given the YAML middleware", t, func() {
+		serve := func(r *http.Request, next http.HandlerFunc) (*httptest.ResponseRecorder, negroni.ResponseWriter) {
+			rec := httptest.NewRecorder()
+			rw := negroni.NewResponseWriter(rec)
+			yamlMiddleware{}.ServeHTTP(rw, r, next)
+			return rec, rw
+		}
+		writeJSON := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; version=2; charset=utf-8")
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "1234", "task_state": "Running"})
+		}
+
+		Convey("YAML request bodies are converted to JSON", func() {
+			var got string
+			var contentType string
+			r := httptest.NewRequest("POST", "/v2/tasks", strings.NewReader("version: 1\nschedule:\n  type: simple\n"))
+			r.Header.Set("Content-Type", "application/x-yaml")
+			_, rw := serve(r, func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				got, contentType = string(b), r.Header.Get("Content-Type")
+				w.WriteHeader(201)
+			})
+			So(rw.Status(), ShouldEqual, 201)
+			So(got, ShouldEqual, `{"schedule":{"type":"simple"},"version":1}`)
+			So(contentType, ShouldEqual, "application/json")
+		})
+		Convey("invalid YAML request bodies are refused", func() {
+			r := httptest.NewRequest("POST", "/v1/workflows", strings.NewReader("version: [1"))
+			r.Header.Set("Content-Type", "text/yaml")
+			called := false
+			_, rw := serve(r, func(w http.ResponseWriter, r *http.Request) { called = true })
+			So(called, ShouldBeFalse)
+			So(rw.Status(), ShouldEqual, 400)
+		})
+		Convey("JSON responses are converted to YAML for clients accepting it", func() {
+			r := httptest.NewRequest("GET", "/v2/tasks/1234", nil)
+			r.Header.Set("Accept", "application/json;q=0.5, application/x-yaml")
+			rec, rw := serve(r, writeJSON)
+			So(rw.Status(), ShouldEqual, 201)
+			So(rec.Header().Get("Content-Type"), ShouldEqual, "application/x-yaml")
+			So(rec.Body.String(), ShouldEqual, "id: \"1234\"\ntask_state: Running\n")
+		})
+		Convey("responses are left as they are", func() {
+			Convey("for clients not accepting YAML", func() {
+				r := httptest.NewRequest("GET", "/v2/tasks/1234", nil)
+				r.Header.Set("Accept", "application/json, application/x-yaml;q=0")
+				rec, _ := serve(r, writeJSON)
+				So(rec.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+			})
+			Convey("for other endpoints", func() {
+				r := httptest.NewRequest("GET", "/v2/plugins", nil)
+				r.Header.Set("Accept", "application/x-yaml")
+				rec, _ := serve(r, writeJSON)
+				So(rec.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+			})
+			Convey("when they are not JSON", func() {
+				r := httptest.NewRequest("GET", "/v1/tasks/1234/watch", nil)
+				r.Header.Set("Accept", "application/x-yaml")
+				rec, _ := serve(r, func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "text/event-stream")
+					w.Write([]byte("data: {}\n\n"))
+				})
+				So(rec.Header().Get("Content-Type"), ShouldEqual, "text/event-stream")
+				So(rec.Body.String(), ShouldEqual, "data: {}\n\n")
+			})
+		})
+	})
+}
@@ -1,3 +1,4 @@
+//go:build legacy
 // +build legacy
 
 /*
@@ -75,6 +76,13 @@ func (t *mockTask) Schedule() schedule.Schedule               { return nil }
 func (t *mockTask) MaxFailures() int                          { return 10 }
 func (t *mockTask) MaxMetricsBuffer() int64                   { return 0 }
 func (t *mockTask) SetMaxMetricsBuffer(int64)                 {}
+func (t *mockTask) MetricsThreshold() float64                 { return 0 }
+func (t *mockTask) SetMetricsThreshold(float64)               {}
+func (t *mockTask) Revision() uint64                          { return 1 }
+func (t *mockTask) ConfigHistory() []core.TaskConfigChange    { return nil }
+func (t *mockTask) RunErrors() []core.TaskRunError            { return nil }
+func (t *mockTask) WorkflowGraph() *core.WorkflowGraph        { return &core.WorkflowGraph{} }
+func (t *mockTask) Stats() core.TaskStats                     { return core.TaskStats{} }
 func (t *mockTask) MaxCollectDuration() time.Duration         { return time.Second }
 func (t *mockTask) SetMaxCollectDuration(time.Duration)       {}
 
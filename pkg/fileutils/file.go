@@ -1,10 +1,13 @@
 package fileutils
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -14,20 +17,35 @@ import (
 // WriteFile takes the name of the original file (fileName), path to the original file (filePath) and the content of the file (b)
 // Returns temporary file path and error
 func WriteFile(fileName, filePath string, b []byte) (string, error) {
+	return WriteFileFrom(fileName, filePath, bytes.NewReader(b))
+}
+
+// WriteFileFrom is WriteFile for content read from r, which is written as it
+// is read instead of being held in memory. The temporary directory is removed
+// when the content cannot be written.
+func WriteFileFrom(fileName, filePath string, r io.Reader) (string, error) {
 	// Create temporary directory
 	dir, err := ioutil.TempDir(filePath, "snap-plugin-")
 	if err != nil {
 		return "", err
 	}
+	path, err := writeFile(filepath.Join(dir, fileName), r)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return path, nil
+}
 
-	f, err := os.Create(filepath.Join(dir, fileName))
+func writeFile(path string, r io.Reader) (string, error) {
+	f, err := os.Create(path)
 	if err != nil {
 		return "", err
 	}
 	// Close before load
 	defer f.Close()
 
-	n, err := f.Write(b)
+	n, err := io.Copy(f, r)
 	log.Debugf("wrote %v to %v", n, f.Name())
 	if err != nil {
 		return "", err
@@ -40,3 +58,26 @@ func WriteFile(fileName, filePath string, b []byte) (string, error) {
 	}
 	return f.Name(), nil
 }
+
+// IsExecutable returns whether the file can be executed by someone. Files
+// having no execute permission on Windows, they are executable there when
+// their extension is one of the executable extensions listed by PATHEXT.
+func IsExecutable(fi os.FileInfo) bool {
+	if runtime.GOOS != "windows" {
+		return fi.Mode()&0111 != 0
+	}
+	ext := strings.ToLower(filepath.Ext(fi.Name()))
+	if ext == "" {
+		return false
+	}
+	pathext := os.Getenv("PATHEXT")
+	if pathext == "" {
+		pathext = ".com;.exe;.bat;.cmd"
+	}
+	for _, e := range strings.Split(strings.ToLower(pathext), ";") {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
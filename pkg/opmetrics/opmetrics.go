@@ -0,0 +1,182 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package opmetrics keeps the operational metrics of snapteld itself and
+// writes them in the Prometheus text exposition format.
+package opmetrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sample is a value of a metric with its label values.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Label is a name and value pair identifying a sample.
+type Label struct {
+	Name, Value string
+}
+
+// Collector is something writing metrics in the exposition format.
+type Collector interface {
+	Collect(w io.Writer)
+}
+
+// Registry holds the collectors written together.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []Collector
+}
+
+// Default is the registry of the operational metrics of snapteld.
+var Default = &Registry{}
+
+// Register adds a collector to the registry.
+func (r *Registry) Register(c Collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Collect writes the metrics of all the collectors registered.
+func (r *Registry) Collect(w io.Writer) {
+	r.mutex.Lock()
+	collectors := make([]Collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mutex.Unlock()
+	for _, c := range collectors {
+		c.Collect(w)
+	}
+}
+
+// Summary tracks the count and the sum of the durations observed, in seconds,
+// for each set of label values.
+type Summary struct {
+	name, help string
+	labels     []string
+	mutex      sync.Mutex
+	values     map[string]*summaryValue
+}
+
+type summaryValue struct {
+	labels []string
+	count  uint64
+	sum    float64
+}
+
+// NewSummary returns a summary with the given label names and registers it
+// in the Default registry.
+func NewSummary(name, help string, labels ...string) *Summary {
+	s := &Summary{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: map[string]*summaryValue{},
+	}
+	Default.Register(s)
+	return s
+}
+
+// Observe adds a duration for the given label values, given in the order of
+// the label names.
+func (s *Summary) Observe(d time.Duration, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		v = &summaryValue{labels: labelValues}
+		s.values[key] = v
+	}
+	v.count++
+	v.sum += d.Seconds()
+}
+
+// Since observes the time elapsed since start.
+func (s *Summary) Since(start time.Time, labelValues ...string) {
+	s.Observe(time.Since(start), labelValues...)
+}
+
+// Collect writes the count and sum of the summary.
+func (s *Summary) Collect(w io.Writer) {
+	s.mutex.Lock()
+	sums := make([]Sample, 0, len(s.values))
+	counts := make([]Sample, 0, len(s.values))
+	for _, v := range s.values {
+		labels := make([]Label, len(s.labels))
+		for i, n := range s.labels {
+			if i < len(v.labels) {
+				labels[i] = Label{Name: n, Value: v.labels[i]}
+			} else {
+				labels[i] = Label{Name: n}
+			}
+		}
+		sums = append(sums, Sample{Labels: labels, Value: v.sum})
+		counts = append(counts, Sample{Labels: labels, Value: float64(v.count)})
+	}
+	s.mutex.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s summary\n", s.name, s.help, s.name)
+	writeSamples(w, s.name+"_sum", sums)
+	writeSamples(w, s.name+"_count", counts)
+}
+
+// Write writes a metric of the given type ('counter' or 'gauge') with its
+// samples.
+func Write(w io.Writer, name, help, typ string, samples []Sample) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	writeSamples(w, name, samples)
+}
+
+func writeSamples(w io.Writer, name string, samples []Sample) {
+	lines := make([]string, len(samples))
+	for i, s := range samples {
+		lines[i] = name + formatLabels(s.Labels) + " " + formatValue(s.Value)
+	}
+	// keep the output stable for scrapers and diffs
+	sort.Strings(lines)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l.Name, labelEscaper.Replace(l.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,76 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opmetrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSummary(t *testing.T) {
+	Convey("Given a summary", t, func() {
+		s := &Summary{name: "test_duration_seconds", help: "Test durations.", labels: []string{"method"}, values: map[string]*summaryValue{}}
+		Convey("it writes the count and sum of each label set", func() {
+			s.Observe(500*time.Millisecond, "GET")
+			s.Observe(time.Second, "GET")
+			s.Observe(250*time.Millisecond, "POST")
+			var buf bytes.Buffer
+			s.Collect(&buf)
+			So(buf.String(), ShouldEqual, `# HELP test_duration_seconds Test durations.
+# TYPE test_duration_seconds summary
+test_duration_seconds_sum{method="GET"} 1.5
+test_duration_seconds_sum{method="POST"} 0.25
+test_duration_seconds_count{method="GET"} 2
+test_duration_seconds_count{method="POST"} 1
+`)
+		})
+	})
+}
+
+func TestWrite(t *testing.T) {
+	Convey("Write escapes label values and sorts the samples", t, func() {
+		var buf bytes.Buffer
+		Write(&buf, "test_total", "Test counter.", "counter", []Sample{
+			{Labels: []Label{{Name: "name", Value: "b"}}, Value: 2},
+			{Labels: []Label{{Name: "name", Value: "a \"quoted\"\n"}}, Value: 1000000},
+		})
+		So(buf.String(), ShouldEqual, `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{name="a \"quoted\"\n"} 1e+06
+test_total{name="b"} 2
+`)
+	})
+	Convey("Registry collects all its collectors", t, func() {
+		r := &Registry{}
+		s1 := &Summary{name: "a_seconds", help: "A.", values: map[string]*summaryValue{}}
+		s2 := &Summary{name: "b_seconds", help: "B.", values: map[string]*summaryValue{}}
+		r.Register(s1)
+		r.Register(s2)
+		var buf bytes.Buffer
+		r.Collect(&buf)
+		So(buf.String(), ShouldContainSubstring, "# TYPE a_seconds summary")
+		So(buf.String(), ShouldContainSubstring, "# TYPE b_seconds summary")
+	})
+}
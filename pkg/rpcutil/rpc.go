@@ -24,15 +24,25 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // GetClientConnection returns a grcp.ClientConn that is unsecured
-// TODO: Add TLS security to connection
 func GetClientConnection(addr string, port int) (*grpc.ClientConn, error) {
+	return GetClientConnectionWithCreds(addr, port, nil)
+}
+
+// GetClientConnectionWithCreds returns a grpc.ClientConn secured with creds,
+// or unsecured when creds is nil
+func GetClientConnectionWithCreds(addr string, port int, creds credentials.TransportCredentials) (*grpc.ClientConn, error) {
 	grpcDialOpts := []grpc.DialOption{
 		grpc.WithTimeout(2 * time.Second),
 	}
-	grpcDialOpts = append(grpcDialOpts, grpc.WithInsecure())
+	if creds != nil {
+		grpcDialOpts = append(grpcDialOpts, grpc.WithTransportCredentials(creds))
+	} else {
+		grpcDialOpts = append(grpcDialOpts, grpc.WithInsecure())
+	}
 	conn, err := grpc.Dial(fmt.Sprintf("%v:%v", addr, port), grpcDialOpts...)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,103 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versions selects plugin versions with constraints.
+package versions
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidConstraint is returned for a version constraint which
+// cannot be parsed
+var ErrInvalidConstraint = errors.New("version constraint must be a comma separated list of comparisons such as '>=3, <5'")
+
+// versionOperators are the comparison operators of version constraints,
+// longest first so that '>=' is not read as '>'
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// Constraint selects plugin versions by comparing them to bounds, e.g.
+// ">=3, <5" selects the versions 3 and 4.
+type Constraint []versionComparison
+
+type versionComparison struct {
+	op      string
+	version int
+}
+
+// ParseConstraint parses a comma separated list of comparisons, each
+// being one of =, !=, >, >=, < and <= followed by a version. A version given
+// without operator must be matched exactly.
+func ParseConstraint(s string) (Constraint, error) {
+	var c Constraint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, o := range versionOperators {
+			if strings.HasPrefix(part, o) {
+				op = o
+				part = strings.TrimSpace(strings.TrimPrefix(part, o))
+				break
+			}
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 1 {
+			return nil, ErrInvalidConstraint
+		}
+		c = append(c, versionComparison{op: op, version: v})
+	}
+	return c, nil
+}
+
+// Check returns true when the version satisfies all the comparisons of the
+// constraint
+func (c Constraint) Check(version int) bool {
+	for _, cmp := range c {
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = version == cmp.version
+		case "!=":
+			ok = version != cmp.version
+		case ">":
+			ok = version > cmp.version
+		case ">=":
+			ok = version >= cmp.version
+		case "<":
+			ok = version < cmp.version
+		case "<=":
+			ok = version <= cmp.version
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	parts := make([]string, len(c))
+	for i, cmp := range c {
+		parts[i] = fmt.Sprintf("%s%d", cmp.op, cmp.version)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,60 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versions
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConstraint(t *testing.T) {
+	Convey("Version constraints", t, func() {
+		Convey("select the versions within their bounds", func() {
+			c, err := ParseConstraint(">=3, <5")
+			So(err, ShouldBeNil)
+			So(c.Check(2), ShouldBeFalse)
+			So(c.Check(3), ShouldBeTrue)
+			So(c.Check(4), ShouldBeTrue)
+			So(c.Check(5), ShouldBeFalse)
+			So(c.String(), ShouldEqual, ">=3, <5")
+		})
+		Convey("match a version given without operator exactly", func() {
+			c, err := ParseConstraint("4")
+			So(err, ShouldBeNil)
+			So(c.Check(4), ShouldBeTrue)
+			So(c.Check(5), ShouldBeFalse)
+		})
+		Convey("exclude versions", func() {
+			c, err := ParseConstraint(">1,!=3")
+			So(err, ShouldBeNil)
+			So(c.Check(2), ShouldBeTrue)
+			So(c.Check(3), ShouldBeFalse)
+		})
+		Convey("are rejected when invalid", func() {
+			for _, s := range []string{"", ">=", "~3", ">=3,", "<0"} {
+				_, err := ParseConstraint(s)
+				So(err, ShouldEqual, ErrInvalidConstraint)
+			}
+		})
+	})
+}
@@ -0,0 +1,251 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+const (
+	// accountingWindow is the length of the time windows metrics are
+	// accounted in
+	accountingWindow = time.Hour
+	// accountingRetention is how long accounting windows are kept
+	accountingRetention = 30 * 24 * time.Hour
+
+	accountingGroupByTask  = "task"
+	accountingGroupByLabel = "label:"
+)
+
+var (
+	accountingLogger = schedulerLogger.WithField("_module", "scheduler-accounting")
+
+	// ErrInvalidAccountingGroup - The error message for an unsupported accounting group_by value
+	ErrInvalidAccountingGroup = errors.New("Invalid accounting group, expected 'task' or 'label:<name>'")
+)
+
+// accountingEntry holds the metrics collected and published by a task within
+// a single accounting window.
+type accountingEntry struct {
+	TaskID    string            `json:"task_id"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Window    time.Time         `json:"window"`
+	Collected uint64            `json:"collected"`
+	Published uint64            `json:"published"`
+}
+
+type accountingKey struct {
+	taskID string
+	window int64
+}
+
+// accountant aggregates the number of metrics collected and published per
+// task over time windows. If a path is given the entries are persisted to it
+// whenever a new window is opened and when the scheduler stops.
+type accountant struct {
+	sync.Mutex
+	path    string
+	entries map[accountingKey]*accountingEntry
+	window  int64
+}
+
+func newAccountant(path string) *accountant {
+	a := &accountant{
+		path:    path,
+		entries: map[accountingKey]*accountingEntry{},
+	}
+	if path == "" {
+		return a
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			accountingLogger.WithFields(log.Fields{
+				"_block": "new-accountant",
+				"path":   path,
+				"error":  err,
+			}).Error("unable to read accounting file")
+		}
+		return a
+	}
+	var entries []*accountingEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		accountingLogger.WithFields(log.Fields{
+			"_block": "new-accountant",
+			"path":   path,
+			"error":  err,
+		}).Error("unable to parse accounting file")
+		return a
+	}
+	for _, e := range entries {
+		a.entries[accountingKey{e.TaskID, e.Window.Unix()}] = e
+	}
+	return a
+}
+
+// record adds the collected and published counts for a task to the current
+// accounting window.
+func (a *accountant) record(taskID string, labels map[string]string, collected, published int) {
+	now := time.Now().Truncate(accountingWindow)
+	a.Lock()
+	defer a.Unlock()
+	if now.Unix() != a.window {
+		if a.window != 0 {
+			a.save()
+		}
+		a.window = now.Unix()
+	}
+	k := accountingKey{taskID, now.Unix()}
+	e, ok := a.entries[k]
+	if !ok {
+		e = &accountingEntry{TaskID: taskID, Labels: labels, Window: now}
+		a.entries[k] = e
+	}
+	e.Collected += uint64(collected)
+	e.Published += uint64(published)
+}
+
+// report aggregates the accounting entries by window and the given group,
+// which is either "task" or "label:<name>".
+func (a *accountant) report(groupBy string) ([]core.AccountingRecord, error) {
+	var group func(*accountingEntry) string
+	switch {
+	case groupBy == "" || groupBy == accountingGroupByTask:
+		group = func(e *accountingEntry) string { return e.TaskID }
+	case strings.HasPrefix(groupBy, accountingGroupByLabel) && len(groupBy) > len(accountingGroupByLabel):
+		label := strings.TrimPrefix(groupBy, accountingGroupByLabel)
+		group = func(e *accountingEntry) string { return e.Labels[label] }
+	default:
+		return nil, fmt.Errorf("%v: %s", ErrInvalidAccountingGroup, groupBy)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	type reportKey struct {
+		group  string
+		window int64
+	}
+	records := map[reportKey]*core.AccountingRecord{}
+	for _, e := range a.entries {
+		k := reportKey{group(e), e.Window.Unix()}
+		r, ok := records[k]
+		if !ok {
+			r = &core.AccountingRecord{Group: k.group, Window: e.Window}
+			records[k] = r
+		}
+		r.Collected += e.Collected
+		r.Published += e.Published
+	}
+	report := make([]core.AccountingRecord, 0, len(records))
+	for _, r := range records {
+		report = append(report, *r)
+	}
+	sort.Sort(byWindowAndGroup(report))
+	return report, nil
+}
+
+// save drops entries older than the retention period and writes the rest to
+// the accounting file. The caller must hold the lock.
+func (a *accountant) save() {
+	cutoff := time.Now().Add(-accountingRetention)
+	entries := make([]*accountingEntry, 0, len(a.entries))
+	for k, e := range a.entries {
+		if e.Window.Before(cutoff) {
+			delete(a.entries, k)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if a.path == "" {
+		return
+	}
+	b, err := json.Marshal(entries)
+	if err == nil {
+		err = ioutil.WriteFile(a.path, b, 0644)
+	}
+	if err != nil {
+		accountingLogger.WithFields(log.Fields{
+			"_block": "save",
+			"path":   a.path,
+			"error":  err,
+		}).Error("unable to write accounting file")
+	}
+}
+
+// close persists the accounting entries.
+func (a *accountant) close() {
+	a.Lock()
+	defer a.Unlock()
+	a.save()
+}
+
+// taskLabels returns the labels of a task used for accounting. These are the
+// tags defined in the task's workflow, where tags defined on a shorter
+// namespace take precedence.
+func taskLabels(t *task) map[string]string {
+	nss := make([]string, 0, len(t.workflow.tags))
+	for ns := range t.workflow.tags {
+		nss = append(nss, ns)
+	}
+	sort.Sort(byLength(nss))
+	labels := map[string]string{}
+	for _, ns := range nss {
+		for k, v := range t.workflow.tags[ns] {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+	}
+	return labels
+}
+
+type byWindowAndGroup []core.AccountingRecord
+
+func (b byWindowAndGroup) Len() int      { return len(b) }
+func (b byWindowAndGroup) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byWindowAndGroup) Less(i, j int) bool {
+	if b[i].Window.Equal(b[j].Window) {
+		return b[i].Group < b[j].Group
+	}
+	return b[i].Window.Before(b[j].Window)
+}
+
+type byLength []string
+
+func (b byLength) Len() int      { return len(b) }
+func (b byLength) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLength) Less(i, j int) bool {
+	if len(b[i]) == len(b[j]) {
+		return b[i] < b[j]
+	}
+	return len(b[i]) < len(b[j])
+}
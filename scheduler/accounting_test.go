@@ -0,0 +1,78 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAccountant(t *testing.T) {
+	Convey("Accounting", t, func() {
+		dir, err := ioutil.TempDir("", "snap-accounting")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "accounting.json")
+
+		a := newAccountant(path)
+		a.record("t1", map[string]string{"team": "red"}, 10, 0)
+		a.record("t1", map[string]string{"team": "red"}, 0, 10)
+		a.record("t2", map[string]string{"team": "red"}, 5, 3)
+		a.record("t3", map[string]string{"team": "blue"}, 2, 2)
+
+		Convey("groups by task", func() {
+			report, err := a.report("task")
+			So(err, ShouldBeNil)
+			So(len(report), ShouldEqual, 3)
+			So(report[0].Group, ShouldEqual, "t1")
+			So(report[0].Collected, ShouldEqual, 10)
+			So(report[0].Published, ShouldEqual, 10)
+		})
+		Convey("groups by label", func() {
+			report, err := a.report("label:team")
+			So(err, ShouldBeNil)
+			So(len(report), ShouldEqual, 2)
+			So(report[0].Group, ShouldEqual, "blue")
+			So(report[1].Group, ShouldEqual, "red")
+			So(report[1].Collected, ShouldEqual, 15)
+			So(report[1].Published, ShouldEqual, 13)
+		})
+		Convey("rejects an invalid group", func() {
+			_, err := a.report("label:")
+			So(err, ShouldNotBeNil)
+			_, err = a.report("plugin")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("is persisted", func() {
+			a.close()
+			b := newAccountant(path)
+			report, err := b.report("label:team")
+			So(err, ShouldBeNil)
+			So(len(report), ShouldEqual, 2)
+			So(report[1].Collected, ShouldEqual, 15)
+		})
+	})
+}
@@ -0,0 +1,133 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// ErrInvalidBatch is returned when a batch sets none of its limits
+var ErrInvalidBatch = errors.New("Batch must set at least one of max_metrics, max_bytes and flush_interval")
+
+// publishBatcher buffers the metrics of several runs of a task for a publish
+// node. It keeps state across runs so it belongs to a single workflow.
+type publishBatcher struct {
+	sync.Mutex
+	maxMetrics int
+	maxBytes   int
+	interval   time.Duration
+	metrics    []core.Metric
+	bytes      int
+	timer      *time.Timer
+}
+
+func newPublishBatcher(b *wmap.BatchWorkflowMapNode) (*publishBatcher, error) {
+	if b.MaxMetrics < 0 || b.MaxBytes < 0 || (b.MaxMetrics == 0 && b.MaxBytes == 0 && b.FlushInterval == "") {
+		return nil, ErrInvalidBatch
+	}
+	pb := &publishBatcher{maxMetrics: b.MaxMetrics, maxBytes: b.MaxBytes}
+	if b.FlushInterval != "" {
+		d, err := time.ParseDuration(b.FlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid batch flush interval '%s': %v", b.FlushInterval, err)
+		}
+		if d <= 0 {
+			return nil, ErrInvalidBatch
+		}
+		pb.interval = d
+	}
+	return pb, nil
+}
+
+// add buffers the metrics of a run and returns the batch to publish once a
+// size limit is reached, nil otherwise. When a flush interval is set the
+// buffered metrics are handed to flush once it elapses since the first of
+// them was buffered.
+func (b *publishBatcher) add(mts []core.Metric, flush func([]core.Metric)) []core.Metric {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.metrics) == 0 && b.interval > 0 && len(mts) > 0 {
+		b.timer = time.AfterFunc(b.interval, func() {
+			if batch := b.take(); len(batch) > 0 {
+				flush(batch)
+			}
+		})
+	}
+	b.metrics = append(b.metrics, mts...)
+	for _, m := range mts {
+		b.bytes += metricSize(m)
+	}
+	if (b.maxMetrics > 0 && len(b.metrics) >= b.maxMetrics) || (b.maxBytes > 0 && b.bytes >= b.maxBytes) {
+		return b.takeLocked()
+	}
+	return nil
+}
+
+// take empties the buffer and returns its metrics
+func (b *publishBatcher) take() []core.Metric {
+	b.Lock()
+	defer b.Unlock()
+	return b.takeLocked()
+}
+
+func (b *publishBatcher) takeLocked() []core.Metric {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.metrics
+	b.metrics = nil
+	b.bytes = 0
+	return batch
+}
+
+// metricSize approximates the number of bytes a metric takes once published
+func metricSize(m core.Metric) int {
+	size := len(m.Namespace().String()) + len(fmt.Sprint(m.Data())) + len(m.Unit())
+	for k, v := range m.Tags() {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// batchJob is the parent job of the publish job of a batch
+type batchJob struct {
+	*coreJob
+	metrics []core.Metric
+}
+
+func newBatchJob(deadline time.Time, metrics []core.Metric, taskID string) job {
+	return &batchJob{
+		metrics: metrics,
+		coreJob: newCoreJob(batchJobType, deadline, taskID, "batch", 0),
+	}
+}
+
+func (b *batchJob) Metrics() []core.Metric {
+	return b.metrics
+}
+
+func (b *batchJob) Run() {}
@@ -0,0 +1,76 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPublishBatcher(t *testing.T) {
+	mts := []core.Metric{
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", "foo"), Data_: 1},
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", "bar"), Data_: 2},
+	}
+	noFlush := func([]core.Metric) {}
+	Convey("Publish batches", t, func() {
+		Convey("are published once max_metrics is reached", func() {
+			b, err := newPublishBatcher(&wmap.BatchWorkflowMapNode{MaxMetrics: 3})
+			So(err, ShouldBeNil)
+			So(b.add(mts, noFlush), ShouldBeNil)
+			batch := b.add(mts, noFlush)
+			So(batch, ShouldHaveLength, 4)
+			So(b.add(mts[:1], noFlush), ShouldBeNil)
+		})
+		Convey("are published once max_bytes is reached", func() {
+			b, err := newPublishBatcher(&wmap.BatchWorkflowMapNode{MaxBytes: metricSize(mts[0]) + 1})
+			So(err, ShouldBeNil)
+			So(b.add(mts[:1], noFlush), ShouldBeNil)
+			So(b.add(mts[1:], noFlush), ShouldHaveLength, 2)
+		})
+		Convey("are flushed once flush_interval elapses", func() {
+			b, err := newPublishBatcher(&wmap.BatchWorkflowMapNode{FlushInterval: "10ms"})
+			So(err, ShouldBeNil)
+			flushed := make(chan []core.Metric, 1)
+			So(b.add(mts, func(batch []core.Metric) { flushed <- batch }), ShouldBeNil)
+			select {
+			case batch := <-flushed:
+				So(batch, ShouldHaveLength, 2)
+			case <-time.After(time.Second):
+				So("batch not flushed", ShouldBeEmpty)
+			}
+			So(b.take(), ShouldBeEmpty)
+		})
+		Convey("need a limit", func() {
+			_, err := newPublishBatcher(&wmap.BatchWorkflowMapNode{})
+			So(err, ShouldEqual, ErrInvalidBatch)
+			_, err = newPublishBatcher(&wmap.BatchWorkflowMapNode{FlushInterval: "soon"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -22,12 +22,17 @@ package scheduler
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
 )
 
 // default configuration values
 const (
 	defaultWorkManagerQueueSize uint = 25
 	defaultWorkManagerPoolSize  uint = 4
+	// process and publish jobs run at once across workflow branches, 0
+	// running them through the worker pools
+	defaultWorkManagerBranchConcurrency uint = 0
 )
 
 // holds the configuration passed in through the SNAP config file
@@ -35,8 +40,18 @@ const (
 //         UnmarshalJSON method in this same file needs to be modified to
 //         match the field mapping that is defined here
 type Config struct {
-	WorkManagerQueueSize uint `json:"work_manager_queue_size"yaml:"work_manager_queue_size"`
-	WorkManagerPoolSize  uint `json:"work_manager_pool_size"yaml:"work_manager_pool_size"`
+	WorkManagerQueueSize         uint     `json:"work_manager_queue_size"yaml:"work_manager_queue_size"`
+	WorkManagerPoolSize          uint     `json:"work_manager_pool_size"yaml:"work_manager_pool_size"`
+	WorkManagerBranchConcurrency uint     `json:"work_manager_branch_concurrency"yaml:"work_manager_branch_concurrency"`
+	AccountingPath               string   `json:"accounting_path"yaml:"accounting_path"`
+	StrictContentTypes           bool     `json:"strict_content_types"yaml:"strict_content_types"`
+	ContentTypePreference        []string `json:"content_type_preference"yaml:"content_type_preference"`
+	// secret references of workflow config resolved from the environment of
+	// snapteld (env:) and from the files of a directory (file:)
+	SecretsFromEnv bool   `json:"secrets_from_env"yaml:"secrets_from_env"`
+	SecretsDir     string `json:"secrets_dir"yaml:"secrets_dir"`
+	// config merged into the process and publish nodes of new workflows
+	WorkflowDefaults wmap.ConfigDefaults `json:"workflow_defaults"yaml:"workflow_defaults"`
 }
 
 const (
@@ -51,9 +66,60 @@ const (
 					"work_manager_pool_size" : {
 						"type": "integer",
 						"minimum": 1
+					},
+					"work_manager_branch_concurrency" : {
+						"type": "integer",
+						"minimum": 0
+					},
+					"accounting_path" : {
+						"type": "string"
+					},
+					"strict_content_types" : {
+						"type": "boolean"
+					},
+					"content_type_preference" : {
+						"type": ["array", "null"],
+						"items": {
+							"type": "string"
+						}
+					},
+					"secrets_from_env" : {
+						"type": "boolean"
+					},
+					"secrets_dir" : {
+						"type": "string"
+					},
+					"workflow_defaults" : {
+						"type": ["object", "null"],
+						"properties" : {
+							"processor" : { "$ref": "#/definitions/workflow_plugin_defaults" },
+							"publisher" : { "$ref": "#/definitions/workflow_plugin_defaults" }
+						},
+						"additionalProperties": false
 					}
 				},
 				"additionalProperties": false
+			},
+			"workflow_plugin_defaults": {
+				"type": ["object", "null"],
+				"additionalProperties": {
+					"type": "object",
+					"properties" : {
+						"all" : {
+							"type": ["object", "null"]
+						},
+						"versions" : {
+							"type": ["object", "null"],
+							"patternProperties": {
+								"^[0-9]+$": {
+									"type": ["object", "null"]
+								}
+							},
+							"additionalProperties": false
+						}
+					},
+					"additionalProperties": false
+				}
 			}
 	`
 )
@@ -61,8 +127,9 @@ const (
 // get the default snapteld configuration
 func GetDefaultConfig() *Config {
 	return &Config{
-		WorkManagerQueueSize: defaultWorkManagerQueueSize,
-		WorkManagerPoolSize:  defaultWorkManagerPoolSize,
+		WorkManagerQueueSize:         defaultWorkManagerQueueSize,
+		WorkManagerPoolSize:          defaultWorkManagerPoolSize,
+		WorkManagerBranchConcurrency: defaultWorkManagerBranchConcurrency,
 	}
 }
 
@@ -88,6 +155,34 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &(c.WorkManagerPoolSize)); err != nil {
 				return fmt.Errorf("%v (while parsing 'scheduler::work_manager_pool_size')", err)
 			}
+		case "work_manager_branch_concurrency":
+			if err := json.Unmarshal(v, &(c.WorkManagerBranchConcurrency)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::work_manager_branch_concurrency')", err)
+			}
+		case "accounting_path":
+			if err := json.Unmarshal(v, &(c.AccountingPath)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::accounting_path')", err)
+			}
+		case "strict_content_types":
+			if err := json.Unmarshal(v, &(c.StrictContentTypes)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::strict_content_types')", err)
+			}
+		case "content_type_preference":
+			if err := json.Unmarshal(v, &(c.ContentTypePreference)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::content_type_preference')", err)
+			}
+		case "secrets_from_env":
+			if err := json.Unmarshal(v, &(c.SecretsFromEnv)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::secrets_from_env')", err)
+			}
+		case "secrets_dir":
+			if err := json.Unmarshal(v, &(c.SecretsDir)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::secrets_dir')", err)
+			}
+		case "workflow_defaults":
+			if err := json.Unmarshal(v, &(c.WorkflowDefaults)); err != nil {
+				return fmt.Errorf("%v (while parsing 'scheduler::workflow_defaults')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in global config file while parsing 'scheduler'", k)
 		}
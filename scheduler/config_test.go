@@ -70,6 +70,15 @@ func TestSchedulerConfigJSON(t *testing.T) {
 		Convey("WorkManagerPoolSize should equal 2", func() {
 			So(cfg.WorkManagerPoolSize, ShouldEqual, 2)
 		})
+		Convey("WorkManagerBranchConcurrency should equal 8", func() {
+			So(cfg.WorkManagerBranchConcurrency, ShouldEqual, 8)
+		})
+		Convey("AccountingPath should equal /var/lib/snap/accounting.json", func() {
+			So(cfg.AccountingPath, ShouldEqual, "/var/lib/snap/accounting.json")
+		})
+		Convey("SecretsDir should equal /run/secrets", func() {
+			So(cfg.SecretsDir, ShouldEqual, "/run/secrets")
+		})
 	})
 
 }
@@ -94,6 +103,15 @@ func TestSchedulerConfigYaml(t *testing.T) {
 		Convey("WorkManagerPoolSize should equal 2", func() {
 			So(cfg.WorkManagerPoolSize, ShouldEqual, 2)
 		})
+		Convey("WorkManagerBranchConcurrency should equal 8", func() {
+			So(cfg.WorkManagerBranchConcurrency, ShouldEqual, 8)
+		})
+		Convey("AccountingPath should equal /var/lib/snap/accounting.json", func() {
+			So(cfg.AccountingPath, ShouldEqual, "/var/lib/snap/accounting.json")
+		})
+		Convey("SecretsDir should equal /run/secrets", func() {
+			So(cfg.SecretsDir, ShouldEqual, "/run/secrets")
+		})
 	})
 
 }
@@ -107,5 +125,18 @@ func TestSchedulerDefaultConfig(t *testing.T) {
 		Convey("WorkManagerPoolSize should equal 4", func() {
 			So(cfg.WorkManagerPoolSize, ShouldEqual, 4)
 		})
+		Convey("WorkManagerBranchConcurrency should equal 0", func() {
+			So(cfg.WorkManagerBranchConcurrency, ShouldEqual, 0)
+		})
+		Convey("AccountingPath should be empty", func() {
+			So(cfg.AccountingPath, ShouldBeEmpty)
+		})
+		Convey("StrictContentTypes should be false", func() {
+			So(cfg.StrictContentTypes, ShouldBeFalse)
+		})
+		Convey("Secrets should not be resolved from the environment or files", func() {
+			So(cfg.SecretsFromEnv, ShouldBeFalse)
+			So(cfg.SecretsDir, ShouldBeEmpty)
+		})
 	})
 }
@@ -0,0 +1,214 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+)
+
+// anyContentType is advertised by plugins accepting or returning any content type
+const anyContentType = "snap.*"
+
+var (
+	// ErrAmbiguousContentType is returned in strict content type mode when more than
+	// one content type is acceptable between a node and its parent
+	ErrAmbiguousContentType = errors.New("Ambiguous content type between workflow nodes")
+	// ErrNoCommonContentType is returned when a node accepts none of the content types
+	// returned by its parent or the content type chosen for it
+	ErrNoCommonContentType = errors.New("No common content type between workflow nodes")
+	// ErrContentTypeOnBuiltinNode is returned when a content type is set on a
+	// filter or sample node, which pass metrics through untouched
+	ErrContentTypeOnBuiltinNode = errors.New("Content type cannot be set on filter or sample nodes")
+)
+
+// negotiatesContentTypes is implemented by metric managers able to report the
+// content types accepted and returned by a loaded plugin
+type negotiatesContentTypes interface {
+	GetPluginContentTypes(string, core.PluginType, int) ([]string, []string, error)
+}
+
+// contentTypeResolver binds the inbound content type of every process and
+// publish node of a workflow when strict content types are enabled. A node
+// fails validation unless a single content type is acceptable, the node names
+// one explicitly or the preference order picks one.
+type contentTypeResolver struct {
+	manager    negotiatesContentTypes
+	preference []string
+	// explicitOnly only checks the content types named by the nodes, leaving
+	// the others to be negotiated by the plugins when strict content types
+	// are disabled
+	explicitOnly bool
+}
+
+// version returns the version of the plugin of a node whose content types
+// are looked up, the version matching its constraint when it has one
+func (r *contentTypeResolver) version(p constrainedPlugin) int {
+	if rv, ok := r.manager.(resolvesVersionConstraints); ok && p.VersionConstraint() != "" {
+		if v, err := rv.ResolvePluginVersion(p.TypeName(), p.Name(), p.VersionConstraint()); err == nil {
+			return v
+		}
+	}
+	return p.Version()
+}
+
+// resolve walks the nodes fed with the given returned content types. The
+// collect node of a workflow may return any content type.
+func (r *contentTypeResolver) resolve(prs []*processNode, pus []*publishNode, returned []string) []serror.SnapError {
+	var serrs []serror.SnapError
+	for _, pr := range prs {
+		// filter and sample nodes pass metrics through untouched
+		if pr.builtin() {
+			serrs = append(serrs, r.resolve(pr.ProcessNodes, pr.PublishNodes, returned)...)
+			continue
+		}
+		// plugins on remote targets are not known locally
+		if pr.Target != "" {
+			serrs = append(serrs, r.resolve(pr.ProcessNodes, pr.PublishNodes, []string{anyContentType})...)
+			continue
+		}
+		accepted, prReturned, err := r.manager.GetPluginContentTypes(pr.Name(), core.ProcessorPluginType, r.version(pr))
+		if err != nil {
+			serrs = append(serrs, nodeContentTypeError(err, pr, nil))
+			continue
+		}
+		ct, serr := r.choose(pr, pr.InboundContentType, returned, accepted)
+		if serr != nil {
+			serrs = append(serrs, serr)
+			continue
+		}
+		pr.InboundContentType = ct
+		serrs = append(serrs, r.resolve(pr.ProcessNodes, pr.PublishNodes, prReturned)...)
+	}
+	for _, pu := range pus {
+		if pu.Target != "" {
+			continue
+		}
+		accepted, _, err := r.manager.GetPluginContentTypes(pu.Name(), core.PublisherPluginType, r.version(pu))
+		if err != nil {
+			serrs = append(serrs, nodeContentTypeError(err, pu, nil))
+			continue
+		}
+		ct, serr := r.choose(pu, pu.InboundContentType, returned, accepted)
+		if serr != nil {
+			serrs = append(serrs, serr)
+			continue
+		}
+		pu.InboundContentType = ct
+	}
+	// dead-letter nodes receive the metrics their publish node was given
+	for _, pu := range pus {
+		if pu.deadLetter != nil {
+			serrs = append(serrs, r.resolve(nil, []*publishNode{pu.deadLetter}, returned)...)
+		}
+	}
+	return serrs
+}
+
+func (r *contentTypeResolver) choose(node core.Plugin, explicit string, returned, accepted []string) (string, serror.SnapError) {
+	if r.explicitOnly && explicit == "" {
+		return "", nil
+	}
+	candidates := commonContentTypes(returned, accepted)
+	if len(candidates) == 0 {
+		return "", nodeContentTypeError(ErrNoCommonContentType, node, nil)
+	}
+	if explicit != "" {
+		if !containsContentType(candidates, explicit) {
+			return "", nodeContentTypeError(ErrNoCommonContentType, node, candidates)
+		}
+		return explicit, nil
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	for _, p := range r.preference {
+		if containsContentType(candidates, p) {
+			return p, nil
+		}
+	}
+	return "", nodeContentTypeError(ErrAmbiguousContentType, node, candidates)
+}
+
+// commonContentTypes returns the content types both returned by a parent and
+// accepted by its child, in the child's priority order.
+func commonContentTypes(returned, accepted []string) []string {
+	if containsString(returned, anyContentType) {
+		return accepted
+	}
+	if containsString(accepted, anyContentType) {
+		return returned
+	}
+	common := []string{}
+	for _, a := range accepted {
+		if containsString(returned, a) {
+			common = append(common, a)
+		}
+	}
+	return common
+}
+
+// containsContentType reports whether ct is among the candidates, either
+// listed or covered by the wildcard content type.
+func containsContentType(candidates []string, ct string) bool {
+	return containsString(candidates, ct) || containsString(candidates, anyContentType)
+}
+
+// hasExplicitContentType reports whether any of the nodes names the content
+// type it accepts
+func hasExplicitContentType(prs []*processNode, pus []*publishNode) bool {
+	for _, pr := range prs {
+		if pr.InboundContentType != "" || hasExplicitContentType(pr.ProcessNodes, pr.PublishNodes) {
+			return true
+		}
+	}
+	for _, pu := range pus {
+		if pu.InboundContentType != "" {
+			return true
+		}
+		if pu.deadLetter != nil && hasExplicitContentType(nil, []*publishNode{pu.deadLetter}) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeContentTypeError(err error, node core.Plugin, candidates []string) serror.SnapError {
+	fields := map[string]interface{}{
+		"plugin-name":    node.Name(),
+		"plugin-version": node.Version(),
+		"plugin-type":    node.TypeName(),
+	}
+	if candidates != nil {
+		fields["content-types"] = candidates
+	}
+	return serror.New(err, fields)
+}
@@ -0,0 +1,115 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/cdata"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockContentTypes map[string][2][]string
+
+func (m mockContentTypes) GetPluginContentTypes(name string, _ core.PluginType, _ int) ([]string, []string, error) {
+	ct, ok := m[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("plugin %s not found", name)
+	}
+	return ct[0], ct[1], nil
+}
+
+func TestContentTypeResolver(t *testing.T) {
+	manager := mockContentTypes{
+		"passthru": {{"snap.gob"}, {"snap.gob", "snap.json"}},
+		"file":     {{"snap.gob", "snap.json"}, nil},
+		"influxdb": {{"snap.json"}, nil},
+	}
+	newPublishNode := func(name string) *publishNode {
+		return &publishNode{name: name, version: -1, config: cdata.NewNode()}
+	}
+	Convey("Strict content types", t, func() {
+		r := &contentTypeResolver{manager: manager}
+		Convey("bind the only acceptable content type", func() {
+			pu := newPublishNode("influxdb")
+			pr := &processNode{name: "passthru", version: -1, config: cdata.NewNode(), PublishNodes: []*publishNode{pu}}
+			So(r.resolve([]*processNode{pr}, nil, []string{anyContentType}), ShouldBeEmpty)
+			So(pr.InboundContentType, ShouldEqual, "snap.gob")
+			So(pu.InboundContentType, ShouldEqual, "snap.json")
+		})
+		Convey("fail on ambiguous content types", func() {
+			pu := newPublishNode("file")
+			errs := r.resolve(nil, []*publishNode{pu}, []string{anyContentType})
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrAmbiguousContentType.Error())
+		})
+		Convey("accept an explicit content type", func() {
+			pu := newPublishNode("file")
+			pu.InboundContentType = "snap.json"
+			So(r.resolve(nil, []*publishNode{pu}, []string{anyContentType}), ShouldBeEmpty)
+			So(pu.InboundContentType, ShouldEqual, "snap.json")
+		})
+		Convey("resolve with the preference order", func() {
+			r.preference = []string{"snap.protobuf", "snap.gob"}
+			pu := newPublishNode("file")
+			So(r.resolve(nil, []*publishNode{pu}, []string{anyContentType}), ShouldBeEmpty)
+			So(pu.InboundContentType, ShouldEqual, "snap.gob")
+		})
+		Convey("fail without a common content type", func() {
+			pu := newPublishNode("influxdb")
+			errs := r.resolve(nil, []*publishNode{pu}, []string{"snap.gob"})
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrNoCommonContentType.Error())
+		})
+	})
+	Convey("Explicit content types only", t, func() {
+		r := &contentTypeResolver{manager: manager, explicitOnly: true}
+		Convey("leave the other nodes to the plugins", func() {
+			pu := newPublishNode("file")
+			So(r.resolve(nil, []*publishNode{pu}, []string{anyContentType}), ShouldBeEmpty)
+			So(pu.InboundContentType, ShouldEqual, "")
+		})
+		Convey("accept an explicit content type", func() {
+			pu := newPublishNode("file")
+			pu.InboundContentType = "snap.gob"
+			So(r.resolve(nil, []*publishNode{pu}, []string{anyContentType}), ShouldBeEmpty)
+			So(pu.InboundContentType, ShouldEqual, "snap.gob")
+		})
+		Convey("fail on a content type the plugin does not accept", func() {
+			pu := newPublishNode("influxdb")
+			pu.InboundContentType = "snap.gob"
+			errs := r.resolve(nil, []*publishNode{pu}, []string{anyContentType})
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrNoCommonContentType.Error())
+		})
+	})
+	Convey("hasExplicitContentType", t, func() {
+		pu := newPublishNode("file")
+		pr := &processNode{name: "passthru", version: -1, config: cdata.NewNode(), PublishNodes: []*publishNode{pu}}
+		So(hasExplicitContentType([]*processNode{pr}, nil), ShouldBeFalse)
+		pu.InboundContentType = "snap.json"
+		So(hasExplicitContentType([]*processNode{pr}, nil), ShouldBeTrue)
+	})
+}
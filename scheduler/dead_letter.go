@@ -0,0 +1,88 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// deadLetterMetric carries a metric a publisher failed to publish to a
+// dead-letter publish node, tagged with the publisher and its error.
+type deadLetterMetric struct {
+	core.Metric
+	tags map[string]string
+}
+
+func (m deadLetterMetric) Tags() map[string]string {
+	return m.tags
+}
+
+func newDeadLetterMetrics(mts []core.Metric, pu *publishNode, err error) []core.Metric {
+	dlm := make([]core.Metric, len(mts))
+	for i, m := range mts {
+		tags := make(map[string]string, len(m.Tags())+2)
+		for k, v := range m.Tags() {
+			tags[k] = v
+		}
+		tags[core.STD_TAG_DEAD_LETTER_PUBLISHER] = fmt.Sprintf("%s:%d", pu.Name(), pu.Version())
+		tags[core.STD_TAG_DEAD_LETTER_ERROR] = err.Error()
+		dlm[i] = deadLetterMetric{Metric: m, tags: tags}
+	}
+	return dlm
+}
+
+// sendToDeadLetter routes the metrics a publish node failed to publish to its
+// dead-letter node, if it has one. The dead-letter node is given a full task
+// deadline since the one of the failed job may be exhausted by its retries.
+func sendToDeadLetter(pj job, t *task, pu *publishNode, errs []error) {
+	if pu.deadLetter == nil || len(pj.Metrics()) == 0 {
+		return
+	}
+	workflowLogger.WithFields(log.Fields{
+		"_block":             "dead-letter",
+		"task-id":            t.id,
+		"task-name":          t.name,
+		"publish-name":       pu.Name(),
+		"publish-version":    pu.Version(),
+		"dead-letter-name":   pu.deadLetter.Name(),
+		"dead-letter-metric": len(pj.Metrics()),
+	}).Warn("Routing metrics to dead-letter publish node")
+	mts := newDeadLetterMetrics(pj.Metrics(), pu, errs[len(errs)-1])
+	deliver(&batchJob{
+		metrics: mts,
+		coreJob: newCoreJob(deadLetterJobType, time.Now().Add(t.DeadlineDuration()), t.id, "dead-letter", 0),
+	}, t, pu.deadLetter)
+}
+
+// deadLetters returns the dead-letter nodes of the given publish nodes
+func deadLetters(pus []*publishNode) []*publishNode {
+	var dls []*publishNode
+	for _, pu := range pus {
+		if pu.deadLetter != nil {
+			dls = append(dls, pu.deadLetter)
+		}
+	}
+	return dls
+}
@@ -0,0 +1,64 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeadLetterMetrics(t *testing.T) {
+	Convey("Dead-letter metrics", t, func() {
+		m := plugin.MetricType{
+			Namespace_: core.NewNamespace("intel", "mock", "foo"),
+			Data_:      1,
+			Tags_:      map[string]string{"rack": "1"},
+		}
+		pu := &publishNode{name: "influxdb", version: 2}
+		mts := newDeadLetterMetrics([]core.Metric{m}, pu, errors.New("connection refused"))
+		So(mts, ShouldHaveLength, 1)
+
+		Convey("keep the original metric", func() {
+			So(mts[0].Namespace(), ShouldResemble, m.Namespace())
+			So(mts[0].Data(), ShouldEqual, 1)
+		})
+		Convey("are tagged with the failed publisher and its error", func() {
+			tags := mts[0].Tags()
+			So(tags["rack"], ShouldEqual, "1")
+			So(tags[core.STD_TAG_DEAD_LETTER_PUBLISHER], ShouldEqual, "influxdb:2")
+			So(tags[core.STD_TAG_DEAD_LETTER_ERROR], ShouldEqual, "connection refused")
+			So(m.Tags(), ShouldNotContainKey, core.STD_TAG_DEAD_LETTER_ERROR)
+		})
+	})
+
+	Convey("deadLetters", t, func() {
+		dl := &publishNode{name: "file"}
+		pus := []*publishNode{{name: "influxdb", deadLetter: dl}, {name: "kafka"}}
+		So(deadLetters(pus), ShouldResemble, []*publishNode{dl})
+		So(deadLetters(nil), ShouldBeEmpty)
+	})
+}
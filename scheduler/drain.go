@@ -0,0 +1,119 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// ErrDrainTimeout is returned when the workflow runs in flight did not
+// finish before the drain timed out.
+var ErrDrainTimeout = errors.New("Timed out waiting for the workflow runs in flight to finish.")
+
+// drainPollInterval is how often a drain checks the runs in flight.
+var drainPollInterval = 100 * time.Millisecond
+
+// runGate is implemented by the managers of work that can stop new workflow
+// runs from starting, e.g. while snapteld is drained before an upgrade.
+type runGate interface {
+	// beginRun returns whether a workflow run can start, counting it as in
+	// flight until endRun is called when it does.
+	beginRun() bool
+	endRun()
+}
+
+// runTracker counts the workflow runs in flight and refuses new ones while
+// draining.
+type runTracker struct {
+	sync.Mutex
+	draining bool
+	inFlight int
+}
+
+func (r *runTracker) begin() bool {
+	r.Lock()
+	defer r.Unlock()
+	if r.draining {
+		return false
+	}
+	r.inFlight++
+	return true
+}
+
+func (r *runTracker) end() {
+	r.Lock()
+	defer r.Unlock()
+	r.inFlight--
+}
+
+func (r *runTracker) pending() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.inFlight
+}
+
+func (r *runTracker) setDraining(draining bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.draining = draining
+}
+
+func (w *workManager) beginRun() bool {
+	return w.runs.begin()
+}
+
+func (w *workManager) endRun() {
+	w.runs.end()
+}
+
+// Drain stops starting new workflow runs, the firings of the tasks are
+// counted as missed meanwhile, and waits for the runs in flight, with the
+// jobs they submitted, to finish. It returns ErrDrainTimeout when they did
+// not before the timeout; new runs stay stopped until Resume is called.
+func (s *scheduler) Drain(timeout time.Duration) error {
+	s.workManager.runs.setDraining(true)
+	schedulerLogger.WithFields(log.Fields{
+		"_block":  "drain",
+		"timeout": timeout.String(),
+	}).Info("Draining the scheduler")
+	deadline := time.Now().Add(timeout)
+	for s.workManager.runs.pending() > 0 {
+		if time.Now().After(deadline) {
+			schedulerLogger.WithFields(log.Fields{
+				"_block":    "drain",
+				"in-flight": s.workManager.runs.pending(),
+			}).Warn(ErrDrainTimeout)
+			return ErrDrainTimeout
+		}
+		time.Sleep(drainPollInterval)
+	}
+	schedulerLogger.WithField("_block", "drain").Info("Scheduler drained")
+	return nil
+}
+
+// Resume starts workflow runs again after a drain.
+func (s *scheduler) Resume() {
+	s.workManager.runs.setDraining(false)
+	schedulerLogger.WithField("_block", "resume").Info("Resuming workflow runs")
+}
@@ -0,0 +1,60 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDrain(t *testing.T) {
+	Convey("Given a scheduler", t, func() {
+		s := &scheduler{workManager: &workManager{}}
+		tk := &task{manager: s.workManager}
+
+		Convey("a drain without runs in flight returns at once", func() {
+			So(s.Drain(time.Second), ShouldBeNil)
+			Convey("and new runs are refused until resumed", func() {
+				So(tk.beginRun(), ShouldBeFalse)
+				s.Resume()
+				So(tk.beginRun(), ShouldBeTrue)
+				tk.endRun()
+			})
+		})
+		Convey("a drain waits for the runs in flight", func() {
+			So(tk.beginRun(), ShouldBeTrue)
+			go func() {
+				time.Sleep(2 * drainPollInterval)
+				tk.endRun()
+			}()
+			So(s.Drain(5*time.Second), ShouldBeNil)
+			So(s.workManager.runs.pending(), ShouldEqual, 0)
+		})
+		Convey("a drain times out when runs stay in flight", func() {
+			So(tk.beginRun(), ShouldBeTrue)
+			So(s.Drain(2*drainPollInterval), ShouldEqual, ErrDrainTimeout)
+			tk.endRun()
+		})
+	})
+}
@@ -0,0 +1,135 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// filterNodeName is the name reported for built-in filter nodes
+const filterNodeName = "filter"
+
+var (
+	// ErrFilterNodeWithPlugin is returned when a filter node also names a processor plugin
+	ErrFilterNodeWithPlugin = errors.New("Filter node cannot reference a plugin")
+	// ErrFilterNodeWithTarget is returned when a filter node is given a remote target
+	ErrFilterNodeWithTarget = errors.New("Filter node runs in-process and cannot have a target")
+)
+
+// metricFilter selects the metrics passed on by a filter node
+type metricFilter struct {
+	include []string
+	exclude []string
+	tags    map[string]string
+}
+
+func newMetricFilter(f *wmap.FilterWorkflowMapNode) (*metricFilter, error) {
+	for _, g := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("Invalid filter glob '%s': %v", g, err)
+		}
+	}
+	return &metricFilter{
+		include: f.Include,
+		exclude: f.Exclude,
+		tags:    f.Tags,
+	}, nil
+}
+
+// match reports whether the metric is passed on by the filter. A metric
+// must match one of the include globs (when any are given), none of the
+// exclude globs and carry every tag of the filter.
+func (f *metricFilter) match(m core.Metric) bool {
+	ns := m.Namespace().String()
+	if len(f.include) > 0 && !matchAny(f.include, ns) {
+		return false
+	}
+	if matchAny(f.exclude, ns) {
+		return false
+	}
+	tags := m.Tags()
+	for k, v := range f.tags {
+		if tv, ok := tags[k]; !ok || tv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *metricFilter) filter(mts []core.Metric) []core.Metric {
+	out := make([]core.Metric, 0, len(mts))
+	for _, m := range mts {
+		if f.match(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func matchAny(globs []string, ns string) bool {
+	for _, g := range globs {
+		// patterns are validated when the filter is created
+		if ok, _ := path.Match(g, ns); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type filterJob struct {
+	*coreJob
+	parentJob job
+	filter    *metricFilter
+	metrics   []core.Metric
+}
+
+func newFilterJob(parentJob job, filter *metricFilter, taskID string) job {
+	j := &filterJob{
+		parentJob: parentJob,
+		filter:    filter,
+		metrics:   []core.Metric{},
+		coreJob:   newCoreJob(filterJobType, parentJob.Deadline(), taskID, filterNodeName, 0),
+	}
+	j.traceFrom = jobSpanContext(parentJob)
+	return j
+}
+
+func (f *filterJob) Metrics() []core.Metric {
+	return f.metrics
+}
+
+func (f *filterJob) Run() {
+	mts := f.parentJob.Metrics()
+	f.metrics = f.filter.filter(mts)
+	log.WithFields(log.Fields{
+		"_module":        "scheduler-job",
+		"block":          "run",
+		"job-type":       "filter",
+		"metrics-in":     len(mts),
+		"metrics-passed": len(f.metrics),
+	}).Debug("filter job completed")
+}
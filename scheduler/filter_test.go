@@ -0,0 +1,79 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/control/plugin"
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetricFilter(t *testing.T) {
+	mts := []core.Metric{
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", "foo"), Tags_: map[string]string{"dc": "east"}},
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", "bar"), Tags_: map[string]string{"dc": "west"}},
+		plugin.MetricType{Namespace_: core.NewNamespace("intel", "psutil", "load")},
+	}
+	Convey("Filter nodes", t, func() {
+		Convey("pass every metric without rules", func() {
+			f, err := newMetricFilter(&wmap.FilterWorkflowMapNode{})
+			So(err, ShouldBeNil)
+			So(f.filter(mts), ShouldHaveLength, 3)
+		})
+		Convey("keep the included namespaces", func() {
+			f, err := newMetricFilter(&wmap.FilterWorkflowMapNode{Include: []string{"/intel/mock/*"}})
+			So(err, ShouldBeNil)
+			out := f.filter(mts)
+			So(out, ShouldHaveLength, 2)
+			So(out[0].Namespace().String(), ShouldEqual, "/intel/mock/foo")
+		})
+		Convey("drop the excluded namespaces", func() {
+			f, err := newMetricFilter(&wmap.FilterWorkflowMapNode{
+				Include: []string{"/intel/*/*"},
+				Exclude: []string{"/intel/mock/bar"},
+			})
+			So(err, ShouldBeNil)
+			out := f.filter(mts)
+			So(out, ShouldHaveLength, 2)
+			So(out[1].Namespace().String(), ShouldEqual, "/intel/psutil/load")
+		})
+		Convey("keep only metrics carrying the tags", func() {
+			f, err := newMetricFilter(&wmap.FilterWorkflowMapNode{Tags: map[string]string{"dc": "west"}})
+			So(err, ShouldBeNil)
+			out := f.filter(mts)
+			So(out, ShouldHaveLength, 1)
+			So(out[0].Namespace().String(), ShouldEqual, "/intel/mock/bar")
+		})
+		Convey("reject invalid globs", func() {
+			_, err := newMetricFilter(&wmap.FilterWorkflowMapNode{Include: []string{"/intel/["}})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("cannot reference a plugin", func() {
+			_, err := convertProcessNode([]wmap.ProcessWorkflowMapNode{{Name: "passthru", Filter: &wmap.FilterWorkflowMapNode{}}})
+			So(err, ShouldEqual, ErrFilterNodeWithPlugin)
+		})
+	})
+}
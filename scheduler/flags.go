@@ -38,6 +38,18 @@ var (
 		EnvVar: "WORK_MANAGER_POOL_SIZE",
 	}
 
+	flSchedulerBranchConcurrency = cli.StringFlag{
+		Name:   "work-manager-branch-concurrency",
+		Usage:  fmt.Sprintf("Number of process and publish jobs run at once across workflow branches, 0 to use the work manager pool (default: %v)", defaultWorkManagerBranchConcurrency),
+		EnvVar: "WORK_MANAGER_BRANCH_CONCURRENCY",
+	}
+
+	flAccountingPath = cli.StringFlag{
+		Name:   "accounting-path",
+		Usage:  "Path to the file metrics accounting is persisted in (default: not persisted)",
+		EnvVar: "SNAP_ACCOUNTING_PATH",
+	}
+
 	// Flags consumed by snapteld
-	Flags = []cli.Flag{flSchedulerQueueSize, flSchedulerPoolSize}
+	Flags = []cli.Flag{flSchedulerQueueSize, flSchedulerPoolSize, flSchedulerBranchConcurrency, flAccountingPath}
 )
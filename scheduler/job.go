@@ -24,6 +24,7 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	opentracing "github.com/opentracing/opentracing-go"
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
@@ -35,6 +36,9 @@ const (
 	collectJobType jobType = iota
 	publishJobType
 	processJobType
+	filterJobType
+	batchJobType
+	deadLetterJobType
 )
 
 const (
@@ -101,6 +105,9 @@ type coreJob struct {
 	deadline  time.Time
 	starttime time.Time
 	errors    []error
+	// traceFrom is the context of the span the span of the job is a child of
+	traceFrom opentracing.SpanContext
+	span      opentracing.Span
 }
 
 func newCoreJob(t jobType, deadline time.Time, taskID string, name string, version int) *coreJob {
@@ -145,6 +152,15 @@ func (c *coreJob) TypeString() string {
 
 	case publishJobType:
 		return "publisher"
+
+	case filterJobType:
+		return "filter"
+
+	case batchJobType:
+		return "batch"
+
+	case deadLetterJobType:
+		return "dead-letter"
 	}
 	return "unknown"
 }
@@ -170,6 +186,8 @@ type collectorJob struct {
 	metrics        []core.Metric
 	configDataTree *cdata.ConfigDataTree
 	tags           map[string]map[string]string
+	// timeout overrides the timeout of the calls to the collectors when set
+	timeout time.Duration
 }
 
 func newCollectorJob(
@@ -194,6 +212,8 @@ type metric struct {
 	namespace core.Namespace
 	version   int
 	config    *cdata.ConfigDataNode
+	// collected on every nth firing of the task only, when greater than 1
+	every int
 }
 
 func (m *metric) Namespace() core.Namespace {
@@ -215,11 +235,25 @@ func (m *metric) Tags() map[string]string       { return nil }
 func (m *metric) LastAdvertisedTime() time.Time { return time.Unix(0, 0) }
 func (m *metric) Timestamp() time.Time          { return time.Unix(0, 0) }
 
+// catalogQuery is a requested metric expanded by control to every cataloged
+// metric matching its namespace and tags (see core.CatalogQuery).
+type catalogQuery struct {
+	metric
+	tags map[string]string
+}
+
+func (q *catalogQuery) QueryTags() map[string]string {
+	return q.tags
+}
+
 func (c *collectorJob) Metrics() []core.Metric {
 	return c.metrics
 }
 
 func (c *collectorJob) Run() {
+	c.startSpan()
+	defer c.finishSpan()
+
 	log.WithFields(log.Fields{
 		"_module":      "scheduler-job",
 		"block":        "run",
@@ -240,7 +274,13 @@ func (c *collectorJob) Run() {
 		}
 	}
 
-	ret, errs := c.collector.CollectMetrics(c.TaskID(), c.tags)
+	var ret []core.Metric
+	var errs []error
+	if tc, ok := c.collector.(timesOutCalls); ok && c.timeout > 0 {
+		ret, errs = tc.CollectMetricsTimeout(c.TaskID(), c.tags, c.timeout)
+	} else {
+		ret, errs = c.collector.CollectMetrics(c.TaskID(), c.tags)
+	}
 
 	log.WithFields(log.Fields{
 		"_module":      "scheduler-job",
@@ -269,6 +309,8 @@ type processJob struct {
 	parentJob job
 	metrics   []core.Metric
 	config    map[string]ctypes.ConfigValue
+	// timeout overrides the timeout of the call to the processor when set
+	timeout time.Duration
 }
 
 func (pr *processJob) Metrics() []core.Metric {
@@ -276,16 +318,21 @@ func (pr *processJob) Metrics() []core.Metric {
 }
 
 func newProcessJob(parentJob job, pluginName string, pluginVersion int, contentType string, config map[string]ctypes.ConfigValue, processor processesMetrics, taskID string) job {
-	return &processJob{
+	j := &processJob{
 		parentJob: parentJob,
 		metrics:   []core.Metric{},
 		coreJob:   newCoreJob(processJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion),
 		config:    config,
 		processor: processor,
 	}
+	j.traceFrom = jobSpanContext(parentJob)
+	return j
 }
 
 func (p *processJob) Run() {
+	p.startSpan()
+	defer p.finishSpan()
+
 	log.WithFields(log.Fields{
 		"_module":        "scheduler-job",
 		"block":          "run",
@@ -295,7 +342,13 @@ func (p *processJob) Run() {
 		"plugin-config":  p.config,
 	}).Debug("starting processor job")
 
-	mts, errs := p.processor.ProcessMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	var mts []core.Metric
+	var errs []error
+	if tc, ok := p.processor.(timesOutCalls); ok && p.timeout > 0 {
+		mts, errs = tc.ProcessMetricsTimeout(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version, p.timeout)
+	} else {
+		mts, errs = p.processor.ProcessMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	}
 	if errs != nil {
 		for _, e := range errs {
 			log.WithFields(log.Fields{
@@ -318,6 +371,8 @@ type publisherJob struct {
 	parentJob job
 	publisher publishesMetrics
 	config    map[string]ctypes.ConfigValue
+	// timeout overrides the timeout of the call to the publisher when set
+	timeout time.Duration
 }
 
 func (pu *publisherJob) Metrics() []core.Metric {
@@ -325,15 +380,20 @@ func (pu *publisherJob) Metrics() []core.Metric {
 }
 
 func newPublishJob(parentJob job, pluginName string, pluginVersion int, contentType string, config map[string]ctypes.ConfigValue, publisher publishesMetrics, taskID string) job {
-	return &publisherJob{
+	j := &publisherJob{
 		parentJob: parentJob,
 		publisher: publisher,
 		coreJob:   newCoreJob(publishJobType, parentJob.Deadline(), taskID, pluginName, pluginVersion),
 		config:    config,
 	}
+	j.traceFrom = jobSpanContext(parentJob)
+	return j
 }
 
 func (p *publisherJob) Run() {
+	p.startSpan()
+	defer p.finishSpan()
+
 	log.WithFields(log.Fields{
 		"_module":        "scheduler-job",
 		"block":          "run",
@@ -343,7 +403,12 @@ func (p *publisherJob) Run() {
 		"plugin-config":  p.config,
 	}).Debug("starting publisher job")
 
-	errs := p.publisher.PublishMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	var errs []error
+	if tc, ok := p.publisher.(timesOutCalls); ok && p.timeout > 0 {
+		errs = tc.PublishMetricsTimeout(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version, p.timeout)
+	} else {
+		errs = p.publisher.PublishMetrics(p.parentJob.Metrics(), p.config, p.taskID, p.name, p.version)
+	}
 	if errs != nil {
 		for _, e := range errs {
 			log.WithFields(log.Fields{
@@ -0,0 +1,39 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import "github.com/intelsdi-x/snap/core"
+
+// dueMetrics returns the requested metrics to collect on the given firing of
+// a task, counted from 1. Metrics collected every nth firing are collected on
+// the first firing and every nth firing after it.
+func dueMetrics(mts []core.RequestedMetric, firing uint) []core.RequestedMetric {
+	if firing > 0 {
+		firing--
+	}
+	due := make([]core.RequestedMetric, 0, len(mts))
+	for _, rm := range mts {
+		if m, ok := rm.(*metric); ok && m.every > 1 && firing%uint(m.every) != 0 {
+			continue
+		}
+		due = append(due, rm)
+	}
+	return due
+}
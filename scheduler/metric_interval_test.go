@@ -0,0 +1,49 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/core"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDueMetrics(t *testing.T) {
+	Convey("Metrics collected every nth firing", t, func() {
+		fast := &metric{namespace: core.NewNamespace("intel", "mock", "foo")}
+		slow := &metric{namespace: core.NewNamespace("intel", "mock", "bar"), every: 3}
+		query := &catalogQuery{metric: metric{namespace: core.NewNamespace("intel", "mock", "*"), every: 3}}
+		mts := []core.RequestedMetric{fast, slow, query}
+		Convey("are collected on the first firing", func() {
+			So(dueMetrics(mts, 1), ShouldResemble, mts)
+		})
+		Convey("are skipped until their nth firing", func() {
+			So(dueMetrics(mts, 2), ShouldResemble, []core.RequestedMetric{fast, query})
+			So(dueMetrics(mts, 3), ShouldResemble, []core.RequestedMetric{fast, query})
+			So(dueMetrics(mts, 4), ShouldResemble, mts)
+		})
+		Convey("may leave nothing to collect", func() {
+			So(dueMetrics([]core.RequestedMetric{slow}, 2), ShouldBeEmpty)
+		})
+	})
+}
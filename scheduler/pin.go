@@ -0,0 +1,53 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// ErrVersionPinningUnsupported is returned when a workflow pins its versions
+// while the metric manager cannot tell the versions it has loaded
+var ErrVersionPinningUnsupported = errors.New("Metric manager cannot resolve the versions to pin")
+
+// resolvesVersions is implemented by metric managers able to report the
+// latest version of the plugins and metrics they have loaded
+type resolvesVersions interface {
+	LatestPluginVersion(string, string) (int, error)
+	LatestMetricVersion(core.Namespace) (int, error)
+}
+
+// pinVersions returns the workflow map with the latest versions it selects
+// replaced by the versions currently loaded, when it asks for it.
+func (s *scheduler) pinVersions(wfMap *wmap.WorkflowMap) (*wmap.WorkflowMap, error) {
+	if !wfMap.PinVersions {
+		return wfMap, nil
+	}
+	rv, ok := s.metricManager.(resolvesVersions)
+	if !ok {
+		return nil, ErrVersionPinningUnsupported
+	}
+	return wfMap.Pin(rv.LatestPluginVersion, func(ns []string) (int, error) {
+		return rv.LatestMetricVersion(core.NewNamespace(ns...))
+	})
+}
@@ -99,6 +99,13 @@ func (q *queue) Stop() {
 	}
 }
 
+// Len returns the number of jobs waiting in the queue.
+func (q *queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.length()
+}
+
 /*
    Below is the private, internal functionality of the queue.
    These functions are not thread-safe, and should not be used
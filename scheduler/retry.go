@@ -0,0 +1,79 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/pkg/chrono"
+)
+
+// ErrInvalidRetries is returned when a workflow node is given a negative number of retries
+var ErrInvalidRetries = errors.New("Retries of a workflow node must not be negative")
+
+// retryPolicy describes how many times and how often a failed process or
+// publish job of a workflow node is retried within a run
+type retryPolicy struct {
+	retries int
+	delay   time.Duration
+}
+
+func newRetryPolicy(retries int, delay string) (retryPolicy, error) {
+	if retries < 0 {
+		return retryPolicy{}, ErrInvalidRetries
+	}
+	r := retryPolicy{retries: retries}
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return retryPolicy{}, fmt.Errorf("Invalid retry_delay '%s': %v", delay, err)
+		}
+		r.delay = d
+	}
+	return r, nil
+}
+
+// work submits the job returned by newJob until it succeeds or the retries
+// are exhausted. Retrying stops early when the next attempt would begin
+// after the deadline of the run since the worker would refuse it anyway.
+func (r retryPolicy) work(t *task, deadline time.Time, newJob func() job) (job, []error) {
+	for attempt := 0; ; attempt++ {
+		j := newJob()
+		errs := t.manager.Work(j).Promise().Await()
+		if len(errs) == 0 || attempt >= r.retries || chrono.Chrono.Now().Add(r.delay).After(deadline) {
+			return j, errs
+		}
+		workflowLogger.WithFields(log.Fields{
+			"_block":      "retry-job",
+			"task-id":     t.id,
+			"task-name":   t.name,
+			"job-type":    j.TypeString(),
+			"plugin-name": j.Name(),
+			"attempt":     attempt + 1,
+			"retries":     r.retries,
+			"error":       errs[len(errs)-1].Error(),
+		}).Warn("Job failed, retrying")
+		time.Sleep(r.delay)
+	}
+}
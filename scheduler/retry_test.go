@@ -0,0 +1,53 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	Convey("Retry policies", t, func() {
+		Convey("default to no retries", func() {
+			r, err := newRetryPolicy(0, "")
+			So(err, ShouldBeNil)
+			So(r, ShouldResemble, retryPolicy{})
+		})
+		Convey("parse the retry delay", func() {
+			r, err := newRetryPolicy(3, "5s")
+			So(err, ShouldBeNil)
+			So(r.retries, ShouldEqual, 3)
+			So(r.delay, ShouldEqual, 5*time.Second)
+		})
+		Convey("reject negative retries", func() {
+			_, err := newRetryPolicy(-1, "")
+			So(err, ShouldEqual, ErrInvalidRetries)
+		})
+		Convey("reject an invalid retry delay", func() {
+			_, err := newRetryPolicy(1, "soon")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
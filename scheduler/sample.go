@@ -0,0 +1,88 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+// sampleNodeName is the name reported for built-in sample nodes
+const sampleNodeName = "sample"
+
+var (
+	// ErrSampleNodeWithPlugin is returned when a sample node also names a processor plugin
+	ErrSampleNodeWithPlugin = errors.New("Sample node cannot reference a plugin")
+	// ErrSampleNodeWithTarget is returned when a sample node is given a remote target
+	ErrSampleNodeWithTarget = errors.New("Sample node runs in-process and cannot have a target")
+	// ErrSampleNodeWithFilter is returned when a node is both a sample and a filter node
+	ErrSampleNodeWithFilter = errors.New("Sample node cannot also be a filter node")
+	// ErrInvalidSampleNode is returned when a sample node does not set exactly one of every and interval
+	ErrInvalidSampleNode = errors.New("Sample node must set exactly one of every and interval")
+)
+
+// sampler decides which task runs a sample node forwards to its child
+// nodes. It keeps state across runs so it belongs to a single workflow.
+type sampler struct {
+	sync.Mutex
+	every    uint64
+	interval time.Duration
+	runs     uint64
+	last     time.Time
+}
+
+func newSampler(s *wmap.SampleWorkflowMapNode) (*sampler, error) {
+	if (s.Every > 0) == (s.Interval != "") || s.Every < 0 {
+		return nil, ErrInvalidSampleNode
+	}
+	smp := &sampler{every: uint64(s.Every)}
+	if s.Interval != "" {
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sample interval '%s': %v", s.Interval, err)
+		}
+		if d <= 0 {
+			return nil, ErrInvalidSampleNode
+		}
+		smp.interval = d
+	}
+	return smp, nil
+}
+
+// sample reports whether the run happening at the given time is forwarded.
+// The first run is always forwarded, then every Nth run or the first run
+// once the interval has elapsed since the last forwarded one.
+func (s *sampler) sample(now time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+	defer func() { s.runs++ }()
+	if s.every > 0 {
+		return s.runs%s.every == 0
+	}
+	if s.runs == 0 || now.Sub(s.last) >= s.interval {
+		s.last = now
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSampler(t *testing.T) {
+	Convey("Sample nodes", t, func() {
+		Convey("forward every Nth run", func() {
+			s, err := newSampler(&wmap.SampleWorkflowMapNode{Every: 3})
+			So(err, ShouldBeNil)
+			now := time.Now()
+			forwarded := []bool{}
+			for i := 0; i < 7; i++ {
+				forwarded = append(forwarded, s.sample(now))
+			}
+			So(forwarded, ShouldResemble, []bool{true, false, false, true, false, false, true})
+		})
+		Convey("forward at most one run per interval", func() {
+			s, err := newSampler(&wmap.SampleWorkflowMapNode{Interval: "1m"})
+			So(err, ShouldBeNil)
+			now := time.Now()
+			So(s.sample(now), ShouldBeTrue)
+			So(s.sample(now.Add(30*time.Second)), ShouldBeFalse)
+			So(s.sample(now.Add(time.Minute)), ShouldBeTrue)
+			So(s.sample(now.Add(90*time.Second)), ShouldBeFalse)
+		})
+		Convey("need exactly one of every and interval", func() {
+			_, err := newSampler(&wmap.SampleWorkflowMapNode{})
+			So(err, ShouldEqual, ErrInvalidSampleNode)
+			_, err = newSampler(&wmap.SampleWorkflowMapNode{Every: 2, Interval: "1m"})
+			So(err, ShouldEqual, ErrInvalidSampleNode)
+			_, err = newSampler(&wmap.SampleWorkflowMapNode{Interval: "often"})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("are built in", func() {
+			prs, err := convertProcessNode([]wmap.ProcessWorkflowMapNode{*wmap.NewSampleNode(10, "")})
+			So(err, ShouldBeNil)
+			So(prs[0].Name(), ShouldEqual, sampleNodeName)
+			So(prs[0].builtin(), ShouldBeTrue)
+		})
+		Convey("cannot reference a plugin", func() {
+			_, err := convertProcessNode([]wmap.ProcessWorkflowMapNode{{Name: "passthru", Sample: &wmap.SampleWorkflowMapNode{Every: 2}}})
+			So(err, ShouldEqual, ErrSampleNodeWithPlugin)
+		})
+	})
+}
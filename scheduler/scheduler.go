@@ -67,6 +67,10 @@ var (
 	ErrTaskDisabledNotStoppable = errors.New("Task is disabled. Only running tasks can be stopped.")
 	// ErrTaskEndedNotStoppable - The error message for when a task is ended and cannot be stopped
 	ErrTaskEndedNotStoppable = errors.New("Task is ended. Only running tasks can be stopped.")
+	// ErrTaskRevisionMismatch - The error message for an operation expecting another revision of the task
+	ErrTaskRevisionMismatch = errors.New("Task was modified, its revision does not match the expected one.")
+	// ErrStreamingScheduleUpdate - The error message for updating a task to or from a streaming schedule
+	ErrStreamingScheduleUpdate = errors.New("The schedule of a task cannot be changed to or from a streaming schedule.")
 )
 
 type schedulerState int
@@ -121,6 +125,13 @@ type scheduler struct {
 	state           schedulerState
 	eventManager    *gomit.EventController
 	taskWatcherColl *taskWatcherCollection
+	accountant      *accountant
+	// content type negotiation, checking only explicit content types unless strict
+	contentTypes *contentTypeResolver
+	workflows    *workflowRegistry
+	// config merged into the process and publish nodes of new workflows
+	configDefaults  wmap.ConfigDefaults
+	workflowFetcher *workflowFetcher
 }
 
 type managesWork interface {
@@ -251,11 +262,28 @@ func New(cfg *Config) *scheduler {
 		PublishWkrSizeOption(cfg.WorkManagerPoolSize),
 		ProcessQSizeOption(cfg.WorkManagerQueueSize),
 		ProcessWkrSizeOption(cfg.WorkManagerPoolSize),
+		BranchConcurrencyOption(cfg.WorkManagerBranchConcurrency),
 	}
 	s := &scheduler{
 		tasks:           newTaskCollection(),
 		eventManager:    gomit.NewEventController(),
 		taskWatcherColl: newTaskWatcherCollection(),
+		accountant:      newAccountant(cfg.AccountingPath),
+		workflows:       newWorkflowRegistry(),
+		configDefaults:  cfg.WorkflowDefaults,
+		workflowFetcher: newWorkflowFetcher(),
+	}
+
+	if cfg.SecretsFromEnv {
+		core.RegisterSecretResolver("env", core.NewEnvSecretResolver())
+	}
+	if cfg.SecretsDir != "" {
+		core.RegisterSecretResolver("file", core.NewFileSecretResolver(cfg.SecretsDir))
+	}
+
+	s.contentTypes = &contentTypeResolver{
+		preference:   cfg.ContentTypePreference,
+		explicitOnly: !cfg.StrictContentTypes,
 	}
 
 	// we are setting the size of the queue and number of workers for
@@ -319,6 +347,30 @@ func (s *scheduler) createTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, s
 		return nil, te
 	}
 
+	wfMap, workflowRef, se := s.resolveWorkflow(wfMap)
+	if se != nil {
+		te.errs = append(te.errs, se)
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("Unable to resolve workflow")
+		return nil, te
+	}
+
+	wfMap, err := s.pinVersions(wfMap)
+	if err != nil {
+		te.errs = append(te.errs, serror.New(err))
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("Unable to pin the versions of the workflow")
+		return nil, te
+	}
+
+	wfMap, err = wfMap.ApplyConfigDefaults(s.configDefaults)
+	if err != nil {
+		te.errs = append(te.errs, serror.New(err))
+		f := buildErrorsLog(te.Errors(), logger)
+		f.Error("Unable to apply the config defaults to the workflow")
+		return nil, te
+	}
+
 	// Generate a workflow from the workflow map
 	wf, err := wmapToWorkflow(wfMap)
 	if err != nil {
@@ -336,23 +388,11 @@ func (s *scheduler) createTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, s
 		f.Error("Unable to create task")
 		return nil, te
 	}
+	task.workflowRef = workflowRef
 
-	// Group dependencies by the node they live on
-	// and validate them.
-	depGroups := getWorkflowPlugins(wf.processNodes, wf.publishNodes, wf.metrics)
-	for k, group := range depGroups {
-		manager, err := task.RemoteManagers.Get(k)
-		if err != nil {
-			te.errs = append(te.errs, serror.New(err))
-			return nil, te
-		}
-		var errs []serror.SnapError
-		errs = manager.ValidateDeps(group.requestedMetrics, group.subscribedPlugins, wf.configTree)
-
-		if len(errs) > 0 {
-			te.errs = append(te.errs, errs...)
-			return nil, te
-		}
+	if errs := s.validateWorkflow(task, wf); len(errs) > 0 {
+		te.errs = append(te.errs, errs...)
+		return nil, te
 	}
 
 	// Add task to taskCollection
@@ -390,28 +430,84 @@ func (s *scheduler) createTask(sch schedule.Schedule, wfMap *wmap.WorkflowMap, s
 	return task, te
 }
 
+// resolveWorkflow returns the workflow found at the URI of the workflow map
+// or the stored workflow it references, along with the name of the latter.
+func (s *scheduler) resolveWorkflow(wfMap *wmap.WorkflowMap) (*wmap.WorkflowMap, string, serror.SnapError) {
+	if uri := wfMap.URI; uri != "" {
+		fetched, err := s.workflowFetcher.get(uri)
+		if err != nil {
+			return nil, "", serror.New(err, map[string]interface{}{"workflow-uri": uri})
+		}
+		wfMap = fetched
+	}
+	if ref := wfMap.Ref; ref != "" {
+		sw, err := s.workflows.get(ref)
+		if err != nil {
+			return nil, "", serror.New(err, map[string]interface{}{"workflow-name": ref})
+		}
+		return sw.wmap, ref, nil
+	}
+	return wfMap, "", nil
+}
+
+// validateWorkflow validates the dependencies of a workflow against the
+// managers of the task, grouped by the node they live on, and binds the
+// content type of every edge of the workflow.
+func (s *scheduler) validateWorkflow(t *task, wf *schedulerWorkflow) []serror.SnapError {
+	depGroups := getWorkflowPlugins(wf.processNodes, wf.publishNodes, wf.metrics)
+	for k, group := range depGroups {
+		manager, err := t.RemoteManagers.Get(k)
+		if err != nil {
+			return []serror.SnapError{serror.New(err)}
+		}
+		if err := checkVersionConstraints(manager, group.subscribedPlugins); err != nil {
+			return []serror.SnapError{serror.New(err)}
+		}
+		if errs := manager.ValidateDeps(group.requestedMetrics, group.subscribedPlugins, wf.configTree); len(errs) > 0 {
+			return errs
+		}
+	}
+
+	if s.contentTypes != nil && s.contentTypes.manager != nil &&
+		(!s.contentTypes.explicitOnly || hasExplicitContentType(wf.processNodes, wf.publishNodes)) {
+		if errs := s.contentTypes.resolve(wf.processNodes, wf.publishNodes, []string{anyContentType}); len(errs) > 0 {
+			f := buildErrorsLog(errs, schedulerLogger.WithField("_block", "validate-workflow"))
+			f.Error("content types of the workflow are ambiguous")
+			return errs
+		}
+	}
+	return nil
+}
+
 // RemoveTask given a tasks id.  The task must be stopped.
 // Can return errors ErrTaskNotFound and ErrTaskNotStopped.
 func (s *scheduler) RemoveTask(id string) error {
-	return s.removeTask(id, "user")
+	return s.removeTask(id, "user", 0)
+}
+
+// RemoveTaskIfRevision removes a task if it was not modified since the given
+// revision. Can also return ErrTaskRevisionMismatch.
+func (s *scheduler) RemoveTaskIfRevision(id string, revision uint64) error {
+	return s.removeTask(id, "user", revision)
 }
 
 func (s *scheduler) RemoveTaskTribe(id string) error {
-	return s.removeTask(id, "tribe")
+	return s.removeTask(id, "tribe", 0)
 }
 
-func (s *scheduler) removeTask(id, source string) error {
+func (s *scheduler) removeTask(id, source string, revision uint64) error {
 	logger := schedulerLogger.WithFields(log.Fields{
 		"_block": "remove-task",
 		"source": source,
 	})
-	t, err := s.getTask(id)
+	t, err := s.lockTask(id, revision)
 	if err != nil {
 		logger.WithFields(log.Fields{
 			"task id": id,
-		}).Error(ErrTaskNotFound)
+		}).Error(err)
 		return err
 	}
+	defer t.opMutex.Unlock()
 	event := &scheduler_event.TaskDeletedEvent{
 		TaskID: t.id,
 		Source: source,
@@ -446,30 +542,37 @@ func (s *scheduler) GetTask(id string) (core.Task, error) {
 
 // StartTask provided a task id a task is started
 func (s *scheduler) StartTask(id string) []serror.SnapError {
-	return s.startTask(id, "user")
+	return s.startTask(id, "user", 0)
+}
+
+// StartTaskIfRevision starts a task if it was not modified since the given
+// revision.
+func (s *scheduler) StartTaskIfRevision(id string, revision uint64) []serror.SnapError {
+	return s.startTask(id, "user", revision)
 }
 
 func (s *scheduler) StartTaskTribe(id string) []serror.SnapError {
-	return s.startTask(id, "tribe")
+	return s.startTask(id, "tribe", 0)
 }
 
-func (s *scheduler) startTask(id, source string) []serror.SnapError {
+func (s *scheduler) startTask(id, source string, revision uint64) []serror.SnapError {
 	logger := schedulerLogger.WithFields(log.Fields{
 		"_block": "start-task",
 		"source": source,
 	})
 
-	t, err := s.getTask(id)
+	t, err := s.lockTask(id, revision)
 	if err != nil {
 		schedulerLogger.WithFields(log.Fields{
 			"_block":  "start-task",
-			"_error":  ErrTaskNotFound,
+			"_error":  err,
 			"task-id": id,
 		}).Error("error starting task")
 		return []serror.SnapError{
 			serror.New(err),
 		}
 	}
+	defer t.opMutex.Unlock()
 
 	if t.state == core.TaskDisabled {
 		logger.WithFields(log.Fields{
@@ -511,6 +614,7 @@ func (s *scheduler) startTask(id, source string) []serror.SnapError {
 	}
 	defer s.eventManager.Emit(event)
 	t.Spin()
+	t.bumpRevision()
 	logger.WithFields(log.Fields{
 		"task-id":    t.ID(),
 		"task-state": t.State(),
@@ -520,19 +624,25 @@ func (s *scheduler) startTask(id, source string) []serror.SnapError {
 
 // StopTask provided a task id a task is stopped
 func (s *scheduler) StopTask(id string) []serror.SnapError {
-	return s.stopTask(id, "user")
+	return s.stopTask(id, "user", 0)
+}
+
+// StopTaskIfRevision stops a task if it was not modified since the given
+// revision.
+func (s *scheduler) StopTaskIfRevision(id string, revision uint64) []serror.SnapError {
+	return s.stopTask(id, "user", revision)
 }
 
 func (s *scheduler) StopTaskTribe(id string) []serror.SnapError {
-	return s.stopTask(id, "tribe")
+	return s.stopTask(id, "tribe", 0)
 }
 
-func (s *scheduler) stopTask(id, source string) []serror.SnapError {
+func (s *scheduler) stopTask(id, source string, revision uint64) []serror.SnapError {
 	logger := schedulerLogger.WithFields(log.Fields{
 		"_block": "stop-task",
 		"source": source,
 	})
-	t, err := s.getTask(id)
+	t, err := s.lockTask(id, revision)
 	if err != nil {
 		logger.WithFields(log.Fields{
 			"_error":  err.Error(),
@@ -542,6 +652,7 @@ func (s *scheduler) stopTask(id, source string) []serror.SnapError {
 			serror.New(err),
 		}
 	}
+	defer t.opMutex.Unlock()
 
 	switch t.state {
 	case core.TaskStopped:
@@ -580,6 +691,7 @@ func (s *scheduler) stopTask(id, source string) []serror.SnapError {
 		}
 		defer s.eventManager.Emit(event)
 		t.Stop()
+		t.bumpRevision()
 		logger.WithFields(log.Fields{
 			"task-id":    t.ID(),
 			"task-state": t.State(),
@@ -591,15 +703,26 @@ func (s *scheduler) stopTask(id, source string) []serror.SnapError {
 
 //EnableTask changes state from disabled to stopped
 func (s *scheduler) EnableTask(id string) (core.Task, error) {
-	t, e := s.getTask(id)
+	return s.enableTask(id, 0)
+}
+
+// EnableTaskIfRevision enables a task if it was not modified since the given
+// revision.
+func (s *scheduler) EnableTaskIfRevision(id string, revision uint64) (core.Task, error) {
+	return s.enableTask(id, revision)
+}
+
+func (s *scheduler) enableTask(id string, revision uint64) (core.Task, error) {
+	t, e := s.lockTask(id, revision)
 	if e != nil {
 		schedulerLogger.WithFields(log.Fields{
 			"_block":  "enable-task",
-			"_error":  ErrTaskNotFound,
+			"_error":  e,
 			"task-id": id,
 		}).Error("error enabling task")
 		return nil, e
 	}
+	defer t.opMutex.Unlock()
 
 	err := t.Enable()
 	if err != nil {
@@ -610,6 +733,7 @@ func (s *scheduler) EnableTask(id string) (core.Task, error) {
 		}).Error("error enabling task")
 		return nil, err
 	}
+	t.bumpRevision()
 	schedulerLogger.WithFields(log.Fields{
 		"_block":     "enable-task",
 		"task-id":    t.ID(),
@@ -618,6 +742,96 @@ func (s *scheduler) EnableTask(id string) (core.Task, error) {
 	return t, nil
 }
 
+// PatchTaskConfig overrides config items of the workflow of a task without
+// recreating it. The patched config is validated against the plugins' config
+// policies before it replaces the current one and is used from the next run
+// of the task. Patches are kept in the task's config history.
+func (s *scheduler) PatchTaskConfig(id string, revision uint64, patch *wmap.ConfigPatch) (core.Task, []serror.SnapError) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":  "patch-task-config",
+		"task-id": id,
+	})
+	t, err := s.lockTask(id, revision)
+	if err != nil {
+		logger.WithField("_error", err).Error("error patching task config")
+		return nil, []serror.SnapError{serror.New(err)}
+	}
+	defer t.opMutex.Unlock()
+
+	wfMap, err := t.WMap().Copy()
+	if err != nil {
+		return nil, []serror.SnapError{serror.New(err)}
+	}
+	if err := wfMap.ApplyConfigPatch(patch); err != nil {
+		logger.WithField("_error", err).Error("error patching task config")
+		return nil, []serror.SnapError{serror.New(err)}
+	}
+	if errs := s.rollForward(t, wfMap); len(errs) > 0 {
+		f := buildErrorsLog(errs, logger)
+		f.Error("error patching task config")
+		return nil, errs
+	}
+	t.recordConfigChange(patch)
+	logger.WithField("task-revision", t.Revision()).Info("task config patched")
+	return t, nil
+}
+
+// UpdateTask replaces the schedule, workflow or deadline of a task without
+// recreating it, so its counters are kept. A new workflow is validated and
+// swapped like a patched config, running tasks keep running and wait on a new
+// schedule from their next firing.
+func (s *scheduler) UpdateTask(id string, revision uint64, update *core.TaskUpdate) (core.Task, []serror.SnapError) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":  "update-task",
+		"task-id": id,
+	})
+	t, err := s.lockTask(id, revision)
+	if err != nil {
+		logger.WithField("_error", err).Error("error updating task")
+		return nil, []serror.SnapError{serror.New(err)}
+	}
+	defer t.opMutex.Unlock()
+
+	if update.Schedule != nil {
+		if err := update.Schedule.Validate(); err != nil {
+			logger.WithField("_error", err).Error("error updating task")
+			return nil, []serror.SnapError{serror.New(err)}
+		}
+		if _, stream := update.Schedule.(*schedule.StreamingSchedule); stream != t.isStream {
+			logger.WithField("_error", ErrStreamingScheduleUpdate).Error("error updating task")
+			return nil, []serror.SnapError{serror.New(ErrStreamingScheduleUpdate)}
+		}
+	}
+	if update.Workflow != nil {
+		wfMap, workflowRef, se := s.resolveWorkflow(update.Workflow)
+		if se != nil {
+			f := buildErrorsLog([]serror.SnapError{se}, logger)
+			f.Error("error updating task")
+			return nil, []serror.SnapError{se}
+		}
+		if errs := s.rollForward(t, wfMap); len(errs) > 0 {
+			f := buildErrorsLog(errs, logger)
+			f.Error("error updating task")
+			return nil, errs
+		}
+		t.Lock()
+		t.workflowRef = workflowRef
+		t.Unlock()
+	} else {
+		t.bumpRevision()
+	}
+	if update.Schedule != nil {
+		t.setSchedule(update.Schedule)
+	}
+	if update.Deadline != 0 {
+		t.Lock()
+		t.SetDeadlineDuration(update.Deadline)
+		t.Unlock()
+	}
+	logger.WithField("task-revision", t.Revision()).Info("task updated")
+	return t, nil
+}
+
 // Start starts the scheduler
 func (s *scheduler) Start() error {
 	if s.metricManager == nil {
@@ -683,6 +897,24 @@ func (s *scheduler) Start() error {
 	return nil
 }
 
+// Ready returns an error when the scheduler can not take tasks: it is not
+// started or has no metric manager set.
+func (s *scheduler) Ready() error {
+	if s.metricManager == nil {
+		return ErrMetricManagerNotSet
+	}
+	if s.state != schedulerStarted {
+		return ErrSchedulerNotStarted
+	}
+	return nil
+}
+
+// WorkQueueLengths returns the number of jobs waiting in each of the
+// scheduler's work queues, keyed by job type.
+func (s *scheduler) WorkQueueLengths() map[string]int {
+	return s.workManager.QueueLengths()
+}
+
 func (s *scheduler) Stop() {
 	s.state = schedulerStopped
 	// stop all tasks that are not already stopped
@@ -690,6 +922,7 @@ func (s *scheduler) Stop() {
 		// Kill ensure another task can't turn it back on while we are shutting down
 		t.Kill()
 	}
+	s.accountant.close()
 	schedulerLogger.WithFields(log.Fields{
 		"_block": "stop-scheduler",
 	}).Info("scheduler stopped")
@@ -698,6 +931,11 @@ func (s *scheduler) Stop() {
 // Set metricManager for scheduler
 func (s *scheduler) SetMetricManager(mm managesMetrics) {
 	s.metricManager = mm
+	if s.contentTypes != nil {
+		if ct, ok := mm.(negotiatesContentTypes); ok {
+			s.contentTypes.manager = ct
+		}
+	}
 	schedulerLogger.WithFields(log.Fields{
 		"_block": "set-metric-manager",
 	}).Debug("metric manager linked")
@@ -717,6 +955,13 @@ func (s *scheduler) WatchTask(id string, tw core.TaskWatcherHandler) (core.TaskW
 	return s.taskWatcherColl.add(task.ID(), tw)
 }
 
+// Accounting returns the number of metrics collected and published per
+// accounting window, grouped either by task or by the value of a task label
+// ("label:<name>").
+func (s *scheduler) Accounting(groupBy string) ([]core.AccountingRecord, error) {
+	return s.accountant.report(groupBy)
+}
+
 // Central handling for all async events in scheduler
 func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 
@@ -730,6 +975,20 @@ func (s *scheduler) HandleGomitEvent(e gomit.Event) {
 			"metric-count":    len(v.Metrics),
 		}).Debug("event received")
 		s.taskWatcherColl.handleMetricCollected(v.TaskID, v.Metrics)
+		if task, err := s.getTask(v.TaskID); err == nil {
+			s.accountant.record(v.TaskID, taskLabels(task), len(v.Metrics), 0)
+		}
+	case *scheduler_event.MetricPublishedEvent:
+		log.WithFields(log.Fields{
+			"_module":         "scheduler-events",
+			"_block":          "handle-events",
+			"event-namespace": e.Namespace(),
+			"task-id":         v.TaskID,
+			"metric-count":    v.Count,
+		}).Debug("event received")
+		if task, err := s.getTask(v.TaskID); err == nil {
+			s.accountant.record(v.TaskID, taskLabels(task), 0, v.Count)
+		}
 	case *scheduler_event.MetricCollectionFailedEvent:
 		log.WithFields(log.Fields{
 			"_module":         "scheduler-events",
@@ -794,6 +1053,27 @@ func (s *scheduler) getTask(id string) (*task, error) {
 	return task, nil
 }
 
+// lockTask returns a task holding the lock which serializes the operations
+// on it. The caller must release the task's opMutex. A revision other than
+// 0 must match the current revision of the task.
+func (s *scheduler) lockTask(id string, revision uint64) (*task, error) {
+	t, err := s.getTask(id)
+	if err != nil {
+		return nil, err
+	}
+	t.opMutex.Lock()
+	// the task may have been removed while waiting for the lock
+	if s.tasks.Get(id) != t {
+		t.opMutex.Unlock()
+		return nil, fmt.Errorf("%v: ID(%v)", ErrTaskNotFound, id)
+	}
+	if revision != 0 && revision != t.Revision() {
+		t.opMutex.Unlock()
+		return nil, ErrTaskRevisionMismatch
+	}
+	return t, nil
+}
+
 func getWorkflowPlugins(prnodes []*processNode, pbnodes []*publishNode, requestedMetrics []core.RequestedMetric) depGroupMap {
 	depGroup := depGroupMap{}
 	// Add metrics to depGroup map under local host(signified by empty string)
@@ -808,6 +1088,11 @@ func getWorkflowPlugins(prnodes []*processNode, pbnodes []*publishNode, requeste
 
 func walkWorkflowForDeps(prnodes []*processNode, pbnodes []*publishNode, requestedMetrics []core.RequestedMetric, depGroup depGroupMap) depGroupMap {
 	for _, pr := range prnodes {
+		// filter and sample nodes are built in and do not depend on a plugin
+		if pr.builtin() {
+			walkWorkflowForDeps(pr.ProcessNodes, pr.PublishNodes, requestedMetrics, depGroup)
+			continue
+		}
 		processors := depGroup[pr.Target]
 		if _, ok := depGroup[pr.Target]; ok {
 			processors.subscribedPlugins = append(processors.subscribedPlugins, pr)
@@ -826,6 +1111,9 @@ func walkWorkflowForDeps(prnodes []*processNode, pbnodes []*publishNode, request
 		}
 		depGroup[pb.Target] = publishers
 	}
+	if dls := deadLetters(pbnodes); len(dls) > 0 {
+		walkWorkflowForDeps(nil, dls, requestedMetrics, depGroup)
+	}
 	return depGroup
 }
 
@@ -45,6 +45,7 @@ type mockMetricManager struct {
 	failValidatingMetricsAfter int
 	failuredSoFar              int
 	autodiscoverPaths          []string
+	subscribing                bool
 }
 
 func (m *mockMetricManager) StreamMetrics(string, map[string]map[string]string, time.Duration, int64) (chan []core.Metric, chan error, []error) {
@@ -71,6 +72,9 @@ func (m *mockMetricManager) ValidateDeps(mts []core.RequestedMetric, prs []core.
 	return nil
 }
 func (m *mockMetricManager) SubscribeDeps(taskID string, req []core.RequestedMetric, prs []core.SubscribedPlugin, cft *cdata.ConfigDataTree) []serror.SnapError {
+	if m.subscribing {
+		return nil
+	}
 	return []serror.SnapError{
 		serror.New(errors.New("metric validation error")),
 	}
@@ -263,6 +267,47 @@ func TestScheduler(t *testing.T) {
 				So(len(err), ShouldEqual, 1)
 				So(err[0].Error(), ShouldEqual, "Task is already stopped.")
 			})
+			Convey("operations expecting another revision fail", func() {
+				So(tsk.Revision(), ShouldEqual, 1)
+				errs := s.StartTaskIfRevision(tsk.ID(), 2)
+				So(errs, ShouldHaveLength, 1)
+				So(errs[0].Error(), ShouldEqual, ErrTaskRevisionMismatch.Error())
+				So(s.RemoveTaskIfRevision(tsk.ID(), 2), ShouldEqual, ErrTaskRevisionMismatch)
+
+				c.subscribing = true
+				errs = s.StartTaskIfRevision(tsk.ID(), 1)
+				So(errs, ShouldBeEmpty)
+				So(tsk.Revision(), ShouldEqual, 2)
+				errs = s.StopTaskIfRevision(tsk.ID(), 1)
+				So(errs, ShouldHaveLength, 1)
+				So(errs[0].Error(), ShouldEqual, ErrTaskRevisionMismatch.Error())
+				So(s.StopTaskIfRevision(tsk.ID(), 2), ShouldBeEmpty)
+				So(tsk.Revision(), ShouldEqual, 3)
+			})
+			Convey("update the task in place", func() {
+				newSch := schedule.NewWindowedSchedule(time.Minute, nil, nil, 0)
+				updated, errs := s.UpdateTask(tsk.ID(), 1, &core.TaskUpdate{Schedule: newSch, Deadline: 3 * time.Second})
+				So(errs, ShouldBeEmpty)
+				So(updated, ShouldEqual, tsk)
+				So(tsk.Schedule(), ShouldEqual, newSch)
+				So(tsk.DeadlineDuration(), ShouldEqual, 3*time.Second)
+				So(tsk.Revision(), ShouldEqual, 2)
+
+				w2 := wmap.NewWorkflowMap()
+				w2.CollectNode.AddMetric("/foo/bar", 1)
+				_, errs = s.UpdateTask(tsk.ID(), 2, &core.TaskUpdate{Workflow: w2})
+				So(errs, ShouldBeEmpty)
+				So(tsk.WMap().CollectNode.ProcessNodes, ShouldBeEmpty)
+				So(tsk.Revision(), ShouldEqual, 3)
+
+				Convey("rejects invalid updates", func() {
+					_, errs := s.UpdateTask(tsk.ID(), 1, &core.TaskUpdate{Deadline: time.Second})
+					So(errs[0].Error(), ShouldEqual, ErrTaskRevisionMismatch.Error())
+					_, errs = s.UpdateTask(tsk.ID(), 0, &core.TaskUpdate{Schedule: schedule.NewStreamingSchedule()})
+					So(errs[0].Error(), ShouldEqual, ErrStreamingScheduleUpdate.Error())
+					So(tsk.Schedule(), ShouldEqual, newSch)
+				})
+			})
 		})
 		Convey("returns a task with a 6 second deadline duration", func() {
 			sch := schedule.NewWindowedSchedule(6*time.Second, nil, nil, 0)
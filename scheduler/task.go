@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -44,6 +45,8 @@ const (
 	DefaultDeadlineDuration = time.Second * 5
 	// DefaultStopOnFailure is used to set the number of failures before a task is disabled
 	DefaultStopOnFailure = 10
+	// taskRunErrorsSize is the number of errors of its runs kept by a task
+	taskRunErrorsSize = 50
 )
 
 var (
@@ -59,15 +62,21 @@ var (
 	ErrTaskDisabledOnFailures = errors.New("Task disabled due to consecutive failures")
 	// ErrTaskNotDisabled - The error message for task must be disabled
 	ErrTaskNotDisabled = errors.New("Task must be disabled")
+	// ErrTaskDisabledOnMetricsThreshold - The error message for task disabled due to a metrics volume spike
+	ErrTaskDisabledOnMetricsThreshold = errors.New("Task disabled due to exceeding its metrics threshold")
 )
 
 type task struct {
 	sync.Mutex //protects state
+	// opMutex serializes the operations on the task (start, stop, remove...)
+	opMutex sync.Mutex
+	// revision is incremented by every operation modifying the task
+	revision uint64
 
 	id                 string
 	name               string
-	schResponseChan    chan schedule.Response
 	killChan           chan struct{}
+	rescheduleChan     chan struct{}
 	schedule           schedule.Schedule
 	workflow           *schedulerWorkflow
 	state              core.TaskState
@@ -89,6 +98,34 @@ type task struct {
 
 	maxCollectDuration time.Duration
 	maxMetricsBuffer   int64
+
+	metricsThreshold float64
+	metricsVolume    *metricsVolume
+
+	// name of the stored workflow the task references, if any, protected by
+	// the task lock
+	workflowRef string
+	// configHistory records the config patches applied to the task
+	configHistory []core.TaskConfigChange
+	// runErrors holds the last taskRunErrorsSize errors of the task runs,
+	// protected by failureMutex
+	runErrors []core.TaskRunError
+	// stats holds the skipped runs and the workflow latencies of the task
+	stats taskStats
+}
+
+// metricsVolume tracks how many metrics a task produces per run so that a
+// sudden spike (e.g. a wildcard explosion) can trip the metrics threshold.
+type metricsVolume struct {
+	sync.Mutex
+	runs       uint
+	average    float64
+	namespaces map[string]struct{}
+	exceeded   *scheduler_event.TaskMetricsThresholdExceededEvent
+}
+
+func newMetricsVolume() *metricsVolume {
+	return &metricsVolume{namespaces: map[string]struct{}{}}
 }
 
 //NewTask creates a Task
@@ -110,7 +147,7 @@ func newTask(s schedule.Schedule, wf *schedulerWorkflow, m *workManager, mm mana
 	task := &task{
 		id:               taskID,
 		name:             name,
-		schResponseChan:  make(chan schedule.Response),
+		rescheduleChan:   make(chan struct{}, 1),
 		schedule:         s,
 		state:            core.TaskStopped,
 		creationTime:     time.Now(),
@@ -122,6 +159,8 @@ func newTask(s schedule.Schedule, wf *schedulerWorkflow, m *workManager, mm mana
 		eventEmitter:     emitter,
 		RemoteManagers:   mgrs,
 		isStream:         stream,
+		metricsVolume:    newMetricsVolume(),
+		revision:         1,
 	}
 	//set options
 	for _, opt := range opts {
@@ -156,6 +195,14 @@ func (t *task) SetMaxMetricsBuffer(i int64) {
 	t.maxMetricsBuffer = i
 }
 
+func (t *task) MetricsThreshold() float64 {
+	return t.metricsThreshold
+}
+
+func (t *task) SetMetricsThreshold(n float64) {
+	t.metricsThreshold = n
+}
+
 //Returns the name of the task
 func (t *task) GetName() string {
 	return t.name
@@ -217,6 +264,47 @@ func (t *task) State() core.TaskState {
 	return t.state
 }
 
+// Revision returns the revision of the task, it changes every time the task
+// is modified so concurrent modifications can be detected.
+func (t *task) Revision() uint64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.revision
+}
+
+// ConfigHistory returns the config patches applied to the task, oldest first
+func (t *task) ConfigHistory() []core.TaskConfigChange {
+	t.Lock()
+	defer t.Unlock()
+	history := make([]core.TaskConfigChange, len(t.configHistory))
+	copy(history, t.configHistory)
+	return history
+}
+
+func (t *task) recordConfigChange(patch *wmap.ConfigPatch) {
+	t.Lock()
+	defer t.Unlock()
+	t.configHistory = append(t.configHistory, core.TaskConfigChange{
+		Time:     time.Now(),
+		Revision: t.revision,
+		Patch:    patch,
+	})
+}
+
+func (t *task) bumpRevision() {
+	t.Lock()
+	t.revision++
+	t.Unlock()
+}
+
+// WorkflowRef returns the name of the stored workflow referenced by the task,
+// empty when its workflow was given inline.
+func (t *task) WorkflowRef() string {
+	t.Lock()
+	defer t.Unlock()
+	return t.workflowRef
+}
+
 // Status returns the state of the workflow.
 func (t *task) Status() WorkflowState {
 	return t.workflow.State()
@@ -302,12 +390,16 @@ func (t *task) stream() {
 					metricsChan = nil
 					break
 				}
-				if len(mts) == 0 {
+				if len(mts) == 0 || !t.beginRun() {
 					continue
 				}
 				t.hitCount++
 				consecutiveFailures = 0
 				t.workflow.StreamStart(t, mts)
+				t.endRun()
+				if t.disableOnMetricsThreshold() {
+					return
+				}
 			case err := <-errChan:
 				taskLogger.WithFields(log.Fields{
 					"_block":    "stream",
@@ -424,6 +516,9 @@ func (t *task) Enable() error {
 		return ErrTaskNotDisabled
 	}
 	t.state = core.TaskStopped
+	// Enabling a task is an explicit acknowledgement of its current metrics
+	// volume, so the history used by the metrics threshold starts over.
+	t.metricsVolume = newMetricsVolume()
 
 	return nil
 }
@@ -441,6 +536,10 @@ func (t *task) WMap() *wmap.WorkflowMap {
 	return t.workflow.workflowMap
 }
 
+func (t *task) WorkflowGraph() *core.WorkflowGraph {
+	return t.workflow.Graph()
+}
+
 func (t *task) Schedule() schedule.Schedule {
 	return t.schedule
 }
@@ -450,19 +549,40 @@ func (t *task) spin() {
 	for {
 		taskLogger.Debug("task spin loop")
 		// Start go routine to wait on schedule
-		go t.waitForSchedule()
+		t.Lock()
+		sch := t.schedule
+		t.Unlock()
+		// each wait has its own response channel, so that the response of
+		// a schedule which was replaced meanwhile is never received
+		resp := make(chan schedule.Response)
+		cancel := make(chan struct{})
+		go t.waitForSchedule(sch, resp, cancel)
 		// wait here on
-		//  schResponseChan - response from schedule
+		//  resp - response from schedule
+		//  rescheduleChan - signals the schedule was replaced
 		//  killChan - signals task needs to be stopped
 		select {
-		case sr := <-t.schResponseChan:
+		case <-t.rescheduleChan:
+			// stop waiting on the previous schedule
+			close(cancel)
+		case sr := <-resp:
 			switch sr.State() {
 			// If response show this schedule is still active we fire
 			case schedule.Active:
 				t.missedIntervals += sr.Missed()
 				t.lastFireTime = time.Now()
+				if !t.beginRun() {
+					// the scheduler is drained
+					t.missedIntervals++
+					t.stats.skip()
+					continue
+				}
 				t.hitCount++
 				t.fire()
+				t.endRun()
+				if t.disableOnMetricsThreshold() {
+					return
+				}
 				if t.lastFailureTime == t.lastFireTime {
 					consecutiveFailures++
 					taskLogger.WithFields(log.Fields{
@@ -528,6 +648,21 @@ func (t *task) spin() {
 	}
 }
 
+// beginRun returns whether a workflow run of the task can start, i.e. the
+// scheduler is not drained.
+func (t *task) beginRun() bool {
+	if g, ok := t.manager.(runGate); ok {
+		return g.beginRun()
+	}
+	return true
+}
+
+func (t *task) endRun() {
+	if g, ok := t.manager.(runGate); ok {
+		g.endRun()
+	}
+}
+
 func (t *task) fire() {
 	t.Lock()
 	defer t.Unlock()
@@ -537,22 +672,139 @@ func (t *task) fire() {
 	t.state = core.TaskSpinning
 }
 
-func (t *task) waitForSchedule() {
+func (t *task) waitForSchedule(sch schedule.Schedule, resp chan<- schedule.Response, cancel chan struct{}) {
 	select {
 	case <-t.killChan:
 		return
-	case t.schResponseChan <- t.schedule.Wait(t.lastFireTime):
+	case <-cancel:
+		return
+	case resp <- sch.Wait(t.lastFireTime):
 	}
 }
 
-// RecordFailure updates the failed runs and last failure properties
-func (t *task) RecordFailure(e []error) {
+// setSchedule replaces the schedule of the task, a running task waits on the
+// new schedule from its next firing.
+func (t *task) setSchedule(sch schedule.Schedule) {
+	t.Lock()
+	t.schedule = sch
+	running := t.state == core.TaskSpinning || t.state == core.TaskFiring
+	t.Unlock()
+	if running && !t.isStream {
+		select {
+		case t.rescheduleChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RecordFailure updates the failed runs and last failure properties, and
+// keeps the errors along with the workflow node they come from
+func (t *task) RecordFailure(node string, name string, version int, e []error) {
 	// We synchronize this update to ensure it is atomic
 	t.failureMutex.Lock()
 	defer t.failureMutex.Unlock()
 	t.failedRuns++
 	t.lastFailureTime = t.lastFireTime
 	t.lastFailureMessage = e[len(e)-1].Error()
+	now := time.Now()
+	for _, err := range e {
+		runErr := core.TaskRunError{
+			Time:          now,
+			Node:          node,
+			PluginName:    name,
+			PluginVersion: version,
+			Message:       err.Error(),
+		}
+		if core.IsPluginTimeout(err) {
+			runErr.Class = core.TaskRunErrorTimeout
+		}
+		t.runErrors = append(t.runErrors, runErr)
+	}
+	if n := len(t.runErrors) - taskRunErrorsSize; n > 0 {
+		t.runErrors = append(t.runErrors[:0], t.runErrors[n:]...)
+	}
+}
+
+// RunErrors returns the last errors of the task runs, oldest first
+func (t *task) RunErrors() []core.TaskRunError {
+	t.failureMutex.Lock()
+	defer t.failureMutex.Unlock()
+	errs := make([]core.TaskRunError, len(t.runErrors))
+	copy(errs, t.runErrors)
+	return errs
+}
+
+// checkMetricsThreshold records the number of metrics collected in a run and
+// reports whether it exceeds the task's metrics threshold times the average
+// of previous runs. Metrics from a run which trips the threshold are not
+// added to the history.
+func (t *task) checkMetricsThreshold(mts []core.Metric) bool {
+	v := t.metricsVolume
+	v.Lock()
+	defer v.Unlock()
+	count := len(mts)
+	if t.metricsThreshold > 0 && v.runs > 0 && float64(count) > t.metricsThreshold*v.average {
+		nss := map[string]struct{}{}
+		for _, m := range mts {
+			ns := m.Namespace().String()
+			if _, ok := v.namespaces[ns]; !ok {
+				nss[ns] = struct{}{}
+			}
+		}
+		offending := make([]string, 0, len(nss))
+		for ns := range nss {
+			offending = append(offending, ns)
+		}
+		sort.Strings(offending)
+		v.exceeded = &scheduler_event.TaskMetricsThresholdExceededEvent{
+			TaskID:     t.id,
+			Count:      count,
+			Average:    v.average,
+			Threshold:  t.metricsThreshold,
+			Namespaces: offending,
+		}
+		return true
+	}
+	v.runs++
+	v.average += (float64(count) - v.average) / float64(v.runs)
+	for _, m := range mts {
+		v.namespaces[m.Namespace().String()] = struct{}{}
+	}
+	return false
+}
+
+// disableOnMetricsThreshold disables the task if its last run exceeded the
+// metrics threshold. The task stays disabled until it is explicitly enabled.
+func (t *task) disableOnMetricsThreshold() bool {
+	v := t.metricsVolume
+	v.Lock()
+	exceeded := v.exceeded
+	v.exceeded = nil
+	v.Unlock()
+	if exceeded == nil {
+		return false
+	}
+	taskLogger.WithFields(log.Fields{
+		"_block":            "disable-on-metrics-threshold",
+		"task-id":           t.id,
+		"task-name":         t.name,
+		"metrics-count":     exceeded.Count,
+		"metrics-average":   exceeded.Average,
+		"metrics-threshold": exceeded.Threshold,
+		"namespaces":        exceeded.Namespaces,
+	}).Error(ErrTaskDisabledOnMetricsThreshold)
+	// You must lock on state change for tasks
+	t.Lock()
+	t.state = core.TaskDisabled
+	t.Unlock()
+	if t.eventEmitter != nil {
+		t.eventEmitter.Emit(exceeded)
+		event := new(scheduler_event.TaskDisabledEvent)
+		event.TaskID = t.id
+		event.Why = fmt.Sprintf("%s: %d metrics collected, average %.2f", ErrTaskDisabledOnMetricsThreshold, exceeded.Count, exceeded.Average)
+		t.eventEmitter.Emit(event)
+	}
+	return true
 }
 
 type taskCollection struct {
@@ -682,5 +934,8 @@ func walkWorkflow(prnodes []*processNode, pbnodes []*publishNode, mgrs *managers
 			mgrs.Add(pu.Target, proxy)
 		}
 	}
+	if dls := deadLetters(pbnodes); len(dls) > 0 {
+		return walkWorkflow(nil, dls, mgrs)
+	}
 	return nil
 }
@@ -0,0 +1,126 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// taskLatencySize is the number of the last collections, and publishes, of a
+// task whose durations the latency percentiles are computed from
+const taskLatencySize = 500
+
+// taskStats holds the counters and latencies of the runs of a task which are
+// not kept by the task itself
+type taskStats struct {
+	sync.Mutex
+	skippedRuns uint
+	collect     latencyWindow
+	publish     latencyWindow
+}
+
+func (s *taskStats) skip() {
+	s.Lock()
+	defer s.Unlock()
+	s.skippedRuns++
+}
+
+func (s *taskStats) observeCollect(d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.collect.add(d)
+}
+
+func (s *taskStats) observePublish(d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.publish.add(d)
+}
+
+// latencyWindow is a ring of the last taskLatencySize durations of a
+// workflow step
+type latencyWindow struct {
+	durations []time.Duration
+	next      int
+}
+
+func (l *latencyWindow) add(d time.Duration) {
+	if len(l.durations) < taskLatencySize {
+		l.durations = append(l.durations, d)
+		return
+	}
+	l.durations[l.next] = d
+	l.next = (l.next + 1) % taskLatencySize
+}
+
+// stats returns the nearest-rank percentiles of the durations in the window
+func (l *latencyWindow) stats() core.LatencyStats {
+	n := len(l.durations)
+	if n == 0 {
+		return core.LatencyStats{}
+	}
+	sorted := make(durations, n)
+	copy(sorted, l.durations)
+	sort.Sort(sorted)
+	percentile := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(n))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return millis(sorted[i])
+	}
+	return core.LatencyStats{
+		Count: n,
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+		Max:   millis(sorted[n-1]),
+	}
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Stats returns the counters of the firings of the task and the latency
+// percentiles of the last collections and publishes of its workflow
+func (t *task) Stats() core.TaskStats {
+	t.stats.Lock()
+	defer t.stats.Unlock()
+	return core.TaskStats{
+		HitCount:       t.hitCount,
+		MissCount:      t.missedIntervals,
+		FailedCount:    t.FailedCount(),
+		SkippedCount:   t.stats.skippedRuns,
+		CollectLatency: t.stats.collect.stats(),
+		PublishLatency: t.stats.publish.stats(),
+	}
+}
@@ -0,0 +1,76 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTaskStats(t *testing.T) {
+	Convey("Given a task", t, func() {
+		tk := &task{hitCount: 12, missedIntervals: 3, failedRuns: 2}
+
+		Convey("without runs its latencies are empty", func() {
+			stats := tk.Stats()
+			So(stats.HitCount, ShouldEqual, 12)
+			So(stats.MissCount, ShouldEqual, 3)
+			So(stats.FailedCount, ShouldEqual, 2)
+			So(stats.SkippedCount, ShouldEqual, 0)
+			So(stats.CollectLatency.Count, ShouldEqual, 0)
+			So(stats.PublishLatency.P99, ShouldEqual, 0)
+		})
+		Convey("skipped runs are counted", func() {
+			tk.stats.skip()
+			tk.stats.skip()
+			So(tk.Stats().SkippedCount, ShouldEqual, 2)
+		})
+		Convey("latency percentiles are computed from the observed durations", func() {
+			for i := 1; i <= 100; i++ {
+				tk.stats.observeCollect(time.Duration(i) * time.Millisecond)
+			}
+			tk.stats.observePublish(5 * time.Millisecond)
+			stats := tk.Stats()
+			So(stats.CollectLatency.Count, ShouldEqual, 100)
+			So(stats.CollectLatency.P50, ShouldEqual, 50)
+			So(stats.CollectLatency.P90, ShouldEqual, 90)
+			So(stats.CollectLatency.P99, ShouldEqual, 99)
+			So(stats.CollectLatency.Max, ShouldEqual, 100)
+			So(stats.PublishLatency.Count, ShouldEqual, 1)
+			So(stats.PublishLatency.P50, ShouldEqual, 5)
+			So(stats.PublishLatency.Max, ShouldEqual, 5)
+		})
+		Convey("only the last durations are kept", func() {
+			for i := 0; i < taskLatencySize; i++ {
+				tk.stats.observeCollect(time.Second)
+			}
+			for i := 0; i < taskLatencySize; i++ {
+				tk.stats.observeCollect(time.Millisecond)
+			}
+			stats := tk.Stats()
+			So(stats.CollectLatency.Count, ShouldEqual, taskLatencySize)
+			So(stats.CollectLatency.Max, ShouldEqual, 1)
+		})
+	})
+}
@@ -22,9 +22,12 @@ limitations under the License.
 package scheduler
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/intelsdi-x/snap/control/plugin"
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/pkg/schedule"
 	"github.com/intelsdi-x/snap/scheduler/wmap"
@@ -38,6 +41,34 @@ var (
 	emitter = gomit.NewEventController()
 )
 
+// gatedSchedule fires each time it is sent a value on fire
+type gatedSchedule struct {
+	fire chan struct{}
+	// waiting is sent a value when the schedule is waited on
+	waiting chan struct{}
+}
+
+func newGatedSchedule() *gatedSchedule {
+	return &gatedSchedule{fire: make(chan struct{}), waiting: make(chan struct{}, 1)}
+}
+
+func (g *gatedSchedule) GetState() schedule.ScheduleState { return schedule.Active }
+func (g *gatedSchedule) Validate() error                  { return nil }
+
+func (g *gatedSchedule) Wait(time.Time) schedule.Response {
+	select {
+	case g.waiting <- struct{}{}:
+	default:
+	}
+	<-g.fire
+	return g
+}
+
+func (g *gatedSchedule) State() schedule.ScheduleState { return schedule.Active }
+func (g *gatedSchedule) Error() error                  { return nil }
+func (g *gatedSchedule) Missed() uint                  { return 0 }
+func (g *gatedSchedule) LastTime() time.Time           { return time.Now() }
+
 func TestTask(t *testing.T) {
 	log.SetLevel(log.FatalLevel)
 	Convey("Task", t, func() {
@@ -127,6 +158,28 @@ func TestTask(t *testing.T) {
 			task.Stop()
 		})
 
+		Convey("a rescheduled task fires on its new schedule only", func() {
+			sch := newGatedSchedule()
+			task, err := newTask(sch, wf, newWorkManager(), c, emitter)
+			So(err, ShouldBeNil)
+			task.Spin()
+			<-sch.waiting
+			for i := 0; i < 20; i++ {
+				next := newGatedSchedule()
+				task.setSchedule(next)
+				<-next.waiting
+				// the replaced schedule fires once the task waits on the new one
+				sch.fire <- struct{}{}
+				sch = next
+			}
+			time.Sleep(time.Millisecond * 50)
+			So(task.HitCount(), ShouldEqual, 0)
+			sch.fire <- struct{}{}
+			time.Sleep(time.Millisecond * 50)
+			So(task.HitCount(), ShouldEqual, 1)
+			task.Stop()
+		})
+
 		Convey("Enable a running task", func() {
 			sch := schedule.NewWindowedSchedule(time.Millisecond*10, nil, nil, 0)
 			task, err := newTask(sch, wf, newWorkManager(), c, emitter)
@@ -137,6 +190,40 @@ func TestTask(t *testing.T) {
 			So(task.State(), ShouldEqual, core.TaskSpinning)
 		})
 
+		Convey("Task is disabled when its metrics threshold is exceeded", func() {
+			sch := schedule.NewWindowedSchedule(time.Millisecond*10, nil, nil, 0)
+			task, err := newTask(sch, wf, newWorkManager(), c, emitter, core.SetMetricsThreshold(2))
+			So(err, ShouldBeNil)
+			So(task.MetricsThreshold(), ShouldEqual, 2)
+			mts := func(nss ...string) []core.Metric {
+				var m []core.Metric
+				for _, ns := range nss {
+					m = append(m, plugin.MetricType{Namespace_: core.NewNamespace("intel", "mock", ns)})
+				}
+				return m
+			}
+			So(task.checkMetricsThreshold(mts("foo", "bar")), ShouldBeFalse)
+			So(task.checkMetricsThreshold(mts("foo", "bar", "baz")), ShouldBeFalse)
+			So(task.disableOnMetricsThreshold(), ShouldBeFalse)
+			So(task.checkMetricsThreshold(mts("foo", "bar", "a", "b", "c", "d")), ShouldBeTrue)
+			So(task.metricsVolume.exceeded.Namespaces, ShouldResemble, []string{"/intel/mock/a", "/intel/mock/b", "/intel/mock/c", "/intel/mock/d"})
+			So(task.disableOnMetricsThreshold(), ShouldBeTrue)
+			So(task.State(), ShouldEqual, core.TaskDisabled)
+			Convey("Enabling the task resets the metrics history", func() {
+				So(task.Enable(), ShouldBeNil)
+				So(task.metricsVolume.runs, ShouldEqual, 0)
+				So(task.checkMetricsThreshold(mts("foo", "bar", "a", "b", "c", "d")), ShouldBeFalse)
+			})
+			Convey("A task without an event emitter is disabled too", func() {
+				So(task.Enable(), ShouldBeNil)
+				task.eventEmitter = nil
+				So(task.checkMetricsThreshold(mts("foo", "bar")), ShouldBeFalse)
+				So(task.checkMetricsThreshold(mts("foo", "bar", "a", "b", "c", "d")), ShouldBeTrue)
+				So(task.disableOnMetricsThreshold(), ShouldBeTrue)
+				So(task.State(), ShouldEqual, core.TaskDisabled)
+			})
+		})
+
 		Convey("Enable a disabled task", func() {
 			sch := schedule.NewWindowedSchedule(time.Millisecond*10, nil, nil, 0)
 			task, err := newTask(sch, wf, newWorkManager(), c, emitter)
@@ -147,6 +234,29 @@ func TestTask(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(task.State(), ShouldEqual, core.TaskStopped)
 		})
+
+		Convey("Task keeps the last errors of its runs", func() {
+			sch := schedule.NewWindowedSchedule(time.Millisecond*10, nil, nil, 0)
+			task, err := newTask(sch, wf, newWorkManager(), c, emitter)
+			So(err, ShouldBeNil)
+
+			task.RecordFailure("publisher", "file", 3, []error{errors.New("disk full")})
+			errs := task.RunErrors()
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Node, ShouldEqual, "publisher")
+			So(errs[0].PluginName, ShouldEqual, "file")
+			So(errs[0].PluginVersion, ShouldEqual, 3)
+			So(errs[0].Message, ShouldEqual, "disk full")
+			So(task.FailedCount(), ShouldEqual, 1)
+
+			for i := 0; i < taskRunErrorsSize; i++ {
+				task.RecordFailure("collector", "", 0, []error{fmt.Errorf("error %d", i)})
+			}
+			errs = task.RunErrors()
+			So(errs, ShouldHaveLength, taskRunErrorsSize)
+			So(errs[0].Message, ShouldEqual, "error 0")
+			So(errs[taskRunErrorsSize-1].Message, ShouldEqual, fmt.Sprintf("error %d", taskRunErrorsSize-1))
+		})
 	})
 
 	Convey("Create task collection", t, func() {
@@ -0,0 +1,57 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+)
+
+// ErrInvalidTimeout is returned when a workflow node is given a timeout which
+// is not positive
+var ErrInvalidTimeout = errors.New("Timeout of a workflow node must be positive")
+
+// timesOutCalls is implemented by metric managers letting a workflow node
+// override the timeout of the calls made to its plugins
+type timesOutCalls interface {
+	CollectMetricsTimeout(string, map[string]map[string]string, time.Duration) ([]core.Metric, []error)
+	ProcessMetricsTimeout([]core.Metric, map[string]ctypes.ConfigValue, string, string, int, time.Duration) ([]core.Metric, []error)
+	PublishMetricsTimeout([]core.Metric, map[string]ctypes.ConfigValue, string, string, int, time.Duration) []error
+}
+
+// parseTimeout returns the timeout of a workflow node, 0 meaning the timeout
+// of the plugin is kept
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid timeout '%s': %v", timeout, err)
+	}
+	if d <= 0 {
+		return 0, ErrInvalidTimeout
+	}
+	return d, nil
+}
@@ -0,0 +1,114 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// timeoutManager records the timeout of the calls it is given
+type timeoutManager struct {
+	timeout time.Duration
+	// untimed counts the calls made without a timeout
+	untimed int
+}
+
+func (m *timeoutManager) CollectMetrics(string, map[string]map[string]string) ([]core.Metric, []error) {
+	return nil, nil
+}
+
+func (m *timeoutManager) ProcessMetrics(mts []core.Metric, _ map[string]ctypes.ConfigValue, _, _ string, _ int) ([]core.Metric, []error) {
+	return mts, nil
+}
+
+func (m *timeoutManager) PublishMetrics([]core.Metric, map[string]ctypes.ConfigValue, string, string, int) []error {
+	m.untimed++
+	return nil
+}
+
+func (m *timeoutManager) CollectMetricsTimeout(_ string, _ map[string]map[string]string, timeout time.Duration) ([]core.Metric, []error) {
+	m.timeout = timeout
+	return nil, nil
+}
+
+func (m *timeoutManager) ProcessMetricsTimeout(mts []core.Metric, _ map[string]ctypes.ConfigValue, _, _ string, _ int, timeout time.Duration) ([]core.Metric, []error) {
+	m.timeout = timeout
+	return mts, nil
+}
+
+func (m *timeoutManager) PublishMetricsTimeout(_ []core.Metric, _ map[string]ctypes.ConfigValue, _, _ string, _ int, timeout time.Duration) []error {
+	m.timeout = timeout
+	return nil
+}
+
+func TestNodeTimeout(t *testing.T) {
+	Convey("Workflow node timeouts", t, func() {
+		Convey("default to the timeout of the plugin", func() {
+			d, err := parseTimeout("")
+			So(err, ShouldBeNil)
+			So(d, ShouldEqual, 0)
+		})
+		Convey("parse the timeout", func() {
+			d, err := parseTimeout("30s")
+			So(err, ShouldBeNil)
+			So(d, ShouldEqual, 30*time.Second)
+		})
+		Convey("reject a timeout which is not positive", func() {
+			_, err := parseTimeout("0s")
+			So(err, ShouldEqual, ErrInvalidTimeout)
+		})
+		Convey("reject an invalid timeout", func() {
+			_, err := parseTimeout("soon")
+			So(err, ShouldNotBeNil)
+		})
+		Convey("are passed on to the metric manager", func() {
+			mgr := &timeoutManager{}
+			parent := newCollectorJob(nil, time.Second, mgr, nil, "task-1", nil)
+			parent.(*collectorJob).timeout = 3 * time.Second
+			parent.Run()
+			So(mgr.timeout, ShouldEqual, 3*time.Second)
+
+			*mgr = timeoutManager{}
+			j := newPublishJob(parent, "file", 1, "", nil, mgr, "task-1")
+			j.Run()
+			So(mgr.untimed, ShouldEqual, 1)
+			So(mgr.timeout, ShouldEqual, 0)
+			j.(*publisherJob).timeout = 5 * time.Second
+			j.Run()
+			So(mgr.timeout, ShouldEqual, 5*time.Second)
+		})
+		Convey("classify timeouts in the errors of the task", func() {
+			tsk := &task{}
+			tsk.RecordFailure("publisher", "file", 1, []error{errors.New("boom"), core.ErrPluginTimeout})
+			errs := tsk.RunErrors()
+			So(errs, ShouldHaveLength, 2)
+			So(errs[0].Class, ShouldBeEmpty)
+			So(errs[1].Class, ShouldEqual, core.TaskRunErrorTimeout)
+		})
+	})
+}
@@ -0,0 +1,100 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+// tracedJob is implemented by the jobs whose spans can parent the spans of
+// the jobs following them in a workflow
+type tracedJob interface {
+	SpanContext() opentracing.SpanContext
+}
+
+// jobSpanContext returns the span context the jobs following j in a
+// workflow are traced from, nil when j is not traced
+func jobSpanContext(j job) opentracing.SpanContext {
+	if tj, ok := j.(tracedJob); ok {
+		return tj.SpanContext()
+	}
+	return nil
+}
+
+// startWorkflowSpan starts the span of a run of the workflow of a task, the
+// root of the spans of its collection, processes and publishes
+func startWorkflowSpan(t *task) opentracing.Span {
+	span := opentracing.StartSpan("workflow-run")
+	span.SetTag("task-id", t.id)
+	span.SetTag("task-name", t.name)
+	return span
+}
+
+// SpanContext returns the context of the span of the job once it started
+// running, otherwise the one the job is traced from. Jobs which are not
+// traced themselves, i.e. filters and batches, thereby pass the context of
+// their parent on.
+func (c *coreJob) SpanContext() opentracing.SpanContext {
+	c.Lock()
+	defer c.Unlock()
+	if c.span != nil {
+		return c.span.Context()
+	}
+	return c.traceFrom
+}
+
+// startSpan starts the span of the run of the job, which wraps the call to
+// its plugin, tagged with the task and the plugin of the job
+func (c *coreJob) startSpan() {
+	var opts []opentracing.StartSpanOption
+	if c.traceFrom != nil {
+		opts = append(opts, opentracing.ChildOf(c.traceFrom))
+	}
+	span := opentracing.StartSpan(c.TypeString(), opts...)
+	ext.SpanKindRPCClient.Set(span)
+	span.SetTag("task-id", c.taskID)
+	if c.name != "" {
+		span.SetTag("plugin-name", c.name)
+		span.SetTag("plugin-version", c.version)
+	}
+	c.Lock()
+	c.span = span
+	c.Unlock()
+}
+
+// finishSpan finishes the span of the run of the job, marked as failed with
+// the last error of the job if any
+func (c *coreJob) finishSpan() {
+	c.Lock()
+	defer c.Unlock()
+	if c.span == nil {
+		return
+	}
+	if len(c.errors) > 0 {
+		ext.Error.Set(c.span, true)
+		c.span.LogFields(
+			otlog.String("event", "error"),
+			otlog.Error(c.errors[len(c.errors)-1]),
+		)
+	}
+	c.span.Finish()
+}
@@ -0,0 +1,88 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type tracedPublisher struct {
+	errs []error
+}
+
+func (p *tracedPublisher) PublishMetrics([]core.Metric, map[string]ctypes.ConfigValue, string, string, int) []error {
+	return p.errs
+}
+
+func TestJobTracing(t *testing.T) {
+	Convey("Given a tracer", t, func() {
+		tracer := mocktracer.New()
+		opentracing.SetGlobalTracer(tracer)
+		defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+		root := startWorkflowSpan(&task{id: "task-1", name: "my-task"})
+		parent := newBatchJob(time.Now().Add(time.Second), nil, "task-1")
+		parent.(*batchJob).traceFrom = root.Context()
+		rootID := root.Context().(mocktracer.MockSpanContext).SpanID
+
+		Convey("a publish is traced as a child of the workflow run through the untraced jobs", func() {
+			pj := newPublishJob(newFilterJob(parent, &metricFilter{}, "task-1"), "file", 3, "", nil, &tracedPublisher{}, "task-1")
+			pj.Run()
+			root.Finish()
+
+			spans := tracer.FinishedSpans()
+			So(spans, ShouldHaveLength, 2)
+			So(spans[0].OperationName, ShouldEqual, "publisher")
+			So(spans[0].ParentID, ShouldEqual, rootID)
+			So(spans[0].Tag("task-id"), ShouldEqual, "task-1")
+			So(spans[0].Tag("plugin-name"), ShouldEqual, "file")
+			So(spans[0].Tag("plugin-version"), ShouldEqual, 3)
+			So(spans[0].Tag("error"), ShouldBeNil)
+			So(spans[1].OperationName, ShouldEqual, "workflow-run")
+			So(spans[1].Tag("task-name"), ShouldEqual, "my-task")
+		})
+		Convey("a failed publish marks its span as an error", func() {
+			pj := newPublishJob(parent, "file", 3, "", nil, &tracedPublisher{errs: []error{errors.New("disk full")}}, "task-1")
+			pj.Run()
+
+			spans := tracer.FinishedSpans()
+			So(spans, ShouldHaveLength, 1)
+			So(spans[0].Tag("error"), ShouldEqual, true)
+			So(spans[0].Logs(), ShouldHaveLength, 1)
+		})
+		Convey("the jobs following a traced job are its children", func() {
+			pj := newPublishJob(parent, "file", 3, "", nil, &tracedPublisher{}, "task-1")
+			pj.Run()
+			next := newPublishJob(pj, "file", 3, "", nil, &tracedPublisher{}, "task-1")
+			So(next.(*publisherJob).traceFrom, ShouldResemble, jobSpanContext(pj))
+			So(jobSpanContext(pj).(mocktracer.MockSpanContext).SpanID, ShouldNotEqual, rootID)
+		})
+	})
+}
@@ -0,0 +1,71 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+// ErrVersionConstraintUnsupported is returned when a workflow constrains the
+// version of a plugin run by a metric manager which cannot resolve it
+var ErrVersionConstraintUnsupported = errors.New("Metric manager cannot resolve plugin version constraints")
+
+// resolvesVersionConstraints is implemented by metric managers able to select
+// the latest loaded version of a plugin matching a version constraint
+type resolvesVersionConstraints interface {
+	ResolvePluginVersion(typeName, name, constraint string) (int, error)
+}
+
+// constrainedPlugin is implemented by the workflow nodes which may select
+// the version of their plugin with a constraint
+type constrainedPlugin interface {
+	core.SubscribedPlugin
+	VersionConstraint() string
+}
+
+// runVersion returns the version of the plugin run by a workflow node: the
+// latest version loaded by the manager matching the constraint of the node,
+// if any, as plugins may have been loaded since the task was created.
+func runVersion(mgr managesMetrics, p constrainedPlugin) (int, error) {
+	if p.VersionConstraint() == "" {
+		return p.Version(), nil
+	}
+	rv, ok := mgr.(resolvesVersionConstraints)
+	if !ok {
+		return 0, ErrVersionConstraintUnsupported
+	}
+	return rv.ResolvePluginVersion(p.TypeName(), p.Name(), p.VersionConstraint())
+}
+
+// checkVersionConstraints returns ErrVersionConstraintUnsupported when the
+// version of a plugin is constrained while the manager cannot resolve it
+func checkVersionConstraints(mgr managesMetrics, plugins []core.SubscribedPlugin) error {
+	if _, ok := mgr.(resolvesVersionConstraints); ok {
+		return nil
+	}
+	for _, p := range plugins {
+		if cp, ok := p.(constrainedPlugin); ok && cp.VersionConstraint() != "" {
+			return ErrVersionConstraintUnsupported
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package wfbuilder assembles workflows in code. Chains of collect, process
+// and publish nodes are built from handles to loaded plugins and typed config
+// values, validated as they are built and converted into a workflow map which
+// can be given to the scheduler.
+package wfbuilder
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+var (
+	// ErrNotProcessor is returned when a processor handle is made from another type of plugin
+	ErrNotProcessor = errors.New("Plugin is not a processor")
+	// ErrNotPublisher is returned when a publisher handle is made from another type of plugin
+	ErrNotPublisher = errors.New("Plugin is not a publisher")
+	// ErrNoMetrics is returned when the workflow does not collect any metric
+	ErrNoMetrics = errors.New("Workflow does not collect any metric")
+	// ErrEmptyNamespace is returned when a metric or config namespace is empty
+	ErrEmptyNamespace = errors.New("Namespace must not be empty")
+	// ErrEmptyConfigKey is returned when a config item has no key
+	ErrEmptyConfigKey = errors.New("Config key must not be empty")
+	// ErrInvalidRetries is returned when a node is given a negative number of retries
+	ErrInvalidRetries = errors.New("Retries must not be negative")
+)
+
+// Processor is a handle to a loaded processor plugin
+type Processor struct {
+	plugin core.Plugin
+}
+
+// NewProcessor returns a handle to the given processor plugin, usually one
+// of the plugins listed in the plugin catalog of the control module.
+func NewProcessor(p core.Plugin) (Processor, error) {
+	if p == nil || p.TypeName() != core.ProcessorPluginType.String() {
+		return Processor{}, ErrNotProcessor
+	}
+	return Processor{plugin: p}, nil
+}
+
+// Publisher is a handle to a loaded publisher plugin
+type Publisher struct {
+	plugin core.Plugin
+}
+
+// NewPublisher returns a handle to the given publisher plugin.
+func NewPublisher(p core.Plugin) (Publisher, error) {
+	if p == nil || p.TypeName() != core.PublisherPluginType.String() {
+		return Publisher{}, ErrNotPublisher
+	}
+	return Publisher{plugin: p}, nil
+}
+
+// Builder builds the collect node of a workflow and the nodes it feeds. The
+// first error met while building is kept and returned by WorkflowMap.
+type Builder struct {
+	metrics map[string]int
+	config  map[string]map[string]interface{}
+	tags    map[string]string
+	err     error
+	node
+}
+
+// Collect starts a workflow collecting the given metrics, usually metrics of
+// the metric catalog of the control module.
+func Collect(metrics ...core.RequestedMetric) *Builder {
+	b := &Builder{
+		metrics: map[string]int{},
+		config:  map[string]map[string]interface{}{},
+	}
+	b.root = b
+	for _, m := range metrics {
+		b.Metric(m)
+	}
+	return b
+}
+
+// Metric adds a metric to collect.
+func (b *Builder) Metric(m core.RequestedMetric) *Builder {
+	if len(m.Namespace()) == 0 {
+		b.fail(ErrEmptyNamespace, "metric")
+		return b
+	}
+	b.metrics[m.Namespace().String()] = m.Version()
+	return b
+}
+
+// Config sets a config item applied to the metrics under the namespace.
+func (b *Builder) Config(ns core.Namespace, key string, value ctypes.ConfigValue) *Builder {
+	if len(ns) == 0 {
+		b.fail(ErrEmptyNamespace, "collect config")
+		return b
+	}
+	v, err := configValue(key, value)
+	if err != nil {
+		b.fail(err, "collect config "+ns.String())
+		return b
+	}
+	if b.config[ns.String()] == nil {
+		b.config[ns.String()] = map[string]interface{}{}
+	}
+	b.config[ns.String()][key] = v
+	return b
+}
+
+// Tag adds a tag attached to every metric collected by the workflow.
+func (b *Builder) Tag(key, value string) *Builder {
+	if b.tags == nil {
+		b.tags = map[string]string{}
+	}
+	b.tags[key] = value
+	return b
+}
+
+// WorkflowMap returns the workflow map of the workflow or the first error
+// met while building it.
+func (b *Builder) WorkflowMap() (*wmap.WorkflowMap, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.metrics) == 0 {
+		return nil, ErrNoMetrics
+	}
+	w := wmap.NewWorkflowMap()
+	for ns, v := range b.metrics {
+		w.CollectNode.AddMetric(ns, v)
+	}
+	for ns, items := range b.config {
+		for k, v := range items {
+			w.CollectNode.AddConfigItem(ns, k, v)
+		}
+	}
+	w.Tags = b.tags
+	w.CollectNode.ProcessNodes, w.CollectNode.PublishNodes = b.children()
+	return w, nil
+}
+
+func (b *Builder) fail(err error, where string) {
+	if b.err == nil {
+		b.err = fmt.Errorf("%v (in %s)", err, where)
+	}
+}
+
+// node holds the process and publish nodes fed by a node
+type node struct {
+	root         *Builder
+	processNodes []*ProcessNode
+	publishNodes []*PublishNode
+}
+
+// Process adds a process node fed by this node and returns it.
+func (n *node) Process(p Processor) *ProcessNode {
+	pr := &ProcessNode{
+		node:   node{root: n.root},
+		plugin: p.plugin,
+		config: map[string]interface{}{},
+	}
+	if p.plugin == nil {
+		n.root.fail(ErrNotProcessor, "process node")
+	}
+	n.processNodes = append(n.processNodes, pr)
+	return pr
+}
+
+// Publish adds a publish node fed by this node and returns it.
+func (n *node) Publish(p Publisher) *PublishNode {
+	pu := &PublishNode{
+		root:   n.root,
+		plugin: p.plugin,
+		config: map[string]interface{}{},
+	}
+	if p.plugin == nil {
+		n.root.fail(ErrNotPublisher, "publish node")
+	}
+	n.publishNodes = append(n.publishNodes, pu)
+	return pu
+}
+
+func (n *node) children() ([]wmap.ProcessWorkflowMapNode, []wmap.PublishWorkflowMapNode) {
+	var prs []wmap.ProcessWorkflowMapNode
+	for _, pr := range n.processNodes {
+		prs = append(prs, pr.workflowMapNode())
+	}
+	var pus []wmap.PublishWorkflowMapNode
+	for _, pu := range n.publishNodes {
+		pus = append(pus, pu.workflowMapNode())
+	}
+	return prs, pus
+}
+
+// ProcessNode is a process node of a workflow being built
+type ProcessNode struct {
+	node
+	plugin     core.Plugin
+	config     map[string]interface{}
+	target     string
+	retries    int
+	retryDelay time.Duration
+}
+
+// Config sets a config item of the processor.
+func (p *ProcessNode) Config(key string, value ctypes.ConfigValue) *ProcessNode {
+	v, err := configValue(key, value)
+	if err != nil {
+		p.root.fail(err, "process node "+p.name())
+		return p
+	}
+	p.config[key] = v
+	return p
+}
+
+// Target runs the processor on the remote snapteld at the given address.
+func (p *ProcessNode) Target(addr string) *ProcessNode {
+	p.target = addr
+	return p
+}
+
+// Retries sets the number of times a failed job of the node is retried and
+// the delay between retries.
+func (p *ProcessNode) Retries(retries int, delay time.Duration) *ProcessNode {
+	if retries < 0 {
+		p.root.fail(ErrInvalidRetries, "process node "+p.name())
+		return p
+	}
+	p.retries, p.retryDelay = retries, delay
+	return p
+}
+
+func (p *ProcessNode) name() string {
+	if p.plugin == nil {
+		return ""
+	}
+	return p.plugin.Name()
+}
+
+func (p *ProcessNode) workflowMapNode() wmap.ProcessWorkflowMapNode {
+	w := wmap.NewProcessNode(p.plugin.Name(), p.plugin.Version())
+	for k, v := range p.config {
+		w.AddConfigItem(k, v)
+	}
+	w.Target = p.target
+	w.Retries = p.retries
+	if p.retryDelay > 0 {
+		w.RetryDelay = p.retryDelay.String()
+	}
+	w.ProcessNodes, w.PublishNodes = p.children()
+	return *w
+}
+
+// PublishNode is a publish node of a workflow being built
+type PublishNode struct {
+	root       *Builder
+	plugin     core.Plugin
+	config     map[string]interface{}
+	target     string
+	retries    int
+	retryDelay time.Duration
+}
+
+// Config sets a config item of the publisher.
+func (p *PublishNode) Config(key string, value ctypes.ConfigValue) *PublishNode {
+	v, err := configValue(key, value)
+	if err != nil {
+		p.root.fail(err, "publish node "+p.name())
+		return p
+	}
+	p.config[key] = v
+	return p
+}
+
+// Target runs the publisher on the remote snapteld at the given address.
+func (p *PublishNode) Target(addr string) *PublishNode {
+	p.target = addr
+	return p
+}
+
+// Retries sets the number of times a failed job of the node is retried and
+// the delay between retries.
+func (p *PublishNode) Retries(retries int, delay time.Duration) *PublishNode {
+	if retries < 0 {
+		p.root.fail(ErrInvalidRetries, "publish node "+p.name())
+		return p
+	}
+	p.retries, p.retryDelay = retries, delay
+	return p
+}
+
+func (p *PublishNode) name() string {
+	if p.plugin == nil {
+		return ""
+	}
+	return p.plugin.Name()
+}
+
+func (p *PublishNode) workflowMapNode() wmap.PublishWorkflowMapNode {
+	w := wmap.NewPublishNode(p.plugin.Name(), p.plugin.Version())
+	for k, v := range p.config {
+		w.AddConfigItem(k, v)
+	}
+	w.Target = p.target
+	w.Retries = p.retries
+	if p.retryDelay > 0 {
+		w.RetryDelay = p.retryDelay.String()
+	}
+	return *w
+}
+
+// configValue returns the workflow map value of a typed config value
+func configValue(key string, value ctypes.ConfigValue) (interface{}, error) {
+	if key == "" {
+		return nil, ErrEmptyConfigKey
+	}
+	switch v := value.(type) {
+	case ctypes.ConfigValueStr:
+		return v.Value, nil
+	case ctypes.ConfigValueInt:
+		return v.Value, nil
+	case ctypes.ConfigValueFloat:
+		return v.Value, nil
+	case ctypes.ConfigValueBool:
+		return v.Value, nil
+	}
+	return nil, fmt.Errorf("Unsupported value for config item '%s': %v", key, value)
+}
@@ -0,0 +1,107 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wfbuilder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/ctypes"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockPlugin struct {
+	typeName string
+	name     string
+	version  int
+}
+
+func (p mockPlugin) TypeName() string { return p.typeName }
+func (p mockPlugin) Name() string     { return p.name }
+func (p mockPlugin) Version() int     { return p.version }
+
+type mockMetric struct {
+	ns      core.Namespace
+	version int
+}
+
+func (m mockMetric) Namespace() core.Namespace { return m.ns }
+func (m mockMetric) Version() int              { return m.version }
+
+func TestBuilder(t *testing.T) {
+	passthru, _ := NewProcessor(mockPlugin{"processor", "passthru", 1})
+	file, _ := NewPublisher(mockPlugin{"publisher", "file", 2})
+	foo := mockMetric{core.NewNamespace("intel", "mock", "foo"), 1}
+
+	Convey("Workflow builder", t, func() {
+		Convey("builds a workflow map", func() {
+			b := Collect(foo).
+				Config(core.NewNamespace("intel", "mock"), "user", ctypes.ConfigValueStr{Value: "root"}).
+				Tag("dc", "east")
+			b.Process(passthru).
+				Config("debug", ctypes.ConfigValueBool{Value: true}).
+				Publish(file).
+				Config("file", ctypes.ConfigValueStr{Value: "/tmp/out"}).
+				Retries(3, 5*time.Second)
+			w, err := b.WorkflowMap()
+			So(err, ShouldBeNil)
+			So(w.CollectNode.GetMetrics(), ShouldHaveLength, 1)
+			So(w.CollectNode.Config["/intel/mock"]["user"], ShouldEqual, "root")
+			So(w.Tags, ShouldResemble, map[string]string{"dc": "east"})
+			pr := w.CollectNode.ProcessNodes[0]
+			So(pr.Name, ShouldEqual, "passthru")
+			So(pr.Version, ShouldEqual, 1)
+			So(pr.Config["debug"], ShouldEqual, true)
+			pu := pr.PublishNodes[0]
+			So(pu.Name, ShouldEqual, "file")
+			So(pu.Config["file"], ShouldEqual, "/tmp/out")
+			So(pu.Retries, ShouldEqual, 3)
+			So(pu.RetryDelay, ShouldEqual, "5s")
+		})
+		Convey("checks the type of plugin handles", func() {
+			_, err := NewProcessor(mockPlugin{"publisher", "file", 1})
+			So(err, ShouldEqual, ErrNotProcessor)
+			_, err = NewPublisher(mockPlugin{"processor", "passthru", 1})
+			So(err, ShouldEqual, ErrNotPublisher)
+		})
+		Convey("requires metrics", func() {
+			_, err := Collect().WorkflowMap()
+			So(err, ShouldEqual, ErrNoMetrics)
+		})
+		Convey("returns the first error met", func() {
+			b := Collect(foo)
+			b.Publish(file).Config("", ctypes.ConfigValueInt{Value: 1})
+			b.Process(passthru).Retries(-1, 0)
+			_, err := b.WorkflowMap()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, ErrEmptyConfigKey.Error())
+		})
+		Convey("rejects handles not made from a plugin", func() {
+			b := Collect(foo)
+			b.Publish(Publisher{})
+			_, err := b.WorkflowMap()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+// ConfigDefaults holds config merged into the process and publish nodes of
+// workflow maps, by plugin type ("processor" or "publisher") and plugin name.
+type ConfigDefaults map[string]map[string]*PluginConfigDefaults
+
+// PluginConfigDefaults holds the default config of a plugin for all of its
+// versions and for specific versions, which take precedence.
+type PluginConfigDefaults struct {
+	All      map[string]interface{}         `json:"all,omitempty"yaml:"all"`
+	Versions map[int]map[string]interface{} `json:"versions,omitempty"yaml:"versions"`
+}
+
+// config returns the default config of the given plugin. Nodes selecting the
+// latest version only get the config for all versions.
+func (d ConfigDefaults) config(typeName, name string, version int) map[string]interface{} {
+	p := d[typeName][name]
+	if p == nil {
+		return nil
+	}
+	cfg := make(map[string]interface{}, len(p.All))
+	for k, v := range p.All {
+		cfg[k] = v
+	}
+	if version > 0 {
+		for k, v := range p.Versions[version] {
+			cfg[k] = v
+		}
+	}
+	return cfg
+}
+
+// ApplyConfigDefaults returns a copy of the workflow map with the default
+// config of their plugin merged into its process and publish nodes. Config
+// items set on a node are kept.
+func (w *WorkflowMap) ApplyConfigDefaults(d ConfigDefaults) (*WorkflowMap, error) {
+	if len(d) == 0 {
+		return w, nil
+	}
+	c, err := w.Copy()
+	if err != nil {
+		return nil, err
+	}
+	if c.CollectNode != nil {
+		d.applyProcessNodes(c.CollectNode.ProcessNodes)
+		d.applyPublishNodes(c.CollectNode.PublishNodes)
+	}
+	return c, nil
+}
+
+func (d ConfigDefaults) applyProcessNodes(nodes []ProcessWorkflowMapNode) {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Filter == nil && n.Sample == nil {
+			n.Config = mergeConfigDefaults(n.Config, d.config("processor", n.Name, n.Version))
+		}
+		d.applyProcessNodes(n.ProcessNodes)
+		d.applyPublishNodes(n.PublishNodes)
+	}
+}
+
+func (d ConfigDefaults) applyPublishNodes(nodes []PublishWorkflowMapNode) {
+	for i := range nodes {
+		n := &nodes[i]
+		n.Config = mergeConfigDefaults(n.Config, d.config("publisher", n.Name, n.Version))
+		if n.DeadLetter != nil {
+			dl := []PublishWorkflowMapNode{*n.DeadLetter}
+			d.applyPublishNodes(dl)
+			n.DeadLetter = &dl[0]
+		}
+	}
+}
+
+func mergeConfigDefaults(cfg, defaults map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return cfg
+	}
+	if cfg == nil {
+		cfg = make(map[string]interface{}, len(defaults))
+	}
+	for k, v := range defaults {
+		if _, ok := cfg[k]; !ok {
+			cfg[k] = v
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,143 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrConfigPatchEmpty is returned when a config patch does not change anything
+var ErrConfigPatchEmpty = errors.New("Config patch does not contain any config item")
+
+// ConfigPatch holds config items to set on the nodes of a workflow. Collect
+// items are keyed by namespace, process and publish items by plugin name and
+// apply to every node of that plugin. A null value removes the item.
+type ConfigPatch struct {
+	Collect map[string]map[string]interface{} `json:"collect,omitempty"yaml:"collect"`
+	Process map[string]map[string]interface{} `json:"process,omitempty"yaml:"process"`
+	Publish map[string]map[string]interface{} `json:"publish,omitempty"yaml:"publish"`
+}
+
+func (cp *ConfigPatch) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "collect":
+			if err := json.Unmarshal(v, &cp.Collect); err != nil {
+				return fmt.Errorf("%v (while parsing 'collect')", err)
+			}
+		case "process":
+			if err := json.Unmarshal(v, &cp.Process); err != nil {
+				return fmt.Errorf("%v (while parsing 'process')", err)
+			}
+		case "publish":
+			if err := json.Unmarshal(v, &cp.Publish); err != nil {
+				return fmt.Errorf("%v (while parsing 'publish')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in config patch.", k)
+		}
+	}
+	return nil
+}
+
+// Copy returns a deep copy of the workflow map
+func (w *WorkflowMap) Copy() (*WorkflowMap, error) {
+	b, err := w.ToJson()
+	if err != nil {
+		return nil, err
+	}
+	return FromJson(string(b))
+}
+
+// ApplyConfigPatch sets the config items of the patch on the workflow map.
+// Every plugin named in the patch must have a node in the workflow.
+func (w *WorkflowMap) ApplyConfigPatch(cp *ConfigPatch) error {
+	if len(cp.Collect) == 0 && len(cp.Process) == 0 && len(cp.Publish) == 0 {
+		return ErrConfigPatchEmpty
+	}
+	if w.CollectNode == nil {
+		return fmt.Errorf("Workflow has no collect node to patch")
+	}
+	for ns, items := range cp.Collect {
+		if w.CollectNode.Config == nil {
+			w.CollectNode.Config = map[string]map[string]interface{}{}
+		}
+		if w.CollectNode.Config[ns] == nil {
+			w.CollectNode.Config[ns] = map[string]interface{}{}
+		}
+		patchConfig(w.CollectNode.Config[ns], items)
+	}
+	patched := map[string]bool{}
+	patchProcessNodes(w.CollectNode.ProcessNodes, w.CollectNode.PublishNodes, cp, patched)
+	for name := range cp.Process {
+		if !patched["process:"+name] {
+			return fmt.Errorf("Workflow has no process node for plugin '%s'", name)
+		}
+	}
+	for name := range cp.Publish {
+		if !patched["publish:"+name] {
+			return fmt.Errorf("Workflow has no publish node for plugin '%s'", name)
+		}
+	}
+	return nil
+}
+
+func patchProcessNodes(prs []ProcessWorkflowMapNode, pus []PublishWorkflowMapNode, cp *ConfigPatch, patched map[string]bool) {
+	for i := range prs {
+		if items, ok := cp.Process[prs[i].Name]; ok && prs[i].Name != "" {
+			if prs[i].Config == nil {
+				prs[i].Config = map[string]interface{}{}
+			}
+			patchConfig(prs[i].Config, items)
+			patched["process:"+prs[i].Name] = true
+		}
+		patchProcessNodes(prs[i].ProcessNodes, prs[i].PublishNodes, cp, patched)
+	}
+	for i := range pus {
+		if items, ok := cp.Publish[pus[i].Name]; ok {
+			if pus[i].Config == nil {
+				pus[i].Config = map[string]interface{}{}
+			}
+			patchConfig(pus[i].Config, items)
+			patched["publish:"+pus[i].Name] = true
+		}
+		if pus[i].DeadLetter != nil {
+			dl := []PublishWorkflowMapNode{*pus[i].DeadLetter}
+			patchProcessNodes(nil, dl, cp, patched)
+			pus[i].DeadLetter = &dl[0]
+		}
+	}
+}
+
+func patchConfig(cfg, items map[string]interface{}) {
+	for k, v := range items {
+		if v == nil {
+			delete(cfg, k)
+			continue
+		}
+		cfg[k] = v
+	}
+}
@@ -0,0 +1,111 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"fmt"
+)
+
+// ConfigRefKey is the config key used to reference config blocks declared in
+// the defs section of a workflow map.
+const ConfigRefKey = "$ref"
+
+// resolveDefs replaces the references to named config blocks found in the
+// config of every node of the workflow map with the items of those blocks.
+// Items set on the node itself take precedence over the referenced ones.
+func (w *WorkflowMap) resolveDefs() error {
+	if w.CollectNode == nil {
+		return nil
+	}
+	for ns, cfg := range w.CollectNode.Config {
+		resolved, err := w.resolveConfig(cfg, "collect config "+ns)
+		if err != nil {
+			return err
+		}
+		w.CollectNode.Config[ns] = resolved
+	}
+	return w.resolveNodes(w.CollectNode.ProcessNodes, w.CollectNode.PublishNodes)
+}
+
+func (w *WorkflowMap) resolveNodes(prs []ProcessWorkflowMapNode, pus []PublishWorkflowMapNode) error {
+	for i := range prs {
+		resolved, err := w.resolveConfig(prs[i].Config, "process node "+prs[i].Name)
+		if err != nil {
+			return err
+		}
+		prs[i].Config = resolved
+		if err := w.resolveNodes(prs[i].ProcessNodes, prs[i].PublishNodes); err != nil {
+			return err
+		}
+	}
+	for i := range pus {
+		resolved, err := w.resolveConfig(pus[i].Config, "publish node "+pus[i].Name)
+		if err != nil {
+			return err
+		}
+		pus[i].Config = resolved
+		if pus[i].DeadLetter != nil {
+			dl := []PublishWorkflowMapNode{*pus[i].DeadLetter}
+			if err := w.resolveNodes(nil, dl); err != nil {
+				return err
+			}
+			pus[i].DeadLetter = &dl[0]
+		}
+	}
+	return nil
+}
+
+func (w *WorkflowMap) resolveConfig(cfg map[string]interface{}, where string) (map[string]interface{}, error) {
+	ref, ok := cfg[ConfigRefKey]
+	if !ok {
+		return cfg, nil
+	}
+	var names []string
+	switch v := ref.(type) {
+	case string:
+		names = []string{v}
+	case []interface{}:
+		for _, n := range v {
+			s, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("Invalid '%s' in %s: expected a name or a list of names", ConfigRefKey, where)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf("Invalid '%s' in %s: expected a name or a list of names", ConfigRefKey, where)
+	}
+	resolved := map[string]interface{}{}
+	for _, name := range names {
+		def, ok := w.Defs[name]
+		if !ok {
+			return nil, fmt.Errorf("Undefined config block '%s' referenced in %s", name, where)
+		}
+		for k, v := range def {
+			resolved[k] = v
+		}
+	}
+	for k, v := range cfg {
+		if k != ConfigRefKey {
+			resolved[k] = v
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,255 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import "encoding/json"
+
+// SchemaID identifies the JSON Schema describing workflow maps
+const SchemaID = "https://github.com/intelsdi-x/snap/schema/workflow.json"
+
+// durationPattern matches the durations accepted by time.ParseDuration
+const durationPattern = `^-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$`
+
+// versionConstraintPattern matches the version constraints of plugin nodes
+const versionConstraintPattern = `^\s*(>=|<=|!=|>|<|=)?\s*0*[1-9][0-9]*\s*(,\s*(>=|<=|!=|>|<|=)?\s*0*[1-9][0-9]*\s*)*$`
+
+// Schema returns the JSON Schema (draft 4) of workflow maps given in JSON,
+// for tooling validating task manifests before they are submitted. It mirrors
+// the checks done by Validate, except the ones only converting the workflow
+// map detects such as references to undefined config blocks.
+func Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-04/schema#",
+		"id":          SchemaID,
+		"title":       "snap workflow map",
+		"description": "The workflow of a snap task",
+		"oneOf": []interface{}{
+			schemaRef("storedWorkflow"),
+			schemaRef("uriWorkflow"),
+			schemaRef("workflow"),
+		},
+		"definitions": map[string]interface{}{
+			"storedWorkflow": schemaObject(map[string]interface{}{
+				ConfigRefKey:     schemaNonEmptyString("Name of a stored workflow"),
+				"schema_version": schemaVersionProperty(),
+			}, ConfigRefKey),
+			"uriWorkflow": schemaObject(map[string]interface{}{
+				"uri": map[string]interface{}{
+					"type":        "string",
+					"pattern":     "^(https?|file)://",
+					"description": "URI of a workflow fetched when the task is created",
+				},
+				"schema_version": schemaVersionProperty(),
+			}, "uri"),
+			"workflow": schemaObject(map[string]interface{}{
+				"schema_version": schemaVersionProperty(),
+				"tags":           schemaRef("stringMap"),
+				"pin_versions": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Pin the latest versions to the ones loaded when the task is created",
+				},
+				"defs": map[string]interface{}{
+					"type":                 "object",
+					"description":          "Named config blocks referenced with " + ConfigRefKey,
+					"additionalProperties": schemaRef("config"),
+				},
+				"collect": schemaRef("collect"),
+			}, "collect"),
+			"collect": schemaAnyOf(schemaObject(map[string]interface{}{
+				"metrics": map[string]interface{}{
+					"type": "object",
+					"patternProperties": map[string]interface{}{
+						"^/": schemaRef("metricInfo"),
+					},
+					"additionalProperties": false,
+				},
+				"query": schemaRef("query"),
+				"config": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": schemaRef("config"),
+				},
+				"tags": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": schemaRef("stringMap"),
+				},
+				"process": schemaArray(schemaRef("processNode")),
+				"publish": schemaArray(schemaRef("publishNode")),
+				"timeout": schemaDuration(),
+			}), schemaRequired("metrics"), schemaRequired("query")),
+			"metricInfo": schemaObject(map[string]interface{}{
+				"version": schemaVersion(0),
+				"every":   schemaInteger(1),
+			}),
+			"query": schemaObject(map[string]interface{}{
+				"namespace": schemaNamespace(),
+				"version":   schemaVersion(0),
+				"tags":      schemaRef("stringMap"),
+			}, "namespace"),
+			"processNode": schemaAnyOf(schemaObject(pluginNodeProperties(map[string]interface{}{
+				"filter":  schemaRef("filter"),
+				"sample":  schemaRef("sample"),
+				"process": schemaArray(schemaRef("processNode")),
+				"publish": schemaArray(schemaRef("publishNode")),
+			})), schemaRequired("plugin_name"), schemaRequired("filter"), schemaRequired("sample")),
+			"publishNode": schemaObject(pluginNodeProperties(map[string]interface{}{
+				"batch":       schemaRef("batch"),
+				"dead_letter": schemaRef("publishNode"),
+			}), "plugin_name"),
+			"filter": schemaObject(map[string]interface{}{
+				"include": schemaArray(map[string]interface{}{"type": "string"}),
+				"exclude": schemaArray(map[string]interface{}{"type": "string"}),
+				"tags":    schemaRef("stringMap"),
+			}),
+			"sample": schemaOneOf(schemaObject(map[string]interface{}{
+				"every":    schemaInteger(1),
+				"interval": schemaDuration(),
+			}), schemaRequired("every"), schemaRequired("interval")),
+			"batch": schemaMinProperties(schemaObject(map[string]interface{}{
+				"max_metrics":    schemaInteger(1),
+				"max_bytes":      schemaInteger(1),
+				"flush_interval": schemaDuration(),
+			}), 1),
+			"config": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					ConfigRefKey: map[string]interface{}{
+						"description": "Named config block(s) the config extends",
+						"oneOf": []interface{}{
+							map[string]interface{}{"type": "string"},
+							schemaArray(map[string]interface{}{"type": "string"}),
+						},
+					},
+				},
+				"additionalProperties": schemaRef("configValue"),
+			},
+			"configValue": map[string]interface{}{
+				"oneOf": []interface{}{
+					map[string]interface{}{"type": []interface{}{"string", "number", "boolean"}},
+					schemaObject(map[string]interface{}{
+						SecretRefKey: schemaNonEmptyString("Reference to a secret resolved when the task is created"),
+					}, SecretRefKey),
+				},
+			},
+			"stringMap": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
+
+// SchemaJSON returns the JSON Schema of workflow maps encoded in JSON
+func SchemaJSON() []byte {
+	b, _ := json.MarshalIndent(Schema(), "", "  ")
+	return b
+}
+
+// pluginNodeProperties returns the properties shared by process and publish
+// nodes along with the given ones
+func pluginNodeProperties(props map[string]interface{}) map[string]interface{} {
+	props["plugin_name"] = schemaNonEmptyString("")
+	props["plugin_version"] = schemaVersion(-1)
+	props["plugin_version_constraint"] = map[string]interface{}{
+		"type":        "string",
+		"pattern":     versionConstraintPattern,
+		"description": "Comparisons selecting the latest loaded version of the plugin matching them, e.g. '>=3, <5'",
+	}
+	props["config"] = schemaRef("config")
+	props["target"] = map[string]interface{}{"type": "string"}
+	props["retries"] = schemaInteger(0)
+	props["retry_delay"] = schemaDuration()
+	props["content_type"] = schemaNonEmptyString("Content type of the metrics sent to the plugin")
+	props["timeout"] = schemaDuration()
+	return props
+}
+
+func schemaObject(props map[string]interface{}, req ...string) map[string]interface{} {
+	o := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(req) > 0 {
+		o["required"] = req
+	}
+	return o
+}
+
+func schemaAnyOf(o map[string]interface{}, schemas ...interface{}) map[string]interface{} {
+	o["anyOf"] = schemas
+	return o
+}
+
+func schemaOneOf(o map[string]interface{}, schemas ...interface{}) map[string]interface{} {
+	o["oneOf"] = schemas
+	return o
+}
+
+func schemaMinProperties(o map[string]interface{}, min int) map[string]interface{} {
+	o["minProperties"] = min
+	return o
+}
+
+func schemaRequired(field string) map[string]interface{} {
+	return map[string]interface{}{"required": []string{field}}
+}
+
+func schemaRef(definition string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/definitions/" + definition}
+}
+
+func schemaArray(items interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func schemaInteger(min int) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": min}
+}
+
+// schemaVersion describes a version given either as an integer or as the
+// latest keyword
+func schemaVersion(min int) map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			schemaInteger(min),
+			map[string]interface{}{"enum": []string{LatestVersionKeyword}},
+		},
+	}
+}
+
+func schemaNonEmptyString(description string) map[string]interface{} {
+	s := map[string]interface{}{"type": "string", "minLength": 1}
+	if description != "" {
+		s["description"] = description
+	}
+	return s
+}
+
+func schemaVersionProperty() map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": 1, "maximum": SchemaVersion}
+}
+
+func schemaNamespace() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "pattern": "^/"}
+}
+
+func schemaDuration() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "pattern": durationPattern}
+}
@@ -0,0 +1,62 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SecretRefKey is the key of a config value referencing a secret kept in an
+// external secret store, e.g. {"secretRef": "vault:kv/db#password"}. The
+// reference, not the secret, is what is kept in the workflow map.
+const SecretRefKey = "secretRef"
+
+// ErrNoSecretResolver is returned when a secret is referenced but no secret
+// resolver has been set
+var ErrNoSecretResolver = errors.New("No secret resolver is set")
+
+// ResolveSecret resolves the secret references found in config values when
+// they are converted to config data nodes. It is set by the core package,
+// which the wmap package cannot depend on.
+var ResolveSecret = func(ref string) (string, error) {
+	return "", ErrNoSecretResolver
+}
+
+// secretRef returns the reference of a config value referencing a secret
+func secretRef(v map[string]interface{}) (string, bool) {
+	if len(v) != 1 {
+		return "", false
+	}
+	ref, ok := v[SecretRefKey].(string)
+	return ref, ok && ref != ""
+}
+
+func resolveSecretValue(key string, v map[string]interface{}) (string, error) {
+	ref, ok := secretRef(v)
+	if !ok {
+		return "", fmt.Errorf("config item '%s' must be a string, a number, a boolean or a secret reference", key)
+	}
+	s, err := ResolveSecret(ref)
+	if err != nil {
+		return "", fmt.Errorf("config item '%s' references a secret which could not be resolved: %v", key, err)
+	}
+	return s, nil
+}
@@ -26,6 +26,12 @@ import (
 func (w *WorkflowMap) String() string {
 	var out string
 	out += "Workflow\n"
+	if len(w.Tags) > 0 {
+		out += "   Tags:\n"
+		for k, v := range w.Tags {
+			out += "      " + fmt.Sprintf("%s=%s\n", k, v)
+		}
+	}
 	out += "   Collect:\n"
 	if w.CollectNode != nil {
 		out += w.CollectNode.String("      ")
@@ -42,8 +48,20 @@ func (c *CollectWorkflowMapNode) String(pad string) string {
 	for k, v := range c.Metrics {
 		out += pad + fmt.Sprintf("      Namespace: %s\n", k)
 		out += pad + fmt.Sprintf("         Version: %d\n", v.Version_)
+		if v.Every > 1 {
+			out += pad + fmt.Sprintf("         Every: %d\n", v.Every)
+		}
 	}
 	out += "\n"
+	if c.Query != nil {
+		out += pad + "Query:\n"
+		out += pad + fmt.Sprintf("      Namespace: %s\n", c.Query.Namespace)
+		out += pad + fmt.Sprintf("         Version: %d\n", c.Query.Version)
+		for x, y := range c.Query.Tags {
+			out += pad + "      " + fmt.Sprintf("%s=%s\n", x, y)
+		}
+		out += "\n"
+	}
 	out += pad + "Config:\n"
 	for k, v := range c.Config {
 		out += pad + "   " + k + "\n"
@@ -83,6 +101,20 @@ func (p *ProcessWorkflowMapNode) String(pad string) string {
 		out += pad + "      " + fmt.Sprintf("%s=%+v\n", k, v)
 	}
 	out += pad + "   Target:" + p.Target + "\n"
+	if p.ContentType != "" {
+		out += pad + "   Content Type: " + p.ContentType + "\n"
+	}
+	if p.Filter != nil {
+		out += pad + "   Filter:\n"
+		out += pad + "      " + fmt.Sprintf("Include=%v\n", p.Filter.Include)
+		out += pad + "      " + fmt.Sprintf("Exclude=%v\n", p.Filter.Exclude)
+		out += pad + "      " + fmt.Sprintf("Tags=%v\n", p.Filter.Tags)
+	}
+	if p.Sample != nil {
+		out += pad + "   Sample:\n"
+		out += pad + "      " + fmt.Sprintf("Every=%d\n", p.Sample.Every)
+		out += pad + "      " + fmt.Sprintf("Interval=%s\n", p.Sample.Interval)
+	}
 
 	out += pad + "   Process Nodes:\n"
 	for _, pr := range p.ProcessNodes {
@@ -104,5 +136,18 @@ func (p *PublishWorkflowMapNode) String(pad string) string {
 	for k, v := range p.Config {
 		out += pad + "      " + fmt.Sprintf("%s=%+v\n", k, v)
 	}
+	if p.ContentType != "" {
+		out += pad + "   Content Type: " + p.ContentType + "\n"
+	}
+	if p.Batch != nil {
+		out += pad + "   Batch:\n"
+		out += pad + "      " + fmt.Sprintf("MaxMetrics=%d\n", p.Batch.MaxMetrics)
+		out += pad + "      " + fmt.Sprintf("MaxBytes=%d\n", p.Batch.MaxBytes)
+		out += pad + "      " + fmt.Sprintf("FlushInterval=%s\n", p.Batch.FlushInterval)
+	}
+	if p.DeadLetter != nil {
+		out += pad + "   Dead Letter:\n"
+		out += p.DeadLetter.String(pad + "   ")
+	}
 	return out
 }
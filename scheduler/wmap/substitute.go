@@ -0,0 +1,74 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templateData holds the values available to template placeholders
+// (e.g. {{.Hostname}}) in config item values.
+type templateData struct {
+	Hostname string
+}
+
+// substituteConfigValue resolves template placeholders and ${ENV_VAR}
+// references found in a config item value. The template is executed first so
+// a "{{" in the value of an environment variable is kept as is rather than
+// executed. Referencing an environment variable that is not set is an error
+// so a misconfigured workflow fails at task creation rather than silently
+// sending an empty value to a plugin.
+func substituteConfigValue(key, value string) (string, error) {
+	if strings.Contains(value, "{{") {
+		tmpl, err := template.New(key).Option("missingkey=error").Funcs(template.FuncMap{
+			"env": os.Getenv,
+		}).Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("config item '%s' has an invalid template: %v", key, err)
+		}
+		hostname, _ := os.Hostname()
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, templateData{Hostname: hostname}); err != nil {
+			return "", fmt.Errorf("config item '%s' could not be resolved: %v", key, err)
+		}
+		value = buf.String()
+	}
+
+	var missing []string
+	value = envVarRegexp.ReplaceAllStringFunc(value, func(m string) string {
+		name := envVarRegexp.FindStringSubmatch(m)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("config item '%s' references undefined environment variable(s): %s", key, strings.Join(missing, ", "))
+	}
+	return value, nil
+}
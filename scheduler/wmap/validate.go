@@ -0,0 +1,534 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/snap/pkg/versions"
+)
+
+// ValidationError points to the node of a workflow map which is not valid
+type ValidationError struct {
+	// Path to the node, e.g. workflow.collect.process[0].publish[1]
+	Path   string `json:"path"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%s: '%s' %s", e.Path, e.Field, e.Reason)
+}
+
+type validator struct {
+	errs []*ValidationError
+}
+
+// Validate checks a workflow map given in JSON and reports every problem
+// found along with the node it was found in.
+func Validate(data []byte) []*ValidationError {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []*ValidationError{{Path: "workflow", Reason: fmt.Sprintf("is not valid JSON: %v", err)}}
+	}
+	v := &validator{}
+	v.workflow("workflow", raw)
+	if len(v.errs) == 0 {
+		// catch what only converting the workflow map detects, such as
+		// references to undefined config blocks
+		if _, err := FromJson(data); err != nil {
+			v.fail("workflow", "", err.Error())
+		}
+	}
+	sort.Sort(byPath(v.errs))
+	return v.errs
+}
+
+type byPath []*ValidationError
+
+func (b byPath) Len() int      { return len(b) }
+func (b byPath) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byPath) Less(i, j int) bool {
+	if b[i].Path == b[j].Path {
+		return b[i].Field < b[j].Field
+	}
+	return b[i].Path < b[j].Path
+}
+
+func (v *validator) fail(p, field, reason string) {
+	v.errs = append(v.errs, &ValidationError{Path: p, Field: field, Reason: reason})
+}
+
+func (v *validator) object(p, field string, raw interface{}) (map[string]interface{}, bool) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		v.fail(p, field, "must be an object")
+	}
+	return m, ok
+}
+
+func (v *validator) array(p, field string, raw interface{}) ([]interface{}, bool) {
+	a, ok := raw.([]interface{})
+	if !ok {
+		v.fail(p, field, "must be a list")
+	}
+	return a, ok
+}
+
+func (v *validator) str(p, field string, raw interface{}) (string, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		v.fail(p, field, "must be a string")
+	}
+	return s, ok
+}
+
+func (v *validator) integer(p, field string, raw interface{}, min int) {
+	f, ok := raw.(float64)
+	if !ok || f != float64(int(f)) {
+		v.fail(p, field, "must be an integer")
+		return
+	}
+	if int(f) < min {
+		v.fail(p, field, fmt.Sprintf("must not be lower than %d", min))
+	}
+}
+
+// version checks a version given either as an integer or as the latest keyword
+func (v *validator) version(p, field string, raw interface{}, min int) {
+	if s, ok := raw.(string); ok {
+		if s != LatestVersionKeyword {
+			v.fail(p, field, fmt.Sprintf("must be an integer or '%s'", LatestVersionKeyword))
+		}
+		return
+	}
+	v.integer(p, field, raw, min)
+}
+
+func (v *validator) stringMap(p, field string, raw interface{}) {
+	m, ok := v.object(p, field, raw)
+	if !ok {
+		return
+	}
+	for k, val := range m {
+		if _, ok := val.(string); !ok {
+			v.fail(p, field+"."+k, "must be a string")
+		}
+	}
+}
+
+func (v *validator) workflow(p string, raw interface{}) {
+	w, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	if ref, ok := w["$ref"]; ok {
+		if s, ok := v.str(p, "$ref", ref); ok && s == "" {
+			v.fail(p, "$ref", "must not be empty")
+		}
+		for k := range w {
+			if k != "$ref" && k != "schema_version" {
+				v.fail(p, k, "cannot be set on a workflow referencing a stored workflow")
+			}
+		}
+		return
+	}
+	if uri, ok := w["uri"]; ok {
+		if s, ok := v.str(p, "uri", uri); ok {
+			if err := CheckWorkflowURI(s); err != nil {
+				v.fail(p, "uri", err.Error())
+			}
+		}
+		for k := range w {
+			if k != "uri" && k != "schema_version" {
+				v.fail(p, k, "cannot be set on a workflow fetched from a URI")
+			}
+		}
+		return
+	}
+	if _, ok := w["collect"]; !ok {
+		v.fail(p, "collect", "is required")
+	}
+	for k, val := range w {
+		switch k {
+		case "schema_version":
+			v.integer(p, k, val, 1)
+			if f, ok := val.(float64); ok && int(f) > SchemaVersion {
+				v.fail(p, k, fmt.Sprintf("is not supported, the latest version is %d", SchemaVersion))
+			}
+		case "tags":
+			v.stringMap(p, k, val)
+		case "pin_versions":
+			if _, ok := val.(bool); !ok {
+				v.fail(p, k, "must be a boolean")
+			}
+		case "defs":
+			if defs, ok := v.object(p, k, val); ok {
+				for name, def := range defs {
+					v.config(p+".defs", name, def)
+				}
+			}
+		case "collect":
+			v.collect(p+".collect", val)
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+}
+
+func (v *validator) collect(p string, raw interface{}) {
+	c, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	for k, val := range c {
+		switch k {
+		case "metrics":
+			metrics, ok := v.object(p, k, val)
+			if !ok {
+				break
+			}
+			if _, hasQuery := c["query"]; len(metrics) == 0 && !hasQuery {
+				v.fail(p, k, "must contain at least one metric")
+			}
+			for ns, info := range metrics {
+				if !strings.HasPrefix(ns, "/") {
+					v.fail(p+".metrics", ns, "must be a namespace starting with '/'")
+				}
+				v.metricInfo(p+".metrics."+ns, info)
+			}
+		case "config":
+			if cfg, ok := v.object(p, k, val); ok {
+				for ns, items := range cfg {
+					v.config(p+".config", ns, items)
+				}
+			}
+		case "tags":
+			if tags, ok := v.object(p, k, val); ok {
+				for ns, t := range tags {
+					v.stringMap(p+".tags", ns, t)
+				}
+			}
+		case "process":
+			v.processNodes(p, val)
+		case "publish":
+			v.publishNodes(p, val)
+		case "query":
+			v.query(p+".query", val)
+		case "timeout":
+			v.timeout(p, k, val)
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+	_, hasMetrics := c["metrics"]
+	_, hasQuery := c["query"]
+	if !hasMetrics && !hasQuery {
+		v.fail(p, "metrics", "is required unless a query is given")
+	}
+}
+
+func (v *validator) query(p string, raw interface{}) {
+	q, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	for k, val := range q {
+		switch k {
+		case "namespace":
+			ns, ok := val.(string)
+			if !ok || !strings.HasPrefix(ns, "/") {
+				v.fail(p, k, "must be a namespace starting with '/'")
+			}
+		case "version":
+			v.version(p, k, val, 0)
+		case "tags":
+			v.stringMap(p, k, val)
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+	if _, ok := q["namespace"]; !ok {
+		v.fail(p, "namespace", "is required")
+	}
+}
+
+func (v *validator) metricInfo(p string, raw interface{}) {
+	info, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	for k, val := range info {
+		switch k {
+		case "version":
+			v.version(p, k, val, 0)
+		case "every":
+			v.integer(p, k, val, 1)
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+}
+
+// config checks a config block whose values must be strings, numbers,
+// booleans or secret references, and whose reference to named blocks must be
+// a name or names.
+func (v *validator) config(p, field string, raw interface{}) {
+	cfg, ok := v.object(p, field, raw)
+	if !ok {
+		return
+	}
+	for k, val := range cfg {
+		if k == ConfigRefKey {
+			switch ref := val.(type) {
+			case string:
+			case []interface{}:
+				for _, n := range ref {
+					if _, ok := n.(string); !ok {
+						v.fail(p+"."+field, k, "must be a name or a list of names")
+						break
+					}
+				}
+			default:
+				v.fail(p+"."+field, k, "must be a name or a list of names")
+			}
+			continue
+		}
+		switch val := val.(type) {
+		case string, float64, bool:
+		case map[string]interface{}:
+			if _, ok := secretRef(val); !ok {
+				v.fail(p+"."+field, k, "must be a string, a number, a boolean or a secret reference")
+			}
+		default:
+			v.fail(p+"."+field, k, "must be a string, a number, a boolean or a secret reference")
+		}
+	}
+}
+
+func (v *validator) processNodes(p string, raw interface{}) {
+	nodes, ok := v.array(p, "process", raw)
+	if !ok {
+		return
+	}
+	for i, n := range nodes {
+		v.processNode(fmt.Sprintf("%s.process[%d]", p, i), n)
+	}
+}
+
+func (v *validator) publishNodes(p string, raw interface{}) {
+	nodes, ok := v.array(p, "publish", raw)
+	if !ok {
+		return
+	}
+	for i, n := range nodes {
+		v.publishNode(fmt.Sprintf("%s.publish[%d]", p, i), n)
+	}
+}
+
+func (v *validator) processNode(p string, raw interface{}) {
+	n, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	_, hasName := n["plugin_name"]
+	_, hasFilter := n["filter"]
+	_, hasSample := n["sample"]
+	switch {
+	case hasFilter && hasName:
+		v.fail(p, "plugin_name", "cannot be set on a filter node")
+	case hasSample && hasName:
+		v.fail(p, "plugin_name", "cannot be set on a sample node")
+	case hasFilter && hasSample:
+		v.fail(p, "sample", "cannot be set on a filter node")
+	case !hasFilter && !hasSample && !hasName:
+		v.fail(p, "plugin_name", "is required")
+	}
+	if _, ok := n["content_type"]; ok && (hasFilter || hasSample) {
+		v.fail(p, "content_type", "cannot be set on a filter or sample node")
+	}
+	for k, val := range n {
+		switch k {
+		case "filter":
+			v.filter(p+".filter", val)
+		case "sample":
+			v.sample(p+".sample", val)
+		case "process":
+			v.processNodes(p, val)
+		case "publish":
+			v.publishNodes(p, val)
+		default:
+			v.pluginNodeField(p, k, val)
+		}
+	}
+}
+
+func (v *validator) publishNode(p string, raw interface{}) {
+	n, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	if _, ok := n["plugin_name"]; !ok {
+		v.fail(p, "plugin_name", "is required")
+	}
+	for k, val := range n {
+		switch k {
+		case "batch":
+			v.batch(p+".batch", val)
+		case "dead_letter":
+			v.publishNode(p+".dead_letter", val)
+		default:
+			v.pluginNodeField(p, k, val)
+		}
+	}
+}
+
+func (v *validator) batch(p string, raw interface{}) {
+	b, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	if len(b) == 0 {
+		v.fail(p, "max_metrics", "one of max_metrics, max_bytes and flush_interval must be set")
+	}
+	for k, val := range b {
+		switch k {
+		case "max_metrics", "max_bytes":
+			v.integer(p, k, val, 1)
+		case "flush_interval":
+			if s, ok := v.str(p, k, val); ok {
+				if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+					v.fail(p, k, "must be a positive duration such as 30s")
+				}
+			}
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+}
+
+// pluginNodeField checks a field shared by process and publish nodes
+func (v *validator) pluginNodeField(p, k string, val interface{}) {
+	switch k {
+	case "plugin_name":
+		if s, ok := v.str(p, k, val); ok && s == "" {
+			v.fail(p, k, "must not be empty")
+		}
+	case "plugin_version":
+		v.version(p, k, val, -1)
+	case "plugin_version_constraint":
+		if s, ok := v.str(p, k, val); ok {
+			if _, err := versions.ParseConstraint(s); err != nil {
+				v.fail(p, k, "must be comparisons to versions such as '>=3, <5'")
+			}
+		}
+	case "config":
+		v.config(p, k, val)
+	case "target":
+		v.str(p, k, val)
+	case "retries":
+		v.integer(p, k, val, 0)
+	case "retry_delay":
+		if s, ok := v.str(p, k, val); ok {
+			if _, err := time.ParseDuration(s); err != nil {
+				v.fail(p, k, "must be a duration such as 5s")
+			}
+		}
+	case "content_type":
+		if s, ok := v.str(p, k, val); ok && s == "" {
+			v.fail(p, k, "must not be empty")
+		}
+	case "timeout":
+		v.timeout(p, k, val)
+	default:
+		v.fail(p, k, "is not a known field")
+	}
+}
+
+// timeout checks the timeout of a node, overriding the one of its plugins
+func (v *validator) timeout(p, field string, raw interface{}) {
+	if s, ok := v.str(p, field, raw); ok {
+		if d, err := time.ParseDuration(s); err != nil || d <= 0 {
+			v.fail(p, field, "must be a positive duration such as 30s")
+		}
+	}
+}
+
+func (v *validator) sample(p string, raw interface{}) {
+	s, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	for k, val := range s {
+		switch k {
+		case "every":
+			v.integer(p, k, val, 1)
+		case "interval":
+			if str, ok := v.str(p, k, val); ok {
+				if d, err := time.ParseDuration(str); err != nil || d <= 0 {
+					v.fail(p, k, "must be a positive duration such as 5m")
+				}
+			}
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+	_, hasEvery := s["every"]
+	_, hasInterval := s["interval"]
+	if hasEvery == hasInterval {
+		v.fail(p, "every", "exactly one of every and interval must be set")
+	}
+}
+
+func (v *validator) filter(p string, raw interface{}) {
+	f, ok := v.object(p, "", raw)
+	if !ok {
+		return
+	}
+	for k, val := range f {
+		switch k {
+		case "include", "exclude":
+			globs, ok := v.array(p, k, val)
+			if !ok {
+				break
+			}
+			for i, g := range globs {
+				field := fmt.Sprintf("%s[%d]", k, i)
+				if s, ok := v.str(p, field, g); ok {
+					if _, err := path.Match(s, ""); err != nil {
+						v.fail(p, field, "is not a valid glob")
+					}
+				}
+			}
+		case "tags":
+			v.stringMap(p, k, val)
+		default:
+			v.fail(p, k, "is not a known field")
+		}
+	}
+}
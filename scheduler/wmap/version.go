@@ -0,0 +1,166 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wmap
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/intelsdi-x/snap/pkg/versions"
+)
+
+const (
+	// LatestVersion selects the latest version of a plugin or metric loaded
+	// at the time the subscriptions of a task are resolved
+	LatestVersion = -1
+	// LatestVersionKeyword is written in place of a version to select the
+	// latest one
+	LatestVersionKeyword = "latest"
+)
+
+var (
+	// versionKeys are the keys of the versions of metrics and plugins in
+	// workflow maps
+	versionKeys = map[string]bool{"version": true, "plugin_version": true}
+	// userKeys hold values given to plugins or metrics, whose keys are not
+	// looked at for versions
+	userKeys = map[string]bool{"config": true, "tags": true, "defs": true}
+)
+
+// unmarshalVersion parses a version given either as an integer or as the
+// latest keyword
+func unmarshalVersion(data []byte, v *int) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != LatestVersionKeyword {
+			return fmt.Errorf("version must be an integer or '%s'", LatestVersionKeyword)
+		}
+		*v = LatestVersion
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// replaceLatestKeyword replaces the latest keyword with LatestVersion in a
+// workflow map decoded from YAML, which is not parsed by unmarshalVersion
+func replaceLatestKeyword(raw interface{}) {
+	switch n := raw.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range n {
+			key, _ := k.(string)
+			switch {
+			case versionKeys[key] && v == LatestVersionKeyword:
+				n[k] = LatestVersion
+			case !userKeys[key]:
+				replaceLatestKeyword(v)
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			replaceLatestKeyword(v)
+		}
+	}
+}
+
+// Pin returns a copy of the workflow map with the latest version of the
+// metrics and of the local process and publish plugins replaced by the
+// versions given by the resolvers, so loading newer versions does not change
+// the workflow. Catalog queries and plugins on remote targets are not pinned.
+func (w *WorkflowMap) Pin(pluginVersion func(typeName, name string) (int, error), metricVersion func(ns []string) (int, error)) (*WorkflowMap, error) {
+	pinned, err := w.Copy()
+	if err != nil {
+		return nil, err
+	}
+	c := pinned.CollectNode
+	if c == nil {
+		return pinned, nil
+	}
+	for ns, m := range c.Metrics {
+		if m.Version_ > 0 {
+			continue
+		}
+		v, err := metricVersion(splitNamespace(ns))
+		if err != nil {
+			return nil, fmt.Errorf("metric '%s' cannot be pinned: %v", ns, err)
+		}
+		m.Version_ = v
+		c.Metrics[ns] = m
+	}
+	if err := pinProcessNodes(c.ProcessNodes, pluginVersion); err != nil {
+		return nil, err
+	}
+	if err := pinPublishNodes(c.PublishNodes, pluginVersion); err != nil {
+		return nil, err
+	}
+	return pinned, nil
+}
+
+func pinProcessNodes(nodes []ProcessWorkflowMapNode, pluginVersion func(string, string) (int, error)) error {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Filter == nil && n.Sample == nil && n.Target == "" && n.Version < 1 {
+			v, err := pluginVersion("processor", n.Name)
+			if err != nil {
+				return fmt.Errorf("processor '%s' cannot be pinned: %v", n.Name, err)
+			}
+			n.Version = v
+		}
+		if err := pinProcessNodes(n.ProcessNodes, pluginVersion); err != nil {
+			return err
+		}
+		if err := pinPublishNodes(n.PublishNodes, pluginVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pinPublishNodes(nodes []PublishWorkflowMapNode, pluginVersion func(string, string) (int, error)) error {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Target == "" && n.Version < 1 {
+			v, err := pluginVersion("publisher", n.Name)
+			if err != nil {
+				return fmt.Errorf("publisher '%s' cannot be pinned: %v", n.Name, err)
+			}
+			n.Version = v
+		}
+		if n.DeadLetter != nil {
+			dl := []PublishWorkflowMapNode{*n.DeadLetter}
+			if err := pinPublishNodes(dl, pluginVersion); err != nil {
+				return err
+			}
+			n.DeadLetter = &dl[0]
+		}
+	}
+	return nil
+}
+
+// unmarshalVersionConstraint parses a plugin version constraint, rejecting
+// those which are not valid
+func unmarshalVersionConstraint(data []byte, c *string) error {
+	if err := json.Unmarshal(data, c); err != nil {
+		return err
+	}
+	if _, err := versions.ParseConstraint(*c); err != nil {
+		return err
+	}
+	return nil
+}
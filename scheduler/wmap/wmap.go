@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
@@ -32,8 +33,19 @@ import (
 	"github.com/intelsdi-x/snap/pkg/stringutils"
 )
 
+// SchemaVersion is the latest version of the workflow map schema
+const SchemaVersion = 1
+
 var (
 	InvalidPayload = errors.New("Payload to convert must be string or []byte")
+	// ErrUnsupportedSchemaVersion is returned for workflow maps written against a newer schema
+	ErrUnsupportedSchemaVersion = fmt.Errorf("Unsupported workflow schema version, the latest supported is %d", SchemaVersion)
+	// ErrRefWithWorkflow is returned when a workflow referencing a stored workflow defines anything else
+	ErrRefWithWorkflow = errors.New("A workflow referencing a stored workflow with '$ref' cannot define anything else")
+	// ErrURIWithWorkflow is returned when a workflow fetched from a URI defines anything else
+	ErrURIWithWorkflow = errors.New("A workflow fetched from a URI with 'uri' cannot define anything else")
+	// ErrUnsupportedWorkflowURI is returned for workflow URIs which are not http, https or file URIs
+	ErrUnsupportedWorkflowURI = errors.New("Workflow URI must be an http, https or file URI")
 )
 
 func FromYaml(payload interface{}) (*WorkflowMap, error) {
@@ -42,11 +54,29 @@ func FromYaml(payload interface{}) (*WorkflowMap, error) {
 		return nil, err
 	}
 
+	// versions given with the latest keyword are replaced beforehand
+	var raw interface{}
+	if err := yaml.Unmarshal(p, &raw); err != nil {
+		return nil, err
+	}
+	replaceLatestKeyword(raw)
+	if p, err = yaml.Marshal(raw); err != nil {
+		return nil, err
+	}
 	wmap := new(WorkflowMap)
 	err = yaml.Unmarshal(p, wmap)
 	if err != nil {
 		return nil, err
 	}
+	if wmap.SchemaVersion > SchemaVersion {
+		return nil, ErrUnsupportedSchemaVersion
+	}
+	if err := wmap.checkRef(); err != nil {
+		return nil, err
+	}
+	if err := wmap.resolveDefs(); err != nil {
+		return nil, err
+	}
 	return wmap, nil
 }
 
@@ -130,7 +160,24 @@ func Sample() *WorkflowMap {
 
 // A map of a desired workflow that is used to create a scheduleWorkflow
 type WorkflowMap struct {
-	CollectNode *CollectWorkflowMapNode `json:"collect"yaml:"collect"`
+	// Tags are attached to every metric collected by the workflow
+	Tags map[string]string `json:"tags,omitempty"yaml:"tags"`
+	// Defs holds named config blocks which can be referenced from the config
+	// of any node with the "$ref" key
+	Defs        map[string]map[string]interface{} `json:"defs,omitempty"yaml:"defs"`
+	CollectNode *CollectWorkflowMapNode           `json:"collect"yaml:"collect"`
+	// SchemaVersion is the version of the schema the workflow map is written
+	// against, unversioned workflow maps use the first version
+	SchemaVersion int `json:"schema_version,omitempty"yaml:"schema_version"`
+	// Ref names a workflow stored in the scheduler which is used in place
+	// of this one
+	Ref string `json:"$ref,omitempty"yaml:"$ref"`
+	// PinVersions replaces the latest version of metrics and plugins with
+	// the versions loaded when the task is created
+	PinVersions bool `json:"pin_versions,omitempty"yaml:"pin_versions"`
+	// URI locates a workflow map fetched by the scheduler which is used in
+	// place of this one
+	URI string `json:"uri,omitempty"yaml:"uri"`
 }
 
 func (w *WorkflowMap) UnmarshalJSON(data []byte) error {
@@ -140,14 +187,79 @@ func (w *WorkflowMap) UnmarshalJSON(data []byte) error {
 	}
 	for k, v := range t {
 		switch k {
+		case "schema_version":
+			if err := json.Unmarshal(v, &w.SchemaVersion); err != nil {
+				return fmt.Errorf("%v (while parsing 'schema_version')", err)
+			}
+			if w.SchemaVersion > SchemaVersion {
+				return ErrUnsupportedSchemaVersion
+			}
+		case "tags":
+			if err := json.Unmarshal(v, &w.Tags); err != nil {
+				return fmt.Errorf("%v (while parsing 'tags')", err)
+			}
+		case "defs":
+			if err := json.Unmarshal(v, &w.Defs); err != nil {
+				return fmt.Errorf("%v (while parsing 'defs')", err)
+			}
 		case "collect":
 			if err := json.Unmarshal(v, &w.CollectNode); err != nil {
 				return err
 			}
+		case "$ref":
+			if err := json.Unmarshal(v, &w.Ref); err != nil {
+				return fmt.Errorf("%v (while parsing '$ref')", err)
+			}
+		case "pin_versions":
+			if err := json.Unmarshal(v, &w.PinVersions); err != nil {
+				return fmt.Errorf("%v (while parsing 'pin_versions')", err)
+			}
+		case "uri":
+			if err := json.Unmarshal(v, &w.URI); err != nil {
+				return fmt.Errorf("%v (while parsing 'uri')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in workflow of task.", k)
 		}
 	}
+	if err := w.checkRef(); err != nil {
+		return err
+	}
+	return w.resolveDefs()
+}
+
+func (w *WorkflowMap) checkRef() error {
+	if w.Ref != "" && (w.CollectNode != nil || w.Tags != nil || w.Defs != nil || w.PinVersions || w.URI != "") {
+		return ErrRefWithWorkflow
+	}
+	if w.URI != "" {
+		if w.CollectNode != nil || w.Tags != nil || w.Defs != nil || w.PinVersions {
+			return ErrURIWithWorkflow
+		}
+		return CheckWorkflowURI(w.URI)
+	}
+	return nil
+}
+
+// CheckWorkflowURI returns an error if the workflow URI is not an http,
+// https or file URI.
+func CheckWorkflowURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return ErrUnsupportedWorkflowURI
+		}
+	case "file":
+		if u.Path == "" {
+			return ErrUnsupportedWorkflowURI
+		}
+	default:
+		return ErrUnsupportedWorkflowURI
+	}
 	return nil
 }
 
@@ -161,6 +273,11 @@ func NewWorkflowMap() *WorkflowMap {
 	return w
 }
 
+// GetTags returns the tags attached to every metric collected by the workflow
+func (w *WorkflowMap) GetTags() map[string]string {
+	return w.Tags
+}
+
 func (w *WorkflowMap) ToJson() ([]byte, error) {
 	return json.Marshal(w)
 }
@@ -175,6 +292,53 @@ type CollectWorkflowMapNode struct {
 	Tags         map[string]map[string]string      `json:"tags,omitempty"yaml:"tags"`
 	ProcessNodes []ProcessWorkflowMapNode          `json:"process,omitempty"yaml:"process"`
 	PublishNodes []PublishWorkflowMapNode          `json:"publish,omitempty"yaml:"publish"`
+	Query        *CatalogQueryWorkflowMapNode      `json:"query,omitempty"yaml:"query"`
+	// Timeout overrides the timeout of the calls to the collector plugins (e.g. "30s")
+	Timeout string `json:"timeout,omitempty"yaml:"timeout"`
+}
+
+// CatalogQueryWorkflowMapNode selects the metrics to collect from the metric
+// catalog instead of listing them. The namespace may contain '*' elements and
+// only metrics carrying all of the given tags are selected. The query is
+// re-evaluated whenever the catalog changes, so metrics exposed by collector
+// plugins loaded after the task was created are picked up automatically.
+type CatalogQueryWorkflowMapNode struct {
+	Namespace string            `json:"namespace"yaml:"namespace"`
+	Version   int               `json:"version,omitempty"yaml:"version"`
+	Tags      map[string]string `json:"tags,omitempty"yaml:"tags"`
+}
+
+func (q *CatalogQueryWorkflowMapNode) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "namespace":
+			if err := json.Unmarshal(v, &q.Namespace); err != nil {
+				return fmt.Errorf("%v (while parsing 'namespace')", err)
+			}
+		case "version":
+			if err := unmarshalVersion(v, &q.Version); err != nil {
+				return fmt.Errorf("%v (while parsing 'version')", err)
+			}
+		case "tags":
+			if err := json.Unmarshal(v, &q.Tags); err != nil {
+				return fmt.Errorf("%v (while parsing 'tags')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in query of collect workflow of task.", k)
+		}
+	}
+	return nil
+}
+
+// GetNamespace returns the elements of the queried namespace
+func (q *CatalogQueryWorkflowMapNode) GetNamespace() []string {
+	firstChar := stringutils.GetFirstChar(q.Namespace)
+	ns := strings.Trim(q.Namespace, firstChar)
+	return strings.Split(ns, firstChar)
 }
 
 func (cw *CollectWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -204,6 +368,14 @@ func (cw *CollectWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &cw.PublishNodes); err != nil {
 				return err
 			}
+		case "query":
+			if err := json.Unmarshal(v, &cw.Query); err != nil {
+				return fmt.Errorf("%v (while parsing 'query')", err)
+			}
+		case "timeout":
+			if err := json.Unmarshal(v, &cw.Timeout); err != nil {
+				return fmt.Errorf("%v (while parsing 'timeout')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in collect workflow of task.", k)
 		}
@@ -215,19 +387,24 @@ func (c *CollectWorkflowMapNode) GetMetrics() []Metric {
 	metrics := make([]Metric, len(c.Metrics))
 	i := 0
 	for k, v := range c.Metrics {
-		// Identify the character to split on by peaking
-		// at the first character of each metric.
-		firstChar := stringutils.GetFirstChar(k)
-		ns := strings.Trim(k, firstChar)
 		metrics[i] = Metric{
-			namespace: strings.Split(ns, firstChar),
+			namespace: splitNamespace(k),
 			version:   v.Version_,
+			every:     v.Every,
 		}
 		i++
 	}
 	return metrics
 }
 
+// splitNamespace splits a namespace of the metrics of a collect node
+func splitNamespace(ns string) []string {
+	// Identify the character to split on by peaking
+	// at the first character of each metric.
+	firstChar := stringutils.GetFirstChar(ns)
+	return strings.Split(strings.Trim(ns, firstChar), firstChar)
+}
+
 func (c *CollectWorkflowMapNode) GetTags() map[string]map[string]string {
 	return c.Tags
 }
@@ -289,6 +466,24 @@ type ProcessWorkflowMapNode struct {
 	// TODO processor config
 	Config map[string]interface{} `json:"config,omitempty"yaml:"config"`
 	Target string                 `json:"target"yaml:"target"`
+	// Retries is the number of times a failed process job is retried
+	Retries int `json:"retries,omitempty"yaml:"retries"`
+	// RetryDelay is the duration waited before retrying (e.g. "5s")
+	RetryDelay string `json:"retry_delay,omitempty"yaml:"retry_delay"`
+	// Timeout overrides the timeout of the call to the processor plugin (e.g. "30s")
+	Timeout string `json:"timeout,omitempty"yaml:"timeout"`
+	// ContentType forces the content type of the metrics sent to the plugin
+	// when it accepts several (e.g. "snap.json")
+	ContentType string `json:"content_type,omitempty"yaml:"content_type"`
+	// Filter makes this node a built-in filter executed by the workflow
+	// engine instead of a processor plugin
+	Filter *FilterWorkflowMapNode `json:"filter,omitempty"yaml:"filter"`
+	// Sample makes this node a built-in sampler forwarding only some of
+	// the task runs to its child nodes
+	Sample *SampleWorkflowMapNode `json:"sample,omitempty"yaml:"sample"`
+	// VersionConstraint selects the latest loaded version of the plugin
+	// matching it (e.g. ">=3, <5") in place of Version
+	VersionConstraint string `json:"plugin_version_constraint,omitempty"yaml:"plugin_version_constraint"`
 }
 
 func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -303,9 +498,13 @@ func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
 				return fmt.Errorf("%v (while parsing 'plugin_name')", err)
 			}
 		case "plugin_version":
-			if err := json.Unmarshal(v, &pw.Version); err != nil {
+			if err := unmarshalVersion(v, &pw.Version); err != nil {
 				return fmt.Errorf("%v (while parsing 'plugin_version')", err)
 			}
+		case "plugin_version_constraint":
+			if err := unmarshalVersionConstraint(v, &pw.VersionConstraint); err != nil {
+				return fmt.Errorf("%v (while parsing 'plugin_version_constraint')", err)
+			}
 		case "process":
 			if err := json.Unmarshal(v, &pw.ProcessNodes); err != nil {
 				return err
@@ -322,6 +521,30 @@ func (pw *ProcessWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &pw.Target); err != nil {
 				return fmt.Errorf("%v (while parsing 'target')", err)
 			}
+		case "retries":
+			if err := json.Unmarshal(v, &pw.Retries); err != nil {
+				return fmt.Errorf("%v (while parsing 'retries')", err)
+			}
+		case "retry_delay":
+			if err := json.Unmarshal(v, &pw.RetryDelay); err != nil {
+				return fmt.Errorf("%v (while parsing 'retry_delay')", err)
+			}
+		case "timeout":
+			if err := json.Unmarshal(v, &pw.Timeout); err != nil {
+				return fmt.Errorf("%v (while parsing 'timeout')", err)
+			}
+		case "content_type":
+			if err := json.Unmarshal(v, &pw.ContentType); err != nil {
+				return fmt.Errorf("%v (while parsing 'content_type')", err)
+			}
+		case "filter":
+			if err := json.Unmarshal(v, &pw.Filter); err != nil {
+				return fmt.Errorf("%v (while parsing 'filter')", err)
+			}
+		case "sample":
+			if err := json.Unmarshal(v, &pw.Sample); err != nil {
+				return fmt.Errorf("%v (while parsing 'sample')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in process workflow of task.", k)
 		}
@@ -338,6 +561,30 @@ func NewProcessNode(name string, version int) *ProcessWorkflowMapNode {
 	return p
 }
 
+// NewFilterNode returns a process node which filters metrics in-process,
+// keeping those matching the include globs and tags and dropping those
+// matching the exclude globs.
+func NewFilterNode(include, exclude []string, tags map[string]string) *ProcessWorkflowMapNode {
+	return &ProcessWorkflowMapNode{
+		Filter: &FilterWorkflowMapNode{
+			Include: include,
+			Exclude: exclude,
+			Tags:    tags,
+		},
+	}
+}
+
+// NewSampleNode returns a process node which forwards only every Nth run
+// (every > 0) or at most one run per interval (e.g. "5m") to its child nodes.
+func NewSampleNode(every int, interval string) *ProcessWorkflowMapNode {
+	return &ProcessWorkflowMapNode{
+		Sample: &SampleWorkflowMapNode{
+			Every:    every,
+			Interval: interval,
+		},
+	}
+}
+
 func (p *ProcessWorkflowMapNode) Add(node interface{}) error {
 	switch x := node.(type) {
 	case *ProcessWorkflowMapNode:
@@ -364,12 +611,129 @@ func (p *ProcessWorkflowMapNode) GetConfigNode() (*cdata.ConfigDataNode, error)
 	return configtoConfigDataNode(p.Config, "")
 }
 
+// FilterWorkflowMapNode describes the metrics passed on by a filter node.
+// Include and Exclude hold namespace globs (e.g. "/intel/mock/*") and Tags
+// holds tag values a metric must carry to be passed on.
+type FilterWorkflowMapNode struct {
+	Include []string          `json:"include,omitempty"yaml:"include"`
+	Exclude []string          `json:"exclude,omitempty"yaml:"exclude"`
+	Tags    map[string]string `json:"tags,omitempty"yaml:"tags"`
+}
+
+func (fw *FilterWorkflowMapNode) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "include":
+			if err := json.Unmarshal(v, &fw.Include); err != nil {
+				return fmt.Errorf("%v (while parsing 'include')", err)
+			}
+		case "exclude":
+			if err := json.Unmarshal(v, &fw.Exclude); err != nil {
+				return fmt.Errorf("%v (while parsing 'exclude')", err)
+			}
+		case "tags":
+			if err := json.Unmarshal(v, &fw.Tags); err != nil {
+				return fmt.Errorf("%v (while parsing 'tags')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in filter workflow of task.", k)
+		}
+	}
+	return nil
+}
+
+// SampleWorkflowMapNode describes the runs forwarded by a sample node.
+// Exactly one of Every (forward every Nth run) and Interval (forward at most
+// one run per duration such as "5m") must be set.
+type SampleWorkflowMapNode struct {
+	Every    int    `json:"every,omitempty"yaml:"every"`
+	Interval string `json:"interval,omitempty"yaml:"interval"`
+}
+
+func (sw *SampleWorkflowMapNode) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "every":
+			if err := json.Unmarshal(v, &sw.Every); err != nil {
+				return fmt.Errorf("%v (while parsing 'every')", err)
+			}
+		case "interval":
+			if err := json.Unmarshal(v, &sw.Interval); err != nil {
+				return fmt.Errorf("%v (while parsing 'interval')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in sample workflow of task.", k)
+		}
+	}
+	return nil
+}
+
+// BatchWorkflowMapNode describes when the metrics buffered by a publish node
+// are published: once MaxMetrics metrics or MaxBytes bytes are buffered, or
+// FlushInterval (e.g. "30s") after the first metric was buffered. At least
+// one of them must be set.
+type BatchWorkflowMapNode struct {
+	MaxMetrics    int    `json:"max_metrics,omitempty"yaml:"max_metrics"`
+	MaxBytes      int    `json:"max_bytes,omitempty"yaml:"max_bytes"`
+	FlushInterval string `json:"flush_interval,omitempty"yaml:"flush_interval"`
+}
+
+func (bw *BatchWorkflowMapNode) UnmarshalJSON(data []byte) error {
+	t := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	for k, v := range t {
+		switch k {
+		case "max_metrics":
+			if err := json.Unmarshal(v, &bw.MaxMetrics); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_metrics')", err)
+			}
+		case "max_bytes":
+			if err := json.Unmarshal(v, &bw.MaxBytes); err != nil {
+				return fmt.Errorf("%v (while parsing 'max_bytes')", err)
+			}
+		case "flush_interval":
+			if err := json.Unmarshal(v, &bw.FlushInterval); err != nil {
+				return fmt.Errorf("%v (while parsing 'flush_interval')", err)
+			}
+		default:
+			return fmt.Errorf("Unrecognized key '%v' in batch workflow of task.", k)
+		}
+	}
+	return nil
+}
+
 type PublishWorkflowMapNode struct {
 	Name    string `json:"plugin_name"yaml:"plugin_name"`
 	Version int    `json:"plugin_version"yaml:"plugin_version"`
 	// TODO publisher config
 	Config map[string]interface{} `json:"config,omitempty"yaml:"config"`
 	Target string                 `json:"target"yaml:"target"`
+	// Retries is the number of times a failed publish job is retried
+	Retries int `json:"retries,omitempty"yaml:"retries"`
+	// RetryDelay is the duration waited before retrying (e.g. "5s")
+	RetryDelay string `json:"retry_delay,omitempty"yaml:"retry_delay"`
+	// Timeout overrides the timeout of the call to the publisher plugin (e.g. "30s")
+	Timeout string `json:"timeout,omitempty"yaml:"timeout"`
+	// ContentType forces the content type of the metrics sent to the plugin
+	// when it accepts several (e.g. "snap.json")
+	ContentType string `json:"content_type,omitempty"yaml:"content_type"`
+	// Batch buffers the metrics of several runs before publishing them
+	Batch *BatchWorkflowMapNode `json:"batch,omitempty"yaml:"batch"`
+	// DeadLetter receives the metrics this node failed to publish
+	DeadLetter *PublishWorkflowMapNode `json:"dead_letter,omitempty"yaml:"dead_letter"`
+	// VersionConstraint selects the latest loaded version of the plugin
+	// matching it (e.g. ">=3, <5") in place of Version
+	VersionConstraint string `json:"plugin_version_constraint,omitempty"yaml:"plugin_version_constraint"`
 }
 
 func (pw *PublishWorkflowMapNode) UnmarshalJSON(data []byte) error {
@@ -384,9 +748,13 @@ func (pw *PublishWorkflowMapNode) UnmarshalJSON(data []byte) error {
 				return fmt.Errorf("%v (while parsing 'plugin_name')", err)
 			}
 		case "plugin_version":
-			if err := json.Unmarshal(v, &pw.Version); err != nil {
+			if err := unmarshalVersion(v, &pw.Version); err != nil {
 				return fmt.Errorf("%v (while parsing 'plugin_version')", err)
 			}
+		case "plugin_version_constraint":
+			if err := unmarshalVersionConstraint(v, &pw.VersionConstraint); err != nil {
+				return fmt.Errorf("%v (while parsing 'plugin_version_constraint')", err)
+			}
 		case "config":
 			if err := json.Unmarshal(v, &pw.Config); err != nil {
 				return fmt.Errorf("%v (while parsing 'config')", err)
@@ -395,6 +763,30 @@ func (pw *PublishWorkflowMapNode) UnmarshalJSON(data []byte) error {
 			if err := json.Unmarshal(v, &pw.Target); err != nil {
 				return fmt.Errorf("%v (while parsing 'target')", err)
 			}
+		case "retries":
+			if err := json.Unmarshal(v, &pw.Retries); err != nil {
+				return fmt.Errorf("%v (while parsing 'retries')", err)
+			}
+		case "retry_delay":
+			if err := json.Unmarshal(v, &pw.RetryDelay); err != nil {
+				return fmt.Errorf("%v (while parsing 'retry_delay')", err)
+			}
+		case "timeout":
+			if err := json.Unmarshal(v, &pw.Timeout); err != nil {
+				return fmt.Errorf("%v (while parsing 'timeout')", err)
+			}
+		case "content_type":
+			if err := json.Unmarshal(v, &pw.ContentType); err != nil {
+				return fmt.Errorf("%v (while parsing 'content_type')", err)
+			}
+		case "batch":
+			if err := json.Unmarshal(v, &pw.Batch); err != nil {
+				return fmt.Errorf("%v (while parsing 'batch')", err)
+			}
+		case "dead_letter":
+			if err := json.Unmarshal(v, &pw.DeadLetter); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in publish workflow of task.", k)
 		}
@@ -426,6 +818,8 @@ func (p *PublishWorkflowMapNode) GetConfigNode() (*cdata.ConfigDataNode, error)
 
 type metricInfo struct {
 	Version_ int `json:"version"yaml:"version"`
+	// Every collects the metric on every nth firing of the task only
+	Every int `json:"every,omitempty"yaml:"every"`
 }
 
 func (m *metricInfo) UnmarshalJSON(data []byte) error {
@@ -436,9 +830,13 @@ func (m *metricInfo) UnmarshalJSON(data []byte) error {
 	for k, v := range t {
 		switch k {
 		case "version":
-			if err := json.Unmarshal(v, &m.Version_); err != nil {
+			if err := unmarshalVersion(v, &m.Version_); err != nil {
 				return fmt.Errorf("%v (while parsing 'version')", err)
 			}
+		case "every":
+			if err := json.Unmarshal(v, &m.Every); err != nil {
+				return fmt.Errorf("%v (while parsing 'every')", err)
+			}
 		default:
 			return fmt.Errorf("Unrecognized key '%v' in metrics in collect workflow of task", k)
 		}
@@ -449,6 +847,7 @@ func (m *metricInfo) UnmarshalJSON(data []byte) error {
 type Metric struct {
 	namespace []string
 	version   int
+	every     int
 }
 
 func (m Metric) Namespace() []string {
@@ -459,12 +858,22 @@ func (m Metric) Version() int {
 	return m.version
 }
 
+// Every returns the number of firings of the task between two collections of
+// the metric, 0 or 1 when it is collected on every firing.
+func (m Metric) Every() int {
+	return m.every
+}
+
 func configtoConfigDataNode(cmap map[string]interface{}, ns string) (*cdata.ConfigDataNode, error) {
 	cdn := cdata.NewNode()
 	for ck, cv := range cmap {
 		switch v := cv.(type) {
 		case string:
-			cdn.AddItem(ck, ctypes.ConfigValueStr{Value: v})
+			s, err := substituteConfigValue(ck, v)
+			if err != nil {
+				return nil, err
+			}
+			cdn.AddItem(ck, ctypes.ConfigValueStr{Value: s})
 		case int:
 			cdn.AddItem(ck, ctypes.ConfigValueInt{Value: v})
 		case float64:
@@ -477,6 +886,12 @@ func configtoConfigDataNode(cmap map[string]interface{}, ns string) (*cdata.Conf
 			}
 		case bool:
 			cdn.AddItem(ck, ctypes.ConfigValueBool{Value: v})
+		case map[string]interface{}:
+			s, err := resolveSecretValue(ck, v)
+			if err != nil {
+				return nil, err
+			}
+			cdn.AddItem(ck, ctypes.ConfigValueStr{Value: s})
 		default:
 			// TODO make sure this is covered in tests!!!
 			return nil, errors.New(fmt.Sprintf("Cannot convert config value to config data node: %s=>%+v", ns, v))
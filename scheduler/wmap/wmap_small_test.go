@@ -22,12 +22,18 @@ limitations under the License.
 package wmap
 
 import (
+	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/intelsdi-x/snap/core/ctypes"
 	"github.com/intelsdi-x/snap/scheduler/wmap/fixtures"
 )
 
@@ -244,3 +250,607 @@ func TestMetricSeparator(t *testing.T) {
 		})
 	})
 }
+
+func TestConfigValueSubstitution(t *testing.T) {
+	Convey("Resolves placeholders in config values", t, func() {
+		os.Setenv("SNAP_WMAP_TEST_USER", "root")
+		defer os.Unsetenv("SNAP_WMAP_TEST_USER")
+		hostname, _ := os.Hostname()
+
+		Convey("environment variables are substituted", func() {
+			pu := NewPublishNode("file", 1)
+			pu.AddConfigItem("user", "${SNAP_WMAP_TEST_USER}@db")
+			cdn, err := pu.GetConfigNode()
+			So(err, ShouldBeNil)
+			So(cdn.Table()["user"], ShouldResemble, ctypes.ConfigValueStr{Value: "root@db"})
+		})
+		Convey("template placeholders are substituted", func() {
+			pu := NewPublishNode("file", 1)
+			pu.AddConfigItem("file", "/tmp/{{.Hostname}}-{{env \"SNAP_WMAP_TEST_USER\"}}.log")
+			cdn, err := pu.GetConfigNode()
+			So(err, ShouldBeNil)
+			So(cdn.Table()["file"], ShouldResemble, ctypes.ConfigValueStr{Value: "/tmp/" + hostname + "-root.log"})
+		})
+		Convey("environment variable values are not executed as templates", func() {
+			os.Setenv("SNAP_WMAP_TEST_PASSWORD", "p{{.Hostname}}")
+			defer os.Unsetenv("SNAP_WMAP_TEST_PASSWORD")
+			pu := NewPublishNode("file", 1)
+			pu.AddConfigItem("password", "${SNAP_WMAP_TEST_PASSWORD}")
+			cdn, err := pu.GetConfigNode()
+			So(err, ShouldBeNil)
+			So(cdn.Table()["password"], ShouldResemble, ctypes.ConfigValueStr{Value: "p{{.Hostname}}"})
+		})
+		Convey("an undefined environment variable is an error", func() {
+			wmap := NewWorkflowMap()
+			wmap.CollectNode.AddConfigItem("/foo/bar", "password", "${SNAP_WMAP_TEST_UNDEFINED}")
+			_, err := wmap.CollectNode.GetConfigTree()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "SNAP_WMAP_TEST_UNDEFINED")
+		})
+		Convey("an invalid template is an error", func() {
+			pu := NewPublishNode("file", 1)
+			pu.AddConfigItem("file", "{{.Unknown")
+			_, err := pu.GetConfigNode()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWorkflowTags(t *testing.T) {
+	Convey("Workflow level tags", t, func() {
+		wmap, err := FromJson(`{"tags": {"dc": "dc1", "role": "db"}, "collect": {"metrics": {"/foo/bar": {}}}}`)
+		So(err, ShouldBeNil)
+		So(wmap.GetTags(), ShouldResemble, map[string]string{"dc": "dc1", "role": "db"})
+
+		wmap, err = FromYaml("tags:\n  dc: dc1\ncollect:\n  metrics:\n    /foo/bar: {}\n")
+		So(err, ShouldBeNil)
+		So(wmap.GetTags(), ShouldResemble, map[string]string{"dc": "dc1"})
+
+		_, err = FromJson(`{"tags": {"dc": 1}}`)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestWorkflowDefs(t *testing.T) {
+	Convey("Named config blocks", t, func() {
+		Convey("are resolved from json", func() {
+			wmap, err := FromJson(`{
+				"defs": {
+					"influx": {"host": "influx.local", "port": 8086},
+					"creds": {"user": "snap", "password": "s3cr3t"}
+				},
+				"collect": {
+					"metrics": {"/foo/bar": {}},
+					"config": {"/foo": {"$ref": "creds"}},
+					"process": [{
+						"plugin_name": "passthru",
+						"publish": [{"plugin_name": "influxdb", "config": {"$ref": ["influx", "creds"], "port": 8087}}]
+					}]
+				}
+			}`)
+			So(err, ShouldBeNil)
+			So(wmap.CollectNode.Config["/foo"], ShouldResemble, map[string]interface{}{"user": "snap", "password": "s3cr3t"})
+			So(wmap.CollectNode.ProcessNodes[0].PublishNodes[0].Config, ShouldResemble, map[string]interface{}{
+				"host":     "influx.local",
+				"port":     float64(8087),
+				"user":     "snap",
+				"password": "s3cr3t",
+			})
+		})
+		Convey("are resolved from yaml", func() {
+			wmap, err := FromYaml("defs:\n  file:\n    file: /tmp/out\ncollect:\n  metrics:\n    /foo/bar: {}\n  publish:\n    - plugin_name: file\n      config:\n        $ref: file\n")
+			So(err, ShouldBeNil)
+			So(wmap.CollectNode.PublishNodes[0].Config, ShouldResemble, map[string]interface{}{"file": "/tmp/out"})
+		})
+		Convey("must be defined", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "config": {"$ref": "missing"}}]}}`)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing")
+		})
+		Convey("must be referenced by name", func() {
+			_, err := FromJson(`{"defs": {"a": {}}, "collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "config": {"$ref": 1}}]}}`)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestFilterNode(t *testing.T) {
+	Convey("Filter nodes", t, func() {
+		Convey("are parsed from json", func() {
+			wmap, err := FromJson(`{
+				"collect": {
+					"metrics": {"/foo/bar": {}},
+					"process": [{
+						"filter": {"include": ["/foo/*"], "exclude": ["/foo/baz"], "tags": {"dc": "east"}},
+						"publish": [{"plugin_name": "file"}]
+					}]
+				}
+			}`)
+			So(err, ShouldBeNil)
+			f := wmap.CollectNode.ProcessNodes[0].Filter
+			So(f, ShouldNotBeNil)
+			So(f.Include, ShouldResemble, []string{"/foo/*"})
+			So(f.Exclude, ShouldResemble, []string{"/foo/baz"})
+			So(f.Tags, ShouldResemble, map[string]string{"dc": "east"})
+			So(wmap.CollectNode.ProcessNodes[0].PublishNodes, ShouldHaveLength, 1)
+		})
+		Convey("reject unknown keys", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "process": [{"filter": {"match": "/foo"}}]}}`)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestPluginVersionConstraint(t *testing.T) {
+	Convey("Plugin version constraints", t, func() {
+		Convey("are parsed from json", func() {
+			wmap, err := FromJson(`{
+				"collect": {
+					"metrics": {"/foo/bar": {}},
+					"process": [{"plugin_name": "passthru", "plugin_version_constraint": ">1"}],
+					"publish": [{"plugin_name": "file", "plugin_version_constraint": ">=3, <5"}]
+				}
+			}`)
+			So(err, ShouldBeNil)
+			So(wmap.CollectNode.ProcessNodes[0].VersionConstraint, ShouldEqual, ">1")
+			So(wmap.CollectNode.PublishNodes[0].VersionConstraint, ShouldEqual, ">=3, <5")
+		})
+		Convey("must be valid", func() {
+			_, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "plugin_version_constraint": "~3"}]}}`)
+			So(err, ShouldNotBeNil)
+			errs := Validate([]byte(`{"collect": {"metrics": {"/foo/bar": {}}, "publish": [{"plugin_name": "file", "plugin_version_constraint": "3+"}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "plugin_version_constraint")
+		})
+	})
+}
+
+func TestNodeRetries(t *testing.T) {
+	Convey("Retries of process and publish nodes", t, func() {
+		wmap, err := FromJson(`{
+			"collect": {
+				"metrics": {"/foo/bar": {}},
+				"process": [{
+					"plugin_name": "passthru",
+					"retries": 2,
+					"publish": [{"plugin_name": "influxdb", "retries": 3, "retry_delay": "5s"}]
+				}]
+			}
+		}`)
+		So(err, ShouldBeNil)
+		pr := wmap.CollectNode.ProcessNodes[0]
+		So(pr.Retries, ShouldEqual, 2)
+		So(pr.RetryDelay, ShouldBeEmpty)
+		So(pr.PublishNodes[0].Retries, ShouldEqual, 3)
+		So(pr.PublishNodes[0].RetryDelay, ShouldEqual, "5s")
+	})
+}
+
+func TestSchemaVersion(t *testing.T) {
+	Convey("Workflow map schema version", t, func() {
+		Convey("defaults to the first version", func() {
+			wmap, err := FromJson(`{"collect": {"metrics": {"/foo/bar": {}}}}`)
+			So(err, ShouldBeNil)
+			So(wmap.SchemaVersion, ShouldEqual, 0)
+		})
+		Convey("rejects newer versions", func() {
+			_, err := FromJson(`{"schema_version": 99, "collect": {"metrics": {"/foo/bar": {}}}}`)
+			So(err, ShouldEqual, ErrUnsupportedSchemaVersion)
+			_, err = FromYaml("schema_version: 99\ncollect:\n  metrics:\n    /foo/bar: {}\n")
+			So(err, ShouldEqual, ErrUnsupportedSchemaVersion)
+		})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	Convey("Validate a workflow map", t, func() {
+		Convey("a valid workflow has no errors", func() {
+			errs := Validate([]byte(`{
+				"schema_version": 1,
+				"collect": {
+					"metrics": {"/foo/bar": {"version": 1}},
+					"process": [{
+						"filter": {"include": ["/foo/*"]},
+						"publish": [{"plugin_name": "file", "retries": 1, "retry_delay": "1s"}]
+					}]
+				}
+			}`))
+			So(errs, ShouldBeEmpty)
+		})
+		Convey("invalid JSON is reported at the root", func() {
+			errs := Validate([]byte(`{"collect":`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow")
+		})
+		Convey("errors point to the offending node", func() {
+			errs := Validate([]byte(`{
+				"collect": {
+					"metrics": {"/foo/bar": {}},
+					"process": [
+						{"plugin_name": "passthru"},
+						{"plugin_name": "passthru", "publish": [{"retries": -1, "color": "red"}]}
+					]
+				}
+			}`))
+			So(errs, ShouldHaveLength, 3)
+			for _, e := range errs {
+				So(e.Path, ShouldEqual, "workflow.collect.process[1].publish[0]")
+			}
+			So(errs[0].Field, ShouldEqual, "color")
+			So(errs[1].Field, ShouldEqual, "plugin_name")
+			So(errs[2].Field, ShouldEqual, "retries")
+		})
+		Convey("metrics are required", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {}}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow.collect")
+			So(errs[0].Field, ShouldEqual, "metrics")
+		})
+		Convey("filter nodes cannot name a plugin", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"plugin_name": "x", "filter": {"include": ["["]}}]}}`))
+			So(errs, ShouldHaveLength, 2)
+			So(errs[0].Path, ShouldEqual, "workflow.collect.process[0]")
+			So(errs[0].Field, ShouldEqual, "plugin_name")
+			So(errs[1].Path, ShouldEqual, "workflow.collect.process[0].filter")
+			So(errs[1].Field, ShouldEqual, "include[0]")
+		})
+		Convey("node timeouts must be positive durations", func() {
+			errs := Validate([]byte(`{
+				"collect": {
+					"metrics": {"/a": {}},
+					"timeout": "30s",
+					"process": [{"plugin_name": "passthru", "timeout": "5s", "publish": [{"plugin_name": "file", "timeout": "1m"}]}]
+				}
+			}`))
+			So(errs, ShouldBeEmpty)
+			errs = Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "timeout": "0s", "publish": [{"plugin_name": "file", "timeout": "soon"}]}}`))
+			So(errs, ShouldHaveLength, 2)
+			So(errs[0].Path, ShouldEqual, "workflow.collect")
+			So(errs[0].Field, ShouldEqual, "timeout")
+			So(errs[1].Path, ShouldEqual, "workflow.collect.publish[0]")
+			So(errs[1].Field, ShouldEqual, "timeout")
+		})
+		Convey("undefined config references are reported", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "config": {"$ref": "missing"}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow")
+		})
+	})
+}
+
+func TestWorkflowRef(t *testing.T) {
+	Convey("Workflow referencing a stored workflow", t, func() {
+		wmap, err := FromJson(`{"$ref": "cpu-to-influx"}`)
+		So(err, ShouldBeNil)
+		So(wmap.Ref, ShouldEqual, "cpu-to-influx")
+		So(wmap.CollectNode, ShouldBeNil)
+		So(Validate([]byte(`{"$ref": "cpu-to-influx"}`)), ShouldBeEmpty)
+
+		Convey("cannot define anything else", func() {
+			_, err := FromJson(`{"$ref": "cpu-to-influx", "collect": {"metrics": {"/foo/bar": {}}}}`)
+			So(err, ShouldEqual, ErrRefWithWorkflow)
+			errs := Validate([]byte(`{"$ref": "cpu-to-influx", "tags": {}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "tags")
+		})
+	})
+}
+
+func TestCatalogQuery(t *testing.T) {
+	Convey("Collect node with a catalog query", t, func() {
+		wmap, err := FromJson(`{"collect": {"query": {"namespace": "/intel/*/cpu", "version": 2, "tags": {"rack": "r1"}}}}`)
+		So(err, ShouldBeNil)
+		q := wmap.CollectNode.Query
+		So(q, ShouldNotBeNil)
+		So(q.GetNamespace(), ShouldResemble, []string{"intel", "*", "cpu"})
+		So(q.Version, ShouldEqual, 2)
+		So(q.Tags, ShouldResemble, map[string]string{"rack": "r1"})
+		So(wmap.CollectNode.GetMetrics(), ShouldBeEmpty)
+
+		Convey("is valid without metrics", func() {
+			So(Validate([]byte(`{"collect": {"query": {"namespace": "/intel/*"}}}`)), ShouldBeEmpty)
+			So(Validate([]byte(`{"collect": {"metrics": {}, "query": {"namespace": "/intel/*"}}}`)), ShouldBeEmpty)
+		})
+		Convey("requires a namespace", func() {
+			errs := Validate([]byte(`{"collect": {"query": {"tags": {"rack": "r1"}}}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow.collect.query")
+			So(errs[0].Field, ShouldEqual, "namespace")
+		})
+		Convey("rejects unknown keys", func() {
+			_, err := FromJson(`{"collect": {"query": {"namespace": "/intel/*", "glob": true}}}`)
+			So(err, ShouldNotBeNil)
+			errs := Validate([]byte(`{"collect": {"query": {"namespace": "/intel/*", "glob": true}}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "glob")
+		})
+	})
+}
+
+func TestSampleNode(t *testing.T) {
+	Convey("Sample node", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/a": {}}, "process": [{"sample": {"every": 10}, "publish": [{"plugin_name": "file"}]}]}}`)
+		So(err, ShouldBeNil)
+		So(wmap.CollectNode.ProcessNodes[0].Sample, ShouldResemble, &SampleWorkflowMapNode{Every: 10})
+
+		Convey("is valid without a plugin", func() {
+			So(Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"sample": {"interval": "5m"}}]}}`)), ShouldBeEmpty)
+		})
+		Convey("needs exactly one of every and interval", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"sample": {"every": 2, "interval": "5m"}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow.collect.process[0].sample")
+			errs = Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"sample": {"every": 0}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "every")
+		})
+		Convey("cannot name a plugin", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"plugin_name": "x", "sample": {"every": 2}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "plugin_name")
+		})
+	})
+}
+
+func TestConfigPatch(t *testing.T) {
+	Convey("Config patch", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {}}, "config": {"/intel/mock": {"password": "secret"}},
+			"process": [{"plugin_name": "passthru", "publish": [{"plugin_name": "file", "config": {"file": "/tmp/a"}}]}]}}`)
+		So(err, ShouldBeNil)
+		patched, err := wmap.Copy()
+		So(err, ShouldBeNil)
+
+		Convey("sets and removes config items", func() {
+			patch := &ConfigPatch{}
+			So(json.Unmarshal([]byte(`{"collect": {"/intel/mock": {"password": null, "verbosity": 3}},
+				"process": {"passthru": {"ratio": 0.5}}, "publish": {"file": {"file": "/tmp/b"}}}`), patch), ShouldBeNil)
+			So(patched.ApplyConfigPatch(patch), ShouldBeNil)
+			So(patched.CollectNode.Config["/intel/mock"], ShouldResemble, map[string]interface{}{"verbosity": float64(3)})
+			So(patched.CollectNode.ProcessNodes[0].Config["ratio"], ShouldEqual, 0.5)
+			So(patched.CollectNode.ProcessNodes[0].PublishNodes[0].Config["file"], ShouldEqual, "/tmp/b")
+			// the original workflow map is left untouched
+			So(wmap.CollectNode.Config["/intel/mock"]["password"], ShouldEqual, "secret")
+		})
+		Convey("fails for plugins missing from the workflow", func() {
+			err := patched.ApplyConfigPatch(&ConfigPatch{Publish: map[string]map[string]interface{}{"influxdb": {"host": "a"}}})
+			So(err, ShouldNotBeNil)
+		})
+		Convey("must not be empty", func() {
+			So(patched.ApplyConfigPatch(&ConfigPatch{}), ShouldEqual, ErrConfigPatchEmpty)
+		})
+		Convey("rejects unknown keys", func() {
+			So(json.Unmarshal([]byte(`{"tags": {}}`), &ConfigPatch{}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestPublishBatch(t *testing.T) {
+	Convey("Publish node batch", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "batch": {"max_metrics": 100, "flush_interval": "30s"}}]}}`)
+		So(err, ShouldBeNil)
+		So(wmap.CollectNode.PublishNodes[0].Batch, ShouldResemble, &BatchWorkflowMapNode{MaxMetrics: 100, FlushInterval: "30s"})
+
+		Convey("is validated", func() {
+			So(Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "batch": {"max_bytes": 4096}}]}}`)), ShouldBeEmpty)
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "batch": {"flush_interval": "-1s"}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow.collect.publish[0].batch")
+			So(errs[0].Field, ShouldEqual, "flush_interval")
+			So(Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "batch": {}}]}}`)), ShouldHaveLength, 1)
+		})
+	})
+}
+
+func TestPublishDeadLetter(t *testing.T) {
+	Convey("Publish node dead letter", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "influxdb", "dead_letter": {"plugin_name": "file", "config": {"file": "/tmp/failed"}}}]}}`)
+		So(err, ShouldBeNil)
+		dl := wmap.CollectNode.PublishNodes[0].DeadLetter
+		So(dl, ShouldNotBeNil)
+		So(dl.Name, ShouldEqual, "file")
+		So(dl.Config["file"], ShouldEqual, "/tmp/failed")
+
+		Convey("is validated", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "influxdb", "dead_letter": {"config": {}}}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Path, ShouldEqual, "workflow.collect.publish[0].dead_letter")
+			So(errs[0].Field, ShouldEqual, "plugin_name")
+		})
+	})
+}
+
+func TestContentType(t *testing.T) {
+	Convey("Node content type", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/a": {}}, "process": [{"plugin_name": "passthru", "content_type": "snap.gob", "publish": [{"plugin_name": "file", "content_type": "snap.json"}]}]}}`)
+		So(err, ShouldBeNil)
+		So(wmap.CollectNode.ProcessNodes[0].ContentType, ShouldEqual, "snap.gob")
+		So(wmap.CollectNode.ProcessNodes[0].PublishNodes[0].ContentType, ShouldEqual, "snap.json")
+
+		Convey("is validated", func() {
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "content_type": ""}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "content_type")
+			errs = Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "process": [{"filter": {"include": ["/a"]}, "content_type": "snap.gob"}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "content_type")
+		})
+	})
+}
+
+func TestSchema(t *testing.T) {
+	Convey("Workflow map JSON Schema", t, func() {
+		var schema map[string]interface{}
+		So(json.Unmarshal(SchemaJSON(), &schema), ShouldBeNil)
+		So(schema["id"], ShouldEqual, SchemaID)
+		defs := schema["definitions"].(map[string]interface{})
+
+		// every field of a node must be described so the schema does not
+		// reject valid workflow maps
+		properties := func(def string) map[string]interface{} {
+			return defs[def].(map[string]interface{})["properties"].(map[string]interface{})
+		}
+		fields := func(v interface{}) []string {
+			var f []string
+			typ := reflect.TypeOf(v)
+			for i := 0; i < typ.NumField(); i++ {
+				tag := typ.Field(i).Tag.Get("json")
+				f = append(f, strings.Split(tag, ",")[0])
+			}
+			return f
+		}
+		Convey("describes every field of the nodes", func() {
+			for def, node := range map[string]interface{}{
+				"processNode": ProcessWorkflowMapNode{},
+				"publishNode": PublishWorkflowMapNode{},
+				"query":       CatalogQueryWorkflowMapNode{},
+				"filter":      FilterWorkflowMapNode{},
+				"sample":      SampleWorkflowMapNode{},
+				"batch":       BatchWorkflowMapNode{},
+			} {
+				for _, f := range fields(node) {
+					So(properties(def), ShouldContainKey, f)
+				}
+			}
+		})
+	})
+}
+
+func TestLatestVersion(t *testing.T) {
+	Convey("Latest version keyword", t, func() {
+		Convey("is parsed from json", func() {
+			wmap, err := FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {"version": "latest"}}, "process": [{"plugin_name": "passthru", "plugin_version": "latest"}], "publish": [{"plugin_name": "file", "plugin_version": 2}]}}`)
+			So(err, ShouldBeNil)
+			So(wmap.CollectNode.Metrics["/intel/mock/foo"].Version_, ShouldEqual, LatestVersion)
+			So(wmap.CollectNode.ProcessNodes[0].Version, ShouldEqual, LatestVersion)
+			So(wmap.CollectNode.PublishNodes[0].Version, ShouldEqual, 2)
+			_, err = FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {"version": "newest"}}}}`)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("is parsed from yaml without touching config items", func() {
+			wmap, err := FromYaml(`
+collect:
+  metrics:
+    /intel/mock/foo:
+      version: latest
+  publish:
+    - plugin_name: file
+      plugin_version: latest
+      config:
+        version: latest
+`)
+			So(err, ShouldBeNil)
+			So(wmap.CollectNode.Metrics["/intel/mock/foo"].Version_, ShouldEqual, LatestVersion)
+			So(wmap.CollectNode.PublishNodes[0].Version, ShouldEqual, LatestVersion)
+			So(wmap.CollectNode.PublishNodes[0].Config["version"], ShouldEqual, "latest")
+		})
+		Convey("is validated", func() {
+			So(Validate([]byte(`{"collect": {"metrics": {"/a": {"version": "latest"}}, "publish": [{"plugin_name": "file", "plugin_version": "latest"}]}}`)), ShouldBeEmpty)
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {}}, "publish": [{"plugin_name": "file", "plugin_version": "newest"}]}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "plugin_version")
+		})
+	})
+	Convey("Pinning versions", t, func() {
+		wmap, err := FromJson(`{"pin_versions": true, "collect": {"metrics": {"/intel/mock/foo": {}, "/intel/mock/bar": {"version": 1}},
+			"process": [{"plugin_name": "passthru", "publish": [{"plugin_name": "file", "target": "127.0.0.1:8082"}]}],
+			"publish": [{"plugin_name": "file", "plugin_version": "latest", "dead_letter": {"plugin_name": "file"}}]}}`)
+		So(err, ShouldBeNil)
+		So(wmap.PinVersions, ShouldBeTrue)
+		latest := map[string]int{"processor:passthru": 3, "publisher:file": 4}
+		pinned, err := wmap.Pin(func(typeName, name string) (int, error) {
+			return latest[typeName+":"+name], nil
+		}, func(ns []string) (int, error) {
+			So(ns, ShouldResemble, []string{"intel", "mock", "foo"})
+			return 5, nil
+		})
+		So(err, ShouldBeNil)
+		c := pinned.CollectNode
+		So(c.Metrics["/intel/mock/foo"].Version_, ShouldEqual, 5)
+		So(c.Metrics["/intel/mock/bar"].Version_, ShouldEqual, 1)
+		So(c.ProcessNodes[0].Version, ShouldEqual, 3)
+		So(c.ProcessNodes[0].PublishNodes[0].Version, ShouldEqual, 0)
+		So(c.PublishNodes[0].Version, ShouldEqual, 4)
+		So(c.PublishNodes[0].DeadLetter.Version, ShouldEqual, 4)
+		So(wmap.CollectNode.Metrics["/intel/mock/foo"].Version_, ShouldEqual, 0)
+
+		Convey("fails when a version cannot be resolved", func() {
+			_, err := wmap.Pin(func(string, string) (int, error) {
+				return 0, errors.New("not loaded")
+			}, func([]string) (int, error) {
+				return 5, nil
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestApplyConfigDefaults(t *testing.T) {
+	Convey("Workflow config defaults", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {}},
+			"process": [{"plugin_name": "passthru", "plugin_version": 2, "publish": [{"plugin_name": "file", "config": {"file": "/tmp/a"}}]}],
+			"publish": [{"plugin_name": "file", "plugin_version": 3, "dead_letter": {"plugin_name": "file"}}]}}`)
+		So(err, ShouldBeNil)
+		var defaults ConfigDefaults
+		err = json.Unmarshal([]byte(`{
+			"processor": {"passthru": {"all": {"debug": true}, "versions": {"1": {"debug": false}}}},
+			"publisher": {"file": {"all": {"file": "/tmp/b", "mode": "append"}, "versions": {"3": {"file": "/tmp/c"}}}}}`), &defaults)
+		So(err, ShouldBeNil)
+		withDefaults, err := wmap.ApplyConfigDefaults(defaults)
+		So(err, ShouldBeNil)
+		c := withDefaults.CollectNode
+		Convey("merges the config for all versions", func() {
+			So(c.ProcessNodes[0].Config, ShouldResemble, map[string]interface{}{"debug": true})
+			So(c.PublishNodes[0].DeadLetter.Config, ShouldResemble, map[string]interface{}{"file": "/tmp/b", "mode": "append"})
+		})
+		Convey("prefers the config for the node's version", func() {
+			So(c.PublishNodes[0].Config, ShouldResemble, map[string]interface{}{"file": "/tmp/c", "mode": "append"})
+		})
+		Convey("keeps the config set on the node", func() {
+			So(c.ProcessNodes[0].PublishNodes[0].Config, ShouldResemble, map[string]interface{}{"file": "/tmp/a", "mode": "append"})
+		})
+		Convey("does not change the workflow map", func() {
+			So(wmap.CollectNode.PublishNodes[0].Config, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestMetricEvery(t *testing.T) {
+	Convey("Metric collection interval", t, func() {
+		wmap, err := FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {}, "/intel/mock/bar": {"every": 6}}}}`)
+		So(err, ShouldBeNil)
+		every := map[string]int{}
+		for _, m := range wmap.CollectNode.GetMetrics() {
+			every[strings.Join(m.Namespace(), "/")] = m.Every()
+		}
+		So(every, ShouldResemble, map[string]int{"intel/mock/foo": 0, "intel/mock/bar": 6})
+		Convey("is validated", func() {
+			So(Validate([]byte(`{"collect": {"metrics": {"/a": {"every": 6}}}}`)), ShouldBeEmpty)
+			errs := Validate([]byte(`{"collect": {"metrics": {"/a": {"every": 0}}}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "every")
+		})
+	})
+}
+
+func TestWorkflowURI(t *testing.T) {
+	Convey("Workflow URI", t, func() {
+		wmap, err := FromJson(`{"uri": "https://example.com/workflows/cpu.yaml"}`)
+		So(err, ShouldBeNil)
+		So(wmap.URI, ShouldEqual, "https://example.com/workflows/cpu.yaml")
+		So(Validate([]byte(`{"uri": "file:///etc/snap/workflows/cpu.json"}`)), ShouldBeEmpty)
+		Convey("cannot define anything else", func() {
+			_, err := FromJson(`{"uri": "https://example.com/cpu.yaml", "collect": {"metrics": {"/foo/bar": {}}}}`)
+			So(err, ShouldEqual, ErrURIWithWorkflow)
+			errs := Validate([]byte(`{"uri": "https://example.com/cpu.yaml", "tags": {}}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "tags")
+		})
+		Convey("must be an http, https or file URI", func() {
+			_, err := FromJson(`{"uri": "ftp://example.com/cpu.yaml"}`)
+			So(err, ShouldEqual, ErrUnsupportedWorkflowURI)
+			errs := Validate([]byte(`{"uri": "example.com/cpu.yaml"}`))
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Field, ShouldEqual, "uri")
+		})
+	})
+}
@@ -59,8 +59,14 @@ type workManager struct {
 	collectchan    chan queuedJob
 	publishchan    chan queuedJob
 	processchan    chan queuedJob
-	kill           chan struct{}
-	mutex          *sync.Mutex
+	// branchSem bounds the process and publish jobs run outside of the
+	// worker pools when branch concurrency is enabled
+	branchConcurrency uint
+	branchSem         chan struct{}
+	kill              chan struct{}
+	mutex             *sync.Mutex
+	// runs counts the workflow runs in flight, refusing new ones while drained
+	runs runTracker
 }
 
 type workManagerState int
@@ -135,6 +141,17 @@ func PublishWkrSizeOption(v uint) workManagerOption {
 	}
 }
 
+// BranchConcurrencyOption sets the number of process and publish jobs which
+// may run at once outside of the worker pools, letting the sibling branches
+// of a workflow run concurrently. Zero sends them through the pools.
+func BranchConcurrencyOption(v uint) workManagerOption {
+	return func(w *workManager) workManagerOption {
+		previous := w.branchConcurrency
+		w.branchConcurrency = v
+		return BranchConcurrencyOption(previous)
+	}
+}
+
 func newWorkManager(opts ...workManagerOption) *workManager {
 
 	wm := &workManager{
@@ -156,6 +173,10 @@ func newWorkManager(opts ...workManagerOption) *workManager {
 		opt(wm)
 	}
 
+	if wm.branchConcurrency > 0 {
+		wm.branchSem = make(chan struct{}, wm.branchConcurrency)
+	}
+
 	wm.collectq = newQueue(wm.collectQSize, wm.sendToWorker)
 	wm.publishq = newQueue(wm.publishQSize, wm.sendToWorker)
 	wm.processq = newQueue(wm.processQSize, wm.sendToWorker)
@@ -225,13 +246,39 @@ func (w *workManager) Work(j job) queuedJob {
 	case collectJobType:
 		w.collectq.Event <- qj
 	case processJobType:
+		if w.branchSem != nil {
+			go w.runBranchJob(qj)
+			break
+		}
 		w.processq.Event <- qj
 	case publishJobType:
+		if w.branchSem != nil {
+			go w.runBranchJob(qj)
+			break
+		}
 		w.publishq.Event <- qj
 	}
 	return qj
 }
 
+// QueueLengths returns the number of jobs waiting in each work queue,
+// keyed by job type.
+func (w *workManager) QueueLengths() map[string]int {
+	return map[string]int{
+		"collect": w.collectq.Len(),
+		"process": w.processq.Len(),
+		"publish": w.publishq.Len(),
+	}
+}
+
+// runBranchJob runs a process or publish job on its own goroutine so the
+// branches of a workflow do not wait on each other in the pooled queues.
+func (w *workManager) runBranchJob(qj queuedJob) {
+	w.branchSem <- struct{}{}
+	defer func() { <-w.branchSem }()
+	runJob(qj)
+}
+
 // AddCollectWorker adds a new worker to
 // the collector worker pool
 func (w *workManager) AddCollectWorker() {
@@ -122,6 +122,21 @@ func (mj *mockJob) Metrics() []core.Metric {
 	return nil
 }
 
+// mockBranchJob is a mockJob dispatched as a process job
+type mockBranchJob struct {
+	*mockJob
+	panics bool
+}
+
+func (mj *mockBranchJob) Type() jobType { return processJobType }
+
+func (mj *mockBranchJob) Run() {
+	if mj.panics {
+		panic("branch failed")
+	}
+	mj.mockJob.Run()
+}
+
 func TestWorkerManager(t *testing.T) {
 	log.SetLevel(log.FatalLevel)
 	Convey(".Work()", t, func() {
@@ -172,6 +187,32 @@ func TestWorkerManager(t *testing.T) {
 			So(j3.worked, ShouldBeFalse)
 		})
 
+		Convey("runs sibling branch jobs concurrently", func() {
+			manager := newWorkManager(BranchConcurrencyOption(2))
+			// j1 blocks until j2 has been worked, which can only happen
+			// if both jobs are running at the same time
+			j1 := &mockBranchJob{mockJob: newMultiSyncMockJob(1)}
+			j2 := &mockBranchJob{mockJob: newMockJob()}
+			qj1 := manager.Work(j1)
+			qj2 := manager.Work(j2)
+			So(qj2.Promise().Await(), ShouldBeEmpty)
+			j1.RendezVous()
+			So(qj1.Promise().Await(), ShouldBeEmpty)
+			So(j1.worked, ShouldBeTrue)
+			So(j2.worked, ShouldBeTrue)
+		})
+
+		Convey("isolates a panicking branch job", func() {
+			manager := newWorkManager(BranchConcurrencyOption(2))
+			j1 := &mockBranchJob{mockJob: newMockJob(), panics: true}
+			j2 := &mockBranchJob{mockJob: newMockJob()}
+			qj1 := manager.Work(j1)
+			qj2 := manager.Work(j2)
+			So(qj1.Promise().Await(), ShouldNotBeEmpty)
+			So(qj2.Promise().Await(), ShouldBeEmpty)
+			So(j2.worked, ShouldBeTrue)
+		})
+
 		// The below convey is WIP
 		/*Convey("Collect queue error ", func() {
 			wMOption1 := CollectQSizeOption(1)
@@ -21,6 +21,7 @@ package scheduler
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/intelsdi-x/snap/pkg/chrono"
 	"github.com/pborman/uuid"
@@ -47,16 +48,7 @@ func (w *worker) start() {
 	for {
 		select {
 		case q := <-w.rcv:
-			// assert that deadline is not exceeded
-			if chrono.Chrono.Now().Before(q.Job().Deadline()) {
-				q.Job().Run()
-			} else {
-				// the deadline was exceeded and this job will not run
-				q.Job().AddErrors(errors.New("Worker refused to run overdue job."))
-			}
-
-			// mark the job complete
-			q.Promise().Complete(q.Job().Errors())
+			runJob(q)
 
 		// the single kill-channel -- used when resizing worker pools
 		case <-w.kamikaze:
@@ -68,3 +60,23 @@ func (w *worker) start() {
 		}
 	}
 }
+
+// runJob runs a queued job and marks it complete. A panic raised while the
+// job runs is recorded as an error of that job so it cannot take down the
+// worker or the jobs of sibling workflow branches.
+func runJob(q queuedJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			q.Job().AddErrors(fmt.Errorf("Job panicked: %v", r))
+		}
+		// mark the job complete
+		q.Promise().Complete(q.Job().Errors())
+	}()
+	// assert that deadline is not exceeded
+	if chrono.Chrono.Now().Before(q.Job().Deadline()) {
+		q.Job().Run()
+	} else {
+		// the deadline was exceeded and this job will not run
+		q.Job().AddErrors(errors.New("Worker refused to run overdue job."))
+	}
+}
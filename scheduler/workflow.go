@@ -27,6 +27,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/intelsdi-x/gomit"
+	"github.com/opentracing/opentracing-go/ext"
 
 	"github.com/intelsdi-x/snap/core"
 	"github.com/intelsdi-x/snap/core/cdata"
@@ -54,6 +55,8 @@ var (
 
 	ErrNullCollectNode        = errors.New("Missing collection node in workflow map")
 	ErrNoMetricsInCollectNode = errors.New("Collection node has not metrics defined to collect")
+	ErrInvalidCatalogQuery    = errors.New("Catalog query of collection node must have a namespace starting with '/'")
+	ErrInvalidMetricInterval  = errors.New("Metric of collection node must be collected every 1 or more firings")
 )
 
 // WmapToWorkflow attempts to convert a wmap.WorkflowMap to a schedulerWorkflow instance.
@@ -63,6 +66,7 @@ func wmapToWorkflow(wfMap *wmap.WorkflowMap) (*schedulerWorkflow, error) {
 	if err != nil {
 		return nil, err
 	}
+	wf.tags = addWorkflowTags(wfMap.GetTags(), wf.tags)
 	// ***
 	// TODO validate workflow makes sense here
 	// - flows that don't end in publishers?
@@ -73,21 +77,55 @@ func wmapToWorkflow(wfMap *wmap.WorkflowMap) (*schedulerWorkflow, error) {
 	return wf, nil
 }
 
+// addWorkflowTags returns the collect node tags with the workflow level tags
+// added at the root namespace so they are attached to every collected metric.
+// Tags defined for the root namespace in the collect node take precedence.
+func addWorkflowTags(wfTags map[string]string, tags map[string]map[string]string) map[string]map[string]string {
+	if len(wfTags) == 0 {
+		return tags
+	}
+	merged := make(map[string]map[string]string, len(tags)+1)
+	for ns, t := range tags {
+		merged[ns] = t
+	}
+	root := map[string]string{}
+	for k, v := range wfTags {
+		root[k] = v
+	}
+	for k, v := range tags["/"] {
+		root[k] = v
+	}
+	merged["/"] = root
+	return merged
+}
+
 func convertCollectionNode(cnode *wmap.CollectWorkflowMapNode, wf *schedulerWorkflow) error {
 	// Collection root
 	// Validate collection node exists
 	if cnode == nil {
 		return ErrNullCollectNode
 	}
-	// Collection node has at least one metric in it
-	if len(cnode.Metrics) < 1 {
+	// Collection node has at least one metric or a catalog query in it
+	if len(cnode.Metrics) < 1 && cnode.Query == nil {
 		return ErrNoMetricsInCollectNode
 	}
 	// Get core.RequestedMetric metrics
 	mts := cnode.GetMetrics()
-	wf.metrics = make([]core.RequestedMetric, len(mts))
+	wf.metrics = make([]core.RequestedMetric, len(mts), len(mts)+1)
 	for i, m := range mts {
-		wf.metrics[i] = &metric{namespace: core.NewNamespace(m.Namespace()...), version: m.Version()}
+		if m.Every() < 0 {
+			return ErrInvalidMetricInterval
+		}
+		wf.metrics[i] = &metric{namespace: core.NewNamespace(m.Namespace()...), version: m.Version(), every: m.Every()}
+	}
+	if q := cnode.Query; q != nil {
+		if !strings.HasPrefix(q.Namespace, "/") {
+			return ErrInvalidCatalogQuery
+		}
+		wf.metrics = append(wf.metrics, &catalogQuery{
+			metric: metric{namespace: core.NewNamespace(q.GetNamespace()...), version: q.Version},
+			tags:   q.Tags,
+		})
 	}
 	// get tags defined
 	wf.tags = cnode.GetTags()
@@ -110,12 +148,50 @@ func convertCollectionNode(cnode *wmap.CollectWorkflowMapNode, wf *schedulerWork
 		return err
 	}
 	wf.publishNodes = pu
+	timeout, err := parseTimeout(cnode.Timeout)
+	if err != nil {
+		return err
+	}
+	wf.collectTimeout = timeout
 	return nil
 }
 
 func convertProcessNode(pr []wmap.ProcessWorkflowMapNode) ([]*processNode, error) {
 	prNodes := make([]*processNode, len(pr))
 	for i, p := range pr {
+		var filter *metricFilter
+		if p.Filter != nil {
+			if p.Name != "" {
+				return nil, ErrFilterNodeWithPlugin
+			}
+			if p.Target != "" {
+				return nil, ErrFilterNodeWithTarget
+			}
+			f, err := newMetricFilter(p.Filter)
+			if err != nil {
+				return nil, err
+			}
+			filter = f
+			p.Name = filterNodeName
+		}
+		var smp *sampler
+		if p.Sample != nil {
+			if p.Filter != nil {
+				return nil, ErrSampleNodeWithFilter
+			}
+			if p.Name != "" {
+				return nil, ErrSampleNodeWithPlugin
+			}
+			if p.Target != "" {
+				return nil, ErrSampleNodeWithTarget
+			}
+			s, err := newSampler(p.Sample)
+			if err != nil {
+				return nil, err
+			}
+			smp = s
+			p.Name = sampleNodeName
+		}
 		cdn, err := p.GetConfigNode()
 		if err != nil {
 			return nil, err
@@ -135,14 +211,31 @@ func convertProcessNode(pr []wmap.ProcessWorkflowMapNode) ([]*processNode, error
 		if p.Version < 1 {
 			p.Version = -1
 		}
+		retry, err := newRetryPolicy(p.Retries, p.RetryDelay)
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := parseTimeout(p.Timeout)
+		if err != nil {
+			return nil, err
+		}
 		p.Name = strings.ToLower(p.Name)
 		prNodes[i] = &processNode{
-			name:         p.Name,
-			version:      p.Version,
-			config:       cdn,
-			Target:       p.Target,
-			ProcessNodes: prC,
-			PublishNodes: puC,
+			name:               p.Name,
+			version:            p.Version,
+			config:             cdn,
+			Target:             p.Target,
+			ProcessNodes:       prC,
+			PublishNodes:       puC,
+			InboundContentType: p.ContentType,
+			filter:             filter,
+			sampler:            smp,
+			retry:              retry,
+			timeout:            timeout,
+			versionConstraint:  p.VersionConstraint,
+		}
+		if p.ContentType != "" && prNodes[i].builtin() {
+			return nil, ErrContentTypeOnBuiltinNode
 		}
 	}
 	return prNodes, nil
@@ -162,12 +255,40 @@ func convertPublishNode(pu []wmap.PublishWorkflowMapNode) ([]*publishNode, error
 		if p.Version < 1 {
 			p.Version = -1
 		}
+		retry, err := newRetryPolicy(p.Retries, p.RetryDelay)
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := parseTimeout(p.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		var batch *publishBatcher
+		if p.Batch != nil {
+			if batch, err = newPublishBatcher(p.Batch); err != nil {
+				return nil, err
+			}
+		}
+		var deadLetter *publishNode
+		if p.DeadLetter != nil {
+			dl, err := convertPublishNode([]wmap.PublishWorkflowMapNode{*p.DeadLetter})
+			if err != nil {
+				return nil, err
+			}
+			deadLetter = dl[0]
+		}
 		p.Name = strings.ToLower(p.Name)
 		puNodes[i] = &publishNode{
-			name:    p.Name,
-			version: p.Version,
-			config:  cdn,
-			Target:  p.Target,
+			name:               p.Name,
+			version:            p.Version,
+			config:             cdn,
+			Target:             p.Target,
+			InboundContentType: p.ContentType,
+			retry:              retry,
+			timeout:            timeout,
+			batch:              batch,
+			deadLetter:         deadLetter,
+			versionConstraint:  p.VersionConstraint,
 		}
 	}
 	return puNodes, nil
@@ -185,6 +306,8 @@ type schedulerWorkflow struct {
 	workflowMap  *wmap.WorkflowMap
 	eventEmitter gomit.Emitter
 	tags         map[string]map[string]string
+	// collectTimeout overrides the timeout of the calls to the collectors
+	collectTimeout time.Duration
 }
 
 type processNode struct {
@@ -195,6 +318,23 @@ type processNode struct {
 	ProcessNodes       []*processNode
 	PublishNodes       []*publishNode
 	InboundContentType string
+	// filter is set for built-in filter nodes which are executed
+	// in-process rather than by a processor plugin
+	filter *metricFilter
+	// sampler is set for built-in sample nodes which forward only some
+	// of the task runs to their child nodes
+	sampler *sampler
+	retry   retryPolicy
+	timeout time.Duration
+	// versionConstraint selects the version of the plugin in place of
+	// version when set
+	versionConstraint string
+}
+
+// builtin reports whether the node is executed by the workflow engine
+// itself rather than by a processor plugin
+func (p *processNode) builtin() bool {
+	return p.filter != nil || p.sampler != nil
 }
 
 func (p *processNode) Name() string {
@@ -205,6 +345,10 @@ func (p *processNode) Version() int {
 	return p.version
 }
 
+func (p *processNode) VersionConstraint() string {
+	return p.versionConstraint
+}
+
 func (p *processNode) Config() *cdata.ConfigDataNode {
 	return p.config
 }
@@ -219,6 +363,15 @@ type publishNode struct {
 	config             *cdata.ConfigDataNode
 	Target             string
 	InboundContentType string
+	retry              retryPolicy
+	timeout            time.Duration
+	// batch is set when the node buffers the metrics of several runs
+	batch *publishBatcher
+	// deadLetter receives the metrics the node failed to publish
+	deadLetter *publishNode
+	// versionConstraint selects the version of the plugin in place of
+	// version when set
+	versionConstraint string
 }
 
 func (p *publishNode) Name() string {
@@ -229,6 +382,10 @@ func (p *publishNode) Version() int {
 	return p.version
 }
 
+func (p *publishNode) VersionConstraint() string {
+	return p.versionConstraint
+}
+
 func (p *publishNode) Config() *cdata.ConfigDataNode {
 	return p.config
 }
@@ -247,14 +404,33 @@ func (s *schedulerWorkflow) Start(t *task) {
 		"task-name": t.name,
 	}).Debug("Starting workflow")
 	s.state = WorkflowStarted
-	j := newCollectorJob(s.metrics, t.deadlineDuration, t.metricsManager, t.workflow.configTree, t.id, s.tags)
+	mts := dueMetrics(s.metrics, t.hitCount)
+	if len(mts) == 0 {
+		workflowLogger.WithFields(log.Fields{
+			"_block":    "workflow-start",
+			"task-id":   t.id,
+			"task-name": t.name,
+			"hit-count": t.hitCount,
+		}).Debug("No metrics to collect on this firing")
+		t.stats.skip()
+		return
+	}
+	// a run is traced from its collection to its last publish
+	span := startWorkflowSpan(t)
+	defer span.Finish()
+	j := newCollectorJob(mts, t.deadlineDuration, t.metricsManager, t.workflow.configTree, t.id, s.tags)
+	j.(*collectorJob).traceFrom = span.Context()
+	j.(*collectorJob).timeout = s.collectTimeout
 
 	// dispatch 'collect' job to be worked
 	// Block until the job has been either run or skipped.
+	start := time.Now()
 	errors := t.manager.Work(j).Promise().Await()
+	t.stats.observeCollect(time.Since(start))
 
 	if len(errors) > 0 {
-		t.RecordFailure(errors)
+		ext.Error.Set(span, true)
+		t.RecordFailure("collector", "", 0, errors)
 		event := new(scheduler_event.MetricCollectionFailedEvent)
 		event.TaskID = t.id
 		event.Errors = errors
@@ -262,6 +438,13 @@ func (s *schedulerWorkflow) Start(t *task) {
 		return
 	}
 
+	// Hold back the run if it trips the task's metrics threshold so that
+	// processors and publishers never see the spike
+	if t.checkMetricsThreshold(j.(*collectorJob).metrics) {
+		t.stats.skip()
+		return
+	}
+
 	// Send event
 	event := new(scheduler_event.MetricCollectedEvent)
 	event.TaskID = t.id
@@ -289,6 +472,9 @@ func (s *schedulerWorkflow) StreamStart(t *task, metrics []core.Metric) {
 		configDataTree: t.workflow.configTree,
 		tags:           t.workflow.tags,
 	}
+	if t.checkMetricsThreshold(metrics) {
+		return
+	}
 	// Send event
 	event := new(scheduler_event.MetricCollectedEvent)
 	event.TaskID = t.id
@@ -343,10 +529,24 @@ func workJobs(prs []*processNode, pus []*publishNode, t *task, pj job) {
 func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 	// Decrement the waitgroup
 	defer wg.Done()
+	// Filter nodes do not need a plugin so they are run directly
+	if pr.filter != nil {
+		j := newFilterJob(pj, pr.filter, t.id)
+		j.Run()
+		workJobs(pr.ProcessNodes, pr.PublishNodes, t, j)
+		return
+	}
+	// Sample nodes pass the parent job on untouched for the sampled runs
+	if pr.sampler != nil {
+		if pr.sampler.sample(time.Now()) {
+			workJobs(pr.ProcessNodes, pr.PublishNodes, t, pj)
+		}
+		return
+	}
 	// Create a new process job
 	mgr, err := t.RemoteManagers.Get(pr.Target)
 	if err != nil {
-		t.RecordFailure([]error{err})
+		t.RecordFailure(pr.TypeName(), pr.Name(), pr.Version(), []error{err})
 		workflowLogger.WithFields(log.Fields{
 			"_block":           "submit-prblish-job",
 			"task-id":          t.id,
@@ -357,7 +557,19 @@ func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 		}).Warn("Error getting control instance")
 		return
 	}
-	j := newProcessJob(pj, pr.Name(), pr.Version(), pr.InboundContentType, pr.config.Table(), mgr, t.id)
+	version, err := runVersion(mgr, pr)
+	if err != nil {
+		t.RecordFailure(pr.TypeName(), pr.Name(), pr.Version(), []error{err})
+		workflowLogger.WithFields(log.Fields{
+			"_block":             "submit-process-job",
+			"task-id":            t.id,
+			"task-name":          t.name,
+			"process-name":       pr.Name(),
+			"version-constraint": pr.VersionConstraint(),
+			"error":              err,
+		}).Warn("No plugin version matching the constraint")
+		return
+	}
 	workflowLogger.WithFields(log.Fields{
 		"_block":           "submit-process-job",
 		"task-id":          t.id,
@@ -366,13 +578,17 @@ func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 		"process-version":  pr.Version(),
 		"parent-node-type": pj.TypeString(),
 	}).Debug("Submitting process job")
-	// Submit the job against the task.managesWork
-	errors := t.manager.Work(j).Promise().Await()
+	// Submit the job against the task.managesWork, retrying it as the node allows
+	j, errors := pr.retry.work(t, pj.Deadline(), func() job {
+		j := newProcessJob(pj, pr.Name(), version, pr.InboundContentType, pr.config.Table(), mgr, t.id)
+		j.(*processJob).timeout = pr.timeout
+		return j
+	})
 	// Check for errors and update the task
 	if len(errors) != 0 {
 		// Record the failures in the task
 		// note: this function is thread safe against t
-		t.RecordFailure(errors)
+		t.RecordFailure(pr.TypeName(), pr.Name(), pr.Version(), errors)
 		workflowLogger.WithFields(log.Fields{
 			"_block":           "submit-process-job",
 			"task-id":          t.id,
@@ -398,10 +614,39 @@ func submitProcessJob(pj job, t *task, wg *sync.WaitGroup, pr *processNode) {
 func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 	// Decrement the waitgroup
 	defer wg.Done()
+	deliver(pj, t, pu)
+}
+
+// deliver hands the metrics of the parent job to a publish node, buffering
+// them first when the node batches metrics
+func deliver(pj job, t *task, pu *publishNode) {
+	if pu.batch != nil {
+		batch := pu.batch.add(pj.Metrics(), func(mts []core.Metric) {
+			// the flush interval elapsed, the batch is published outside of a run
+			publish(newBatchJob(time.Now().Add(t.DeadlineDuration()), mts, t.id), t, pu)
+		})
+		if batch == nil {
+			workflowLogger.WithFields(log.Fields{
+				"_block":          "submit-publish-job",
+				"task-id":         t.id,
+				"task-name":       t.name,
+				"publish-name":    pu.Name(),
+				"publish-version": pu.Version(),
+			}).Debug("Metrics buffered in publish batch")
+			return
+		}
+		pj = newBatchJob(pj.Deadline(), batch, t.id)
+	}
+	publish(pj, t, pu)
+}
+
+// publish submits the publish job of a publish node for the metrics of its
+// parent job
+func publish(pj job, t *task, pu *publishNode) {
 	// Create a new process job
 	mgr, err := t.RemoteManagers.Get(pu.Target)
 	if err != nil {
-		t.RecordFailure([]error{err})
+		t.RecordFailure(pu.TypeName(), pu.Name(), pu.Version(), []error{err})
 		workflowLogger.WithFields(log.Fields{
 			"_block":           "submit-publish-job",
 			"task-id":          t.id,
@@ -410,9 +655,23 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 			"publish-version":  pu.Version(),
 			"parent-node-type": pj.TypeString(),
 		}).Warn("Error getting control instance")
+		sendToDeadLetter(pj, t, pu, []error{err})
+		return
+	}
+	version, err := runVersion(mgr, pu)
+	if err != nil {
+		t.RecordFailure(pu.TypeName(), pu.Name(), pu.Version(), []error{err})
+		workflowLogger.WithFields(log.Fields{
+			"_block":             "submit-publish-job",
+			"task-id":            t.id,
+			"task-name":          t.name,
+			"publish-name":       pu.Name(),
+			"version-constraint": pu.VersionConstraint(),
+			"error":              err,
+		}).Warn("No plugin version matching the constraint")
+		sendToDeadLetter(pj, t, pu, []error{err})
 		return
 	}
-	j := newPublishJob(pj, pu.Name(), pu.Version(), pu.InboundContentType, pu.config.Table(), mgr, t.id)
 	workflowLogger.WithFields(log.Fields{
 		"_block":           "submit-publish-job",
 		"task-id":          t.id,
@@ -421,13 +680,19 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 		"publish-version":  pu.Version(),
 		"parent-node-type": pj.TypeString(),
 	}).Debug("Submitting publish job")
-	// Submit the job against the task.managesWork
-	errors := t.manager.Work(j).Promise().Await()
+	// Submit the job against the task.managesWork, retrying it as the node allows
+	start := time.Now()
+	_, errors := pu.retry.work(t, pj.Deadline(), func() job {
+		j := newPublishJob(pj, pu.Name(), version, pu.InboundContentType, pu.config.Table(), mgr, t.id)
+		j.(*publisherJob).timeout = pu.timeout
+		return j
+	})
+	t.stats.observePublish(time.Since(start))
 	// Check for errors and update the task
 	if len(errors) != 0 {
 		// Record the failures in the task
 		// note: this function is thread safe against t
-		t.RecordFailure(errors)
+		t.RecordFailure(pu.TypeName(), pu.Name(), pu.Version(), errors)
 		workflowLogger.WithFields(log.Fields{
 			"_block":           "submit-publish-job",
 			"task-id":          t.id,
@@ -436,6 +701,7 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 			"publish-version":  pu.Version(),
 			"parent-node-type": pj.TypeString(),
 		}).Warn("Publish job failed")
+		sendToDeadLetter(pj, t, pu, errors)
 		return
 	}
 	workflowLogger.WithFields(log.Fields{
@@ -446,6 +712,12 @@ func submitPublishJob(pj job, t *task, wg *sync.WaitGroup, pu *publishNode) {
 		"publish-version":  pu.Version(),
 		"parent-node-type": pj.TypeString(),
 	}).Debug("Publish job completed")
+	if t.eventEmitter != nil {
+		t.eventEmitter.Emit(&scheduler_event.MetricPublishedEvent{
+			TaskID: t.id,
+			Count:  len(pj.Metrics()),
+		})
+	}
 	// Publish nodes cannot contain child nodes (publish is a terminal node)
 	// so unlike process nodes there is not a call to workJobs here for child nodes.
 }
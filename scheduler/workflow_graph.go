@@ -0,0 +1,80 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/snap/core"
+)
+
+const collectGraphNodeID = "collect"
+
+// Graph describes the nodes of the workflow and the content types bound
+// between them
+func (s *schedulerWorkflow) Graph() *core.WorkflowGraph {
+	g := &core.WorkflowGraph{}
+	collect := core.WorkflowGraphNode{ID: collectGraphNodeID, Type: "collector"}
+	for _, m := range s.metrics {
+		collect.Metrics = append(collect.Metrics, m.Namespace().String())
+	}
+	g.AddNode("", "", collect)
+	graphNodes(g, collectGraphNodeID, s.processNodes, s.publishNodes)
+	return g
+}
+
+func graphNodes(g *core.WorkflowGraph, parent string, prs []*processNode, pus []*publishNode) {
+	for i, pr := range prs {
+		n := core.WorkflowGraphNode{
+			ID:          fmt.Sprintf("%s.process[%d]", parent, i),
+			Type:        pr.TypeName(),
+			Target:      pr.Target,
+			ContentType: pr.InboundContentType,
+		}
+		switch {
+		case pr.filter != nil:
+			n.Type = "filter"
+		case pr.sampler != nil:
+			n.Type = "sample"
+		default:
+			n.Name = pr.Name()
+			n.Version = pr.Version()
+		}
+		g.AddNode(parent, core.WorkflowEdgeMetrics, n)
+		graphNodes(g, n.ID, pr.ProcessNodes, pr.PublishNodes)
+	}
+	for i, pu := range pus {
+		graphPublishNode(g, parent, fmt.Sprintf("%s.publish[%d]", parent, i), core.WorkflowEdgeMetrics, pu)
+	}
+}
+
+func graphPublishNode(g *core.WorkflowGraph, parent, id, kind string, pu *publishNode) {
+	g.AddNode(parent, kind, core.WorkflowGraphNode{
+		ID:          id,
+		Type:        pu.TypeName(),
+		Name:        pu.Name(),
+		Version:     pu.Version(),
+		Target:      pu.Target,
+		ContentType: pu.InboundContentType,
+	})
+	if pu.deadLetter != nil {
+		graphPublishNode(g, id, id+".dead_letter", core.WorkflowEdgeDeadLetter, pu.deadLetter)
+	}
+}
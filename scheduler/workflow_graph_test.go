@@ -0,0 +1,79 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorkflowGraph(t *testing.T) {
+	Convey("Workflow graph", t, func() {
+		wfMap, err := wmap.FromJson(`{"collect": {"metrics": {"/intel/mock/foo": {}},
+			"process": [{"plugin_name": "passthru", "content_type": "snap.gob", "publish": [{"plugin_name": "file"}]}, {"filter": {"include": ["/intel/*"]}}],
+			"publish": [{"plugin_name": "influxdb", "plugin_version": 2, "dead_letter": {"plugin_name": "file"}}]}}`)
+		So(err, ShouldBeNil)
+		wf, err := wmapToWorkflow(wfMap)
+		So(err, ShouldBeNil)
+		g := wf.Graph()
+
+		var ids []string
+		for _, n := range g.Nodes {
+			ids = append(ids, n.ID)
+		}
+		So(ids, ShouldResemble, []string{
+			"collect",
+			"collect.process[0]",
+			"collect.process[0].publish[0]",
+			"collect.process[1]",
+			"collect.publish[0]",
+			"collect.publish[0].dead_letter",
+		})
+		So(g.Nodes[0].Metrics, ShouldResemble, []string{"/intel/mock/foo"})
+		So(g.Nodes[1], ShouldResemble, core.WorkflowGraphNode{
+			ID:          "collect.process[0]",
+			Type:        "processor",
+			Name:        "passthru",
+			Version:     -1,
+			ContentType: "snap.gob",
+		})
+		So(g.Nodes[3].Type, ShouldEqual, "filter")
+		So(g.Nodes[3].Name, ShouldBeEmpty)
+
+		So(g.Edges, ShouldHaveLength, 5)
+		So(g.Edges[0], ShouldResemble, core.WorkflowGraphEdge{
+			From:        "collect",
+			To:          "collect.process[0]",
+			Kind:        core.WorkflowEdgeMetrics,
+			ContentType: "snap.gob",
+		})
+		So(g.Edges[4], ShouldResemble, core.WorkflowGraphEdge{
+			From: "collect.publish[0]",
+			To:   "collect.publish[0].dead_letter",
+			Kind: core.WorkflowEdgeDeadLetter,
+		})
+	})
+}
@@ -0,0 +1,261 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/snap/core"
+	"github.com/intelsdi-x/snap/core/serror"
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+var (
+	// ErrWorkflowNotFound - The error message for a stored workflow which does not exist
+	ErrWorkflowNotFound = errors.New("Workflow not found")
+	// ErrWorkflowNameEmpty - The error message for storing a workflow without a name
+	ErrWorkflowNameEmpty = errors.New("Workflow name must not be empty")
+	// ErrWorkflowInUse - The error message for removing a stored workflow still referenced by tasks
+	ErrWorkflowInUse = errors.New("Workflow is referenced by tasks")
+	// ErrWorkflowRefNested - The error message for storing a workflow which references another stored workflow
+	ErrWorkflowRefNested = errors.New("A stored workflow cannot reference another stored workflow")
+	// ErrWorkflowURIStored - The error message for storing a workflow which is fetched from a URI
+	ErrWorkflowURIStored = errors.New("A stored workflow cannot be fetched from a URI")
+)
+
+type storedWorkflow struct {
+	version int
+	updated time.Time
+	wmap    *wmap.WorkflowMap
+}
+
+// workflowRegistry holds the latest version of the workflows stored by name
+type workflowRegistry struct {
+	sync.Mutex
+	workflows map[string]*storedWorkflow
+}
+
+func newWorkflowRegistry() *workflowRegistry {
+	return &workflowRegistry{workflows: map[string]*storedWorkflow{}}
+}
+
+func (r *workflowRegistry) get(name string) (*storedWorkflow, error) {
+	r.Lock()
+	defer r.Unlock()
+	sw, ok := r.workflows[name]
+	if !ok {
+		return nil, ErrWorkflowNotFound
+	}
+	return sw, nil
+}
+
+func (r *workflowRegistry) put(name string, wfMap *wmap.WorkflowMap) *storedWorkflow {
+	r.Lock()
+	defer r.Unlock()
+	sw := &storedWorkflow{version: 1, updated: time.Now(), wmap: wfMap}
+	if prev, ok := r.workflows[name]; ok {
+		sw.version = prev.version + 1
+	}
+	r.workflows[name] = sw
+	return sw
+}
+
+func (r *workflowRegistry) names() []string {
+	r.Lock()
+	defer r.Unlock()
+	names := make([]string, 0, len(r.workflows))
+	for name := range r.workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddWorkflow stores a workflow map under the given name, replacing the
+// previous version if there is one. When rollForward is set the tasks
+// referencing the workflow are moved to the new version, running tasks have
+// their plugins resubscribed without being stopped. A task which cannot be
+// moved keeps its previous workflow and the error is returned.
+func (s *scheduler) AddWorkflow(name string, wfMap *wmap.WorkflowMap, rollForward bool) (core.StoredWorkflow, []serror.SnapError) {
+	logger := schedulerLogger.WithFields(log.Fields{
+		"_block":        "add-workflow",
+		"workflow-name": name,
+	})
+	if name == "" {
+		return core.StoredWorkflow{}, []serror.SnapError{serror.New(ErrWorkflowNameEmpty)}
+	}
+	if wfMap.Ref != "" {
+		return core.StoredWorkflow{}, []serror.SnapError{serror.New(ErrWorkflowRefNested)}
+	}
+	if wfMap.URI != "" {
+		return core.StoredWorkflow{}, []serror.SnapError{serror.New(ErrWorkflowURIStored)}
+	}
+	// Ensure the workflow map converts before it is referenced by any task
+	_, err := wmapToWorkflow(wfMap)
+	if err != nil {
+		return core.StoredWorkflow{}, []serror.SnapError{serror.New(err)}
+	}
+	sw := s.workflows.put(name, wfMap)
+	logger.WithFields(log.Fields{
+		"workflow-version": sw.version,
+	}).Info("workflow stored")
+
+	var errs []serror.SnapError
+	if rollForward {
+		for id, t := range s.tasks.Table() {
+			if t.WorkflowRef() != name {
+				continue
+			}
+			// the task may have been removed in the meantime
+			if t, err = s.lockTask(id, 0); err != nil {
+				continue
+			}
+			terrs := s.rollForward(t, wfMap)
+			t.opMutex.Unlock()
+			if len(terrs) > 0 {
+				for _, e := range terrs {
+					fields := e.Fields()
+					if fields == nil {
+						fields = map[string]interface{}{}
+					}
+					fields["task-id"] = t.ID()
+					e.SetFields(fields)
+				}
+				errs = append(errs, terrs...)
+				f := buildErrorsLog(terrs, logger)
+				f.WithField("task-id", t.ID()).Error("unable to roll task forward")
+				continue
+			}
+			logger.WithFields(log.Fields{
+				"task-id":          t.ID(),
+				"workflow-version": sw.version,
+			}).Info("task rolled forward")
+		}
+	}
+	return s.storedWorkflow(name, sw), errs
+}
+
+// GetWorkflows returns the latest version of every stored workflow
+func (s *scheduler) GetWorkflows() []core.StoredWorkflow {
+	var workflows []core.StoredWorkflow
+	for _, name := range s.workflows.names() {
+		if sw, err := s.workflows.get(name); err == nil {
+			workflows = append(workflows, s.storedWorkflow(name, sw))
+		}
+	}
+	return workflows
+}
+
+// GetWorkflow returns the latest version of a stored workflow
+func (s *scheduler) GetWorkflow(name string) (core.StoredWorkflow, error) {
+	sw, err := s.workflows.get(name)
+	if err != nil {
+		return core.StoredWorkflow{}, err
+	}
+	return s.storedWorkflow(name, sw), nil
+}
+
+// RemoveWorkflow removes a stored workflow which is not referenced by any task
+func (s *scheduler) RemoveWorkflow(name string) error {
+	s.workflows.Lock()
+	defer s.workflows.Unlock()
+	if _, ok := s.workflows.workflows[name]; !ok {
+		return ErrWorkflowNotFound
+	}
+	if len(s.workflowTasks(name)) > 0 {
+		return ErrWorkflowInUse
+	}
+	delete(s.workflows.workflows, name)
+	return nil
+}
+
+func (s *scheduler) storedWorkflow(name string, sw *storedWorkflow) core.StoredWorkflow {
+	return core.StoredWorkflow{
+		Name:     name,
+		Version:  sw.version,
+		Updated:  sw.updated,
+		Workflow: sw.wmap,
+		TaskIDs:  s.workflowTasks(name),
+	}
+}
+
+// workflowTasks returns the ids of the tasks referencing a stored workflow
+func (s *scheduler) workflowTasks(name string) []string {
+	var ids []string
+	for id, t := range s.tasks.Table() {
+		if t.WorkflowRef() == name {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// rollForward replaces the workflow of a task, the caller must hold the
+// task's opMutex. The task lock is held for the whole swap so a running task
+// cannot fire with its plugins unsubscribed.
+func (s *scheduler) rollForward(t *task, wfMap *wmap.WorkflowMap) []serror.SnapError {
+	wfMap, err := s.pinVersions(wfMap)
+	if err != nil {
+		return []serror.SnapError{serror.New(err)}
+	}
+	wfMap, err = wfMap.ApplyConfigDefaults(s.configDefaults)
+	if err != nil {
+		return []serror.SnapError{serror.New(err)}
+	}
+	wf, err := wmapToWorkflow(wfMap)
+	if err != nil {
+		return []serror.SnapError{serror.New(err)}
+	}
+	wf.eventEmitter = s.eventManager
+	if err := createTaskClients(&t.RemoteManagers, wf); err != nil {
+		return []serror.SnapError{serror.New(err)}
+	}
+	if errs := s.validateWorkflow(t, wf); len(errs) > 0 {
+		return errs
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	running := t.state == core.TaskSpinning || t.state == core.TaskFiring
+	if running {
+		if errs := t.UnsubscribePlugins(); len(errs) > 0 {
+			return errs
+		}
+	}
+	previous := t.workflow
+	t.workflow = wf
+	if running {
+		if _, errs := t.SubscribePlugins(); len(errs) > 0 {
+			t.workflow = previous
+			if _, rerrs := t.SubscribePlugins(); len(rerrs) > 0 {
+				errs = append(errs, rerrs...)
+			}
+			return errs
+		}
+	}
+	t.revision++
+	return nil
+}
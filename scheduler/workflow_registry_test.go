@@ -0,0 +1,78 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+func TestWorkflowRegistry(t *testing.T) {
+	Convey("Stored workflows", t, func() {
+		s := New(GetDefaultConfig())
+		wf := wmap.NewWorkflowMap()
+		wf.CollectNode.AddMetric("/foo/bar", 1)
+
+		Convey("are versioned by name", func() {
+			stored, errs := s.AddWorkflow("foo", wf, false)
+			So(errs, ShouldBeEmpty)
+			So(stored.Version, ShouldEqual, 1)
+			stored, errs = s.AddWorkflow("foo", wf, true)
+			So(errs, ShouldBeEmpty)
+			So(stored.Version, ShouldEqual, 2)
+			So(stored.TaskIDs, ShouldBeEmpty)
+
+			_, errs = s.AddWorkflow("bar", wf, false)
+			So(errs, ShouldBeEmpty)
+			workflows := s.GetWorkflows()
+			So(workflows, ShouldHaveLength, 2)
+			So(workflows[0].Name, ShouldEqual, "bar")
+			So(workflows[1].Name, ShouldEqual, "foo")
+		})
+		Convey("must have a name", func() {
+			_, errs := s.AddWorkflow("", wf, false)
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrWorkflowNameEmpty.Error())
+		})
+		Convey("cannot reference another stored workflow", func() {
+			_, errs := s.AddWorkflow("foo", &wmap.WorkflowMap{Ref: "bar"}, false)
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrWorkflowRefNested.Error())
+		})
+		Convey("must be valid", func() {
+			_, errs := s.AddWorkflow("foo", &wmap.WorkflowMap{}, false)
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Error(), ShouldEqual, ErrNullCollectNode.Error())
+		})
+		Convey("can be removed", func() {
+			_, errs := s.AddWorkflow("foo", wf, false)
+			So(errs, ShouldBeEmpty)
+			So(s.RemoveWorkflow("foo"), ShouldBeNil)
+			_, err := s.GetWorkflow("foo")
+			So(err, ShouldEqual, ErrWorkflowNotFound)
+			So(s.RemoveWorkflow("foo"), ShouldEqual, ErrWorkflowNotFound)
+		})
+	})
+}
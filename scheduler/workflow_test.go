@@ -431,3 +431,19 @@ func TestWorkJobs(t *testing.T) {
 
 	})
 }
+
+func TestAddWorkflowTags(t *testing.T) {
+	Convey("Workflow level tags are added at the root namespace", t, func() {
+		tags := map[string]map[string]string{
+			"/":          {"role": "web"},
+			"/intel/foo": {"foo": "bar"},
+		}
+		merged := addWorkflowTags(map[string]string{"dc": "dc1", "role": "db"}, tags)
+		So(merged["/"], ShouldResemble, map[string]string{"dc": "dc1", "role": "web"})
+		So(merged["/intel/foo"], ShouldResemble, map[string]string{"foo": "bar"})
+		So(tags["/"], ShouldResemble, map[string]string{"role": "web"})
+
+		So(addWorkflowTags(nil, tags), ShouldResemble, tags)
+		So(addWorkflowTags(map[string]string{"dc": "dc1"}, nil), ShouldResemble, map[string]map[string]string{"/": {"dc": "dc1"}})
+	})
+}
@@ -0,0 +1,168 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ghodss/yaml"
+
+	"github.com/intelsdi-x/snap/scheduler/wmap"
+)
+
+const (
+	// fetched workflows are reused for this long before being fetched again
+	workflowURICacheTTL = time.Minute
+	workflowURITimeout  = 30 * time.Second
+	// fetched workflows larger than this are rejected
+	workflowURIMaxSize = 1 << 20
+)
+
+var (
+	// ErrWorkflowURINested - The error message for a workflow fetched from a URI which does not define the workflow itself
+	ErrWorkflowURINested = errors.New("A workflow fetched from a URI cannot reference a stored workflow or another URI")
+	// ErrWorkflowURITooLarge - The error message for a workflow fetched from a URI which exceeds the maximum size
+	ErrWorkflowURITooLarge = fmt.Errorf("A workflow fetched from a URI cannot exceed %d bytes", workflowURIMaxSize)
+)
+
+// workflowFetcher fetches the workflow maps tasks are created from by URI,
+// caching them so many tasks can share a centrally hosted workflow. A cached
+// workflow is used when fetching it again fails.
+type workflowFetcher struct {
+	sync.Mutex
+	client *http.Client
+	ttl    time.Duration
+	cache  map[string]*fetchedWorkflow
+}
+
+type fetchedWorkflow struct {
+	wmap    *wmap.WorkflowMap
+	fetched time.Time
+}
+
+func newWorkflowFetcher() *workflowFetcher {
+	return &workflowFetcher{
+		client: &http.Client{Timeout: workflowURITimeout},
+		ttl:    workflowURICacheTTL,
+		cache:  map[string]*fetchedWorkflow{},
+	}
+}
+
+// get returns the validated workflow map found at the URI. The cache is not
+// locked while the workflow is fetched, so that a slow host does not hold up
+// the tasks created from other URIs.
+func (f *workflowFetcher) get(uri string) (*wmap.WorkflowMap, error) {
+	f.Lock()
+	cached := f.cache[uri]
+	f.Unlock()
+	if cached != nil && time.Since(cached.fetched) < f.ttl {
+		return cached.wmap, nil
+	}
+	wfMap, err := f.fetch(uri)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		schedulerLogger.WithFields(log.Fields{
+			"_block":       "fetch-workflow",
+			"workflow-uri": uri,
+			"fetched":      cached.fetched,
+			"error":        err.Error(),
+		}).Warn("Unable to fetch workflow, using cached copy")
+		return cached.wmap, nil
+	}
+	f.Lock()
+	f.cache[uri] = &fetchedWorkflow{wmap: wfMap, fetched: time.Now()}
+	f.Unlock()
+	return wfMap, nil
+}
+
+func (f *workflowFetcher) fetch(uri string) (*wmap.WorkflowMap, error) {
+	if err := wmap.CheckWorkflowURI(uri); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	var r io.ReadCloser
+	if u.Scheme == "file" {
+		if r, err = os.Open(u.Path); err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := f.client.Get(uri)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("Fetching workflow from %s failed: %s", uri, resp.Status)
+		}
+		r = resp.Body
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(r, workflowURIMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > workflowURIMaxSize {
+		return nil, ErrWorkflowURITooLarge
+	}
+	return parseFetchedWorkflow(b)
+}
+
+// parseFetchedWorkflow parses and validates a workflow map given in JSON or
+// YAML
+func parseFetchedWorkflow(b []byte) (*wmap.WorkflowMap, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		js, err := yaml.YAMLToJSON(b)
+		if err != nil {
+			return nil, err
+		}
+		b = js
+	}
+	if errs := wmap.Validate(b); len(errs) > 0 {
+		reasons := make([]string, len(errs))
+		for i, e := range errs {
+			reasons[i] = e.Error()
+		}
+		return nil, fmt.Errorf("Fetched workflow is not valid: %s", strings.Join(reasons, "; "))
+	}
+	wfMap, err := wmap.FromJson(b)
+	if err != nil {
+		return nil, err
+	}
+	if wfMap.Ref != "" || wfMap.URI != "" {
+		return nil, ErrWorkflowURINested
+	}
+	return wfMap, nil
+}
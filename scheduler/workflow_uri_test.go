@@ -0,0 +1,103 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2017 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const fetchedWorkflowYaml = `
+collect:
+  metrics:
+    /intel/mock/foo: {}
+  publish:
+    - plugin_name: file
+`
+
+func TestWorkflowFetcher(t *testing.T) {
+	Convey("Workflow fetcher", t, func() {
+		fetches := 0
+		status := http.StatusOK
+		body := `{"collect": {"metrics": {"/intel/mock/foo": {}}}}`
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches++
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+		}))
+		defer ts.Close()
+		f := newWorkflowFetcher()
+
+		Convey("fetches and caches workflows over http", func() {
+			wfMap, err := f.get(ts.URL + "/wf.json")
+			So(err, ShouldBeNil)
+			So(wfMap.CollectNode.Metrics, ShouldContainKey, "/intel/mock/foo")
+			_, err = f.get(ts.URL + "/wf.json")
+			So(err, ShouldBeNil)
+			So(fetches, ShouldEqual, 1)
+		})
+		Convey("uses the cached workflow when fetching fails", func() {
+			_, err := f.get(ts.URL)
+			So(err, ShouldBeNil)
+			f.ttl = 0
+			status = http.StatusInternalServerError
+			wfMap, err := f.get(ts.URL)
+			So(err, ShouldBeNil)
+			So(wfMap, ShouldNotBeNil)
+			So(fetches, ShouldEqual, 2)
+		})
+		Convey("fails when the workflow cannot be fetched", func() {
+			status = http.StatusNotFound
+			_, err := f.get(ts.URL)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("rejects invalid workflows", func() {
+			body = `{"collect": {"metrics": {"/intel/mock/foo": {"every": 0}}}}`
+			_, err := f.get(ts.URL)
+			So(err, ShouldNotBeNil)
+			body = `{"$ref": "cpu-to-influx"}`
+			_, err = f.get(ts.URL)
+			So(err, ShouldEqual, ErrWorkflowURINested)
+		})
+		Convey("fetches yaml workflows from files", func() {
+			file, err := ioutil.TempFile("", "workflow")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+			_, err = file.WriteString(fetchedWorkflowYaml)
+			So(err, ShouldBeNil)
+			file.Close()
+			wfMap, err := f.get("file://" + file.Name())
+			So(err, ShouldBeNil)
+			So(wfMap.CollectNode.PublishNodes, ShouldHaveLength, 1)
+		})
+		Convey("rejects other URIs", func() {
+			_, err := f.get("ftp://example.com/wf.json")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -87,6 +87,10 @@ var (
 	// used to save a reference to the CLi App
 	cliApp *cli.App
 
+	// signals receives the signals shutting snapteld down, or restarting it,
+	// sent by the system or by the REST API after a drain
+	signals = make(chan os.Signal, 1)
+
 	// log levels
 	l = map[int]string{
 		1: "debug",
@@ -180,6 +184,10 @@ type coreModule interface {
 	Name() string
 }
 
+type managesPluginTrust interface {
+	SetPluginTrustLevel(int)
+}
+
 type managesTribe interface {
 	GetAgreement(name string) (*agreement.Agreement, serror.SnapError)
 	GetAgreements() map[string]*agreement.Agreement
@@ -321,8 +329,9 @@ func action(ctx *cli.Context) error {
 	s.SetMetricManager(c)
 	coreModules = append(coreModules, s)
 
-	// Auth requested and not provided as part of config
-	if cfg.RestAPI.Enable && cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" {
+	// Auth requested and neither a password nor tokens provided as part of config
+	if cfg.RestAPI.Enable && cfg.RestAPI.RestAuth && cfg.RestAPI.RestAuthPassword == "" &&
+		len(cfg.RestAPI.RestAuthTokens) == 0 && len(cfg.RestAPI.RestAuthTokenRoles) == 0 {
 		fmt.Println("What password do you want to use for authentication?")
 		fmt.Print("Password:")
 		password, err := terminal.ReadPassword(0)
@@ -361,6 +370,13 @@ func action(ctx *cli.Context) error {
 		r.BindMetricManager(c)
 		r.BindConfigManager(c.Config)
 		r.BindTaskManager(s)
+		c.RegisterEventHandler("rest", r)
+		s.RegisterEventHandler("rest", r)
+		r.AddReadinessCheck("control", c.Ready)
+		r.AddReadinessCheck("scheduler", s.Ready)
+		r.SetDaemonConfig(cfg)
+		addDaemonSettings(r, cfg, c)
+		r.SetShutdown(func() { signals <- syscall.SIGTERM })
 
 		//Rest Authentication
 		if cfg.RestAPI.RestAuth {
@@ -368,6 +384,18 @@ func action(ctx *cli.Context) error {
 			r.SetAPIAuth(cfg.RestAPI.RestAuth)
 			log.Info("REST API authentication password is set")
 			r.SetAPIAuthPwd(cfg.RestAPI.RestAuthPassword)
+			tokens, err := cfg.RestAPI.AuthTokens()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(tokens) > 0 {
+				log.Info("REST API authentication tokens are set")
+				r.SetAPIAuthTokens(tokens)
+			}
+			if cfg.RestAPI.RestAuthAllowReads {
+				log.Info("REST API allows unauthenticated read-only requests")
+				r.SetAPIAuthAllowReads(true)
+			}
 			if !cfg.RestAPI.HTTPS {
 				log.Warning("Using REST API authentication without HTTPS enabled.")
 			}
@@ -811,6 +839,7 @@ func applyCmdLineFlags(cfg *Config, ctx *cli.Context) {
 	cfg.Control.PluginLoadTimeout = setIntVal(cfg.Control.PluginLoadTimeout, ctx, "plugin-load-timeout")
 	cfg.Control.PluginTrust = setIntVal(cfg.Control.PluginTrust, ctx, "plugin-trust")
 	cfg.Control.AutoDiscoverPath = setStringVal(cfg.Control.AutoDiscoverPath, ctx, "auto-discover")
+	cfg.Control.AutoDiscoverWatch = setBoolVal(cfg.Control.AutoDiscoverWatch, ctx, "auto-discover-watch")
 	cfg.Control.KeyringPaths = setStringVal(cfg.Control.KeyringPaths, ctx, "keyring-paths")
 	cfg.Control.CacheExpiration = jsonutil.Duration{setDurationVal(cfg.Control.CacheExpiration.Duration, ctx, "cache-expiration")}
 	cfg.Control.ListenAddr = setStringVal(cfg.Control.ListenAddr, ctx, "control-listen-addr")
@@ -827,11 +856,16 @@ func applyCmdLineFlags(cfg *Config, ctx *cli.Context) {
 	cfg.RestAPI.RestAuth = setBoolVal(cfg.RestAPI.RestAuth, ctx, "rest-auth")
 	cfg.RestAPI.RestAuthPassword = setStringVal(cfg.RestAPI.RestAuthPassword, ctx, "rest-auth-pwd")
 	cfg.RestAPI.Pprof = setBoolVal(cfg.RestAPI.Pprof, ctx, "pprof")
+	cfg.RestAPI.PprofAddr = setStringVal(cfg.RestAPI.PprofAddr, ctx, "pprof-addr")
 	cfg.RestAPI.Corsd = setStringVal(cfg.RestAPI.Corsd, ctx, "allowed_origins")
+	cfg.RestAPI.CorsMethods = setStringVal(cfg.RestAPI.CorsMethods, ctx, "allowed_methods")
+	cfg.RestAPI.CorsHeaders = setStringVal(cfg.RestAPI.CorsHeaders, ctx, "allowed_headers")
 
 	// next for the scheduler related flags
 	cfg.Scheduler.WorkManagerQueueSize = setUIntVal(cfg.Scheduler.WorkManagerQueueSize, ctx, "work-manager-queue-size")
 	cfg.Scheduler.WorkManagerPoolSize = setUIntVal(cfg.Scheduler.WorkManagerPoolSize, ctx, "work-manager-pool-size")
+	cfg.Scheduler.WorkManagerBranchConcurrency = setUIntVal(cfg.Scheduler.WorkManagerBranchConcurrency, ctx, "work-manager-branch-concurrency")
+	cfg.Scheduler.AccountingPath = setStringVal(cfg.Scheduler.AccountingPath, ctx, "accounting-path")
 	// and finally for the tribe-related flags
 	cfg.Tribe.Name = setStringVal(cfg.Tribe.Name, ctx, "tribe-node-name")
 	cfg.Tribe.Enable = setBoolVal(cfg.Tribe.Enable, ctx, "tribe")
@@ -1011,7 +1045,7 @@ func printErrorAndExit(name string, err error) {
 }
 
 func startInterruptHandling(modules ...coreModule) {
-	c := make(chan os.Signal, 1)
+	c := signals
 	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGHUP)
 
 	//Let's block until someone tells us to quit
@@ -1057,6 +1091,49 @@ func startInterruptHandling(modules ...coreModule) {
 	}()
 }
 
+// addDaemonSettings makes the settings of snapteld which can be changed while
+// it runs changeable through PATCH /config, keeping cfg up to date.
+func addDaemonSettings(r *rest.Server, cfg *Config, c managesPluginTrust) {
+	r.AddDaemonSetting("log_level", func(v json.RawMessage) error {
+		var level int
+		if err := json.Unmarshal(v, &level); err != nil {
+			return err
+		}
+		if level < 1 || level > 5 {
+			return errors.New("log level was invalid (needs: 1-5)")
+		}
+		cfg.LogLevel = level
+		log.SetLevel(getLevel(level))
+		log.Info("setting log level to: ", l[level])
+		return nil
+	})
+	r.AddDaemonSetting("gomaxprocs", func(v json.RawMessage) error {
+		var maxProcs int
+		if err := json.Unmarshal(v, &maxProcs); err != nil {
+			return err
+		}
+		if maxProcs < 1 {
+			return errors.New("gomaxprocs was invalid (needs: 1 or more)")
+		}
+		setMaxProcs(maxProcs)
+		cfg.GoMaxProcs = runtime.GOMAXPROCS(0)
+		return nil
+	})
+	r.AddDaemonSetting("control.plugin_trust_level", func(v json.RawMessage) error {
+		var trust int
+		if err := json.Unmarshal(v, &trust); err != nil {
+			return err
+		}
+		if trust < 0 || trust > 2 {
+			return errors.New("plugin trust was invalid (needs: 0-2)")
+		}
+		cfg.Control.PluginTrust = trust
+		c.SetPluginTrustLevel(trust)
+		log.Info("setting plugin trust level to: ", t[trust])
+		return nil
+	})
+}
+
 func getLevel(i int) log.Level {
 	switch i {
 	case 1: